@@ -18,6 +18,7 @@ package handler
 
 import (
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -98,3 +99,34 @@ func (a *ApiHandler) GetEthV1BeaconBlobSidecars(w http.ResponseWriter, r *http.R
 
 	return beaconhttp.NewBeaconResponse(resp), nil
 }
+
+// GetEthV1BeaconBlobRetention returns the bounds of the blob sidecars retention of the node (non-standard)
+func (a *ApiHandler) GetEthV1BeaconBlobRetention(w http.ResponseWriter, r *http.Request) (*beaconhttp.BeaconResponse, error) {
+	bounds := a.blobStoage.RetentionBounds()
+	pruning := bounds.SlotsKept != math.MaxUint64
+	var retentionEpochs uint64
+	if pruning {
+		retentionEpochs = bounds.SlotsKept / a.beaconChainCfg.SlotsPerEpoch
+	}
+	earliestEpoch := bounds.EarliestSlot // math.MaxUint64 if no blob sidecars are stored
+	if earliestEpoch != math.MaxUint64 {
+		earliestEpoch /= a.beaconChainCfg.SlotsPerEpoch
+	}
+	return newBeaconResponse(struct {
+		Pruning          bool   `json:"pruning"`
+		RetentionEpochs  uint64 `json:"retention_epochs,string"`
+		MinEpochsForBlob uint64 `json:"min_epochs_for_blob_sidecars_requests,string"`
+		EarliestSlot     uint64 `json:"earliest_slot,string"`
+		EarliestEpoch    uint64 `json:"earliest_epoch,string"`
+		PrunedUntilSlot  uint64 `json:"pruned_until_slot,string"`
+		StoredBytes      uint64 `json:"stored_bytes,string"`
+	}{
+		Pruning:          pruning,
+		RetentionEpochs:  retentionEpochs,
+		MinEpochsForBlob: a.beaconChainCfg.MinEpochsForBlobSidecarsRequests,
+		EarliestSlot:     bounds.EarliestSlot,
+		EarliestEpoch:    earliestEpoch,
+		PrunedUntilSlot:  bounds.PrunedUntil,
+		StoredBytes:      bounds.StoredBytes,
+	}), nil
+}
@@ -49,6 +49,8 @@ type CaplinConfig struct {
 	ArchiveStates             bool
 	ImmediateBlobsBackfilling bool
 	BlobPruningDisabled       bool
+	// BlobRetentionEpochs is for how many epochs the blob sidecars are kept, the CL minimum if lower
+	BlobRetentionEpochs       uint64
 	SnapshotGenerationEnabled bool
 	// Network related config
 	NetworkId NetworkType
@@ -97,6 +99,11 @@ func (c CaplinConfig) RelayUrlExist() bool {
 	return c.MevRelayUrl != ""
 }
 
+// BlobRetentionSlots returns for how many slots the blob sidecars are kept when pruned, never less than the CL minimum
+func (c CaplinConfig) BlobRetentionSlots(beaconCfg *BeaconChainConfig) uint64 {
+	return max(c.BlobRetentionEpochs, beaconCfg.MinEpochsForBlobSidecarsRequests) * beaconCfg.SlotsPerEpoch
+}
+
 type NetworkType int
 
 const (
@@ -23,7 +23,9 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -31,6 +33,7 @@ import (
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/crypto/kzg"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon/cl/clparams"
 	"github.com/erigontech/erigon/cl/cltypes"
 	"github.com/erigontech/erigon/cl/cltypes/solid"
@@ -41,6 +44,8 @@ import (
 
 const (
 	subdivisionSlot = 10_000
+	// earliestSlotFile keeps the earliest slot of the stored blob sidecars, which is not known from the file names
+	earliestSlotFile = "earliest_slot"
 )
 
 type BlobStorage interface {
@@ -50,6 +55,15 @@ type BlobStorage interface {
 	WriteStream(w io.Writer, slot uint64, blockRoot libcommon.Hash, idx uint64) error // Used for P2P networking
 	KzgCommitmentsCount(ctx context.Context, blockRoot libcommon.Hash) (uint32, error)
 	Prune() error
+	RetentionBounds() RetentionBounds
+}
+
+// RetentionBounds describes which blob sidecars the store keeps
+type RetentionBounds struct {
+	SlotsKept    uint64 // math.MaxUint64 if the blob sidecars are never pruned
+	EarliestSlot uint64 // slot of the earliest stored blob sidecars, at least PrunedUntil, math.MaxUint64 if none are stored
+	PrunedUntil  uint64 // the blob sidecars before this slot are pruned
+	StoredBytes  uint64 // size of the stored blob sidecars, known once the first pruning accounted them in background
 }
 
 type BlobStore struct {
@@ -58,10 +72,27 @@ type BlobStore struct {
 	beaconChainConfig *clparams.BeaconChainConfig
 	ethClock          eth_clock.EthereumClock
 	slotsKept         uint64
+
+	prunedUntil atomic.Uint64
+	storedBytes atomic.Int64
+	accounting  atomic.Bool // the blob sidecars written before the start are being accounted
+	accounted   atomic.Bool // storedBytes includes the blob sidecars written before the start
+
+	earliestLock   sync.Mutex // serializes the updates of earliestSlotFile
+	earliestStored atomic.Uint64
+	earliestSaved  bool // earliestSlotFile existed at the start
 }
 
 func NewBlobStore(db kv.RwDB, fs afero.Fs, slotsKept uint64, beaconChainConfig *clparams.BeaconChainConfig, ethClock eth_clock.EthereumClock) BlobStorage {
-	return &BlobStore{fs: fs, db: db, slotsKept: slotsKept, beaconChainConfig: beaconChainConfig, ethClock: ethClock}
+	bs := &BlobStore{fs: fs, db: db, slotsKept: slotsKept, beaconChainConfig: beaconChainConfig, ethClock: ethClock}
+	bs.earliestStored.Store(math.MaxUint64)
+	if data, err := afero.ReadFile(fs, earliestSlotFile); err == nil {
+		if slot, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			bs.earliestStored.Store(slot)
+			bs.earliestSaved = true
+		}
+	}
+	return bs
 }
 
 func blobSidecarFilePath(slot, index uint64, blockRoot libcommon.Hash) (folderpath, filepath string) {
@@ -99,6 +130,12 @@ func (bs *BlobStore) WriteBlobSidecars(ctx context.Context, blockRoot libcommon.
 		if err := file.Sync(); err != nil {
 			return err
 		}
+		if info, err := file.Stat(); err == nil {
+			bs.storedBytes.Add(info.Size())
+		}
+		if err := bs.updateEarliest(blobSidecar.SignedBlockHeader.Header.Slot, false); err != nil {
+			return err
+		}
 	}
 	val := make([]byte, 4)
 	binary.LittleEndian.PutUint32(val, uint32(len(blobSidecars)))
@@ -152,27 +189,126 @@ func (bs *BlobStore) ReadBlobSidecars(ctx context.Context, slot uint64, blockRoo
 	return blobSidecars, true, nil
 }
 
-// Do a bit of pruning
+// Prune removes the blob sidecars older than slotsKept, a whole subdivision at a time. The first call starts
+// accounting the blob sidecars written before the start in background, pruning begins once it's done.
 func (bs *BlobStore) Prune() error {
+	if !bs.accounted.Load() {
+		if bs.accounting.CompareAndSwap(false, true) {
+			go bs.account()
+		}
+		return nil
+	}
+	defer bs.updateMetrics()
 	if bs.slotsKept == math.MaxUint64 {
 		return nil
 	}
 
 	currentSlot := bs.ethClock.GetCurrentSlot()
-	currentSlot -= bs.slotsKept
-	currentSlot = (currentSlot / subdivisionSlot) * subdivisionSlot
-	var startPrune uint64
-	minSlotsForBlobSidecarRequest := bs.beaconChainConfig.MinSlotsForBlobsSidecarsRequest()
-	if currentSlot >= minSlotsForBlobSidecarRequest {
-		startPrune = currentSlot - minSlotsForBlobSidecarRequest
+	if currentSlot < bs.slotsKept {
+		return nil
+	}
+	pruneUntil := ((currentSlot - bs.slotsKept) / subdivisionSlot) * subdivisionSlot
+	if pruneUntil <= bs.prunedUntil.Load() {
+		return nil
+	}
+	folders, err := afero.ReadDir(bs.fs, ".")
+	if err != nil {
+		return err
+	}
+	// delete all the folders that are older than slotsKept, whatever the retention was when they were written
+	for _, folder := range folders {
+		subdir, err := strconv.ParseUint(folder.Name(), 10, 64)
+		if err != nil || !folder.IsDir() || (subdir+1)*subdivisionSlot > pruneUntil {
+			continue
+		}
+		size, err := bs.dirSize(folder.Name())
+		if err != nil {
+			return err
+		}
+		if err := bs.fs.RemoveAll(folder.Name()); err != nil {
+			return err
+		}
+		bs.storedBytes.Add(-size)
+	}
+	bs.prunedUntil.Store(pruneUntil)
+	return bs.updateEarliest(pruneUntil, true)
+}
+
+// account adds the size of the blob sidecars written before the start to storedBytes, and sets the earliest stored
+// slot to the start of the earliest subdivision if it wasn't saved (by an older version). Blob sidecars written while
+// the files are walked may be counted twice.
+func (bs *BlobStore) account() {
+	defer bs.accounted.Store(true)
+	size, err := bs.dirSize(".")
+	if err != nil {
+		log.Warn("[Caplin] could not account the stored blob sidecars", "err", err)
+		return
 	}
-	// delete all the folders that are older than slotsKept
-	for i := startPrune; i < currentSlot; i += subdivisionSlot {
-		bs.fs.RemoveAll(strconv.FormatUint(i/subdivisionSlot, 10))
+	bs.storedBytes.Add(size)
+	if bs.earliestSaved {
+		return
 	}
+
+	folders, err := afero.ReadDir(bs.fs, ".")
+	if err != nil {
+		log.Warn("[Caplin] could not account the stored blob sidecars", "err", err)
+		return
+	}
+	for _, folder := range folders {
+		if subdir, err := strconv.ParseUint(folder.Name(), 10, 64); err == nil && folder.IsDir() {
+			if err := bs.updateEarliest(subdir*subdivisionSlot, false); err != nil {
+				log.Warn("[Caplin] could not save the earliest stored blob sidecars slot", "err", err)
+			}
+		}
+	}
+}
+
+// updateEarliest lowers the earliest stored slot to `slot`, or raises it if the blob sidecars before `slot` are pruned
+func (bs *BlobStore) updateEarliest(slot uint64, pruned bool) error {
+	bs.earliestLock.Lock()
+	defer bs.earliestLock.Unlock()
+	earliest := bs.earliestStored.Load()
+	if (pruned && (earliest >= slot || earliest == math.MaxUint64)) || (!pruned && earliest <= slot) {
+		return nil
+	}
+	if err := afero.WriteFile(bs.fs, earliestSlotFile, []byte(strconv.FormatUint(slot, 10)), 0644); err != nil {
+		return err
+	}
+	bs.earliestStored.Store(slot)
 	return nil
 }
 
+func (bs *BlobStore) dirSize(path string) (size int64, err error) {
+	err = afero.Walk(bs.fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func (bs *BlobStore) updateMetrics() {
+	bounds := bs.RetentionBounds()
+	blobStoreSize.SetUint64(bounds.StoredBytes)
+	blobStorePrunedUntil.SetUint64(bounds.PrunedUntil)
+}
+
+func (bs *BlobStore) RetentionBounds() RetentionBounds {
+	bounds := RetentionBounds{
+		SlotsKept:    bs.slotsKept,
+		EarliestSlot: bs.earliestStored.Load(),
+		PrunedUntil:  bs.prunedUntil.Load(),
+	}
+	if storedBytes := bs.storedBytes.Load(); storedBytes > 0 && bs.accounted.Load() {
+		bounds.StoredBytes = uint64(storedBytes)
+	}
+	return bounds
+}
+
 func (bs *BlobStore) WriteStream(w io.Writer, slot uint64, blockRoot libcommon.Hash, idx uint64) error {
 	_, filePath := blobSidecarFilePath(slot, idx, blockRoot)
 	file, err := bs.fs.Open(filePath)
@@ -216,9 +352,14 @@ func (bs *BlobStore) RemoveBlobSidecars(ctx context.Context, slot uint64, blockR
 	kzgCommitmentsLength := binary.LittleEndian.Uint32(val)
 	for i := uint32(0); i < kzgCommitmentsLength; i++ {
 		_, filePath := blobSidecarFilePath(slot, uint64(i), blockRoot)
+		info, err := bs.fs.Stat(filePath)
+		if err != nil {
+			return err
+		}
 		if err := bs.fs.Remove(filePath); err != nil {
 			return err
 		}
+		bs.storedBytes.Add(-info.Size())
 		tx.Delete(kv.BlockRootToKzgCommitments, blockRoot[:])
 	}
 	return tx.Commit()
@@ -233,7 +374,7 @@ type verifyHeaderSignatureFn func(header *cltypes.SignedBeaconBlockHeader) error
 
 // VerifyAgainstIdentifiersAndInsertIntoTheBlobStore does all due verification for blobs before database insertion. it also returns the latest correctly return blob.
 func VerifyAgainstIdentifiersAndInsertIntoTheBlobStore(ctx context.Context, storage BlobStorage, identifiers *solid.ListSSZ[*cltypes.BlobIdentifier], sidecars []*cltypes.BlobSidecar, verifySignatureFn verifyHeaderSignatureFn) (uint64, uint64, error) {
-	kzgCtx := kzg.Ctx()
+	kzgCtx := kzg.Verifier()
 	inserted := atomic.Uint64{}
 	if identifiers.Len() == 0 || len(sidecars) == 0 {
 		return 0, 0, nil
@@ -18,7 +18,9 @@ package blob_storage
 
 import (
 	"context"
+	"math"
 	"testing"
+	"time"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/kv"
@@ -26,8 +28,10 @@ import (
 	"github.com/erigontech/erigon/cl/clparams"
 	"github.com/erigontech/erigon/cl/cltypes"
 	"github.com/erigontech/erigon/cl/cltypes/solid"
+	"github.com/erigontech/erigon/cl/utils/eth_clock"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func setupTestDB(t *testing.T) kv.RwDB {
@@ -66,3 +70,62 @@ func TestBlobDB(t *testing.T) {
 	require.Equal(t, s1.SignedBlockHeader, sidecars[0].SignedBlockHeader)
 	require.Equal(t, s2.SignedBlockHeader, sidecars[1].SignedBlockHeader)
 }
+
+func TestBlobDBPrune(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ethClock := eth_clock.NewMockEthereumClock(gomock.NewController(t))
+	ethClock.EXPECT().GetCurrentSlot().Return(uint64(35_000)).AnyTimes()
+	beaconConfig := clparams.MainnetBeaconConfig
+	beaconConfig.DenebForkEpoch = 0
+
+	bs := NewBlobStore(db, afero.NewMemMapFs(), 15_000, &beaconConfig, ethClock)
+	blockRoot := libcommon.Hash{1}
+	for _, slot := range []uint64{1, 15_000, 25_000} {
+		sidecar := cltypes.NewBlobSidecar(0, &cltypes.Blob{1}, libcommon.Bytes48{2}, libcommon.Bytes48{3}, &cltypes.SignedBeaconBlockHeader{Header: &cltypes.BeaconBlockHeader{Slot: slot}}, solid.NewHashVector(cltypes.CommitmentBranchSize))
+		require.NoError(t, bs.WriteBlobSidecars(context.Background(), blockRoot, []*cltypes.BlobSidecar{sidecar}))
+	}
+	require.Equal(t, uint64(1), bs.RetentionBounds().EarliestSlot)
+	require.Zero(t, bs.RetentionBounds().StoredBytes) // not accounted until the first pruning
+
+	// the first pruning accounts the stored blob sidecars in the background
+	require.NoError(t, bs.Prune())
+	require.Eventually(t, bs.(*BlobStore).accounted.Load, time.Second, 10*time.Millisecond)
+	require.NoError(t, bs.Prune())
+	bounds := bs.RetentionBounds()
+	require.Equal(t, uint64(15_000), bounds.SlotsKept)
+	require.Equal(t, uint64(20_000), bounds.EarliestSlot)
+	require.Equal(t, uint64(20_000), bounds.PrunedUntil)
+	require.NotZero(t, bounds.StoredBytes)
+
+	// whole subdivisions before the retention window are gone
+	_, found, err := bs.ReadBlobSidecars(context.Background(), 15_000, blockRoot)
+	require.NoError(t, err)
+	require.False(t, found)
+	_, found, err = bs.ReadBlobSidecars(context.Background(), 25_000, blockRoot)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	// the earliest stored slot survives restarts
+	reopened := NewBlobStore(db, bs.(*BlobStore).fs, 15_000, &beaconConfig, ethClock)
+	require.Equal(t, uint64(20_000), reopened.RetentionBounds().EarliestSlot)
+}
+
+func TestBlobDBEarliestSlot(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ethClock := eth_clock.NewMockEthereumClock(gomock.NewController(t))
+	beaconConfig := clparams.MainnetBeaconConfig
+
+	bs := NewBlobStore(db, afero.NewMemMapFs(), math.MaxUint64, &beaconConfig, ethClock)
+	require.Equal(t, uint64(math.MaxUint64), bs.RetentionBounds().EarliestSlot)
+
+	blockRoot := libcommon.Hash{1}
+	for _, slot := range []uint64{25_000, 26_000} {
+		sidecar := cltypes.NewBlobSidecar(0, &cltypes.Blob{1}, libcommon.Bytes48{2}, libcommon.Bytes48{3}, &cltypes.SignedBeaconBlockHeader{Header: &cltypes.BeaconBlockHeader{Slot: slot}}, solid.NewHashVector(cltypes.CommitmentBranchSize))
+		require.NoError(t, bs.WriteBlobSidecars(context.Background(), blockRoot, []*cltypes.BlobSidecar{sidecar}))
+	}
+	require.Equal(t, uint64(25_000), bs.RetentionBounds().EarliestSlot)
+}
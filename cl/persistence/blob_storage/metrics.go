@@ -0,0 +1,26 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package blob_storage
+
+import "github.com/erigontech/erigon-lib/metrics"
+
+var (
+	// blobStoreSize is the size of the blob sidecars on disk
+	blobStoreSize = metrics.GetOrCreateGauge("blob_store_size_bytes")
+	// blobStorePrunedUntil is the slot the blob sidecars are pruned until
+	blobStorePrunedUntil = metrics.GetOrCreateGauge("blob_store_pruned_until_slot")
+)
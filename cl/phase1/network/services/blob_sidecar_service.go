@@ -135,7 +135,7 @@ func (b *blobSidecarService) ProcessMessage(ctx context.Context, subnetId *uint6
 }
 
 func (b *blobSidecarService) verifyAndStoreBlobSidecar(msg *cltypes.BlobSidecar) error {
-	kzgCtx := kzg.Ctx()
+	kzgCtx := kzg.Verifier()
 
 	if !b.test && !cltypes.VerifyCommitmentInclusionProof(msg.KzgCommitment, msg.CommitmentInclusionProof, msg.Index,
 		clparams.DenebVersion, msg.SignedBlockHeader.Header.BodyRoot) {
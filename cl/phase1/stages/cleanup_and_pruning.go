@@ -22,8 +22,5 @@ func cleanupAndPruning(ctx context.Context, logger log.Logger, cfg *Cfg, args Ar
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	return cfg.blobStore.Prune()
+	return tx.Commit()
 }
@@ -125,6 +125,7 @@ const (
 	ChainTipSync             StageName = "ChainTipSync"
 	ForkChoice               StageName = "ForkChoice"
 	CleanupAndPruning        StageName = "CleanupAndPruning"
+	PruneBlobs               StageName = "PruneBlobs"
 	SleepForSlot             StageName = "SleepForSlot"
 	DownloadHistoricalBlocks StageName = "DownloadHistoricalBlocks"
 )
@@ -182,7 +183,7 @@ digraph {
 
     subgraph cluster_1 {
         label="head";
-        ChainTipSync; ForkChoice; CleanupAndPruning; SleepForSlot;
+        ChainTipSync; ForkChoice; CleanupAndPruning; PruneBlobs; SleepForSlot;
     }
 
 
@@ -194,7 +195,8 @@ digraph {
     NotInSync -> ForwardSync
     SleepForSlot -> ChainTipSync
 
-    CleanupAndPruning -> SleepForSlot
+    CleanupAndPruning -> PruneBlobs
+    PruneBlobs -> SleepForSlot
 }
 
 */
@@ -294,10 +296,20 @@ func ConsensusClStages(ctx context.Context,
 					if x := MetaCatchingUp(args); x != "" {
 						return x
 					}
-					return SleepForSlot
+					return PruneBlobs
 				},
 				ActionFunc: cleanupAndPruning,
 			},
+			PruneBlobs: {
+				Description: `prune the blob sidecars out of the retention window`,
+				TransitionFunc: func(cfg *Cfg, args Args, err error) string {
+					if x := MetaCatchingUp(args); x != "" {
+						return x
+					}
+					return SleepForSlot
+				},
+				ActionFunc: pruneBlobs,
+			},
 			SleepForSlot: {
 				Description: `sleep until the next slot`,
 				TransitionFunc: func(cfg *Cfg, args Args, err error) string {
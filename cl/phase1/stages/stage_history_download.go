@@ -127,7 +127,7 @@ func SpawnStageHistoryDownload(cfg StageHistoryReconstructionCfg, ctx context.Co
 		hasDownloadEnoughForImmediateBlobsBackfilling := true
 		if cfg.caplinConfig.ImmediateBlobsBackfilling {
 			// download twice the number of blocks needed for good measure
-			blocksToDownload := cfg.beaconCfg.MinSlotsForBlobsSidecarsRequest() * 2
+			blocksToDownload := cfg.caplinConfig.BlobRetentionSlots(cfg.beaconCfg) * 2
 			hasDownloadEnoughForImmediateBlobsBackfilling = cfg.startingSlot < blocksToDownload || slot > cfg.startingSlot-blocksToDownload
 		}
 		if cfg.engine != nil && cfg.engine.SupportInsertion() && blk.Version() >= clparams.BellatrixVersion {
@@ -301,7 +301,7 @@ func downloadBlobHistoryWorker(cfg StageHistoryReconstructionCfg, ctx context.Co
 	targetSlot := cfg.beaconCfg.DenebForkEpoch * cfg.beaconCfg.SlotsPerEpoch
 	// in case of immediate blobs backfilling we need to backfill the blobs for the last relevant epochs
 	if !cfg.caplinConfig.ArchiveBlobs && cfg.caplinConfig.ImmediateBlobsBackfilling {
-		targetSlot = currentSlot - min(currentSlot, cfg.beaconCfg.MinSlotsForBlobsSidecarsRequest())
+		targetSlot = currentSlot - min(currentSlot, cfg.caplinConfig.BlobRetentionSlots(cfg.beaconCfg))
 	}
 	logger.Info("[Blobs-Downloader] Downloading blobs backwards", "slot", currentSlot)
 
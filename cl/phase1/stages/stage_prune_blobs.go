@@ -0,0 +1,36 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// pruneBlobs removes the blob sidecars older than the configured retention, independently of the blocks pruning.
+func pruneBlobs(ctx context.Context, logger log.Logger, cfg *Cfg, args Args) error {
+	prevPrunedUntil := cfg.blobStore.RetentionBounds().PrunedUntil
+	if err := cfg.blobStore.Prune(); err != nil {
+		return fmt.Errorf("pruning blob sidecars: %w", err)
+	}
+	if bounds := cfg.blobStore.RetentionBounds(); bounds.PrunedUntil > prevPrunedUntil {
+		logger.Debug("[Caplin] Pruned blob sidecars", "until", bounds.PrunedUntil, "earliest", bounds.EarliestSlot, "stored", bounds.StoredBytes)
+	}
+	return nil
+}
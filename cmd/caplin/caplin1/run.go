@@ -206,7 +206,7 @@ func RunCaplinService(ctx context.Context, engine execution_client.ExecutionEngi
 	}
 	ethClock := eth_clock.NewEthereumClock(state.GenesisTime(), state.GenesisValidatorsRoot(), beaconConfig)
 
-	pruneBlobDistance := uint64(128600)
+	pruneBlobDistance := config.BlobRetentionSlots(beaconConfig)
 	if config.ArchiveBlobs || config.BlobPruningDisabled {
 		pruneBlobDistance = math.MaxUint64
 	}
@@ -35,7 +35,6 @@ import (
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/tracing"
 	"github.com/erigontech/erigon/core/types"
-	"github.com/erigontech/erigon/turbo/rpchelper"
 )
 
 type Prestate struct {
@@ -50,41 +49,48 @@ type ommer struct {
 
 //go:generate gencodec -type stEnv -field-override stEnvMarshaling -out gen_stenv.go
 type stEnv struct {
-	Coinbase         libcommon.Address                      `json:"currentCoinbase"   gencodec:"required"`
-	Difficulty       *big.Int                               `json:"currentDifficulty"`
-	Random           *big.Int                               `json:"currentRandom"`
-	MixDigest        libcommon.Hash                         `json:"mixHash,omitempty"`
-	ParentDifficulty *big.Int                               `json:"parentDifficulty"`
-	GasLimit         uint64                                 `json:"currentGasLimit"   gencodec:"required"`
-	Number           uint64                                 `json:"currentNumber"     gencodec:"required"`
-	Timestamp        uint64                                 `json:"currentTimestamp"  gencodec:"required"`
-	ParentTimestamp  uint64                                 `json:"parentTimestamp,omitempty"`
-	BlockHashes      map[math.HexOrDecimal64]libcommon.Hash `json:"blockHashes,omitempty"`
-	Ommers           []ommer                                `json:"ommers,omitempty"`
-	BaseFee          *big.Int                               `json:"currentBaseFee,omitempty"`
-	ParentUncleHash  libcommon.Hash                         `json:"parentUncleHash"`
-	UncleHash        libcommon.Hash                         `json:"uncleHash,omitempty"`
-	Withdrawals      []*types.Withdrawal                    `json:"withdrawals,omitempty"`
-	WithdrawalsHash  *libcommon.Hash                        `json:"withdrawalsRoot,omitempty"`
-	RequestsHash     *libcommon.Hash                        `json:"requestsHash,omitempty"`
+	Coinbase              libcommon.Address                      `json:"currentCoinbase"   gencodec:"required"`
+	Difficulty            *big.Int                               `json:"currentDifficulty"`
+	Random                *big.Int                               `json:"currentRandom"`
+	MixDigest             libcommon.Hash                         `json:"mixHash,omitempty"`
+	ParentDifficulty      *big.Int                               `json:"parentDifficulty"`
+	GasLimit              uint64                                 `json:"currentGasLimit"   gencodec:"required"`
+	Number                uint64                                 `json:"currentNumber"     gencodec:"required"`
+	Timestamp             uint64                                 `json:"currentTimestamp"  gencodec:"required"`
+	ParentTimestamp       uint64                                 `json:"parentTimestamp,omitempty"`
+	BlockHashes           map[math.HexOrDecimal64]libcommon.Hash `json:"blockHashes,omitempty"`
+	Ommers                []ommer                                `json:"ommers,omitempty"`
+	BaseFee               *big.Int                               `json:"currentBaseFee,omitempty"`
+	ParentUncleHash       libcommon.Hash                         `json:"parentUncleHash"`
+	UncleHash             libcommon.Hash                         `json:"uncleHash,omitempty"`
+	Withdrawals           []*types.Withdrawal                    `json:"withdrawals,omitempty"`
+	WithdrawalsHash       *libcommon.Hash                        `json:"withdrawalsRoot,omitempty"`
+	RequestsHash          *libcommon.Hash                        `json:"requestsHash,omitempty"`
+	ParentBeaconBlockRoot *libcommon.Hash                        `json:"parentBeaconBlockRoot,omitempty"`
+	ExcessBlobGas         *uint64                                `json:"currentExcessBlobGas,omitempty"`
+	ParentExcessBlobGas   *uint64                                `json:"parentExcessBlobGas,omitempty"`
+	ParentBlobGasUsed     *uint64                                `json:"parentBlobGasUsed,omitempty"`
 }
 
 type stEnvMarshaling struct {
-	Coinbase         libcommon.UnprefixedAddress
-	Difficulty       *math.HexOrDecimal256
-	Random           *math.HexOrDecimal256
-	ParentDifficulty *math.HexOrDecimal256
-	GasLimit         math.HexOrDecimal64
-	Number           math.HexOrDecimal64
-	Timestamp        math.HexOrDecimal64
-	ParentTimestamp  math.HexOrDecimal64
-	BaseFee          *math.HexOrDecimal256
+	Coinbase            libcommon.UnprefixedAddress
+	Difficulty          *math.HexOrDecimal256
+	Random              *math.HexOrDecimal256
+	ParentDifficulty    *math.HexOrDecimal256
+	GasLimit            math.HexOrDecimal64
+	Number              math.HexOrDecimal64
+	Timestamp           math.HexOrDecimal64
+	ParentTimestamp     math.HexOrDecimal64
+	BaseFee             *math.HexOrDecimal256
+	ExcessBlobGas       *math.HexOrDecimal64
+	ParentExcessBlobGas *math.HexOrDecimal64
+	ParentBlobGasUsed   *math.HexOrDecimal64
 }
 
 func MakePreState(chainRules *chain.Rules, tx kv.RwTx, sd *state3.SharedDomains, accounts types.GenesisAlloc) (state.StateReader, state.WriterWithChangeSets) {
 	var blockNr uint64 = 0
 
-	stateReader, stateWriter := rpchelper.NewLatestStateReader(tx), state.NewWriterV4(sd)
+	stateReader, stateWriter := state.NewReaderV3(sd), state.NewWriterV4(sd)
 	sd.SetBlockNum(blockNr)
 
 	statedb := state.New(stateReader) //ibs
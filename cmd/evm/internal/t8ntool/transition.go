@@ -48,6 +48,7 @@ import (
 	libstate "github.com/erigontech/erigon-lib/state"
 	"github.com/erigontech/erigon/consensus/ethash"
 	"github.com/erigontech/erigon/consensus/merge"
+	"github.com/erigontech/erigon/consensus/misc"
 	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
@@ -244,6 +245,21 @@ func Main(ctx *cli.Context) error {
 		return NewError(ErrorVMConfig, errors.New("shanghai config but missing 'withdrawals' in env section"))
 	}
 
+	if chainConfig.IsCancun(prestate.Env.Timestamp) {
+		if prestate.Env.ParentBeaconBlockRoot == nil {
+			return NewError(ErrorVMConfig, errors.New("post-cancun env requires parentBeaconBlockRoot to be set"))
+		}
+		// If excess blob gas was not provided by caller, calculate it from the parent's values
+		if prestate.Env.ExcessBlobGas == nil {
+			parent := &types.Header{ExcessBlobGas: prestate.Env.ParentExcessBlobGas, BlobGasUsed: prestate.Env.ParentBlobGasUsed}
+			excessBlobGas := misc.CalcExcessBlobGas(chainConfig, parent, prestate.Env.Timestamp)
+			prestate.Env.ExcessBlobGas = &excessBlobGas
+		}
+	} else {
+		prestate.Env.ParentBeaconBlockRoot = nil
+		prestate.Env.ExcessBlobGas = nil
+	}
+
 	isMerged := chainConfig.TerminalTotalDifficulty != nil && chainConfig.TerminalTotalDifficulty.BitLen() == 0
 	env := prestate.Env
 	if isMerged {
@@ -297,7 +313,13 @@ func Main(ctx *cli.Context) error {
 		return h
 	}
 
-	db, agg := temporaltest.NewTestDB(nil, datadir.New(""))
+	tmpDir, err := os.MkdirTemp("", "t8n-")
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed creating datadir: %v", err))
+	}
+	defer os.RemoveAll(tmpDir)
+
+	db, agg := temporaltest.NewTestDB(nil, datadir.New(tmpDir))
 	defer db.Close()
 	defer agg.Close()
 
@@ -412,35 +434,31 @@ func getTransaction(txJson ethapi.RPCTransaction) (types.Transaction, error) {
 		}
 	}
 
-	commonTx := types.CommonTx{
-		Nonce:    uint64(txJson.Nonce),
-		To:       txJson.To,
-		Value:    value,
-		GasLimit: uint64(txJson.Gas),
-		Data:     txJson.Input,
+	// CommonTx is filled in place: it holds atomic caches and must not be copied
+	setCommon := func(commonTx *types.CommonTx) {
+		commonTx.Nonce = uint64(txJson.Nonce)
+		commonTx.To = txJson.To
+		commonTx.Value = value
+		commonTx.GasLimit = uint64(txJson.Gas)
+		commonTx.Data = txJson.Input
+		commonTx.V.SetFromBig(txJson.V.ToInt())
+		commonTx.R.SetFromBig(txJson.R.ToInt())
+		commonTx.S.SetFromBig(txJson.S.ToInt())
 	}
 
-	commonTx.V.SetFromBig(txJson.V.ToInt())
-	commonTx.R.SetFromBig(txJson.R.ToInt())
-	commonTx.S.SetFromBig(txJson.S.ToInt())
-	if txJson.Type == types.LegacyTxType || txJson.Type == types.AccessListTxType {
-		legacyTx := types.LegacyTx{
-			//it's ok to copy here - because it's constructor of object - no parallel access yet
-			CommonTx: commonTx, //nolint
-			GasPrice: gasPrice,
-		}
-
-		if txJson.Type == types.LegacyTxType {
-			return &legacyTx, nil
-		}
-
-		return &types.AccessListTx{
-			//it's ok to copy here - because it's constructor of object - no parallel access yet
-			LegacyTx:   legacyTx, //nolint
+	if txJson.Type == types.LegacyTxType {
+		legacyTx := &types.LegacyTx{GasPrice: gasPrice}
+		setCommon(&legacyTx.CommonTx)
+		return legacyTx, nil
+	} else if txJson.Type == types.AccessListTxType {
+		accessListTx := &types.AccessListTx{
 			ChainID:    chainId,
 			AccessList: *txJson.Accesses,
-		}, nil
-	} else if txJson.Type == types.DynamicFeeTxType || txJson.Type == types.SetCodeTxType {
+		}
+		accessListTx.GasPrice = gasPrice
+		setCommon(&accessListTx.CommonTx)
+		return accessListTx, nil
+	} else if txJson.Type == types.DynamicFeeTxType || txJson.Type == types.BlobTxType || txJson.Type == types.SetCodeTxType {
 		var tipCap *uint256.Int
 		var feeCap *uint256.Int
 		if txJson.MaxPriorityFeePerGas != nil {
@@ -457,17 +475,33 @@ func getTransaction(txJson ethapi.RPCTransaction) (types.Transaction, error) {
 			}
 		}
 
-		dynamicFeeTx := types.DynamicFeeTransaction{
-			//it's ok to copy here - because it's constructor of object - no parallel access yet
-			CommonTx:   commonTx, //nolint
-			ChainID:    chainId,
-			TipCap:     tipCap,
-			FeeCap:     feeCap,
-			AccessList: *txJson.Accesses,
+		setDynamicFee := func(dynamicFeeTx *types.DynamicFeeTransaction) {
+			setCommon(&dynamicFeeTx.CommonTx)
+			dynamicFeeTx.ChainID = chainId
+			dynamicFeeTx.TipCap = tipCap
+			dynamicFeeTx.FeeCap = feeCap
+			dynamicFeeTx.AccessList = *txJson.Accesses
 		}
 
-		if txJson.Type == types.DynamicFeeTxType {
-			return &dynamicFeeTx, nil
+		switch txJson.Type {
+		case types.DynamicFeeTxType:
+			dynamicFeeTx := &types.DynamicFeeTransaction{}
+			setDynamicFee(dynamicFeeTx)
+			return dynamicFeeTx, nil
+		case types.BlobTxType:
+			if txJson.MaxFeePerBlobGas == nil {
+				return nil, errors.New("maxFeePerBlobGas is required for blob transaction")
+			}
+			blobFeeCap, overflow := uint256.FromBig(txJson.MaxFeePerBlobGas.ToInt())
+			if overflow {
+				return nil, errors.New("maxFeePerBlobGas field caused an overflow (uint256)")
+			}
+			blobTx := &types.BlobTx{
+				MaxFeePerBlobGas:    blobFeeCap,
+				BlobVersionedHashes: txJson.BlobVersionedHashes,
+			}
+			setDynamicFee(&blobTx.DynamicFeeTransaction)
+			return blobTx, nil
 		}
 
 		auths := make([]types.Authorization, 0)
@@ -479,11 +513,9 @@ func getTransaction(txJson ethapi.RPCTransaction) (types.Transaction, error) {
 			auths = append(auths, a)
 		}
 
-		return &types.SetCodeTransaction{
-			// it's ok to copy here - because it's constructor of object - no parallel access yet
-			DynamicFeeTransaction: dynamicFeeTx, //nolint
-			Authorizations:        auths,
-		}, nil
+		setCodeTx := &types.SetCodeTransaction{Authorizations: auths}
+		setDynamicFee(&setCodeTx.DynamicFeeTransaction)
+		return setCodeTx, nil
 	} else {
 		return nil, nil
 	}
@@ -608,15 +640,27 @@ func NewHeader(env stEnv) *types.Header {
 	var header types.Header
 	header.Coinbase = env.Coinbase
 	header.Difficulty = env.Difficulty
+	if header.Difficulty == nil {
+		header.Difficulty = new(big.Int) // post-merge
+	}
 	header.GasLimit = env.GasLimit
 	header.Number = new(big.Int).SetUint64(env.Number)
 	header.Time = env.Timestamp
 	header.BaseFee = env.BaseFee
 	header.MixDigest = env.MixDigest
+	if env.Random != nil {
+		header.MixDigest = libcommon.BigToHash(env.Random)
+	}
+	if env.Number > 0 {
+		// EIP-2935 stores parent hash in the history contract
+		header.ParentHash = env.BlockHashes[math.HexOrDecimal64(env.Number-1)]
+	}
 
 	header.UncleHash = env.UncleHash
 	header.WithdrawalsHash = env.WithdrawalsHash
 	header.RequestsHash = env.RequestsHash
+	header.ParentBeaconBlockRoot = env.ParentBeaconBlockRoot
+	header.ExcessBlobGas = env.ExcessBlobGas
 
 	return &header
 }
@@ -253,6 +253,70 @@ func TestT8n(t *testing.T) {
 	}
 }
 
+// TestT8nBlobFields checks only the Cancun/Prague header fields of the result,
+// the rest of the output is covered by TestT8n.
+func TestT8nBlobFields(t *testing.T) {
+	tt := new(testT8n)
+	tt.TestCmd = cmdtest.NewTestCmd(t, tt)
+	for i, tc := range []struct {
+		base        string
+		input       t8nInput
+		expExitCode int
+		expOut      string
+	}{
+		{ // eip-4844
+			base: "./testdata/27",
+			input: t8nInput{
+				"alloc.json", "txs.json", "env.json", "Cancun",
+			},
+			expOut: "exp.json",
+		},
+		{ // blob tx without maxFeePerBlobGas
+			base: "./testdata/27",
+			input: t8nInput{
+				"alloc.json", "txs_nofee.json", "env.json", "Cancun",
+			},
+			expExitCode: 10,
+		},
+		{ // eip-7685
+			base: "./testdata/28",
+			input: t8nInput{
+				"alloc.json", "txs.json", "env.json", "Prague",
+			},
+			expOut: "exp.json",
+		},
+	} {
+		args := []string{"t8n"}
+		args = append(args, (&t8nOutput{result: true}).get()...)
+		args = append(args, tc.input.get(tc.base)...)
+		tt.Run("evm-test", args...)
+		if tc.expOut != "" {
+			wantRaw, err := os.ReadFile(fmt.Sprintf("%v/%v", tc.base, tc.expOut))
+			if err != nil {
+				t.Fatalf("test %d: could not read expected output: %v", i, err)
+			}
+			var have, want struct {
+				Result map[string]interface{} `json:"result"`
+			}
+			if err := json.Unmarshal(tt.Output(), &have); err != nil {
+				t.Fatalf("test %d, json parsing failed: %v", i, err)
+			}
+			if err := json.Unmarshal(wantRaw, &want); err != nil {
+				t.Fatalf("test %d, json parsing failed: %v", i, err)
+			}
+			for field, w := range want.Result {
+				if h := have.Result[field]; !reflect.DeepEqual(h, w) {
+					t.Fatalf("test %d: wrong %s, have %v, want %v", i, field, h, w)
+				}
+			}
+		}
+		tt.WaitExit()
+		if have, want := tt.ExitStatus(), tc.expExitCode; have != want {
+			t.Fatalf("test %d: wrong exit code, have %d, want %d", i, have, want)
+		}
+	}
+}
+
 // cmpJson compares the JSON in two byte slices.
 func cmpJson(a, b []byte) (bool, error) {
 	var j, j2 interface{}
@@ -0,0 +1,123 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	libstate "github.com/erigontech/erigon-lib/state"
+
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/services"
+)
+
+// dryRunSnapshot is the part of the db reported by --dry-run, taken before the stage runs
+type dryRunSnapshot struct {
+	stage    stages.SyncStage
+	progress uint64
+	tables   map[string]uint64 // amount of entries by table
+}
+
+// dryRunAggregator turns off building and merging of the state files with --dry-run: the exec stage builds them
+// in the background right in the snapshots dir, the rollback of the tx would leave them there
+func dryRunAggregator(agg *libstate.Aggregator) {
+	if dryRun {
+		agg.SetProduceMod(false)
+	}
+}
+
+// beginDryRun returns nil if --dry-run is not set
+func beginDryRun(tx kv.Tx, stage stages.SyncStage) (*dryRunSnapshot, error) {
+	if !dryRun {
+		return nil, nil
+	}
+	tables, err := countTables(tx)
+	if err != nil {
+		return nil, err
+	}
+	p, err := stages.GetStageProgress(tx, stage)
+	if err != nil {
+		return nil, err
+	}
+	return &dryRunSnapshot{stage: stage, progress: p, tables: tables}, nil
+}
+
+func countTables(tx kv.Tx) (map[string]uint64, error) {
+	names, err := tx.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+	tables := make(map[string]uint64, len(names))
+	for _, name := range names {
+		if tables[name], err = tx.Count(name); err != nil {
+			return nil, fmt.Errorf("count %s: %w", name, err)
+		}
+	}
+	return tables, nil
+}
+
+// reportDryRun logs what the stage changed in tx since the snapshot: its progress, the changed tables and,
+// for the execution stage, the state root against the one of the header. The caller rolls tx back.
+func reportDryRun(ctx context.Context, tx kv.RwTx, br services.HeaderReader, before *dryRunSnapshot, logger log.Logger) error {
+	after, err := beginDryRun(tx, before.stage)
+	if err != nil {
+		return err
+	}
+	logger.Info("[dry-run] stage progress", "stage", before.stage, "before", before.progress, "after", after.progress)
+
+	names := make([]string, 0, len(after.tables))
+	for name := range after.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if was, now := before.tables[name], after.tables[name]; was != now {
+			logger.Info("[dry-run] table changed", "table", name, "before", was, "after", now, "diff", int64(now)-int64(was))
+		}
+	}
+
+	if before.stage == stages.Execution && after.progress != before.progress {
+		doms, err := libstate.NewSharedDomains(tx, logger)
+		if err != nil {
+			return err
+		}
+		defer doms.Close()
+		root, err := doms.ComputeCommitment(ctx, false, doms.BlockNum(), "dry-run")
+		if err != nil {
+			return err
+		}
+		header, err := br.HeaderByNumber(ctx, tx, after.progress)
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return fmt.Errorf("header %d not found", after.progress)
+		}
+		if computed := libcommon.BytesToHash(root); computed != header.Root {
+			logger.Warn("[dry-run] state root mismatch", "block", after.progress, "computed", computed, "header", header.Root)
+		} else {
+			logger.Info("[dry-run] state root matches", "block", after.progress, "root", computed)
+		}
+	}
+	logger.Info("[dry-run] nothing committed")
+	return nil
+}
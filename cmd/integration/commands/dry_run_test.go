@@ -0,0 +1,115 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/prune"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/stagedsync"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+// dbDigest hashes every entry of every table
+func dbDigest(t *testing.T, db kv.RoDB) [32]byte {
+	h := sha256.New()
+	require.NoError(t, db.View(context.Background(), func(tx kv.Tx) error {
+		names, err := tx.ListBuckets()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			h.Write([]byte(name))
+			if err := tx.ForEach(name, nil, func(k, v []byte) error {
+				h.Write(k)
+				h.Write(v)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	return [32]byte(h.Sum(nil))
+}
+
+// dirFiles lists the files of the dir with their sizes
+func dirFiles(t *testing.T, dir string) map[string]int64 {
+	files := map[string]int64{}
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files[path] = info.Size()
+		return nil
+	}))
+	return files
+}
+
+func TestDryRunLeavesDbAndFiles(t *testing.T) {
+	defer func(was bool) { dryRun = was }(dryRun)
+	dryRun = true
+
+	m := mock.Mock(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, block *core.BlockGen) {
+		txn, err := types.SignTx(types.NewTransaction(block.TxNonce(m.Address), libcommon.Address{1}, uint256.NewInt(1), 21_000, uint256.NewInt(1), nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), m.Key)
+		require.NoError(t, err)
+		block.AddTx(txn)
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+	agg := m.HistoryV3Components()
+	dryRunAggregator(agg)
+
+	dbBefore, filesBefore := dbDigest(t, m.DB), dirFiles(t, m.Dirs.Snap)
+
+	tx, err := m.DB.BeginRw(m.Ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	before, err := beginDryRun(tx, stages.Senders)
+	require.NoError(t, err)
+	require.NotNil(t, before)
+
+	// stage_senders --dry-run --unwind=2, then forward again
+	cfg := stagedsync.StageSendersCfg(m.DB, m.ChainConfig, ethconfig.Defaults.Sync, false, m.Dirs.Tmp, prune.Mode{}, m.BlockReader, nil)
+	require.NoError(t, stagedsync.UnwindSendersStage(&stagedsync.UnwindState{ID: stages.Senders, UnwindPoint: 1, CurrentBlockNumber: 3}, tx, cfg, m.Ctx))
+	require.NoError(t, stagedsync.SpawnRecoverSendersStage(cfg, &stagedsync.StageState{ID: stages.Senders, BlockNumber: 1}, nil, tx, 3, m.Ctx, m.Log))
+	// the exec stage builds the state files in the background as it goes, a no-op in the dry-run
+	maxTxNum, err := rawdbv3.TxNums.Max(tx, 3)
+	require.NoError(t, err)
+	<-agg.BuildFilesInBackground(maxTxNum)
+
+	require.NoError(t, reportDryRun(m.Ctx, tx, m.BlockReader, before, m.Log))
+	tx.Rollback()
+
+	require.Equal(t, dbBefore, dbDigest(t, m.DB))
+	require.Equal(t, filesBefore, dirFiles(t, m.Dirs.Snap))
+}
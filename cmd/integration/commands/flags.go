@@ -32,7 +32,7 @@ var (
 	block, pruneTo, unwind                   uint64
 	unwindEvery                              uint64
 	batchSizeStr                             string
-	reset, noCommit                          bool
+	reset, noCommit, dryRun                  bool
 	resetPruneAt                             bool
 	bucket                                   string
 	datadirCli, toChaindata                  string
@@ -106,6 +106,10 @@ func withNoCommit(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&noCommit, "no-commit", false, "run everything in 1 transaction, but doesn't commit it")
 }
 
+func withDryRun(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "run the stage in 1 transaction, report what it would change (progress, table sizes, state root), don't commit nor build state files")
+}
+
 func withPruneTo(cmd *cobra.Command) {
 	cmd.Flags().Uint64Var(&pruneTo, "prune.to", 0, "how much blocks unwind on each iteration")
 }
@@ -18,6 +18,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -97,6 +98,9 @@ func openDB(opts kv2.MdbxOpts, applyMigrations bool, logger log.Logger) (tdb kv.
 		if err != nil {
 			return nil, err
 		}
+		if has && dryRun {
+			return nil, errors.New("--dry-run: the db has pending migrations, apply them by a run without --dry-run first")
+		}
 		if has {
 			logger.Info("Re-Opening DB in exclusive mode to apply DB migrations")
 			rawDB.Close()
@@ -502,6 +502,7 @@ func init() {
 	withChain(cmdStageSenders)
 	withHeimdall(cmdStageSenders)
 	withChaosMonkey(cmdStageSenders)
+	withDryRun(cmdStageSenders)
 	rootCmd.AddCommand(cmdStageSenders)
 
 	withConfig(cmdStageSnapshots)
@@ -563,6 +564,7 @@ func init() {
 	withWorkers(cmdStageExec)
 	withChaosMonkey(cmdStageExec)
 	withChainTipMode(cmdStageExec)
+	withDryRun(cmdStageExec)
 	rootCmd.AddCommand(cmdStageExec)
 
 	withConfig(cmdStageCustomTrace)
@@ -601,6 +603,7 @@ func init() {
 	withChain(cmdStageTxLookup)
 	withHeimdall(cmdStageTxLookup)
 	withChaosMonkey(cmdStageTxLookup)
+	withDryRun(cmdStageTxLookup)
 	rootCmd.AddCommand(cmdStageTxLookup)
 
 	withConfig(cmdPrintMigrations)
@@ -1013,6 +1016,10 @@ func stageSenders(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) er
 
 	s := stage(sync, tx, nil, stages.Senders)
 	logger.Info("Stage", "name", s.ID, "progress", s.BlockNumber)
+	dryRunBefore, err := beginDryRun(tx, stages.Senders)
+	if err != nil {
+		return err
+	}
 
 	pm, err := prune.Get(tx)
 	if err != nil {
@@ -1033,6 +1040,9 @@ func stageSenders(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) er
 			return err
 		}
 	}
+	if dryRunBefore != nil {
+		return reportDryRun(ctx, tx, br, dryRunBefore, logger)
+	}
 	return tx.Commit()
 }
 
@@ -1103,16 +1113,29 @@ func stageExec(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) error
 		}
 	}
 
+	if dryRun && chainTipMode {
+		return errors.New("--dry-run is not supported with --sync.mode.chaintip, use --no-commit")
+	}
 	var tx kv.RwTx //nil - means lower-level code (each stage) will manage transactions
-	if noCommit {
+	var dryRunBefore *dryRunSnapshot
+	if noCommit || dryRun {
 		var err error
 		tx, err = db.BeginRw(ctx)
 		if err != nil {
 			return err
 		}
 		defer tx.Rollback()
+		if dryRunBefore, err = beginDryRun(tx, stages.Execution); err != nil {
+			return err
+		}
 	}
 	txc := wrap.TxContainer{Tx: tx}
+	finish := func() error {
+		if dryRunBefore != nil {
+			return reportDryRun(ctx, tx, br, dryRunBefore, logger)
+		}
+		return nil
+	}
 
 	if unwind > 0 {
 		u := sync.NewUnwindState(stages.Execution, s.BlockNumber-unwind, s.BlockNumber, true, false)
@@ -1120,7 +1143,7 @@ func stageExec(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) error
 		if err != nil {
 			return err
 		}
-		return nil
+		return finish()
 	}
 
 	if pruneTo > 0 {
@@ -1132,7 +1155,7 @@ func stageExec(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) error
 		if err != nil {
 			return err
 		}
-		return nil
+		return finish()
 	}
 
 	if chainTipMode {
@@ -1194,7 +1217,7 @@ func stageExec(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) error
 		return err
 	}
 
-	return nil
+	return finish()
 }
 
 func stageCustomTrace(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) error {
@@ -1289,6 +1312,10 @@ func stageTxLookup(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) e
 	defer tx.Rollback()
 
 	s := stage(sync, tx, nil, stages.TxLookup)
+	dryRunBefore, err := beginDryRun(tx, stages.TxLookup)
+	if err != nil {
+		return err
+	}
 	if pruneTo > 0 {
 		pm.History = prune.Distance(s.BlockNumber - pruneTo)
 	}
@@ -1317,6 +1344,9 @@ func stageTxLookup(db kv.TemporalRwDB, ctx context.Context, logger log.Logger) e
 			return err
 		}
 	}
+	if dryRunBefore != nil {
+		return reportDryRun(ctx, tx, br, dryRunBefore, logger)
+	}
 	return tx.Commit()
 }
 
@@ -1383,6 +1413,7 @@ func allSnapshots(ctx context.Context, db kv.RoDB, logger log.Logger) (*freezebl
 		}
 
 		_aggSingleton.SetProduceMod(snapCfg.ProduceE3)
+		dryRunAggregator(_aggSingleton)
 
 		g := &errgroup.Group{}
 		g.Go(func() error {
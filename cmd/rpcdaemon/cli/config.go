@@ -28,6 +28,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -86,6 +87,8 @@ import (
 	"github.com/erigontech/erigon/turbo/logging"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
+	"github.com/erigontech/erigon/turbo/shards"
+	"github.com/erigontech/erigon/turbo/snapshotsync"
 	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
 
 	// Force-load native and js packages, to trigger registration
@@ -99,8 +102,9 @@ var rootCmd = &cobra.Command{
 }
 
 var (
-	stateCacheStr string
-	polygonSync   bool
+	stateCacheStr        string
+	responseCacheSizeStr string
+	polygonSync          bool
 )
 
 type HeimdallReader interface {
@@ -152,6 +156,14 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpCORSDomain, "http.corsdomain", []string{}, "Comma separated list of domains from which to accept cross origin requests (browser enforced)")
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpVirtualHost, "http.vhosts", nodecfg.DefaultConfig.HTTPVirtualHosts, "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.")
 	rootCmd.PersistentFlags().BoolVar(&cfg.HttpCompression, "http.compression", true, "Disable http compression")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpResponseCacheDir, utils.HttpResponseCacheFlag.Name, "", utils.HttpResponseCacheFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&responseCacheSizeStr, utils.HttpResponseCacheSizeFlag.Name, utils.HttpResponseCacheSizeFlag.Value, utils.HttpResponseCacheSizeFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.HttpCost, utils.HttpCostFlag.Name, false, utils.HttpCostFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.HttpDrainTimeout, utils.HttpDrainTimeoutFlag.Name, utils.HttpDrainTimeoutFlag.Value, utils.HttpDrainTimeoutFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.HttpDrainRetryAfter, utils.HttpDrainRetryAfterFlag.Name, utils.HttpDrainRetryAfterFlag.Value, utils.HttpDrainRetryAfterFlag.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.HttpDrainGrace, utils.HttpDrainGraceFlag.Name, utils.HttpDrainGraceFlag.Value, utils.HttpDrainGraceFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Snap.Manifests, utils.DownloaderManifestFlag.Name, nil, utils.DownloaderManifestFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Snap.ManifestPublishers, utils.DownloaderManifestPublishersFlag.Name, nil, utils.DownloaderManifestPublishersFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketEnabled, "ws", false, "Enable Websockets - Same port as HTTP[S]")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketCompression, "ws.compression", false, "Enable Websocket compression (RFC 7692)")
 
@@ -180,8 +192,21 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().IntVar(&cfg.BatchLimit, utils.RpcBatchLimit.Name, utils.RpcBatchLimit.Value, utils.RpcBatchLimit.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, utils.RpcReturnDataLimit.Name, utils.RpcReturnDataLimit.Value, utils.RpcReturnDataLimit.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.AllowUnprotectedTxs, utils.AllowUnprotectedTxs.Name, utils.AllowUnprotectedTxs.Value, utils.AllowUnprotectedTxs.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.GethCompatErrors, utils.RpcGethCompatErrorsFlag.Name, false, utils.RpcGethCompatErrorsFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.GasPriceStrategy, utils.GpoStrategyFlag.Name, utils.GpoStrategyFlag.Value, utils.GpoStrategyFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.MaxGetProofRewindBlockCount, utils.RpcMaxGetProofRewindBlockCount.Name, utils.RpcMaxGetProofRewindBlockCount.Value, utils.RpcMaxGetProofRewindBlockCount.Usage)
 	rootCmd.PersistentFlags().Uint64Var(&cfg.OtsMaxPageSize, utils.OtsSearchMaxCapFlag.Name, utils.OtsSearchMaxCapFlag.Value, utils.OtsSearchMaxCapFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.AnalyticsEnabled, utils.RpcAnalyticsFlag.Name, false, utils.RpcAnalyticsFlag.Usage)
+	rootCmd.PersistentFlags().Uint64Var(&cfg.AnalyticsRetentionDays, utils.RpcAnalyticsRetentionFlag.Name, utils.RpcAnalyticsRetentionFlag.Value, utils.RpcAnalyticsRetentionFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.AnalyticsStateExpiry, utils.RpcAnalyticsStateExpiryFlag.Name, false, utils.RpcAnalyticsStateExpiryFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.AnalyticsSelectors, utils.RpcAnalyticsSelectorsFlag.Name, false, utils.RpcAnalyticsSelectorsFlag.Usage)
+	rootCmd.PersistentFlags().Uint64Var(&cfg.AnalyticsSelectorsBackfill, utils.RpcAnalyticsSelectorsBackfillFlag.Name, utils.RpcAnalyticsSelectorsBackfillFlag.Value, utils.RpcAnalyticsSelectorsBackfillFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.ChainStatsFile, utils.RpcAnalyticsChainStatsFlag.Name, "", utils.RpcAnalyticsChainStatsFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.WatchListsFile, utils.RpcWatchListsFlag.Name, "", utils.RpcWatchListsFlag.Usage)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.WatchListsWebhookHosts, utils.RpcWatchListsWebhooksFlag.Name, nil, utils.RpcWatchListsWebhooksFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.SourceMapsDir, utils.RpcSourceMapsFlag.Name, "", utils.RpcSourceMapsFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.WitnessDir, utils.RpcWitnessDirFlag.Name, "", utils.RpcWitnessDirFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.IPLDDir, utils.RpcIPLDDirFlag.Name, "", utils.RpcIPLDDirFlag.Usage)
 	rootCmd.PersistentFlags().DurationVar(&cfg.RPCSlowLogThreshold, utils.RPCSlowFlag.Name, utils.RPCSlowFlag.Value, utils.RPCSlowFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketSubscribeLogsChannelSize, utils.WSSubscribeLogsChannelSize.Name, utils.WSSubscribeLogsChannelSize.Value, utils.WSSubscribeLogsChannelSize.Usage)
 
@@ -204,6 +229,11 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 			return fmt.Errorf("state.cache value of %v is not valid", stateCacheStr)
 		}
 
+		err = cfg.HttpResponseCacheSize.UnmarshalText([]byte(responseCacheSizeStr))
+		if err != nil {
+			return fmt.Errorf("%s value of %v is not valid", utils.HttpResponseCacheSizeFlag.Name, responseCacheSizeStr)
+		}
+
 		cfg.WithDatadir = cfg.DataDir != ""
 		if cfg.WithDatadir {
 			if cfg.DataDir == "" {
@@ -308,7 +338,7 @@ func EmbeddedServices(ctx context.Context,
 	erigonDB kv.RoDB, stateCacheCfg kvcache.CoherentConfig,
 	rpcFiltersConfig rpchelper.FiltersConfig,
 	blockReader services.FullBlockReader, ethBackendServer remote.ETHBACKENDServer, txPoolServer txpool.TxpoolServer,
-	miningServer txpool.MiningServer, stateDiffClient StateChangesClient,
+	miningServer txpool.MiningServer, stateDiffClient StateChangesClient, headEvents *shards.HeadEvents,
 	logger log.Logger,
 ) (eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, stateCache kvcache.Cache, ff *rpchelper.Filters) {
 	if stateCacheCfg.CacheSize > 0 {
@@ -321,19 +351,73 @@ func EmbeddedServices(ctx context.Context,
 		stateCache = kvcache.NewDummy()
 	}
 
-	subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache)
-
 	directClient := direct.NewEthBackendClientDirect(ethBackendServer)
 
 	eth = rpcservices.NewRemoteBackend(directClient, erigonDB, blockReader)
 
 	txPool = direct.NewTxPoolClient(txPoolServer)
 	mining = direct.NewMiningClient(miningServer)
-	ff = rpchelper.New(ctx, rpcFiltersConfig, eth, txPool, mining, func() {}, logger)
+	if headEvents == nil {
+		subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache)
+		ff = rpchelper.New(ctx, rpcFiltersConfig, eth, txPool, mining, func() {}, logger)
+		return
+	}
 
+	// heads, logs and state changes come from the ordered bus of the node instead of the separate streams
+	ff = rpchelper.New(ctx, rpcFiltersConfig, nil, txPool, mining, func() {}, logger)
+	subscribeToHeadEventsLoop(ctx, headEvents, stateCache, ff, logger)
 	return
 }
 
+// subscribeToHeadEventsLoop applies the head events in order: the state changes to the cache first, then the headers
+// and logs to the filters - so that a subscriber notified of a new head already reads its state through the cache
+func subscribeToHeadEventsLoop(ctx context.Context, headEvents *shards.HeadEvents, cache kvcache.Cache, ff *rpchelper.Filters, logger log.Logger) {
+	go func() {
+		var next uint64 // sequence number of the next expected event, 0 - any
+		for {
+			events, unsubscribe, err := headEvents.Subscribe(next, 128)
+			if err != nil {
+				// the cache starts over on a gap of state versions, the missed heads and logs are lost
+				logger.Warn("[rpcdaemon subscribeToHeadEvents] missed events", "err", err)
+				next = 0
+				continue
+			}
+			next = consumeHeadEvents(ctx, events, cache, ff, next)
+			unsubscribe()
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			logger.Debug("[rpcdaemon subscribeToHeadEvents] dropped for lagging behind, resubscribing", "next", next)
+		}
+	}()
+}
+
+// consumeHeadEvents returns the sequence number of the next expected event, once the channel is closed or ctx is done
+func consumeHeadEvents(ctx context.Context, events <-chan shards.HeadEvent, cache kvcache.Cache, ff *rpchelper.Filters, next uint64) uint64 {
+	for {
+		select {
+		case <-ctx.Done():
+			return next
+		case ev, ok := <-events:
+			if !ok {
+				return next
+			}
+			if ev.StateChanges != nil {
+				cache.OnNewBlock(ev.StateChanges)
+			}
+			for _, header := range ev.Headers {
+				ff.OnNewEvent(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: header})
+			}
+			for _, l := range ev.Logs {
+				ff.OnNewLogs(l)
+			}
+			next = ev.Seq + 1
+		}
+	}
+}
+
 // RemoteServices - use when RPCDaemon run as independent process. Still it can use --datadir flag to enable
 // `cfg.WithDatadir` (mode when it on 1 machine with Erigon)
 func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger, rootCancel context.CancelFunc) (
@@ -444,6 +528,11 @@ func RemoteServices(ctx context.Context, cfg *httpcfg.HttpCfg, logger log.Logger
 			return nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, err
 		}
 		if allSegmentsDownloadComplete {
+			// the files are shared with Erigon, so mismatches are refused rather than moved to quarantine
+			snapCfg.QuarantineMismatches = false
+			if err := snapshotsync.VerifyAttestations(ctx, cfg.Dirs, snapCfg, logger); err != nil {
+				return nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, err
+			}
 			allSnapshots.OptimisticalyOpenFolder()
 			allBorSnapshots.OptimisticalyOpenFolder()
 
@@ -682,7 +771,18 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 
 	srv.SetBatchLimit(cfg.BatchLimit)
 
+	if cfg.HttpResponseCacheDir != "" {
+		responseCache, err := rpc.NewResponseCache(cfg.DataDirPath(cfg.HttpResponseCacheDir), int64(cfg.HttpResponseCacheSize), logger)
+		if err != nil {
+			return fmt.Errorf("could not open HTTP response cache: %w", err)
+		}
+		srv.SetResponseCache(responseCache)
+	}
+	srv.SetCostAccounting(cfg.HttpCost)
+
 	defer srv.Stop()
+	var endpoints []drainedEndpoint // shut down while draining srv
+	defer func() { drainRpcServer(srv, endpoints, cfg, logger) }()
 
 	var defaultAPIList []rpc.API
 
@@ -736,7 +836,7 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 		wsHandler = srv.WebsocketHandler([]string{"*"}, nil, cfg.WebsocketCompression, logger)
 	}
 	graphQLHandler := graphql.CreateHandler(defaultAPIList)
-	apiHandler, err := createHandler(cfg, defaultAPIList, httpHandler, wsHandler, graphQLHandler, nil)
+	apiHandler, err := createHandler(cfg, srv, defaultAPIList, httpHandler, wsHandler, graphQLHandler, nil)
 	if err != nil {
 		return err
 	}
@@ -754,12 +854,7 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 			return fmt.Errorf("could not start separate Websocket RPC api at port %d: %w", cfg.WebsocketPort, err)
 		}
 		info = append(info, "websocket.url", wsAddr)
-		defer func() {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			_ = wsListener.Shutdown(shutdownCtx)
-			logger.Info("HTTP endpoint closed", "url", wsAddr)
-		}()
+		endpoints = append(endpoints, drainedEndpoint{"HTTP", wsAddr, wsListener})
 	}
 
 	if cfg.HttpServerEnabled {
@@ -774,12 +869,7 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 			return fmt.Errorf("could not start RPC api: %w", err)
 		}
 		info = append(info, "http.url", httpAddr)
-		defer func() {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			_ = listener.Shutdown(shutdownCtx)
-			logger.Info("HTTP endpoint closed", "url", httpAddr)
-		}()
+		endpoints = append(endpoints, drainedEndpoint{"HTTP", httpAddr, listener})
 	}
 	if cfg.HttpsURL != "" {
 		cfg.HttpsServerEnabled = true
@@ -802,12 +892,7 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 			return fmt.Errorf("could not start RPC api: %w", err)
 		}
 		info = append(info, "https.url", httpAddr)
-		defer func() {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			_ = listener.Shutdown(shutdownCtx)
-			logger.Info("HTTPS endpoint closed", "url", httpAddr)
-		}()
+		endpoints = append(endpoints, drainedEndpoint{"HTTPS", httpAddr, listener})
 	}
 
 	var (
@@ -847,6 +932,42 @@ func startRegularRpcServer(ctx context.Context, cfg *httpcfg.HttpCfg, rpcAPI []r
 	return nil
 }
 
+type drainedEndpoint struct {
+	name string
+	addr net.Addr
+	srv  *http.Server
+}
+
+// drainRpcServer marks srv as draining and keeps the endpoints open for --http.drain.grace, so that load balancers
+// see the health check failing and stop routing requests here. Then it shuts down the endpoints, which stops
+// accepting connections and lets in-flight HTTP requests finish, together with draining websocket connections of
+// srv, both bounded by --http.drain.timeout.
+func drainRpcServer(srv *rpc.Server, endpoints []drainedEndpoint, cfg *httpcfg.HttpCfg, logger log.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HttpDrainGrace+cfg.HttpDrainTimeout)
+	defer cancel()
+	srv.StartDraining(ctx, cfg.HttpDrainRetryAfter)
+	if cfg.HttpDrainGrace > 0 {
+		logger.Info("[rpc] waiting for load balancers before closing endpoints", "grace", cfg.HttpDrainGrace)
+		time.Sleep(cfg.HttpDrainGrace)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srv.Drain(ctx, cfg.HttpDrainRetryAfter)
+	}()
+	for _, e := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = e.srv.Shutdown(ctx)
+			logger.Info(e.name+" endpoint closed", "url", e.addr)
+		}()
+	}
+	wg.Wait()
+}
+
 type engineInfo struct {
 	Srv                *rpc.Server
 	EngineSrv          *rpc.Server
@@ -917,14 +1038,14 @@ func ObtainJWTSecret(cfg *httpcfg.HttpCfg, logger log.Logger) ([]byte, error) {
 	return jwtSecret, nil
 }
 
-func createHandler(cfg *httpcfg.HttpCfg, apiList []rpc.API, httpHandler http.Handler, wsHandler http.Handler, graphQLHandler http.Handler, jwtSecret []byte) (http.Handler, error) {
+func createHandler(cfg *httpcfg.HttpCfg, srv *rpc.Server, apiList []rpc.API, httpHandler http.Handler, wsHandler http.Handler, graphQLHandler http.Handler, jwtSecret []byte) (http.Handler, error) {
 	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if cfg.GraphQLEnabled && graphql.ProcessGraphQLcheckIfNeeded(graphQLHandler, w, r) {
 			return
 		}
 
 		// adding a healthcheck here
-		if health.ProcessHealthcheckIfNeeded(w, r, apiList) {
+		if health.ProcessDrainIfNeeded(w, r, srv) || health.ProcessHealthcheckIfNeeded(w, r, apiList) {
 			return
 		}
 		if cfg.WebsocketEnabled && wsHandler != nil && isWebsocket(r) {
@@ -962,7 +1083,7 @@ func createEngineListener(cfg *httpcfg.HttpCfg, engineApi []rpc.API, logger log.
 
 	graphQLHandler := graphql.CreateHandler(engineApi)
 
-	engineApiHandler, err := createHandler(cfg, engineApi, engineHttpHandler, wsHandler, graphQLHandler, jwtSecret)
+	engineApiHandler, err := createHandler(cfg, engineSrv, engineApi, engineHttpHandler, wsHandler, graphQLHandler, jwtSecret)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -17,14 +17,21 @@
 package httpcfg
 
 import (
+	"path/filepath"
 	"time"
 
+	"github.com/c2h5oh/datasize"
+
 	"github.com/erigontech/erigon/turbo/rpchelper"
 
 	"github.com/erigontech/erigon-lib/common/datadir"
 	"github.com/erigontech/erigon-lib/kv/kvcache"
+	libstate "github.com/erigontech/erigon-lib/state"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/rpc/rpccfg"
+	"github.com/erigontech/erigon/turbo/shards"
+	"github.com/erigontech/erigon/txnprovider/txpool"
+	"github.com/erigontech/erigon/txnprovider/userop"
 )
 
 type HttpCfg struct {
@@ -47,6 +54,13 @@ type HttpCfg struct {
 	HttpVirtualHost    []string
 	AuthRpcVirtualHost []string
 	HttpCompression    bool
+	// Disk cache of responses for finalized data (--http.responsecache), disabled if empty
+	HttpResponseCacheDir  string
+	HttpResponseCacheSize datasize.ByteSize
+	HttpCost              bool          // report the cost of requests in responses (--http.cost)
+	HttpDrainTimeout      time.Duration // how long in-flight requests may take on shutdown (--http.drain.timeout)
+	HttpDrainRetryAfter   time.Duration // when websocket clients are told to reconnect on shutdown (--http.drain.retryafter)
+	HttpDrainGrace        time.Duration // how long endpoints stay open after health checks start failing on shutdown (--http.drain.grace)
 
 	HttpsServerEnabled bool
 	HttpsURL           string
@@ -88,6 +102,7 @@ type HttpCfg struct {
 	SocketListenUrl     string
 
 	JWTSecretPath             string // Engine API Authentication
+	AuthRpcRecordFile         string // file the Engine API calls are recorded to (--authrpc.record), disabled if empty
 	TraceRequests             bool   // Print requests to logs at INFO level
 	DebugSingleRequest        bool   // Print single-request-related debugging info to logs at INFO level
 	HTTPTimeouts              rpccfg.HTTPTimeouts
@@ -99,12 +114,58 @@ type HttpCfg struct {
 	LogDirVerbosity string
 	LogDirPath      string
 
-	BatchLimit                  int  // Maximum number of requests in a batch
-	ReturnDataLimit             int  // Maximum number of bytes returned from calls (like eth_call)
-	AllowUnprotectedTxs         bool // Whether to allow non EIP-155 protected transactions  txs over RPC
-	MaxGetProofRewindBlockCount int  //Max GetProof rewind block count
+	BatchLimit                  int    // Maximum number of requests in a batch
+	ReturnDataLimit             int    // Maximum number of bytes returned from calls (like eth_call)
+	AllowUnprotectedTxs         bool   // Whether to allow non EIP-155 protected transactions  txs over RPC
+	GethCompatErrors            bool   // Whether to return errors in geth's format
+	GasPriceStrategy            string // Default gas price oracle strategy (eth_gasPrice, eth_maxPriorityFeePerGas)
+	MaxGetProofRewindBlockCount int    //Max GetProof rewind block count
 	// Ots API
 	OtsMaxPageSize uint64
 
+	// Rolling analytics indices (erigon_topContracts, ...)
+	AnalyticsEnabled       bool
+	AnalyticsRetentionDays uint64
+	AnalyticsStateExpiry   bool
+	// Index of function selectors (erigon_getTransactionsBySelector)
+	AnalyticsSelectors         bool
+	AnalyticsSelectorsBackfill uint64
+	// Daily chain statistics file (erigon_chainStats), disabled if empty
+	ChainStatsFile string
+	// Watch-lists file (erigon_addWatchList, ...), disabled if empty
+	WatchListsFile string
+	// Hosts watch-list webhooks may be sent to, webhooks are rejected if empty
+	WatchListsWebhookHosts []string
+	// Contract source maps file (debug_addSourceMap, ...), disabled if empty
+	SourceMapsDir string
+	// Directory of exported transaction witnesses (debug_exportBlockWitnesses), disabled if empty
+	WitnessDir string
+	// Directory of exported CAR files (erigon_exportCAR), disabled if empty
+	IPLDDir string
+
 	RPCSlowLogThreshold time.Duration
+
+	// Set by the node for the embedded rpcdaemon (admin_setCompactionMode, ...), nil otherwise
+	CompactionScheduler *libstate.CompactionScheduler
+	// Blocks recently displaced by reorgs, served by eth_getBlockByHash
+	NonCanonicalBlocks *shards.NonCanonicalBlocks
+	// Receipts of the latest executed blocks, served by eth_getBlockReceipts/eth_getTransactionReceipt without re-execution
+	RecentReceipts *shards.RecentReceipts
+	// Propagation of the recent pool transactions (erigon_traceTxPropagation), nil if the txpool is not internal
+	TxnPropagation *txpool.PropagationLog
+	// Transactions included by the local block builder before sealing (eth_subscribe "softConfirmations"), nil
+	// unless the node runs in sequencer mode
+	SoftConfirmations *shards.Events
+	// Stage transitions and sync milestones (erigon_subscribe "syncEvents"), nil unless the rpcdaemon is embedded
+	SyncEvents *shards.Events
+	// ERC-4337 user operation mempool (eth_sendUserOperation), nil unless enabled by --userops
+	UserOps *userop.Pool
+}
+
+// DataDirPath resolves a relative path of the --rpc.* files and directories against the datadir
+func (c *HttpCfg) DataDirPath(path string) string {
+	if filepath.IsAbs(path) || c.Dirs.DataDir == "" {
+		return path
+	}
+	return filepath.Join(c.Dirs.DataDir, path)
 }
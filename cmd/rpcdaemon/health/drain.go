@@ -0,0 +1,43 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+// ProcessDrainIfNeeded answers health checks with 503 and the drain progress while the server is being drained
+// before shutdown (see rpc.Server.Drain), so that load balancers stop routing to it. Returns false if the request
+// is not a health check or the server is not draining, srv may be nil.
+func ProcessDrainIfNeeded(w http.ResponseWriter, r *http.Request, srv *rpc.Server) bool {
+	if srv == nil || !strings.EqualFold(r.URL.Path, urlPath) || !srv.Draining() {
+		return false
+	}
+	body, err := json.Marshal(srv.DrainStatus())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write(body)
+	return true
+}
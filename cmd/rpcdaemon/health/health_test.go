@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
 
 	"github.com/erigontech/erigon/rpc"
 )
@@ -577,3 +578,37 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessDrainIfNeeded(t *testing.T) {
+	srv := rpc.NewServer(1, false, false, true, log.New(), 0)
+	defer srv.Stop()
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:9090/health", nil)
+	if ProcessDrainIfNeeded(httptest.NewRecorder(), r, srv) {
+		t.Fatal("processed before draining")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	srv.Drain(ctx, time.Second)
+
+	w := httptest.NewRecorder()
+	if !ProcessDrainIfNeeded(w, r, srv) {
+		t.Fatal("not processed while draining")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	var status rpc.DrainStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Draining || status.Deadline.IsZero() {
+		t.Fatalf("wrong drain status: %+v", status)
+	}
+
+	other := httptest.NewRequest(http.MethodPost, "http://localhost:9090/", nil)
+	if ProcessDrainIfNeeded(httptest.NewRecorder(), other, srv) {
+		t.Fatal("processed a request which is not a health check")
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon/cmd/rpctest/rpctest"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_recorder"
 	"github.com/erigontech/erigon/turbo/logging"
 )
 
@@ -465,6 +466,27 @@ func main() {
 	}
 	with(replayCmd, withErigonUrl, withRecord)
 
+	var (
+		engineURL      string
+		jwtSecretPath  string
+		replaySpeed    float64
+		stopOnMismatch bool
+	)
+	var replayEngineCmd = &cobra.Command{
+		Use:   "replayEngine",
+		Short: "Feed engine_newPayload/forkchoiceUpdated calls recorded with --authrpc.record into a node",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := engine_recorder.ReplayConfig{Speed: replaySpeed, StopOnMismatch: stopOnMismatch}
+			return rpctest.ReplayEngine(cmd.Context(), engineURL, jwtSecretPath, recordFile, cfg, logger)
+		},
+	}
+	replayEngineCmd.Flags().StringVar(&engineURL, "engineUrl", "http://localhost:8551", "Engine API (authrpc) url of the node")
+	replayEngineCmd.Flags().StringVar(&jwtSecretPath, "jwtSecret", "jwt.hex", "Path to the JWT secret of the node")
+	replayEngineCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Speed relative to the recorded pace, 0 - send every call right after the previous one")
+	replayEngineCmd.Flags().BoolVar(&stopOnMismatch, "stopOnMismatch", false, "Stop at the first response differing from the recorded one")
+	with(replayEngineCmd, withRecord)
+
 	var tmpDataDir, tmpDataDirOrig string
 	var notRegenerateGethData bool
 	var compareAccountRange = &cobra.Command{
@@ -518,6 +540,7 @@ func main() {
 		benchEthGetBalanceCmd,
 		benchOtsGetBlockTransactions,
 		replayCmd,
+		replayEngineCmd,
 	)
 	if err := rootCmd.ExecuteContext(rootContext()); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,64 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpctest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/cl/phase1/execution_client/rpc_helper"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_recorder"
+)
+
+// ReplayEngine feeds the engine API calls recorded by a node (--authrpc.record) into the node at engineURL
+func ReplayEngine(ctx context.Context, engineURL, jwtSecretPath, recordFile string, cfg engine_recorder.ReplayConfig, logger log.Logger) error {
+	data, err := os.ReadFile(jwtSecretPath)
+	if err != nil {
+		return fmt.Errorf("cannot read JWT secret: %w", err)
+	}
+	jwtSecret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(jwtSecret) != 32 {
+		return errors.New("invalid JWT secret")
+	}
+	client, err := rpc.DialHTTPWithClient(engineURL, &http.Client{Transport: rpc_helper.NewJWTRoundTripper(jwtSecret)}, logger)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	f, err := os.Open(recordFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	res, err := engine_recorder.Replay(ctx, client, f, cfg, logger)
+	logger.Info("[engine-replay] done", "calls", res.Calls, "mismatches", res.Mismatches)
+	if err != nil {
+		return err
+	}
+	if res.Mismatches > 0 {
+		return fmt.Errorf("%d of %d responses differ from the recorded ones", res.Mismatches, res.Calls)
+	}
+	return nil
+}
@@ -289,6 +289,9 @@ func (rw *Worker) RunTxTaskNoLock(txTask *state.TxTask, isMining, skipPostEvalua
 	// Prepare read set, write set and balanceIncrease set and send for serialisation
 	if txTask.Error == nil {
 		txTask.BalanceIncreaseSet = ibs.BalanceIncreaseSet()
+		if txTask.RecordPreimages {
+			txTask.Preimages = ibs.Preimages()
+		}
 		//for addr, bal := range txTask.BalanceIncreaseSet {
 		//	fmt.Printf("BalanceIncreaseSet [%x]=>[%d]\n", addr, &bal)
 		//}
@@ -44,6 +44,7 @@ import (
 	"github.com/erigontech/erigon-lib/common/paths"
 	"github.com/erigontech/erigon-lib/crypto"
 	libkzg "github.com/erigontech/erigon-lib/crypto/kzg"
+	"github.com/erigontech/erigon-lib/crypto/sigrecover"
 	"github.com/erigontech/erigon-lib/direct"
 	downloadercfg2 "github.com/erigontech/erigon-lib/downloader/downloadercfg"
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -52,6 +53,7 @@ import (
 	"github.com/erigontech/erigon/cmd/utils/flags"
 	"github.com/erigontech/erigon/consensus/ethash/ethashcfg"
 	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/gasprice/gaspricecfg"
 	"github.com/erigontech/erigon/node/nodecfg"
@@ -65,6 +67,7 @@ import (
 	"github.com/erigontech/erigon/turbo/logging"
 	"github.com/erigontech/erigon/txnprovider/shutter"
 	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+	"github.com/erigontech/erigon/txnprovider/userop/useropcfg"
 )
 
 // These are all the command line flags we support.
@@ -112,14 +115,35 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	OverrideShanghaiFlag = flags.BigFlag{
+		Name:  "override.shanghai",
+		Usage: "Manually specify the Shanghai fork time, overriding the bundled setting",
+	}
+	OverrideCancunFlag = flags.BigFlag{
+		Name:  "override.cancun",
+		Usage: "Manually specify the Cancun fork time, overriding the bundled setting",
+	}
 	OverridePragueFlag = flags.BigFlag{
 		Name:  "override.prague",
 		Usage: "Manually specify the Prague fork time, overriding the bundled setting",
 	}
+	OverrideOsakaFlag = flags.BigFlag{
+		Name:  "override.osaka",
+		Usage: "Manually specify the Osaka fork time, overriding the bundled setting",
+	}
+	OverrideEipsFlag = cli.StringFlag{
+		Name:  "override.eips",
+		Usage: "Comma separated list of individually scheduled EIPs (<eip>=<timestamp>), e.g. 1153=1700000000,7516=1700000000",
+	}
 	TrustedSetupFile = cli.StringFlag{
 		Name:  "trusted-setup-file",
 		Usage: "Absolute path to trusted_setup.json file",
 	}
+	SigRecoverAcceleratorFlag = cli.StringFlag{
+		Name:  "sigrecover.accelerator",
+		Usage: "Backend of the batched secp256k1 sender recovery (senders stage, txpool): cpu, parallel (spreads a batch over all cores), auto or the name of an accelerator registered by a custom build. Auto selects the first available accelerator by name",
+		Value: sigrecover.CPU,
+	}
 	// Ethash settings
 	EthashCachesInMemoryFlag = cli.IntFlag{
 		Name:  "ethash.cachesinmem",
@@ -209,6 +233,11 @@ var (
 		Usage: "How often transactions should be committed to the storage",
 		Value: txpoolcfg.DefaultConfig.CommitEvery,
 	}
+	TxPoolIngressRulesFlag = cli.StringFlag{
+		Name:  "txpool.ingressrules",
+		Usage: "JSON file of rules rejecting or deprioritizing transactions by destination address or selector, reloaded on change",
+		Value: "",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -251,6 +280,14 @@ var (
 		Usage: "Time interval to recreate the block being mined",
 		Value: ethconfig.Defaults.Miner.Recommit,
 	}
+	MinerOrderingFlag = cli.StringFlag{
+		Name:  "miner.ordering",
+		Usage: "Transaction ordering of built blocks: price-time, fifo (first seen by the txpool first) or grpc://host:port of an external ordering service (JSON over gRPC, see txnprovider/ordering). The policy chooses which of the candidates get included. The txpool order by default",
+	}
+	MinerSoftConfirmationsFlag = cli.BoolFlag{
+		Name:  "miner.softconfirmations",
+		Usage: "Sequencer mode: stream transactions included into the block being built with provisional receipts before the block is sealed (eth_subscribe \"softConfirmations\", embedded rpcdaemon only)",
+	}
 	MinerNoVerfiyFlag = cli.BoolFlag{
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
@@ -333,11 +370,44 @@ var (
 		Usage: "Path to the token that ensures safe connection between CL and EL",
 		Value: "",
 	}
+	AuthRpcRecordFlag = cli.StringFlag{
+		Name:  "authrpc.record",
+		Usage: "Append every engine_newPayload and engine_forkchoiceUpdated call with its response status to this file, to be replayed into another node by `rpctest replayEngine`",
+		Value: "",
+	}
 
 	HttpCompressionFlag = cli.BoolFlag{
 		Name:  "http.compression",
 		Usage: "Enable compression over HTTP-RPC",
 	}
+	HttpResponseCacheFlag = cli.StringFlag{
+		Name:  "http.responsecache",
+		Usage: "Directory of the disk cache of HTTP-RPC responses for finalized data (eth_getBlockByHash, eth_getTransactionReceipt, debug_traceTransaction and similar methods), served with ETag support. Relative path is resolved against datadir. Disabled if empty",
+	}
+	HttpResponseCacheSizeFlag = cli.StringFlag{
+		Name:  "http.responsecache.size",
+		Usage: "Max size of the HTTP-RPC response cache",
+		Value: "1GB",
+	}
+	HttpCostFlag = cli.BoolFlag{
+		Name:  "http.cost",
+		Usage: "Report the resources spent on every HTTP-RPC request (DB reads, EVM gas, wall time) in the \"x-erigon-cost\" member of its response",
+	}
+	HttpDrainTimeoutFlag = cli.DurationFlag{
+		Name:  "http.drain.timeout",
+		Usage: "On shutdown, how long to wait for in-flight HTTP and WebSocket requests to finish before closing connections. The health endpoint reports 503 meanwhile",
+		Value: 5 * time.Second,
+	}
+	HttpDrainGraceFlag = cli.DurationFlag{
+		Name:  "http.drain.grace",
+		Usage: "On shutdown, how long the HTTP and WebSocket endpoints keep accepting connections after the health endpoint starts reporting 503, for load balancers to notice it",
+		Value: 5 * time.Second,
+	}
+	HttpDrainRetryAfterFlag = cli.DurationFlag{
+		Name:  "http.drain.retryafter",
+		Usage: "On shutdown, when WebSocket clients are told to reconnect (\"retry-after=<seconds>\" reason of the close frame)",
+		Value: 10 * time.Second,
+	}
 	WsCompressionFlag = cli.BoolFlag{
 		Name:  "ws.compression",
 		Usage: "Enable compression over WebSocket",
@@ -490,6 +560,10 @@ var (
 		Name:  "rpc.allow-unprotected-txs",
 		Usage: "Allow for unprotected (non-EIP155 signed) transactions to be submitted via RPC",
 	}
+	RpcGethCompatErrorsFlag = cli.BoolFlag{
+		Name:  "rpc.gethcompat.errors",
+		Usage: "Return errors of eth_call, eth_estimateGas and eth_sendRawTransaction in geth's format (codes and messages), for SDKs matching error strings",
+	}
 	// Careful! Because we must rewind the hash state
 	// and re-compute the state trie, the further back in time the request, the more
 	// computationally intensive the operation becomes.
@@ -620,6 +694,11 @@ var (
 		Usage: "Maximum gas price will be recommended by gpo",
 		Value: ethconfig.Defaults.GPO.MaxPrice.Int64(),
 	}
+	GpoStrategyFlag = cli.StringFlag{
+		Name:  "gpo.strategy",
+		Usage: "Gas price oracle strategy used by eth_gasPrice and eth_maxPriorityFeePerGas unless the request selects one: percentile, mempool (percentile raised to pending pool tips), ewma (moving average of per-block percentiles)",
+		Value: ethconfig.Defaults.GPO.Strategy,
+	}
 
 	// Metrics flags
 	MetricsEnabledFlag = cli.BoolFlag{
@@ -713,6 +792,20 @@ var (
 		Name:  "downloader.verify",
 		Usage: "Verify snapshots on startup. It will not report problems found, but re-download broken pieces.",
 	}
+	DownloaderManifestFlag = cli.StringFlag{
+		Name:  "downloader.manifest",
+		Usage: "Comma separated urls or paths of signed snapshot manifests. Snapshot files are verified against them before use, see --downloader.manifest.publishers",
+		Value: "",
+	}
+	DownloaderManifestPublishersFlag = cli.StringFlag{
+		Name:  "downloader.manifest.publishers",
+		Usage: "Comma separated addresses of the publishers trusted to sign snapshot manifests",
+		Value: "",
+	}
+	DownloaderManifestQuarantineFlag = cli.BoolFlag{
+		Name:  "downloader.manifest.quarantine",
+		Usage: "Move snapshot files not matching the manifests to the quarantine dir instead of refusing to start",
+	}
 	DisableIPV6 = cli.BoolFlag{
 		Name:  "downloader.disable.ipv6",
 		Usage: "Turns off ipv6 for the downloader",
@@ -749,6 +842,10 @@ var (
 		Usage: "Runtime limit of chaindata db size (can change at any time)",
 		Value: (200 * datasize.GB).String(),
 	}
+	DbGrowthStepFlag = cli.StringFlag{
+		Name:  "db.growth.step",
+		Usage: "Chaindata db file grows by this step (can change at any time). Bigger step means less remaps and fragmentation of the file. Default: 16MB",
+	}
 	DbWriteMapFlag = cli.BoolFlag{
 		Name:  "db.writemap",
 		Usage: "Enable WRITE_MAP feature for fast database writes and fast commit times",
@@ -908,6 +1005,57 @@ var (
 		Value: 25,
 	}
 
+	RpcAnalyticsFlag = cli.BoolFlag{
+		Name:  "rpc.analytics",
+		Usage: "Maintain rolling in-memory analytics indices for erigon_topContracts and similar methods",
+	}
+	RpcAnalyticsRetentionFlag = cli.Uint64Flag{
+		Name:  "rpc.analytics.retention",
+		Usage: "Amount of days kept by rolling analytics indices",
+		Value: 7,
+	}
+	RpcAnalyticsStateExpiryFlag = cli.BoolFlag{
+		Name:  "rpc.analytics.stateexpiry",
+		Usage: "Experimental: track last access block of every read or written account and storage slot for erigon_stateExpiryReport, re-executes every new block. Memory usage is bounded, above ~1M accounts or storage slots the report is estimated from a sample",
+	}
+	RpcAnalyticsSelectorsFlag = cli.BoolFlag{
+		Name:  "rpc.analytics.selectors",
+		Usage: "Maintain in-memory index of function selectors called by transactions for erigon_getTransactionsBySelector",
+	}
+	RpcAnalyticsSelectorsBackfillFlag = cli.Uint64Flag{
+		Name:  "rpc.analytics.selectors.backfill",
+		Usage: "Amount of blocks below the head at start which are added to the function selectors index in background",
+		Value: 0,
+	}
+	RpcAnalyticsChainStatsFlag = cli.StringFlag{
+		Name:  "rpc.analytics.chainstats",
+		Usage: "File with daily chain statistics for erigon_chainStats, maintained with every new head and backfilled down to genesis in background. Relative path is resolved against datadir. Disabled if empty",
+	}
+
+	RpcWatchListsFlag = cli.StringFlag{
+		Name:  "rpc.watchlists",
+		Usage: "File with address watch-lists notified about pool, mined and reorged transactions (erigon_addWatchList and similar methods). Lists added by RPC are saved to it. Relative path is resolved against datadir",
+	}
+	RpcWatchListsWebhooksFlag = cli.StringSliceFlag{
+		Name:  "rpc.watchlists.webhooks",
+		Usage: "Comma separated hosts (host or host:port) watch-list webhooks may be sent to. Watch-lists with webhooks are rejected if empty",
+	}
+
+	RpcSourceMapsFlag = cli.StringFlag{
+		Name:  "rpc.sourcemaps",
+		Usage: "Directory with solc source maps of contracts used to annotate traces (debug_addSourceMap and similar methods), one file per contract. Source maps added by RPC are saved to it. Relative path is resolved against datadir",
+	}
+
+	RpcWitnessDirFlag = cli.StringFlag{
+		Name:  "rpc.witness.dir",
+		Usage: "Directory where debug_exportBlockWitnesses writes transaction witnesses. Relative path is resolved against datadir. Export is disabled if empty",
+	}
+
+	RpcIPLDDirFlag = cli.StringFlag{
+		Name:  "rpc.ipld.dir",
+		Usage: "Directory where erigon_exportCAR writes CAR files of blocks and state for IPFS. Relative path is resolved against datadir. Export is disabled if empty",
+	}
+
 	DiagnosticsURLFlag = cli.StringFlag{
 		Name:  "diagnostics.addr",
 		Usage: "Address of the diagnostics system provided by the support team",
@@ -1040,6 +1188,11 @@ var (
 		Usage: "disable blob pruning in caplin",
 		Value: false,
 	}
+	CaplinBlobRetentionEpochsFlag = cli.Uint64Flag{
+		Name:  "caplin.blobs-retention-epochs",
+		Usage: "number of epochs caplin keeps the blob sidecars for (e.g. 82125 for a year), never less than the CL minimum (4096 epochs on mainnet)",
+		Value: 0,
+	}
 	CaplinDisableCheckpointSyncFlag = cli.BoolFlag{
 		Name:  "caplin.checkpoint-sync.disable",
 		Usage: "disable checkpoint sync in caplin",
@@ -1101,6 +1254,11 @@ var (
 		Usage: "Enable 'chaos monkey' to generate spontaneous network/consensus/etc failures. Use ONLY for testing",
 		Value: false,
 	}
+	PreimagesFlag = cli.BoolFlag{
+		Name:  "cache.preimages",
+		Usage: "Enable recording the SHA3/keccak preimages of account addresses and storage keys touched during execution (see eth_getKeyPreimage)",
+		Value: false,
+	}
 	ShutterEnabledFlag = cli.BoolFlag{
 		Name:  "shutter",
 		Usage: "Enable the Shutter encrypted transactions mempool (defaults to false)",
@@ -1113,6 +1271,37 @@ var (
 		Name:  "shutter.p2p.listen.port",
 		Usage: "Use to override the default p2p listen port (defaults to 23102)",
 	}
+	UserOpsEnabledFlag = cli.BoolFlag{
+		Name:  "userops",
+		Usage: "Enable the ERC-4337 user operation mempool (eth_sendUserOperation, eth_getUserOperationReceipt)",
+	}
+	UserOpsEntryPointsFlag = cli.StringFlag{
+		Name:  "userops.entrypoints",
+		Usage: "Comma separated list of the supported v0.6 EntryPoint contracts (defaults to the canonical " + useropcfg.EntryPointV06.Hex() + ")",
+	}
+	UserOpsMaxOpsFlag = cli.IntFlag{
+		Name:  "userops.maxops",
+		Usage: "Max amount of pooled user operations",
+		Value: useropcfg.DefaultConfig.MaxOps,
+	}
+	UserOpsMaxOpsPerSenderFlag = cli.IntFlag{
+		Name:  "userops.maxops.sender",
+		Usage: "Max amount of pooled user operations of one sender",
+		Value: useropcfg.DefaultConfig.MaxOpsPerSender,
+	}
+	UserOpsP2pMempoolIdFlag = cli.StringFlag{
+		Name:  "userops.p2p.mempoolid",
+		Usage: "Id of the mempool to gossip user operations with other bundlers (the canonical mempool IPFS CID), gossip is disabled if not set",
+	}
+	UserOpsP2pBootstrapNodesFlag = cli.StringSliceFlag{
+		Name:  "userops.p2p.bootstrap.nodes",
+		Usage: "Multiaddrs of the bundlers to connect to on start",
+	}
+	UserOpsP2pListenPortFlag = cli.Uint64Flag{
+		Name:  "userops.p2p.listen.port",
+		Usage: "User operation mempool p2p listen port",
+		Value: useropcfg.DefaultConfig.ListenPort,
+	}
 	PolygonPosSingleSlotFinalityFlag = cli.BoolFlag{
 		Name:  "polygon.pos.ssf",
 		Usage: "Enabling Polygon PoS Single Slot Finality",
@@ -1502,6 +1691,11 @@ func setDataDir(ctx *cli.Context, cfg *nodecfg.Config) error {
 	if err := cfg.MdbxDBSizeLimit.UnmarshalText([]byte(ctx.String(DbSizeLimitFlag.Name))); err != nil {
 		return fmt.Errorf("failed to parse --%s: %w", DbSizeLimitFlag.Name, err)
 	}
+	if ctx.IsSet(DbGrowthStepFlag.Name) {
+		if err := cfg.MdbxGrowthStep.UnmarshalText([]byte(ctx.String(DbGrowthStepFlag.Name))); err != nil {
+			return fmt.Errorf("failed to parse --%s: %w", DbGrowthStepFlag.Name, err)
+		}
+	}
 	cfg.MdbxWriteMap = ctx.Bool(DbWriteMapFlag.Name)
 	szLimit := cfg.MdbxDBSizeLimit.Bytes()
 	if szLimit%256 != 0 || szLimit < 256 {
@@ -1537,6 +1731,9 @@ func setGPO(ctx *cli.Context, cfg *gaspricecfg.Config) {
 	if ctx.IsSet(GpoMaxGasPriceFlag.Name) {
 		cfg.MaxPrice = big.NewInt(ctx.Int64(GpoMaxGasPriceFlag.Name))
 	}
+	if ctx.IsSet(GpoStrategyFlag.Name) {
+		cfg.Strategy = ctx.String(GpoStrategyFlag.Name)
+	}
 }
 
 // nolint
@@ -1550,6 +1747,9 @@ func setGPOCobra(f *pflag.FlagSet, cfg *gaspricecfg.Config) {
 	if v := f.Int64(GpoMaxGasPriceFlag.Name, GpoMaxGasPriceFlag.Value, GpoMaxGasPriceFlag.Usage); v != nil {
 		cfg.MaxPrice = big.NewInt(*v)
 	}
+	if v := f.String(GpoStrategyFlag.Name, GpoStrategyFlag.Value, GpoStrategyFlag.Usage); v != nil {
+		cfg.Strategy = *v
+	}
 }
 
 func setTxPool(ctx *cli.Context, dbDir string, fullCfg *ethconfig.Config) {
@@ -1602,6 +1802,9 @@ func setTxPool(ctx *cli.Context, dbDir string, fullCfg *ethconfig.Config) {
 	if ctx.IsSet(TxPoolGossipDisableFlag.Name) {
 		cfg.NoGossip = ctx.Bool(TxPoolGossipDisableFlag.Name)
 	}
+	if ctx.IsSet(TxPoolIngressRulesFlag.Name) {
+		cfg.IngressRules = ctx.String(TxPoolIngressRulesFlag.Name)
+	}
 	cfg.LogEvery = 3 * time.Minute
 	cfg.CommitEvery = libcommon.RandomizeDuration(ctx.Duration(TxPoolCommitEveryFlag.Name))
 	cfg.DBDir = dbDir
@@ -1626,6 +1829,34 @@ func setShutter(ctx *cli.Context, chainName string, nodeConfig *nodecfg.Config,
 	ethConfig.Shutter = config
 }
 
+func setUserOps(ctx *cli.Context, nodeConfig *nodecfg.Config, ethConfig *ethconfig.Config) {
+	if enabled := ctx.Bool(UserOpsEnabledFlag.Name); !enabled {
+		return
+	}
+
+	config := useropcfg.DefaultConfig
+	config.Enabled = true
+	config.PrivateKey = nodeConfig.P2P.PrivateKey
+	if ctx.IsSet(UserOpsEntryPointsFlag.Name) {
+		config.EntryPoints = nil
+		for _, entryPoint := range libcommon.CliString2Array(ctx.String(UserOpsEntryPointsFlag.Name)) {
+			if !libcommon.IsHexAddress(entryPoint) {
+				Fatalf("Option %s: invalid address %q", UserOpsEntryPointsFlag.Name, entryPoint)
+			}
+			config.EntryPoints = append(config.EntryPoints, libcommon.HexToAddress(entryPoint))
+		}
+	}
+	config.MaxOps = ctx.Int(UserOpsMaxOpsFlag.Name)
+	config.MaxOpsPerSender = ctx.Int(UserOpsMaxOpsPerSenderFlag.Name)
+	config.MempoolId = ctx.String(UserOpsP2pMempoolIdFlag.Name)
+	if ctx.IsSet(UserOpsP2pBootstrapNodesFlag.Name) {
+		config.BootstrapNodes = ctx.StringSlice(UserOpsP2pBootstrapNodesFlag.Name)
+	}
+	config.ListenPort = ctx.Uint64(UserOpsP2pListenPortFlag.Name)
+
+	ethConfig.UserOps = config
+}
+
 func setEthash(ctx *cli.Context, datadir string, cfg *ethconfig.Config) {
 	if ctx.IsSet(EthashDatasetDirFlag.Name) {
 		cfg.Ethash.DatasetDir = ctx.String(EthashDatasetDirFlag.Name)
@@ -1761,6 +1992,28 @@ func setMiner(ctx *cli.Context, cfg *params.MiningConfig) {
 	if ctx.IsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.IsSet(MinerOrderingFlag.Name) {
+		cfg.Ordering = ctx.String(MinerOrderingFlag.Name)
+	}
+	cfg.SoftConfirmations = ctx.Bool(MinerSoftConfirmationsFlag.Name)
+}
+
+func setSnapshotManifests(ctx *cli.Context, cfg *ethconfig.BlocksFreezing) {
+	manifests := ctx.String(DownloaderManifestFlag.Name)
+	if manifests == "" {
+		return
+	}
+	cfg.Manifests = libcommon.CliString2Array(manifests)
+	cfg.ManifestPublishers = libcommon.CliString2Array(ctx.String(DownloaderManifestPublishersFlag.Name))
+	if len(cfg.ManifestPublishers) == 0 {
+		Fatalf("Flag --%s requires --%s", DownloaderManifestFlag.Name, DownloaderManifestPublishersFlag.Name)
+	}
+	for _, publisher := range cfg.ManifestPublishers {
+		if !libcommon.IsHexAddress(publisher) {
+			Fatalf("Invalid manifest publisher address: %s", publisher)
+		}
+	}
+	cfg.QuarantineMismatches = ctx.Bool(DownloaderManifestQuarantineFlag.Name)
 }
 
 func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1786,6 +2039,32 @@ func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
+func setOverrideEips(ctx *cli.Context, cfg *ethconfig.Config) {
+	overrides := ctx.String(OverrideEipsFlag.Name)
+	if overrides == "" {
+		return
+	}
+	cfg.OverrideEipTimes = make(map[int]*big.Int)
+	for _, entry := range libcommon.CliString2Array(overrides) {
+		parts := strings.Split(entry, "=")
+		if len(parts) != 2 {
+			Fatalf("Invalid override.eips entry: %s", entry)
+		}
+		eip, err := strconv.Atoi(parts[0])
+		if err != nil {
+			Fatalf("Invalid override.eips EIP number %s: %v", parts[0], err)
+		}
+		if !vm.ValidEip(eip) {
+			Fatalf("EIP %d can't be scheduled individually, supported: %s", eip, strings.Join(vm.ActivateableEips(), ","))
+		}
+		activation, ok := new(big.Int).SetString(parts[1], 0)
+		if !ok {
+			Fatalf("Invalid override.eips timestamp %s", parts[1])
+		}
+		cfg.OverrideEipTimes[eip] = activation
+	}
+}
+
 func setBeaconAPI(ctx *cli.Context, cfg *ethconfig.Config) error {
 	allowed := ctx.StringSlice(BeaconAPIFlag.Name)
 	if err := cfg.CaplinConfig.BeaconAPIRouter.UnwrapEndpointsList(allowed); err != nil {
@@ -1811,6 +2090,7 @@ func setCaplin(ctx *cli.Context, cfg *ethconfig.Config) {
 	cfg.CaplinConfig.ArchiveBlobs = ctx.Bool(CaplinArchiveBlobsFlag.Name)
 	cfg.CaplinConfig.ImmediateBlobsBackfilling = ctx.Bool(CaplinImmediateBlobBackfillFlag.Name)
 	cfg.CaplinConfig.BlobPruningDisabled = ctx.Bool(CaplinDisableBlobPruningFlag.Name)
+	cfg.CaplinConfig.BlobRetentionEpochs = ctx.Uint64(CaplinBlobRetentionEpochsFlag.Name)
 	cfg.CaplinConfig.DisabledCheckpointSync = ctx.Bool(CaplinDisableCheckpointSyncFlag.Name)
 	cfg.CaplinConfig.ArchiveStates = ctx.Bool(CaplinArchiveStatesFlag.Name)
 	cfg.CaplinConfig.MevRelayUrl = ctx.String(CaplinMevRelayUrl.Name)
@@ -1926,6 +2206,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	cfg.Snapshot.DisableDownloadE3 = ctx.Bool(SnapSkipStateSnapshotDownloadFlag.Name)
 	cfg.Snapshot.NoDownloader = ctx.Bool(NoDownloaderFlag.Name)
 	cfg.Snapshot.Verify = ctx.Bool(DownloaderVerifyFlag.Name)
+	setSnapshotManifests(ctx, &cfg.Snapshot)
 	cfg.Snapshot.DownloaderAddr = strings.TrimSpace(ctx.String(DownloaderAddrFlag.Name))
 	cfg.Snapshot.ChainName = chain
 	nodeConfig.Http.Snap = cfg.Snapshot
@@ -1939,6 +2220,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 
 	setTxPool(ctx, nodeConfig.Dirs.TxPool, cfg)
 	setShutter(ctx, chain, nodeConfig, cfg)
+	setUserOps(ctx, nodeConfig, cfg)
 
 	setEthash(ctx, nodeConfig.Dirs.DataDir, cfg)
 	setClique(ctx, &cfg.Clique, nodeConfig.Dirs.DataDir)
@@ -2006,10 +2288,23 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		}
 	}
 
+	if ctx.IsSet(OverrideShanghaiFlag.Name) {
+		cfg.OverrideShanghaiTime = flags.GlobalBig(ctx, OverrideShanghaiFlag.Name)
+		cfg.TxPool.OverrideShanghaiTime = cfg.OverrideShanghaiTime
+	}
+	if ctx.IsSet(OverrideCancunFlag.Name) {
+		cfg.OverrideCancunTime = flags.GlobalBig(ctx, OverrideCancunFlag.Name)
+		cfg.TxPool.OverrideCancunTime = cfg.OverrideCancunTime
+	}
 	if ctx.IsSet(OverridePragueFlag.Name) {
 		cfg.OverridePragueTime = flags.GlobalBig(ctx, OverridePragueFlag.Name)
 		cfg.TxPool.OverridePragueTime = cfg.OverridePragueTime
 	}
+	if ctx.IsSet(OverrideOsakaFlag.Name) {
+		cfg.OverrideOsakaTime = flags.GlobalBig(ctx, OverrideOsakaFlag.Name)
+		cfg.TxPool.OverrideOsakaTime = cfg.OverrideOsakaTime
+	}
+	setOverrideEips(ctx, cfg)
 
 	if clparams.EmbeddedSupported(cfg.NetworkID) || cfg.CaplinConfig.IsDevnet() {
 		cfg.InternalCL = !ctx.Bool(ExternalConsensusFlag.Name)
@@ -2018,6 +2313,9 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	if ctx.IsSet(TrustedSetupFile.Name) {
 		libkzg.SetTrustedSetupFilePath(ctx.String(TrustedSetupFile.Name))
 	}
+	if err := sigrecover.Select(ctx.String(SigRecoverAcceleratorFlag.Name), logger); err != nil {
+		Fatalf("%v", err)
+	}
 
 	// Do this after chain config as there are chain type registration
 	// dependencies for know config which need to be set-up
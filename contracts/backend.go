@@ -52,7 +52,7 @@ func (b Backend) PendingNonceAt(ctx context.Context, account libcommon.Address)
 }
 
 func (b Backend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
-	price, err := b.api.GasPrice(ctx)
+	price, err := b.api.GasPrice(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
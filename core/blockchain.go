@@ -32,6 +32,7 @@ import (
 	"github.com/erigontech/erigon-lib/chain"
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/dbg"
+	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon-lib/common/math"
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon-lib/metrics"
@@ -78,6 +79,10 @@ type EphemeralExecResult struct {
 	Rejected         RejectedTxs           `json:"rejected,omitempty"`
 	Difficulty       *math.HexOrDecimal256 `json:"currentDifficulty" gencodec:"required"`
 	GasUsed          math.HexOrDecimal64   `json:"gasUsed"`
+	BlobGasUsed      *math.HexOrDecimal64  `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas    *math.HexOrDecimal64  `json:"currentExcessBlobGas,omitempty"`
+	RequestsHash     *libcommon.Hash       `json:"requestsHash,omitempty"`
+	Requests         []hexutil.Bytes       `json:"requests,omitempty"`
 	StateSyncReceipt *types.Receipt        `json:"-"`
 }
 
@@ -168,10 +173,11 @@ func ExecuteBlockEphemerally(
 		}
 	}
 	var newBlock *types.Block
+	var requests types.FlatRequests
 	var err error
 	if !vmConfig.ReadOnly {
 		txs := block.Transactions()
-		newBlock, _, _, _, err = FinalizeBlockExecution(engine, stateReader, block.Header(), txs, block.Uncles(), stateWriter, chainConfig, ibs, receipts, block.Withdrawals(), chainReader, true, logger)
+		newBlock, _, _, requests, err = FinalizeBlockExecution(engine, stateReader, block.Header(), txs, block.Uncles(), stateWriter, chainConfig, ibs, receipts, block.Withdrawals(), chainReader, true, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -189,6 +195,19 @@ func ExecuteBlockEphemerally(
 		GasUsed:     math.HexOrDecimal64(*usedGas),
 		Rejected:    rejectedTxs,
 	}
+	if chainConfig.IsCancun(header.Time) {
+		execRs.BlobGasUsed = (*math.HexOrDecimal64)(usedBlobGas)
+		if header.ExcessBlobGas != nil {
+			execRs.ExcessBlobGas = (*math.HexOrDecimal64)(header.ExcessBlobGas)
+		}
+	}
+	if chainConfig.IsPrague(header.Time) {
+		execRs.RequestsHash = requests.Hash()
+		execRs.Requests = make([]hexutil.Bytes, 0, len(requests))
+		for i := range requests {
+			execRs.Requests = append(execRs.Requests, requests[i].Encode())
+		}
+	}
 
 	if chainConfig.Bor != nil {
 		var logs []*types.Log
@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/chain/networkname"
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/datadir"
@@ -125,6 +126,29 @@ func TestCommitGenesisIdempotency(t *testing.T) {
 	require.Equal(t, uint64(2), seq)
 }
 
+func TestChainOverrides(t *testing.T) {
+	t.Parallel()
+	config := &chain.Config{
+		CancunTime:    big.NewInt(10),
+		ExtraEipTimes: map[int]*big.Int{1153: big.NewInt(5)},
+	}
+	overrides := &core.ChainOverrides{
+		PragueTime: big.NewInt(20),
+		OsakaTime:  big.NewInt(30),
+		EipTimes:   map[int]*big.Int{7516: big.NewInt(25)},
+	}
+	overrides.Apply(config)
+	require.Equal(t, big.NewInt(10), config.CancunTime)
+	require.Equal(t, big.NewInt(20), config.PragueTime)
+	require.Equal(t, big.NewInt(30), config.OsakaTime)
+	require.Equal(t, []int{1153}, config.ExtraEips(24))
+	require.Equal(t, []int{1153, 7516}, config.ExtraEips(25))
+
+	var noOverrides *core.ChainOverrides
+	noOverrides.Apply(config)
+	require.Equal(t, big.NewInt(20), config.PragueTime)
+}
+
 func TestAllocConstructor(t *testing.T) {
 	t.Parallel()
 	require := require.New(t)
@@ -73,13 +73,52 @@ func CommitGenesisBlock(db kv.RwDB, genesis *types.Genesis, dirs datadir.Dirs, l
 	return CommitGenesisBlockWithOverride(db, genesis, nil, dirs, logger)
 }
 
-func CommitGenesisBlockWithOverride(db kv.RwDB, genesis *types.Genesis, overridePragueTime *big.Int, dirs datadir.Dirs, logger log.Logger) (*chain.Config, *types.Block, error) {
+// ChainOverrides contains the changes to chain config, which are applied on top of the stored or bundled one.
+// Used to simulate upcoming forks (or individual EIPs) at custom time on any chain.
+type ChainOverrides struct {
+	ShanghaiTime *big.Int
+	CancunTime   *big.Int
+	PragueTime   *big.Int
+	OsakaTime    *big.Int
+	EipTimes     map[int]*big.Int // EIP number -> activation time, see vm.ActivateableEips
+}
+
+// Apply applies overrides to the chain config
+func (o *ChainOverrides) Apply(config *chain.Config) {
+	if o == nil {
+		return
+	}
+	if o.ShanghaiTime != nil {
+		config.ShanghaiTime = o.ShanghaiTime
+	}
+	if o.CancunTime != nil {
+		config.CancunTime = o.CancunTime
+	}
+	if o.PragueTime != nil {
+		config.PragueTime = o.PragueTime
+	}
+	if o.OsakaTime != nil {
+		config.OsakaTime = o.OsakaTime
+	}
+	if len(o.EipTimes) > 0 {
+		eipTimes := make(map[int]*big.Int, len(config.ExtraEipTimes)+len(o.EipTimes))
+		for eip, t := range config.ExtraEipTimes {
+			eipTimes[eip] = t
+		}
+		for eip, t := range o.EipTimes {
+			eipTimes[eip] = t
+		}
+		config.ExtraEipTimes = eipTimes
+	}
+}
+
+func CommitGenesisBlockWithOverride(db kv.RwDB, genesis *types.Genesis, overrides *ChainOverrides, dirs datadir.Dirs, logger log.Logger) (*chain.Config, *types.Block, error) {
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
 		return nil, nil, err
 	}
 	defer tx.Rollback()
-	c, b, err := WriteGenesisBlock(tx, genesis, overridePragueTime, dirs, logger)
+	c, b, err := WriteGenesisBlock(tx, genesis, overrides, dirs, logger)
 	if err != nil {
 		return c, b, err
 	}
@@ -90,7 +129,7 @@ func CommitGenesisBlockWithOverride(db kv.RwDB, genesis *types.Genesis, override
 	return c, b, nil
 }
 
-func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overridePragueTime *big.Int, dirs datadir.Dirs, logger log.Logger) (*chain.Config, *types.Block, error) {
+func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrides *ChainOverrides, dirs datadir.Dirs, logger log.Logger) (*chain.Config, *types.Block, error) {
 	if err := rawdb.WriteGenesisIfNotExist(tx, genesis); err != nil {
 		return nil, nil, err
 	}
@@ -105,12 +144,6 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overridePragueTime *b
 		return nil, nil, storedErr
 	}
 
-	applyOverrides := func(config *chain.Config) {
-		if overridePragueTime != nil {
-			config.PragueTime = overridePragueTime
-		}
-	}
-
 	if (storedHash == libcommon.Hash{}) {
 		custom := true
 		if genesis == nil {
@@ -118,7 +151,7 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overridePragueTime *b
 			genesis = MainnetGenesisBlock()
 			custom = false
 		}
-		applyOverrides(genesis.Config)
+		overrides.Apply(genesis.Config)
 		block, _, err1 := write(tx, genesis, dirs, logger)
 		if err1 != nil {
 			return genesis.Config, nil, err1
@@ -150,7 +183,7 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overridePragueTime *b
 	}
 	// Get the existing chain configuration.
 	newCfg := genesis.ConfigOrDefault(storedHash)
-	applyOverrides(newCfg)
+	overrides.Apply(newCfg)
 	if err := newCfg.CheckConfigForkOrder(); err != nil {
 		return newCfg, nil, err
 	}
@@ -171,7 +204,7 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overridePragueTime *b
 	// In that case, only apply the overrides.
 	if genesis == nil && params.ChainConfigByGenesisHash(storedHash) == nil {
 		newCfg = storedCfg
-		applyOverrides(newCfg)
+		overrides.Apply(newCfg)
 	}
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
@@ -0,0 +1,43 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+// ReadPreimage retrieves a single preimage of the provided hash.
+func ReadPreimage(db kv.Getter, hash libcommon.Hash) ([]byte, error) {
+	data, err := db.GetOne(kv.PreimagePrefix, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preimage: %w", err)
+	}
+	return data, nil
+}
+
+// WritePreimages writes the provided set of preimages to the database.
+func WritePreimages(db kv.Putter, preimages map[libcommon.Hash][]byte) error {
+	for hash, preimage := range preimages {
+		if err := db.Put(kv.PreimagePrefix, hash[:], preimage); err != nil {
+			return fmt.Errorf("failed to store preimage: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/kv/memdb"
+	"github.com/erigontech/erigon/core/rawdb"
+)
+
+func TestPreimageStorage(t *testing.T) {
+	t.Parallel()
+	_, tx := memdb.NewTestTx(t)
+
+	addr := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	slot := libcommon.HexToHash("0x01")
+	require.NoError(t, rawdb.WritePreimages(tx, map[libcommon.Hash][]byte{
+		crypto.Keccak256Hash(addr[:]): addr[:],
+		crypto.Keccak256Hash(slot[:]): slot[:],
+	}))
+
+	preimage, err := rawdb.ReadPreimage(tx, crypto.Keccak256Hash(addr[:]))
+	require.NoError(t, err)
+	require.Equal(t, addr[:], preimage)
+	preimage, err = rawdb.ReadPreimage(tx, crypto.Keccak256Hash(slot[:]))
+	require.NoError(t, err)
+	require.Equal(t, slot[:], preimage)
+
+	preimage, err = rawdb.ReadPreimage(tx, libcommon.Hash{1})
+	require.NoError(t, err)
+	require.Nil(t, preimage)
+}
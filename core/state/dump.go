@@ -20,13 +20,15 @@
 package state
 
 import (
+	"bytes"
+	"container/heap"
 	"encoding/json"
 	"errors"
 	"fmt"
 
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
-	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/common/length"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/order"
 	"github.com/erigontech/erigon-lib/kv/rawdbv3"
@@ -137,28 +139,25 @@ func NewDumper(db kv.TemporalTx, txNumsReader rawdbv3.TxNumsReader, blockNumber
 
 var TooMuchIterations = errors.New("[rpc] dumper: too much iterations protection triggered")
 
+// MaxScanByHash - default max amount of accounts DumpToCollectorByHash scans per call. Each page requires full scan of
+// the accounts domain, larger domains are scanned over several calls resumed by the cursor returned as the next key.
+const MaxScanByHash = 1_000_000
+
 func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bool, startAddress libcommon.Address, maxResults int) ([]byte, error) {
-	var emptyCodeHash = crypto.Keccak256Hash(nil)
-	var emptyHash = libcommon.Hash{}
 	var accountList []*DumpAccount
 	var addrList []libcommon.Address
-	var acc accounts.Account
 	var numberOfResults int
 	if maxResults == 0 {
 		maxResults = kv.Unlim
 	}
 
-	c.OnRoot(emptyHash) // We do not calculate the root
+	c.OnRoot(libcommon.Hash{}) // We do not calculate the root
 
 	ttx := d.tx
 	txNum, err := d.txNumsReader.Min(ttx, d.blockNumber+1)
 	if err != nil {
 		return nil, err
 	}
-	txNumForStorage, err := d.txNumsReader.Min(ttx, d.blockNumber+1)
-	if err != nil {
-		return nil, err
-	}
 
 	var nextKey []byte
 	it, err := ttx.RangeAsOf(kv.AccountsDomain, startAddress[:], nil, txNum, order.Asc, kv.Unlim) //unlim because need skip empty vals
@@ -179,30 +178,11 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 			break
 		}
 
-		if e := accounts.DeserialiseV3(&acc, v); e != nil {
-			return nil, fmt.Errorf("decoding %x for %x: %w", v, k, e)
-		}
-		account := DumpAccount{
-			Balance:  acc.Balance.ToBig().String(),
-			Nonce:    acc.Nonce,
-			Root:     hexutil.Bytes(emptyHash[:]), // We cannot provide historical storage hash
-			CodeHash: hexutil.Bytes(emptyCodeHash[:]),
-			Storage:  make(map[string]string),
-		}
-		if acc.CodeHash != emptyCodeHash {
-			account.CodeHash = hexutil.Bytes(acc.CodeHash.Bytes())
-
-			if !excludeCode {
-				r, _, err := ttx.GetLatest(kv.CodeDomain, k)
-				if err != nil {
-					return nil, err
-				}
-				if r != nil {
-					account.Code = r
-				}
-			}
+		account, err := d.dumpAccount(k, v, txNum, excludeCode)
+		if err != nil {
+			return nil, err
 		}
-		accountList = append(accountList, &account)
+		accountList = append(accountList, account)
 		addrList = append(addrList, libcommon.BytesToAddress(k))
 
 		numberOfResults++
@@ -212,29 +192,9 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 	for i, addr := range addrList {
 		account := accountList[i]
 		if !excludeStorage {
-			t := trie.New(libcommon.Hash{})
-			nextAcc, _ := kv.NextSubtree(addr[:])
-			r, err := ttx.RangeAsOf(kv.StorageDomain, addr[:], nextAcc, txNumForStorage, order.Asc, kv.Unlim) //unlim because need skip empty vals
-			if err != nil {
-				return nil, fmt.Errorf("walking over storage for %x: %w", addr, err)
+			if err := d.dumpStorage(addr, account, txNum); err != nil {
+				return nil, err
 			}
-			defer r.Close()
-			for r.HasNext() {
-				k, vs, err := r.Next()
-				if err != nil {
-					return nil, fmt.Errorf("walking over storage for %x: %w", addr, err)
-				}
-				if len(vs) == 0 {
-					continue // Skip deleted entries
-				}
-				loc := k[20:]
-				account.Storage[libcommon.BytesToHash(loc).String()] = libcommon.Bytes2Hex(vs)
-				h, _ := libcommon.HashData(loc)
-				t.Update(h.Bytes(), libcommon.Copy(vs))
-			}
-			r.Close()
-
-			account.Root = t.Hash().Bytes()
 		}
 		c.OnAccount(addr, *account)
 	}
@@ -242,6 +202,233 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 	return nextKey, nil
 }
 
+type hashedAccount struct {
+	hash libcommon.Hash
+	addr libcommon.Address
+	v    []byte
+}
+
+// hashedAccountsHeap - max-heap by hash, keeps N smallest hashes seen so far
+type hashedAccountsHeap []hashedAccount
+
+func (h hashedAccountsHeap) Len() int           { return len(h) }
+func (h hashedAccountsHeap) Less(i, j int) bool { return bytes.Compare(h[i].hash[:], h[j].hash[:]) > 0 }
+func (h hashedAccountsHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *hashedAccountsHeap) Push(x any)        { *h = append(*h, x.(hashedAccount)) }
+func (h *hashedAccountsHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// byHashCursor is the state of the scan of DumpToCollectorByHash resumed by the next call, encoded as
+// start hash | address to resume the scan from | addresses of the smallest hashes found so far
+type byHashCursor struct {
+	start      libcommon.Hash
+	from       libcommon.Address
+	candidates []libcommon.Address
+}
+
+// IsByHashCursor reports whether the key is the cursor of an unfinished scan returned by DumpToCollectorByHash
+func IsByHashCursor(key []byte) bool {
+	return len(key) >= length.Hash+length.Addr && (len(key)-length.Hash)%length.Addr == 0
+}
+
+func (c *byHashCursor) encode() []byte {
+	res := make([]byte, 0, length.Hash+length.Addr*(len(c.candidates)+1))
+	res = append(res, c.start[:]...)
+	res = append(res, c.from[:]...)
+	for _, addr := range c.candidates {
+		res = append(res, addr[:]...)
+	}
+	return res
+}
+
+func decodeByHashCursor(key []byte) *byHashCursor {
+	c := &byHashCursor{start: libcommon.BytesToHash(key[:length.Hash]), from: libcommon.BytesToAddress(key[length.Hash : length.Hash+length.Addr])}
+	for rest := key[length.Hash+length.Addr:]; len(rest) > 0; rest = rest[length.Addr:] {
+		c.candidates = append(c.candidates, libcommon.BytesToAddress(rest[:length.Addr]))
+	}
+	return c
+}
+
+// DumpToCollectorByHash - same as DumpToCollector, but accounts are ordered by keccak256(address) (as in geth's
+// secure trie) and pagination starts from `start` hash. Erigon stores state by plain address - so whole accounts
+// domain is scanned, only `maxResults` smallest hashes are kept in memory. A call scans at most `maxScan`
+// accounts: if the scan is not finished, no accounts are collected and the cursor to resume it is returned instead
+// of the next key, `start` is either a hash or such a cursor.
+// Returns hash of the first account which didn't fit into the result, nil if no more accounts.
+func (d *Dumper) DumpToCollectorByHash(c DumpCollector, excludeCode, excludeStorage bool, start []byte, maxResults, maxScan int) ([]byte, error) {
+	if maxResults <= 0 {
+		return nil, errors.New("dumper: maxResults must be positive when iterating by hash")
+	}
+	if maxScan <= 0 {
+		return nil, errors.New("dumper: maxScan must be positive")
+	}
+	cursor := &byHashCursor{start: libcommon.BytesToHash(start)}
+	if IsByHashCursor(start) {
+		cursor = decodeByHashCursor(start)
+		if len(cursor.candidates) > maxResults+1 {
+			return nil, fmt.Errorf("dumper: cursor of %d accounts, more than maxResults %d", len(cursor.candidates), maxResults)
+		}
+	}
+	c.OnRoot(libcommon.Hash{}) // We do not calculate the root
+
+	ttx := d.tx
+	txNum, err := d.txNumsReader.Min(ttx, d.blockNumber+1)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep one extra element to know the key of next page
+	h := make(hashedAccountsHeap, 0, maxResults+1)
+	push := func(k, v []byte) error {
+		addrHash, err := libcommon.HashData(k)
+		if err != nil {
+			return err
+		}
+		if bytes.Compare(addrHash[:], cursor.start[:]) < 0 {
+			return nil
+		}
+		if h.Len() == maxResults+1 {
+			if bytes.Compare(addrHash[:], h[0].hash[:]) >= 0 {
+				return nil
+			}
+			heap.Pop(&h)
+		}
+		heap.Push(&h, hashedAccount{hash: addrHash, addr: libcommon.BytesToAddress(k), v: libcommon.Copy(v)})
+		return nil
+	}
+	for _, addr := range cursor.candidates {
+		v, _, err := ttx.GetAsOf(kv.AccountsDomain, addr[:], txNum)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			continue
+		}
+		if err := push(addr[:], v); err != nil {
+			return nil, err
+		}
+	}
+	var from []byte
+	if IsByHashCursor(start) {
+		from = cursor.from[:]
+	}
+	it, err := ttx.RangeAsOf(kv.AccountsDomain, from, nil, txNum, order.Asc, kv.Unlim) //unlim because need skip empty vals
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	for i := 0; it.HasNext(); i++ {
+		k, v, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if i >= maxScan {
+			cursor.from, cursor.candidates = libcommon.BytesToAddress(k), make([]libcommon.Address, 0, h.Len())
+			for _, item := range h {
+				cursor.candidates = append(cursor.candidates, item.addr)
+			}
+			return cursor.encode(), nil
+		}
+		if len(v) == 0 {
+			continue
+		}
+		if err := push(k, v); err != nil {
+			return nil, err
+		}
+	}
+	it.Close()
+
+	sorted := make([]hashedAccount, h.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(&h).(hashedAccount)
+	}
+	var nextKey []byte
+	if len(sorted) > maxResults {
+		nextKey = libcommon.Copy(sorted[maxResults].hash[:])
+		sorted = sorted[:maxResults]
+	}
+
+	for _, item := range sorted {
+		account, err := d.dumpAccount(item.addr[:], item.v, txNum, excludeCode)
+		if err != nil {
+			return nil, err
+		}
+		secureKey := hexutil.Bytes(libcommon.Copy(item.hash[:]))
+		account.SecureKey = &secureKey
+		if !excludeStorage {
+			if err := d.dumpStorage(item.addr, account, txNum); err != nil {
+				return nil, err
+			}
+		}
+		c.OnAccount(item.addr, *account)
+	}
+	return nextKey, nil
+}
+
+func (d *Dumper) dumpAccount(k, v []byte, txNum uint64, excludeCode bool) (*DumpAccount, error) {
+	var acc accounts.Account
+	if e := accounts.DeserialiseV3(&acc, v); e != nil {
+		return nil, fmt.Errorf("decoding %x for %x: %w", v, k, e)
+	}
+	account := &DumpAccount{
+		Balance:  acc.Balance.ToBig().String(),
+		Nonce:    acc.Nonce,
+		Root:     hexutil.Bytes(libcommon.Hash{}.Bytes()), // We cannot provide historical storage hash
+		CodeHash: hexutil.Bytes(emptyCodeHashH.Bytes()),
+		Storage:  make(map[string]string),
+	}
+	if acc.CodeHash == emptyCodeHashH {
+		return account, nil
+	}
+	account.CodeHash = hexutil.Bytes(acc.CodeHash.Bytes())
+	if excludeCode {
+		return account, nil
+	}
+	code, ok, err := d.tx.GetAsOf(kv.CodeDomain, k, txNum)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if code, _, err = d.tx.GetLatest(kv.CodeDomain, k); err != nil {
+			return nil, err
+		}
+	}
+	if code != nil {
+		account.Code = code
+	}
+	return account, nil
+}
+
+func (d *Dumper) dumpStorage(addr libcommon.Address, account *DumpAccount, txNum uint64) error {
+	t := trie.New(libcommon.Hash{})
+	nextAcc, _ := kv.NextSubtree(addr[:])
+	r, err := d.tx.RangeAsOf(kv.StorageDomain, addr[:], nextAcc, txNum, order.Asc, kv.Unlim) //unlim because need skip empty vals
+	if err != nil {
+		return fmt.Errorf("walking over storage for %x: %w", addr, err)
+	}
+	defer r.Close()
+	for r.HasNext() {
+		k, vs, err := r.Next()
+		if err != nil {
+			return fmt.Errorf("walking over storage for %x: %w", addr, err)
+		}
+		if len(vs) == 0 {
+			continue // Skip deleted entries
+		}
+		loc := k[20:]
+		account.Storage[libcommon.BytesToHash(loc).String()] = libcommon.Bytes2Hex(vs)
+		h, _ := libcommon.HashData(loc)
+		t.Update(h.Bytes(), libcommon.Copy(vs))
+	}
+	account.Root = t.Hash().Bytes()
+	return nil
+}
+
 // RawDump returns the entire state an a single large object
 func (d *Dumper) RawDump(excludeCode, excludeStorage bool) Dump {
 	dump := &Dump{
@@ -278,6 +465,16 @@ func (d *Dumper) IteratorDump(excludeCode, excludeStorage bool, start libcommon.
 	return *iterator, err
 }
 
+// IteratorDumpByHash dumps out a batch of accounts ordered by address hash, starting with the given start hash
+func (d *Dumper) IteratorDumpByHash(excludeCode, excludeStorage bool, start []byte, maxResults, maxScan int) (IteratorDump, error) {
+	iterator := &IteratorDump{
+		Accounts: make(map[libcommon.Address]DumpAccount),
+	}
+	var err error
+	iterator.Next, err = d.DumpToCollectorByHash(iterator, excludeCode, excludeStorage, start, maxResults, maxScan)
+	return *iterator, err
+}
+
 func (d *Dumper) DefaultRawDump() Dump {
 	return d.RawDump(false, false)
 }
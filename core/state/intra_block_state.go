@@ -934,6 +934,34 @@ func (sdb *IntraBlockState) BalanceIncreaseSet() map[libcommon.Address]uint256.I
 	return s
 }
 
+// Preimages returns keccak256 preimages of account addresses and storage keys touched (read or written) since the
+// last Reset, including non-existent accounts
+func (sdb *IntraBlockState) Preimages() map[libcommon.Hash][]byte {
+	preimages := make(map[libcommon.Hash][]byte, len(sdb.stateObjects)+len(sdb.nilAccounts))
+	addAddr := func(addr libcommon.Address) {
+		preimages[crypto.Keccak256Hash(addr[:])] = libcommon.CopyBytes(addr[:])
+	}
+	addKey := func(key libcommon.Hash) {
+		preimages[crypto.Keccak256Hash(key[:])] = libcommon.CopyBytes(key[:])
+	}
+	for addr, so := range sdb.stateObjects {
+		addAddr(addr)
+		for key := range so.originStorage {
+			addKey(key)
+		}
+		for key := range so.dirtyStorage {
+			addKey(key)
+		}
+	}
+	for addr := range sdb.nilAccounts {
+		addAddr(addr)
+	}
+	for addr := range sdb.balanceInc {
+		addAddr(addr)
+	}
+	return preimages
+}
+
 func (sdb *IntraBlockState) MakeWriteSet(chainRules *chain.Rules, stateWriter StateWriter) error {
 	for addr := range sdb.journal.dirties {
 		sdb.stateObjectsDirty[addr] = struct{}{}
@@ -61,6 +61,7 @@ type TxTask struct {
 	EvmBlockContext evmtypes.BlockContext
 
 	HistoryExecution bool // use history reader for that txn instead of state reader
+	RecordPreimages  bool // collect keccak256 preimages of touched addresses and storage keys into Preimages
 
 	BalanceIncreaseSet map[libcommon.Address]uint256.Int
 	ReadLists          map[string]*state.KvList
@@ -73,6 +74,7 @@ type TxTask struct {
 	Logs               []*types.Log
 	TraceFroms         map[libcommon.Address]struct{}
 	TraceTos           map[libcommon.Address]struct{}
+	Preimages          map[libcommon.Hash][]byte
 
 	UsedGas uint64
 
@@ -164,6 +166,7 @@ func (t *TxTask) createReceipt(cumulativeGasUsed uint64) *types.Receipt {
 	//}
 	return receipt
 }
+
 func (t *TxTask) Reset() *TxTask {
 	t.BalanceIncreaseSet = nil
 	returnReadList(t.ReadLists)
@@ -173,6 +176,7 @@ func (t *TxTask) Reset() *TxTask {
 	t.Logs = nil
 	t.TraceFroms = nil
 	t.TraceTos = nil
+	t.Preimages = nil
 	t.Error = nil
 	t.Failed = false
 	return t
@@ -95,6 +95,19 @@ func (stx *BlobTx) AsMessage(s Signer, baseFee *big.Int, rules *chain.Rules) (*M
 	return &msg, err
 }
 
+func (stx *BlobTx) WithSignature(signer Signer, sig []byte) (Transaction, error) {
+	cpy := stx.copy()
+	r, s, v, err := signer.SignatureValues(stx, sig)
+	if err != nil {
+		return nil, err
+	}
+	cpy.R.Set(r)
+	cpy.S.Set(s)
+	cpy.V.Set(v)
+	cpy.ChainID = signer.ChainID()
+	return cpy, nil
+}
+
 func (stx *BlobTx) cachedSender() (sender libcommon.Address, ok bool) {
 	s := stx.from.Load()
 	if s == nil {
@@ -33,6 +33,7 @@ import (
 	libcommon "github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/u256"
 	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/crypto/sigrecover"
 )
 
 var ErrInvalidChainId = errors.New("invalid chain id for signer")
@@ -217,6 +218,45 @@ func (sg Signer) Sender(tx Transaction) (libcommon.Address, error) {
 
 // SenderWithContext returns the sender address of the transaction.
 func (sg Signer) SenderWithContext(context *secp256k1.Context, txn Transaction) (libcommon.Address, error) {
+	sighash, sig, err := sg.senderSignature(txn)
+	if err != nil {
+		return libcommon.Address{}, err
+	}
+	pub, err := crypto.EcrecoverWithContext(context, sighash[:], sig[:])
+	if err != nil {
+		return libcommon.Address{}, err
+	}
+	return pubToAddress(pub)
+}
+
+// SendersWithContext returns the sender addresses of the transactions, recovered in one batch which is offloaded
+// to the signature recovery accelerator if one is selected (see sigrecover.Select). errs[i] is set for the
+// transactions whose sender can't be recovered, context is used for the recovery on the CPU.
+func (sg Signer) SendersWithContext(context *secp256k1.Context, txns []Transaction) (senders []libcommon.Address, errs []error) {
+	senders, errs = make([]libcommon.Address, len(txns)), make([]error, len(txns))
+	hashes, sigs := make([][32]byte, 0, len(txns)), make([][65]byte, 0, len(txns))
+	indexes := make([]int, 0, len(txns)) // of the transactions in the batch
+	for i, txn := range txns {
+		sighash, sig, err := sg.senderSignature(txn)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		hashes, sigs, indexes = append(hashes, sighash), append(sigs, sig), append(indexes, i)
+	}
+	pubs, recoverErrs := sigrecover.Recover(context, hashes, sigs)
+	for j, i := range indexes {
+		if recoverErrs[j] != nil {
+			errs[i] = recoverErrs[j]
+			continue
+		}
+		senders[i], errs[i] = pubToAddress(pubs[j][:])
+	}
+	return senders, errs
+}
+
+// senderSignature returns the hash and the signature (r || s || v, v is 0 or 1) the sender is recovered from
+func (sg Signer) senderSignature(txn Transaction) (libcommon.Hash, [crypto.SignatureLength]byte, error) {
 	var V uint256.Int
 	var R, S *uint256.Int
 	signChainID := sg.chainID.ToBig() // This is reset to nil if txn is unprotected
@@ -225,16 +265,16 @@ func (sg Signer) SenderWithContext(context *secp256k1.Context, txn Transaction)
 	case *LegacyTx:
 		if !t.Protected() {
 			if !sg.unprotected {
-				return libcommon.Address{}, fmt.Errorf("unprotected txn is not supported by signer %s", sg)
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, fmt.Errorf("unprotected txn is not supported by signer %s", sg)
 			}
 			signChainID = nil
 			V.Set(&t.V)
 		} else {
 			if !sg.protected {
-				return libcommon.Address{}, fmt.Errorf("protected txn is not supported by signer %s", sg)
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, fmt.Errorf("protected txn is not supported by signer %s", sg)
 			}
 			if !DeriveChainId(&t.V).Eq(&sg.chainID) {
-				return libcommon.Address{}, ErrInvalidChainId
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 			}
 			V.Sub(&t.V, &sg.chainIDMul)
 			V.Sub(&V, u256.Num8)
@@ -242,14 +282,14 @@ func (sg Signer) SenderWithContext(context *secp256k1.Context, txn Transaction)
 		R, S = &t.R, &t.S
 	case *AccessListTx:
 		if !sg.accessList {
-			return libcommon.Address{}, fmt.Errorf("accessList txn is not supported by signer %s", sg)
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, fmt.Errorf("accessList txn is not supported by signer %s", sg)
 		}
 		if t.ChainID == nil {
 			if !sg.chainID.IsZero() {
-				return libcommon.Address{}, ErrInvalidChainId
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 			}
 		} else if !t.ChainID.Eq(&sg.chainID) {
-			return libcommon.Address{}, ErrInvalidChainId
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 		}
 		// ACL txs are defined to use 0 and 1 as their recovery id, add
 		// 27 to become equivalent to unprotected Homestead signatures.
@@ -257,14 +297,14 @@ func (sg Signer) SenderWithContext(context *secp256k1.Context, txn Transaction)
 		R, S = &t.R, &t.S
 	case *DynamicFeeTransaction:
 		if !sg.dynamicFee {
-			return libcommon.Address{}, fmt.Errorf("dynamicFee txn is not supported by signer %s", sg)
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, fmt.Errorf("dynamicFee txn is not supported by signer %s", sg)
 		}
 		if t.ChainID == nil {
 			if !sg.chainID.IsZero() {
-				return libcommon.Address{}, ErrInvalidChainId
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 			}
 		} else if !t.ChainID.Eq(&sg.chainID) {
-			return libcommon.Address{}, ErrInvalidChainId
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 		}
 		// ACL and DynamicFee txs are defined to use 0 and 1 as their recovery
 		// id, add 27 to become equivalent to unprotected Homestead signatures.
@@ -272,14 +312,14 @@ func (sg Signer) SenderWithContext(context *secp256k1.Context, txn Transaction)
 		R, S = &t.R, &t.S
 	case *BlobTx:
 		if !sg.blob {
-			return libcommon.Address{}, fmt.Errorf("blob txn is not supported by signer %s", sg)
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, fmt.Errorf("blob txn is not supported by signer %s", sg)
 		}
 		if t.ChainID == nil {
 			if !sg.chainID.IsZero() {
-				return libcommon.Address{}, ErrInvalidChainId
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 			}
 		} else if !t.ChainID.Eq(&sg.chainID) {
-			return libcommon.Address{}, ErrInvalidChainId
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 		}
 		// ACL, DynamicFee, and blob txs are defined to use 0 and 1 as their recovery
 		// id, add 27 to become equivalent to unprotected Homestead signatures.
@@ -287,23 +327,24 @@ func (sg Signer) SenderWithContext(context *secp256k1.Context, txn Transaction)
 		R, S = &t.R, &t.S
 	case *SetCodeTransaction:
 		if !sg.setCode {
-			return libcommon.Address{}, fmt.Errorf("setCode tx is not supported by signer %s", sg)
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, fmt.Errorf("setCode tx is not supported by signer %s", sg)
 		}
 		if t.ChainID == nil {
 			if !sg.chainID.IsZero() {
-				return libcommon.Address{}, ErrInvalidChainId
+				return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 			}
 		} else if !t.ChainID.Eq(&sg.chainID) {
-			return libcommon.Address{}, ErrInvalidChainId
+			return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrInvalidChainId
 		}
 		// ACL, DynamicFee, blob, and setCode txs are defined to use 0 and 1 as their recovery
 		// id, add 27 to become equivalent to unprotected Homestead signatures.
 		V.Add(&t.V, u256.Num27)
 		R, S = &t.R, &t.S
 	default:
-		return libcommon.Address{}, ErrTxTypeNotSupported
+		return libcommon.Hash{}, [crypto.SignatureLength]byte{}, ErrTxTypeNotSupported
 	}
-	return recoverPlain(context, txn.SigningHash(signChainID), R, S, &V, !sg.malleable)
+	sig, err := plainSignature(R, S, &V, !sg.malleable)
+	return txn.SigningHash(signChainID), sig, err
 }
 
 // SignatureValues returns the raw R, S, V values corresponding to the
@@ -358,25 +399,24 @@ func decodeSignature(sig []byte) (r, s, v *uint256.Int) {
 	return r, s, v
 }
 
-func recoverPlain(context *secp256k1.Context, sighash libcommon.Hash, R, S, Vb *uint256.Int, homestead bool) (libcommon.Address, error) {
+// plainSignature encodes the signature in the format used for the recovery, r || s || v
+func plainSignature(R, S, Vb *uint256.Int, homestead bool) ([crypto.SignatureLength]byte, error) {
+	var sig [crypto.SignatureLength]byte
 	if Vb.BitLen() > 8 {
-		return libcommon.Address{}, ErrInvalidSig
+		return sig, ErrInvalidSig
 	}
 	V := byte(Vb.Uint64() - 27)
 	if !crypto.TransactionSignatureIsValid(V, R, S, !homestead) {
-		return libcommon.Address{}, ErrInvalidSig
+		return sig, ErrInvalidSig
 	}
-	// encode the signature in uncompressed format
 	r, s := R.Bytes(), S.Bytes()
-	sig := make([]byte, crypto.SignatureLength)
 	copy(sig[32-len(r):32], r)
 	copy(sig[64-len(s):64], s)
 	sig[64] = V
-	// recover the public key from the signature
-	pub, err := crypto.EcrecoverWithContext(context, sighash[:], sig)
-	if err != nil {
-		return libcommon.Address{}, err
-	}
+	return sig, nil
+}
+
+func pubToAddress(pub []byte) (libcommon.Address, error) {
 	if len(pub) == 0 || pub[0] != 4 {
 		return libcommon.Address{}, errors.New("invalid public key")
 	}
@@ -729,6 +729,39 @@ func TestBlobTxEncodeDecode(t *testing.T) {
 	}
 }
 
+func TestBlobTxWithSignature(t *testing.T) {
+	key, _ := crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	signer := LatestSignerForChainID(big.NewInt(1))
+	tx := &BlobTx{
+		DynamicFeeTransaction: DynamicFeeTransaction{
+			CommonTx: CommonTx{GasLimit: 21000, To: randAddr(), Value: uint256.NewInt(1)},
+			ChainID:  uint256.NewInt(1),
+			TipCap:   uint256.NewInt(1),
+			FeeCap:   uint256.NewInt(10),
+		},
+		MaxFeePerBlobGas:    uint256.NewInt(16),
+		BlobVersionedHashes: []libcommon.Hash{{0x01}},
+	}
+	signed, err := SignTx(tx, *signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobTx, ok := signed.(*BlobTx)
+	if !ok {
+		t.Fatalf("wrong signed tx type %T", signed)
+	}
+	if !blobTx.MaxFeePerBlobGas.Eq(tx.MaxFeePerBlobGas) || len(blobTx.BlobVersionedHashes) != 1 {
+		t.Fatalf("blob fields lost on signing")
+	}
+	from, err := blobTx.Sender(*signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey); from != want {
+		t.Fatalf("wrong sender, have %x, want %x", from, want)
+	}
+}
+
 func TestShortUnwrap(t *testing.T) {
 	blobTxRlp, _ := MakeBlobTxnRlp()
 	shortRlp, err := UnwrapTxPlayloadRlp(blobTxRlp)
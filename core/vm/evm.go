@@ -108,6 +108,7 @@ func NewEVM(blockCtx evmtypes.BlockContext, txCtx evmtypes.TxContext, state evmt
 			blockCtx.BaseFee = new(uint256.Int)
 		}
 	}
+	vmConfig = vmConfig.withScheduledEips(chainConfig, blockCtx.Time)
 	evm := &EVM{
 		Context:         blockCtx,
 		TxContext:       txCtx,
@@ -139,6 +140,7 @@ func (evm *EVM) ResetBetweenBlocks(blockCtx evmtypes.BlockContext, txCtx evmtype
 			blockCtx.BaseFee = new(uint256.Int)
 		}
 	}
+	vmConfig = vmConfig.withScheduledEips(evm.chainConfig, blockCtx.Time)
 	evm.Context = blockCtx
 	evm.TxContext = txCtx
 	evm.intraBlockState = ibs
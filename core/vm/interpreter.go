@@ -58,6 +58,23 @@ var pool = sync.Pool{
 	},
 }
 
+// withScheduledEips returns copy of config with EIPs individually scheduled by chain config (see --override.eips) added
+func (vmConfig *Config) withScheduledEips(chainConfig *chain.Config, time uint64) Config {
+	cfg := *vmConfig
+	scheduled := chainConfig.ExtraEips(time)
+	if len(scheduled) == 0 {
+		return cfg
+	}
+	cfg.ExtraEips = make([]int, 0, len(vmConfig.ExtraEips)+len(scheduled))
+	cfg.ExtraEips = append(cfg.ExtraEips, vmConfig.ExtraEips...)
+	for _, eip := range scheduled {
+		if !slices.Contains(cfg.ExtraEips, eip) {
+			cfg.ExtraEips = append(cfg.ExtraEips, eip)
+		}
+	}
+	return cfg
+}
+
 func (vmConfig *Config) HasEip3860(rules *chain.Rules) bool {
 	return slices.Contains(vmConfig.ExtraEips, 3860) || rules.IsShanghai
 }
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"sort"
 	"strconv"
 	"time"
 
@@ -70,6 +71,10 @@ type Config struct {
 	PragueTime   *big.Int `json:"pragueTime,omitempty"`
 	OsakaTime    *big.Int `json:"osakaTime,omitempty"`
 
+	// (Optional) individually scheduled EIPs: EIP number -> activation time.
+	// Only EIPs which can be activated on top of any fork are supported (see vm.ActivateableEips)
+	ExtraEipTimes map[int]*big.Int `json:"extraEipTimes,omitempty"`
+
 	// Optional EIP-4844 parameters (see also EIP-7691 & EIP-7840)
 	MinBlobGasPrice *uint64       `json:"minBlobGasPrice,omitempty"`
 	BlobSchedule    *BlobSchedule `json:"blobSchedule,omitempty"`
@@ -304,6 +309,21 @@ func (c *Config) IsOsaka(time uint64) bool {
 	return isForked(c.OsakaTime, time)
 }
 
+// ExtraEips returns sorted list of individually scheduled EIPs which are active at given time
+func (c *Config) ExtraEips(time uint64) []int {
+	if len(c.ExtraEipTimes) == 0 {
+		return nil
+	}
+	var eips []int
+	for eip, activation := range c.ExtraEipTimes {
+		if isForked(activation, time) {
+			eips = append(eips, eip)
+		}
+	}
+	sort.Ints(eips)
+	return eips
+}
+
 func (c *Config) GetBurntContract(num uint64) *common.Address {
 	if len(c.BurntContract) == 0 {
 		return nil
@@ -17,6 +17,7 @@
 package chain
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -82,3 +83,18 @@ func TestNilBlobSchedule(t *testing.T) {
 	assert.Equal(t, uint64(9), b.MaxBlobsPerBlock(isPrague))
 	assert.Equal(t, uint64(5007716), b.BaseFeeUpdateFraction(isPrague))
 }
+
+func TestExtraEips(t *testing.T) {
+	c := &Config{}
+	assert.Empty(t, c.ExtraEips(100))
+
+	c.ExtraEipTimes = map[int]*big.Int{
+		7516: big.NewInt(20),
+		1153: big.NewInt(10),
+		5656: big.NewInt(30),
+	}
+	assert.Empty(t, c.ExtraEips(9))
+	assert.Equal(t, []int{1153}, c.ExtraEips(10))
+	assert.Equal(t, []int{1153, 7516}, c.ExtraEips(29))
+	assert.Equal(t, []int{1153, 5656, 7516}, c.ExtraEips(30))
+}
@@ -53,7 +53,7 @@ var (
 	// force skipping of any non-Erigon2 .torrent files
 	DownloaderOnlyBlocks = EnvBool("DOWNLOADER_ONLY_BLOCKS", false)
 
-	// allows to collect reading metrics for kv by file level
+	// allows to collect reading metrics for kv by file level, and of domain reads by tier (db, files cache, file level)
 	KVReadLevelledMetrics = EnvBool("KV_READ_METRICS", false)
 
 	// run prune on flush with given timeout. If timeout is 0, no prune on flush will be performed
@@ -0,0 +1,34 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package kzg
+
+import (
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+)
+
+// Backend verifies KZG proofs, implemented by the go-kzg-4844 context
+type Backend interface {
+	VerifyKZGProof(commitment gokzg4844.KZGCommitment, point, claim gokzg4844.Scalar, proof gokzg4844.KZGProof) error
+	VerifyBlobKZGProof(blob gokzg4844.BlobRef, commitment gokzg4844.KZGCommitment, proof gokzg4844.KZGProof) error
+	// VerifyBlobKZGProofBatch verifies proofs of many blobs at once, which is much cheaper than one by one
+	VerifyBlobKZGProofBatch(blobs []gokzg4844.BlobRef, commitments []gokzg4844.KZGCommitment, proofs []gokzg4844.KZGProof) error
+}
+
+// Verifier returns the backend verifying the proofs
+func Verifier() Backend {
+	return Ctx()
+}
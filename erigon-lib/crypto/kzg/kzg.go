@@ -128,8 +128,7 @@ func PointEvaluationPrecompile(input []byte) ([]byte, error) {
 	var quotientKZG [48]byte
 	copy(quotientKZG[:], input[144:PrecompileInputLength])
 
-	cryptoCtx := Ctx()
-	err := cryptoCtx.VerifyKZGProof(dataKZG, x, y, quotientKZG)
+	err := Verifier().VerifyKZGProof(dataKZG, x, y, quotientKZG)
 	if err != nil {
 		return nil, fmt.Errorf("verify_kzg_proof error: %w", err)
 	}
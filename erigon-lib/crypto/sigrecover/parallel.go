@@ -0,0 +1,67 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package sigrecover
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/erigontech/secp256k1"
+)
+
+// Parallel is the accelerator spreading a batch over all CPU cores, for callers recovering a batch in one goroutine
+const Parallel = "parallel"
+
+func init() {
+	Register(Parallel, func() (Accelerator, error) { return newParallel(runtime.GOMAXPROCS(0)), nil })
+}
+
+// parallel recovers parts of a batch concurrently, every worker with its own secp256k1 context. Concurrent batches
+// share the workers' contexts, so all of them together use at most `workers` cores.
+type parallel struct {
+	workers  int
+	contexts chan *secp256k1.Context
+}
+
+func newParallel(workers int) *parallel {
+	p := &parallel{workers: workers, contexts: make(chan *secp256k1.Context, workers)}
+	for i := 0; i < workers; i++ {
+		p.contexts <- secp256k1.NewContext()
+	}
+	return p
+}
+
+func (p *parallel) Recover(hashes [][32]byte, sigs [][65]byte, pubs [][65]byte, ok []bool) error {
+	part := (len(hashes) + p.workers - 1) / p.workers
+	var wg sync.WaitGroup
+	for from := 0; from < len(hashes); from += part {
+		to := min(from+part, len(hashes))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := <-p.contexts
+			defer func() { p.contexts <- ctx }()
+			for i := from; i < to; i++ {
+				ok[i] = recoverCPU(ctx, &hashes[i], &sigs[i], &pubs[i]) == nil
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (p *parallel) Close() {}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sigrecover recovers secp256k1 public keys of many signatures at once. It defines the Accelerator
+// interface batches are offloaded to when one is registered and selected, and the CPU recovery used otherwise
+// and for anything the accelerator fails on.
+//
+// Parallel is the accelerator shipped: it spreads a batch over all CPU cores. Other accelerators (GPU, FPGA) are
+// added by a package calling Register from init, linked into a custom build.
+package sigrecover
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/erigontech/secp256k1"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+const (
+	CPU  = "cpu"  // no accelerator
+	Auto = "auto" // the first registered accelerator which opens, the CPU if none
+
+	// MinBatch - smaller batches are recovered on the CPU, as moving them to the device costs more than it saves
+	MinBatch = 64
+)
+
+var (
+	acceleratedSignatures = metrics.GetOrCreateCounter(`sigrecover_accelerated_total`)
+	cpuSignatures         = metrics.GetOrCreateCounter(`sigrecover_cpu_total`)
+	acceleratorFailures   = metrics.GetOrCreateCounter(`sigrecover_accelerator_failures_total`)
+)
+
+// Accelerator recovers public keys on a device, it must be safe for concurrent use
+type Accelerator interface {
+	// Recover writes the 65 byte uncompressed public keys of the signatures (r || s || v, v is 0 or 1) of the hashes
+	// to pubs. ok[i] is false if the signature can't be recovered. An error means the device failed,
+	// the whole batch is recovered on the CPU then.
+	Recover(hashes [][32]byte, sigs [][65]byte, pubs [][65]byte, ok []bool) error
+	Close()
+}
+
+var (
+	mu          sync.RWMutex
+	registered  = map[string]func() (Accelerator, error){}
+	accelerator Accelerator
+)
+
+// Register makes the accelerator available for Select, called from init of the accelerator package
+func Register(name string, open func() (Accelerator, error)) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered[name] = open
+}
+
+// Accelerators returns names of the registered accelerators: Parallel, and those registered by a custom build
+func Accelerators() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select opens the accelerator used by Recover: CPU, Auto or the name of a registered accelerator
+func Select(name string, logger log.Logger) error {
+	var acc Accelerator
+	switch name {
+	case CPU:
+	case Auto:
+		for _, n := range Accelerators() {
+			mu.RLock()
+			open := registered[n]
+			mu.RUnlock()
+			a, err := open()
+			if err != nil {
+				logger.Info("[sigrecover] accelerator unavailable", "name", n, "err", err)
+				continue
+			}
+			acc, name = a, n
+			break
+		}
+	default:
+		mu.RLock()
+		open, ok := registered[name]
+		mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown signature recovery accelerator %q, available: %s", name, strings.Join(append([]string{CPU, Auto}, Accelerators()...), ", "))
+		}
+		var err error
+		if acc, err = open(); err != nil {
+			return fmt.Errorf("open signature recovery accelerator %s: %w", name, err)
+		}
+	}
+	mu.Lock()
+	prev := accelerator
+	accelerator = acc
+	mu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+	if acc != nil {
+		logger.Info("[sigrecover] signature recovery offloaded", "accelerator", name)
+	}
+	return nil
+}
+
+// Enabled reports whether an accelerator is selected, callers can skip batching otherwise
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return accelerator != nil
+}
+
+var errRecoverFailed = errors.New("invalid public key")
+
+// Recover recovers the public keys of the signatures (r || s || v, v is 0 or 1) of the hashes, errs[i] is set
+// if the signature can't be recovered. cryptoContext is used for recovery on the CPU.
+func Recover(cryptoContext *secp256k1.Context, hashes [][32]byte, sigs [][65]byte) (pubs [][65]byte, errs []error) {
+	pubs, errs = make([][65]byte, len(hashes)), make([]error, len(hashes))
+	mu.RLock()
+	acc := accelerator
+	mu.RUnlock()
+	if acc != nil && len(hashes) >= MinBatch {
+		ok := make([]bool, len(hashes))
+		if err := acc.Recover(hashes, sigs, pubs, ok); err == nil {
+			acceleratedSignatures.AddInt(len(hashes))
+			for i := range ok {
+				if !ok[i] { // recovered on the CPU for the exact error
+					errs[i] = recoverCPU(cryptoContext, &hashes[i], &sigs[i], &pubs[i])
+				}
+			}
+			return pubs, errs
+		}
+		acceleratorFailures.Inc()
+	}
+	cpuSignatures.AddInt(len(hashes))
+	for i := range hashes {
+		errs[i] = recoverCPU(cryptoContext, &hashes[i], &sigs[i], &pubs[i])
+	}
+	return pubs, errs
+}
+
+func recoverCPU(cryptoContext *secp256k1.Context, hash *[32]byte, sig *[65]byte, pub *[65]byte) error {
+	key, err := secp256k1.RecoverPubkeyWithContext(cryptoContext, hash[:], sig[:], pub[:0])
+	if err != nil {
+		return err
+	}
+	if len(key) != len(pub) || key[0] != 4 {
+		return errRecoverFailed
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package sigrecover
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/erigontech/secp256k1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// testAccelerator recovers on the CPU, failing the signatures at the odd indexes or the whole batch
+type testAccelerator struct {
+	fail    bool
+	batches int
+}
+
+func (a *testAccelerator) Recover(hashes [][32]byte, sigs [][65]byte, pubs [][65]byte, ok []bool) error {
+	a.batches++
+	if a.fail {
+		return errors.New("device lost")
+	}
+	for i := range hashes {
+		ok[i] = i%2 == 0 && recoverCPU(secp256k1.DefaultContext, &hashes[i], &sigs[i], &pubs[i]) == nil
+	}
+	return nil
+}
+
+func (a *testAccelerator) Close() {}
+
+func TestRecover(t *testing.T) {
+	hashes, sigs, want := make([][32]byte, MinBatch), make([][65]byte, MinBatch), make([][]byte, MinBatch)
+	for i := range hashes {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		hashes[i][0], hashes[i][31] = byte(i), 1
+		sig, err := crypto.Sign(hashes[i][:], key)
+		require.NoError(t, err)
+		copy(sigs[i][:], sig)
+		want[i] = crypto.MarshalPubkeyStd(&key.PublicKey)
+	}
+	sigs[3][64] = 5 // invalid recovery id
+
+	acc := &testAccelerator{}
+	Register("test", func() (Accelerator, error) { return acc, nil })
+	t.Cleanup(func() { require.NoError(t, Select(CPU, log.New())) })
+	require.NoError(t, Select("test", log.New()))
+	require.True(t, Enabled())
+
+	check := func(pubs [][65]byte, errs []error) {
+		for i := range pubs {
+			if i == 3 {
+				require.Error(t, errs[i])
+				continue
+			}
+			require.NoError(t, errs[i])
+			require.Equal(t, want[i], pubs[i][:])
+		}
+	}
+	check(Recover(secp256k1.DefaultContext, hashes, sigs))
+	require.Equal(t, 1, acc.batches)
+
+	acc.fail = true // falls back to the CPU
+	check(Recover(secp256k1.DefaultContext, hashes, sigs))
+	require.Equal(t, 2, acc.batches)
+
+	Recover(secp256k1.DefaultContext, hashes[:MinBatch-1], sigs[:MinBatch-1]) // too small to offload
+	require.Equal(t, 2, acc.batches)
+
+	require.Error(t, Select("missing", log.New()))
+}
+
+// testBatch signs n random hashes, every 7th signature is invalid in one of the ways recovery rejects
+func testBatch(t *testing.T, n int) (hashes [][32]byte, sigs [][65]byte) {
+	hashes, sigs = make([][32]byte, n), make([][65]byte, n)
+	for i := range hashes {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		copy(hashes[i][:], crypto.Keccak256([]byte{byte(i), byte(i >> 8)}))
+		sig, err := crypto.Sign(hashes[i][:], key)
+		require.NoError(t, err)
+		copy(sigs[i][:], sig)
+		if i%7 == 0 {
+			switch i / 7 % 4 {
+			case 0:
+				sigs[i][64] = 4 // recovery id out of range
+			case 1:
+				clear(sigs[i][:32]) // zero r
+			case 2:
+				copy(sigs[i][32:64], bytes.Repeat([]byte{0xff}, 32)) // s above the curve order
+			case 3:
+				copy(sigs[i][:32], bytes.Repeat([]byte{0xff}, 32)) // r above the field size
+			}
+		}
+	}
+	return hashes, sigs
+}
+
+func TestParallelParity(t *testing.T) {
+	hashes, sigs := testBatch(t, 1000)
+	cpuPubs, cpuErrs := Recover(secp256k1.DefaultContext, hashes, sigs)
+
+	t.Cleanup(func() { require.NoError(t, Select(CPU, log.New())) })
+	require.NoError(t, Select(Parallel, log.New()))
+	require.True(t, Enabled())
+	for _, n := range []int{MinBatch, MinBatch + 1, 333, len(hashes)} {
+		pubs, errs := Recover(secp256k1.DefaultContext, hashes[:n], sigs[:n])
+		require.Equal(t, cpuPubs[:n], pubs, n)
+		require.Equal(t, cpuErrs[:n], errs, n)
+	}
+	var failed int
+	for _, err := range cpuErrs {
+		if err != nil {
+			failed++
+		}
+	}
+	require.Equal(t, (len(hashes)+6)/7, failed)
+
+	// concurrent batches share the workers
+	acc := newParallel(3)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pubs, ok := make([][65]byte, len(hashes)), make([]bool, len(hashes))
+			assert.NoError(t, acc.Recover(hashes, sigs, pubs, ok))
+			for i := range ok {
+				assert.Equal(t, cpuErrs[i] == nil, ok[i], i)
+			}
+			assert.Equal(t, cpuPubs, pubs)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, acc.contexts, 3)
+}
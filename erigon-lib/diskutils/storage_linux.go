@@ -0,0 +1,64 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+//go:build linux
+
+package diskutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// StorageForDirPath reads the characteristics of the device holding the directory from sysfs,
+// returns false if they can't be found (e.g. network or virtual filesystems)
+func StorageForDirPath(dirPath string) (Storage, bool) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(SmlinkForDirPath(dirPath), &stat); err != nil {
+		return Storage{}, false
+	}
+	dev, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(stat.Dev), unix.Minor(stat.Dev)))
+	if err != nil {
+		return Storage{}, false
+	}
+	queue := filepath.Join(dev, "queue")
+	if _, err := os.Stat(queue); err != nil { // partition, the queue is of the whole disk
+		queue = filepath.Join(filepath.Dir(dev), "queue")
+	}
+	rotational, ok := readSysfsUint(filepath.Join(queue, "rotational"))
+	if !ok {
+		return Storage{}, false
+	}
+	blockSize, _ := readSysfsUint(filepath.Join(queue, "physical_block_size"))
+	return Storage{Rotational: rotational == 1, PhysicalBlockSize: blockSize}, true
+}
+
+func readSysfsUint(path string) (uint64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
@@ -768,25 +768,11 @@ func fileHashBytes(ctx context.Context, fileInfo snaptype.FileInfo, stats *AggSt
 		stats.LocalFileHashTime += time.Since(t)
 	}(time.Now())
 
-	info := &metainfo.Info{PieceLength: downloadercfg.DefaultPieceSize, Name: fileInfo.Name()}
-
-	if err := info.BuildFromFilePath(fileInfo.Path); err != nil {
-		return nil, fmt.Errorf("can't get local hash for %s: %w", fileInfo.Name(), err)
-	}
-
-	meta, err := CreateMetaInfo(info, nil)
-
+	hash, err := FileInfoHash(fileInfo.Path, fileInfo.Name())
 	if err != nil {
 		return nil, fmt.Errorf("can't get local hash for %s: %w", fileInfo.Name(), err)
 	}
-
-	spec, err := torrent.TorrentSpecFromMetaInfoErr(meta)
-
-	if err != nil {
-		return nil, fmt.Errorf("can't get local hash for %s: %w", fileInfo.Name(), err)
-	}
-
-	return spec.InfoHash.Bytes(), nil
+	return hash.Bytes(), nil
 }
 
 func (d *Downloader) MainLoopInBackground(silent bool) {
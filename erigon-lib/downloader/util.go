@@ -253,6 +253,21 @@ func CreateMetaInfo(info *metainfo.Info, mi *metainfo.MetaInfo) (*metainfo.MetaI
 	return mi, nil
 }
 
+// FileInfoHash returns the info hash of the torrent of the file at `path` named `name` (relative to the snapshots dir),
+// built from the file content with the default piece size - what the preverified hashes are. Reads the whole file.
+func FileInfoHash(path, name string) (metainfo.Hash, error) {
+	info := &metainfo.Info{PieceLength: downloadercfg.DefaultPieceSize}
+	if err := info.BuildFromFilePath(path); err != nil {
+		return metainfo.Hash{}, err
+	}
+	info.Name = name
+	meta, err := CreateMetaInfo(info, nil)
+	if err != nil {
+		return metainfo.Hash{}, err
+	}
+	return meta.HashInfoBytes(), nil
+}
+
 func AllTorrentPaths(dirs datadir.Dirs) ([]string, error) {
 	files, err := dir2.ListFiles(dirs.Snap, ".torrent")
 	if err != nil {
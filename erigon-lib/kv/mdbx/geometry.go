@@ -0,0 +1,167 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package mdbx
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/c2h5oh/datasize"
+
+	"github.com/erigontech/erigon-lib/diskutils"
+	"github.com/erigontech/erigon-lib/kv"
+)
+
+const (
+	minPageSize = 4 * datasize.KB
+	maxPageSize = 64 * datasize.KB
+
+	// part of the pages of a db taken by overflow (large values) pages, past which bigger pages are advised
+	overflowPagesShare = 0.3
+	// b-tree depth of a table, past which bigger pages are advised
+	deepTable = 5
+	// part of the size limit used by the db, past which a bigger limit is advised
+	sizeLimitShare = 0.75
+
+	minGrowthStep           = 16 * datasize.MB
+	maxGrowthStep           = 2 * datasize.GB
+	minGrowthStepRotational = 1 * datasize.GB
+)
+
+// TableGeometry is the b-tree stats of a table
+type TableGeometry struct {
+	Name                                  string
+	Entries                               uint64
+	Depth                                 uint
+	LeafPages, BranchPages, OverflowPages uint64
+}
+
+// Geometry is the page size, size settings and tables of an open db, input of AdviseGeometry
+type Geometry struct {
+	PageSize   datasize.ByteSize
+	Size       datasize.ByteSize // current size of the data file
+	SizeLimit  datasize.ByteSize
+	GrowthStep datasize.ByteSize
+	Tables     []TableGeometry // sorted by size, largest first
+}
+
+// Geometry reads the page size, size settings and stats of the tables of the db
+func (db *MdbxKV) Geometry(ctx context.Context) (*Geometry, error) {
+	g := &Geometry{}
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		info, err := db.env.Info(tx.(*MdbxTx).tx)
+		if err != nil {
+			return err
+		}
+		g.PageSize = datasize.ByteSize(info.PageSize)
+		g.Size = datasize.ByteSize(info.Geo.Current)
+		g.SizeLimit = datasize.ByteSize(info.Geo.Upper)
+		g.GrowthStep = datasize.ByteSize(info.Geo.Grow)
+		for name, cfg := range db.buckets {
+			if cfg.IsDeprecated || cfg.DBI == NonExistingDBI {
+				continue
+			}
+			st, err := tx.(*MdbxTx).BucketStat(name)
+			if err != nil {
+				return err
+			}
+			g.Tables = append(g.Tables, TableGeometry{Name: name, Entries: st.Entries, Depth: st.Depth,
+				LeafPages: st.LeafPages, BranchPages: st.BranchPages, OverflowPages: st.OverflowPages})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(g.Tables, func(i, j int) bool { return g.Tables[i].pages() > g.Tables[j].pages() })
+	return g, nil
+}
+
+func (t TableGeometry) pages() uint64 { return t.LeafPages + t.BranchPages + t.OverflowPages }
+
+// GeometryAdvice is the page size and size settings advised for a db, with the reasons of every change
+type GeometryAdvice struct {
+	PageSize   datasize.ByteSize
+	GrowthStep datasize.ByteSize
+	SizeLimit  datasize.ByteSize
+	Reasons    []string
+}
+
+// PageSizeChanged means the advice can be applied only by copying the db, the page size is fixed at its creation
+func (a GeometryAdvice) PageSizeChanged(g *Geometry) bool { return a.PageSize != g.PageSize }
+
+// Changed means any of the settings differs from the current ones
+func (a GeometryAdvice) Changed(g *Geometry) bool {
+	return a.PageSize != g.PageSize || a.GrowthStep != g.GrowthStep || a.SizeLimit != g.SizeLimit
+}
+
+// AdviseGeometry recommends page size and size settings from the observed tables and the storage device
+// (ok=false if unknown):
+//   - pages not smaller than the physical block of the device, to avoid read-modify-write of partial blocks
+//   - at least 8KB pages for dbs past 8TB (see --db.pagesize)
+//   - doubled pages if large values spill to overflow pages or the largest tables have deep b-trees
+//   - growth step proportional to the db size, larger on HDD to keep the file less fragmented
+//   - size limit with room to double the db when it's close to the current limit
+func AdviseGeometry(g *Geometry, storage diskutils.Storage, ok bool) GeometryAdvice {
+	a := GeometryAdvice{PageSize: g.PageSize, GrowthStep: g.GrowthStep, SizeLimit: g.SizeLimit}
+
+	pageSize := g.PageSize
+	if ok && datasize.ByteSize(storage.PhysicalBlockSize) > pageSize {
+		pageSize = datasize.ByteSize(storage.PhysicalBlockSize)
+		a.Reasons = append(a.Reasons, fmt.Sprintf("device physical block is %s, larger than the page", pageSize.HR()))
+	}
+	if g.Size > 8*datasize.TB && pageSize < 8*datasize.KB {
+		pageSize = 8 * datasize.KB
+		a.Reasons = append(a.Reasons, fmt.Sprintf("db is %s, larger than 8TB", g.Size.HR()))
+	}
+	var overflow, total uint64
+	for _, t := range g.Tables {
+		overflow += t.OverflowPages
+		total += t.pages()
+	}
+	if total > 0 && float64(overflow)/float64(total) > overflowPagesShare {
+		pageSize = max(pageSize, 2*g.PageSize)
+		a.Reasons = append(a.Reasons, fmt.Sprintf("%.0f%% of pages are overflow pages of large values", 100*float64(overflow)/float64(total)))
+	}
+	if len(g.Tables) > 0 && g.Tables[0].Depth >= deepTable {
+		pageSize = max(pageSize, 2*g.PageSize)
+		a.Reasons = append(a.Reasons, fmt.Sprintf("largest table %s has b-tree depth %d", g.Tables[0].Name, g.Tables[0].Depth))
+	}
+	a.PageSize = min(max(roundUpPow2(pageSize), minPageSize), maxPageSize)
+
+	growthStep := min(max(roundUpPow2(g.Size/256), minGrowthStep), maxGrowthStep)
+	if ok && storage.Rotational {
+		growthStep = max(growthStep, minGrowthStepRotational)
+	}
+	if growthStep > g.GrowthStep {
+		a.GrowthStep = growthStep
+		a.Reasons = append(a.Reasons, fmt.Sprintf("growth step %s is small for a db of %s", g.GrowthStep.HR(), g.Size.HR()))
+	}
+
+	if g.SizeLimit > 0 && float64(g.Size) > sizeLimitShare*float64(g.SizeLimit) {
+		a.SizeLimit = ((2*g.Size + datasize.GB - 1) / datasize.GB) * datasize.GB
+		a.Reasons = append(a.Reasons, fmt.Sprintf("db is %s, close to the size limit %s", g.Size.HR(), g.SizeLimit.HR()))
+	}
+	return a
+}
+
+func roundUpPow2(v datasize.ByteSize) datasize.ByteSize {
+	if v <= 1 {
+		return 1
+	}
+	return datasize.ByteSize(1) << bits.Len64(uint64(v-1))
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package mdbx
+
+import (
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/diskutils"
+)
+
+func TestAdviseGeometry(t *testing.T) {
+	g := &Geometry{
+		PageSize:   4 * datasize.KB,
+		Size:       100 * datasize.GB,
+		SizeLimit:  2 * datasize.TB,
+		GrowthStep: 1 * datasize.GB,
+		Tables:     []TableGeometry{{Name: "small", Depth: 3, LeafPages: 100}},
+	}
+	a := AdviseGeometry(g, diskutils.Storage{PhysicalBlockSize: 512}, true)
+	require.False(t, a.Changed(g))
+	require.Empty(t, a.Reasons)
+
+	t.Run("overflow pages", func(t *testing.T) {
+		g := *g
+		g.Tables = []TableGeometry{{Name: "values", Depth: 3, LeafPages: 60, OverflowPages: 40}}
+		a := AdviseGeometry(&g, diskutils.Storage{}, false)
+		require.True(t, a.PageSizeChanged(&g))
+		require.Equal(t, 8*datasize.KB, a.PageSize)
+	})
+	t.Run("device block", func(t *testing.T) {
+		a := AdviseGeometry(g, diskutils.Storage{PhysicalBlockSize: 16 * 1024}, true)
+		require.Equal(t, 16*datasize.KB, a.PageSize)
+	})
+	t.Run("page size cap", func(t *testing.T) {
+		g := *g
+		g.PageSize = 64 * datasize.KB
+		g.Tables = []TableGeometry{{Name: "deep", Depth: 6, LeafPages: 100}}
+		a := AdviseGeometry(&g, diskutils.Storage{}, false)
+		require.False(t, a.PageSizeChanged(&g))
+	})
+	t.Run("growth and limit", func(t *testing.T) {
+		g := *g
+		g.Size = 1800 * datasize.GB
+		g.GrowthStep = 16 * datasize.MB
+		a := AdviseGeometry(&g, diskutils.Storage{}, false)
+		require.Equal(t, maxGrowthStep, a.GrowthStep)
+		require.Equal(t, 3600*datasize.GB, a.SizeLimit)
+	})
+	t.Run("hdd", func(t *testing.T) {
+		g := *g
+		g.Size = 10 * datasize.GB
+		g.GrowthStep = 16 * datasize.MB
+		a := AdviseGeometry(&g, diskutils.Storage{Rotational: true}, true)
+		require.Equal(t, minGrowthStepRotational, a.GrowthStep)
+	})
+}
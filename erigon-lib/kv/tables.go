@@ -130,7 +130,7 @@ const (
 
 	ConfigTable = "Config" // config prefix for the db
 
-	PreimagePrefix = "SecureKey" // preimagePrefix + hash -> preima
+	PreimagePrefix = "SecureKey" // keccak256(key) -> key, filled only if preimages recording enabled (--cache.preimages)
 
 	// Progress of sync stages: stageName -> stageData
 	SyncStageProgress = "SyncStage"
@@ -346,6 +346,7 @@ var ChaindataTables = []string{
 	Receipts,
 	TxLookup,
 	ConfigTable,
+	PreimagePrefix,
 	DatabaseInfo,
 	IncarnationMap,
 	SyncStageProgress,
@@ -75,6 +75,8 @@ type Aggregator struct {
 	// It means goroutine which creating small files - can't be locked by merge or indexing.
 	buildingFiles atomic.Bool
 	mergingFiles  atomic.Bool
+	// defers building and merging while RPC is under load, nil - never defer
+	compactionScheduler *CompactionScheduler
 
 	//warmupWorking          atomic.Bool
 	ctx       context.Context
@@ -722,6 +724,9 @@ func (a *Aggregator) MergeLoop(ctx context.Context) error {
 	defer a.mergingFiles.Store(false)
 
 	for {
+		if err := a.waitCompaction(ctx); err != nil {
+			return err
+		}
 		somethingMerged, err := a.mergeLoopStep(ctx, a.visibleFilesMinimaxTxNum.Load())
 		if err != nil {
 			return err
@@ -1461,6 +1466,18 @@ func (a *Aggregator) SetProduceMod(produce bool) {
 	a.produce = produce
 }
 
+// SetCompactionScheduler makes background building and merging of files wait for the scheduler before every step
+func (a *Aggregator) SetCompactionScheduler(s *CompactionScheduler) {
+	a.compactionScheduler = s
+}
+
+func (a *Aggregator) waitCompaction(ctx context.Context) error {
+	if a.compactionScheduler == nil {
+		return nil
+	}
+	return a.compactionScheduler.Wait(ctx)
+}
+
 // Returns channel which is closed when aggregation is done
 func (a *Aggregator) BuildFilesInBackground(txNum uint64) chan struct{} {
 	fin := make(chan struct{})
@@ -1517,6 +1534,10 @@ func (a *Aggregator) BuildFilesInBackground(txNum uint64) chan struct{} {
 		// - to remove old data from db as early as possible
 		// - during files build, may happen commit of new data. on each loop step getting latest id in db
 		for ; step < lastInDB; step++ { //`step` must be fully-written - means `step+1` records must be visible
+			if err := a.waitCompaction(a.ctx); err != nil {
+				close(fin)
+				return
+			}
 			if err := a.buildFiles(a.ctx, step); err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, common2.ErrStopped) {
 					close(fin)
@@ -0,0 +1,140 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// Modes of CompactionScheduler
+const (
+	CompactionAuto  = "auto"  // defer while the RPC latency is above the threshold
+	CompactionPause = "pause" // defer until the mode changes
+	CompactionForce = "force" // never defer
+)
+
+const compactionPollInterval = 5 * time.Second
+
+// CompactionScheduler defers building and merging of files (see Aggregator.SetCompactionScheduler) while the node
+// serves RPC under load: it's checked before every step, so long compactions are paced by the load too.
+// Compaction deferred for maxDefer runs anyway - otherwise the db would grow without bounds.
+type CompactionScheduler struct {
+	latency   func() time.Duration // recent p99 latency of RPC requests
+	threshold time.Duration        // 0 - don't defer in the auto mode
+	maxDefer  time.Duration
+	logger    log.Logger
+
+	mu            sync.Mutex
+	mode          string
+	modeChanged   chan struct{} // closed on SetMode
+	deferredSince time.Time     // zero if nothing is deferred
+}
+
+// CompactionStatus is the result of admin_compactionStatus
+type CompactionStatus struct {
+	Mode        string        `json:"mode"`
+	Latency     time.Duration `json:"latency"`   // recent p99 latency of RPC requests, ns
+	Threshold   time.Duration `json:"threshold"` // ns, 0 - disabled
+	MaxDefer    time.Duration `json:"maxDefer"`  // ns
+	Deferred    bool          `json:"deferred"`
+	DeferredFor time.Duration `json:"deferredFor,omitempty"` // ns
+}
+
+func NewCompactionScheduler(latency func() time.Duration, threshold, maxDefer time.Duration, logger log.Logger) *CompactionScheduler {
+	return &CompactionScheduler{latency: latency, threshold: threshold, maxDefer: maxDefer, logger: logger,
+		mode: CompactionAuto, modeChanged: make(chan struct{})}
+}
+
+// SetMode overrides the scheduling until the next call, CompactionAuto returns to latency based scheduling
+func (s *CompactionScheduler) SetMode(mode string) error {
+	switch mode {
+	case CompactionAuto, CompactionPause, CompactionForce:
+	default:
+		return fmt.Errorf("unknown compaction mode %q, expected one of: %s, %s, %s", mode, CompactionAuto, CompactionPause, CompactionForce)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mode = mode
+	close(s.modeChanged)
+	s.modeChanged = make(chan struct{})
+	s.logger.Info("[agg] compaction mode set", "mode", mode)
+	return nil
+}
+
+func (s *CompactionScheduler) Status() CompactionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := CompactionStatus{Mode: s.mode, Latency: s.latency(), Threshold: s.threshold, MaxDefer: s.maxDefer,
+		Deferred: !s.deferredSince.IsZero()}
+	if st.Deferred {
+		st.DeferredFor = time.Since(s.deferredSince)
+	}
+	return st
+}
+
+// Wait blocks until the compaction step may run
+func (s *CompactionScheduler) Wait(ctx context.Context) error {
+	var start time.Time
+	defer func() {
+		if !start.IsZero() {
+			s.mu.Lock()
+			s.deferredSince = time.Time{}
+			s.mu.Unlock()
+			mxCompactionDeferredSeconds.Add(time.Since(start).Seconds())
+		}
+	}()
+	for {
+		s.mu.Lock()
+		mode, modeChanged := s.mode, s.modeChanged
+		s.mu.Unlock()
+
+		var latency time.Duration
+		switch mode {
+		case CompactionForce:
+			return nil
+		case CompactionAuto:
+			if s.threshold <= 0 {
+				return nil
+			}
+			if latency = s.latency(); latency <= s.threshold {
+				return nil
+			}
+			if !start.IsZero() && time.Since(start) >= s.maxDefer {
+				s.logger.Warn("[agg] compaction deferred for too long, running under RPC load", "p99", latency, "deferred", time.Since(start))
+				return nil
+			}
+		}
+		if start.IsZero() {
+			start = time.Now()
+			s.mu.Lock()
+			s.deferredSince = start
+			s.mu.Unlock()
+			mxCompactionDeferred.Inc()
+			s.logger.Info("[agg] compaction deferred", "mode", mode, "p99", latency, "threshold", s.threshold)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-modeChanged:
+		case <-time.After(compactionPollInterval):
+		}
+	}
+}
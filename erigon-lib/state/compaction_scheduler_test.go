@@ -0,0 +1,55 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package state
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestCompactionScheduler(t *testing.T) {
+	var latency atomic.Int64
+	s := NewCompactionScheduler(func() time.Duration { return time.Duration(latency.Load()) }, 100*time.Millisecond, time.Hour, log.New())
+	ctx := context.Background()
+
+	require.NoError(t, s.Wait(ctx))
+
+	latency.Store(int64(time.Second))
+	done := make(chan error)
+	go func() { done <- s.Wait(ctx) }()
+	require.Eventually(t, func() bool { return s.Status().Deferred }, time.Second, time.Millisecond)
+
+	require.NoError(t, s.SetMode(CompactionForce))
+	require.NoError(t, <-done)
+	require.False(t, s.Status().Deferred)
+	require.NoError(t, s.Wait(ctx))
+
+	require.NoError(t, s.SetMode(CompactionPause))
+	latency.Store(0)
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Wait(cctx), context.DeadlineExceeded)
+
+	require.Error(t, s.SetMode("never"))
+	require.NoError(t, s.SetMode(CompactionAuto))
+	require.NoError(t, s.Wait(ctx))
+}
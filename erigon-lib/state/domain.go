@@ -1415,6 +1415,12 @@ func (dt *DomainRoTx) Unwind(ctx context.Context, rwTx kv.RwTx, step, txNumUnwin
 // of file where the value is stored (not exact step when kv has been set)
 // maxTxNum, if > 0, filters out files with bigger txnums from search
 func (dt *DomainRoTx) getFromFiles(filekey []byte, maxTxNum uint64) (v []byte, found bool, fileStartTxNum uint64, fileEndTxNum uint64, err error) {
+	v, found, fileStartTxNum, fileEndTxNum, _, err = dt.getFromFilesCached(filekey, maxTxNum)
+	return v, found, fileStartTxNum, fileEndTxNum, err
+}
+
+// getFromFilesCached is getFromFiles which also reports if the result came from getFromFileCache
+func (dt *DomainRoTx) getFromFilesCached(filekey []byte, maxTxNum uint64) (v []byte, found bool, fileStartTxNum uint64, fileEndTxNum uint64, fromCache bool, err error) {
 	if len(dt.files) == 0 {
 		return
 	}
@@ -1430,7 +1436,7 @@ func (dt *DomainRoTx) getFromFiles(filekey []byte, maxTxNum uint64) (v []byte, f
 	}
 	if dt.getFromFileCache != nil && maxTxNum == math.MaxUint64 {
 		if cv, ok := dt.getFromFileCache.Get(hi); ok {
-			return cv.v, true, dt.files[cv.lvl].startTxNum, dt.files[cv.lvl].endTxNum, nil
+			return cv.v, true, dt.files[cv.lvl].startTxNum, dt.files[cv.lvl].endTxNum, true, nil
 		}
 	}
 
@@ -1460,7 +1466,7 @@ func (dt *DomainRoTx) getFromFiles(filekey []byte, maxTxNum uint64) (v []byte, f
 
 		v, found, _, err = dt.getLatestFromFile(i, filekey)
 		if err != nil {
-			return nil, false, 0, 0, err
+			return nil, false, 0, 0, false, err
 		}
 		if !found {
 			if traceGetLatest == dt.name {
@@ -1475,7 +1481,7 @@ func (dt *DomainRoTx) getFromFiles(filekey []byte, maxTxNum uint64) (v []byte, f
 		if dt.getFromFileCache != nil {
 			dt.getFromFileCache.Add(hi, domainGetFromFileCacheItem{lvl: uint8(i), v: v})
 		}
-		return v, true, dt.files[i].startTxNum, dt.files[i].endTxNum, nil
+		return v, true, dt.files[i].startTxNum, dt.files[i].endTxNum, false, nil
 	}
 	if traceGetLatest == dt.name {
 		fmt.Printf("GetLatest(%s, %x) -> not found in %d files\n", dt.name.String(), filekey, len(dt.files))
@@ -1484,7 +1490,7 @@ func (dt *DomainRoTx) getFromFiles(filekey []byte, maxTxNum uint64) (v []byte, f
 	if dt.getFromFileCache != nil {
 		dt.getFromFileCache.Add(hi, domainGetFromFileCacheItem{lvl: 0, v: nil})
 	}
-	return nil, false, 0, 0, nil
+	return nil, false, 0, 0, false, nil
 }
 
 // Returns the first txNum from available history
@@ -1518,11 +1524,26 @@ func (dt *DomainRoTx) GetAsOfFile(key []byte, txNum uint64) ([]byte, bool, error
 // GetAsOf does not always require usage of roTx. If it is possible to determine
 // historical value based only on static files, roTx will not be used.
 func (dt *DomainRoTx) GetAsOf(key []byte, txNum uint64, roTx kv.Tx) ([]byte, bool, error) {
-	v, hOk, err := dt.ht.HistorySeek(key, txNum, roTx)
+	var t time.Time
+	if dbg.KVReadLevelledMetrics {
+		t = time.Now()
+	}
+	tier := readTierHistoryFiles
+	v, hOk, err := dt.ht.historySeekInFiles(key, txNum)
 	if err != nil {
 		return nil, false, err
 	}
+	if !hOk {
+		tier = readTierHistoryDB
+		v, hOk, err = dt.ht.historySeekInDB(key, txNum, roTx)
+		if err != nil {
+			return nil, false, err
+		}
+	}
 	if hOk {
+		if dbg.KVReadLevelledMetrics {
+			mxsGetAsOf[dt.name][tier].ObserveDuration(t)
+		}
 		if len(v) == 0 { // if history successfuly found marker of key creation
 			if traceGetAsOf == dt.d.filenameBase {
 				fmt.Printf("DomainGetAsOf(%s  , %x, %d) -> not found in history\n", dt.d.filenameBase, key, txNum)
@@ -1540,6 +1561,9 @@ func (dt *DomainRoTx) GetAsOf(key []byte, txNum uint64, roTx kv.Tx) ([]byte, boo
 	if err != nil {
 		return nil, false, err
 	}
+	if dbg.KVReadLevelledMetrics {
+		mxsGetAsOf[dt.name][readTierLatest].ObserveDuration(t)
+	}
 	if traceGetAsOf == dt.d.filenameBase {
 		if ok {
 			fmt.Printf("DomainGetAsOf(%s, %x, %d) -> found in latest state\n", dt.d.filenameBase, key, txNum)
@@ -1728,18 +1752,35 @@ func (dt *DomainRoTx) GetLatest(key []byte, roTx kv.Tx) ([]byte, uint64, bool, e
 		}()
 	}
 
+	var t time.Time
+	if dbg.KVReadLevelledMetrics {
+		t = time.Now()
+	}
+
 	v, foundStep, found, err = dt.getLatestFromDb(key, roTx)
 	if err != nil {
 		return nil, 0, false, fmt.Errorf("getLatestFromDb: %w", err)
 	}
 	if found {
+		if dbg.KVReadLevelledMetrics {
+			mxsGetLatest[dt.name][readTierDB].ObserveDuration(t)
+		}
 		return v, foundStep, true, nil
 	}
 
-	v, foundInFile, _, endTxNum, err := dt.getFromFiles(key, 0)
+	v, foundInFile, startTxNum, endTxNum, fromCache, err := dt.getFromFilesCached(key, 0)
 	if err != nil {
 		return nil, 0, false, fmt.Errorf("getFromFiles: %w", err)
 	}
+	if dbg.KVReadLevelledMetrics {
+		tier := readTierNotFound
+		if fromCache {
+			tier = readTierCache
+		} else if foundInFile {
+			tier = fileReadTier(startTxNum, endTxNum, dt.d.aggregationStep)
+		}
+		mxsGetLatest[dt.name][tier].ObserveDuration(t)
+	}
 	return v, endTxNum / dt.d.aggregationStep, foundInFile, nil
 }
 
@@ -2576,3 +2576,16 @@ func TestCanBuild(t *testing.T) {
 	require.True(t, canBuild)
 	_ = writer.PutWithPrev(k, nil, hexutil.EncodeTs(d.aggregationStep*2+1), nil, 0)
 }
+
+func TestFileReadTier(t *testing.T) {
+	t.Parallel()
+
+	step := uint64(16)
+	require.Equal(t, readTierL0, fileReadTier(0, step, step))
+	require.Equal(t, readTierL0+1, fileReadTier(2*step, 4*step, step))
+	require.Equal(t, readTierL0+5, fileReadTier(0, 32*step, step))
+	require.Equal(t, readTierL0+6, fileReadTier(0, 64*step, step))
+	require.Equal(t, readTierL0+6, fileReadTier(0, 256*step, step))
+	require.Equal(t, "L6", fileLevelNames()[6])
+	require.Len(t, mxsGetLatest[0], readTierL0+readFileLevels)
+}
@@ -17,6 +17,9 @@
 package state
 
 import (
+	"fmt"
+	"math/bits"
+
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/metrics"
 )
@@ -59,6 +62,9 @@ var (
 	mxFlushTook            = metrics.GetOrCreateSummary("domain_flush_took")
 	mxCommitmentRunning    = metrics.GetOrCreateGauge("domain_running_commitment")
 	mxCommitmentTook       = metrics.GetOrCreateSummary("domain_commitment_took")
+
+	mxCompactionDeferred        = metrics.GetOrCreateCounter("domain_compaction_deferred")
+	mxCompactionDeferredSeconds = metrics.GetOrCreateCounter("domain_compaction_deferred_seconds")
 )
 
 var (
@@ -116,3 +122,56 @@ var (
 		},
 	}
 )
+
+// Tiers of domain reads, collected with KV_READ_METRICS=true. Counts of the summaries give hit ratios of the tiers.
+const (
+	// GetLatest: found in db, in getFromFileCache, not found, or found in a file of level L0..L6 (readTierL0+level)
+	readTierDB = iota
+	readTierCache
+	readTierNotFound
+	readTierL0
+
+	readFileLevels = 7 // file of level L<n> spans 2^n steps, files of 64 steps and bigger are L6
+)
+
+const (
+	// GetAsOf: found in history files, in history db, or read from the latest state
+	readTierHistoryFiles = iota
+	readTierHistoryDB
+	readTierLatest
+)
+
+var (
+	mxsGetLatest = domainReadTierMetrics("domain_get_latest", append([]string{"db", "cache", "none"}, fileLevelNames()...))
+	mxsGetAsOf   = domainReadTierMetrics("domain_get_as_of", []string{"history_files", "history_db", "latest"})
+)
+
+func fileLevelNames() []string {
+	names := make([]string, readFileLevels)
+	for i := range names {
+		names[i] = fmt.Sprintf("L%d", i)
+	}
+	return names
+}
+
+func domainReadTierMetrics(name string, tiers []string) (res [kv.DomainLen][]metrics.Summary) {
+	domains := [kv.DomainLen]string{
+		kv.AccountsDomain:   "account",
+		kv.StorageDomain:    "storage",
+		kv.CodeDomain:       "code",
+		kv.CommitmentDomain: "commitment",
+		kv.ReceiptDomain:    "receipt",
+	}
+	for d, domain := range domains {
+		for _, tier := range tiers {
+			res[d] = append(res[d], metrics.GetOrCreateSummary(fmt.Sprintf(`%s{tier="%s",domain="%s"}`, name, tier, domain)))
+		}
+	}
+	return res
+}
+
+// fileReadTier returns the GetLatest tier of a read from the file [startTxNum, endTxNum)
+func fileReadTier(startTxNum, endTxNum, aggregationStep uint64) int {
+	level := bits.Len64((endTxNum-startTxNum)/aggregationStep) - 1
+	return readTierL0 + min(max(level, 0), readFileLevels-1)
+}
@@ -394,3 +394,17 @@ func VerifyStorageProofByHash(storageRoot libcommon.Hash, keyHash libcommon.Hash
 
 	return nil
 }
+
+// ProvenValue returns the value which the proof proves under the (hashed) key in the trie with the root,
+// nil if the proof proves the key is absent
+func ProvenValue(root libcommon.Hash, keyHash libcommon.Hash, proof []hexutil.Bytes) ([]byte, error) {
+	pm, used, err := proofMap(proof)
+	if err != nil {
+		return nil, fmt.Errorf("could not construct proofMap: %w", err)
+	}
+	value, err := verifyProof(root, keyHash[:], pm, used)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify proof: %w", err)
+	}
+	return value, nil
+}
@@ -130,12 +130,15 @@ import (
 	"github.com/erigontech/erigon/turbo/services"
 	"github.com/erigontech/erigon/turbo/shards"
 	"github.com/erigontech/erigon/turbo/silkworm"
+	"github.com/erigontech/erigon/turbo/snapshotsync"
 	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
 	stages2 "github.com/erigontech/erigon/turbo/stages"
 	"github.com/erigontech/erigon/txnprovider"
+	"github.com/erigontech/erigon/txnprovider/ordering"
 	"github.com/erigontech/erigon/txnprovider/shutter"
 	"github.com/erigontech/erigon/txnprovider/txpool"
 	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+	"github.com/erigontech/erigon/txnprovider/userop"
 )
 
 // Config contains the configuration options of the ETH protocol.
@@ -150,6 +153,8 @@ type Ethereum struct {
 	chainDB    kv.TemporalRwDB
 	privateAPI *grpc.Server
 
+	compactionScheduler *libstate.CompactionScheduler // paces building and merging of state files by RPC load
+
 	engine consensus.Engine
 
 	gasPrice  *uint256.Int
@@ -203,6 +208,7 @@ type Ethereum struct {
 	txPoolGrpcServer          txpoolproto.TxpoolServer
 	txPoolRpcClient           txpoolproto.TxpoolClient
 	shutterPool               *shutter.Pool
+	userOpPool                *userop.Pool
 	blockBuilderNotifyNewTxns chan struct{}
 	forkValidator             *engine_helpers.ForkValidator
 	downloader                *downloader.Downloader
@@ -318,7 +324,13 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 			genesisSpec = nil
 		}
 		var genesisErr error
-		chainConfig, genesis, genesisErr = core.WriteGenesisBlock(tx, genesisSpec, config.OverridePragueTime, dirs, logger)
+		chainConfig, genesis, genesisErr = core.WriteGenesisBlock(tx, genesisSpec, &core.ChainOverrides{
+			ShanghaiTime: config.OverrideShanghaiTime,
+			CancunTime:   config.OverrideCancunTime,
+			PragueTime:   config.OverridePragueTime,
+			OsakaTime:    config.OverrideOsakaTime,
+			EipTimes:     config.OverrideEipTimes,
+		}, dirs, logger)
 		if _, ok := genesisErr.(*chain.ConfigCompatError); genesisErr != nil && !ok {
 			return genesisErr
 		}
@@ -349,6 +361,9 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		return nil, err
 	}
 	backend.blockSnapshots, backend.blockReader, backend.blockWriter = allSnapshots, blockReader, blockWriter
+	backend.compactionScheduler = libstate.NewCompactionScheduler(func() time.Duration { return rpc.RecentLatency(0.99) },
+		config.Sync.CompactionRPCLatency, config.Sync.CompactionMaxDefer, logger)
+	agg.SetCompactionScheduler(backend.compactionScheduler)
 
 	backend.chainDB, err = temporal.New(rawChainDB, agg)
 	if err != nil {
@@ -693,7 +708,18 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		logger,
 		latestBlockBuiltStore,
 	)
-	httpRpcCfg := stack.Config().Http
+	// node-provided objects are set on the node config, so that Init passes them to the embedded rpcdaemon
+	httpRpcCfg := &stack.Config().Http
+	httpRpcCfg.CompactionScheduler = s.compactionScheduler
+	httpRpcCfg.NonCanonicalBlocks = s.notifications.NonCanonicalBlocks
+	httpRpcCfg.RecentReceipts = s.notifications.RecentReceipts
+	if s.txPool != nil {
+		httpRpcCfg.TxnPropagation = s.txPool.PropagationLog()
+	}
+	if config.Miner.SoftConfirmations {
+		httpRpcCfg.SoftConfirmations = s.notifications.Events
+	}
+	httpRpcCfg.SyncEvents = s.notifications.Events
 	ethRpcClient, txPoolRpcClient, miningRpcClient, rpcDaemonStateCache, rpcFilters := rpcdaemoncli.EmbeddedServices(
 		ctx,
 		backend.chainDB,
@@ -704,6 +730,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		backend.txPoolGrpcServer,
 		backend.miningRPC,
 		backend.stateDiffClient,
+		backend.notifications.HeadEvents,
 		logger,
 	)
 	backend.ethRpcClient = ethRpcClient
@@ -712,6 +739,12 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	backend.rpcDaemonStateCache = rpcDaemonStateCache
 	backend.rpcFilters = rpcFilters
 
+	if config.UserOps.Enabled {
+		validator := userop.NewEvmValidator(backend.chainDB, blockReader, backend.engine, chainConfig)
+		backend.userOpPool = userop.NewPool(config.UserOps, chainConfig.ChainID, validator, logger)
+		httpRpcCfg.UserOps = backend.userOpPool
+	}
+
 	if config.Shutter.Enabled {
 		if config.TxPool.Disable {
 			panic("can't enable shutter pool when devp2p txpool is disabled")
@@ -746,6 +779,17 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 		backend.shutterPool = shutter.NewPool(logger, config.Shutter, secondaryTxnProvider, contractBackend, backend.stateDiffClient)
 		txnProvider = backend.shutterPool
 	}
+	if config.Miner.Ordering != "" && txnProvider != nil {
+		var arrivals ordering.ArrivalLog
+		if backend.txPool != nil {
+			arrivals = backend.txPool.PropagationLog()
+		}
+		policy, err := ordering.New(ctx, config.Miner.Ordering, arrivals, logger)
+		if err != nil {
+			return nil, err
+		}
+		txnProvider = ordering.NewProvider(txnProvider, policy, chainConfig)
+	}
 
 	miner := stagedsync.NewMiningState(&config.Miner)
 	backend.pendingBlocks = miner.PendingResultCh
@@ -973,6 +1017,7 @@ func New(ctx context.Context, stack *node.Node, config *ethconfig.Config, logger
 	}
 
 	backend.stagedSync = stagedsync.New(config.Sync, backend.syncStages, backend.syncUnwindOrder, backend.syncPruneOrder, logger, stages.ModeApplyingBlocks)
+	backend.stagedSync.SetEvents(backend.notifications.Events)
 
 	hook := stages2.NewHook(backend.sentryCtx, backend.chainDB, backend.notifications, backend.stagedSync, backend.blockReader, backend.chainConfig, backend.logger, backend.sentriesClient.SetStatus)
 
@@ -1144,7 +1189,7 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config, chainConfig
 		}
 	}
 
-	s.apiList = jsonrpc.APIList(chainKv, s.ethRpcClient, s.txPoolRpcClient, s.miningRpcClient, s.rpcFilters, s.rpcDaemonStateCache, blockReader, &httpRpcCfg, s.engine, s.logger, s.polygonBridge, s.heimdallService)
+	s.apiList = jsonrpc.APIList(ctx, chainKv, s.ethRpcClient, s.txPoolRpcClient, s.miningRpcClient, s.rpcFilters, s.rpcDaemonStateCache, blockReader, &httpRpcCfg, s.engine, s.logger, s.polygonBridge, s.heimdallService)
 
 	if config.SilkwormRpcDaemon && httpRpcCfg.Enabled {
 		interface_log_settings := silkworm.RpcInterfaceLogSettings{
@@ -1539,6 +1584,10 @@ func setUpBlockReader(ctx context.Context, db kv.RwDB, dirs datadir.Dirs, snConf
 		return nil, nil, nil, nil, nil, nil, nil, err
 	}
 	if allSegmentsDownloadComplete {
+		// on restart, the files are opened here, before the snapshots stage
+		if err := snapshotsync.VerifyAttestations(ctx, dirs, snConfig.Snapshot, logger); err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
 		allSnapshots.OptimisticalyOpenFolder()
 		if chainConfig.Bor != nil {
 			allBorSnapshots.OptimisticalyOpenFolder()
@@ -1689,6 +1738,17 @@ func (s *Ethereum) Start() error {
 		})
 	}
 
+	if s.userOpPool != nil {
+		s.bgComponentsEg.Go(func() error {
+			defer s.logger.Info("user operation pool goroutine terminated")
+			err := s.userOpPool.Run(s.sentryCtx, s.rpcFilters)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				s.logger.Error("userOpPool.Run error", "err", err)
+			}
+			return err
+		})
+	}
+
 	return nil
 }
 
@@ -45,6 +45,7 @@ import (
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/txnprovider/shutter"
 	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+	"github.com/erigontech/erigon/txnprovider/userop/useropcfg"
 )
 
 // BorDefaultMinerGasPrice defines the minimum gas price for bor validators to mine a transaction.
@@ -52,6 +53,7 @@ var BorDefaultMinerGasPrice = big.NewInt(25 * params.GWei)
 
 // FullNodeGPO contains default gasprice oracle settings for full node.
 var FullNodeGPO = gaspricecfg.Config{
+	Strategy:         gaspricecfg.StrategyPercentile,
 	Blocks:           20,
 	Default:          big.NewInt(0),
 	Percentile:       60,
@@ -63,6 +65,7 @@ var FullNodeGPO = gaspricecfg.Config{
 
 // LightClientGPO contains default gasprice oracle settings for light client.
 var LightClientGPO = gaspricecfg.Config{
+	Strategy:         gaspricecfg.StrategyPercentile,
 	Blocks:           2,
 	Percentile:       60,
 	MaxHeaderHistory: 300,
@@ -80,6 +83,7 @@ var Defaults = Config{
 		//LoopBlockLimit:             100_000,
 		ParallelStateFlushing: true,
 		ChaosMonkey:           false,
+		CompactionMaxDefer:    10 * time.Minute,
 	},
 	Ethash: ethashcfg.Config{
 		CachesInMem:      2,
@@ -96,6 +100,7 @@ var Defaults = Config{
 		Recommit: 3 * time.Second,
 	},
 	TxPool:      txpoolcfg.DefaultConfig,
+	UserOps:     useropcfg.DefaultConfig,
 	RPCGasCap:   50000000,
 	GPO:         FullNodeGPO,
 	RPCTxFeeCap: 1, // 1 ether
@@ -143,6 +148,10 @@ type BlocksFreezing struct {
 	DisableDownloadE3 bool // disable download state snapshots
 	DownloaderAddr    string
 	ChainName         string
+
+	Manifests            []string // urls or paths of the signed snapshot manifests, the files are verified against before use
+	ManifestPublishers   []string // addresses of the publishers trusted to sign the manifests
+	QuarantineMismatches bool     // move the files not matching the manifests aside instead of refusing to start
 }
 
 func (s BlocksFreezing) String() string {
@@ -213,6 +222,7 @@ type Config struct {
 	// Transaction pool options
 	TxPool  txpoolcfg.Config
 	Shutter shutter.Config
+	UserOps useropcfg.Config // ERC-4337 user operation mempool
 
 	// Gas Price Oracle options
 	GPO gaspricecfg.Config
@@ -243,7 +253,11 @@ type Config struct {
 	// Consensus layer
 	InternalCL bool
 
-	OverridePragueTime *big.Int `toml:",omitempty"`
+	OverrideShanghaiTime *big.Int         `toml:",omitempty"`
+	OverrideCancunTime   *big.Int         `toml:",omitempty"`
+	OverridePragueTime   *big.Int         `toml:",omitempty"`
+	OverrideOsakaTime    *big.Int         `toml:",omitempty"`
+	OverrideEipTimes     map[int]*big.Int `toml:",omitempty"`
 
 	// Embedded Silkworm support
 	SilkwormExecution            bool
@@ -282,4 +296,9 @@ type Sync struct {
 
 	ChaosMonkey              bool
 	AlwaysGenerateChangesets bool
+	RecordPreimages          bool // store keccak256 preimages of touched account addresses and storage keys
+
+	// CompactionRPCLatency defers building and merging of state files while p99 latency of RPC calls is above, 0 - disabled
+	CompactionRPCLatency time.Duration
+	CompactionMaxDefer   time.Duration // compaction deferred for longer runs anyway
 }
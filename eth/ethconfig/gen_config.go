@@ -18,6 +18,7 @@ import (
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/txnprovider/shutter"
 	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+	"github.com/erigontech/erigon/txnprovider/userop/useropcfg"
 )
 
 // MarshalTOML marshals as TOML.
@@ -42,6 +43,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Aura                                chain.AuRaConfig
 		TxPool                              txpoolcfg.Config
 		Shutter                             shutter.Config
+		UserOps                             useropcfg.Config
 		GPO                                 gaspricecfg.Config
 		RPCGasCap                           uint64  `toml:",omitempty"`
 		RPCTxFeeCap                         float64 `toml:",omitempty"`
@@ -54,7 +56,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		PolygonSyncStage                    bool
 		Ethstats                            string
 		InternalCL                          bool
-		OverridePragueTime                  *big.Int `toml:",omitempty"`
+		OverrideShanghaiTime                *big.Int         `toml:",omitempty"`
+		OverrideCancunTime                  *big.Int         `toml:",omitempty"`
+		OverridePragueTime                  *big.Int         `toml:",omitempty"`
+		OverrideOsakaTime                   *big.Int         `toml:",omitempty"`
+		OverrideEipTimes                    map[int]*big.Int `toml:",omitempty"`
 		SilkwormExecution                   bool
 		SilkwormRpcDaemon                   bool
 		SilkwormSentry                      bool
@@ -90,6 +96,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Aura = c.Aura
 	enc.TxPool = c.TxPool
 	enc.Shutter = c.Shutter
+	enc.UserOps = c.UserOps
 	enc.GPO = c.GPO
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
@@ -102,7 +109,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.PolygonSyncStage = c.PolygonSyncStage
 	enc.Ethstats = c.Ethstats
 	enc.InternalCL = c.InternalCL
+	enc.OverrideShanghaiTime = c.OverrideShanghaiTime
+	enc.OverrideCancunTime = c.OverrideCancunTime
 	enc.OverridePragueTime = c.OverridePragueTime
+	enc.OverrideOsakaTime = c.OverrideOsakaTime
+	enc.OverrideEipTimes = c.OverrideEipTimes
 	enc.SilkwormExecution = c.SilkwormExecution
 	enc.SilkwormRpcDaemon = c.SilkwormRpcDaemon
 	enc.SilkwormSentry = c.SilkwormSentry
@@ -142,6 +153,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Aura                                *chain.AuRaConfig
 		TxPool                              *txpoolcfg.Config
 		Shutter                             *shutter.Config
+		UserOps                             *useropcfg.Config
 		GPO                                 *gaspricecfg.Config
 		RPCGasCap                           *uint64  `toml:",omitempty"`
 		RPCTxFeeCap                         *float64 `toml:",omitempty"`
@@ -154,7 +166,11 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		PolygonSyncStage                    *bool
 		Ethstats                            *string
 		InternalCL                          *bool
-		OverridePragueTime                  *big.Int `toml:",omitempty"`
+		OverrideShanghaiTime                *big.Int         `toml:",omitempty"`
+		OverrideCancunTime                  *big.Int         `toml:",omitempty"`
+		OverridePragueTime                  *big.Int         `toml:",omitempty"`
+		OverrideOsakaTime                   *big.Int         `toml:",omitempty"`
+		OverrideEipTimes                    map[int]*big.Int `toml:",omitempty"`
 		SilkwormExecution                   *bool
 		SilkwormRpcDaemon                   *bool
 		SilkwormSentry                      *bool
@@ -231,6 +247,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Shutter != nil {
 		c.Shutter = *dec.Shutter
 	}
+	if dec.UserOps != nil {
+		c.UserOps = *dec.UserOps
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
@@ -267,9 +286,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.InternalCL != nil {
 		c.InternalCL = *dec.InternalCL
 	}
+	if dec.OverrideShanghaiTime != nil {
+		c.OverrideShanghaiTime = dec.OverrideShanghaiTime
+	}
+	if dec.OverrideCancunTime != nil {
+		c.OverrideCancunTime = dec.OverrideCancunTime
+	}
 	if dec.OverridePragueTime != nil {
 		c.OverridePragueTime = dec.OverridePragueTime
 	}
+	if dec.OverrideOsakaTime != nil {
+		c.OverrideOsakaTime = dec.OverrideOsakaTime
+	}
+	if dec.OverrideEipTimes != nil {
+		c.OverrideEipTimes = dec.OverrideEipTimes
+	}
 	if dec.SilkwormExecution != nil {
 		c.SilkwormExecution = *dec.SilkwormExecution
 	}
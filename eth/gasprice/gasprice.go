@@ -23,7 +23,9 @@ import (
 	"container/heap"
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/holiman/uint256"
 
@@ -48,9 +50,16 @@ type OracleBackend interface {
 	PendingBlockAndReceipts() (*types.Block, types.Receipts)
 }
 
+// PoolBackend is implemented by oracle backends with access to the txpool, required by the mempool strategy
+type PoolBackend interface {
+	// PendingTips returns effective tips of the pending sub-pool transactions at the given base fee
+	PendingTips(ctx context.Context, baseFee *uint256.Int) ([]*uint256.Int, error)
+}
+
+// Cache keeps the latest suggested price of each strategy with the head block hash it was computed for
 type Cache interface {
-	GetLatest() (libcommon.Hash, *big.Int)
-	SetLatest(hash libcommon.Hash, price *big.Int)
+	GetLatest(strategy string) (libcommon.Hash, *big.Int)
+	SetLatest(strategy string, hash libcommon.Hash, price *big.Int)
 }
 
 // Oracle recommends gas prices based on the content of recent
@@ -62,6 +71,7 @@ type Oracle struct {
 	maxPrice    *big.Int
 	ignorePrice *big.Int
 	cache       Cache
+	strategy    string
 
 	checkBlocks                       int
 	percentile                        int
@@ -98,6 +108,11 @@ func NewOracle(backend OracleBackend, params gaspricecfg.Config, cache Cache, lo
 		log.Warn("Sanitizing invalid gasprice oracle ignore price", "provided", params.IgnorePrice, "updated", ignorePrice)
 	}
 
+	strategy := params.Strategy
+	if strategy == "" {
+		strategy = gaspricecfg.StrategyPercentile
+	}
+
 	setBorDefaultGpoIgnorePrice(backend.ChainConfig(), params, log)
 
 	return &Oracle{
@@ -108,6 +123,7 @@ func NewOracle(backend OracleBackend, params gaspricecfg.Config, cache Cache, lo
 		checkBlocks:      blocks,
 		percentile:       percent,
 		cache:            cache,
+		strategy:         strategy,
 		maxHeaderHistory: params.MaxHeaderHistory,
 		maxBlockHistory:  params.MaxBlockHistory,
 		log:              log,
@@ -119,7 +135,11 @@ func NewOracle(backend OracleBackend, params gaspricecfg.Config, cache Cache, lo
 // NODE: if caller wants legacy txn SuggestedPrice, we need to add
 // baseFee to the returned bigInt
 func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
-	latestHead, latestPrice := oracle.cache.GetLatest()
+	suggest, ok := strategies[oracle.strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown gas price strategy %q, supported: %s", oracle.strategy, strings.Join(Strategies(), ", "))
+	}
+	latestHead, latestPrice := oracle.cache.GetLatest(oracle.strategy)
 	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if err != nil {
 		return latestPrice, err
@@ -134,38 +154,24 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 	}
 
 	// check again, the last request could have populated the cache
-	latestHead, latestPrice = oracle.cache.GetLatest()
+	latestHead, latestPrice = oracle.cache.GetLatest(oracle.strategy)
 	if latestHead == headHash {
 		return latestPrice, nil
 	}
 
-	number := head.Number.Uint64()
-	txPrices := make(sortingHeap, 0, sampleNumber*oracle.checkBlocks)
-	for txPrices.Len() < sampleNumber*oracle.checkBlocks && number > 0 {
-		err := oracle.getBlockPrices(ctx, number, sampleNumber, oracle.ignorePrice, &txPrices)
-		if err != nil {
-			return latestPrice, err
-		}
-		number--
+	tip, err := suggest(ctx, oracle, head)
+	if err != nil {
+		return latestPrice, err
 	}
 	price := latestPrice
-	if txPrices.Len() > 0 {
-		// Item with this position needs to be extracted from the sorting heap
-		// so we pop all the items before it
-		percentilePosition := (txPrices.Len() - 1) * oracle.percentile / 100
-		for i := 0; i < percentilePosition; i++ {
-			heap.Pop(&txPrices)
-		}
-	}
-	if txPrices.Len() > 0 {
-		// Don't need to pop it, just take from the top of the heap
-		price = txPrices[0].ToBig()
+	if tip != nil {
+		price = tip.ToBig()
 	}
 	if price.Cmp(oracle.maxPrice) > 0 {
 		price = new(big.Int).Set(oracle.maxPrice)
 	}
 
-	oracle.cache.SetLatest(headHash, price)
+	oracle.cache.SetLatest(oracle.strategy, headHash, price)
 
 	return price, nil
 }
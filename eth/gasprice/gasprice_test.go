@@ -99,3 +99,49 @@ func TestSuggestPrice(t *testing.T) {
 		t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
 	}
 }
+
+func TestSuggestPriceStrategies(t *testing.T) {
+	m := newTestBackend(t)
+	baseApi := jsonrpc.NewBaseApi(nil, kvcache.NewDummy(), m.BlockReader, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs, nil)
+
+	tx, _ := m.DB.BeginTemporalRo(m.Ctx)
+	defer tx.Rollback()
+
+	// one cache shared by all strategies, as in the rpc daemon
+	cache := jsonrpc.NewGasPriceCache()
+	suggest := func(strategy string) (*big.Int, error) {
+		config := gaspricecfg.Config{
+			Strategy:   strategy,
+			Blocks:     2,
+			Percentile: 60,
+			Default:    big.NewInt(params.GWei),
+		}
+		oracle := gasprice.NewOracle(jsonrpc.NewGasPriceOracleBackend(tx, baseApi), config, cache, log.New())
+		return oracle.SuggestTipCap(context.Background())
+	}
+
+	got, err := suggest(gaspricecfg.StrategyPercentile)
+	if err != nil {
+		t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+	}
+	if expect := big.NewInt(params.GWei * int64(30)); got.Cmp(expect) != 0 {
+		t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
+	}
+
+	// The per-block tips sampled are: 31G, 32G, weighted 1/3 and 2/3
+	got, err = suggest(gaspricecfg.StrategyEWMA)
+	if err != nil {
+		t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+	}
+	if expect := big.NewInt(params.GWei * 95 / 3); got.Cmp(expect) != 0 {
+		t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
+	}
+
+	// Backend without txpool
+	if _, err = suggest(gaspricecfg.StrategyMempool); err == nil {
+		t.Fatal("expected error for mempool strategy without txpool")
+	}
+	if _, err = suggest("unknown"); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
@@ -31,7 +31,15 @@ var (
 	DefaultMaxPrice = big.NewInt(500 * params.GWei)
 )
 
+// Gas price oracle strategies
+const (
+	StrategyPercentile = "percentile" // percentile of the lowest tips of the last blocks
+	StrategyMempool    = "mempool"    // same as percentile, raised to the percentile of pending pool tips if higher
+	StrategyEWMA       = "ewma"       // exponentially weighted moving average of per-block percentile tips
+)
+
 type Config struct {
+	Strategy         string // default strategy, one of Strategy* constants
 	Blocks           int
 	Percentile       int
 	MaxHeaderHistory int
@@ -0,0 +1,150 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon/consensus/misc"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/gasprice/gaspricecfg"
+)
+
+// strategy suggests a tip for the block following head, nil if there is nothing to sample
+type strategy func(ctx context.Context, oracle *Oracle, head *types.Header) (*uint256.Int, error)
+
+var strategies = map[string]strategy{
+	gaspricecfg.StrategyPercentile: percentileStrategy,
+	gaspricecfg.StrategyMempool:    mempoolStrategy,
+	gaspricecfg.StrategyEWMA:       ewmaStrategy,
+}
+
+// Strategies returns the names of the supported gas price strategies
+func Strategies() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsValidStrategy reports whether name is one of the supported gas price strategies
+func IsValidStrategy(name string) bool {
+	_, ok := strategies[name]
+	return ok
+}
+
+// percentileStrategy takes the configured percentile of the lowest tips sampled from the last blocks
+func percentileStrategy(ctx context.Context, oracle *Oracle, head *types.Header) (*uint256.Int, error) {
+	number := head.Number.Uint64()
+	txPrices := make(sortingHeap, 0, sampleNumber*oracle.checkBlocks)
+	for txPrices.Len() < sampleNumber*oracle.checkBlocks && number > 0 {
+		if err := oracle.getBlockPrices(ctx, number, sampleNumber, oracle.ignorePrice, &txPrices); err != nil {
+			return nil, err
+		}
+		number--
+	}
+	return percentileOf(txPrices, oracle.percentile), nil
+}
+
+// mempoolStrategy is percentileStrategy raised to the configured percentile of
+// the pending pool tips, so that the suggestion keeps up with a congested pool
+func mempoolStrategy(ctx context.Context, oracle *Oracle, head *types.Header) (*uint256.Int, error) {
+	pool, ok := oracle.backend.(PoolBackend)
+	if !ok {
+		return nil, errors.New("gas price oracle backend has no txpool access")
+	}
+	tip, err := percentileStrategy(ctx, oracle, head)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseFee *uint256.Int
+	if cc := oracle.backend.ChainConfig(); cc != nil && cc.IsLondon(head.Number.Uint64()+1) {
+		baseFee, _ = uint256.FromBig(misc.CalcBaseFee(cc, head))
+	}
+	tips, err := pool.PendingTips(ctx, baseFee)
+	if err != nil {
+		return nil, err
+	}
+	ignoreUnder, _ := uint256.FromBig(oracle.ignorePrice)
+	poolPrices := make(sortingHeap, 0, len(tips))
+	for _, t := range tips {
+		if ignoreUnder != nil && t.Lt(ignoreUnder) {
+			continue
+		}
+		poolPrices = append(poolPrices, t)
+	}
+	heap.Init(&poolPrices)
+	if poolTip := percentileOf(poolPrices, oracle.percentile); poolTip != nil && (tip == nil || poolTip.Gt(tip)) {
+		tip = poolTip
+	}
+	return tip, nil
+}
+
+// ewmaStrategy averages the per-block percentile tips of the last blocks with
+// exponentially decreasing weights, newer blocks weighing more
+func ewmaStrategy(ctx context.Context, oracle *Oracle, head *types.Header) (*uint256.Int, error) {
+	number := head.Number.Uint64()
+	blockTips := make([]*uint256.Int, 0, oracle.checkBlocks)
+	for len(blockTips) < oracle.checkBlocks && number > 0 {
+		txPrices := make(sortingHeap, 0, sampleNumber)
+		if err := oracle.getBlockPrices(ctx, number, sampleNumber, oracle.ignorePrice, &txPrices); err != nil {
+			return nil, err
+		}
+		if tip := percentileOf(txPrices, oracle.percentile); tip != nil {
+			blockTips = append(blockTips, tip)
+		}
+		number--
+	}
+	if len(blockTips) == 0 {
+		return nil, nil
+	}
+
+	// smoothing factor alpha = 2/(N+1): avg = (2*tip + (N-1)*avg) / (N+1)
+	n := uint64(oracle.checkBlocks)
+	weight, newWeight, total := uint256.NewInt(n-1), uint256.NewInt(2), uint256.NewInt(n+1)
+	avg := new(uint256.Int).Set(blockTips[len(blockTips)-1])
+	for i := len(blockTips) - 2; i >= 0; i-- {
+		avg.Mul(avg, weight)
+		avg.Add(avg, new(uint256.Int).Mul(blockTips[i], newWeight))
+		avg.Div(avg, total)
+	}
+	return avg, nil
+}
+
+// percentileOf returns the item at the given percentile of the heap, nil if the heap is empty.
+// The heap is consumed.
+func percentileOf(h sortingHeap, percentile int) *uint256.Int {
+	if h.Len() == 0 {
+		return nil
+	}
+	// Item with this position needs to be extracted from the sorting heap
+	// so we pop all the items before it
+	percentilePosition := (h.Len() - 1) * percentile / 100
+	for i := 0; i < percentilePosition; i++ {
+		heap.Pop(&h)
+	}
+	// Don't need to pop it, just take from the top of the heap
+	return h[0]
+}
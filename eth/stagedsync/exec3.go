@@ -543,6 +543,7 @@ Loop:
 
 				// use history reader instead of state reader to catch up to the tx where we left off
 				HistoryExecution: offsetFromBlockBeginning > 0 && txIndex < int(offsetFromBlockBeginning),
+				RecordPreimages:  cfg.syncCfg.RecordPreimages,
 
 				BlockReceipts: blockReceipts,
 
@@ -150,6 +150,37 @@ type parallelExecutor struct {
 	logEvery                 *time.Ticker
 	slowDownLimit            *time.Ticker
 	progress                 *Progress
+
+	// preimages of applied txs, accumulated by applyLoop and written on commit
+	preimagesLock sync.Mutex
+	preimages     map[common.Hash][]byte
+}
+
+func (pe *parallelExecutor) addPreimages(preimages map[common.Hash][]byte) {
+	if len(preimages) == 0 {
+		return
+	}
+	pe.preimagesLock.Lock()
+	defer pe.preimagesLock.Unlock()
+	if pe.preimages == nil {
+		pe.preimages = make(map[common.Hash][]byte, len(preimages))
+	}
+	for hash, preimage := range preimages {
+		pe.preimages[hash] = preimage
+	}
+}
+
+func (pe *parallelExecutor) flushPreimages(tx kv.RwTx) error {
+	pe.preimagesLock.Lock()
+	defer pe.preimagesLock.Unlock()
+	if len(pe.preimages) == 0 {
+		return nil
+	}
+	if err := rawdb.WritePreimages(tx, pe.preimages); err != nil {
+		return err
+	}
+	pe.preimages = nil
+	return nil
 }
 
 func (pe *parallelExecutor) applyLoop(ctx context.Context, maxTxNum uint64, blockComplete *atomic.Bool, errCh chan error) {
@@ -320,6 +351,9 @@ func (pe *parallelExecutor) rwLoop(ctx context.Context, maxTxNum uint64, logger
 				t2 = time.Since(tt)
 				tt = time.Now()
 
+				if err := pe.flushPreimages(tx); err != nil {
+					return err
+				}
 				if err := pe.doms.Flush(ctx, tx); err != nil {
 					return err
 				}
@@ -362,6 +396,9 @@ func (pe *parallelExecutor) rwLoop(ctx context.Context, maxTxNum uint64, logger
 			logger.Info("Committed", "time", time.Since(commitStart), "drain", t0, "drain_and_lock", t1, "rs.flush", t2, "agg.flush", t3, "tx.commit", t4)
 		}
 	}
+	if err := pe.flushPreimages(tx); err != nil {
+		return err
+	}
 	if err := pe.doms.Flush(ctx, tx); err != nil {
 		return err
 	}
@@ -441,6 +478,7 @@ func (pe *parallelExecutor) processResultQueue(ctx context.Context, inputTxNum u
 		if err := pe.rs.ApplyLogsAndTraces4(txTask, pe.rs.Domains()); err != nil {
 			return outputTxNum, conflicts, triggers, processedBlockNum, false, fmt.Errorf("StateV3.Apply: %w", err)
 		}
+		pe.addPreimages(txTask.Preimages)
 		processedBlockNum = txTask.BlockNum
 		if !stopedAtBlockEnd {
 			stopedAtBlockEnd = txTask.Final
@@ -12,6 +12,7 @@ import (
 	state2 "github.com/erigontech/erigon-lib/state"
 	"github.com/erigontech/erigon/consensus"
 	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/core/rawdb/rawtemporaldb"
 	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
@@ -73,6 +74,12 @@ func (se *serialExecutor) execute(ctx context.Context, tasks []*state.TxTask) (c
 						return fmt.Errorf("%w, txnIdx=%d, %v", consensus.ErrInvalidBlock, txTask.TxIndex, err) //same as in stage_exec.go
 					}
 				}
+				if !se.isMining && !se.inMemExec && !se.skipPostEvaluation && !se.execStage.CurrentSyncCycle.IsInitialCycle {
+					// receipts of the valid block only, RPC serves them at head without re-execution
+					if err := se.cfg.notifications.RecentReceipts.Add(se.cfg.chainConfig, txTask.Header, txTask.Txs, txTask.BlockReceipts); err != nil {
+						se.logger.Warn(fmt.Sprintf("[%s] can't keep recent receipts", se.execStage.LogPrefix()), "block", txTask.BlockNum, "err", err)
+					}
+				}
 
 				se.outputBlockNum.SetUint64(txTask.BlockNum)
 			}
@@ -137,6 +144,12 @@ func (se *serialExecutor) execute(ctx context.Context, tasks []*state.TxTask) (c
 			}
 		}
 
+		if txTask.RecordPreimages {
+			if err := rawdb.WritePreimages(se.applyTx, txTask.Preimages); err != nil {
+				return false, err
+			}
+		}
+
 		// MA applystate
 		if err := se.rs.ApplyState4(ctx, txTask); err != nil {
 			return false, err
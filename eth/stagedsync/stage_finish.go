@@ -129,11 +129,11 @@ func PruneFinish(u *PruneState, tx kv.RwTx, cfg FinishCfg, ctx context.Context)
 	return nil
 }
 
-// [from,to)
-func NotifyNewHeaders(ctx context.Context, notifyFrom, notifyTo uint64, notifier ChainEventNotifier, tx kv.Tx, logger log.Logger) error {
+// [from,to), returns the RLP of the notified headers
+func NotifyNewHeaders(ctx context.Context, notifyFrom, notifyTo uint64, notifier ChainEventNotifier, tx kv.Tx, logger log.Logger) ([][]byte, error) {
 	if notifier == nil {
 		logger.Trace("RPC Daemon notification channel not set. No headers notifications will be sent")
-		return nil
+		return nil, nil
 	}
 	// Notify all headers we have (either canonical or not) in a maximum range span of 1024
 	var headersRlp [][]byte
@@ -152,12 +152,12 @@ func NotifyNewHeaders(ctx context.Context, notifyFrom, notifyTo uint64, notifier
 		return libcommon.Stopped(ctx.Done())
 	}); err != nil {
 		logger.Error("RPC Daemon notification failed", "err", err)
-		return err
+		return nil, err
 	}
 
 	if len(headersRlp) > 0 {
 		notifier.OnNewHeader(headersRlp)
 		logger.Debug("RPC Daemon notified of new headers", "from", notifyFrom-1, "to", notifyTo, "amount", len(headersRlp))
 	}
-	return nil
+	return headersRlp, nil
 }
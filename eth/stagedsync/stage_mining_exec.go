@@ -44,6 +44,7 @@ import (
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/turbo/services"
+	"github.com/erigontech/erigon/turbo/shards"
 	"github.com/erigontech/erigon/txnprovider"
 )
 
@@ -122,11 +123,13 @@ func SpawnMiningExecStage(s *StageState, txc wrap.TxContainer, cfg MiningExecCfg
 	}
 
 	if len(preparedTxns) > 0 {
+		included := len(current.Txns)
 		logs, _, err := addTransactionsToMiningBlock(ctx, logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, preparedTxns, cfg.miningState.MiningConfig.Etherbase, ibs, cfg.interrupt, cfg.payloadId, logger)
 		if err != nil {
 			return err
 		}
 		NotifyPendingLogs(logPrefix, cfg.notifier, logs, logger)
+		notifySoftConfirmations(cfg, current, included)
 	} else {
 
 		yielded := mapset.NewSet[[32]byte]()
@@ -156,11 +159,13 @@ func SpawnMiningExecStage(s *StageState, txc wrap.TxContainer, cfg MiningExecCfg
 			}
 
 			if len(txns) > 0 {
+				included := len(current.Txns)
 				logs, stop, err := addTransactionsToMiningBlock(ctx, logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, txns, cfg.miningState.MiningConfig.Etherbase, ibs, cfg.interrupt, cfg.payloadId, logger)
 				if err != nil {
 					return err
 				}
 				NotifyPendingLogs(logPrefix, cfg.notifier, logs, logger)
+				notifySoftConfirmations(cfg, current, included)
 				if stop {
 					break
 				}
@@ -274,6 +279,9 @@ func getNextTransactions(
 		txnprovider.WithBlobGasTarget(remainingBlobGas),
 		txnprovider.WithTxnIdsFilter(alreadyYielded),
 	}
+	if header.BaseFee != nil {
+		provideOpts = append(provideOpts, txnprovider.WithBaseFee(uint256.MustFromBig(header.BaseFee)))
+	}
 
 	txns, err := cfg.txnProvider.ProvideTxns(ctx, provideOpts...)
 	if err != nil {
@@ -554,3 +562,17 @@ func NotifyPendingLogs(logPrefix string, notifier ChainEventNotifier, logs types
 	}
 	notifier.OnNewPendingLogs(logs)
 }
+
+// notifySoftConfirmations streams the transactions included into the block being built since `from`, in sequencer mode
+func notifySoftConfirmations(cfg MiningExecCfg, current *MiningBlock, from int) {
+	if !cfg.miningState.MiningConfig.SoftConfirmations || cfg.notifier == nil || from >= len(current.Txns) ||
+		!cfg.notifier.HasSoftConfirmationSubscriptions() {
+		return
+	}
+	header := types.CopyHeader(current.Header)
+	confirmations := make([]shards.SoftConfirmation, 0, len(current.Txns)-from)
+	for i := from; i < len(current.Txns); i++ {
+		confirmations = append(confirmations, shards.SoftConfirmation{PayloadId: cfg.payloadId, Header: header, Txn: current.Txns[i], Receipt: current.Receipts[i]})
+	}
+	cfg.notifier.OnSoftConfirmations(confirmations)
+}
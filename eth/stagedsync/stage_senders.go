@@ -351,11 +351,12 @@ func recoverSenders(ctx context.Context, logPrefix string, cryptoContext *secp25
 		body := job.body
 		signer := types.MakeSigner(config, job.blockNumber, job.blockTime)
 		job.senders = make([]byte, len(body.Transactions)*length.Addr)
-		for i, txn := range body.Transactions {
-			from, err := signer.SenderWithContext(cryptoContext, txn)
-			if err != nil {
+		// recovered in one batch, which is offloaded to the signature recovery accelerator if one is selected
+		senders, errs := signer.SendersWithContext(cryptoContext, body.Transactions)
+		for i, from := range senders {
+			if errs[i] != nil {
 				job.err = fmt.Errorf("%w: error recovering sender for tx=%x, %v",
-					consensus.ErrInvalidBlock, txn.Hash(), err)
+					consensus.ErrInvalidBlock, body.Transactions[i].Hash(), errs[i])
 				break
 			}
 			copy(job.senders[i*length.Addr:], from[:])
@@ -313,6 +313,7 @@ func DownloadAndIndexSnapshotsIfNeed(s *StageState, ctx context.Context, tx kv.R
 	// It's ok to notify before tx.Commit(), because RPCDaemon does read list of files by gRPC (not by reading from db)
 	if cfg.notifier.Events != nil {
 		cfg.notifier.Events.OnNewSnapshot()
+		cfg.notifier.Events.OnSyncEvent(shards.SyncEvent{Type: shards.SyncEventSnapshotsDownloaded, Block: cfg.blockReader.FrozenBlocks()})
 	}
 
 	if cfg.silkworm != nil {
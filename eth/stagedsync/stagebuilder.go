@@ -26,6 +26,7 @@ import (
 	"github.com/erigontech/erigon-lib/wrap"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/shards"
 )
 
 type ChainEventNotifier interface {
@@ -33,6 +34,8 @@ type ChainEventNotifier interface {
 	OnNewPendingLogs(types.Logs)
 	OnLogs([]*remote.SubscribeLogsReply)
 	HasLogSubsriptions() bool
+	OnSoftConfirmations([]shards.SoftConfirmation)
+	HasSoftConfirmationSubscriptions() bool
 }
 
 func MiningStages(
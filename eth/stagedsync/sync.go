@@ -32,6 +32,7 @@ import (
 
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
+	"github.com/erigontech/erigon/turbo/shards"
 )
 
 type Sync struct {
@@ -50,6 +51,7 @@ type Sync struct {
 	logger        log.Logger
 	stagesIdsList []string
 	mode          stages.Mode
+	events        *shards.Events // nil if stage transitions are not reported
 }
 
 type Timing struct {
@@ -65,6 +67,16 @@ func (s *Sync) Len() int {
 
 func (s *Sync) Cfg() ethconfig.Sync { return s.cfg }
 
+// SetEvents makes the sync report stage starts, finishes and unwinds as shards.SyncEvent
+func (s *Sync) SetEvents(events *shards.Events) { s.events = events }
+
+func (s *Sync) onSyncEvent(typ string, stage stages.SyncStage, block uint64, took time.Duration) {
+	if s.events == nil {
+		return
+	}
+	s.events.OnSyncEvent(shards.SyncEvent{Type: typ, Stage: string(stage), Block: block, Duration: took})
+}
+
 func (s *Sync) UnwindPoint() uint64 {
 	return *s.unwindPoint
 }
@@ -525,6 +537,7 @@ func (s *Sync) runStage(stage *Stage, db kv.RwDB, txc wrap.TxContainer, initialC
 	if err != nil {
 		return err
 	}
+	s.onSyncEvent(shards.SyncEventStageStart, stage.ID, stageState.BlockNumber, 0)
 
 	if err = stage.Forward(badBlockUnwind, stageState, s, txc, s.logger); err != nil {
 		wrappedError := fmt.Errorf("[%s] %w", s.LogPrefix(), err)
@@ -539,6 +552,13 @@ func (s *Sync) runStage(stage *Stage, db kv.RwDB, txc wrap.TxContainer, initialC
 	} else {
 		s.logger.Debug(fmt.Sprintf("[%s] DONE", logPrefix), "in", took)
 	}
+	if s.events != nil {
+		after, err := s.StageState(stage.ID, txc.Tx, db, initialCycle, firstCycle)
+		if err != nil {
+			return err
+		}
+		s.onSyncEvent(shards.SyncEventStageFinish, stage.ID, after.BlockNumber, took)
+	}
 	s.timings = append(s.timings, Timing{stage: stage.ID, took: took})
 	return nil
 }
@@ -571,6 +591,7 @@ func (s *Sync) unwindStage(initialCycle bool, stage *Stage, db kv.RwDB, txc wrap
 		logPrefix := s.LogPrefix()
 		s.logger.Info(fmt.Sprintf("[%s] Unwind done", logPrefix), "in", took)
 	}
+	s.onSyncEvent(shards.SyncEventStageUnwind, stage.ID, unwind.UnwindPoint, took)
 	s.timings = append(s.timings, Timing{isUnwind: true, stage: stage.ID, took: took})
 	return nil
 }
@@ -20,10 +20,13 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon/eth/tracers/logger"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
 )
 
@@ -32,11 +35,57 @@ type TraceConfig struct {
 	*logger.LogConfig
 	Tracer         *string
 	TracerConfig   *json.RawMessage
+	Tracers        []TracerSpec // Runs several tracers in a single replay, the result is keyed by TracerSpec.ResultKey
 	Timeout        *string
 	Reexec         *uint64
 	NoRefunds      *bool // Turns off gas refunds when tracing
+	SchemaVersion  *bool // Wraps the whole output of debug_trace* and trace_* as {"schemaVersion": tracers.SchemaVersion, "result": ...}
+	SourceMaps     *bool // Annotates struct logs and call frames with positions in the uploaded contract sources
 	StateOverrides *ethapi.StateOverrides
 
+	SourceLocator sourcemap.Locator `json:"-"` // Set by the API from SourceMaps
+
 	BorTraceEnabled *bool
 	TxIndex         *hexutil.Uint
 }
+
+// TracerSpec is one of the tracers of a multiplexed trace
+type TracerSpec struct {
+	Tracer       string           `json:"tracer"`
+	TracerConfig *json.RawMessage `json:"tracerConfig"`
+	Key          *string          `json:"key"` // Result key, the tracer name by default - set it to run a tracer twice
+}
+
+// WithSchemaVersion returns true if the output is wrapped with the schema version
+func (c *TraceConfig) WithSchemaVersion() bool {
+	return c != nil && c.SchemaVersion != nil && *c.SchemaVersion
+}
+
+// ResultKey is the key of the tracer result in the multiplexed trace result
+func (s TracerSpec) ResultKey() string {
+	if s.Key != nil {
+		return *s.Key
+	}
+	return s.Tracer
+}
+
+// UnmarshalJSON also accepts a bare array of tracer specs as the config, a shorthand for {"tracers": [...]}
+func (c *TraceConfig) UnmarshalJSON(input []byte) error {
+	if trimmed := bytes.TrimSpace(input); len(trimmed) > 0 && trimmed[0] == '[' {
+		*c = TraceConfig{}
+		if err := json.Unmarshal(trimmed, &c.Tracers); err != nil {
+			return err
+		}
+		if len(c.Tracers) == 0 {
+			return errors.New("empty list of tracers")
+		}
+		return nil
+	}
+	type traceConfig TraceConfig
+	var dec traceConfig
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*c = TraceConfig(dec)
+	return nil
+}
@@ -27,6 +27,7 @@ import (
 	libcommon "github.com/erigontech/erigon-lib/common"
 
 	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 )
 
 // JsonStreamLogger is an EVM state logger and implements Tracer.
@@ -47,6 +48,7 @@ type JsonStreamLogger struct {
 	output    []byte //nolint
 	err       error  //nolint
 	env       *vm.EVM
+	locator   sourcemap.Locator
 }
 
 // NewStructLogger returns a new logger
@@ -63,6 +65,11 @@ func NewJsonStreamLogger(cfg *LogConfig, ctx context.Context, stream *jsoniter.S
 	return logger
 }
 
+// SetSourceLocator makes the logger annotate every struct log with its position in the contract sources
+func (l *JsonStreamLogger) SetSourceLocator(locator sourcemap.Locator) {
+	l.locator = locator
+}
+
 func (l *JsonStreamLogger) CaptureTxStart(gasLimit uint64) {}
 
 func (l *JsonStreamLogger) CaptureTxEnd(restGas uint64) {}
@@ -145,6 +152,31 @@ func (l *JsonStreamLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint6
 		l.stream.WriteObjectField("error")
 		l.stream.WriteString(err.Error())
 	}
+	if l.locator != nil {
+		addr := contract.Address()
+		if contract.CodeAddr != nil {
+			addr = *contract.CodeAddr
+		}
+		if loc := l.locator.Locate(addr, contract.Code, pc); loc != nil {
+			l.stream.WriteMore()
+			l.stream.WriteObjectField("source")
+			l.stream.WriteObjectStart()
+			l.stream.WriteObjectField("file")
+			l.stream.WriteString(loc.File)
+			l.stream.WriteMore()
+			l.stream.WriteObjectField("line")
+			l.stream.WriteInt(loc.Line)
+			l.stream.WriteMore()
+			l.stream.WriteObjectField("column")
+			l.stream.WriteInt(loc.Column)
+			if loc.Jump != "" {
+				l.stream.WriteMore()
+				l.stream.WriteObjectField("jump")
+				l.stream.WriteString(loc.Jump)
+			}
+			l.stream.WriteObjectEnd()
+		}
+	}
 	if !l.cfg.DisableStack {
 		l.stream.WriteMore()
 		l.stream.WriteObjectField("stack")
@@ -32,6 +32,7 @@ import (
 	"github.com/erigontech/erigon/accounts/abi"
 	"github.com/erigontech/erigon/core/vm"
 	"github.com/erigontech/erigon/eth/tracers"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 )
 
 //go:generate gencodec -type callFrame -field-override callFrameMarshaling -out gen_callframe_json.go
@@ -59,6 +60,8 @@ type callFrame struct {
 	Revertal string            `json:"revertReason,omitempty"`
 	Calls    []callFrame       `json:"calls,omitempty" rlp:"optional"`
 	Logs     []callLog         `json:"logs,omitempty" rlp:"optional"`
+	// Source position of the instruction that made the call, set when source maps are requested
+	Source *sourcemap.Location `json:"source,omitempty" rlp:"-"`
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
 	Value *big.Int `json:"value,omitempty" rlp:"optional"`
@@ -113,6 +116,12 @@ type callTracer struct {
 	logIndex    uint64
 	logGaps     map[uint64]int
 	precompiles []bool // keep track of whether scopes are for pre-compiles or not
+
+	// last executed instruction, to locate the calls in the sources
+	locator  sourcemap.Locator
+	lastPC   uint64
+	lastCode []byte
+	lastAddr libcommon.Address
 }
 
 func defaultCallTracerConfig() callTracerConfig {
@@ -138,7 +147,11 @@ func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, e
 	}
 	// First callframe contains txn context info
 	// and is populated on start and end.
-	return &callTracer{callstack: make([]callFrame, 1), config: config}, nil
+	t := &callTracer{callstack: make([]callFrame, 1), config: config}
+	if ctx != nil {
+		t.locator = ctx.SourceLocator
+	}
+	return t, nil
 }
 
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
@@ -176,6 +189,12 @@ func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
 
 // CaptureState implements the EVMLogger interface to trace a single step of VM execution.
 func (t *callTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.locator != nil {
+		t.lastPC, t.lastCode, t.lastAddr = pc, scope.Contract.Code, scope.Contract.Address()
+		if scope.Contract.CodeAddr != nil {
+			t.lastAddr = *scope.Contract.CodeAddr
+		}
+	}
 	// Only logs need to be captured via opcode processing
 	if !t.config.WithLog {
 		return
@@ -243,6 +262,9 @@ func (t *callTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libc
 	if value != nil {
 		call.Value = value.ToBig()
 	}
+	if t.locator != nil {
+		call.Source = t.locator.Locate(t.lastAddr, t.lastCode, t.lastPC)
+	}
 	t.callstack = append(t.callstack, call)
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
 	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 )
 
 var _ = (*callFrameMarshaling)(nil)
@@ -16,19 +17,20 @@ var _ = (*callFrameMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (c callFrame) MarshalJSON() ([]byte, error) {
 	type callFrame0 struct {
-		Type       vm.OpCode      `json:"-"`
-		From       common.Address `json:"from"`
-		Gas        hexutil.Uint64 `json:"gas"`
-		GasUsed    hexutil.Uint64 `json:"gasUsed"`
-		To         common.Address `json:"to,omitempty" rlp:"optional"`
-		Input      hexutil.Bytes  `json:"input" rlp:"optional"`
-		Output     hexutil.Bytes  `json:"output,omitempty" rlp:"optional"`
-		Error      string         `json:"error,omitempty" rlp:"optional"`
-		Revertal   string         `json:"revertReason,omitempty"`
-		Calls      []callFrame    `json:"calls,omitempty" rlp:"optional"`
-		Logs       []callLog      `json:"logs,omitempty" rlp:"optional"`
-		Value      *hexutil.Big   `json:"value,omitempty" rlp:"optional"`
-		TypeString string         `json:"type"`
+		Type       vm.OpCode           `json:"-"`
+		From       common.Address      `json:"from"`
+		Gas        hexutil.Uint64      `json:"gas"`
+		GasUsed    hexutil.Uint64      `json:"gasUsed"`
+		To         common.Address      `json:"to,omitempty" rlp:"optional"`
+		Input      hexutil.Bytes       `json:"input" rlp:"optional"`
+		Output     hexutil.Bytes       `json:"output,omitempty" rlp:"optional"`
+		Error      string              `json:"error,omitempty" rlp:"optional"`
+		Revertal   string              `json:"revertReason,omitempty"`
+		Calls      []callFrame         `json:"calls,omitempty" rlp:"optional"`
+		Logs       []callLog           `json:"logs,omitempty" rlp:"optional"`
+		Source     *sourcemap.Location `json:"source,omitempty" rlp:"-"`
+		Value      *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
+		TypeString string              `json:"type"`
 	}
 	var enc callFrame0
 	enc.Type = c.Type
@@ -42,6 +44,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.Revertal = c.Revertal
 	enc.Calls = c.Calls
 	enc.Logs = c.Logs
+	enc.Source = c.Source
 	enc.Value = (*hexutil.Big)(c.Value)
 	enc.TypeString = c.TypeString()
 	return json.Marshal(&enc)
@@ -50,18 +53,19 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (c *callFrame) UnmarshalJSON(input []byte) error {
 	type callFrame0 struct {
-		Type     *vm.OpCode      `json:"-"`
-		From     *common.Address `json:"from"`
-		Gas      *hexutil.Uint64 `json:"gas"`
-		GasUsed  *hexutil.Uint64 `json:"gasUsed"`
-		To       *common.Address `json:"to,omitempty" rlp:"optional"`
-		Input    *hexutil.Bytes  `json:"input" rlp:"optional"`
-		Output   *hexutil.Bytes  `json:"output,omitempty" rlp:"optional"`
-		Error    *string         `json:"error,omitempty" rlp:"optional"`
-		Revertal *string         `json:"revertReason,omitempty"`
-		Calls    []callFrame     `json:"calls,omitempty" rlp:"optional"`
-		Logs     []callLog       `json:"logs,omitempty" rlp:"optional"`
-		Value    *hexutil.Big    `json:"value,omitempty" rlp:"optional"`
+		Type     *vm.OpCode          `json:"-"`
+		From     *common.Address     `json:"from"`
+		Gas      *hexutil.Uint64     `json:"gas"`
+		GasUsed  *hexutil.Uint64     `json:"gasUsed"`
+		To       *common.Address     `json:"to,omitempty" rlp:"optional"`
+		Input    *hexutil.Bytes      `json:"input" rlp:"optional"`
+		Output   *hexutil.Bytes      `json:"output,omitempty" rlp:"optional"`
+		Error    *string             `json:"error,omitempty" rlp:"optional"`
+		Revertal *string             `json:"revertReason,omitempty"`
+		Calls    []callFrame         `json:"calls,omitempty" rlp:"optional"`
+		Logs     []callLog           `json:"logs,omitempty" rlp:"optional"`
+		Source   *sourcemap.Location `json:"source,omitempty" rlp:"-"`
+		Value    *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
 	}
 	var dec callFrame0
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -100,6 +104,9 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.Logs != nil {
 		c.Logs = dec.Logs
 	}
+	if dec.Source != nil {
+		c.Source = dec.Source
+	}
 	if dec.Value != nil {
 		c.Value = (*big.Int)(dec.Value)
 	}
@@ -63,6 +63,12 @@ func newMuxTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, er
 	return &muxTracer{names: names, tracers: objects}, nil
 }
 
+// NewMuxTracer returns a mux tracer running the given tracers in order, its
+// result is keyed by the given names.
+func NewMuxTracer(names []string, objects []tracers.Tracer) tracers.Tracer {
+	return &muxTracer{names: names, tracers: objects}
+}
+
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
 func (t *muxTracer) CaptureStart(env *vm.EVM, from libcommon.Address, to libcommon.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
 	for _, t := range t.tracers {
@@ -0,0 +1,174 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+const (
+	maxContracts    = 10_000   // max amount of contracts with uploaded sources
+	maxContractSize = 16 << 20 // max size of the source map and sources of one contract
+	maxTotalSize    = 1 << 30  // max size of the source maps and sources of all contracts
+)
+
+// Registry keeps uploaded contract sources, persisted to the directory: one file per contract, so that an upload
+// rewrites the file of the contract only. The contents of the sources are not kept in memory.
+type Registry struct {
+	dir string
+
+	mu        sync.RWMutex
+	maps      map[common.Address]*contractMap
+	sizes     map[common.Address]int
+	totalSize int
+}
+
+// NewRegistry reads the registered contracts saved in `dir`, a missing directory is an empty registry
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{
+		dir:   dir,
+		maps:  map[common.Address]*contractMap{},
+		sizes: map[common.Address]int{},
+	}
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var c Contract
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("parse source map file %s: %w", f.Name(), err)
+		}
+		if f.Name() != contractFileName(c.Address) {
+			return nil, fmt.Errorf("source map file %s is of another contract %x", f.Name(), c.Address)
+		}
+		m, err := parse(&c)
+		if err != nil {
+			return nil, fmt.Errorf("source map of %x: %w", c.Address, err)
+		}
+		size := contractSize(&c)
+		r.maps[c.Address], r.sizes[c.Address] = m, size
+		r.totalSize += size
+	}
+	return r, nil
+}
+
+// Add uploads sources of the contract, replacing the previous ones
+func (r *Registry) Add(c Contract) error {
+	if err := validate(&c); err != nil {
+		return err
+	}
+	m, err := parse(&c)
+	if err != nil {
+		return err
+	}
+	size := contractSize(&c)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.maps[c.Address]; !ok && len(r.maps) >= maxContracts {
+		return fmt.Errorf("too many contracts with source maps, max %d", maxContracts)
+	}
+	totalSize := r.totalSize - r.sizes[c.Address] + size
+	if totalSize > maxTotalSize {
+		return fmt.Errorf("source maps and sources of all contracts are too large: %d bytes, max %d", totalSize, maxTotalSize)
+	}
+	if err := r.save(&c); err != nil {
+		return err
+	}
+	r.maps[c.Address], r.sizes[c.Address], r.totalSize = m, size, totalSize
+	return nil
+}
+
+// Remove forgets sources of the contract, returns false if there were none
+func (r *Registry) Remove(addr common.Address) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.maps[addr]; !ok {
+		return false, nil
+	}
+	if err := os.Remove(filepath.Join(r.dir, contractFileName(addr))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	r.totalSize -= r.sizes[addr]
+	delete(r.maps, addr)
+	delete(r.sizes, addr)
+	return true, nil
+}
+
+// Addresses returns the contracts with uploaded sources, sorted
+func (r *Registry) Addresses() []common.Address {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]common.Address, 0, len(r.maps))
+	for addr := range r.maps {
+		res = append(res, addr)
+	}
+	sort.Slice(res, func(i, j int) bool { return bytes.Compare(res[i][:], res[j][:]) < 0 })
+	return res
+}
+
+// Locate implements Locator
+func (r *Registry) Locate(addr common.Address, code []byte, pc uint64) *Location {
+	r.mu.RLock()
+	m := r.maps[addr]
+	r.mu.RUnlock()
+	if m == nil {
+		return nil
+	}
+	return m.locate(code, pc)
+}
+
+func contractFileName(addr common.Address) string {
+	return fmt.Sprintf("%x.json", addr)
+}
+
+// save writes the file of the contract, must be called with r.mu held
+func (r *Registry) save(c *Contract) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(r.dir, contractFileName(c.Address))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
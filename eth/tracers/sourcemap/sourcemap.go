@@ -0,0 +1,192 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sourcemap maps executed instructions back to contract sources using solc source maps,
+// see https://docs.soliditylang.org/en/latest/internals/source_mappings.html
+package sourcemap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// Location is the source position an instruction was compiled from
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`           // 1-based
+	Column int    `json:"column"`         // 1-based, in bytes
+	Jump   string `json:"jump,omitempty"` // "i" - jump into a function, "o" - return from a function
+}
+
+// Locator maps the instruction at pc of the code executed at the address to its source position,
+// nil if the source of the code is unknown
+type Locator interface {
+	Locate(addr common.Address, code []byte, pc uint64) *Location
+}
+
+// Source is one source file of a contract
+type Source struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Contract is the source of the deployed code of an address
+type Contract struct {
+	Address   common.Address `json:"address"`
+	SourceMap string         `json:"sourceMap"` // runtime source map, as output by `solc --combined-json srcmap-runtime`
+	Sources   []Source       `json:"sources"`   // indexed by the source ids used in the source map
+}
+
+type entry struct {
+	start, file int
+	jump        byte
+}
+
+// contractMap is the parsed source map of a contract, the contents of the sources are not kept
+type contractMap struct {
+	entries    []entry
+	names      []string // of every source
+	sizes      []int    // of every source
+	lineStarts [][]int  // offsets of the line starts of every source
+
+	mu      sync.Mutex
+	codeLen int
+	indexes []int // instruction index of every code byte, -1 for push data
+}
+
+func parse(c *Contract) (*contractMap, error) {
+	m := &contractMap{names: make([]string, len(c.Sources)), sizes: make([]int, len(c.Sources)), lineStarts: make([][]int, len(c.Sources))}
+	for i, s := range c.Sources {
+		m.names[i], m.sizes[i] = s.Name, len(s.Content)
+		starts := []int{0}
+		for j := 0; j < len(s.Content); j++ {
+			if s.Content[j] == '\n' {
+				starts = append(starts, j+1)
+			}
+		}
+		m.lineStarts[i] = starts
+	}
+	// empty fields keep the value of the previous entry
+	prev := entry{file: -1}
+	for i, item := range strings.Split(c.SourceMap, ";") {
+		e := prev
+		for j, field := range strings.Split(item, ":") {
+			if field == "" {
+				continue
+			}
+			switch j {
+			case 0, 2:
+				v, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("source map entry %d: %w", i, err)
+				}
+				if j == 0 {
+					e.start = v
+				} else {
+					e.file = v
+				}
+			case 3:
+				e.jump = field[0]
+			}
+		}
+		if e.file >= len(c.Sources) {
+			return nil, fmt.Errorf("source map entry %d: unknown source %d", i, e.file)
+		}
+		m.entries = append(m.entries, e)
+		prev = e
+	}
+	return m, nil
+}
+
+func (m *contractMap) locate(code []byte, pc uint64) *Location {
+	if pc >= uint64(len(code)) {
+		return nil
+	}
+	idx := m.instructionIndex(code, pc)
+	if idx < 0 || idx >= len(m.entries) {
+		return nil
+	}
+	e := m.entries[idx]
+	if e.file < 0 || e.start < 0 || e.start > m.sizes[e.file] { // compiler generated code
+		return nil
+	}
+	starts := m.lineStarts[e.file]
+	line := sort.SearchInts(starts, e.start+1) - 1
+	loc := &Location{File: m.names[e.file], Line: line + 1, Column: e.start - starts[line] + 1}
+	if e.jump == 'i' || e.jump == 'o' {
+		loc.Jump = string(e.jump)
+	}
+	return loc
+}
+
+// instructionIndex returns the index of the instruction at pc, the indexes are cached for the deployed code.
+// Code of another length (init code of the contract creation) is indexed on every call.
+func (m *contractMap) instructionIndex(code []byte, pc uint64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.indexes == nil {
+		m.codeLen, m.indexes = len(code), instructionIndexes(code)
+	}
+	if m.codeLen == len(code) {
+		return m.indexes[pc]
+	}
+	return instructionIndexes(code)[pc]
+}
+
+func instructionIndexes(code []byte) []int {
+	indexes := make([]int, len(code))
+	n := 0
+	for pc := 0; pc < len(code); n++ {
+		indexes[pc] = n
+		next := pc + 1
+		if op := code[pc]; op >= 0x60 && op <= 0x7f { // PUSH1..PUSH32
+			next += int(op) - 0x5f
+		}
+		for i := pc + 1; i < next && i < len(code); i++ {
+			indexes[i] = -1
+		}
+		pc = next
+	}
+	return indexes
+}
+
+func validate(c *Contract) error {
+	if c.SourceMap == "" {
+		return errors.New("empty source map")
+	}
+	if len(c.Sources) == 0 {
+		return errors.New("no sources")
+	}
+	if size := contractSize(c); size > maxContractSize {
+		return fmt.Errorf("source map and sources are too large: %d bytes, max %d", size, maxContractSize)
+	}
+	return nil
+}
+
+// contractSize is the size of the source map and the sources of the contract
+func contractSize(c *Contract) int {
+	size := len(c.SourceMap)
+	for _, s := range c.Sources {
+		size += len(s.Content)
+	}
+	return size
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package sourcemap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+func TestLocate(t *testing.T) {
+	addr := common.HexToAddress("0x1")
+	// PUSH1 0x80, PUSH1 0x40, MSTORE, STOP
+	code := []byte{0x60, 0x80, 0x60, 0x40, 0x52, 0x00}
+	contract := Contract{
+		Address:   addr,
+		SourceMap: "0:50:0:-;;34:5::i;::-1",
+		Sources:   []Source{{Name: "A.sol", Content: "contract A {\n  function f() {\n    x = 1;\n  }\n}\n"}},
+	}
+
+	dir := filepath.Join(t.TempDir(), "sourcemaps")
+	r, err := NewRegistry(dir)
+	require.NoError(t, err)
+	require.NoError(t, r.Add(contract))
+
+	require.Equal(t, &Location{File: "A.sol", Line: 1, Column: 1}, r.Locate(addr, code, 0))
+	require.Nil(t, r.Locate(addr, code, 1)) // push data
+	require.Equal(t, &Location{File: "A.sol", Line: 1, Column: 1}, r.Locate(addr, code, 2))
+	require.Equal(t, &Location{File: "A.sol", Line: 3, Column: 5, Jump: "i"}, r.Locate(addr, code, 4))
+	require.Nil(t, r.Locate(addr, code, 5)) // compiler generated
+	require.Nil(t, r.Locate(common.HexToAddress("0x2"), code, 0))
+
+	// reloaded from the files
+	r, err = NewRegistry(dir)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{addr}, r.Addresses())
+	require.Equal(t, &Location{File: "A.sol", Line: 3, Column: 5, Jump: "i"}, r.Locate(addr, code, 4))
+
+	removed, err := r.Remove(addr)
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.Nil(t, r.Locate(addr, code, 0))
+	r, err = NewRegistry(dir)
+	require.NoError(t, err)
+	require.Empty(t, r.Addresses())
+
+	contract.SourceMap = "0:50:1:-"
+	require.Error(t, r.Add(contract)) // unknown source
+}
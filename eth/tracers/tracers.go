@@ -27,14 +27,28 @@ import (
 	libcommon "github.com/erigontech/erigon-lib/common"
 
 	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 )
 
+// SchemaVersion is the version of the trace output layout of debug_trace* and trace_* methods, reported when
+// requested by the trace config. It is bumped whenever the output of any built-in tracer or of trace_* methods
+// changes in a way that breaks byte-stable diffing: added, removed or renamed fields, changed encoding or ordering.
+const SchemaVersion = 1
+
+// Versioned is the trace output wrapped with its SchemaVersion
+type Versioned struct {
+	SchemaVersion int `json:"schemaVersion"`
+	Result        any `json:"result"`
+}
+
 // Context contains some contextual infos for a transaction execution that is not
 // available from within the EVM object.
 type Context struct {
 	BlockHash libcommon.Hash // Hash of the block the txn is contained within (zero if dangling txn or call)
 	TxIndex   int            // Index of the transaction within a block (zero if dangling txn or call)
 	TxHash    libcommon.Hash // Hash of the transaction being traced (zero if dangling call)
+
+	SourceLocator sourcemap.Locator // Maps executed instructions to contract sources (nil if not requested)
 }
 
 // Tracer interface extends vm.EVMLogger and additionally
@@ -20,10 +20,12 @@
 package tracers_test
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
 	"math/big"
+	mrand "math/rand"
 	"testing"
 
 	"github.com/holiman/uint256"
@@ -135,3 +137,106 @@ func TestPrestateTracerCreate2(t *testing.T) {
 		t.Fatalf("Expected 0x60f3f640a8508fc6a86d45df051962668e1e8ac7 in result")
 	}
 }
+
+// TestTraceOutputStable traces the same transaction twice, reading the state touched by it in a different order
+// before each run, and expects byte-identical output
+func TestTraceOutputStable(t *testing.T) {
+	contract := libcommon.HexToAddress("0x00000000000000000000000000000000c0ffee00")
+	others := []libcommon.Address{
+		libcommon.HexToAddress("0x00000000000000000000000000000000000000a1"),
+		libcommon.HexToAddress("0x00000000000000000000000000000000000000a2"),
+		libcommon.HexToAddress("0x00000000000000000000000000000000000000a3"),
+		libcommon.HexToAddress("0x00000000000000000000000000000000000000a4"),
+	}
+	// SLOAD slots 0..7, BALANCE of the other accounts, then SSTORE slot 1 := 2 and slot 5 := 9
+	var code []byte
+	storage := map[libcommon.Hash]libcommon.Hash{}
+	for i := byte(0); i < 8; i++ {
+		code = append(code, byte(vm.PUSH1), i, byte(vm.SLOAD), byte(vm.POP))
+		storage[libcommon.BytesToHash([]byte{i})] = libcommon.BytesToHash([]byte{i + 1})
+	}
+	for _, addr := range others {
+		code = append(code, byte(vm.PUSH20))
+		code = append(code, addr[:]...)
+		code = append(code, byte(vm.BALANCE), byte(vm.POP))
+	}
+	code = append(code, byte(vm.PUSH1), 2, byte(vm.PUSH1), 1, byte(vm.SSTORE), byte(vm.PUSH1), 9, byte(vm.PUSH1), 5, byte(vm.SSTORE), byte(vm.STOP))
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	txn, err := types.SignTx(types.NewTransaction(0, contract, uint256.NewInt(0), 1_000_000, uint256.NewInt(1), nil), *signer, key)
+	require.NoError(t, err)
+	origin := crypto.PubkeyToAddress(key.PublicKey)
+
+	alloc := types.GenesisAlloc{
+		contract: {Code: code, Storage: storage, Balance: big.NewInt(1)},
+		origin:   {Balance: big.NewInt(500000000000000)},
+	}
+	for i, addr := range others {
+		alloc[addr] = types.GenesisAccount{Balance: big.NewInt(int64(i + 1)), Nonce: uint64(i)}
+	}
+	blockCtx := evmtypes.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    consensus.Transfer,
+		BlockNumber: 8000000,
+		Time:        5,
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		BaseFee:     uint256.NewInt(0),
+		BlobBaseFee: uint256.NewInt(50000),
+	}
+	rules := params.AllProtocolChanges.Rules(blockCtx.BlockNumber, blockCtx.Time)
+	m := mock.Mock(t)
+
+	trace := func(seed int64, name, config string) []byte {
+		tx, err := m.DB.BeginRw(m.Ctx)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		statedb, err := tests.MakePreState(rules, tx, alloc, blockCtx.BlockNumber)
+		require.NoError(t, err)
+
+		accessed := append([]libcommon.Address{contract, origin}, others...)
+		slots := make([]libcommon.Hash, 0, len(storage))
+		for slot := range storage {
+			slots = append(slots, slot)
+		}
+		rnd := mrand.New(mrand.NewSource(seed))
+		rnd.Shuffle(len(accessed), func(i, j int) { accessed[i], accessed[j] = accessed[j], accessed[i] })
+		rnd.Shuffle(len(slots), func(i, j int) { slots[i], slots[j] = slots[j], slots[i] })
+		for _, addr := range accessed {
+			_, err := statedb.GetBalance(addr)
+			require.NoError(t, err)
+		}
+		for _, slot := range slots {
+			var v uint256.Int
+			require.NoError(t, statedb.GetState(contract, &slot, &v))
+		}
+
+		tracer, err := tracers.New(name, new(tracers.Context), json.RawMessage(config))
+		require.NoError(t, err)
+		evm := vm.NewEVM(blockCtx, evmtypes.TxContext{Origin: origin, GasPrice: uint256.NewInt(1)}, statedb, params.AllProtocolChanges, vm.Config{Debug: true, Tracer: tracer})
+		msg, err := txn.AsMessage(*signer, nil, rules)
+		require.NoError(t, err)
+		st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(txn.GetGasLimit()))
+		_, err = st.TransitionDb(false, false)
+		require.NoError(t, err)
+		res, err := tracer.GetResult()
+		require.NoError(t, err)
+		return res
+	}
+
+	for _, tt := range []struct{ name, config string }{
+		{"prestateTracer", `{}`},
+		{"prestateTracer", `{"diffMode": true}`},
+		{"callTracer", `{"withLog": true}`},
+		{"muxTracer", `{"prestateTracer": {"diffMode": true}, "callTracer": {}, "4byteTracer": {}}`},
+	} {
+		first := trace(1, tt.name, tt.config)
+		for seed := int64(2); seed < 6; seed++ {
+			if again := trace(seed, tt.name, tt.config); !bytes.Equal(first, again) {
+				t.Fatalf("%s %s: output depends on state access order:\n%s\n%s", tt.name, tt.config, first, again)
+			}
+		}
+	}
+}
@@ -45,6 +45,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/log/v3"
 
+	"github.com/erigontech/erigon-lib/diskutils"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/mdbx"
 	"github.com/erigontech/erigon-lib/kv/memdb"
@@ -398,11 +399,42 @@ func OpenDatabase(ctx context.Context, config *nodecfg.Config, label kv.Label, n
 		}); err != nil {
 			return nil, err
 		}
+		if !readonly {
+			logGeometryAdvice(ctx, db, dbPath, logger)
+		}
 	}
 
 	return db, nil
 }
 
+// logGeometryAdvice logs page size and size settings fitting the observed chaindata and device better than the current ones
+func logGeometryAdvice(ctx context.Context, db kv.RwDB, dbPath string, logger log.Logger) {
+	mdbxDB, ok := db.(*mdbx.MdbxKV)
+	if !ok {
+		return
+	}
+	g, err := mdbxDB.Geometry(ctx)
+	if err != nil {
+		logger.Debug("[db] can't read geometry", "err", err)
+		return
+	}
+	storage, known := diskutils.StorageForDirPath(dbPath)
+	advice := mdbx.AdviseGeometry(g, storage, known)
+	if !advice.Changed(g) {
+		return
+	}
+	logger.Info("[db] chaindata settings can be tuned", "reasons", strings.Join(advice.Reasons, "; "))
+	if advice.GrowthStep != g.GrowthStep {
+		logger.Info("[db] advised", "flag", "--"+utils.DbGrowthStepFlag.Name, "current", g.GrowthStep.HR(), "advised", advice.GrowthStep.HR())
+	}
+	if advice.SizeLimit != g.SizeLimit {
+		logger.Info("[db] advised", "flag", "--"+utils.DbSizeLimitFlag.Name, "current", g.SizeLimit.HR(), "advised", advice.SizeLimit.HR())
+	}
+	if advice.PageSizeChanged(g) {
+		logger.Info("[db] advised page size requires migration, run `erigon db tune --apply` with the node stopped", "current", g.PageSize.HR(), "advised", advice.PageSize.HR())
+	}
+}
+
 // ResolvePath returns the absolute path of a resource in the instance directory.
 func (n *Node) ResolvePath(x string) string {
 	return n.config.ResolvePath(x)
@@ -37,4 +37,8 @@ type MiningConfig struct {
 	GasLimit   uint64            // Target gas limit for mined blocks.
 	GasPrice   *big.Int          // Minimum gas price for mining a transaction
 	Recommit   time.Duration     // The time interval for miner to re-create mining work.
+	Ordering   string            // Transaction ordering policy of built blocks (see txnprovider/ordering), the txpool order if empty
+	// Sequencer mode: stream transactions included in the block being built with provisional receipts
+	// (eth_subscribe "softConfirmations") before the block is sealed
+	SoftConfirmations bool
 }
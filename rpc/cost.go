@@ -0,0 +1,76 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// CostField is the member of responses reporting the resources spent by the server on the request
+const CostField = "x-erigon-cost"
+
+type costKey struct{}
+
+// Cost accumulates the resources spent on one request. Methods record them with AddDBReads / AddGas
+// on CostFromContext, which is nil when cost accounting is disabled.
+type Cost struct {
+	start   time.Time
+	dbReads atomic.Uint64
+	gas     atomic.Uint64
+}
+
+// CostReport is the value of CostField
+type CostReport struct {
+	DBReads    uint64  `json:"dbReads"`    // point reads and opened cursors / ranges
+	Gas        uint64  `json:"gas"`        // gas of the EVM executions
+	WallTimeMs float64 `json:"wallTimeMs"` // time spent in the method
+}
+
+// CostFromContext returns the cost of the request being served, nil if cost accounting is disabled
+func CostFromContext(ctx context.Context) *Cost {
+	c, _ := ctx.Value(costKey{}).(*Cost)
+	return c
+}
+
+func withCost(ctx context.Context) (context.Context, *Cost) {
+	c := &Cost{start: time.Now()}
+	return context.WithValue(ctx, costKey{}, c), c
+}
+
+// AddDBReads records database reads, no-op on nil
+func (c *Cost) AddDBReads(n uint64) {
+	if c != nil {
+		c.dbReads.Add(n)
+	}
+}
+
+// AddGas records gas used by an EVM execution, no-op on nil
+func (c *Cost) AddGas(gas uint64) {
+	if c != nil {
+		c.gas.Add(gas)
+	}
+}
+
+func (c *Cost) report() *CostReport {
+	return &CostReport{
+		DBReads:    c.dbReads.Load(),
+		Gas:        c.gas.Load(),
+		WallTimeMs: float64(time.Since(c.start).Microseconds()) / 1000,
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+type costTestService struct{}
+
+func (s *costTestService) Execute(ctx context.Context, gas uint64, reads uint64) (uint64, error) {
+	CostFromContext(ctx).AddGas(gas)
+	CostFromContext(ctx).AddDBReads(reads)
+	return gas, nil
+}
+
+func (s *costTestService) Stream(ctx context.Context, gas uint64, stream *jsoniter.Stream) error {
+	CostFromContext(ctx).AddGas(gas)
+	stream.WriteUint64(gas)
+	return nil
+}
+
+func TestCostAccounting(t *testing.T) {
+	logger := log.New()
+	server := NewServer(50, false /* traceRequests */, false /* debugSingleRequests */, false, logger, 100)
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("cost", new(costTestService)))
+
+	call := func(body string) []byte {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.Bytes()
+	}
+	execute := `{"jsonrpc":"2.0","id":1,"method":"cost_execute","params":[21000,3]}`
+	stream := `{"jsonrpc":"2.0","id":2,"method":"cost_stream","params":[50000]}`
+
+	var msg jsonrpcMessage
+	require.NoError(t, json.Unmarshal(call(execute), &msg))
+	require.Nil(t, msg.Cost)
+
+	server.SetCostAccounting(true)
+	msg = jsonrpcMessage{}
+	require.NoError(t, json.Unmarshal(call(execute), &msg))
+	require.Equal(t, "21000", string(msg.Result))
+	require.NotNil(t, msg.Cost)
+	require.Equal(t, uint64(21000), msg.Cost.Gas)
+	require.Equal(t, uint64(3), msg.Cost.DBReads)
+
+	msg = jsonrpcMessage{}
+	require.NoError(t, json.Unmarshal(call(stream), &msg))
+	require.Equal(t, "50000", string(msg.Result))
+	require.NotNil(t, msg.Cost)
+	require.Equal(t, uint64(50000), msg.Cost.Gas)
+	require.Zero(t, msg.Cost.DBReads)
+
+	// every request of a batch has its own cost
+	var batch []jsonrpcMessage
+	require.NoError(t, json.Unmarshal(call("["+execute+","+stream+"]"), &batch))
+	require.Len(t, batch, 2)
+	for _, msg := range batch {
+		require.NotNil(t, msg.Cost)
+		require.Equal(t, string(msg.Result), strconv.FormatUint(msg.Cost.Gas, 10))
+	}
+}
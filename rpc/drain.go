@@ -0,0 +1,112 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const drainPollInterval = 50 * time.Millisecond
+
+// DrainStatus is the progress of draining the server before shutdown, see Server.Drain
+type DrainStatus struct {
+	Draining    bool      `json:"draining"`
+	InFlight    int64     `json:"inFlight"`    // calls being served
+	Connections int       `json:"connections"` // open websocket and IPC connections
+	Deadline    time.Time `json:"deadline"`    // when the remaining calls are cancelled, zero if there is no deadline
+}
+
+type drainState struct {
+	draining   atomic.Bool
+	deadline   atomic.Int64 // unix nanoseconds, 0 if there is no deadline
+	retryAfter atomic.Int64 // nanoseconds
+}
+
+func (d *drainState) retryAfterSeconds() int {
+	return int(time.Duration(d.retryAfter.Load()).Seconds())
+}
+
+// Draining reports whether the server is being drained, see Drain
+func (s *Server) Draining() bool {
+	return s.drain.draining.Load()
+}
+
+// DrainStatus returns the progress of draining, meant to be reported by health checks
+func (s *Server) DrainStatus() DrainStatus {
+	status := DrainStatus{
+		Draining:    s.Draining(),
+		InFlight:    s.inFlight.Load(),
+		Connections: s.codecs.Cardinality(),
+	}
+	if deadline := s.drain.deadline.Load(); deadline != 0 {
+		status.Deadline = time.Unix(0, deadline)
+	}
+	return status
+}
+
+// StartDraining marks the server as draining without waiting for anything: health checks report it, new websocket
+// connections are refused and HTTP responses ask to close the connection. The deadline of ctx is reported as the
+// drain deadline. Drain calls it, calling it before lets the listeners stay open for a while.
+func (s *Server) StartDraining(ctx context.Context, retryAfter time.Duration) {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.drain.deadline.Store(deadline.UnixNano())
+	}
+	s.drain.retryAfter.Store(int64(retryAfter))
+	if !s.drain.draining.Swap(true) {
+		s.logger.Info("[rpc] draining", "inFlight", s.inFlight.Load(), "connections", s.codecs.Cardinality())
+	}
+}
+
+// Drain prepares the server for shutdown without failing requests of the clients (see StartDraining), then, once
+// the calls being served finish or ctx is done, websocket connections are closed with the "service restart" close
+// code and a "retry-after=<seconds>" reason telling clients when to reconnect. Listeners are expected to stop
+// accepting connections meanwhile, and Stop to be called afterwards.
+func (s *Server) Drain(ctx context.Context, retryAfter time.Duration) {
+	s.StartDraining(ctx, retryAfter)
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+wait:
+	for s.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			s.logger.Warn("[rpc] drain deadline reached, cancelling calls", "inFlight", s.inFlight.Load())
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	var conns []*websocketCodec
+	s.codecs.Each(func(c interface{}) bool {
+		if wc, ok := c.(*websocketCodec); ok {
+			conns = append(conns, wc)
+		}
+		return false
+	})
+	var wg sync.WaitGroup
+	for _, wc := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wc.closeForRestart(retryAfter)
+		}()
+	}
+	wg.Wait()
+	s.logger.Info("[rpc] drained", "websockets", len(conns))
+}
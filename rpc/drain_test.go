@@ -0,0 +1,181 @@
+// Copyright 2018 The go-ethereum Authors
+// (original work)
+// Copyright 2024 The Erigon Authors
+// (modifications)
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+
+	var (
+		srv     = newTestServer(logger)
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_sleep","params":[300000000]}`)); err != nil {
+		t.Fatal(err)
+	}
+	for srv.DrainStatus().InFlight == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drained := make(chan struct{})
+	go func() {
+		srv.Drain(ctx, 7*time.Second)
+		close(drained)
+	}()
+	for !srv.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	if status := srv.DrainStatus(); status.InFlight != 1 || status.Connections != 1 || status.Deadline.IsZero() {
+		t.Fatalf("wrong drain status: %+v", status)
+	}
+
+	// new connections are refused
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err == nil {
+		t.Fatal("connected while draining")
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "7" {
+		t.Fatalf("wrong response while draining: %v", resp)
+	}
+
+	// the call in flight is answered before the close frame
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("call in flight failed: %v", err)
+	}
+	if !strings.Contains(string(msg), `"id":1`) || strings.Contains(string(msg), `"error"`) {
+		t.Fatalf("wrong response: %s", msg)
+	}
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("expected close frame, got %v", err)
+	}
+	if closeErr.Code != websocket.CloseServiceRestart || closeErr.Text != "retry-after=7" {
+		t.Fatalf("wrong close frame: %v", closeErr)
+	}
+
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain didn't finish")
+	}
+	if status := srv.DrainStatus(); status.InFlight != 0 {
+		t.Fatalf("calls left in flight: %+v", status)
+	}
+}
+
+func TestDrainDeadline(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+
+	var (
+		srv     = newTestServer(logger)
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_block","params":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	for srv.DrainStatus().InFlight == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	srv.Drain(ctx, time.Second)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("drain took %v, longer than the deadline", elapsed)
+	}
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseServiceRestart {
+		t.Fatalf("expected close frame, got %v", err)
+	}
+}
+
+func TestStartDraining(t *testing.T) {
+	t.Parallel()
+	logger := log.New()
+
+	var (
+		srv     = newTestServer(logger)
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}, nil, false, logger))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.StartDraining(ctx, time.Second)
+	if status := srv.DrainStatus(); !status.Draining || status.Deadline.IsZero() {
+		t.Fatalf("wrong drain status: %+v", status)
+	}
+
+	// open connections are served until Drain
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_sleep","params":[1000]}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("call failed while draining: %v", err)
+	}
+	if !strings.Contains(string(msg), `"id":1`) || strings.Contains(string(msg), `"error"`) {
+		t.Fatalf("wrong response: %s", msg)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -76,6 +77,8 @@ type handler struct {
 	serverSubs          map[ID]*Subscription
 	maxBatchConcurrency uint
 	traceRequests       bool
+	costAccounting      bool          // whether to report the cost of requests in responses, see CostField
+	inFlight            *atomic.Int64 // calls being served, nil if not counted
 
 	//slow requests
 	slowLogThreshold time.Duration
@@ -331,9 +334,15 @@ func (h *handler) cancelServerSubscriptions(err error) {
 // startCallProc runs fn in a new goroutine and starts tracking it in the h.calls wait group.
 func (h *handler) startCallProc(fn func(*callProc)) {
 	h.callWG.Add(1)
+	if h.inFlight != nil {
+		h.inFlight.Add(1)
+	}
 	go func() {
 		ctx, cancel := context.WithCancel(h.rootCtx)
 		defer h.callWG.Done()
+		if h.inFlight != nil {
+			defer h.inFlight.Add(-1)
+		}
 		defer cancel()
 		fn(&callProc{ctx: ctx})
 	}()
@@ -491,6 +500,7 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 			failedReqeustGauge.Inc()
 		}
 		newRPCServingTimerMS(msg.Method, answer == nil || answer.Error == nil).ObserveDuration(start)
+		recentLatencies.add(msg.Method, start)
 	}
 	return answer
 }
@@ -530,12 +540,22 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jso
 
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value, stream *jsoniter.Stream) *jsonrpcMessage {
+	var cost *Cost
+	if h.costAccounting {
+		ctx, cost = withCost(ctx)
+	}
 	if !callb.streamable {
 		result, err := callb.call(ctx, msg.Method, args, stream)
+		var resp *jsonrpcMessage
 		if err != nil {
-			return msg.errorResponse(err)
+			resp = msg.errorResponse(err)
+		} else {
+			resp = msg.response(result)
 		}
-		return msg.response(result)
+		if cost != nil {
+			resp.Cost = cost.report()
+		}
+		return resp
 	}
 
 	stream.WriteObjectStart()
@@ -554,6 +574,11 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 		stream.WriteMore()
 		HandleError(err, stream)
 	}
+	if cost != nil {
+		stream.WriteMore()
+		stream.WriteObjectField(CostField)
+		stream.WriteVal(cost.report())
+	}
 	stream.WriteObjectEnd()
 	stream.Flush()
 	return nil
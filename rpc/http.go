@@ -194,28 +194,9 @@ type httpServerConn struct {
 
 func newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
 	conn := &httpServerConn{Writer: w, r: r}
-	// if the request is a GET request, and the body is empty, we turn the request into fake json rpc request, see below
-	// https://www.jsonrpc.org/historical/json-rpc-over-http.html#encoded-parameters
-	// we however allow for non base64 encoded parameters to be passed
 	if r.Method == http.MethodGet && r.ContentLength == 0 {
-		// default id 1
-		id := `1`
-		id_up := r.URL.Query().Get("id")
-		if id_up != "" {
-			id = id_up
-		}
-		method_up := r.URL.Query().Get("method")
-		params, _ := url.QueryUnescape(r.URL.Query().Get("params"))
-		param := []byte(params)
-		if pb, err := base64.URLEncoding.DecodeString(params); err == nil {
-			param = pb
-		}
 		buf := new(bytes.Buffer)
-		json.NewEncoder(buf).Encode(jsonrpcMessage{
-			ID:     json.RawMessage(id),
-			Method: method_up,
-			Params: param,
-		})
+		json.NewEncoder(buf).Encode(getRequestMessage(r))
 		conn.Reader = buf
 	} else {
 		// it's a post request or whatever, so just process it like normal
@@ -224,6 +205,29 @@ func newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
 	return NewCodec(conn)
 }
 
+// getRequestMessage turns the GET request with an empty body into fake json rpc request, see below
+// https://www.jsonrpc.org/historical/json-rpc-over-http.html#encoded-parameters
+// we however allow for non base64 encoded parameters to be passed
+func getRequestMessage(r *http.Request) *jsonrpcMessage {
+	// default id 1
+	id := `1`
+	id_up := r.URL.Query().Get("id")
+	if id_up != "" {
+		id = id_up
+	}
+	method_up := r.URL.Query().Get("method")
+	params, _ := url.QueryUnescape(r.URL.Query().Get("params"))
+	param := []byte(params)
+	if pb, err := base64.URLEncoding.DecodeString(params); err == nil {
+		param = pb
+	}
+	return &jsonrpcMessage{
+		ID:     json.RawMessage(id),
+		Method: method_up,
+		Params: param,
+	}
+}
+
 // Close does nothing and always returns nil.
 func (t *httpServerConn) Close() error { return nil }
 
@@ -239,9 +243,16 @@ func (t *httpServerConn) SetWriteDeadline(time.Time) error { return nil }
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Permit dumb empty requests for remote health-checks (AWS)
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
+		if s.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
+	if s.Draining() { // let keep-alive clients reconnect to another instance
+		w.Header().Set("Connection", "close")
+	}
 	if code, err := validateRequest(r); err != nil {
 		http.Error(w, err.Error(), code)
 		return
@@ -275,6 +286,17 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var capture *responseCapture
+	if s.responseCache != nil {
+		var served bool
+		if ctx, capture, served = s.responseCache.serveHTTP(ctx, w, r); served {
+			return
+		}
+		if capture != nil {
+			w = capture
+		}
+	}
+
 	w.Header().Set("content-type", contentType)
 	codec := newHTTPServerConn(r, w)
 	defer codec.Close()
@@ -283,6 +305,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		stream = jsoniter.NewStream(jsoniter.ConfigDefault, w, 4096)
 	}
 	s.serveSingleRequest(ctx, codec, stream)
+	if capture != nil {
+		capture.finish()
+	}
 }
 
 // validateRequest returns a non-zero response code and error message if the
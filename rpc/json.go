@@ -58,6 +58,7 @@ type jsonrpcMessage struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 	Error   *jsonError      `json:"error,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
+	Cost    *CostReport     `json:"x-erigon-cost,omitempty"`
 }
 
 func (msg *jsonrpcMessage) isNotification() bool {
@@ -0,0 +1,75 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	latencyWindowSize   = 1024             // max amount of requests RecentLatency is computed over
+	latencyWindowPeriod = 30 * time.Second // requests served earlier are not counted
+	latencyMinSamples   = 16               // less requests in the period mean there is no load
+)
+
+// recentLatencies keeps durations of the last calls served by the process, for RecentLatency
+var recentLatencies = &latencyWindow{}
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples [latencyWindowSize]latencySample
+	next    int
+}
+
+func (w *latencyWindow) add(method string, start time.Time) {
+	if strings.HasPrefix(method, "engine_") { // consensus layer calls are not the query load
+		return
+	}
+	w.mu.Lock()
+	w.samples[w.next] = latencySample{at: start, duration: time.Since(start)}
+	w.next = (w.next + 1) % latencyWindowSize
+	w.mu.Unlock()
+}
+
+func (w *latencyWindow) quantile(q float64, now time.Time) time.Duration {
+	durations := make([]time.Duration, 0, latencyWindowSize)
+	w.mu.Lock()
+	for _, s := range w.samples {
+		if !s.at.IsZero() && now.Sub(s.at) <= latencyWindowPeriod {
+			durations = append(durations, s.duration)
+		}
+	}
+	w.mu.Unlock()
+	if len(durations) < latencyMinSamples {
+		return 0
+	}
+	slices.Sort(durations)
+	return durations[int(q*float64(len(durations)-1))]
+}
+
+// RecentLatency returns the q-quantile (e.g. 0.99) of durations of the calls served by the process
+// during the last 30 seconds, 0 if there were only a few. Engine API calls are not counted.
+func RecentLatency(q float64) time.Duration {
+	return recentLatencies.quantile(q, time.Now())
+}
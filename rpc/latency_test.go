@@ -0,0 +1,43 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyWindow(t *testing.T) {
+	w := &latencyWindow{}
+	now := time.Now()
+	if q := w.quantile(0.99, now); q != 0 {
+		t.Fatalf("empty window: %v", q)
+	}
+	for i := 1; i <= 100; i++ {
+		w.samples[w.next] = latencySample{at: now, duration: time.Duration(i) * time.Millisecond}
+		w.next++
+	}
+	if q := w.quantile(0.99, now); q != 99*time.Millisecond {
+		t.Fatalf("p99: %v", q)
+	}
+	if q := w.quantile(0.99, now.Add(time.Minute)); q != 0 {
+		t.Fatalf("outdated samples counted: %v", q)
+	}
+	w.add("engine_newPayloadV4", now)
+	if w.next != 100 {
+		t.Fatal("engine API call counted")
+	}
+}
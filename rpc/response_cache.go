@@ -0,0 +1,285 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// maxCachedResultSize - results larger than this are not cached
+const maxCachedResultSize = 32 * 1024 * 1024
+
+type immutableResultKey struct{}
+
+// MarkImmutable tells the server that the result of the request being served never changes (e.g. it is the data of
+// a finalized block), so it may be stored in the response cache and served from it for the same request later.
+func MarkImmutable(ctx context.Context) {
+	if v, ok := ctx.Value(immutableResultKey{}).(*atomic.Bool); ok {
+		v.Store(true)
+	}
+}
+
+// ImmutableResultCacheable reports whether MarkImmutable has any effect for the request,
+// so that methods can skip the finality check otherwise
+func ImmutableResultCacheable(ctx context.Context) bool {
+	_, ok := ctx.Value(immutableResultKey{}).(*atomic.Bool)
+	return ok
+}
+
+// ResponseCache is a disk-backed LRU cache of results of immutable requests (see MarkImmutable), served over HTTP
+// with ETag / If-None-Match support so that CDN-fronted endpoints can offload repeat traffic.
+// Only single (not batched) HTTP requests are cached, the first response of a request has no ETag.
+type ResponseCache struct {
+	dir     string
+	maxSize int64
+	logger  log.Logger
+
+	mu    sync.Mutex
+	size  int64
+	lru   *list.List               // of *cachedResult, most recently used at front
+	index map[string]*list.Element // by key
+}
+
+type cachedResult struct {
+	key  string
+	size int64
+}
+
+// NewResponseCache opens the cache in `dir`, keeping at most maxSize bytes of results
+func NewResponseCache(dir string, maxSize int64, logger log.Logger) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &ResponseCache{dir: dir, maxSize: maxSize, logger: logger, lru: list.New(), index: map[string]*list.Element{}}
+
+	type file struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, ".tmp") { // interrupted write
+			return os.Remove(path)
+		}
+		if len(d.Name()) != 2*sha256.Size {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, file{key: d.Name(), size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		c.index[f.key] = c.lru.PushFront(&cachedResult{key: f.key, size: f.size})
+		c.size += f.size
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+func responseCacheKey(method string, params json.RawMessage) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, params); err == nil {
+		params = compact.Bytes()
+	}
+	h.Write(params)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// get returns the cached result and its ETag
+func (c *ResponseCache) get(key string) ([]byte, string, bool) {
+	c.mu.Lock()
+	e, ok := c.index[key]
+	if ok {
+		c.lru.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, "", false
+	}
+	result, err := os.ReadFile(c.path(key))
+	if err != nil { // evicted meanwhile
+		return nil, "", false
+	}
+	sum := sha256.Sum256(result)
+	return result, `"` + hex.EncodeToString(sum[:16]) + `"`, true
+}
+
+func (c *ResponseCache) put(key string, result []byte) {
+	size := int64(len(result))
+	if size > c.maxSize {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.index[key]; ok { // written by a concurrent request
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.logger.Warn("[rpc] response cache write failed", "err", err)
+		return
+	}
+	if err := os.WriteFile(path+".tmp", result, 0644); err != nil {
+		c.logger.Warn("[rpc] response cache write failed", "err", err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		c.logger.Warn("[rpc] response cache write failed", "err", err)
+		return
+	}
+	c.index[key] = c.lru.PushFront(&cachedResult{key: key, size: size})
+	c.size += size
+	c.evict()
+}
+
+// evict removes least recently used results until the cache fits maxSize, must be called with c.mu held
+func (c *ResponseCache) evict() {
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove must be called with c.mu held
+func (c *ResponseCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*cachedResult)
+	delete(c.index, entry.key)
+	c.size -= entry.size
+	if err := os.Remove(c.path(entry.key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.logger.Warn("[rpc] response cache eviction failed", "err", err)
+	}
+}
+
+// serveHTTP serves the request from the cache if possible. Otherwise, if the request may be cached, returns the
+// context which the methods mark the result immutable in, and the writer capturing the response to store.
+func (c *ResponseCache) serveHTTP(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, *responseCapture, bool) {
+	var msg *jsonrpcMessage
+	switch {
+	case r.Method == http.MethodGet && r.ContentLength == 0:
+		msg = getRequestMessage(r)
+	case r.Method == http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestContentLength))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		body = bytes.TrimLeft(body, " \t\r\n")
+		if err != nil || len(body) == 0 || body[0] == '[' { // batch
+			return ctx, nil, false
+		}
+		msg = new(jsonrpcMessage)
+		if err := json.Unmarshal(body, msg); err != nil {
+			return ctx, nil, false
+		}
+	default:
+		return ctx, nil, false
+	}
+	if !msg.isCall() {
+		return ctx, nil, false
+	}
+
+	key := responseCacheKey(msg.Method, msg.Params)
+	if result, etag, ok := c.get(key); ok {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return ctx, nil, true
+		}
+		w.Header().Set("content-type", contentType)
+		resp, err := json.Marshal(&jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result})
+		if err != nil {
+			return ctx, nil, false
+		}
+		w.Write(append(resp, '\n'))
+		return ctx, nil, true
+	}
+
+	capture := &responseCapture{ResponseWriter: w, cache: c, key: key}
+	return context.WithValue(ctx, immutableResultKey{}, &capture.immutable), capture, false
+}
+
+// responseCapture copies the response to store it in the cache if the method marks the result immutable
+type responseCapture struct {
+	http.ResponseWriter
+	cache *ResponseCache
+	key   string
+
+	immutable atomic.Bool
+	buf       bytes.Buffer
+	overflow  bool
+}
+
+func (rc *responseCapture) Write(p []byte) (int, error) {
+	if !rc.overflow {
+		if rc.buf.Len()+len(p) > maxCachedResultSize {
+			rc.overflow = true
+			rc.buf = bytes.Buffer{}
+		} else {
+			rc.buf.Write(p)
+		}
+	}
+	return rc.ResponseWriter.Write(p)
+}
+
+func (rc *responseCapture) finish() {
+	if !rc.immutable.Load() || rc.overflow {
+		return
+	}
+	var resp jsonrpcMessage
+	if err := json.Unmarshal(rc.buf.Bytes(), &resp); err != nil {
+		return
+	}
+	if resp.Error != nil || len(resp.Result) == 0 || bytes.Equal(resp.Result, null) {
+		return
+	}
+	rc.cache.put(rc.key, resp.Result)
+}
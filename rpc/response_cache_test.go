@@ -0,0 +1,105 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+type cacheTestService struct {
+	calls int
+}
+
+func (s *cacheTestService) Double(ctx context.Context, n int, immutable bool) (int, error) {
+	s.calls++
+	if immutable {
+		MarkImmutable(ctx)
+	}
+	return 2 * n, nil
+}
+
+func TestResponseCache(t *testing.T) {
+	logger := log.New()
+	dir := t.TempDir()
+	service := new(cacheTestService)
+	server := NewServer(50, false /* traceRequests */, false /* debugSingleRequests */, false, logger, 100)
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("cache", service))
+	cache, err := NewResponseCache(dir, 1<<20, logger)
+	require.NoError(t, err)
+	server.SetResponseCache(cache)
+
+	call := func(id int, params string, ifNoneMatch string) *httptest.ResponseRecorder {
+		body := `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"cache_double","params":` + params + `}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	requireResult := func(w *httptest.ResponseRecorder, id string, result string) {
+		require.Equal(t, http.StatusOK, w.Code)
+		var msg jsonrpcMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+		require.Nil(t, msg.Error)
+		require.Equal(t, id, string(msg.ID))
+		require.Equal(t, result, string(msg.Result))
+	}
+
+	w := call(1, "[2, true]", "")
+	requireResult(w, "1", "4")
+	require.Empty(t, w.Header().Get("ETag"))
+	require.Equal(t, 1, service.calls)
+
+	// served from the cache, whitespace in params doesn't matter
+	w = call(2, "[2,true]", "")
+	requireResult(w, "2", "4")
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, 1, service.calls)
+
+	w = call(3, "[2,true]", etag)
+	require.Equal(t, http.StatusNotModified, w.Code)
+	require.Equal(t, 1, service.calls)
+
+	// not marked immutable
+	requireResult(call(4, "[3,false]", ""), "4", "6")
+	requireResult(call(5, "[3,false]", ""), "5", "6")
+	require.Equal(t, 3, service.calls)
+
+	// persisted
+	cache, err = NewResponseCache(dir, 1<<20, logger)
+	require.NoError(t, err)
+	server.SetResponseCache(cache)
+	w = call(6, "[2,true]", "")
+	requireResult(w, "6", "4")
+	require.Equal(t, etag, w.Header().Get("ETag"))
+	require.Equal(t, 3, service.calls)
+}
@@ -62,6 +62,11 @@ type Server struct {
 	batchLimit          int  // Maximum number of requests in a batch
 	logger              log.Logger
 	rpcSlowLogThreshold time.Duration
+	responseCache       *ResponseCache // nil if disabled
+	costAccounting      bool
+
+	inFlight atomic.Int64 // calls being served, over HTTP and websockets
+	drain    drainState
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -85,6 +90,16 @@ func (s *Server) SetBatchLimit(limit int) {
 	s.batchLimit = limit
 }
 
+// SetResponseCache enables serving results of immutable requests over HTTP from the cache, see MarkImmutable
+func (s *Server) SetResponseCache(cache *ResponseCache) {
+	s.responseCache = cache
+}
+
+// SetCostAccounting enables reporting the resources spent on every HTTP request in the CostField member of its response
+func (s *Server) SetCostAccounting(enabled bool) {
+	s.costAccounting = enabled
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -110,7 +125,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services, s.logger)
+	c := initClient(codec, s.idgen, &s.services, &s.inFlight, s.logger)
 	<-codec.closed()
 	c.Close()
 }
@@ -126,6 +141,8 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stre
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency, s.traceRequests, s.logger, s.rpcSlowLogThreshold)
 	h.allowSubscribe = false
+	h.costAccounting = s.costAccounting
+	h.inFlight = &s.inFlight
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.ReadBatch()
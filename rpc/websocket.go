@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -62,6 +63,11 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 		if jwtSecret != nil && !CheckJwtSecret(w, r, jwtSecret) {
 			return
 		}
+		if s.Draining() {
+			w.Header().Set("Retry-After", strconv.Itoa(s.drain.retryAfterSeconds()))
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			logger.Warn("WebSocket upgrade failed", "err", err)
@@ -297,6 +303,16 @@ func (wc *websocketCodec) WriteJSON(ctx context.Context, v interface{}) error {
 	return err
 }
 
+// closeForRestart sends the close frame telling the peer that the server restarts and when to reconnect,
+// then closes the connection
+func (wc *websocketCodec) closeForRestart(retryAfter time.Duration) {
+	msg := websocket.FormatCloseMessage(websocket.CloseServiceRestart, fmt.Sprintf("retry-after=%d", int(retryAfter.Seconds())))
+	wc.jsonCodec.encMu.Lock()
+	wc.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsPingWriteTimeout)) //nolint:errcheck
+	wc.jsonCodec.encMu.Unlock()
+	wc.Close()
+}
+
 // pingLoop sends periodic ping frames when the connection is idle.
 func (wc *websocketCodec) pingLoop() {
 	timer := time.NewTimer(wsPingInterval)
@@ -117,7 +117,7 @@ type btHeaderMarshaling struct {
 	ExcessBlobGas *math.HexOrDecimal64
 }
 
-func (bt *BlockTest) Run(t *testing.T, checkStateRoot bool) error {
+func (bt *BlockTest) Run(t testing.TB, checkStateRoot bool) error {
 	config, ok := Forks[bt.json.Network]
 	if !ok {
 		return UnsupportedForkError{bt.json.Network}
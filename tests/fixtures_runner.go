@@ -0,0 +1,370 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	mdbx2 "github.com/erigontech/mdbx-go/mdbx"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/config3"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/kv/temporal"
+	"github.com/erigontech/erigon-lib/log/v3"
+	libstate "github.com/erigontech/erigon-lib/state"
+	"github.com/erigontech/erigon/core/vm"
+)
+
+type FixtureKind string
+
+const (
+	StateFixture      FixtureKind = "state"
+	BlockchainFixture FixtureKind = "blockchain"
+	EOFFixture        FixtureKind = "eof"
+)
+
+var errEOFNotSupported = errors.New("EOF is not supported")
+
+// FixtureResult is the outcome of a single fixture (or a single fork/index of a state fixture)
+type FixtureResult struct {
+	File     string        `json:"file"`
+	Name     string        `json:"name"`
+	Kind     FixtureKind   `json:"kind"`
+	Fork     string        `json:"fork,omitempty"`
+	Pass     bool          `json:"pass"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+type FixturesReport struct {
+	Total   int             `json:"total"`
+	Passed  int             `json:"passed"`
+	Failed  int             `json:"failed"`
+	Results []FixtureResult `json:"results"`
+}
+
+type FixturesConfig struct {
+	Workers int
+	Filter  *regexp.Regexp // run only fixtures with matching name, nil - run all
+	// BlockchainCommand returns the command line of a process executing the blockchain fixture by
+	// RunBlockchainFixture
+	BlockchainCommand func(file, name string) []string
+}
+
+type fixture struct {
+	file string
+	name string
+	kind FixtureKind
+	raw  json.RawMessage
+}
+
+// RunFixtures executes execution-spec-tests fixtures found in `paths` (files or directories with *.json files)
+// by cfg.Workers goroutines. Kind of every fixture is detected by its content. State fixtures are executed by the
+// in-process EVM, blockchain fixtures are imported through the staged sync by child processes, so that a panic in
+// any of its goroutines fails only the fixture. EOF fixtures are not supported and reported as failed.
+func RunFixtures(ctx context.Context, paths []string, cfg FixturesConfig) (*FixturesReport, error) {
+	fixtures, err := loadFixtures(paths, cfg.Filter)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make(chan fixture)
+	results := make(chan []FixtureResult)
+	var wg sync.WaitGroup
+	for i := 0; i < max(cfg.Workers, 1); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dirs := datadir.New(filepath.Join(os.TempDir(), fmt.Sprintf("erigon-fixtures-%d-%d", os.Getpid(), i)))
+			defer func() { _ = os.RemoveAll(dirs.DataDir) }()
+			for f := range jobs {
+				results <- runFixture(ctx, f, dirs, cfg)
+			}
+		}(i)
+	}
+	go func() {
+		defer close(jobs)
+		for _, f := range fixtures {
+			select {
+			case jobs <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &FixturesReport{}
+	for res := range results {
+		report.Results = append(report.Results, res...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(report.Results, func(i, j int) bool {
+		a, b := report.Results[i], report.Results[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Fork < b.Fork
+	})
+	for _, r := range report.Results {
+		report.Total++
+		if r.Pass {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func loadFixtures(paths []string, filter *regexp.Regexp) ([]fixture, error) {
+	var fixtures []fixture
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+				return nil
+			}
+			entries, err := readFixturesFile(path)
+			if err != nil {
+				return err
+			}
+			for name, raw := range entries {
+				if filter != nil && !filter.MatchString(name) {
+					continue
+				}
+				kind, err := detectFixtureKind(raw)
+				if err != nil {
+					return fmt.Errorf("%s: %s: %w", path, name, err)
+				}
+				fixtures = append(fixtures, fixture{file: path, name: name, kind: kind, raw: raw})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fixtures, nil
+}
+
+func readFixturesFile(path string) (map[string]json.RawMessage, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(src, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func detectFixtureKind(raw json.RawMessage) (FixtureKind, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", err
+	}
+	switch {
+	case fields["blocks"] != nil:
+		return BlockchainFixture, nil
+	case fields["transaction"] != nil && fields["post"] != nil:
+		return StateFixture, nil
+	case fields["vectors"] != nil:
+		return EOFFixture, nil
+	default:
+		return "", errors.New("unknown fixture format")
+	}
+}
+
+func runFixture(ctx context.Context, f fixture, dirs datadir.Dirs, cfg FixturesConfig) []FixtureResult {
+	start := time.Now()
+	failed := func(err error) []FixtureResult {
+		return []FixtureResult{{File: f.file, Name: f.name, Kind: f.kind, Error: err.Error(), Duration: time.Since(start)}}
+	}
+
+	switch f.kind {
+	case StateFixture:
+		var test StateTest
+		if err := json.Unmarshal(f.raw, &test); err != nil {
+			return failed(err)
+		}
+		results, err := runStateFixture(f, &test, dirs)
+		if err != nil {
+			return failed(err)
+		}
+		return results
+	case BlockchainFixture:
+		res := FixtureResult{File: f.file, Name: f.name, Kind: f.kind, Pass: true}
+		var network struct{ Network string }
+		if err := json.Unmarshal(f.raw, &network); err != nil {
+			return failed(err)
+		}
+		res.Fork = network.Network
+		if err := runBlockchainFixtureProcess(ctx, cfg.BlockchainCommand(f.file, f.name)); err != nil {
+			res.Pass, res.Error = false, err.Error()
+		}
+		res.Duration = time.Since(start)
+		return []FixtureResult{res}
+	default:
+		return failed(errEOFNotSupported)
+	}
+}
+
+func runStateFixture(f fixture, test *StateTest, dirs datadir.Dirs) (results []FixtureResult, err error) {
+	_db := mdbx.New(kv.ChainDB, log.New()).
+		Path(dirs.Chaindata).
+		AddFlags(mdbx2.UtterlyNoSync | mdbx2.NoMetaSync | mdbx2.NoMemInit | mdbx2.WriteMap).
+		GrowthStep(1 * datasize.MB).
+		MustOpen()
+	defer _db.Close()
+	agg, err := libstate.NewAggregator2(context.Background(), dirs, config3.DefaultStepSize, _db, log.New())
+	if err != nil {
+		return nil, err
+	}
+	defer agg.Close()
+	db, err := temporal.New(_db, agg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	for _, subtest := range test.Subtests() {
+		start := time.Now()
+		res := FixtureResult{File: f.file, Name: fmt.Sprintf("%s/%d", f.name, subtest.Index), Kind: f.kind, Fork: subtest.Fork, Pass: true}
+		err := func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			tx, err := db.BeginRw(context.Background())
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			_, _, err = test.Run(tx, subtest, vm.Config{}, dirs)
+			return err
+		}()
+		if err != nil {
+			res.Pass, res.Error = false, err.Error()
+		}
+		res.Duration = time.Since(start)
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// runBlockchainFixtureProcess executes the fixture by child process, result is reported as JSON-encoded error
+// string on stdout. If the child crashed - tail of its stderr is reported.
+func runBlockchainFixtureProcess(ctx context.Context, args []string) error {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+
+	var errMsg string
+	if err := json.Unmarshal(stdout.Bytes(), &errMsg); err != nil {
+		if runErr == nil {
+			runErr = err
+		}
+		const tailLen = 2048
+		tail := stderr.Bytes()
+		if len(tail) > tailLen {
+			tail = tail[len(tail)-tailLen:]
+		}
+		return fmt.Errorf("fixture process failed: %w: %s", runErr, bytes.TrimSpace(tail))
+	}
+	if errMsg != "" {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+// RunBlockchainFixture imports blocks of the fixture `name` of the file and prints the result for the parent
+// process of RunFixtures. It's meant to be the only work of the process: the mock sentry importing the blocks
+// panics on failures of its goroutines.
+func RunBlockchainFixture(file, name string) error {
+	log.Root().SetHandler(log.DiscardHandler())
+	entries, err := readFixturesFile(file)
+	if err != nil {
+		return err
+	}
+	raw, ok := entries[name]
+	if !ok {
+		return fmt.Errorf("fixture %s not found in %s", name, file)
+	}
+	var test BlockTest
+	if err := json.Unmarshal(raw, &test); err != nil {
+		return err
+	}
+
+	var errMsg string
+	if err := runBlockTest(&test); err != nil {
+		errMsg = err.Error()
+	}
+	out, err := json.Marshal(errMsg)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// runBlockTest runs the test without testing.TB: the mock sentry keeps its files in os.TempDir then, which is
+// replaced by a directory of this fixture
+func runBlockTest(test *BlockTest) (err error) {
+	tmpDir, err := os.MkdirTemp("", "erigon-fixture-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := os.Setenv("TMPDIR", tmpDir); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return test.Run(nil, true)
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFixtureKind(t *testing.T) {
+	kind, err := detectFixtureKind([]byte(`{"blocks": [], "genesisBlockHeader": {}}`))
+	require.NoError(t, err)
+	require.Equal(t, BlockchainFixture, kind)
+
+	kind, err = detectFixtureKind([]byte(`{"env": {}, "pre": {}, "transaction": {}, "post": {}}`))
+	require.NoError(t, err)
+	require.Equal(t, StateFixture, kind)
+
+	kind, err = detectFixtureKind([]byte(`{"vectors": {}}`))
+	require.NoError(t, err)
+	require.Equal(t, EOFFixture, kind)
+
+	_, err = detectFixtureKind([]byte(`{"foo": 1}`))
+	require.Error(t, err)
+}
+
+func TestRunFixturesFailsEOF(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "eof.json"), []byte(`{"a": {"vectors": {}}, "b": {"vectors": {}}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`not a fixture`), 0644))
+
+	report, err := RunFixtures(context.Background(), []string{dir}, FixturesConfig{Workers: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Total)
+	require.Equal(t, 2, report.Failed)
+	require.Equal(t, "a", report.Results[0].Name)
+	require.False(t, report.Results[0].Pass)
+	require.Equal(t, errEOFNotSupported.Error(), report.Results[0].Error)
+
+	fixtures, err := loadFixtures([]string{dir}, regexp.MustCompile("^b$"))
+	require.NoError(t, err)
+	require.Len(t, fixtures, 1)
+}
+
+// Shanghai transfer of 1000 wei from the sender to fixtureTo with gas price 10 and base fee 7, fixture hashes are
+// computed by the trie and block encoding without the EVM
+const (
+	fixtureSenderKey   = "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+	fixtureSender      = "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b"
+	fixtureTo          = "0x1000000000000000000000000000000000000000"
+	fixtureCoinbase    = "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba"
+	fixtureTxBytes     = "0xf861800a8252089410000000000000000000000000000000000000008203e88026a01c2c43e05b3a3b6feb0b7140c153993460333f6550a42830fa6a55742fbc21dba05ad61743924d56e1e995c42c8c42b60ea7c7421bc7b7c4c06b94f2f01fa7ffcc"
+	fixturePreRoot     = "0x517f2cdf6adb1a644878c390ffab4e130f1bed4b498ef7ce58c5addd98d61018"
+	fixturePostRoot    = "0x2cf30520b2387a9b8696af45adf590c61ef3d5b17fecb8af7184c680b5c697c2"
+	fixtureGenesis     = "0x1de44967542af16db84973128ee3102d0fefb0873c0d0cbf5d0df351f08d9b33"
+	fixtureBlock       = "0xa85cd8b80d276fd95f2a1b189d22c512b48b02c63d6129b34fee824ff9260a43"
+	fixtureBlockRlp    = "0xf9027ff90215a01de44967542af16db84973128ee3102d0fefb0873c0d0cbf5d0df351f08d9b33a01dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347942adc25665018aa1fe0e6bc666dac8fc2697ff9baa02cf30520b2387a9b8696af45adf590c61ef3d5b17fecb8af7184c680b5c697c2a062e3815fcdaee3a1de6ba87b185ef1755179388b7362f454449a08599d2414bca0056b23fbba480696b65fe5a59b8f2148a1299103c4f57df839233af2cf4ca2d2b901000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080018401c9c3808252080c00a0000000000000000000000000000000000000000000000000000000000000000088000000000000000007a056e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421f863f861800a8252089410000000000000000000000000000000000000008203e88026a01c2c43e05b3a3b6feb0b7140c153993460333f6550a42830fa6a55742fbc21dba05ad61743924d56e1e995c42c8c42b60ea7c7421bc7b7c4c06b94f2f01fa7ffccc0c0"
+	fixtureTxRoot      = "0x62e3815fcdaee3a1de6ba87b185ef1755179388b7362f454449a08599d2414bc"
+	fixtureReceiptRoot = "0x056b23fbba480696b65fe5a59b8f2148a1299103c4f57df839233af2cf4ca2d2"
+	emptyListHash      = "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347"
+	emptyTrieRoot      = "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"
+	zeroHash           = "0x0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+func fixturePre() map[string]any {
+	return map[string]any{fixtureSender: map[string]any{"nonce": "0x00", "balance": "0x0de0b6b3a7640000", "code": "0x", "storage": map[string]any{}}}
+}
+
+func stateFixture(postRoot string) map[string]any {
+	return map[string]any{
+		"env": map[string]any{
+			"currentCoinbase":   fixtureCoinbase,
+			"currentDifficulty": "0x00",
+			"currentRandom":     "0x0000000000000000000000000000000000000000000000000000000000020000",
+			"currentGasLimit":   "0x01c9c380",
+			"currentNumber":     "0x01",
+			"currentTimestamp":  "0x0c",
+			"currentBaseFee":    "0x07",
+		},
+		"pre": fixturePre(),
+		"transaction": map[string]any{
+			"nonce":     "0x00",
+			"gasPrice":  "0x0a",
+			"gasLimit":  []string{"0x5208"},
+			"to":        fixtureTo,
+			"value":     []string{"0x03e8"},
+			"data":      []string{"0x"},
+			"sender":    fixtureSender,
+			"secretKey": fixtureSenderKey,
+		},
+		"post": map[string]any{
+			"Shanghai": []any{map[string]any{
+				"hash":    postRoot,
+				"logs":    emptyListHash,
+				"txbytes": fixtureTxBytes,
+				"indexes": map[string]int{"data": 0, "gas": 0, "value": 0},
+			}},
+		},
+	}
+}
+
+func fixtureHeader(number, parentHash, stateRoot, txRoot, receiptRoot, gasUsed, timestamp, hash string) map[string]any {
+	return map[string]any{
+		"parentHash":       parentHash,
+		"uncleHash":        emptyListHash,
+		"coinbase":         fixtureCoinbase,
+		"stateRoot":        stateRoot,
+		"transactionsTrie": txRoot,
+		"receiptTrie":      receiptRoot,
+		"bloom":            "0x" + strings.Repeat("00", 256),
+		"difficulty":       "0x00",
+		"number":           number,
+		"gasLimit":         "0x01c9c380",
+		"gasUsed":          gasUsed,
+		"timestamp":        timestamp,
+		"extraData":        "0x00",
+		"mixHash":          zeroHash,
+		"nonce":            "0x0000000000000000",
+		"baseFeePerGas":    "0x07",
+		"withdrawalsRoot":  emptyTrieRoot,
+		"hash":             hash,
+	}
+}
+
+func blockchainFixture(senderBalance string) map[string]any {
+	return map[string]any{
+		"network":            "Shanghai",
+		"sealEngine":         "NoProof",
+		"genesisBlockHeader": fixtureHeader("0x00", zeroHash, fixturePreRoot, emptyTrieRoot, emptyTrieRoot, "0x00", "0x00", fixtureGenesis),
+		"pre":                fixturePre(),
+		"blocks": []any{map[string]any{
+			"rlp":         fixtureBlockRlp,
+			"blockHeader": fixtureHeader("0x01", fixtureGenesis, fixturePostRoot, fixtureTxRoot, fixtureReceiptRoot, "0x5208", "0x0c", fixtureBlock),
+		}},
+		"postState": map[string]any{
+			fixtureSender:   map[string]any{"nonce": "0x01", "balance": senderBalance, "code": "0x", "storage": map[string]any{}},
+			fixtureTo:       map[string]any{"nonce": "0x00", "balance": "0x03e8", "code": "0x", "storage": map[string]any{}},
+			fixtureCoinbase: map[string]any{"nonce": "0x00", "balance": "0xf618", "code": "0x", "storage": map[string]any{}},
+		},
+		"lastblockhash": fixtureBlock,
+	}
+}
+
+func writeFixtures(t *testing.T, fixtures map[string]any) string {
+	dir := t.TempDir()
+	src, err := json.Marshal(fixtures)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixtures.json"), src, 0644))
+	return dir
+}
+
+func TestRunStateFixture(t *testing.T) {
+	dir := writeFixtures(t, map[string]any{
+		"transfer":       stateFixture(fixturePostRoot),
+		"transfer_wrong": stateFixture(fixturePreRoot),
+	})
+
+	report, err := RunFixtures(context.Background(), []string{dir}, FixturesConfig{Workers: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Total)
+	require.Equal(t, 1, report.Passed)
+	require.Equal(t, 1, report.Failed)
+
+	pass, fail := report.Results[0], report.Results[1]
+	require.Equal(t, "transfer/0", pass.Name)
+	require.Equal(t, StateFixture, pass.Kind)
+	require.Equal(t, "Shanghai", pass.Fork)
+	require.True(t, pass.Pass, pass.Error)
+	require.Equal(t, "transfer_wrong/0", fail.Name)
+	require.False(t, fail.Pass)
+	require.Contains(t, fail.Error, "post state root mismatch")
+}
+
+// fixtureProcessEnv makes TestBlockchainFixtureProcess run the fixture given after "--" instead of skipping
+const fixtureProcessEnv = "ERIGON_TEST_BLOCKCHAIN_FIXTURE_PROCESS"
+
+// TestBlockchainFixtureProcess is the child process of TestRunBlockchainFixture, the test binary stands for
+// `erigon test run-fixtures`
+func TestBlockchainFixtureProcess(t *testing.T) {
+	if os.Getenv(fixtureProcessEnv) == "" {
+		t.Skip("child process of TestRunBlockchainFixture")
+	}
+	args := flag.Args()
+	if err := RunBlockchainFixture(args[0], args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestRunBlockchainFixture(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Setenv(fixtureProcessEnv, "1")
+	dir := writeFixtures(t, map[string]any{
+		"transfer":       blockchainFixture("0x0de0b6b3a760c7c8"),
+		"transfer_wrong": blockchainFixture("0x0de0b6b3a7640000"),
+	})
+
+	report, err := RunFixtures(context.Background(), []string{dir}, FixturesConfig{
+		Workers: 2,
+		BlockchainCommand: func(file, name string) []string {
+			return []string{os.Args[0], "-test.run=^TestBlockchainFixtureProcess$", "--", file, name}
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Total)
+	require.Equal(t, 1, report.Passed)
+	require.Equal(t, 1, report.Failed)
+
+	pass, fail := report.Results[0], report.Results[1]
+	require.Equal(t, "transfer", pass.Name)
+	require.Equal(t, BlockchainFixture, pass.Kind)
+	require.Equal(t, "Shanghai", pass.Fork)
+	require.True(t, pass.Pass, pass.Error)
+	require.Equal(t, "transfer_wrong", fail.Name)
+	require.False(t, fail.Pass)
+	require.Contains(t, fail.Error, "account balance mismatch")
+}
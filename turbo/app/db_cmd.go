@@ -0,0 +1,142 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/common/dir"
+	"github.com/erigontech/erigon-lib/diskutils"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/backup"
+	"github.com/erigontech/erigon-lib/kv/mdbx"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cmd/utils"
+	"github.com/erigontech/erigon/turbo/debug"
+)
+
+var dbTuneApplyFlag = cli.BoolFlag{
+	Name:  "apply",
+	Usage: "copy chaindata into a new db with the advised page size, the old one is kept as chaindata.bak-<time>",
+}
+
+var dbCommand = cli.Command{
+	Name:  "db",
+	Usage: "Chaindata database maintenance",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "tune",
+			Action: doDBTune,
+			Usage:  "Advise page size and size settings fitting the tables of chaindata and the storage device, migrate to the advised page size with --apply",
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&dbTuneApplyFlag,
+			}),
+		},
+	},
+}
+
+func doDBTune(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	ctx := cliCtx.Context
+	dirs, l, err := datadir.New(cliCtx.String(utils.DataDirFlag.Name)).MustFlock()
+	if err != nil {
+		return fmt.Errorf("stop erigon before tuning the db: %w", err)
+	}
+	defer l.Unlock()
+
+	db := dbCfg(kv.ChainDB, dirs.Chaindata).MustOpen()
+	g, err := db.(*mdbx.MdbxKV).Geometry(ctx)
+	db.Close()
+	if err != nil {
+		return err
+	}
+	storage, known := diskutils.StorageForDirPath(dirs.Chaindata)
+	advice := mdbx.AdviseGeometry(g, storage, known)
+
+	logger.Info("[db tune] chaindata", "size", g.Size.HR(), "pagesize", g.PageSize.HR(), "growth_step", g.GrowthStep.HR(), "size_limit", g.SizeLimit.HR())
+	if known {
+		logger.Info("[db tune] device", "rotational", storage.Rotational, "physical_block", storage.PhysicalBlockSize)
+	}
+	for i, t := range g.Tables {
+		if i == 10 {
+			break
+		}
+		logger.Info("[db tune] table", "name", t.Name, "entries", t.Entries, "depth", t.Depth,
+			"leaf", t.LeafPages, "branch", t.BranchPages, "overflow", t.OverflowPages)
+	}
+	if !advice.Changed(g) {
+		logger.Info("[db tune] current settings fit chaindata")
+		return nil
+	}
+	for _, reason := range advice.Reasons {
+		logger.Info("[db tune] reason", "reason", reason)
+	}
+	if advice.GrowthStep != g.GrowthStep {
+		logger.Info("[db tune] start erigon with", "flag", fmt.Sprintf("--%s=%s", utils.DbGrowthStepFlag.Name, advice.GrowthStep.HR()))
+	}
+	if advice.SizeLimit != g.SizeLimit {
+		logger.Info("[db tune] start erigon with", "flag", fmt.Sprintf("--%s=%s", utils.DbSizeLimitFlag.Name, advice.SizeLimit.HR()))
+	}
+	if !advice.PageSizeChanged(g) {
+		return nil
+	}
+	if !cliCtx.Bool(dbTuneApplyFlag.Name) {
+		logger.Info("[db tune] page size can be changed only by copying the db, run with --apply", "current", g.PageSize.HR(), "advised", advice.PageSize.HR(), "free_space_needed", g.Size.HR())
+		return nil
+	}
+	return migratePageSize(cliCtx, dirs, advice.PageSize, logger)
+}
+
+// migratePageSize copies chaindata into a new db with the page size, then swaps them keeping the old one as a backup
+func migratePageSize(cliCtx *cli.Context, dirs datadir.Dirs, pageSize datasize.ByteSize, logger log.Logger) error {
+	tmp := dirs.Chaindata + ".tune"
+	exists, err := dir.Exist(tmp)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s exists, remove the leftover of an interrupted migration", tmp)
+	}
+	start := time.Now()
+	logger.Info("[db tune] copying chaindata", "to", tmp, "pagesize", pageSize.HR())
+	src, dst := backup.OpenPair(dirs.Chaindata, tmp, kv.ChainDB, pageSize, logger)
+	err = backup.Kv2kv(cliCtx.Context, src, dst, nil, backup.ReadAheadThreads, logger)
+	src.Close()
+	dst.Close()
+	if err != nil {
+		return errors.Join(err, os.RemoveAll(tmp))
+	}
+	bak := fmt.Sprintf("%s.bak-%d", dirs.Chaindata, start.Unix())
+	if err := os.Rename(dirs.Chaindata, bak); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dirs.Chaindata); err != nil {
+		return errors.Join(err, os.Rename(bak, dirs.Chaindata))
+	}
+	logger.Info("[db tune] done, remove the backup once erigon runs fine", "backup", bak, "took", time.Since(start))
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon/turbo/debug"
+	"github.com/erigontech/erigon/turbo/rpcselftest"
+)
+
+var (
+	selftestURLFlag = cli.StringFlag{
+		Name:  "rpc.url",
+		Usage: "JSON-RPC endpoint of the node to test: http(s)://, ws(s):// or path of the IPC socket",
+		Value: "http://localhost:8545",
+	}
+	selftestExecutionAPIsFlag = cli.StringFlag{
+		Name:  "execution-apis.dir",
+		Usage: "Directory of execution-apis test cases (.io files) to run too. They pass only against a node running the chain of the test cases",
+	}
+	selftestErigonFlag = cli.BoolFlag{
+		Name:  "erigon",
+		Usage: "Check the erigon_ namespace too",
+		Value: true,
+	}
+	selftestTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Timeout of one check",
+		Value: 30 * time.Second,
+	}
+	selftestFuzzFlag = cli.IntFlag{
+		Name:  "fuzz",
+		Usage: "Random requests per method with random, mostly malformed, params: each must be answered with a result or a JSON-RPC error without crashing the method handler, and the node must stay responsive. 0 turns fuzzing off",
+	}
+	selftestFuzzSeedFlag = cli.Int64Flag{
+		Name:  "fuzz.seed",
+		Usage: "Seed of the random requests, reported as fuzzSeed - pass it to reproduce failures. Random if 0",
+	}
+	selftestReportFlag = cli.StringFlag{
+		Name:  "report",
+		Usage: "File to write the JSON report to, - for stdout",
+		Value: "-",
+	}
+)
+
+var rpcCommand = cli.Command{
+	Name:  "rpc",
+	Usage: "JSON-RPC tools",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "selftest",
+			Action: doRPCSelftest,
+			Usage:  "Run the JSON-RPC conformance suite, and fuzzing if --fuzz is set, against a running node and report pass/fail of every check as JSON, fails if any check fails",
+			Flags: joinFlags([]cli.Flag{
+				&selftestURLFlag,
+				&selftestExecutionAPIsFlag,
+				&selftestErigonFlag,
+				&selftestTimeoutFlag,
+				&selftestFuzzFlag,
+				&selftestFuzzSeedFlag,
+				&selftestReportFlag,
+			}),
+		},
+	},
+}
+
+func doRPCSelftest(cliCtx *cli.Context) error {
+	logger, _, _, err := debug.Setup(cliCtx, true /* rootLogger */)
+	if err != nil {
+		return err
+	}
+	report, err := rpcselftest.Run(cliCtx.Context, rpcselftest.Config{
+		URL:              cliCtx.String(selftestURLFlag.Name),
+		ExecutionAPIsDir: cliCtx.String(selftestExecutionAPIsFlag.Name),
+		Erigon:           cliCtx.Bool(selftestErigonFlag.Name),
+		Timeout:          cliCtx.Duration(selftestTimeoutFlag.Name),
+		Fuzz:             cliCtx.Int(selftestFuzzFlag.Name),
+		Seed:             cliCtx.Int64(selftestFuzzSeedFlag.Name),
+	}, logger)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path := cliCtx.String(selftestReportFlag.Name); path == "-" {
+		fmt.Println(string(data))
+	} else if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+
+	for _, r := range report.Results {
+		if r.Status == rpcselftest.StatusFail {
+			logger.Warn("[rpc selftest] check failed", "check", r.Name, "err", r.Error)
+		}
+	}
+	logger.Info("[rpc selftest] done", "url", report.URL, "passed", report.Passed, "failed", report.Failed, "skipped", report.Skipped)
+	if !report.OK() {
+		return fmt.Errorf("%d of %d checks failed", report.Failed, len(report.Results))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/tests"
+)
+
+var (
+	fixturesWorkersFlag = cli.IntFlag{
+		Name:  "workers",
+		Usage: "Amount of fixtures executed in parallel",
+		Value: runtime.NumCPU(),
+	}
+	fixturesRunFlag = cli.StringFlag{
+		Name:  "run",
+		Usage: "Run only fixtures with name matching the regular expression",
+	}
+	fixturesReportFlag = cli.StringFlag{
+		Name:  "report",
+		Usage: "Write JSON report to the file instead of stdout",
+	}
+	// blockchain fixtures are executed by child processes, see tests.RunFixtures
+	fixtureFileFlag = cli.StringFlag{
+		Name:   "blockchain.file",
+		Hidden: true,
+	}
+	fixtureNameFlag = cli.StringFlag{
+		Name:   "blockchain.name",
+		Hidden: true,
+	}
+)
+
+var testCommand = cli.Command{
+	Name:  "test",
+	Usage: "Validate Erigon against consensus tests",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "run-fixtures",
+			Action:    runFixtures,
+			Usage:     "Execute execution-spec-tests fixtures (state and blockchain tests) and print JSON report",
+			ArgsUsage: "<fixtures file or directory>...",
+			Flags: []cli.Flag{
+				&fixturesWorkersFlag,
+				&fixturesRunFlag,
+				&fixturesReportFlag,
+				&fixtureFileFlag,
+				&fixtureNameFlag,
+			},
+		},
+	},
+}
+
+func runFixtures(cliCtx *cli.Context) error {
+	if file := cliCtx.String(fixtureFileFlag.Name); file != "" {
+		return tests.RunBlockchainFixture(file, cliCtx.String(fixtureNameFlag.Name))
+	}
+	if cliCtx.NArg() == 0 {
+		return errors.New("path to fixtures is required")
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cfg := tests.FixturesConfig{
+		Workers: cliCtx.Int(fixturesWorkersFlag.Name),
+		BlockchainCommand: func(file, name string) []string {
+			return []string{self, "test", "run-fixtures", "--" + fixtureFileFlag.Name, file, "--" + fixtureNameFlag.Name, name}
+		},
+	}
+	if expr := cliCtx.String(fixturesRunFlag.Name); expr != "" {
+		filter, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %w", fixturesRunFlag.Name, err)
+		}
+		cfg.Filter = filter
+	}
+
+	ctx, cancel := common.RootContext()
+	defer cancel()
+	report, err := tests.RunFixtures(ctx, cliCtx.Args().Slice(), cfg)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path := cliCtx.String(fixturesReportFlag.Name); path != "" {
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(string(out))
+	}
+	fmt.Fprintf(os.Stderr, "total: %d, passed: %d, failed: %d\n", report.Total, report.Passed, report.Failed)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d fixtures failed", report.Failed)
+	}
+	return nil
+}
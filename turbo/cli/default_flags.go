@@ -39,6 +39,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.TxPoolGlobalQueueFlag,
 	&utils.TxPoolTraceSendersFlag,
 	&utils.TxPoolCommitEveryFlag,
+	&utils.TxPoolIngressRulesFlag,
 	&PruneDistanceFlag,
 	&PruneBlocksDistanceFlag,
 	&PruneModeFlag,
@@ -64,7 +65,14 @@ var DefaultFlags = []cli.Flag{
 	&utils.AuthRpcAddr,
 	&utils.AuthRpcPort,
 	&utils.JWTSecretPath,
+	&utils.AuthRpcRecordFlag,
 	&utils.HttpCompressionFlag,
+	&utils.HttpResponseCacheFlag,
+	&utils.HttpResponseCacheSizeFlag,
+	&utils.HttpCostFlag,
+	&utils.HttpDrainTimeoutFlag,
+	&utils.HttpDrainRetryAfterFlag,
+	&utils.HttpDrainGraceFlag,
 	&utils.HTTPCORSDomainFlag,
 	&utils.HTTPVirtualHostsFlag,
 	&utils.AuthRpcVirtualHostsFlag,
@@ -84,6 +92,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcBatchLimit,
 	&utils.RpcReturnDataLimit,
 	&utils.AllowUnprotectedTxs,
+	&utils.RpcGethCompatErrorsFlag,
 	&utils.RpcMaxGetProofRewindBlockCount,
 	&utils.RPCGlobalTxFeeCapFlag,
 	&utils.TxpoolApiAddrFlag,
@@ -110,6 +119,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.SnapSkipStateSnapshotDownloadFlag,
 	&utils.DbPageSizeFlag,
 	&utils.DbSizeLimitFlag,
+	&utils.DbGrowthStepFlag,
 	&utils.DbWriteMapFlag,
 	&utils.TorrentPortFlag,
 	&utils.TorrentMaxPeersFlag,
@@ -140,6 +150,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.FakePoWFlag,
 	&utils.GpoBlocksFlag,
 	&utils.GpoPercentileFlag,
+	&utils.GpoStrategyFlag,
 	&utils.InsecureUnlockAllowedFlag,
 	&utils.IdentityFlag,
 	&utils.CliqueSnapshotCheckpointIntervalFlag,
@@ -155,6 +166,8 @@ var DefaultFlags = []cli.Flag{
 	&utils.MinerNoVerfiyFlag,
 	&utils.MinerSigningKeyFileFlag,
 	&utils.MinerRecommitIntervalFlag,
+	&utils.MinerOrderingFlag,
+	&utils.MinerSoftConfirmationsFlag,
 	&utils.SentryAddrFlag,
 	&utils.SentryLogPeerInfoFlag,
 	&utils.DownloaderAddrFlag,
@@ -162,6 +175,9 @@ var DefaultFlags = []cli.Flag{
 	&utils.DisableIPV6,
 	&utils.NoDownloaderFlag,
 	&utils.DownloaderVerifyFlag,
+	&utils.DownloaderManifestFlag,
+	&utils.DownloaderManifestPublishersFlag,
+	&utils.DownloaderManifestQuarantineFlag,
 	&HealthCheckFlag,
 	&utils.HeimdallURLFlag,
 	&utils.WebSeedsFlag,
@@ -173,7 +189,11 @@ var DefaultFlags = []cli.Flag{
 	&utils.PolygonSyncFlag,
 	&utils.PolygonSyncStageFlag,
 	&utils.EthStatsURLFlag,
+	&utils.OverrideShanghaiFlag,
+	&utils.OverrideCancunFlag,
 	&utils.OverridePragueFlag,
+	&utils.OverrideOsakaFlag,
+	&utils.OverrideEipsFlag,
 
 	&utils.CaplinDiscoveryAddrFlag,
 	&utils.CaplinDiscoveryPortFlag,
@@ -191,6 +211,17 @@ var DefaultFlags = []cli.Flag{
 	&utils.SentinelStaticPeers,
 
 	&utils.OtsSearchMaxCapFlag,
+	&utils.RpcAnalyticsFlag,
+	&utils.RpcAnalyticsRetentionFlag,
+	&utils.RpcAnalyticsStateExpiryFlag,
+	&utils.RpcAnalyticsSelectorsFlag,
+	&utils.RpcAnalyticsSelectorsBackfillFlag,
+	&utils.RpcAnalyticsChainStatsFlag,
+	&utils.RpcWatchListsFlag,
+	&utils.RpcWatchListsWebhooksFlag,
+	&utils.RpcSourceMapsFlag,
+	&utils.RpcWitnessDirFlag,
+	&utils.RpcIPLDDirFlag,
 
 	&utils.SilkwormExecutionFlag,
 	&utils.SilkwormRpcDaemonFlag,
@@ -221,6 +252,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.CaplinImmediateBlobBackfillFlag,
 
 	&utils.CaplinDisableBlobPruningFlag,
+	&utils.CaplinBlobRetentionEpochsFlag,
 	&utils.CaplinDisableCheckpointSyncFlag,
 	&utils.CaplinEnableSnapshotGeneration,
 	&utils.CaplinMevRelayUrl,
@@ -229,18 +261,29 @@ var DefaultFlags = []cli.Flag{
 	&utils.CaplinCustomGenesisFlag,
 
 	&utils.TrustedSetupFile,
+	&utils.SigRecoverAcceleratorFlag,
 	&utils.RPCSlowFlag,
 
 	&utils.TxPoolGossipDisableFlag,
 	&SyncLoopBlockLimitFlag,
 	&SyncLoopBreakAfterFlag,
 	&SyncParallelStateFlushing,
+	&CompactionRPCLatencyFlag,
+	&CompactionMaxDeferFlag,
 
 	&utils.ChaosMonkeyFlag,
+	&utils.PreimagesFlag,
 
 	&utils.ShutterEnabledFlag,
 	&utils.ShutterP2pBootstrapNodesFlag,
 	&utils.ShutterP2pListenPortFlag,
+	&utils.UserOpsEnabledFlag,
+	&utils.UserOpsEntryPointsFlag,
+	&utils.UserOpsMaxOpsFlag,
+	&utils.UserOpsMaxOpsPerSenderFlag,
+	&utils.UserOpsP2pMempoolIdFlag,
+	&utils.UserOpsP2pBootstrapNodesFlag,
+	&utils.UserOpsP2pListenPortFlag,
 
 	&utils.PolygonPosSingleSlotFinalityFlag,
 	&utils.PolygonPosSingleSlotFinalityBlockAtFlag,
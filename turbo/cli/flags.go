@@ -156,6 +156,17 @@ var (
 		Value: true,
 	}
 
+	CompactionRPCLatencyFlag = cli.DurationFlag{
+		Name:  "compaction.rpc.latency",
+		Usage: "Defer building and merging of state files while p99 latency of RPC calls served by this process is above (e.g. 500ms), 0 - disabled. Can be overridden with admin_setCompactionMode",
+	}
+
+	CompactionMaxDeferFlag = cli.DurationFlag{
+		Name:  "compaction.max.defer",
+		Usage: "Build and merge state files anyway after deferring them this long, to keep the db from growing without bounds",
+		Value: ethconfig.Defaults.Sync.CompactionMaxDefer,
+	}
+
 	UploadLocationFlag = cli.StringFlag{
 		Name:  "upload.location",
 		Usage: "Location to upload snapshot segments to",
@@ -351,6 +362,8 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 		cfg.Sync.LoopBlockLimit = limit
 	}
 	cfg.Sync.ParallelStateFlushing = ctx.Bool(SyncParallelStateFlushing.Name)
+	cfg.Sync.CompactionRPCLatency = ctx.Duration(CompactionRPCLatencyFlag.Name)
+	cfg.Sync.CompactionMaxDefer = ctx.Duration(CompactionMaxDeferFlag.Name)
 
 	if location := ctx.String(UploadLocationFlag.Name); len(location) > 0 {
 		cfg.Sync.UploadLocation = location
@@ -392,6 +405,10 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config, logger log.
 	if ctx.Bool(utils.ChaosMonkeyFlag.Name) {
 		cfg.ChaosMonkey = true
 	}
+
+	if ctx.Bool(utils.PreimagesFlag.Name) {
+		cfg.RecordPreimages = true
+	}
 }
 
 func ApplyFlagsForEthConfigCobra(f *pflag.FlagSet, cfg *ethconfig.Config) {
@@ -500,6 +517,7 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		AuthRpcHTTPListenAddress: ctx.String(utils.AuthRpcAddr.Name),
 		AuthRpcPort:              ctx.Int(utils.AuthRpcPort.Name),
 		JWTSecretPath:            jwtSecretPath,
+		AuthRpcRecordFile:        ctx.String(utils.AuthRpcRecordFlag.Name),
 		TraceRequests:            ctx.Bool(utils.HTTPTraceFlag.Name),
 		DebugSingleRequest:       ctx.Bool(utils.HTTPDebugSingleFlag.Name),
 		HttpCORSDomain:           libcommon.CliString2Array(ctx.String(utils.HTTPCORSDomainFlag.Name)),
@@ -540,10 +558,26 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		BatchLimit:                  ctx.Int(utils.RpcBatchLimit.Name),
 		ReturnDataLimit:             ctx.Int(utils.RpcReturnDataLimit.Name),
 		AllowUnprotectedTxs:         ctx.Bool(utils.AllowUnprotectedTxs.Name),
+		GethCompatErrors:            ctx.Bool(utils.RpcGethCompatErrorsFlag.Name),
+		GasPriceStrategy:            ctx.String(utils.GpoStrategyFlag.Name),
 		MaxGetProofRewindBlockCount: ctx.Int(utils.RpcMaxGetProofRewindBlockCount.Name),
 
 		OtsMaxPageSize: ctx.Uint64(utils.OtsSearchMaxCapFlag.Name),
 
+		AnalyticsEnabled:       ctx.Bool(utils.RpcAnalyticsFlag.Name),
+		AnalyticsRetentionDays: ctx.Uint64(utils.RpcAnalyticsRetentionFlag.Name),
+		AnalyticsStateExpiry:   ctx.Bool(utils.RpcAnalyticsStateExpiryFlag.Name),
+
+		AnalyticsSelectors:         ctx.Bool(utils.RpcAnalyticsSelectorsFlag.Name),
+		AnalyticsSelectorsBackfill: ctx.Uint64(utils.RpcAnalyticsSelectorsBackfillFlag.Name),
+		ChainStatsFile:             ctx.String(utils.RpcAnalyticsChainStatsFlag.Name),
+
+		WatchListsFile:         ctx.String(utils.RpcWatchListsFlag.Name),
+		WatchListsWebhookHosts: ctx.StringSlice(utils.RpcWatchListsWebhooksFlag.Name),
+		SourceMapsDir:          ctx.String(utils.RpcSourceMapsFlag.Name),
+		WitnessDir:             ctx.String(utils.RpcWitnessDirFlag.Name),
+		IPLDDir:                ctx.String(utils.RpcIPLDDirFlag.Name),
+
 		TxPoolApiAddr: ctx.String(utils.TxpoolApiAddrFlag.Name),
 
 		StateCache:          kvcache.DefaultCoherentConfig,
@@ -575,6 +609,16 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		utils.Fatalf("Invalid state.cache value provided")
 	}
 
+	c.HttpResponseCacheDir = ctx.String(utils.HttpResponseCacheFlag.Name)
+	c.HttpCost = ctx.Bool(utils.HttpCostFlag.Name)
+	c.HttpDrainTimeout = ctx.Duration(utils.HttpDrainTimeoutFlag.Name)
+	c.HttpDrainRetryAfter = ctx.Duration(utils.HttpDrainRetryAfterFlag.Name)
+	c.HttpDrainGrace = ctx.Duration(utils.HttpDrainGraceFlag.Name)
+	err = c.HttpResponseCacheSize.UnmarshalText([]byte(ctx.String(utils.HttpResponseCacheSizeFlag.Name)))
+	if err != nil {
+		utils.Fatalf("Invalid %s value provided", utils.HttpResponseCacheSizeFlag.Name)
+	}
+
 	/*
 		rootCmd.PersistentFlags().BoolVar(&cfg.GRPCServerEnabled, "grpc", false, "Enable GRPC server")
 		rootCmd.PersistentFlags().StringVar(&cfg.GRPCListenAddress, "grpc.addr", node.DefaultGRPCHost, "GRPC server listening interface")
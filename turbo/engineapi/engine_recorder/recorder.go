@@ -0,0 +1,89 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+// Package engine_recorder records the engine API calls driving the node (engine_newPayload and
+// engine_forkchoiceUpdated) to a file and replays them into another node, so that shadow forks and
+// regression tests reproduce the sequence seen on the network exactly.
+package engine_recorder
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
+)
+
+const (
+	newPayloadPrefix        = "engine_newPayload"
+	forkchoiceUpdatedPrefix = "engine_forkchoiceUpdated"
+)
+
+// Entry is one recorded call, the file is a sequence of entries, one JSON object per line
+type Entry struct {
+	Time   time.Time         `json:"time"` // when the call was received
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Status string            `json:"status,omitempty"` // payload status of the response, empty if the call failed
+	Error  string            `json:"error,omitempty"`
+}
+
+// Recorder appends calls to the file, it may be used concurrently
+type Recorder struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Open starts recording to the file at path, appending to the calls recorded before
+func Open(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{f: f}, nil
+}
+
+// Record appends the call received at `received` with its params and the payload status of the response,
+// status is nil if callErr is not
+func (r *Recorder) Record(received time.Time, method string, status *engine_types.PayloadStatus, callErr error, params ...interface{}) error {
+	e := Entry{Time: received, Method: method, Params: make([]json.RawMessage, len(params))}
+	for i, p := range params {
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		e.Params[i] = raw
+	}
+	if callErr != nil {
+		e.Error = callErr.Error()
+	} else if status != nil {
+		e.Status = string(status.Status)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.f.Write(append(line, '\n'))
+	return err
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
+)
+
+type replayCall struct {
+	method string
+	params string
+}
+
+type callerStub struct {
+	calls     []replayCall
+	responses []string // JSON results, "" for an error
+}
+
+func (c *callerStub) CallContext(_ context.Context, result interface{}, method string, args ...interface{}) error {
+	params, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	c.calls = append(c.calls, replayCall{method, string(params)})
+	resp := c.responses[len(c.calls)-1]
+	if resp == "" {
+		return errors.New("failed")
+	}
+	return json.Unmarshal([]byte(resp), result)
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.jsonl")
+	r, err := Open(path)
+	require.NoError(t, err)
+
+	start := time.Now()
+	blockHash := libcommon.HexToHash("0x01")
+	payload := &engine_types.ExecutionPayload{BlockHash: blockHash}
+	beaconRoot := libcommon.HexToHash("0x02")
+	valid := &engine_types.PayloadStatus{Status: engine_types.ValidStatus}
+	require.NoError(t, r.Record(start, "engine_newPayloadV3", valid, nil, payload, []libcommon.Hash{}, &beaconRoot))
+	fcu := &engine_types.ForkChoiceState{HeadHash: blockHash}
+	require.NoError(t, r.Record(start.Add(200*time.Millisecond), "engine_forkchoiceUpdatedV3", valid, nil, fcu, nil))
+	require.NoError(t, r.Record(start.Add(300*time.Millisecond), "engine_newPayloadV3", nil, errors.New("invalid params"), payload, nil, nil))
+	require.NoError(t, r.Close())
+
+	recorded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var first Entry
+	require.NoError(t, json.NewDecoder(bytes.NewReader(recorded)).Decode(&first))
+	require.Equal(t, "engine_newPayloadV3", first.Method)
+	require.Equal(t, "VALID", first.Status)
+	require.Len(t, first.Params, 3)
+
+	// replayed as recorded, at 10x speed
+	caller := &callerStub{responses: []string{
+		`{"status":"VALID","latestValidHash":null,"validationError":null}`,
+		`{"payloadStatus":{"status":"VALID"},"payloadId":null}`,
+		"",
+	}}
+	replayStart := time.Now()
+	res, err := Replay(context.Background(), caller, bytes.NewReader(recorded), ReplayConfig{Speed: 10}, log.New())
+	require.NoError(t, err)
+	require.Equal(t, ReplayResult{Calls: 3, Mismatches: 0}, res)
+	require.GreaterOrEqual(t, time.Since(replayStart), 30*time.Millisecond)
+	require.Len(t, caller.calls, 3)
+	require.Equal(t, "engine_forkchoiceUpdatedV3", caller.calls[1].method)
+	require.Equal(t, fmt.Sprintf("[%s,null]", mustMarshal(t, fcu)), caller.calls[1].params)
+
+	// a node answering differently
+	caller = &callerStub{responses: []string{
+		`{"status":"SYNCING"}`,
+		`{"payloadStatus":{"status":"VALID"}}`,
+		"",
+	}}
+	res, err = Replay(context.Background(), caller, bytes.NewReader(recorded), ReplayConfig{}, log.New())
+	require.NoError(t, err)
+	require.Equal(t, ReplayResult{Calls: 3, Mismatches: 1}, res)
+
+	caller = &callerStub{responses: []string{`{"status":"SYNCING"}`}}
+	res, err = Replay(context.Background(), caller, bytes.NewReader(recorded), ReplayConfig{StopOnMismatch: true}, log.New())
+	require.Error(t, err)
+	require.Equal(t, ReplayResult{Calls: 1, Mismatches: 1}, res)
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
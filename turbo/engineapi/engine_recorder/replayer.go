@@ -0,0 +1,118 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package engine_recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// Caller sends engine API calls to the node, implemented by rpc.Client
+type Caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+type ReplayConfig struct {
+	// Speed relative to the recorded pace: 1 - as recorded, 2 - twice faster, 0 - every call right after the previous one
+	Speed          float64
+	StopOnMismatch bool
+}
+
+type ReplayResult struct {
+	Calls      int `json:"calls"`
+	Mismatches int `json:"mismatches"` // calls answered with another status than recorded
+}
+
+// Replay sends the calls recorded in r to the node in order, one at a time, pacing them as configured,
+// and compares the payload statuses of the responses with the recorded ones
+func Replay(ctx context.Context, caller Caller, r io.Reader, cfg ReplayConfig, logger log.Logger) (ReplayResult, error) {
+	var res ReplayResult
+	dec := json.NewDecoder(r)
+	var first time.Time
+	start := time.Now()
+	for {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return res, nil
+			}
+			return res, fmt.Errorf("entry %d: %w", res.Calls, err)
+		}
+		if first.IsZero() {
+			first = e.Time
+		}
+		if cfg.Speed > 0 {
+			if wait := time.Until(start.Add(time.Duration(float64(e.Time.Sub(first)) / cfg.Speed))); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return res, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		status, callErr := call(ctx, caller, &e)
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		res.Calls++
+		if (callErr != nil) != (e.Error != "") || status != e.Status {
+			res.Mismatches++
+			logger.Warn("[engine-replay] response differs from the recorded one", "call", res.Calls, "method", e.Method,
+				"recorded", e.Time, "status", status, "recordedStatus", e.Status, "err", callErr, "recordedErr", e.Error)
+			if cfg.StopOnMismatch {
+				return res, fmt.Errorf("call %d (%s): status %q, recorded %q", res.Calls, e.Method, status, e.Status)
+			}
+			continue
+		}
+		logger.Debug("[engine-replay] call", "n", res.Calls, "method", e.Method, "status", status)
+	}
+}
+
+// call returns the payload status of the response, empty if the call failed
+func call(ctx context.Context, caller Caller, e *Entry) (string, error) {
+	args := make([]interface{}, len(e.Params))
+	for i, p := range e.Params {
+		args[i] = p
+	}
+	var result json.RawMessage
+	if err := caller.CallContext(ctx, &result, e.Method, args...); err != nil {
+		return "", err
+	}
+	var resp struct {
+		Status        string `json:"status"`
+		PayloadStatus *struct {
+			Status string `json:"status"`
+		} `json:"payloadStatus"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(e.Method, newPayloadPrefix):
+		return resp.Status, nil
+	case strings.HasPrefix(e.Method, forkchoiceUpdatedPrefix) && resp.PayloadStatus != nil:
+		return resp.PayloadStatus.Status, nil
+	}
+	return "", nil
+}
@@ -48,6 +48,7 @@ import (
 	"github.com/erigontech/erigon/turbo/engineapi/engine_block_downloader"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_helpers"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_logs_spammer"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_recorder"
 	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
 	"github.com/erigontech/erigon/turbo/execution/eth1/eth1_chain_reader"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
@@ -77,6 +78,7 @@ type EngineServer struct {
 	logger  log.Logger
 
 	engineLogSpamer *engine_logs_spammer.EngineLogsSpammer
+	recorder        *engine_recorder.Recorder // nil unless --authrpc.record is set
 	// TODO Remove this on next release
 	printPectraBanner bool
 }
@@ -120,6 +122,22 @@ func (e *EngineServer) Start(
 	if !e.caplin {
 		e.engineLogSpamer.Start(ctx)
 	}
+	if httpConfig.AuthRpcRecordFile != "" {
+		recorder, err := engine_recorder.Open(httpConfig.AuthRpcRecordFile)
+		if err != nil {
+			e.logger.Error("[EngineServer] could not open the file to record calls to", "path", httpConfig.AuthRpcRecordFile, "err", err)
+		} else {
+			e.recorder = recorder
+			// the auth server outlives Start, so is the file
+			go func() {
+				<-ctx.Done()
+				if err := recorder.Close(); err != nil {
+					e.logger.Warn("[EngineServer] could not close the record file", "err", err)
+				}
+			}()
+			e.logger.Info("[EngineServer] recording newPayload and forkchoiceUpdated calls", "path", httpConfig.AuthRpcRecordFile)
+		}
+	}
 	base := jsonrpc.NewBaseApi(filters, stateCache, blockReader, httpConfig.WithDatadir, httpConfig.EvmCallTimeout, engineReader, httpConfig.Dirs, nil)
 	ethImpl := jsonrpc.NewEthAPI(base, db, eth, txPool, mining, httpConfig.Gascap, httpConfig.Feecap, httpConfig.ReturnDataLimit, httpConfig.AllowUnprotectedTxs, httpConfig.MaxGetProofRewindBlockCount, httpConfig.WebsocketSubscribeLogsChannelSize, e.logger)
 	e.txpool = txPool
@@ -142,6 +160,24 @@ func (e *EngineServer) Start(
 	}
 }
 
+// record appends the call to the --authrpc.record file, if set
+func (s *EngineServer) record(received time.Time, method string, status *engine_types.PayloadStatus, err error, params ...interface{}) {
+	if s.recorder == nil {
+		return
+	}
+	if err := s.recorder.Record(received, method, status, err, params...); err != nil {
+		s.logger.Warn("[EngineServer] could not record call", "method", method, "err", err)
+	}
+}
+
+func (s *EngineServer) recordForkchoiceUpdated(received time.Time, method string, resp *engine_types.ForkChoiceUpdatedResponse, err error, params ...interface{}) {
+	var status *engine_types.PayloadStatus
+	if resp != nil {
+		status = resp.PayloadStatus
+	}
+	s.record(received, method, status, err, params...)
+}
+
 func (s *EngineServer) checkWithdrawalsPresence(time uint64, withdrawals types.Withdrawals) error {
 	if !s.config.IsShanghai(time) && withdrawals != nil {
 		return &rpc.InvalidParamsError{Message: "withdrawals before Shanghai"}
@@ -18,7 +18,10 @@ package engineapi
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/erigontech/erigon-lib/direct"
@@ -40,6 +43,8 @@ import (
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethconfig"
 	"github.com/erigontech/erigon/eth/protocols/eth"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_recorder"
+	"github.com/erigontech/erigon/turbo/engineapi/engine_types"
 
 	"github.com/erigontech/erigon/rpc/rpccfg"
 	"github.com/erigontech/erigon/turbo/jsonrpc"
@@ -126,3 +131,29 @@ func TestGetBlobsV1(t *testing.T) {
 	require.Equal(blobsResp[0].Proof, wrappedTxn.Proofs[0][:])
 	require.Equal(blobsResp[1].Proof, wrappedTxn.Proofs[1][:])
 }
+
+func TestRecordEngineCalls(t *testing.T) {
+	mockSentry, require := mock.MockWithTxPoolCancun(t), require.New(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, mockSentry)
+	txPool := txpool.NewTxpoolClient(conn)
+	ff := rpchelper.New(ctx, rpchelper.DefaultFiltersConfig, nil, txPool, txpool.NewMiningClient(conn), func() {}, mockSentry.Log)
+
+	path := filepath.Join(t.TempDir(), "engine.jsonl")
+	executionRpc := direct.NewExecutionClientDirect(mockSentry.Eth1ExecutionService)
+	eth := rpcservices.NewRemoteBackend(nil, mockSentry.DB, mockSentry.BlockReader)
+	// not consuming: the call fails right away, and is recorded with its error
+	engineServer := NewEngineServer(mockSentry.Log, mockSentry.ChainConfig, executionRpc, mockSentry.HeaderDownload(), nil, false, true, false, false)
+	engineServer.Start(ctx, &httpcfg.HttpCfg{AuthRpcRecordFile: path}, mockSentry.DB, mockSentry.BlockReader, ff, nil, mockSentry.Engine, eth, txPool, nil)
+
+	fcu := &engine_types.ForkChoiceState{HeadHash: mockSentry.Genesis.Hash()}
+	_, fcuErr := engineServer.ForkchoiceUpdatedV1(ctx, fcu, nil)
+	require.Error(fcuErr)
+
+	recorded, err := os.ReadFile(path)
+	require.NoError(err)
+	var entry engine_recorder.Entry
+	require.NoError(json.Unmarshal(bytes.TrimSpace(recorded), &entry))
+	require.Equal("engine_forkchoiceUpdatedV1", entry.Method)
+	require.Equal(fcuErr.Error(), entry.Error)
+	require.Len(entry.Params, 2)
+}
@@ -63,6 +63,7 @@ type EthereumExecutionModule struct {
 	semaphore         *semaphore.Weighted
 	executionPipeline *stagedsync.Sync
 	forkValidator     *engine_helpers.ForkValidator
+	validationCache   *validationCache
 
 	logger log.Logger
 	// Block building
@@ -106,6 +107,7 @@ func NewEthereumExecutionModule(blockReader services.FullBlockReader, db kv.RwDB
 		executionPipeline:   executionPipeline,
 		logger:              logger,
 		forkValidator:       forkValidator,
+		validationCache:     newValidationCache(validationCacheSize),
 		builders:            make(map[uint64]*builder.BlockBuilder),
 		builderFunc:         builderFunc,
 		config:              config,
@@ -163,6 +165,33 @@ func (e *EthereumExecutionModule) canonicalHash(ctx context.Context, tx kv.Tx, b
 	return canonical, nil
 }
 
+// displacedBlocks returns the canonical blocks of [from, to], at most shards.NonCanonicalBlocksLimit latest ones
+func (e *EthereumExecutionModule) displacedBlocks(ctx context.Context, tx kv.Tx, from, to uint64) ([]*types.Block, error) {
+	if to >= from+shards.NonCanonicalBlocksLimit {
+		from = to - shards.NonCanonicalBlocksLimit + 1
+	}
+	var blocks []*types.Block
+	for n := from; n <= to; n++ {
+		hash, err := e.canonicalHash(ctx, tx, n)
+		if err != nil {
+			return nil, err
+		}
+		if hash == (libcommon.Hash{}) {
+			continue
+		}
+		var block *types.Block
+		if e.blockReader == nil {
+			block = rawdb.ReadBlock(tx, hash, n)
+		} else if block, _, err = e.blockReader.BlockWithSenders(ctx, tx, hash, n); err != nil {
+			return nil, err
+		}
+		if block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
 func (e *EthereumExecutionModule) unwindToCommonCanonical(tx kv.RwTx, header *types.Header) error {
 	currentHeader := header
 
@@ -189,6 +218,14 @@ func (e *EthereumExecutionModule) unwindToCommonCanonical(tx kv.RwTx, header *ty
 }
 
 func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execution.ValidationRequest) (*execution.ValidationReceipt, error) {
+	blockHash := gointerfaces.ConvertH256ToHash(req.Hash)
+	// The same payload delivered again is answered without unwinding and re-executing,
+	// which also keeps the extending fork of the first validation for the forkchoice update.
+	if res, ok := e.validationCache.get(blockHash); ok {
+		e.hook.LastNewBlockSeen(req.Number)
+		e.logger.Debug("ethereumExecutionModule.ValidateChain: cached result", "hash", blockHash, "status", res.status)
+		return res.receipt(), nil
+	}
 	if !e.semaphore.TryAcquire(1) {
 		e.logger.Trace("ethereumExecutionModule.ValidateChain: ExecutionStatus_Busy")
 		return &execution.ValidationReceipt{
@@ -200,7 +237,6 @@ func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execut
 
 	e.hook.LastNewBlockSeen(req.Number) // used by eth_syncing
 	e.forkValidator.ClearWithUnwind(e.accumulator, e.stateChangeConsumer)
-	blockHash := gointerfaces.ConvertH256ToHash(req.Hash)
 
 	var (
 		header             *types.Header
@@ -276,14 +312,15 @@ func (e *EthereumExecutionModule) ValidateChain(ctx context.Context, req *execut
 		e.logger.Warn("ethereumExecutionModule.ValidateChain: chain is invalid", "hash", libcommon.Hash(blockHash))
 		validationStatus = execution.ExecutionStatus_BadBlock
 	}
-	validationReceipt := &execution.ValidationReceipt{
-		ValidationStatus: validationStatus,
-		LatestValidHash:  gointerfaces.ConvertHashToH256(lvh),
-	}
+	res := validationResult{status: validationStatus, latestValidHash: lvh}
 	if validationError != nil {
-		validationReceipt.ValidationError = validationError.Error()
+		res.validationError = validationError.Error()
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
-	return validationReceipt, tx.Commit()
+	e.validationCache.add(blockHash, res)
+	return res.receipt(), nil
 }
 
 func (e *EthereumExecutionModule) purgeBadChain(ctx context.Context, tx kv.RwTx, latestValidHash, headHash libcommon.Hash) error {
@@ -309,6 +346,7 @@ func (e *EthereumExecutionModule) purgeBadChain(ctx context.Context, tx kv.RwTx,
 		}
 
 		rawdb.DeleteHeader(tx, currentHash, currentNumber)
+		e.validationCache.remove(currentHash)
 		currentHash = currentHeader.ParentHash
 		currentNumber--
 	}
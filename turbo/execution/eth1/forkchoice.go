@@ -335,6 +335,14 @@ func (e *EthereumExecutionModule) updateForkChoice(ctx context.Context, original
 			unwindTarget = minUnwindableBlock
 		}
 
+		// keep the blocks being displaced for RPC, the unwind removes them from the canonical chain
+		displaced, err := e.displacedBlocks(ctx, tx, currentParentNumber+1, headersProgressBefore)
+		if err != nil {
+			sendForkchoiceErrorWithoutWaiting(e.logger, outcomeCh, err, false)
+			return
+		}
+		e.hook.BlocksDisplaced(displaced)
+
 		// if unwindTarget <
 		if err := e.executionPipeline.UnwindTo(unwindTarget, stagedsync.ForkChoice, tx); err != nil {
 			sendForkchoiceErrorWithoutWaiting(e.logger, outcomeCh, err, false)
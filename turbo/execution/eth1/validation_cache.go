@@ -0,0 +1,73 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package eth1
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	execution "github.com/erigontech/erigon-lib/gointerfaces/executionproto"
+)
+
+// validationCacheSize - amount of validation results kept for payloads delivered again (CL re-sends, gossip then engine API)
+const validationCacheSize = 256
+
+// validationResult is the final outcome of ValidateChain for a block, it never changes for the same block hash
+type validationResult struct {
+	status          execution.ExecutionStatus
+	latestValidHash libcommon.Hash
+	validationError string
+}
+
+func (r validationResult) receipt() *execution.ValidationReceipt {
+	return &execution.ValidationReceipt{
+		ValidationStatus: r.status,
+		LatestValidHash:  gointerfaces.ConvertHashToH256(r.latestValidHash),
+		ValidationError:  r.validationError,
+	}
+}
+
+// validationCache keeps the final ValidateChain results by block hash
+type validationCache struct {
+	results *lru.Cache[libcommon.Hash, validationResult]
+}
+
+func newValidationCache(size int) *validationCache {
+	results, err := lru.New[libcommon.Hash, validationResult](size)
+	if err != nil {
+		panic(err)
+	}
+	return &validationCache{results: results}
+}
+
+func (c *validationCache) get(blockHash libcommon.Hash) (validationResult, bool) {
+	return c.results.Get(blockHash)
+}
+
+// add keeps the result if it is final: MissingSegment may turn into a result once the missing blocks arrive,
+// Busy and TooFarAway depend on the state of the node
+func (c *validationCache) add(blockHash libcommon.Hash, res validationResult) {
+	if res.status == execution.ExecutionStatus_Success || res.status == execution.ExecutionStatus_BadBlock {
+		c.results.Add(blockHash, res)
+	}
+}
+
+// remove drops the result of a block deleted from the db, a new delivery of it has to be validated again
+func (c *validationCache) remove(blockHash libcommon.Hash) {
+	c.results.Remove(blockHash)
+}
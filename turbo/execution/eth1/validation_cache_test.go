@@ -0,0 +1,64 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package eth1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	execution "github.com/erigontech/erigon-lib/gointerfaces/executionproto"
+)
+
+func TestValidationCache(t *testing.T) {
+	cache := newValidationCache(2)
+	valid, bad, missing := libcommon.Hash{1}, libcommon.Hash{2}, libcommon.Hash{3}
+
+	cache.add(valid, validationResult{status: execution.ExecutionStatus_Success, latestValidHash: valid})
+	cache.add(bad, validationResult{status: execution.ExecutionStatus_BadBlock, latestValidHash: valid, validationError: "invalid"})
+	cache.add(missing, validationResult{status: execution.ExecutionStatus_MissingSegment})
+
+	res, ok := cache.get(valid)
+	require.True(t, ok)
+	receipt := res.receipt()
+	require.Equal(t, execution.ExecutionStatus_Success, receipt.ValidationStatus)
+	require.Equal(t, valid, libcommon.Hash(gointerfaces.ConvertH256ToHash(receipt.LatestValidHash)))
+
+	res, ok = cache.get(bad)
+	require.True(t, ok)
+	require.Equal(t, execution.ExecutionStatus_BadBlock, res.status)
+	require.Equal(t, "invalid", res.validationError)
+
+	// not final, validated again on the next delivery
+	_, ok = cache.get(missing)
+	require.False(t, ok)
+
+	// the block is purged from the db
+	cache.remove(bad)
+	_, ok = cache.get(bad)
+	require.False(t, ok)
+	_, ok = cache.get(valid)
+	require.True(t, ok)
+
+	// least recently used results are evicted
+	cache.add(libcommon.Hash{4}, validationResult{status: execution.ExecutionStatus_Success})
+	cache.add(libcommon.Hash{5}, validationResult{status: execution.ExecutionStatus_Success})
+	_, ok = cache.get(valid)
+	require.False(t, ok)
+}
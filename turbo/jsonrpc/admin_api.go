@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	remote "github.com/erigontech/erigon-lib/gointerfaces/remoteproto"
+	libstate "github.com/erigontech/erigon-lib/state"
 	"github.com/erigontech/erigon/p2p"
 
 	"github.com/erigontech/erigon/turbo/rpchelper"
@@ -38,13 +39,22 @@ type AdminAPI interface {
 
 	// AddPeer requests connecting to a remote node.
 	AddPeer(ctx context.Context, url string) (bool, error)
+
+	// CompactionStatus returns whether building and merging of state files is deferred by the RPC load.
+	CompactionStatus(ctx context.Context) (*libstate.CompactionStatus, error)
+
+	// SetCompactionMode overrides the scheduling of building and merging of state files: auto, pause or force.
+	SetCompactionMode(ctx context.Context, mode string) (*libstate.CompactionStatus, error)
 }
 
 // AdminAPIImpl data structure to store things needed for admin_* commands.
 type AdminAPIImpl struct {
 	ethBackend rpchelper.ApiBackend
+	compaction *libstate.CompactionScheduler // nil if the rpcdaemon is not embedded into the node
 }
 
+var errCompactionNotEmbedded = errors.New("compaction scheduling is available only in the rpcdaemon embedded into erigon")
+
 // NewAdminAPI returns AdminAPIImpl instance.
 func NewAdminAPI(eth rpchelper.ApiBackend) *AdminAPIImpl {
 	return &AdminAPIImpl{
@@ -79,3 +89,22 @@ func (api *AdminAPIImpl) AddPeer(ctx context.Context, url string) (bool, error)
 	}
 	return result.Success, nil
 }
+
+func (api *AdminAPIImpl) CompactionStatus(ctx context.Context) (*libstate.CompactionStatus, error) {
+	if api.compaction == nil {
+		return nil, errCompactionNotEmbedded
+	}
+	status := api.compaction.Status()
+	return &status, nil
+}
+
+func (api *AdminAPIImpl) SetCompactionMode(ctx context.Context, mode string) (*libstate.CompactionStatus, error) {
+	if api.compaction == nil {
+		return nil, errCompactionNotEmbedded
+	}
+	if err := api.compaction.SetMode(mode); err != nil {
+		return nil, err
+	}
+	status := api.compaction.Status()
+	return &status, nil
+}
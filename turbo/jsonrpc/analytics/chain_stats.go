@@ -0,0 +1,292 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+var chainStatsDays = metrics.GetOrCreateGauge(`chain_stats_days`)
+
+// BlockStats is the contribution of a single block to the daily chain statistics
+type BlockStats struct {
+	Number           uint64
+	Hash             common.Hash
+	Time             uint64
+	Transactions     uint64
+	GasUsed          uint64
+	BurntFees        uint256.Int      // base fee and blob fee burnt
+	ContractsCreated uint64           // contract creation transactions
+	Addresses        []common.Address // senders and recipients of transactions, may repeat
+}
+
+func (b *BlockStats) Day() uint64 { return b.Time / SecondsPerDay }
+
+// DayStats are the aggregates of the blocks of one UTC day
+type DayStats struct {
+	Day              uint64 // days since unix epoch
+	FirstBlock       uint64
+	LastBlock        uint64
+	Blocks           uint64
+	Transactions     uint64
+	GasUsed          uint64
+	BurntFees        uint256.Int
+	ContractsCreated uint64
+	ActiveAddresses  uint64 `json:"-"` // estimated amount of distinct senders and recipients
+}
+
+type dayStats struct {
+	DayStats
+	active hyperLogLog // addresses of the blocks deeper than reorg depth
+}
+
+func (d *dayStats) add(b *BlockStats) {
+	if d.Blocks == 0 || b.Number < d.FirstBlock {
+		d.FirstBlock = b.Number
+	}
+	d.LastBlock = max(d.LastBlock, b.Number)
+	d.Blocks++
+	d.Transactions += b.Transactions
+	d.GasUsed += b.GasUsed
+	d.BurntFees.Add(&d.BurntFees, &b.BurntFees)
+	d.ContractsCreated += b.ContractsCreated
+}
+
+// sub reverts add of the newest block of the day
+func (d *dayStats) sub(b *BlockStats) {
+	d.LastBlock = b.Number - 1
+	d.Blocks--
+	d.Transactions -= b.Transactions
+	d.GasUsed -= b.GasUsed
+	d.BurntFees.Sub(&d.BurntFees, &b.BurntFees)
+	d.ContractsCreated -= b.ContractsCreated
+}
+
+func (d *dayStats) addAddresses(addrs []common.Address) {
+	for _, addr := range addrs {
+		d.active.add(addr)
+	}
+}
+
+// ChainStatsIndex keeps daily chain statistics of a continuous range of blocks: it grows forward with new heads
+// and backward by backfilling. Active addresses are counted with HyperLogLog, which can't remove an address,
+// so addresses of blocks within reorg depth are kept aside until the blocks become final.
+type ChainStatsIndex struct {
+	lock        sync.RWMutex
+	started     bool
+	first, last uint64
+	days        map[uint64]*dayStats
+	recent      RecentBlocks[*BlockStats]
+}
+
+func NewChainStatsIndex() *ChainStatsIndex {
+	return &ChainStatsIndex{days: map[uint64]*dayStats{}}
+}
+
+// Range returns the range of indexed blocks
+func (idx *ChainStatsIndex) Range() (from, to uint64, ok bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.first, idx.last, idx.started
+}
+
+// LastBlock returns the last indexed block
+func (idx *ChainStatsIndex) LastBlock() (uint64, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.last, idx.started
+}
+
+// BlockHash returns hash of the indexed block with given number, if it is still within reorg depth
+func (idx *ChainStatsIndex) BlockHash(blockNum uint64) (common.Hash, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.recent.Hash(blockNum)
+}
+
+func (idx *ChainStatsIndex) day(day uint64) *dayStats {
+	d, ok := idx.days[day]
+	if !ok {
+		d = &dayStats{DayStats: DayStats{Day: day}, active: newHyperLogLog()}
+		idx.days[day] = d
+		chainStatsDays.SetInt(len(idx.days))
+	}
+	return d
+}
+
+// AddBlock adds the new head block. If block's number is not above the last indexed block - it's treated
+// as a reorg: all blocks with number >= b.Number are removed first.
+func (idx *ChainStatsIndex) AddBlock(b *BlockStats) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if !idx.started {
+		idx.started, idx.first = true, b.Number
+	}
+	idx.recent.UnwindTo(b.Number, idx.remove)
+	idx.day(b.Day()).add(b)
+	idx.first, idx.last = min(idx.first, b.Number), b.Number
+	if final, ok := idx.recent.Push(b.Number, b.Hash, b); ok {
+		idx.day(final.Day()).addAddresses(final.Addresses)
+	}
+}
+
+// AddHistoricalBlock adds block right below the indexed range, used to backfill the index
+func (idx *ChainStatsIndex) AddHistoricalBlock(b *BlockStats) bool {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if !idx.started || idx.first == 0 || b.Number != idx.first-1 {
+		return false
+	}
+	d := idx.day(b.Day())
+	d.add(b)
+	d.addAddresses(b.Addresses)
+	idx.first = b.Number
+	return true
+}
+
+// remove removes the block orphaned by reorg
+func (idx *ChainStatsIndex) remove(_ uint64, b *BlockStats) {
+	d := idx.days[b.Day()]
+	d.sub(b)
+	if d.Blocks == 0 {
+		delete(idx.days, b.Day())
+		chainStatsDays.SetInt(len(idx.days))
+	}
+}
+
+// Days returns statistics of the days within [fromDay, toDay] which have indexed blocks, ascending
+func (idx *ChainStatsIndex) Days(fromDay, toDay uint64) []DayStats {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	var res []DayStats
+	for day, d := range idx.days {
+		if day < fromDay || day > toDay {
+			continue
+		}
+		active := d.active.clone()
+		idx.recent.Each(func(_ uint64, b *BlockStats) {
+			if b.Day() == day {
+				for _, addr := range b.Addresses {
+					active.add(addr)
+				}
+			}
+		})
+		s := d.DayStats
+		s.ActiveAddresses = active.estimate()
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Day < res[j].Day })
+	return res
+}
+
+type chainStatsFile struct {
+	First uint64         `json:"first"`
+	Last  uint64         `json:"last"`
+	Days  []dayStatsFile `json:"days"`
+}
+
+type dayStatsFile struct {
+	DayStats
+	Active []byte `json:"active"` // HyperLogLog registers
+}
+
+// Save writes the blocks deeper than reorg depth to the file, the rest are re-indexed after LoadChainStatsIndex
+func (idx *ChainStatsIndex) Save(path string) error {
+	idx.lock.RLock()
+	f := chainStatsFile{First: idx.first, Last: idx.last}
+	if oldest, ok := idx.recent.Oldest(); ok {
+		f.Last = oldest - 1
+	}
+	if !idx.started || f.Last < f.First || f.Last == ^uint64(0) {
+		idx.lock.RUnlock()
+		return nil
+	}
+	days := make(map[uint64]*dayStatsFile, len(idx.days))
+	for day, d := range idx.days {
+		days[day] = &dayStatsFile{DayStats: d.DayStats, Active: d.active.clone()}
+	}
+	var recent []*BlockStats
+	idx.recent.Each(func(_ uint64, b *BlockStats) { recent = append(recent, b) })
+	idx.lock.RUnlock()
+
+	for i := len(recent) - 1; i >= 0; i-- { // newest first, as on unwind
+		b := recent[i]
+		d := &dayStats{DayStats: days[b.Day()].DayStats}
+		d.sub(b)
+		days[b.Day()].DayStats = d.DayStats
+	}
+
+	for _, d := range days {
+		if d.Blocks > 0 {
+			f.Days = append(f.Days, *d)
+		}
+	}
+	sort.Slice(f.Days, func(i, j int) bool { return f.Days[i].Day < f.Days[j].Day })
+	data, err := json.Marshal(&f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadChainStatsIndex loads the index saved to the file, empty index if the file doesn't exist
+func LoadChainStatsIndex(path string) (*ChainStatsIndex, error) {
+	idx := NewChainStatsIndex()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f chainStatsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse chain stats file %s: %w", path, err)
+	}
+	for _, d := range f.Days {
+		if len(d.Active) != hllRegisters {
+			return nil, fmt.Errorf("parse chain stats file %s: day %d: bad active addresses sketch", path, d.Day)
+		}
+		idx.days[d.Day] = &dayStats{DayStats: d.DayStats, active: d.Active}
+	}
+	if len(f.Days) > 0 {
+		idx.started, idx.first, idx.last = true, f.First, f.Last
+	}
+	chainStatsDays.SetInt(len(idx.days))
+	return idx, nil
+}
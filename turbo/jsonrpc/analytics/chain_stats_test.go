@@ -0,0 +1,112 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+func statsBlock(num, time, txs uint64, addrs ...common.Address) *BlockStats {
+	return &BlockStats{
+		Number:       num,
+		Hash:         common.Hash{byte(num)},
+		Time:         time,
+		Transactions: txs,
+		GasUsed:      txs * 21000,
+		BurntFees:    *uint256.NewInt(txs * 21000 * 10),
+		Addresses:    addrs,
+	}
+}
+
+func TestChainStatsIndex(t *testing.T) {
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	idx := NewChainStatsIndex()
+	require.False(t, idx.AddHistoricalBlock(statsBlock(9, 0, 1)))
+
+	idx.AddBlock(statsBlock(10, SecondsPerDay-1, 2, a, b))
+	idx.AddBlock(statsBlock(11, SecondsPerDay, 1, a))
+	idx.AddBlock(statsBlock(12, SecondsPerDay+12, 1, c))
+
+	days := idx.Days(0, 10)
+	require.Len(t, days, 2)
+	require.Equal(t, uint64(0), days[0].Day)
+	require.Equal(t, uint64(2), days[0].Transactions)
+	require.Equal(t, uint64(2), days[0].ActiveAddresses)
+	require.Equal(t, uint64(1), days[1].Day)
+	require.Equal(t, uint64(11), days[1].FirstBlock)
+	require.Equal(t, uint64(12), days[1].LastBlock)
+	require.Equal(t, uint64(2), days[1].Blocks)
+	require.Equal(t, uint64(2*21000), days[1].GasUsed)
+	require.Equal(t, uint64(2), days[1].ActiveAddresses)
+
+	// reorg: block 12 replaced
+	idx.AddBlock(statsBlock(12, SecondsPerDay+12, 3, a))
+	days = idx.Days(1, 1)
+	require.Len(t, days, 1)
+	require.Equal(t, uint64(4), days[0].Transactions)
+	require.Equal(t, uint64(4*21000*10), days[0].BurntFees.Uint64())
+	require.Equal(t, uint64(1), days[0].ActiveAddresses)
+
+	// backfill
+	require.True(t, idx.AddHistoricalBlock(statsBlock(9, SecondsPerDay-20, 1, c)))
+	days = idx.Days(0, 0)
+	require.Equal(t, uint64(9), days[0].FirstBlock)
+	require.Equal(t, uint64(3), days[0].Transactions)
+	require.Equal(t, uint64(3), days[0].ActiveAddresses)
+
+	// blocks within reorg depth are not saved, backfilled block below them is
+	path := filepath.Join(t.TempDir(), "chainstats.json")
+	require.NoError(t, idx.Save(path))
+	loaded, err := LoadChainStatsIndex(path)
+	require.NoError(t, err)
+	from, to, ok := loaded.Range()
+	require.True(t, ok)
+	require.Equal(t, uint64(9), from)
+	require.Equal(t, uint64(9), to)
+	require.Len(t, loaded.Days(0, 1), 1)
+
+	for n := uint64(13); n < 13+maxReorgDepth; n++ {
+		idx.AddBlock(statsBlock(n, 2*SecondsPerDay+n, 1, common.Address{byte(n)}))
+	}
+	require.NoError(t, idx.Save(path))
+	loaded, err = LoadChainStatsIndex(path)
+	require.NoError(t, err)
+	from, to, ok = loaded.Range()
+	require.True(t, ok)
+	require.Equal(t, uint64(9), from)
+	require.Equal(t, uint64(12), to)
+	require.Equal(t, idx.Days(0, 1), loaded.Days(0, 1))
+	require.Empty(t, loaded.Days(2, 2))
+}
+
+func TestHyperLogLog(t *testing.T) {
+	h := newHyperLogLog()
+	require.Zero(t, h.estimate())
+	for i := 0; i < 100_000; i++ {
+		var addr common.Address
+		binary.BigEndian.PutUint64(addr[:], uint64(i%50_000))
+		h.add(addr)
+	}
+	require.InDelta(t, 50_000, float64(h.estimate()), 50_000*0.05)
+}
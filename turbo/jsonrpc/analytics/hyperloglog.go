@@ -0,0 +1,69 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/bits"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// hllPrecision - 2^12 registers, standard error of the estimate is ~1.6%
+const hllPrecision = 12
+
+const hllRegisters = 1 << hllPrecision
+
+// hyperLogLog estimates the amount of distinct addresses added to it in fixed memory
+type hyperLogLog []byte
+
+func newHyperLogLog() hyperLogLog {
+	return make(hyperLogLog, hllRegisters)
+}
+
+func (h hyperLogLog) add(addr common.Address) {
+	sum := sha256.Sum256(addr[:])
+	x := binary.BigEndian.Uint64(sum[:8])
+	i := x >> (64 - hllPrecision)
+	rank := byte(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h[i] {
+		h[i] = rank
+	}
+}
+
+func (h hyperLogLog) clone() hyperLogLog {
+	return append(hyperLogLog(nil), h...)
+}
+
+func (h hyperLogLog) estimate() uint64 {
+	var sum float64
+	var zeros int
+	for _, r := range h {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	m := float64(hllRegisters)
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 { // small range correction: linear counting
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(e))
+}
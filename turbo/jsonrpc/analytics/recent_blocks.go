@@ -0,0 +1,90 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// maxReorgDepth is how many of the most recent blocks keep their individual contribution,
+// deeper reorgs can't be reverted and are ignored
+const maxReorgDepth = 128
+
+type recentBlock[T any] struct {
+	number uint64
+	hash   common.Hash
+	data   T
+}
+
+// RecentBlocks keeps last maxReorgDepth blocks added to an index, ascending by number, along with their
+// contribution - to detect reorgs by hash and to revert contribution of orphaned blocks.
+// Not thread-safe: guarded by the lock of the owning index.
+type RecentBlocks[T any] struct {
+	blocks []recentBlock[T]
+}
+
+// Hash returns hash of the block with given number, if it is still within reorg depth
+func (r *RecentBlocks[T]) Hash(blockNum uint64) (common.Hash, bool) {
+	for i := len(r.blocks) - 1; i >= 0; i-- {
+		if r.blocks[i].number == blockNum {
+			return r.blocks[i].hash, true
+		}
+		if r.blocks[i].number < blockNum {
+			break
+		}
+	}
+	return common.Hash{}, false
+}
+
+// Push appends block above all recent blocks, the oldest block falls out of reorg depth and its data is returned
+func (r *RecentBlocks[T]) Push(blockNum uint64, hash common.Hash, data T) (evicted T, ok bool) {
+	r.blocks = append(r.blocks, recentBlock[T]{number: blockNum, hash: hash, data: data})
+	if len(r.blocks) > maxReorgDepth {
+		evicted, ok = r.blocks[0].data, true
+		r.blocks = r.blocks[len(r.blocks)-maxReorgDepth:]
+	}
+	return evicted, ok
+}
+
+// Oldest returns number of the oldest block still within reorg depth
+func (r *RecentBlocks[T]) Oldest() (uint64, bool) {
+	if len(r.blocks) == 0 {
+		return 0, false
+	}
+	return r.blocks[0].number, true
+}
+
+// Each calls f for every recent block, ascending by number
+func (r *RecentBlocks[T]) Each(f func(blockNum uint64, data T)) {
+	for _, b := range r.blocks {
+		f(b.number, b.data)
+	}
+}
+
+// UnwindTo removes all blocks with number >= blockNum, newest first, `revert` (if not nil) is called for each of them
+func (r *RecentBlocks[T]) UnwindTo(blockNum uint64, revert func(blockNum uint64, data T)) {
+	for len(r.blocks) > 0 {
+		last := r.blocks[len(r.blocks)-1]
+		if last.number < blockNum {
+			return
+		}
+		if revert != nil {
+			revert(last.number, last.data)
+		}
+		r.blocks = r.blocks[:len(r.blocks)-1]
+	}
+}
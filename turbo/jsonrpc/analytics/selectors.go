@@ -0,0 +1,155 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"sync"
+
+	"github.com/RoaringBitmap/roaring/v2/roaring64"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+const SelectorLen = 4
+
+var selectorIndexBlocks = metrics.GetOrCreateGauge(`selector_index_blocks`)
+
+// Selector is the function selector: first 4 bytes of transaction's calldata
+type Selector [SelectorLen]byte
+
+// SelectorOf returns selector of the calldata, false if calldata is shorter than selector
+func SelectorOf(data []byte) (Selector, bool) {
+	var s Selector
+	if len(data) < SelectorLen {
+		return s, false
+	}
+	copy(s[:], data)
+	return s, true
+}
+
+// SelectorIndex is an in-memory index: selector -> numbers of blocks which have at least one transaction
+// calling the selector. Index covers continuous range of blocks: it grows forward with new heads and
+// optionally backward by backfilling.
+type SelectorIndex struct {
+	lock        sync.RWMutex
+	started     bool
+	first, last uint64
+	blocks      map[Selector]*roaring64.Bitmap
+	recent      RecentBlocks[[]Selector]
+}
+
+func NewSelectorIndex() *SelectorIndex {
+	return &SelectorIndex{blocks: map[Selector]*roaring64.Bitmap{}}
+}
+
+// Range returns the range of indexed blocks
+func (idx *SelectorIndex) Range() (from, to uint64, ok bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.first, idx.last, idx.started
+}
+
+// LastBlock returns the last indexed block
+func (idx *SelectorIndex) LastBlock() (uint64, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.last, idx.started
+}
+
+// BlockHash returns hash of the indexed block with given number, if it is still within reorg depth
+func (idx *SelectorIndex) BlockHash(blockNum uint64) (common.Hash, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.recent.Hash(blockNum)
+}
+
+// AddBlock adds selectors called by transactions of the new head block. If block's number is not above the last
+// indexed block - it's treated as a reorg: all blocks with number >= blockNum are removed first.
+func (idx *SelectorIndex) AddBlock(blockNum uint64, hash common.Hash, selectors []Selector) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if !idx.started {
+		idx.started, idx.first = true, blockNum
+	}
+	idx.recent.UnwindTo(blockNum, idx.remove)
+	idx.add(blockNum, selectors)
+	idx.first, idx.last = min(idx.first, blockNum), blockNum
+	idx.recent.Push(blockNum, hash, selectors)
+	selectorIndexBlocks.SetUint64(idx.last - idx.first + 1)
+}
+
+// AddHistoricalBlock adds block right below the indexed range, used to backfill the index
+func (idx *SelectorIndex) AddHistoricalBlock(blockNum uint64, selectors []Selector) bool {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if !idx.started || idx.first == 0 || blockNum != idx.first-1 {
+		return false
+	}
+	idx.add(blockNum, selectors)
+	idx.first = blockNum
+	selectorIndexBlocks.SetUint64(idx.last - idx.first + 1)
+	return true
+}
+
+func (idx *SelectorIndex) add(blockNum uint64, selectors []Selector) {
+	for _, s := range selectors {
+		bm, ok := idx.blocks[s]
+		if !ok {
+			bm = roaring64.New()
+			idx.blocks[s] = bm
+		}
+		bm.Add(blockNum)
+	}
+}
+
+// remove removes the block orphaned by reorg
+func (idx *SelectorIndex) remove(blockNum uint64, selectors []Selector) {
+	for _, s := range selectors {
+		if bm, ok := idx.blocks[s]; ok {
+			bm.Remove(blockNum)
+			if bm.IsEmpty() {
+				delete(idx.blocks, s)
+			}
+		}
+	}
+}
+
+// Blocks returns up to `limit` numbers of blocks within [from, to] which have transactions calling the selector,
+// in ascending order
+func (idx *SelectorIndex) Blocks(s Selector, from, to uint64, limit int) []uint64 {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	bm, ok := idx.blocks[s]
+	if !ok {
+		return nil
+	}
+	var res []uint64
+	it := bm.Iterator()
+	it.AdvanceIfNeeded(from)
+	for it.HasNext() && len(res) < limit {
+		n := it.Next()
+		if n > to {
+			break
+		}
+		res = append(res, n)
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+func TestSelectorIndex(t *testing.T) {
+	transfer, approve := Selector{0xa9, 0x05, 0x9c, 0xbb}, Selector{0x09, 0x5e, 0xa7, 0xb3}
+	idx := NewSelectorIndex()
+
+	_, _, ok := idx.Range()
+	require.False(t, ok)
+	require.False(t, idx.AddHistoricalBlock(9, nil))
+
+	idx.AddBlock(10, common.Hash{10}, []Selector{transfer})
+	idx.AddBlock(11, common.Hash{11}, []Selector{transfer, approve})
+	idx.AddBlock(12, common.Hash{12}, []Selector{approve})
+
+	require.Equal(t, []uint64{10, 11}, idx.Blocks(transfer, 0, 100, 10))
+	require.Equal(t, []uint64{11, 12}, idx.Blocks(approve, 0, 100, 10))
+	require.Equal(t, []uint64{11}, idx.Blocks(approve, 0, 100, 1))
+	require.Equal(t, []uint64{12}, idx.Blocks(approve, 12, 100, 10))
+	require.Empty(t, idx.Blocks(approve, 0, 10, 10))
+	require.Empty(t, idx.Blocks(Selector{1, 2, 3, 4}, 0, 100, 10))
+
+	// reorg: blocks 11 and 12 replaced by new block 11
+	idx.AddBlock(11, common.Hash{111}, []Selector{approve})
+	require.Equal(t, []uint64{10}, idx.Blocks(transfer, 0, 100, 10))
+	require.Equal(t, []uint64{11}, idx.Blocks(approve, 0, 100, 10))
+	h, ok := idx.BlockHash(11)
+	require.True(t, ok)
+	require.Equal(t, common.Hash{111}, h)
+	_, ok = idx.BlockHash(12)
+	require.False(t, ok)
+
+	// backfill
+	require.False(t, idx.AddHistoricalBlock(8, nil))
+	require.True(t, idx.AddHistoricalBlock(9, []Selector{transfer}))
+	require.Equal(t, []uint64{9, 10}, idx.Blocks(transfer, 0, 100, 10))
+	from, to, ok := idx.Range()
+	require.True(t, ok)
+	require.Equal(t, uint64(9), from)
+	require.Equal(t, uint64(11), to)
+}
+
+func TestSelectorOf(t *testing.T) {
+	_, ok := SelectorOf([]byte{1, 2, 3})
+	require.False(t, ok)
+	s, ok := SelectorOf([]byte{1, 2, 3, 4, 5})
+	require.True(t, ok)
+	require.Equal(t, Selector{1, 2, 3, 4}, s)
+}
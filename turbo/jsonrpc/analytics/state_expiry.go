@@ -0,0 +1,225 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/metrics"
+)
+
+// DefaultExpiryPolicies - idle periods (in blocks) reported when caller doesn't provide own policies:
+// ~1 month, ~6 months and ~1 year of 12s slots
+var DefaultExpiryPolicies = []uint64{216_000, 1_296_000, 2_628_000}
+
+var (
+	stateExpiryTrackedAccounts = metrics.GetOrCreateGauge(`state_expiry_tracked{kind="accounts"}`)
+	stateExpiryTrackedSlots    = metrics.GetOrCreateGauge(`state_expiry_tracked{kind="storage"}`)
+	stateExpiryStaleAccounts   = metrics.GetOrCreateGaugeVec("state_expiry_stale_accounts", []string{"policy"}, "Accounts not accessed within policy period")
+	stateExpiryStaleSlots      = metrics.GetOrCreateGaugeVec("state_expiry_stale_storage", []string{"policy"}, "Storage slots not accessed within policy period")
+)
+
+// metricsUpdateInterval - stale state metrics require full scan of tracked state, so they are recomputed
+// at most once per this amount of blocks
+const metricsUpdateInterval = 256
+
+// ExpiryPolicyReport - how much of tracked state would be expired if everything not accessed for `Period` blocks is expired
+type ExpiryPolicyReport struct {
+	Period          uint64
+	Complete        bool // false if tracking started less than `Period` blocks ago, then numbers are lower bound
+	ExpiredAccounts uint64
+	ExpiredSlots    uint64
+}
+
+type StateExpiryReport struct {
+	FromBlock       uint64 // first tracked block
+	LastBlock       uint64 // last tracked block
+	TrackedAccounts uint64
+	TrackedSlots    uint64
+	Estimated       bool // true if keys were sampled to stay within the bound, then numbers are estimates
+	Policies        []ExpiryPolicyReport
+}
+
+// StateExpiryMaxKeys - default bound of tracked accounts and, separately, of tracked storage slots: ~100MB each
+const StateExpiryMaxKeys = 1 << 20
+
+// StateExpiryTracker keeps block of last access (read or write) for every account and storage slot accessed since
+// tracking started.
+// Keys are stored as-is: 20 bytes of address for accounts, address+location for storage slots.
+type StateExpiryTracker struct {
+	lock         sync.RWMutex
+	started      bool
+	fromBlock    uint64
+	lastBlock    uint64
+	metricsBlock uint64 // last block for which metrics were published
+	accounts     sampledAccess
+	slots        sampledAccess
+	recent       RecentBlocks[struct{}] // only to detect reorgs
+}
+
+// NewStateExpiryTracker creates tracker of at most `maxKeys` accounts and `maxKeys` storage slots
+func NewStateExpiryTracker(maxKeys int) *StateExpiryTracker {
+	return &StateExpiryTracker{
+		accounts: newSampledAccess(maxKeys),
+		slots:    newSampledAccess(maxKeys),
+	}
+}
+
+// LastBlock returns the last tracked block
+func (t *StateExpiryTracker) LastBlock() (uint64, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.lastBlock, t.started
+}
+
+// BlockHash returns hash of the tracked block with given number, if it is still within reorg depth
+func (t *StateExpiryTracker) BlockHash(blockNum uint64) (common.Hash, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.recent.Hash(blockNum)
+}
+
+// MarkAccessed marks accounts and storage slots as read or written in given block. Access block never goes backwards:
+// after reorg orphaned blocks still count as accesses - it's precise enough for statistics.
+func (t *StateExpiryTracker) MarkAccessed(blockNum uint64, hash common.Hash, accounts, slots [][]byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.started {
+		t.started, t.fromBlock = true, blockNum
+	}
+	t.recent.UnwindTo(blockNum, nil)
+	t.recent.Push(blockNum, hash, struct{}{})
+	t.lastBlock = blockNum
+	for _, k := range accounts {
+		t.accounts.mark(k, blockNum)
+	}
+	for _, k := range slots {
+		t.slots.mark(k, blockNum)
+	}
+	stateExpiryTrackedAccounts.SetUint64(t.accounts.count())
+	stateExpiryTrackedSlots.SetUint64(t.slots.count())
+}
+
+// Report counts tracked accounts and slots which were not accessed within each of `policies` periods
+func (t *StateExpiryTracker) Report(policies []uint64) StateExpiryReport {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	res := StateExpiryReport{
+		FromBlock:       t.fromBlock,
+		LastBlock:       t.lastBlock,
+		TrackedAccounts: t.accounts.count(),
+		TrackedSlots:    t.slots.count(),
+		Estimated:       t.accounts.level > 0 || t.slots.level > 0,
+		Policies:        make([]ExpiryPolicyReport, len(policies)),
+	}
+	for i, period := range policies {
+		res.Policies[i] = ExpiryPolicyReport{Period: period, Complete: t.started && t.lastBlock-t.fromBlock >= period}
+	}
+	for _, accessed := range t.accounts.blocks {
+		for i, period := range policies {
+			if accessed+period < t.lastBlock {
+				res.Policies[i].ExpiredAccounts += t.accounts.scale()
+			}
+		}
+	}
+	for _, accessed := range t.slots.blocks {
+		for i, period := range policies {
+			if accessed+period < t.lastBlock {
+				res.Policies[i].ExpiredSlots += t.slots.scale()
+			}
+		}
+	}
+	return res
+}
+
+// UpdateMetrics publishes report for DefaultExpiryPolicies, at most once per metricsUpdateInterval blocks
+func (t *StateExpiryTracker) UpdateMetrics() {
+	t.lock.Lock()
+	if t.metricsBlock > 0 && t.lastBlock < t.metricsBlock+metricsUpdateInterval {
+		t.lock.Unlock()
+		return
+	}
+	t.metricsBlock = t.lastBlock
+	t.lock.Unlock()
+
+	for _, p := range t.Report(DefaultExpiryPolicies).Policies {
+		policy := strconv.FormatUint(p.Period, 10)
+		stateExpiryStaleAccounts.WithLabelValues(policy).SetUint64(p.ExpiredAccounts)
+		stateExpiryStaleSlots.WithLabelValues(policy).SetUint64(p.ExpiredSlots)
+	}
+}
+
+// sampledAccess keeps block of last access of at most `maxKeys` keys. When the bound is reached, half of the keys is
+// dropped and from then on only 1 of 2^level keys is tracked, chosen by hash of the key - the same key is always either
+// tracked or not. Counts of the tracked keys scaled by 2^level estimate counts of all keys.
+type sampledAccess struct {
+	maxKeys int
+	level   uint
+	blocks  map[string]uint64
+}
+
+func newSampledAccess(maxKeys int) sampledAccess {
+	return sampledAccess{maxKeys: maxKeys, blocks: map[string]uint64{}}
+}
+
+func (s *sampledAccess) sampled(k string) bool {
+	return s.level == 0 || keyHash(k)>>(64-s.level) == 0
+}
+
+func (s *sampledAccess) mark(k []byte, blockNum uint64) {
+	if !s.sampled(string(k)) {
+		return
+	}
+	if s.blocks[string(k)] < blockNum {
+		s.blocks[string(k)] = blockNum
+	}
+	for len(s.blocks) > s.maxKeys && s.level < 63 {
+		s.level++
+		for k := range s.blocks {
+			if !s.sampled(k) {
+				delete(s.blocks, k)
+			}
+		}
+	}
+}
+
+func (s *sampledAccess) scale() uint64 {
+	return 1 << s.level
+}
+
+// count returns the estimated amount of accessed keys
+func (s *sampledAccess) count() uint64 {
+	return uint64(len(s.blocks)) * s.scale()
+}
+
+// keyHash is 64-bit FNV-1a with murmur3 finalizer: keys often differ only in a few last bytes
+func keyHash(k string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(k); i++ {
+		h ^= uint64(k[i])
+		h *= 1099511628211
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
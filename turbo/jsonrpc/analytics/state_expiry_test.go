@@ -0,0 +1,98 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+func TestStateExpiryTracker(t *testing.T) {
+	tr := NewStateExpiryTracker(StateExpiryMaxKeys)
+	_, ok := tr.LastBlock()
+	require.False(t, ok)
+
+	a, b := []byte{1}, []byte{2}
+	s1, s2 := []byte{1, 1}, []byte{1, 2}
+	tr.MarkAccessed(10, common.Hash{10}, [][]byte{a, b}, [][]byte{s1, s2})
+	tr.MarkAccessed(20, common.Hash{20}, [][]byte{a}, [][]byte{s1})
+	tr.MarkAccessed(15, common.Hash{15}, nil, [][]byte{s1}) // reorg to lower block must not move last access back
+	tr.MarkAccessed(40, common.Hash{40}, nil, nil)
+
+	last, ok := tr.LastBlock()
+	require.True(t, ok)
+	require.Equal(t, uint64(40), last)
+	_, ok = tr.BlockHash(20)
+	require.False(t, ok) // orphaned
+	h, ok := tr.BlockHash(15)
+	require.True(t, ok)
+	require.Equal(t, common.Hash{15}, h)
+
+	r := tr.Report([]uint64{5, 25, 100})
+	require.Equal(t, uint64(10), r.FromBlock)
+	require.Equal(t, uint64(40), r.LastBlock)
+	require.Equal(t, uint64(2), r.TrackedAccounts)
+	require.Equal(t, uint64(2), r.TrackedSlots)
+	require.Equal(t, []ExpiryPolicyReport{
+		{Period: 5, Complete: true, ExpiredAccounts: 2, ExpiredSlots: 2},
+		{Period: 25, Complete: true, ExpiredAccounts: 1, ExpiredSlots: 1},
+		{Period: 100, Complete: false},
+	}, r.Policies)
+}
+
+func TestStateExpiryTrackerBound(t *testing.T) {
+	const maxKeys, n = 1000, 100_000
+	tr := NewStateExpiryTracker(maxKeys)
+	key := func(i int) []byte {
+		return binary.BigEndian.AppendUint64(make([]byte, 12), uint64(i))
+	}
+	// first half of the accounts and slots is accessed only in block 10, second half also in block 1000
+	for block := uint64(10); block <= 1000; block += 990 {
+		var keys [][]byte
+		for i := 0; i < n; i++ {
+			if block == 10 || i >= n/2 {
+				keys = append(keys, key(i))
+			}
+		}
+		tr.MarkAccessed(block, common.Hash{byte(block)}, keys, keys)
+	}
+	tr.MarkAccessed(2000, common.Hash{}, nil, nil)
+
+	require.LessOrEqual(t, len(tr.accounts.blocks), maxKeys)
+	require.LessOrEqual(t, len(tr.slots.blocks), maxKeys)
+	r := tr.Report([]uint64{1500, 2500})
+	require.True(t, r.Estimated)
+	require.InEpsilon(t, n, r.TrackedAccounts, 0.15)
+	require.InEpsilon(t, n, r.TrackedSlots, 0.15)
+	require.InEpsilon(t, n/2, r.Policies[0].ExpiredAccounts, 0.15)
+	require.InEpsilon(t, n/2, r.Policies[0].ExpiredSlots, 0.15)
+	require.Zero(t, r.Policies[1].ExpiredAccounts)
+
+	// keys are either always tracked or never, re-accessing doesn't grow the sample
+	tracked := len(tr.accounts.blocks)
+	var keys [][]byte
+	for i := 0; i < n; i++ {
+		keys = append(keys, key(i))
+	}
+	tr.MarkAccessed(2001, common.Hash{}, keys, nil)
+	require.Len(t, tr.accounts.blocks, tracked)
+	require.Zero(t, tr.Report([]uint64{1500}).Policies[0].ExpiredAccounts)
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// SecondsPerDay is the width of a single aggregation bucket
+const SecondsPerDay = 24 * 60 * 60
+
+type Metric string
+
+const (
+	MetricGas          Metric = "gas"
+	MetricStorageSlots Metric = "storageSlots"
+)
+
+func ParseMetric(s string) (Metric, error) {
+	switch Metric(s) {
+	case MetricGas, MetricStorageSlots:
+		return Metric(s), nil
+	case "":
+		return MetricGas, nil
+	default:
+		return "", fmt.Errorf("unknown metric %q, expected one of: %s, %s", s, MetricGas, MetricStorageSlots)
+	}
+}
+
+// ContractUsage is what a single contract consumed within one bucket
+type ContractUsage struct {
+	Gas          uint64 // gas used by transactions sent directly to the contract
+	StorageSlots int64  // net amount of storage slots created (negative if more slots were cleared)
+}
+
+func (u *ContractUsage) add(o ContractUsage) {
+	u.Gas += o.Gas
+	u.StorageSlots += o.StorageSlots
+}
+
+func (u *ContractUsage) sub(o ContractUsage) {
+	u.Gas -= o.Gas
+	u.StorageSlots -= o.StorageSlots
+}
+
+// BlockUsage is the contribution of a single block to the index
+type BlockUsage struct {
+	Number    uint64
+	Hash      common.Hash
+	Time      uint64
+	Contracts map[common.Address]ContractUsage
+}
+
+func (b *BlockUsage) Day() uint64 { return b.Time / SecondsPerDay }
+
+// TopContract is a single entry of the top-N report
+type TopContract struct {
+	Address      common.Address
+	Gas          uint64
+	StorageSlots int64
+}
+
+// TopContractsIndex is a rolling in-memory index of per-day usage of contracts.
+// Only last `retentionDays` days are kept, older buckets are evicted as new blocks arrive.
+type TopContractsIndex struct {
+	lock          sync.RWMutex
+	retentionDays uint64
+	daily         map[uint64]map[common.Address]*ContractUsage
+	recent        RecentBlocks[*BlockUsage]
+}
+
+func NewTopContractsIndex(retentionDays uint64) *TopContractsIndex {
+	if retentionDays == 0 {
+		retentionDays = 1
+	}
+	return &TopContractsIndex{
+		retentionDays: retentionDays,
+		daily:         map[uint64]map[common.Address]*ContractUsage{},
+	}
+}
+
+// LastBlock returns number of the last indexed block
+func (idx *TopContractsIndex) LastBlock() (uint64, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	if len(idx.recent.blocks) == 0 {
+		return 0, false
+	}
+	return idx.recent.blocks[len(idx.recent.blocks)-1].number, true
+}
+
+// BlockHash returns hash of the indexed block with given number, if it is still within reorg depth
+func (idx *TopContractsIndex) BlockHash(blockNum uint64) (common.Hash, bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	return idx.recent.Hash(blockNum)
+}
+
+// AddBlock adds block contribution to the index. If block's number is not above the last indexed block -
+// it's treated as a reorg: contribution of all blocks with number >= b.Number is reverted first.
+func (idx *TopContractsIndex) AddBlock(b *BlockUsage) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	idx.recent.UnwindTo(b.Number, idx.revert)
+
+	day := b.Day()
+	bucket, ok := idx.daily[day]
+	if !ok {
+		bucket = map[common.Address]*ContractUsage{}
+		idx.daily[day] = bucket
+	}
+	for addr, u := range b.Contracts {
+		acc, ok := bucket[addr]
+		if !ok {
+			acc = &ContractUsage{}
+			bucket[addr] = acc
+		}
+		acc.add(u)
+	}
+
+	idx.recent.Push(b.Number, b.Hash, b)
+	idx.evict(day)
+}
+
+// revert reverts contribution of the block orphaned by reorg
+func (idx *TopContractsIndex) revert(_ uint64, b *BlockUsage) {
+	bucket, ok := idx.daily[b.Day()]
+	if !ok {
+		return
+	}
+	for addr, u := range b.Contracts {
+		acc, ok := bucket[addr]
+		if !ok {
+			continue
+		}
+		acc.sub(u)
+		if acc.Gas == 0 && acc.StorageSlots == 0 {
+			delete(bucket, addr)
+		}
+	}
+}
+
+func (idx *TopContractsIndex) evict(currentDay uint64) {
+	if currentDay < idx.retentionDays {
+		return
+	}
+	oldest := currentDay - idx.retentionDays + 1
+	for day := range idx.daily {
+		if day < oldest {
+			delete(idx.daily, day)
+		}
+	}
+}
+
+// Days returns the range of days currently held by the index
+func (idx *TopContractsIndex) Days() (from, to uint64, ok bool) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	for day := range idx.daily {
+		if !ok || day < from {
+			from = day
+		}
+		if !ok || day > to {
+			to = day
+		}
+		ok = true
+	}
+	return from, to, ok
+}
+
+// Top returns `limit` contracts with the highest value of `metric` aggregated over days [fromDay, toDay].
+// Result is deterministic: ties are ordered by address.
+func (idx *TopContractsIndex) Top(metric Metric, fromDay, toDay uint64, limit int) []TopContract {
+	idx.lock.RLock()
+	totals := map[common.Address]*ContractUsage{}
+	for day, bucket := range idx.daily {
+		if day < fromDay || day > toDay {
+			continue
+		}
+		for addr, u := range bucket {
+			acc, ok := totals[addr]
+			if !ok {
+				acc = &ContractUsage{}
+				totals[addr] = acc
+			}
+			acc.add(*u)
+		}
+	}
+	idx.lock.RUnlock()
+
+	res := make([]TopContract, 0, len(totals))
+	for addr, u := range totals {
+		if metric == MetricGas && u.Gas == 0 {
+			continue
+		}
+		if metric == MetricStorageSlots && u.StorageSlots <= 0 {
+			continue
+		}
+		res = append(res, TopContract{Address: addr, Gas: u.Gas, StorageSlots: u.StorageSlots})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		switch metric {
+		case MetricStorageSlots:
+			if res[i].StorageSlots != res[j].StorageSlots {
+				return res[i].StorageSlots > res[j].StorageSlots
+			}
+		default:
+			if res[i].Gas != res[j].Gas {
+				return res[i].Gas > res[j].Gas
+			}
+		}
+		return bytes.Compare(res[i].Address[:], res[j].Address[:]) < 0
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+func TestTopContractsIndex(t *testing.T) {
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	idx := NewTopContractsIndex(2)
+
+	idx.AddBlock(&BlockUsage{Number: 1, Hash: common.Hash{1}, Time: 0, Contracts: map[common.Address]ContractUsage{
+		a: {Gas: 100, StorageSlots: 1},
+		b: {Gas: 300},
+	}})
+	idx.AddBlock(&BlockUsage{Number: 2, Hash: common.Hash{2}, Time: SecondsPerDay, Contracts: map[common.Address]ContractUsage{
+		a: {Gas: 250, StorageSlots: 5},
+		c: {Gas: 50, StorageSlots: -2},
+	}})
+
+	top := idx.Top(MetricGas, 0, 1, 10)
+	require.Equal(t, []TopContract{{a, 350, 6}, {b, 300, 0}, {c, 50, -2}}, top)
+
+	top = idx.Top(MetricStorageSlots, 0, 1, 10)
+	require.Equal(t, []TopContract{{a, 350, 6}}, top)
+
+	top = idx.Top(MetricGas, 0, 1, 1)
+	require.Len(t, top, 1)
+
+	// reorg: block 2 replaced
+	idx.AddBlock(&BlockUsage{Number: 2, Hash: common.Hash{22}, Time: SecondsPerDay, Contracts: map[common.Address]ContractUsage{
+		c: {Gas: 1000},
+	}})
+	top = idx.Top(MetricGas, 0, 1, 10)
+	require.Equal(t, []TopContract{{c, 1000, 0}, {b, 300, 0}, {a, 100, 1}}, top)
+	h, ok := idx.BlockHash(2)
+	require.True(t, ok)
+	require.Equal(t, common.Hash{22}, h)
+
+	// day 0 falls out of retention window
+	idx.AddBlock(&BlockUsage{Number: 3, Hash: common.Hash{3}, Time: 2 * SecondsPerDay, Contracts: map[common.Address]ContractUsage{
+		b: {Gas: 1},
+	}})
+	from, to, ok := idx.Days()
+	require.True(t, ok)
+	require.Equal(t, uint64(1), from)
+	require.Equal(t, uint64(2), to)
+	top = idx.Top(MetricGas, 0, 2, 10)
+	require.Equal(t, []TopContract{{c, 1000, 0}, {b, 1, 0}}, top)
+}
+
+func TestParseMetric(t *testing.T) {
+	m, err := ParseMetric("")
+	require.NoError(t, err)
+	require.Equal(t, MetricGas, m)
+	m, err = ParseMetric("storageSlots")
+	require.NoError(t, err)
+	require.Equal(t, MetricStorageSlots, m)
+	_, err = ParseMetric("foo")
+	require.Error(t, err)
+}
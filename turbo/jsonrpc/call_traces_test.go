@@ -17,7 +17,10 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"sync"
 	"testing"
 
@@ -29,9 +32,12 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/erigontech/erigon/core"
 	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/tracers"
+	tracersConfig "github.com/erigontech/erigon/eth/tracers/config"
 	"github.com/erigontech/erigon/turbo/stages/mock"
 )
 
@@ -274,3 +280,101 @@ func TestFilterAddressIntersection(t *testing.T) {
 		require.Empty(t, blockNumbersFromTraces(t, stream.Buffer()))
 	})
 }
+
+func TestTraceBlockDedupCode(t *testing.T) {
+	m := mock.Mock(t)
+	api := NewTraceAPI(newBaseApiForTest(m), m.DB, &httpcfg.HttpCfg{})
+
+	// deploys 40 bytes of STOP
+	runtime := make([]byte, 40)
+	initCode := append(common.FromHex("0x6028600c60003960286000f3"), runtime...)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 1, func(i int, block *core.BlockGen) {
+		signer := types.LatestSigner(m.ChainConfig)
+		for j := 0; j < 3; j++ {
+			txn, err := types.SignTx(types.NewContractCreation(block.TxNonce(m.Address), new(uint256.Int), 100_000, new(uint256.Int), initCode), *signer, m.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block.AddTx(txn)
+		}
+	})
+	require.NoError(t, err, "generate chain")
+	require.NoError(t, m.InsertChain(chain), "inserting chain")
+
+	plain, err := api.traceBlock(context.Background(), 1, new(bool), nil)
+	require.NoError(t, err)
+	for _, trace := range plain {
+		if action, ok := trace.Action.(*CreateTraceAction); ok {
+			require.Equal(t, hexutil.Bytes(initCode), action.Init)
+		}
+	}
+
+	deduped, err := api.traceBlockDedupedCode(context.Background(), 1, new(bool), nil)
+	require.NoError(t, err)
+	initHash, codeHash := crypto.Keccak256Hash(initCode), crypto.Keccak256Hash(runtime)
+	require.Equal(t, map[common.Hash]hexutil.Bytes{initHash: initCode, codeHash: runtime}, deduped.Codes)
+
+	var creates int
+	for _, trace := range deduped.Traces {
+		action, ok := trace.Action.(*CreateTraceAction)
+		if !ok {
+			continue
+		}
+		result := trace.Result.(*CreateTraceResult)
+		require.Empty(t, action.Init)
+		require.Equal(t, initHash, *action.InitHash)
+		require.Empty(t, result.Code)
+		require.Equal(t, codeHash, *result.CodeHash)
+		creates++
+	}
+	require.Equal(t, 3, creates)
+}
+
+func TestTraceSchemaVersion(t *testing.T) {
+	m := mock.Mock(t)
+	api := NewTraceAPI(newBaseApiForTest(m), m.DB, &httpcfg.HttpCfg{})
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 1, func(i int, block *core.BlockGen) {
+		txn, err := types.SignTx(types.NewTransaction(block.TxNonce(m.Address), common.Address{1}, uint256.NewInt(1), 21_000, new(uint256.Int), nil), *types.LatestSigner(m.ChainConfig), m.Key)
+		require.NoError(t, err)
+		block.AddTx(txn)
+	})
+	require.NoError(t, err, "generate chain")
+	require.NoError(t, m.InsertChain(chain), "inserting chain")
+
+	schemaVersion := true
+	traceConfig := &tracersConfig.TraceConfig{SchemaVersion: &schemaVersion}
+	versioned := func(result []byte) string {
+		return fmt.Sprintf(`{"schemaVersion":%d,"result":%s}`, tracers.SchemaVersion, result)
+	}
+
+	plain, err := api.Block(m.Ctx, 1, new(bool), nil)
+	require.NoError(t, err)
+	plainJson, err := json.Marshal(plain)
+	require.NoError(t, err)
+	res, err := api.Block(m.Ctx, 1, new(bool), traceConfig)
+	require.NoError(t, err)
+	resJson, err := json.Marshal(res)
+	require.NoError(t, err)
+	require.Equal(t, versioned(plainJson), string(resJson))
+
+	txHash := chain.Blocks[0].Transactions()[0].Hash()
+	plain, err = api.ReplayTransaction(m.Ctx, txHash, []string{"trace", "stateDiff"}, new(bool), nil)
+	require.NoError(t, err)
+	plainJson, err = json.Marshal(plain)
+	require.NoError(t, err)
+	res, err = api.ReplayTransaction(m.Ctx, txHash, []string{"trace", "stateDiff"}, new(bool), traceConfig)
+	require.NoError(t, err)
+	resJson, err = json.Marshal(res)
+	require.NoError(t, err)
+	require.Equal(t, versioned(plainJson), string(resJson))
+
+	filter := func(traceConfig *tracersConfig.TraceConfig) []byte {
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		fromBlock, toBlock := hexutil.Uint64(1), hexutil.Uint64(1)
+		require.NoError(t, api.Filter(m.Ctx, TraceFilterRequest{FromBlock: &fromBlock, ToBlock: &toBlock}, new(bool), traceConfig, stream))
+		require.NoError(t, stream.Flush())
+		return buf.Bytes()
+	}
+	require.Equal(t, versioned(filter(nil)), string(filter(traceConfig)))
+}
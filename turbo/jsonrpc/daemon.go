@@ -17,6 +17,8 @@
 package jsonrpc
 
 import (
+	"context"
+
 	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/kvcache"
@@ -24,28 +26,97 @@ import (
 	"github.com/erigontech/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/erigontech/erigon/consensus"
 	"github.com/erigontech/erigon/consensus/clique"
+	"github.com/erigontech/erigon/eth/gasprice"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 	"github.com/erigontech/erigon/polygon/bor"
 	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/analytics"
+	"github.com/erigontech/erigon/turbo/jsonrpc/watch"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
 )
 
-// APIList describes the list of available RPC apis
-func APIList(db kv.TemporalRoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
+// APIList describes the list of available RPC apis. Background indexers (if enabled) run until ctx is cancelled.
+func APIList(ctx context.Context, db kv.TemporalRoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
 	filters *rpchelper.Filters, stateCache kvcache.Cache,
 	blockReader services.FullBlockReader, cfg *httpcfg.HttpCfg, engine consensus.EngineReader,
 	logger log.Logger, bridgeReader bridgeReader, spanProducersReader spanProducersReader,
 ) (list []rpc.API) {
+	if cfg.HttpCost {
+		db = rpchelper.NewCostAccountingDB(db)
+	}
 	base := NewBaseApi(filters, stateCache, blockReader, cfg.WithDatadir, cfg.EvmCallTimeout, engine, cfg.Dirs, bridgeReader)
+	base.nonCanonicalBlocks = cfg.NonCanonicalBlocks
+	base.recentReceipts = cfg.RecentReceipts
 	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap, cfg.Feecap, cfg.ReturnDataLimit, cfg.AllowUnprotectedTxs, cfg.MaxGetProofRewindBlockCount, cfg.WebsocketSubscribeLogsChannelSize, logger)
+	ethImpl.GethCompatErrors = cfg.GethCompatErrors
+	ethImpl.softConfirmations = cfg.SoftConfirmations
+	ethImpl.userOps = cfg.UserOps
+	if cfg.GasPriceStrategy != "" && !gasprice.IsValidStrategy(cfg.GasPriceStrategy) {
+		logger.Warn("[rpc] unknown gas price strategy, using the default one", "strategy", cfg.GasPriceStrategy, "supported", gasprice.Strategies())
+	} else {
+		ethImpl.GasPriceStrategy = cfg.GasPriceStrategy
+	}
 	erigonImpl := NewErigonAPI(base, db, eth)
+	erigonImpl.gasCap = cfg.Gascap
+	erigonImpl.txPool = txPool
+	erigonImpl.txnPropagation = cfg.TxnPropagation
+	erigonImpl.syncEvents = cfg.SyncEvents
+	if cfg.AnalyticsEnabled {
+		erigonImpl.topContracts = analytics.NewTopContractsIndex(cfg.AnalyticsRetentionDays)
+		go erigonImpl.followHeads(ctx, erigonImpl.topContractsFollower(), logger)
+	}
+	if cfg.AnalyticsStateExpiry {
+		erigonImpl.stateExpiry = analytics.NewStateExpiryTracker(analytics.StateExpiryMaxKeys)
+		go erigonImpl.followHeads(ctx, erigonImpl.stateExpiryFollower(), logger)
+	}
+	if cfg.AnalyticsSelectors {
+		erigonImpl.selectors = analytics.NewSelectorIndex()
+		go erigonImpl.followHeads(ctx, erigonImpl.selectorsFollower(cfg.AnalyticsSelectorsBackfill, logger), logger)
+	}
+	if cfg.ChainStatsFile != "" {
+		path := cfg.DataDirPath(cfg.ChainStatsFile)
+		chainStats, err := analytics.LoadChainStatsIndex(path)
+		if err != nil {
+			logger.Error("[rpc] chain stats disabled", "err", err)
+		} else {
+			erigonImpl.chainStats = chainStats
+			go erigonImpl.followHeads(ctx, erigonImpl.chainStatsFollower(path, logger), logger)
+		}
+	}
+	if cfg.WatchListsFile != "" {
+		watcher, err := watch.NewWatcher(cfg.DataDirPath(cfg.WatchListsFile), cfg.WatchListsWebhookHosts, logger)
+		if err != nil {
+			logger.Error("[rpc] watch-lists disabled", "err", err)
+		} else {
+			erigonImpl.watcher = watcher
+			go erigonImpl.followHeads(ctx, erigonImpl.watchFollower(), logger)
+			go erigonImpl.followPendingTxs(ctx, logger)
+			go func() {
+				<-ctx.Done()
+				watcher.Close()
+			}()
+		}
+	}
 	txpoolImpl := NewTxPoolAPI(base, db, txPool)
 	netImpl := NewNetAPIImpl(eth)
 	debugImpl := NewPrivateDebugAPI(base, db, cfg.Gascap)
+	if cfg.SourceMapsDir != "" {
+		sourceMaps, err := sourcemap.NewRegistry(cfg.DataDirPath(cfg.SourceMapsDir))
+		if err != nil {
+			logger.Error("[rpc] source maps disabled", "err", err)
+		} else {
+			debugImpl.sourceMaps = sourceMaps
+		}
+	}
+	if cfg.WitnessDir != "" {
+		debugImpl.witnessDir = cfg.DataDirPath(cfg.WitnessDir)
+	}
 	traceImpl := NewTraceAPI(base, db, cfg)
 	web3Impl := NewWeb3APIImpl(eth)
 	dbImpl := NewDBAPIImpl() /* deprecated */
 	adminImpl := NewAdminAPI(eth)
+	adminImpl.compaction = cfg.CompactionScheduler
 	parityImpl := NewParityAPIImpl(base, db)
 
 	var borImpl *BorImpl
@@ -65,6 +136,11 @@ func APIList(db kv.TemporalRoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolC
 	}
 
 	otsImpl := NewOtterscanAPI(base, db, cfg.OtsMaxPageSize)
+	erigonImpl.ots = otsImpl
+	erigonImpl.eth = ethImpl
+	if cfg.IPLDDir != "" {
+		erigonImpl.ipldDir = cfg.DataDirPath(cfg.IPLDDir)
+	}
 	gqlImpl := NewGraphQLAPI(base, db)
 	overlayImpl := NewOverlayAPI(base, db, cfg.Gascap, cfg.OverlayGetLogsTimeout, cfg.OverlayReplayBlockTimeout, otsImpl)
 
@@ -26,6 +26,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/order"
 	"github.com/erigontech/erigon-lib/kv/rawdbv3"
@@ -38,9 +39,11 @@ import (
 	// types2 "github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/stagedsync/stages"
 	tracersConfig "github.com/erigontech/erigon/eth/tracers/config"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
 	// bortypes "github.com/erigontech/erigon/polygon/bor/types"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/jsonrpc/debugger"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
 )
@@ -69,21 +72,51 @@ type PrivateDebugAPI interface {
 	GetRawReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutil.Bytes, error)
 	GetBadBlocks(ctx context.Context) ([]map[string]interface{}, error)
 	GetRawTransaction(ctx context.Context, hash common.Hash) (hexutil.Bytes, error)
+
+	// Step-level debug sessions (see ./debug_session.go)
+	StartSession(ctx context.Context, hash common.Hash) (*debugger.State, error)
+	SessionStep(ctx context.Context, id string) (*debugger.State, error)
+	SessionStepOver(ctx context.Context, id string) (*debugger.State, error)
+	SessionContinue(ctx context.Context, id string) (*debugger.State, error)
+	SessionState(ctx context.Context, id string) (*debugger.State, error)
+	SessionStorage(ctx context.Context, id string, address common.Address, keys []common.Hash) (map[common.Hash]common.Hash, error)
+	SessionSetBreakpoint(ctx context.Context, id string, bp debugger.Breakpoint) (*debugger.Breakpoint, error)
+	SessionRemoveBreakpoint(ctx context.Context, id string, breakpoint int) (bool, error)
+	SessionBreakpoints(ctx context.Context, id string) ([]debugger.Breakpoint, error)
+	StopSession(ctx context.Context, id string) (bool, error)
+
+	// Contract source maps used to annotate traces (see ./debug_sourcemap.go)
+	AddSourceMap(ctx context.Context, contract sourcemap.Contract) (bool, error)
+	RemoveSourceMap(ctx context.Context, address common.Address) (bool, error)
+	GetSourceMaps(ctx context.Context) ([]common.Address, error)
+
+	// Pre-state witnesses of transactions for provers (see ./debug_witness.go)
+	GetTransactionWitness(ctx context.Context, hash common.Hash) (hexutil.Bytes, error)
+	GetBlockWitnesses(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutil.Bytes, error)
+	ExportBlockWitnesses(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) (uint64, error)
 }
 
 // PrivateDebugAPIImpl is implementation of the PrivateDebugAPI interface based on remote Db access
 type PrivateDebugAPIImpl struct {
 	*BaseAPI
-	db     kv.TemporalRoDB
-	GasCap uint64
+	db       kv.TemporalRoDB
+	GasCap   uint64
+	sessions *debugger.Manager
+
+	sourceMaps          *sourcemap.Registry // nil if source maps are disabled
+	witnessDir          string              // directory of exported witnesses, export is disabled if empty
+	accountRangeMaxScan int                 // max accounts scanned by one debug_accountRange call paginated by hash
 }
 
 // NewPrivateDebugAPI returns PrivateDebugAPIImpl instance
 func NewPrivateDebugAPI(base *BaseAPI, db kv.TemporalRoDB, gascap uint64) *PrivateDebugAPIImpl {
 	return &PrivateDebugAPIImpl{
-		BaseAPI: base,
-		db:      db,
-		GasCap:  gascap,
+		BaseAPI:  base,
+		db:       db,
+		GasCap:   gascap,
+		sessions: debugger.NewManager(),
+
+		accountRangeMaxScan: state.MaxScanByHash,
 	}
 }
 
@@ -111,7 +144,11 @@ func (api *PrivateDebugAPIImpl) StorageRangeAt(ctx context.Context, blockHash co
 	return storageRangeAt(tx, contractAddress, keyStart, fromTxNum, maxResult)
 }
 
-// AccountRange implements debug_accountRange. Returns a range of accounts involved in the given block rangeb
+// AccountRange implements debug_accountRange. Returns a range of accounts involved in the given block range.
+// If `startKey` is 32 bytes long - it's treated as address hash and accounts are paginated in order of
+// keccak256(address), same as geth does. Otherwise, `startKey` is an address (or its prefix) and accounts are
+// paginated in plain address order. Pagination by hash scans the whole accounts domain for every page, at most
+// accountRangeMaxScan accounts per call: then `next` is the cursor resuming the scan and the page is empty.
 func (api *PrivateDebugAPIImpl) AccountRange(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, startKey []byte, maxResults int, excludeCode, excludeStorage bool) (state.IteratorDump, error) {
 	tx, err := api.db.BeginTemporalRo(ctx)
 	if err != nil {
@@ -161,7 +198,12 @@ func (api *PrivateDebugAPIImpl) AccountRange(ctx context.Context, blockNrOrHash
 	}
 
 	dumper := state.NewDumper(tx, rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader)), blockNumber)
-	res, err := dumper.IteratorDump(excludeCode, excludeStorage, common.BytesToAddress(startKey), maxResults)
+	var res state.IteratorDump
+	if len(startKey) == length.Hash || state.IsByHashCursor(startKey) {
+		res, err = dumper.IteratorDumpByHash(excludeCode, excludeStorage, startKey, maxResults, api.accountRangeMaxScan)
+	} else {
+		res, err = dumper.IteratorDump(excludeCode, excludeStorage, common.BytesToAddress(startKey), maxResults)
+	}
 	if err != nil {
 		return state.IteratorDump{}, err
 	}
@@ -206,8 +248,8 @@ func (api *PrivateDebugAPIImpl) GetModifiedAccountsByNumber(ctx context.Context,
 	}
 
 	// is endNum too big?
-	if endNum > latestBlock {
-		return nil, fmt.Errorf("end block (%d) is later than the latest block (%d)", endNum, latestBlock)
+	if endNum > latestBlock+1 {
+		return nil, fmt.Errorf("end block (%d) is later than the latest block (%d)", endNum-1, latestBlock)
 	}
 
 	if startNum > endNum {
@@ -218,15 +260,17 @@ func (api *PrivateDebugAPIImpl) GetModifiedAccountsByNumber(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	endTxNum, err := txNumsReader.Min(tx, endNum)
+	// end of the range is right after the last (system) txn of the end block: it is there block rewards and
+	// withdrawals are applied. Also works for the latest block, which has no next block yet.
+	endTxNum, err := txNumsReader.Max(tx, endNum-1)
 	if err != nil {
 		return nil, err
 	}
-	return getModifiedAccounts(tx, startTxNum, endTxNum-1)
+	return getModifiedAccounts(tx, startTxNum, endTxNum+1)
 }
 
-// getModifiedAccounts returns a list of addresses that were modified in the block range
-// [startNum:endNum)
+// getModifiedAccounts returns a list of addresses that were modified in the txNum range
+// [startTxNum:endTxNum). Range includes system txs of blocks: block rewards and withdrawals are applied there.
 func getModifiedAccounts(tx kv.TemporalTx, startTxNum, endTxNum uint64) ([]common.Address, error) {
 	it, err := tx.HistoryRange(kv.AccountsDomain, int(startTxNum), int(endTxNum), order.Asc, kv.Unlim)
 	if err != nil {
@@ -235,17 +279,12 @@ func getModifiedAccounts(tx kv.TemporalTx, startTxNum, endTxNum uint64) ([]commo
 	defer it.Close()
 
 	var result []common.Address
-	saw := make(map[common.Address]struct{})
 	for it.HasNext() {
-		k, _, err := it.Next()
+		k, _, err := it.Next() // keys are sorted and have no duplicates
 		if err != nil {
 			return nil, err
 		}
-		//TODO: data is sorted, enough to compare with prevKey
-		if _, ok := saw[common.BytesToAddress(k)]; !ok {
-			saw[common.BytesToAddress(k)] = struct{}{}
-			result = append(result, common.BytesToAddress(k))
-		}
+		result = append(result, common.BytesToAddress(k))
 	}
 	return result, nil
 }
@@ -290,11 +329,11 @@ func (api *PrivateDebugAPIImpl) GetModifiedAccountsByHash(ctx context.Context, s
 	if err != nil {
 		return nil, err
 	}
-	endTxNum, err := txNumsReader.Min(tx, endNum)
+	endTxNum, err := txNumsReader.Max(tx, endNum-1)
 	if err != nil {
 		return nil, err
 	}
-	return getModifiedAccounts(tx, startTxNum, endTxNum-1)
+	return getModifiedAccounts(tx, startTxNum, endTxNum+1)
 }
 
 func (api *PrivateDebugAPIImpl) AccountAt(ctx context.Context, blockHash common.Hash, txIndex uint64, address common.Address) (*AccountResult, error) {
@@ -19,7 +19,9 @@ package jsonrpc
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"reflect"
 	"testing"
@@ -40,13 +42,17 @@ import (
 	"github.com/erigontech/erigon-lib/kv/stream"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/state"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/tracers"
 	tracersConfig "github.com/erigontech/erigon/eth/tracers/config"
 	"github.com/erigontech/erigon/params"
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/rpc/rpccfg"
 	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/jsonrpc/debugger"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -217,6 +223,129 @@ func TestTraceTransactionNoRefund(t *testing.T) {
 	}
 }
 
+func TestTraceTransactionSchemaVersion(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	for _, tt := range debugTraceTransactionTests {
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		var schemaVersion = true
+		err := api.TraceTransaction(m.Ctx, common.HexToHash(tt.txHash), &tracersConfig.TraceConfig{SchemaVersion: &schemaVersion}, stream)
+		if err != nil {
+			t.Errorf("traceTransaction %s: %v", tt.txHash, err)
+		}
+		if err = stream.Flush(); err != nil {
+			t.Fatalf("error flusing: %v", err)
+		}
+		var res struct {
+			SchemaVersion int                    `json:"schemaVersion"`
+			Result        ethapi.ExecutionResult `json:"result"`
+		}
+		if err = json.Unmarshal(buf.Bytes(), &res); err != nil {
+			t.Fatalf("parsing result: %v, %s", err, buf.String())
+		}
+		if res.SchemaVersion != tracers.SchemaVersion {
+			t.Errorf("wrong schema version for transaction %s, got %d, expected %d", tt.txHash, res.SchemaVersion, tracers.SchemaVersion)
+		}
+		if res.Result.Gas != tt.gas {
+			t.Errorf("wrong gas for transaction %s, got %d, expected %d", tt.txHash, res.Result.Gas, tt.gas)
+		}
+	}
+
+	// the whole block trace is wrapped once, not every transaction of it
+	trace := func(config *tracersConfig.TraceConfig) []byte {
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		if err := api.TraceBlockByNumber(m.Ctx, rpc.BlockNumber(1), config, stream); err != nil {
+			t.Fatalf("traceBlock: %v", err)
+		}
+		if err := stream.Flush(); err != nil {
+			t.Fatalf("error flusing: %v", err)
+		}
+		return buf.Bytes()
+	}
+	schemaVersion := true
+	plain, versioned := trace(&tracersConfig.TraceConfig{}), trace(&tracersConfig.TraceConfig{SchemaVersion: &schemaVersion})
+	if expected := fmt.Sprintf(`{"schemaVersion":%d,"result":%s}`, tracers.SchemaVersion, plain); string(versioned) != expected {
+		t.Errorf("wrong versioned block trace, got %s, expected %s", versioned, expected)
+	}
+}
+
+func TestTraceTransactionMultiTracer(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	trace := func(t *testing.T, txHash common.Hash, config string) ([]byte, error) {
+		var cfg tracersConfig.TraceConfig
+		require.NoError(t, json.Unmarshal([]byte(config), &cfg))
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		err := api.TraceTransaction(m.Ctx, txHash, &cfg, stream)
+		require.NoError(t, stream.Flush())
+		return buf.Bytes(), err
+	}
+	for _, tt := range debugTraceTransactionTests {
+		txHash := common.HexToHash(tt.txHash)
+		out, err := trace(t, txHash, `[{"tracer":"callTracer"},{"tracer":"prestateTracer","tracerConfig":{"diffMode":true},"key":"prestateDiff"},{"tracer":"prestateTracer"}]`)
+		require.NoError(t, err)
+		var res map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(out, &res), string(out))
+		require.Len(t, res, 3)
+
+		// every result is the one of the tracer run alone
+		for key, config := range map[string]string{
+			"callTracer":     `{"tracer":"callTracer"}`,
+			"prestateDiff":   `{"tracer":"prestateTracer","tracerConfig":{"diffMode":true}}`,
+			"prestateTracer": `{"tracer":"prestateTracer"}`,
+		} {
+			single, err := trace(t, txHash, config)
+			require.NoError(t, err)
+			require.JSONEq(t, string(single), string(res[key]), "%s %s", tt.txHash, key)
+		}
+	}
+
+	_, err := trace(t, common.HexToHash(debugTraceTransactionTests[0].txHash), `{"tracers":[{"tracer":"callTracer"},{"tracer":"callTracer"}]}`)
+	require.ErrorContains(t, err, "duplicate result key")
+}
+
+func TestDebugSession(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+	for _, tt := range debugTraceTransactionTests {
+		st, err := api.StartSession(m.Ctx, common.HexToHash(tt.txHash))
+		require.NoError(t, err)
+		if !st.Done {
+			require.Equal(t, uint64(0), st.PC)
+			require.Equal(t, uint64(0), st.Steps)
+
+			st, err = api.SessionStep(m.Ctx, st.Session)
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), st.Steps)
+
+			bp, err := api.SessionSetBreakpoint(m.Ctx, st.Session, debugger.Breakpoint{Opcode: "JUMPDEST"})
+			require.NoError(t, err)
+			for i := 0; !st.Done && i < 1000; i++ {
+				st, err = api.SessionContinue(m.Ctx, st.Session)
+				require.NoError(t, err)
+				if !st.Done {
+					require.Equal(t, "JUMPDEST", st.Op)
+					require.Equal(t, bp.ID, *st.Breakpoint)
+				}
+			}
+		}
+		require.True(t, st.Done)
+		require.Empty(t, st.Result.Error)
+		require.Equal(t, tt.gas, uint64(st.Result.Gas), tt.txHash)
+		require.Equal(t, tt.failed, st.Result.Failed, tt.txHash)
+		require.Equal(t, tt.returnValue, hex.EncodeToString(st.Result.ReturnValue), tt.txHash)
+
+		stopped, err := api.StopSession(m.Ctx, st.Session)
+		require.NoError(t, err)
+		require.True(t, stopped)
+		_, err = api.SessionStep(m.Ctx, st.Session)
+		require.ErrorIs(t, err, debugger.ErrSessionNotFound)
+	}
+}
+
 func TestStorageRangeAt(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
@@ -368,6 +497,55 @@ func TestAccountRange(t *testing.T) {
 			require.Equal(t, v.CodeHash.String(), hashedCode.String())
 		}
 	})
+	paginateByHash := func(t *testing.T, api *PrivateDebugAPIImpl) {
+		n := rpc.BlockNumber(7)
+		all, err := api.AccountRange(m.Ctx, rpc.BlockNumberOrHash{BlockNumber: &n}, nil, AccountRangeMaxResults, true, true)
+		require.NoError(t, err)
+		require.Nil(t, all.Next)
+
+		var prev []byte
+		seen, resumed := 0, 0
+		start := make([]byte, 32)
+		for start != nil {
+			page, err := api.AccountRange(m.Ctx, rpc.BlockNumberOrHash{BlockNumber: &n}, start, 3, true, true)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Accounts), 3)
+			if state.IsByHashCursor(page.Next) {
+				// the scan is not finished, it resumes from the cursor
+				require.Empty(t, page.Accounts)
+				require.Equal(t, start[:32], page.Next[:32])
+				start = page.Next
+				resumed++
+				continue
+			}
+			for addr, acc := range page.Accounts {
+				require.NotNil(t, acc.SecureKey)
+				h, _ := common.HashData(addr[:])
+				require.Equal(t, h[:], []byte(*acc.SecureKey))
+				require.True(t, bytes.Compare(h[:], start[:32]) >= 0)
+				if page.Next != nil {
+					require.True(t, bytes.Compare(h[:], page.Next) < 0)
+				}
+				_, ok := all.Accounts[addr]
+				require.True(t, ok)
+				seen++
+			}
+			require.True(t, prev == nil || page.Next == nil || bytes.Compare(prev, page.Next) < 0)
+			prev, start = page.Next, page.Next
+		}
+		require.Equal(t, len(all.Accounts), seen)
+		if api.accountRangeMaxScan < len(all.Accounts) {
+			require.NotZero(t, resumed)
+		}
+	}
+	t.Run("paginate by address hash", func(t *testing.T) {
+		paginateByHash(t, api)
+	})
+	t.Run("paginate by address hash resuming the scan", func(t *testing.T) {
+		api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+		api.accountRangeMaxScan = 2
+		paginateByHash(t, api)
+	})
 }
 
 func TestGetModifiedAccountsByNumber(t *testing.T) {
@@ -396,9 +574,39 @@ func TestGetModifiedAccountsByNumber(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, 3, len(result))
 	})
-	t.Run("invalid input", func(t *testing.T) {
+	t.Run("latest block as end", func(t *testing.T) {
 		n, n2 := rpc.BlockNumber(0), rpc.BlockNumber(11)
 		_, err := api.GetModifiedAccountsByNumber(m.Ctx, n, &n2)
+		require.NoError(t, err)
+
+		n = rpc.BlockNumber(11)
+		result, err := api.GetModifiedAccountsByNumber(m.Ctx, n, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, result)
+	})
+	t.Run("end block system txn", func(t *testing.T) {
+		tx, err := m.DB.BeginTemporalRo(m.Ctx)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(m.Ctx, m.BlockReader))
+		header, err := m.BlockReader.HeaderByNumber(m.Ctx, tx, 10)
+		require.NoError(t, err)
+		maxTxNum, err := txNumsReader.Max(tx, 10)
+		require.NoError(t, err)
+
+		// block reward is applied in the last (system) txn of the block
+		rewarded, err := getModifiedAccounts(tx, maxTxNum, maxTxNum+1)
+		require.NoError(t, err)
+		require.Contains(t, rewarded, header.Coinbase)
+
+		n := rpc.BlockNumber(10)
+		result, err := api.GetModifiedAccountsByNumber(m.Ctx, n, &n)
+		require.NoError(t, err)
+		require.Contains(t, result, header.Coinbase)
+	})
+	t.Run("invalid input", func(t *testing.T) {
+		n, n2 := rpc.BlockNumber(0), rpc.BlockNumber(12)
+		_, err := api.GetModifiedAccountsByNumber(m.Ctx, n, &n2)
 		require.Error(t, err)
 
 		n, n2 = rpc.BlockNumber(0), rpc.BlockNumber(1_000_000)
@@ -0,0 +1,172 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/turbo/jsonrpc/debugger"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+// maxSessionStorageSlots - max amount of slots read by one debug_sessionStorage call
+const maxSessionStorageSlots = 1024
+
+// StartSession implements debug_startSession. Replays the transaction in a new debug session,
+// paused before its first instruction.
+func (api *PrivateDebugAPIImpl) StartSession(ctx context.Context, hash common.Hash) (*debugger.State, error) {
+	return api.sessions.Start(ctx, func(ctx context.Context, tracer vm.EVMLogger) (*evmtypes.ExecutionResult, error) {
+		tx, err := api.db.BeginTemporalRo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		chainConfig, err := api.chainConfig(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		blockNum, _, ok, err := api.txnLookup(ctx, tx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("transaction %#x not found", hash)
+		}
+		if err = api.BaseAPI.checkPruneHistory(ctx, tx, blockNum); err != nil {
+			return nil, err
+		}
+		block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", blockNum)
+		}
+		txnIndex := -1
+		for i, txn := range block.Transactions() {
+			if txn.Hash() == hash {
+				txnIndex = i
+				break
+			}
+		}
+		if txnIndex < 0 {
+			return nil, fmt.Errorf("transaction %#x not found", hash)
+		}
+
+		engine := api.engine()
+		txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+		ibs, blockCtx, _, rules, signer, err := transactions.ComputeBlockContext(ctx, engine, block.HeaderNoCopy(), chainConfig, api._blockReader, txNumsReader, tx, txnIndex)
+		if err != nil {
+			return nil, err
+		}
+		msg, txCtx, err := transactions.ComputeTxContext(ibs, engine, rules, signer, block, chainConfig, txnIndex)
+		if err != nil {
+			return nil, err
+		}
+		evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
+		gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
+		return core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, engine)
+	})
+}
+
+// SessionStep implements debug_sessionStep. Executes one instruction.
+func (api *PrivateDebugAPIImpl) SessionStep(ctx context.Context, id string) (*debugger.State, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Step(ctx)
+}
+
+// SessionStepOver implements debug_sessionStepOver. Executes one instruction, running calls it makes to their end.
+func (api *PrivateDebugAPIImpl) SessionStepOver(ctx context.Context, id string) (*debugger.State, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.StepOver(ctx)
+}
+
+// SessionContinue implements debug_sessionContinue. Executes until the next breakpoint or the end of the transaction.
+func (api *PrivateDebugAPIImpl) SessionContinue(ctx context.Context, id string) (*debugger.State, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Continue(ctx)
+}
+
+// SessionState implements debug_sessionState. Returns pc, stack, memory and storage at the current instruction.
+func (api *PrivateDebugAPIImpl) SessionState(ctx context.Context, id string) (*debugger.State, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.State(ctx)
+}
+
+// SessionStorage implements debug_sessionStorage. Reads storage slots of any account at the current instruction.
+func (api *PrivateDebugAPIImpl) SessionStorage(ctx context.Context, id string, address common.Address, keys []common.Hash) (map[common.Hash]common.Hash, error) {
+	if len(keys) > maxSessionStorageSlots {
+		return nil, fmt.Errorf("too many storage slots requested: %d, max %d", len(keys), maxSessionStorageSlots)
+	}
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Storage(ctx, address, keys)
+}
+
+// SessionSetBreakpoint implements debug_sessionSetBreakpoint. Pauses execution before the instruction
+// at the given pc or before any instruction with the given opcode, optionally only in the code of one address.
+func (api *PrivateDebugAPIImpl) SessionSetBreakpoint(ctx context.Context, id string, bp debugger.Breakpoint) (*debugger.Breakpoint, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.SetBreakpoint(bp)
+}
+
+// SessionRemoveBreakpoint implements debug_sessionRemoveBreakpoint.
+func (api *PrivateDebugAPIImpl) SessionRemoveBreakpoint(ctx context.Context, id string, breakpoint int) (bool, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return false, err
+	}
+	return s.RemoveBreakpoint(breakpoint), nil
+}
+
+// SessionBreakpoints implements debug_sessionBreakpoints.
+func (api *PrivateDebugAPIImpl) SessionBreakpoints(ctx context.Context, id string) ([]debugger.Breakpoint, error) {
+	s, err := api.sessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Breakpoints(), nil
+}
+
+// StopSession implements debug_stopSession. Aborts the replay, returns false if there was no such session.
+func (api *PrivateDebugAPIImpl) StopSession(ctx context.Context, id string) (bool, error) {
+	return api.sessions.Stop(id), nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erigontech/erigon-lib/common"
+	tracersConfig "github.com/erigontech/erigon/eth/tracers/config"
+	"github.com/erigontech/erigon/eth/tracers/sourcemap"
+)
+
+var errSourceMapsDisabled = errors.New("source maps are disabled, start the node with --rpc.sourcemaps")
+
+// AddSourceMap implements debug_addSourceMap. Uploads the runtime source map and sources of a contract,
+// replacing the previous ones. Returns true on success.
+func (api *PrivateDebugAPIImpl) AddSourceMap(ctx context.Context, contract sourcemap.Contract) (bool, error) {
+	if api.sourceMaps == nil {
+		return false, errSourceMapsDisabled
+	}
+	if err := api.sourceMaps.Add(contract); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveSourceMap implements debug_removeSourceMap. Returns false if the contract had no source map.
+func (api *PrivateDebugAPIImpl) RemoveSourceMap(ctx context.Context, address common.Address) (bool, error) {
+	if api.sourceMaps == nil {
+		return false, errSourceMapsDisabled
+	}
+	return api.sourceMaps.Remove(address)
+}
+
+// GetSourceMaps implements debug_getSourceMaps. Returns the contracts with uploaded source maps.
+func (api *PrivateDebugAPIImpl) GetSourceMaps(ctx context.Context) ([]common.Address, error) {
+	if api.sourceMaps == nil {
+		return nil, errSourceMapsDisabled
+	}
+	return api.sourceMaps.Addresses(), nil
+}
+
+// withSourceMaps makes the tracers of the config annotate their output with source positions, if requested
+func (api *PrivateDebugAPIImpl) withSourceMaps(config *tracersConfig.TraceConfig) error {
+	if config == nil || config.SourceMaps == nil || !*config.SourceMaps {
+		return nil
+	}
+	if api.sourceMaps == nil {
+		return errSourceMapsDisabled
+	}
+	config.SourceLocator = api.sourceMaps
+	return nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/witness"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+// maxWitnessExportBlocks - max amount of blocks exported by one debug_exportBlockWitnesses call
+const maxWitnessExportBlocks = 1000
+
+var errWitnessExportDisabled = errors.New("witness export is disabled, start the node with --rpc.witness.dir")
+
+// GetTransactionWitness implements debug_getTransactionWitness. Replays the transaction and returns the pre-state
+// it read (accounts, storage slots and chunks of code) in the binary witness format, see package witness.
+func (api *PrivateDebugAPIImpl) GetTransactionWitness(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	blockNum, _, ok, err := api.txnLookup(ctx, tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	block, err := api.witnessBlock(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	for i, txn := range block.Transactions() {
+		if txn.Hash() == hash {
+			w, err := api.transactionWitness(ctx, tx, block, i)
+			if err != nil {
+				return nil, err
+			}
+			return w.Encode(), nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %#x not found", hash)
+}
+
+// GetBlockWitnesses implements debug_getBlockWitnesses. Returns witnesses of all transactions of the block, in order.
+func (api *PrivateDebugAPIImpl) GetBlockWitnesses(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutil.Bytes, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	blockNum, _, _, err := rpchelper.GetBlockNumber(ctx, blockNrOrHash, tx, api._blockReader, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.witnessBlock(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]hexutil.Bytes, 0, len(block.Transactions()))
+	for i := range block.Transactions() {
+		w, err := api.transactionWitness(ctx, tx, block, i)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, w.Encode())
+	}
+	return res, nil
+}
+
+// ExportBlockWitnesses implements debug_exportBlockWitnesses. Writes witnesses of all transactions of the blocks
+// to <witness dir>/<block number>/<transaction index>.wit, returns the amount of written witnesses.
+func (api *PrivateDebugAPIImpl) ExportBlockWitnesses(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) (uint64, error) {
+	if api.witnessDir == "" {
+		return 0, errWitnessExportDisabled
+	}
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	from, _, _, err := rpchelper.GetBlockNumber(ctx, rpc.BlockNumberOrHashWithNumber(fromBlock), tx, api._blockReader, api.filters)
+	if err != nil {
+		return 0, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(ctx, rpc.BlockNumberOrHashWithNumber(toBlock), tx, api._blockReader, api.filters)
+	if err != nil {
+		return 0, err
+	}
+	if from > to {
+		return 0, fmt.Errorf("fromBlock %d is after toBlock %d", from, to)
+	}
+	if to-from >= maxWitnessExportBlocks {
+		return 0, fmt.Errorf("too many blocks requested: %d, max %d", to-from+1, maxWitnessExportBlocks)
+	}
+
+	var written uint64
+	for blockNum := from; blockNum <= to; blockNum++ {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		block, err := api.witnessBlock(ctx, tx, blockNum)
+		if err != nil {
+			return written, err
+		}
+		dir := filepath.Join(api.witnessDir, strconv.FormatUint(blockNum, 10))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return written, err
+		}
+		for i := range block.Transactions() {
+			w, err := api.transactionWitness(ctx, tx, block, i)
+			if err != nil {
+				return written, err
+			}
+			path := filepath.Join(dir, strconv.Itoa(i)+".wit")
+			if err := os.WriteFile(path+".tmp", w.Encode(), 0644); err != nil {
+				return written, err
+			}
+			if err := os.Rename(path+".tmp", path); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+func (api *PrivateDebugAPIImpl) witnessBlock(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (*types.Block, error) {
+	if err := api.BaseAPI.checkPruneHistory(ctx, tx, blockNum); err != nil {
+		return nil, err
+	}
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+	return block, nil
+}
+
+// transactionWitness replays the transaction on the state before it, recording the reads
+func (api *PrivateDebugAPIImpl) transactionWitness(ctx context.Context, tx kv.TemporalTx, block *types.Block, txnIndex int) (*witness.Witness, error) {
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	engine := api.engine()
+	txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+	_, blockCtx, reader, rules, signer, err := transactions.ComputeBlockContext(ctx, engine, block.HeaderNoCopy(), chainConfig, api._blockReader, txNumsReader, tx, txnIndex)
+	if err != nil {
+		return nil, err
+	}
+	recorder := witness.NewRecorder(reader)
+	ibs := state.New(recorder)
+	msg, txCtx, err := transactions.ComputeTxContext(ibs, engine, rules, signer, block, chainConfig, txnIndex)
+	if err != nil {
+		return nil, err
+	}
+	evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: recorder.Tracer()})
+	gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
+	if _, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, engine); err != nil {
+		return nil, fmt.Errorf("transaction %d of block %d: %w", txnIndex, block.NumberU64(), err)
+	}
+	return recorder.Witness(), nil
+}
@@ -0,0 +1,591 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger replays transactions step by step on behalf of remote debugger frontends.
+// Every session runs its replay in a dedicated goroutine, paused inside the tracer between
+// instructions until the next command arrives. The replay holds a database transaction, so
+// a replay paused for longer than releaseTimeout is stopped, and the next command re-executes
+// the transaction up to the same instruction.
+package debugger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+)
+
+const (
+	maxSessions    = 16               // max amount of concurrently open sessions
+	maxBreakpoints = 256              // max amount of breakpoints in one session
+	idleTimeout    = 5 * time.Minute  // session is stopped if no command arrives for this long
+	releaseTimeout = 2 * time.Second  // paused replay is stopped to release its database transaction if no command arrives for this long
+	maxMemorySize  = 1 << 20          // memory reported in the state is truncated to this size
+	storageTimeout = 30 * time.Second // max time to wait for storage reads of a paused session
+)
+
+var (
+	ErrTooManySessions = errors.New("too many debug sessions")
+	ErrSessionNotFound = errors.New("debug session not found")
+	errSessionDone     = errors.New("debug session is done")
+)
+
+// resume modes of a paused session
+type mode int
+
+const (
+	modeStep     mode = iota // pause before the next instruction
+	modeStepOver             // pause before the next instruction of the same or an outer call frame
+	modeContinue             // pause at the next breakpoint only
+)
+
+// RunFunc replays the transaction with the given tracer. It's called from the session goroutine,
+// so it must open its own database transaction, and stop when ctx is cancelled. It's called again
+// for every replay restarted after release, and must execute the same instructions.
+type RunFunc func(ctx context.Context, tracer vm.EVMLogger) (*evmtypes.ExecutionResult, error)
+
+// Breakpoint pauses execution before the instruction at PC or before any instruction with Opcode
+type Breakpoint struct {
+	ID      int             `json:"id"`
+	PC      *hexutil.Uint64 `json:"pc,omitempty"`
+	Opcode  string          `json:"opcode,omitempty"`
+	Address *common.Address `json:"address,omitempty"` // limits the breakpoint to the code executed at the address, optional
+}
+
+func (b *Breakpoint) validate() error {
+	if (b.PC == nil) == (b.Opcode == "") {
+		return errors.New("breakpoint needs either pc or opcode")
+	}
+	if b.Opcode != "" && vm.StringToOp(b.Opcode).String() != b.Opcode {
+		return fmt.Errorf("unknown opcode %q", b.Opcode)
+	}
+	return nil
+}
+
+func (b *Breakpoint) matches(pc uint64, op vm.OpCode, addr common.Address) bool {
+	if b.Address != nil && *b.Address != addr {
+		return false
+	}
+	if b.PC != nil {
+		return uint64(*b.PC) == pc
+	}
+	return op.String() == b.Opcode
+}
+
+// Result of the finished replay
+type Result struct {
+	Gas         hexutil.Uint64 `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue hexutil.Bytes  `json:"returnValue"`
+	Error       string         `json:"error,omitempty"` // replay error, the EVM error is reported by `failed`
+}
+
+// State of the session: the instruction it's paused before, or the result once it's done
+type State struct {
+	Session    string                      `json:"session"`
+	Done       bool                        `json:"done"`
+	Steps      uint64                      `json:"steps"` // instructions executed so far
+	PC         uint64                      `json:"pc"`
+	Op         string                      `json:"op"`
+	Gas        uint64                      `json:"gas"`
+	GasCost    uint64                      `json:"gasCost"`
+	Depth      int                         `json:"depth"`
+	Address    common.Address              `json:"address"` // address of the executed code's storage
+	Stack      []string                    `json:"stack"`   // top of the stack is the last item
+	Memory     hexutil.Bytes               `json:"memory"`
+	Storage    map[common.Hash]common.Hash `json:"storage"`              // current values of the slots of `address` loaded or stored so far
+	Breakpoint *int                        `json:"breakpoint,omitempty"` // ID of the breakpoint which paused execution
+	Result     *Result                     `json:"result,omitempty"`     // set once done
+}
+
+type storageRequest struct {
+	addr  common.Address
+	keys  []common.Hash
+	reply chan map[common.Hash]common.Hash
+}
+
+// command is sent to the paused replay: either resume mode or storage request
+type command struct {
+	mode    mode
+	storage *storageRequest
+}
+
+// Session is one paused transaction replay
+type Session struct {
+	id           string
+	run          RunFunc
+	releaseAfter time.Duration
+	mu           sync.Mutex // serializes commands
+	cmds         chan command
+	paused       chan *State // paused or done states sent by the replay
+	quit         chan struct{}
+	timer        *time.Timer
+
+	last    *State
+	running bool          // command was sent, but its state has not been received yet
+	exited  chan struct{} // closed once the current replay goroutine exits
+
+	bpMu        sync.RWMutex
+	breakpoints []*Breakpoint
+	nextBP      int
+
+	// replay side, accessed by the session goroutine only, and by the command side once it exited
+	env      *vm.EVM
+	mode     mode
+	depth    int
+	steps    uint64
+	slots    map[common.Address]map[common.Hash]struct{} // storage slots loaded or stored so far
+	released bool                                        // replay is stopped to release the database transaction
+	skipping bool                                        // restarted replay executes instructions without pausing up to `skipTo`
+	skipTo   uint64
+}
+
+// Manager keeps the open sessions
+type Manager struct {
+	mu           sync.Mutex
+	sessions     map[string]*Session
+	releaseAfter time.Duration
+}
+
+func NewManager() *Manager {
+	return &Manager{sessions: map[string]*Session{}, releaseAfter: releaseTimeout}
+}
+
+// Start replays the transaction in a new session and returns its state paused before the first instruction,
+// or done if the transaction executes no code.
+func (m *Manager) Start(ctx context.Context, run RunFunc) (*State, error) {
+	s := &Session{
+		id:           newSessionID(),
+		run:          run,
+		releaseAfter: m.releaseAfter,
+		cmds:         make(chan command),
+		paused:       make(chan *State, 1),
+		quit:         make(chan struct{}),
+		mode:         modeStep,
+	}
+	m.mu.Lock()
+	if len(m.sessions) >= maxSessions {
+		m.mu.Unlock()
+		return nil, ErrTooManySessions
+	}
+	m.sessions[s.id] = s
+	s.timer = time.AfterFunc(idleTimeout, func() { m.Stop(s.id) })
+	m.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startReplay(false)
+	s.running = true
+	st, err := s.await(ctx)
+	if err != nil {
+		m.Stop(s.id)
+		return nil, err
+	}
+	if st.Result != nil && st.Result.Error != "" {
+		m.Stop(s.id)
+		return nil, errors.New(st.Result.Error)
+	}
+	return st, nil
+}
+
+// Get returns the open session, and postpones its idle timeout
+func (m *Manager) Get(id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	s.timer.Reset(idleTimeout)
+	return s, nil
+}
+
+// Stop aborts the replay and forgets the session, returns false if there was no such session
+func (m *Manager) Stop(id string) bool {
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	s.timer.Stop()
+	close(s.quit)
+	return true
+}
+
+// startReplay runs the transaction in a new goroutine, either from the start, or restarted after release up to the
+// instruction the released replay was paused at. Must be called with s.mu held, and no replay running.
+func (s *Session) startReplay(restart bool) {
+	exited := make(chan struct{})
+	s.exited = exited
+	s.steps, s.slots, s.released = 0, map[common.Address]map[common.Hash]struct{}{}, false
+	s.skipping = restart
+	if restart {
+		s.skipTo = s.last.Steps
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		select {
+		case <-s.quit:
+		case <-exited:
+		}
+	}()
+	go func() {
+		defer close(exited)
+		res, err := s.run(runCtx, &tracer{s: s})
+		if s.released {
+			return
+		}
+		st := &State{Session: s.id, Done: true, Steps: s.steps, Result: &Result{}}
+		if err != nil {
+			st.Result.Error = err.Error()
+		} else {
+			st.Result.Gas = hexutil.Uint64(res.UsedGas)
+			st.Result.Failed = res.Failed()
+			st.Result.ReturnValue = res.Return()
+			if len(res.Revert()) > 0 {
+				st.Result.ReturnValue = res.Revert()
+			}
+		}
+		select {
+		case s.paused <- st:
+		case <-s.quit:
+		}
+	}()
+}
+
+// send delivers the command to the paused replay, restarting it if it was released. Returns false if the replay
+// finished instead, then its state is to be awaited. Must be called with s.mu held.
+func (s *Session) send(cmd command) (bool, error) {
+	for {
+		select {
+		case s.cmds <- cmd:
+			return true, nil
+		case <-s.exited:
+			if !s.released {
+				return false, nil
+			}
+			s.startReplay(true)
+		case <-s.quit:
+			return false, ErrSessionNotFound
+		}
+	}
+}
+
+// Step resumes execution until the next instruction
+func (s *Session) Step(ctx context.Context) (*State, error) {
+	return s.resume(ctx, modeStep)
+}
+
+// StepOver resumes execution until the next instruction of the current or an outer call frame,
+// so that calls made by the current instruction run to their end
+func (s *Session) StepOver(ctx context.Context) (*State, error) {
+	return s.resume(ctx, modeStepOver)
+}
+
+// Continue resumes execution until the next breakpoint or the end of the transaction
+func (s *Session) Continue(ctx context.Context) (*State, error) {
+	return s.resume(ctx, modeContinue)
+}
+
+// State returns the current state without resuming execution
+func (s *Session) State(ctx context.Context) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return s.await(ctx)
+	}
+	return s.last, nil
+}
+
+// Storage reads storage slots of any account at the current instruction
+func (s *Session) Storage(ctx context.Context, addr common.Address, keys []common.Hash) (map[common.Hash]common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		if _, err := s.await(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if s.last.Done {
+		return nil, errSessionDone
+	}
+	req := &storageRequest{addr: addr, keys: keys, reply: make(chan map[common.Hash]common.Hash, 1)}
+	sent, err := s.send(command{storage: req})
+	if err != nil {
+		return nil, err
+	}
+	if !sent {
+		s.running = true
+		if _, err := s.await(ctx); err != nil {
+			return nil, err
+		}
+		return nil, errSessionDone
+	}
+	select {
+	case res := <-req.reply:
+		return res, nil
+	case <-s.quit:
+		return nil, ErrSessionNotFound
+	case <-time.After(storageTimeout):
+		return nil, errors.New("storage read timed out")
+	}
+}
+
+// SetBreakpoint adds the breakpoint, its ID is assigned by the session
+func (s *Session) SetBreakpoint(bp Breakpoint) (*Breakpoint, error) {
+	if err := bp.validate(); err != nil {
+		return nil, err
+	}
+	s.bpMu.Lock()
+	defer s.bpMu.Unlock()
+	if len(s.breakpoints) >= maxBreakpoints {
+		return nil, fmt.Errorf("too many breakpoints, max %d", maxBreakpoints)
+	}
+	s.nextBP++
+	bp.ID = s.nextBP
+	s.breakpoints = append(s.breakpoints, &bp)
+	return &bp, nil
+}
+
+// RemoveBreakpoint returns false if there was no such breakpoint
+func (s *Session) RemoveBreakpoint(id int) bool {
+	s.bpMu.Lock()
+	defer s.bpMu.Unlock()
+	for i, bp := range s.breakpoints {
+		if bp.ID == id {
+			s.breakpoints = append(s.breakpoints[:i], s.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Breakpoints returns the breakpoints of the session
+func (s *Session) Breakpoints() []Breakpoint {
+	s.bpMu.RLock()
+	defer s.bpMu.RUnlock()
+	res := make([]Breakpoint, len(s.breakpoints))
+	for i, bp := range s.breakpoints {
+		res[i] = *bp
+	}
+	return res
+}
+
+func (s *Session) resume(ctx context.Context, mode mode) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		// the previous command timed out, report where it stopped instead of moving on
+		return s.await(ctx)
+	}
+	if s.last.Done {
+		return s.last, nil
+	}
+	if _, err := s.send(command{mode: mode}); err != nil {
+		return nil, err
+	}
+	s.running = true
+	return s.await(ctx)
+}
+
+// await waits for the replay to pause or finish, must be called with s.mu held
+func (s *Session) await(ctx context.Context) (*State, error) {
+	select {
+	case st := <-s.paused:
+		s.running = false
+		s.last = st
+		return st, nil
+	case <-s.quit:
+		return nil, ErrSessionNotFound
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Session) breakpointAt(pc uint64, op vm.OpCode, addr common.Address) *int {
+	s.bpMu.RLock()
+	defer s.bpMu.RUnlock()
+	for _, bp := range s.breakpoints {
+		if bp.matches(pc, op, addr) {
+			id := bp.ID
+			return &id
+		}
+	}
+	return nil
+}
+
+func newSessionID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// tracer pauses the replay inside CaptureState until the session receives a resume command
+type tracer struct {
+	s *Session
+}
+
+func (t *tracer) CaptureTxStart(gasLimit uint64) {}
+func (t *tracer) CaptureTxEnd(restGas uint64)    {}
+func (t *tracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.s.env = env
+}
+func (t *tracer) CaptureEnd(output []byte, usedGas uint64, err error) {}
+func (t *tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+}
+func (t *tracer) CaptureExit(output []byte, usedGas uint64, err error) {}
+func (t *tracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *tracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	s := t.s
+	if s.released {
+		return // until the cancelled interpreter stops
+	}
+	select {
+	case <-s.quit:
+		s.env.Cancel()
+		return
+	default:
+	}
+	addr := scope.Contract.Address()
+
+	if s.skipping {
+		if s.steps < s.skipTo {
+			t.trackSlot(op, scope, addr)
+			s.steps++
+			return
+		}
+		// back at the instruction the released replay was paused at, its state is already reported
+		s.skipping = false
+		t.wait(depth)
+		t.trackSlot(op, scope, addr)
+		s.steps++
+		return
+	}
+
+	var pause bool
+	switch s.mode {
+	case modeStep:
+		pause = true
+	case modeStepOver:
+		pause = depth <= s.depth
+	}
+	bp := s.breakpointAt(pc, op, addr)
+	if pause || bp != nil {
+		st := &State{
+			Session:    s.id,
+			Steps:      s.steps,
+			PC:         pc,
+			Op:         op.String(),
+			Gas:        gas,
+			GasCost:    cost,
+			Depth:      depth,
+			Address:    addr,
+			Stack:      make([]string, len(scope.Stack.Data)),
+			Breakpoint: bp,
+		}
+		for i := range scope.Stack.Data {
+			st.Stack[i] = scope.Stack.Data[i].Hex()
+		}
+		mem := scope.Memory.Data()
+		if len(mem) > maxMemorySize {
+			mem = mem[:maxMemorySize]
+		}
+		st.Memory = common.CopyBytes(mem)
+		slots := make([]common.Hash, 0, len(s.slots[addr]))
+		for slot := range s.slots[addr] {
+			slots = append(slots, slot)
+		}
+		st.Storage = t.readStorage(addr, slots)
+		t.pause(st, depth)
+	}
+	t.trackSlot(op, scope, addr)
+	s.steps++
+}
+
+// pause publishes the state and waits for the next command
+func (t *tracer) pause(st *State, depth int) {
+	s := t.s
+	select {
+	case s.paused <- st:
+	case <-s.quit:
+		s.env.Cancel()
+		return
+	}
+	t.wait(depth)
+}
+
+// wait serves storage reads until a resume command arrives, or stops the replay if no command arrives for releaseAfter
+func (t *tracer) wait(depth int) {
+	s := t.s
+	for {
+		select {
+		case cmd := <-s.cmds:
+			if cmd.storage != nil {
+				cmd.storage.reply <- t.readStorage(cmd.storage.addr, cmd.storage.keys)
+				continue
+			}
+			s.mode, s.depth = cmd.mode, depth
+			return
+		case <-time.After(s.releaseAfter):
+			s.released = true
+			s.env.Cancel()
+			return
+		case <-s.quit:
+			s.env.Cancel()
+			return
+		}
+	}
+}
+
+// trackSlot records the storage slot of the executed code's account loaded or stored by the instruction
+func (t *tracer) trackSlot(op vm.OpCode, scope *vm.ScopeContext, addr common.Address) {
+	if (op != vm.SLOAD && op != vm.SSTORE) || scope.Stack.Len() < 1 {
+		return
+	}
+	if t.s.slots[addr] == nil {
+		t.s.slots[addr] = map[common.Hash]struct{}{}
+	}
+	t.s.slots[addr][scope.Stack.Back(0).Bytes32()] = struct{}{}
+}
+
+func (t *tracer) readStorage(addr common.Address, keys []common.Hash) map[common.Hash]common.Hash {
+	res := make(map[common.Hash]common.Hash, len(keys))
+	for i := range keys {
+		var value uint256.Int
+		if err := t.s.env.IntraBlockState().GetState(addr, &keys[i], &value); err == nil {
+			res[keys[i]] = value.Bytes32()
+		}
+	}
+	return res
+}
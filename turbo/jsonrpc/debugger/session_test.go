@@ -0,0 +1,147 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/core/vm/evmtypes"
+	"github.com/erigontech/erigon/params"
+)
+
+// loopCode counts from 1 to 3: PUSH1 0; JUMPDEST; PUSH1 1; ADD; DUP1; PUSH1 3; GT; PUSH1 2; JUMPI; STOP
+var loopCode = common.FromHex("0x60005b6001018060031160025700")
+
+const (
+	loopSteps = 1 + 3*8 + 1 // PUSH1, 3 iterations of the loop, STOP
+	loopGas   = 3 + 3*29
+)
+
+// testState serves storage reads only, the loop code touches no other state
+type testState struct {
+	evmtypes.IntraBlockState
+}
+
+func (testState) GetState(addr common.Address, slot *common.Hash, value *uint256.Int) error {
+	value.SetBytes(slot[:])
+	return nil
+}
+
+// testRun executes the loop code, counting the replays and the replays in progress, as open database transactions
+func testRun(runs, open *atomic.Int32) RunFunc {
+	return func(ctx context.Context, tracer vm.EVMLogger) (*evmtypes.ExecutionResult, error) {
+		runs.Add(1)
+		open.Add(1)
+		defer open.Add(-1)
+
+		const gas = 1_000_000
+		addr := common.Address{1}
+		evm := vm.NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, testState{}, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+		contract := vm.NewContract(vm.AccountRef(addr), addr, new(uint256.Int), gas, false, evm.JumpDestCache)
+		contract.Code = loopCode
+		tracer.CaptureStart(evm, addr, addr, false, false, nil, gas, new(uint256.Int), loopCode)
+		ret, err := evm.Interpreter().Run(contract, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		return &evmtypes.ExecutionResult{UsedGas: gas - contract.Gas, ReturnData: ret}, nil
+	}
+}
+
+func TestSessionReleasesTx(t *testing.T) {
+	ctx := context.Background()
+	var runs, open atomic.Int32
+	m := NewManager()
+	m.releaseAfter = 10 * time.Millisecond
+
+	st, err := m.Start(ctx, testRun(&runs, &open))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), st.PC)
+	s, err := m.Get(st.Session)
+	require.NoError(t, err)
+
+	// paused replay releases its transaction, every command re-executes up to the paused instruction
+	var steps []uint64
+	for i := 0; i < 3; i++ {
+		require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, time.Millisecond)
+		st, err = s.Step(ctx)
+		require.NoError(t, err)
+		steps = append(steps, st.PC)
+	}
+	require.Equal(t, []uint64{2, 3, 5}, steps) // JUMPDEST; PUSH1 1; ADD
+	require.Equal(t, uint64(3), st.Steps)
+	require.Equal(t, []string{"0x0", "0x1"}, st.Stack)
+	require.Equal(t, int32(4), runs.Load())
+
+	require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, time.Millisecond)
+	slot := common.Hash{2}
+	storage, err := s.Storage(ctx, common.Address{1}, []common.Hash{slot})
+	require.NoError(t, err)
+	require.Equal(t, map[common.Hash]common.Hash{slot: slot}, storage)
+	require.Equal(t, int32(5), runs.Load())
+
+	bp, err := s.SetBreakpoint(Breakpoint{Opcode: "JUMPDEST"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, time.Millisecond)
+	st, err = s.Continue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, bp.ID, *st.Breakpoint)
+	require.Equal(t, uint64(1+8), st.Steps) // second iteration
+	require.Equal(t, []string{"0x1"}, st.Stack)
+
+	require.True(t, s.RemoveBreakpoint(bp.ID))
+	require.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, time.Millisecond)
+	st, err = s.Continue(ctx)
+	require.NoError(t, err)
+	require.True(t, st.Done)
+	require.Equal(t, uint64(loopSteps), st.Steps)
+	require.Equal(t, hexutil.Uint64(loopGas), st.Result.Gas)
+	require.Empty(t, st.Result.Error)
+	require.Zero(t, open.Load())
+
+	require.True(t, m.Stop(st.Session))
+	_, err = m.Get(st.Session)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSessionKeepsTxWhileStepping(t *testing.T) {
+	ctx := context.Background()
+	var runs, open atomic.Int32
+	m := NewManager()
+
+	st, err := m.Start(ctx, testRun(&runs, &open))
+	require.NoError(t, err)
+	s, err := m.Get(st.Session)
+	require.NoError(t, err)
+	for !st.Done {
+		require.Equal(t, int32(1), open.Load())
+		st, err = s.Step(ctx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(loopSteps), st.Steps)
+	require.Equal(t, int32(1), runs.Load())
+	require.True(t, m.Stop(st.Session))
+}
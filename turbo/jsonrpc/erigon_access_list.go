@@ -0,0 +1,140 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+const (
+	defaultPredictAccessListBlocks = 8
+	maxPredictAccessListBlocks     = 64
+)
+
+// AccessListPrediction is the result of erigon_predictAccessList
+type AccessListPrediction struct {
+	AccessList  types.AccessList       `json:"accessList"` // union of the access lists of all simulations
+	Stability   []AddressStability     `json:"stability"`  // in the order of AccessList
+	Simulations []AccessListSimulation `json:"simulations"`
+}
+
+// AddressStability - share of the successful simulations which accessed the address and each of its slots
+type AddressStability struct {
+	Address     common.Address  `json:"address"`
+	Score       float64         `json:"score"`
+	StorageKeys []SlotStability `json:"storageKeys"`
+}
+
+type SlotStability struct {
+	Key   common.Hash `json:"key"`
+	Score float64     `json:"score"`
+}
+
+// AccessListSimulation is the call simulated on the state after the block
+type AccessListSimulation struct {
+	Block   hexutil.Uint64 `json:"block"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Error   string         `json:"error,omitempty"` // the call reverted or the simulation failed (e.g. pruned state)
+	Failed  bool           `json:"failed"`          // the simulation failed and is not counted in the scores
+}
+
+// PredictAccessList implements erigon_predictAccessList. Creates the access list of the call (as eth_createAccessList)
+// on the states after each of the last `blocks` blocks (8 by default, up to 64) and returns their union with the share
+// of the simulations accessing every address and slot: slots with low scores depend on the volatile state.
+func (api *ErigonImpl) PredictAccessList(ctx context.Context, args ethapi.CallArgs, blocks *hexutil.Uint64) (*AccessListPrediction, error) {
+	if api.eth == nil {
+		return nil, errors.New("eth API is not available")
+	}
+	n := uint64(defaultPredictAccessListBlocks)
+	if blocks != nil {
+		n = uint64(*blocks)
+	}
+	if n == 0 || n > maxPredictAccessListBlocks {
+		return nil, fmt.Errorf("blocks must be in [1, %d]", maxPredictAccessListBlocks)
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := rpchelper.GetLatestBlockNumber(tx)
+	tx.Rollback()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccessListPrediction{AccessList: types.AccessList{}, Simulations: []AccessListSimulation{}}
+	addrIndex := map[common.Address]int{}
+	addrCount := map[common.Address]int{}
+	slotCount := map[common.Address]map[common.Hash]int{}
+	succeeded := 0
+	for i := uint64(0); i < n && i <= latest; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		blockNum := latest - i
+		sim := AccessListSimulation{Block: hexutil.Uint64(blockNum)}
+		blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNum))
+		result, err := api.eth.CreateAccessList(ctx, args, &blockNrOrHash, nil)
+		if err == nil && result == nil {
+			err = fmt.Errorf("block %d not found", blockNum)
+		}
+		if err != nil {
+			sim.Error, sim.Failed = err.Error(), true
+			res.Simulations = append(res.Simulations, sim)
+			continue
+		}
+		sim.GasUsed, sim.Error = result.GasUsed, result.Error
+		res.Simulations = append(res.Simulations, sim)
+		succeeded++
+
+		for _, tuple := range *result.Accesslist {
+			idx, ok := addrIndex[tuple.Address]
+			if !ok {
+				idx = len(res.AccessList)
+				addrIndex[tuple.Address] = idx
+				res.AccessList = append(res.AccessList, types.AccessTuple{Address: tuple.Address, StorageKeys: []common.Hash{}})
+				slotCount[tuple.Address] = map[common.Hash]int{}
+			}
+			addrCount[tuple.Address]++
+			for _, key := range tuple.StorageKeys {
+				if slotCount[tuple.Address][key] == 0 {
+					res.AccessList[idx].StorageKeys = append(res.AccessList[idx].StorageKeys, key)
+				}
+				slotCount[tuple.Address][key]++
+			}
+		}
+	}
+
+	res.Stability = make([]AddressStability, 0, len(res.AccessList))
+	for _, tuple := range res.AccessList {
+		s := AddressStability{Address: tuple.Address, Score: float64(addrCount[tuple.Address]) / float64(succeeded), StorageKeys: make([]SlotStability, 0, len(tuple.StorageKeys))}
+		for _, key := range tuple.StorageKeys {
+			s.StorageKeys = append(s.StorageKeys, SlotStability{Key: key, Score: float64(slotCount[tuple.Address][key]) / float64(succeeded)})
+		}
+		res.Stability = append(res.Stability, s)
+	}
+	return res, nil
+}
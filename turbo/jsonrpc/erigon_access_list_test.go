@@ -0,0 +1,68 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+)
+
+func TestErigonPredictAccessList(t *testing.T) {
+	m, bankAddr, contractAddr := chainWithDeployedContract(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	api.eth = NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+	ctx := context.Background()
+
+	data := hexutil.Bytes(contractInvocationData(5))
+	args := ethapi.CallArgs{From: &bankAddr, To: &contractAddr, Data: &data}
+	blocks := hexutil.Uint64(3)
+	res, err := api.PredictAccessList(ctx, args, &blocks)
+	require.NoError(t, err)
+	require.Len(t, res.Simulations, 3)
+	require.Equal(t, hexutil.Uint64(3), res.Simulations[0].Block)
+	require.Equal(t, hexutil.Uint64(1), res.Simulations[2].Block)
+	for _, sim := range res.Simulations {
+		require.False(t, sim.Failed, sim.Error)
+	}
+
+	// the contract is deployed in block 1, so every simulation stores all its slots
+	require.Len(t, res.Stability, len(res.AccessList))
+	var found bool
+	for i, s := range res.Stability {
+		require.Equal(t, res.AccessList[i].Address, s.Address)
+		if s.Address != contractAddr {
+			continue
+		}
+		found = true
+		require.Equal(t, 1.0, s.Score)
+		require.NotEmpty(t, s.StorageKeys)
+		for _, slot := range s.StorageKeys {
+			require.Equal(t, 1.0, slot.Score)
+		}
+	}
+	require.True(t, found)
+
+	blocks = maxPredictAccessListBlocks + 1
+	_, err = api.PredictAccessList(ctx, args, &blocks)
+	require.Error(t, err)
+}
@@ -0,0 +1,688 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb/rawtemporaldb"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/jsonrpc/analytics"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+// analyticsMaxCatchUpBlocks - how many missed blocks indexer will process on new head before skipping forward
+const analyticsMaxCatchUpBlocks = 128
+
+// analyticsMaxLimit - max amount of entries returned by analytics methods
+const analyticsMaxLimit = 1000
+
+var errAnalyticsDisabled = errors.New("analytics index is disabled, start rpcdaemon with --rpc.analytics")
+
+// analyticsIndex is an in-memory analytics index fed block by block by headFollower
+type analyticsIndex interface {
+	// LastBlock returns the last indexed block
+	LastBlock() (uint64, bool)
+	// BlockHash returns hash of the indexed block with given number, if it is still within reorg depth
+	BlockHash(blockNum uint64) (common.Hash, bool)
+}
+
+// headFollower describes how an analytics index is fed with canonical blocks by followHeads
+type headFollower struct {
+	name  string
+	index analyticsIndex
+	// contiguous - index must cover continuous range of blocks: if it fell behind by more than
+	// analyticsMaxCatchUpBlocks, missed blocks are indexed over several heads instead of being skipped
+	contiguous bool
+	// indexBlock adds canonical block to the index, returns false if block is not available yet
+	indexBlock func(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (bool, error)
+	// afterHead is called after blocks of every head are indexed, optional
+	afterHead func(ctx context.Context) error
+}
+
+// followHeads feeds the index with every new canonical head until ctx is cancelled
+func (api *ErigonImpl) followHeads(ctx context.Context, f *headFollower, logger log.Logger) {
+	heads, id := api.filters.SubscribeNewHeads(32)
+	defer api.filters.UnsubscribeHeads(id)
+
+	for {
+		var head *types.Header
+		select {
+		case <-ctx.Done():
+			return
+		case h, ok := <-heads:
+			if !ok {
+				return
+			}
+			head = h
+		}
+		if err := api.indexHead(ctx, f, head.Number.Uint64()); err != nil {
+			logger.Warn("[rpc] analytics: failed to index block", "index", f.name, "block", head.Number.Uint64(), "err", err)
+			continue
+		}
+		if f.afterHead != nil {
+			if err := f.afterHead(ctx); err != nil {
+				logger.Warn("[rpc] analytics: failed to process head", "index", f.name, "block", head.Number.Uint64(), "err", err)
+			}
+		}
+	}
+}
+
+// indexHead indexes blocks from the first not indexed (or orphaned by reorg) block up to the head,
+// at most analyticsMaxCatchUpBlocks+1 blocks
+func (api *ErigonImpl) indexHead(ctx context.Context, f *headFollower, headNum uint64) error {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	from, err := api.firstBlockToIndex(ctx, tx, f.index, headNum)
+	if err != nil {
+		return err
+	}
+	from, to := catchUpRange(from, headNum, f.contiguous)
+	for blockNum := from; blockNum <= to; blockNum++ {
+		ok, err := f.indexBlock(ctx, tx, blockNum)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+	}
+	return nil
+}
+
+// firstBlockToIndex walks back to the latest indexed block which is still canonical and returns the block after it:
+// everything above was orphaned by reorg and gets re-indexed. For empty index it's the head.
+func (api *ErigonImpl) firstBlockToIndex(ctx context.Context, tx kv.Tx, idx analyticsIndex, headNum uint64) (uint64, error) {
+	last, ok := idx.LastBlock()
+	if !ok {
+		return headNum, nil
+	}
+	from := last + 1
+	for n := min(last, headNum); ; n-- {
+		indexed, ok := idx.BlockHash(n)
+		if !ok {
+			break
+		}
+		canonical, ok, err := api._blockReader.CanonicalHash(ctx, tx, n)
+		if err != nil {
+			return 0, err
+		}
+		if ok && canonical == indexed {
+			return n + 1, nil
+		}
+		from = n
+		if n == 0 {
+			break
+		}
+	}
+	return from, nil
+}
+
+// catchUpRange bounds blocks indexed on a single head: if index fell behind by more than analyticsMaxCatchUpBlocks,
+// contiguous index continues from where it stopped, others skip forward to the head
+func catchUpRange(from, headNum uint64, contiguous bool) (uint64, uint64) {
+	if from > headNum || headNum-from <= analyticsMaxCatchUpBlocks {
+		return from, headNum
+	}
+	if contiguous {
+		return from, from + analyticsMaxCatchUpBlocks
+	}
+	return headNum - analyticsMaxCatchUpBlocks, headNum
+}
+
+// TopContractsResult is the result of erigon_topContracts
+type TopContractsResult struct {
+	Metric        string              `json:"metric"`
+	FromTimestamp hexutil.Uint64      `json:"fromTimestamp"`
+	ToTimestamp   hexutil.Uint64      `json:"toTimestamp"`
+	LastBlock     hexutil.Uint64      `json:"lastBlock"`
+	Contracts     []TopContractResult `json:"contracts"`
+}
+
+type TopContractResult struct {
+	Address      common.Address `json:"address"`
+	GasUsed      hexutil.Uint64 `json:"gasUsed"`
+	StorageSlots int64          `json:"storageSlots"`
+}
+
+// TopContracts implements erigon_topContracts. Returns top contracts by cumulative gas used by transactions sent to
+// them ("gas") or by net amount of storage slots created within the window ("storageSlots": slots created minus
+// slots cleared over the last `days` days, not the total amount of contract's slots) held by the rolling analytics index.
+func (api *ErigonImpl) TopContracts(ctx context.Context, metric string, days *hexutil.Uint64, limit *hexutil.Uint64) (*TopContractsResult, error) {
+	if api.topContracts == nil {
+		return nil, errAnalyticsDisabled
+	}
+	m, err := analytics.ParseMetric(metric)
+	if err != nil {
+		return nil, err
+	}
+	n := 100
+	if limit != nil {
+		n = int(*limit)
+	}
+	if n <= 0 || n > analyticsMaxLimit {
+		return nil, fmt.Errorf("limit must be in range [1, %d]", analyticsMaxLimit)
+	}
+
+	lastBlock, ok := api.topContracts.LastBlock()
+	if !ok {
+		return &TopContractsResult{Metric: string(m), Contracts: []TopContractResult{}}, nil
+	}
+	firstDay, lastDay, _ := api.topContracts.Days()
+	fromDay := firstDay
+	if days != nil && *days > 0 && uint64(*days) <= lastDay {
+		fromDay = max(firstDay, lastDay-uint64(*days)+1)
+	}
+
+	top := api.topContracts.Top(m, fromDay, lastDay, n)
+	res := &TopContractsResult{
+		Metric:        string(m),
+		FromTimestamp: hexutil.Uint64(fromDay * analytics.SecondsPerDay),
+		ToTimestamp:   hexutil.Uint64((lastDay+1)*analytics.SecondsPerDay - 1),
+		LastBlock:     hexutil.Uint64(lastBlock),
+		Contracts:     make([]TopContractResult, 0, len(top)),
+	}
+	for _, c := range top {
+		res.Contracts = append(res.Contracts, TopContractResult{Address: c.Address, GasUsed: hexutil.Uint64(c.Gas), StorageSlots: c.StorageSlots})
+	}
+	return res, nil
+}
+
+// topContractsFollower feeds the rolling analytics index with every new canonical head
+func (api *ErigonImpl) topContractsFollower() *headFollower {
+	return &headFollower{
+		name:  "top contracts",
+		index: api.topContracts,
+		indexBlock: func(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (bool, error) {
+			txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+			usage, err := api.blockContractsUsage(ctx, tx, txNumsReader, blockNum)
+			if err != nil || usage == nil {
+				return false, err
+			}
+			api.topContracts.AddBlock(usage)
+			return true, nil
+		},
+	}
+}
+
+func (api *ErigonImpl) blockContractsUsage(ctx context.Context, tx kv.TemporalTx, txNumsReader rawdbv3.TxNumsReader, blockNum uint64) (*analytics.BlockUsage, error) {
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	usage := &analytics.BlockUsage{
+		Number:    blockNum,
+		Hash:      block.Hash(),
+		Time:      block.Time(),
+		Contracts: map[common.Address]analytics.ContractUsage{},
+	}
+
+	minTxNum, err := txNumsReader.Min(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	maxTxNum, err := txNumsReader.Max(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	// plain transfers to EOAs are not counted: only recipients which have code by the end of the block
+	isContract := map[common.Address]bool{}
+	hasCode := func(addr common.Address) (bool, error) {
+		if v, ok := isContract[addr]; ok {
+			return v, nil
+		}
+		code, ok, err := tx.GetAsOf(kv.CodeDomain, addr[:], maxTxNum+1)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			if code, _, err = tx.GetLatest(kv.CodeDomain, addr[:]); err != nil {
+				return false, err
+			}
+		}
+		isContract[addr] = len(code) > 0
+		return len(code) > 0, nil
+	}
+
+	// +1 for system txn in the beginning of block
+	var prevCumGasUsed uint64
+	for i, txn := range block.Transactions() {
+		cumGasUsed, _, _, err := rawtemporaldb.ReceiptAsOf(tx, minTxNum+uint64(i)+2)
+		if err != nil {
+			return nil, err
+		}
+		gasUsed := cumGasUsed - prevCumGasUsed
+		prevCumGasUsed = cumGasUsed
+		to := txn.GetTo()
+		if to == nil {
+			continue
+		}
+		if ok, err := hasCode(*to); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		u := usage.Contracts[*to]
+		u.Gas += gasUsed
+		usage.Contracts[*to] = u
+	}
+
+	// storage slots created/cleared within the block
+	it, err := tx.HistoryRange(kv.StorageDomain, int(minTxNum), int(maxTxNum+1), order.Asc, kv.Unlim)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	for it.HasNext() {
+		k, _, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		before, _, err := tx.GetAsOf(kv.StorageDomain, k, minTxNum)
+		if err != nil {
+			return nil, err
+		}
+		after, ok, err := tx.GetAsOf(kv.StorageDomain, k, maxTxNum+1)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if after, _, err = tx.GetLatest(kv.StorageDomain, k); err != nil {
+				return nil, err
+			}
+		}
+		var delta int64
+		switch {
+		case len(before) == 0 && len(after) > 0:
+			delta = 1
+		case len(before) > 0 && len(after) == 0:
+			delta = -1
+		default:
+			continue
+		}
+		addr := common.BytesToAddress(k[:length.Addr])
+		u := usage.Contracts[addr]
+		u.StorageSlots += delta
+		usage.Contracts[addr] = u
+	}
+	return usage, nil
+}
+
+var errStateExpiryDisabled = errors.New("state expiry tracking is disabled, start rpcdaemon with --rpc.analytics.stateexpiry")
+
+// StateExpiryReportResult is the result of erigon_stateExpiryReport
+type StateExpiryReportResult struct {
+	FromBlock       hexutil.Uint64             `json:"fromBlock"`
+	LastBlock       hexutil.Uint64             `json:"lastBlock"`
+	TrackedAccounts hexutil.Uint64             `json:"trackedAccounts"`
+	TrackedSlots    hexutil.Uint64             `json:"trackedStorageSlots"`
+	Estimated       bool                       `json:"estimated"` // true if tracked state outgrew the memory bound, then counts are estimated from a sample of keys
+	Policies        []ExpiryPolicyReportResult `json:"policies"`
+}
+
+type ExpiryPolicyReportResult struct {
+	Period          hexutil.Uint64 `json:"period"`
+	Complete        bool           `json:"complete"`
+	ExpiredAccounts hexutil.Uint64 `json:"expiredAccounts"`
+	ExpiredSlots    hexutil.Uint64 `json:"expiredStorageSlots"`
+}
+
+// StateExpiryReport implements erigon_stateExpiryReport. For every policy (period in blocks without access) returns how
+// many of the accounts and storage slots read or written since tracking started would be expired. If `policies` is
+// empty - default policies of ~1 month, ~6 months and ~1 year are used.
+func (api *ErigonImpl) StateExpiryReport(ctx context.Context, policies []hexutil.Uint64) (*StateExpiryReportResult, error) {
+	if api.stateExpiry == nil {
+		return nil, errStateExpiryDisabled
+	}
+	periods := analytics.DefaultExpiryPolicies
+	if len(policies) > 0 {
+		periods = make([]uint64, len(policies))
+		for i, p := range policies {
+			periods[i] = uint64(p)
+		}
+	}
+
+	r := api.stateExpiry.Report(periods)
+	res := &StateExpiryReportResult{
+		FromBlock:       hexutil.Uint64(r.FromBlock),
+		LastBlock:       hexutil.Uint64(r.LastBlock),
+		TrackedAccounts: hexutil.Uint64(r.TrackedAccounts),
+		TrackedSlots:    hexutil.Uint64(r.TrackedSlots),
+		Estimated:       r.Estimated,
+		Policies:        make([]ExpiryPolicyReportResult, 0, len(r.Policies)),
+	}
+	for _, p := range r.Policies {
+		res.Policies = append(res.Policies, ExpiryPolicyReportResult{
+			Period:          hexutil.Uint64(p.Period),
+			Complete:        p.Complete,
+			ExpiredAccounts: hexutil.Uint64(p.ExpiredAccounts),
+			ExpiredSlots:    hexutil.Uint64(p.ExpiredSlots),
+		})
+	}
+	return res, nil
+}
+
+// stateExpiryFollower feeds the state expiry tracker with state changes (from history) and state reads (by
+// re-execution) of every new canonical head
+func (api *ErigonImpl) stateExpiryFollower() *headFollower {
+	return &headFollower{
+		name:  "state expiry",
+		index: api.stateExpiry,
+		indexBlock: func(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (bool, error) {
+			block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+			if err != nil || block == nil {
+				return false, err
+			}
+			txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+			minTxNum, err := txNumsReader.Min(tx, blockNum)
+			if err != nil {
+				return false, err
+			}
+			maxTxNum, err := txNumsReader.Max(tx, blockNum)
+			if err != nil {
+				return false, err
+			}
+			accounts, err := appendHistoryKeys(tx, kv.AccountsDomain, minTxNum, maxTxNum, nil)
+			if err != nil {
+				return false, err
+			}
+			slots, err := appendHistoryKeys(tx, kv.StorageDomain, minTxNum, maxTxNum, nil)
+			if err != nil {
+				return false, err
+			}
+			accounts, slots, err = api.appendBlockReads(ctx, tx, block, accounts, slots)
+			if err != nil {
+				return false, err
+			}
+			api.stateExpiry.MarkAccessed(blockNum, block.Hash(), accounts, slots)
+			return true, nil
+		},
+		afterHead: func(context.Context) error {
+			api.stateExpiry.UpdateMetrics()
+			return nil
+		},
+	}
+}
+
+// appendBlockReads re-executes the transactions of the block and appends the accounts and storage slots (address +
+// location) they read
+func (api *ErigonImpl) appendBlockReads(ctx context.Context, tx kv.TemporalTx, block *types.Block, accounts, slots [][]byte) ([][]byte, [][]byte, error) {
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	engine := api.engine()
+	txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+	recorder := newReadRecorder(nil)
+	for txIndex := range block.Transactions() {
+		_, blockCtx, reader, rules, signer, err := transactions.ComputeBlockContext(ctx, engine, block.HeaderNoCopy(), chainConfig, api._blockReader, txNumsReader, tx, txIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the state before each transaction is read from history, reads of all of them accumulate
+		recorder.StateReader = reader
+		ibs := state.New(recorder)
+		msg, txCtx, err := transactions.ComputeTxContext(ibs, engine, rules, signer, block, chainConfig, txIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{})
+		gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
+		if _, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, engine); err != nil {
+			return nil, nil, fmt.Errorf("transaction %d of block %d: %w", txIndex, block.NumberU64(), err)
+		}
+	}
+	for addr, read := range recorder.slots {
+		accounts = append(accounts, addr.Bytes())
+		for slot := range read {
+			slots = append(slots, append(addr.Bytes(), slot.Bytes()...))
+		}
+	}
+	return accounts, slots, nil
+}
+
+// appendHistoryKeys appends keys of `domain` changed within txNums [minTxNum, maxTxNum]
+func appendHistoryKeys(tx kv.TemporalTx, domain kv.Domain, minTxNum, maxTxNum uint64, keys [][]byte) ([][]byte, error) {
+	it, err := tx.HistoryRange(domain, int(minTxNum), int(maxTxNum+1), order.Asc, kv.Unlim)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	for it.HasNext() {
+		k, _, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, common.CopyBytes(k))
+	}
+	return keys, nil
+}
+
+// analyticsBackfillBatch - how many blocks are backfilled between two new heads
+const analyticsBackfillBatch = 1000
+
+var errSelectorsDisabled = errors.New("function selectors index is disabled, start rpcdaemon with --rpc.analytics.selectors")
+
+// SelectorFilter is the criteria of erigon_getTransactionsBySelector
+type SelectorFilter struct {
+	Selector  hexutil.Bytes    `json:"selector"`
+	To        *common.Address  `json:"to,omitempty"`
+	FromBlock *rpc.BlockNumber `json:"fromBlock,omitempty"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock,omitempty"`
+	After     *SelectorCursor  `json:"after,omitempty"` // return transactions after this one, `next` of the previous page
+	PageSize  *hexutil.Uint64  `json:"pageSize,omitempty"`
+}
+
+// SelectorCursor is a position of transaction in the chain
+type SelectorCursor struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+}
+
+// TransactionsBySelectorResult is the result of erigon_getTransactionsBySelector
+type TransactionsBySelectorResult struct {
+	IndexedFromBlock hexutil.Uint64           `json:"indexedFromBlock"`
+	IndexedToBlock   hexutil.Uint64           `json:"indexedToBlock"`
+	Transactions     []*ethapi.RPCTransaction `json:"transactions"`
+	Next             *SelectorCursor          `json:"next,omitempty"` // nil if there are no more transactions
+}
+
+// GetTransactionsBySelector implements erigon_getTransactionsBySelector. Returns transactions whose calldata starts
+// with the function selector, optionally only those sent to `to`, in ascending order. Only blocks held by the
+// function selectors index are searched, the indexed range is returned along with transactions.
+func (api *ErigonImpl) GetTransactionsBySelector(ctx context.Context, filter SelectorFilter) (*TransactionsBySelectorResult, error) {
+	if api.selectors == nil {
+		return nil, errSelectorsDisabled
+	}
+	selector, ok := analytics.SelectorOf(filter.Selector)
+	if !ok || len(filter.Selector) != analytics.SelectorLen {
+		return nil, fmt.Errorf("selector must be %d bytes", analytics.SelectorLen)
+	}
+	pageSize := 100
+	if filter.PageSize != nil {
+		pageSize = int(*filter.PageSize)
+	}
+	if pageSize <= 0 || pageSize > analyticsMaxLimit {
+		return nil, fmt.Errorf("pageSize must be in range [1, %d]", analyticsMaxLimit)
+	}
+
+	res := &TransactionsBySelectorResult{Transactions: []*ethapi.RPCTransaction{}}
+	indexedFrom, indexedTo, ok := api.selectors.Range()
+	if !ok {
+		return res, nil
+	}
+	res.IndexedFromBlock, res.IndexedToBlock = hexutil.Uint64(indexedFrom), hexutil.Uint64(indexedTo)
+
+	from, to := indexedFrom, indexedTo
+	if filter.FromBlock != nil && *filter.FromBlock >= 0 {
+		from = max(from, uint64(*filter.FromBlock))
+	}
+	if filter.ToBlock != nil && *filter.ToBlock >= 0 {
+		to = min(to, uint64(*filter.ToBlock))
+	}
+	if filter.After != nil {
+		from = max(from, uint64(filter.After.BlockNumber))
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for from <= to {
+		blockNums := api.selectors.Blocks(selector, from, to, pageSize)
+		if len(blockNums) == 0 {
+			break
+		}
+		for _, blockNum := range blockNums {
+			block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				continue
+			}
+			for i, txn := range block.Transactions() {
+				if filter.After != nil && blockNum == uint64(filter.After.BlockNumber) && uint64(i) <= uint64(filter.After.TransactionIndex) {
+					continue
+				}
+				if s, ok := analytics.SelectorOf(txn.GetData()); !ok || s != selector || txn.GetTo() == nil {
+					continue
+				}
+				if filter.To != nil && *txn.GetTo() != *filter.To {
+					continue
+				}
+				if len(res.Transactions) == pageSize {
+					last := res.Transactions[len(res.Transactions)-1]
+					res.Next = &SelectorCursor{BlockNumber: hexutil.Uint64(last.BlockNumber.ToInt().Uint64()), TransactionIndex: *last.TransactionIndex}
+					return res, nil
+				}
+				res.Transactions = append(res.Transactions, ethapi.NewRPCTransaction(txn, block.Hash(), blockNum, uint64(i), block.BaseFee()))
+			}
+		}
+		from = blockNums[len(blockNums)-1] + 1
+	}
+	return res, nil
+}
+
+// selectorsFollower feeds the function selectors index with every new canonical head. Between heads it backfills
+// up to `backfill` blocks below the first indexed block.
+func (api *ErigonImpl) selectorsFollower(backfill uint64, logger log.Logger) *headFollower {
+	var backfillTo uint64
+	return &headFollower{
+		name:       "selectors",
+		index:      api.selectors,
+		contiguous: true,
+		indexBlock: func(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (bool, error) {
+			block, err := api._blockReader.BlockByNumber(ctx, tx, blockNum)
+			if err != nil || block == nil {
+				return false, err
+			}
+			api.selectors.AddBlock(blockNum, block.Hash(), blockSelectors(block))
+			return true, nil
+		},
+		afterHead: func(ctx context.Context) error {
+			if backfill == 0 {
+				return nil
+			}
+			if backfillTo == 0 {
+				first, _, _ := api.selectors.Range()
+				backfillTo = first - min(first, backfill)
+			}
+			done, err := api.backfillSelectors(ctx, backfillTo)
+			if err != nil {
+				return err
+			}
+			if done {
+				first, last, _ := api.selectors.Range()
+				logger.Info("[rpc] analytics: selectors index backfilled", "from", first, "to", last)
+				backfill = 0
+			}
+			return nil
+		},
+	}
+}
+
+// backfillSelectors adds next batch of blocks below the indexed range, returns true when `to` is reached
+func (api *ErigonImpl) backfillSelectors(ctx context.Context, to uint64) (bool, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for i := 0; i < analyticsBackfillBatch; i++ {
+		first, _, _ := api.selectors.Range()
+		if first <= to {
+			return true, nil
+		}
+		block, err := api._blockReader.BlockByNumber(ctx, tx, first-1)
+		if err != nil {
+			return false, err
+		}
+		if block == nil {
+			return true, nil // pruned
+		}
+		api.selectors.AddHistoricalBlock(first-1, blockSelectors(block))
+	}
+	return false, nil
+}
+
+// blockSelectors returns distinct selectors called by transactions of the block, contract creations are skipped
+func blockSelectors(block *types.Block) []analytics.Selector {
+	var res []analytics.Selector
+	seen := map[analytics.Selector]struct{}{}
+	for _, txn := range block.Transactions() {
+		if txn.GetTo() == nil {
+			continue
+		}
+		s, ok := analytics.SelectorOf(txn.GetData())
+		if !ok {
+			continue
+		}
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		res = append(res, s)
+	}
+	return res
+}
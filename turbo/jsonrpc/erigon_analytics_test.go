@@ -0,0 +1,99 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/turbo/jsonrpc/analytics"
+)
+
+func TestCatchUpRange(t *testing.T) {
+	from, to := catchUpRange(10, 20, false)
+	require.Equal(t, []uint64{10, 20}, []uint64{from, to})
+	from, to = catchUpRange(21, 20, false)
+	require.Greater(t, from, to)
+
+	// fell behind: skip forward, or continue from where it stopped if index must be contiguous
+	from, to = catchUpRange(10, 1000, false)
+	require.Equal(t, []uint64{1000 - analyticsMaxCatchUpBlocks, 1000}, []uint64{from, to})
+	from, to = catchUpRange(10, 1000, true)
+	require.Equal(t, []uint64{10, 10 + analyticsMaxCatchUpBlocks}, []uint64{from, to})
+}
+
+func TestFollowerReindexesOrphanedBlocks(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	api.selectors = analytics.NewSelectorIndex()
+	f := api.selectorsFollower(0, log.New())
+	ctx := context.Background()
+
+	tx, err := m.DB.BeginTemporalRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	from, err := api.firstBlockToIndex(ctx, tx, api.selectors, 11)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), from) // empty index starts from the head
+
+	// blocks 10 and 11 were indexed on a fork
+	canonical9, _, err := m.BlockReader.CanonicalHash(ctx, tx, 9)
+	require.NoError(t, err)
+	api.selectors.AddBlock(9, canonical9, nil)
+	api.selectors.AddBlock(10, common.Hash{10}, nil)
+	api.selectors.AddBlock(11, common.Hash{11}, nil)
+
+	from, err = api.firstBlockToIndex(ctx, tx, api.selectors, 11)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), from)
+
+	require.NoError(t, api.indexHead(ctx, f, 11))
+	for n := uint64(9); n <= 11; n++ {
+		canonical, _, err := m.BlockReader.CanonicalHash(ctx, tx, n)
+		require.NoError(t, err)
+		indexed, ok := api.selectors.BlockHash(n)
+		require.True(t, ok)
+		require.Equal(t, canonical, indexed)
+	}
+	from, err = api.firstBlockToIndex(ctx, tx, api.selectors, 11)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), from)
+}
+
+func TestAppendBlockReads(t *testing.T) {
+	m, _, contractAddr := chainWithDeployedContract(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	ctx := context.Background()
+
+	tx, err := m.DB.BeginTemporalRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	block, err := api.blockByNumberWithSenders(ctx, tx, 3)
+	require.NoError(t, err)
+
+	// the contract account is only read by the call, it's not in the history of the block
+	accounts, slots, err := api.appendBlockReads(ctx, tx, block, nil, nil)
+	require.NoError(t, err)
+	require.Contains(t, accounts, contractAddr.Bytes())
+	require.Contains(t, slots, append(contractAddr.Bytes(), common.Hash{}.Bytes()...))
+}
@@ -21,18 +21,27 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/types/accounts"
 	"github.com/erigontech/erigon/core/types"
 	"github.com/erigontech/erigon/eth/filters"
 	"github.com/erigontech/erigon/p2p"
 	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/jsonrpc/analytics"
+	"github.com/erigontech/erigon/turbo/jsonrpc/watch"
 	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/shards"
+	"github.com/erigontech/erigon/turbo/snapshotsync"
+	txpool2 "github.com/erigontech/erigon/txnprovider/txpool"
 )
 
 // ErigonAPI Erigon specific routines
 type ErigonAPI interface {
 	// System related (see ./erigon_system.go)
 	Forks(ctx context.Context) (Forks, error)
+	ForkConfig(ctx context.Context) (*ForkConfig, error)
 	BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.BlockNumber) (hexutil.Uint64, error)
 
 	// Blocks related (see ./erigon_blocks.go)
@@ -46,11 +55,76 @@ type ErigonAPI interface {
 	//GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error)
 	GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.ErigonLogs, error)
 	GetLatestLogs(ctx context.Context, crit filters.FilterCriteria, logOptions filters.LogFilterOptions) (types.ErigonLogs, error)
+	ExplainGetLogs(ctx context.Context, crit filters.FilterCriteria) (*GetLogsPlan, error) // see ./erigon_logs_plan.go
 	// Gets cannonical block receipt through hash. If the block is not cannonical returns error
 	GetBlockReceiptsByBlockHash(ctx context.Context, cannonicalBlockHash common.Hash) ([]map[string]interface{}, error)
 
+	// Confirmations related (see ./erigon_confirmations.go)
+	GetTransactionConfirmations(ctx context.Context, txnHash common.Hash) (*TransactionConfirmations, error)
+
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(ctx context.Context) ([]p2p.NodeInfo, error)
+
+	// Contracts related (see ./erigon_contracts.go)
+	GetContractLineage(ctx context.Context, addr common.Address) (*ContractLineage, error)
+	GetContractLifecycle(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage) (*ContractLifecycleResult, error)
+
+	// Proxy related (see ./erigon_proxy.go)
+	ResolveProxy(ctx context.Context, addr common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*ProxyResolution, error)
+
+	// Sync related (see ./erigon_sync_status.go)
+	SyncStatus(ctx context.Context) (*SyncStatus, error)
+
+	// IPLD related (see ./erigon_ipld.go)
+	ExportCAR(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, state map[common.Address][]common.Hash) (*CARExport, error)
+
+	// Snapshots related (see ./erigon_snapshot_attestation.go)
+	SnapshotAttestation(ctx context.Context) (*snapshotsync.AttestationStatus, error)
+
+	// Access list related (see ./erigon_access_list.go)
+	PredictAccessList(ctx context.Context, args ethapi.CallArgs, blocks *hexutil.Uint64) (*AccessListPrediction, error)
+
+	// Proof related (see ./erigon_verify_proof.go)
+	VerifyProof(ctx context.Context, proof accounts.AccProofResult, stateRoot common.Hash) (*ProofVerification, error)
+	CallWithProof(ctx context.Context, args ethapi.CallArgs) (*CallWithProof, error) // see ./erigon_call_proof.go
+
+	// Txpool related (see ./erigon_txpropagation.go)
+	TraceTxPropagation(ctx context.Context, hash common.Hash) (*txpool2.TxnPropagation, error)
+
+	// Rollup related (see ./erigon_output_root.go)
+	OutputAtBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*OutputRootResult, error)
+
+	// History related (see ./erigon_history.go)
+	GetStorageHistory(ctx context.Context, addr common.Address, slot common.Hash, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage) (*StorageHistoryResult, error)
+	GetAccountHistory(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, stride *hexutil.Uint64, page *HistoryPage) (*AccountHistoryResult, error)
+	GetCodeHistory(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage) (*CodeHistoryResult, error)
+
+	// State related (see ./erigon_state.go)
+	GetAccounts(ctx context.Context, addresses []common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]AccountInfo, error)
+	GetStorageAtMany(ctx context.Context, slots []StorageSlot, blockNrOrHash rpc.BlockNumberOrHash) ([]common.Hash, error)
+
+	// Analytics related (see ./erigon_analytics.go)
+	TopContracts(ctx context.Context, metric string, days *hexutil.Uint64, limit *hexutil.Uint64) (*TopContractsResult, error)
+	StateExpiryReport(ctx context.Context, policies []hexutil.Uint64) (*StateExpiryReportResult, error)
+	GetTransactionsBySelector(ctx context.Context, filter SelectorFilter) (*TransactionsBySelectorResult, error)
+
+	// Chain statistics related (see ./erigon_chain_stats.go)
+	ChainStats(ctx context.Context, fromDate, toDate *string) (*ChainStatsResult, error)
+
+	// Watch-lists related (see ./erigon_watch.go)
+	AddWatchList(ctx context.Context, addresses []common.Address, webhook *string) (*watch.List, error)
+	RemoveWatchList(ctx context.Context, id string) (bool, error)
+	GetWatchLists(ctx context.Context) ([]watch.List, error)
+	WatchEvents(ctx context.Context, id *string) (*rpc.Subscription, error)
+
+	// Sender diagnostics related (see ./erigon_sender.go)
+	DiagnoseSender(ctx context.Context, addr common.Address) (*SenderDiagnosis, error)
+
+	// Sync events related (see ./erigon_sync_events.go)
+	SyncEvents(ctx context.Context) (*rpc.Subscription, error)
+
+	// Token related (see ./erigon_token_reconcile.go)
+	ReconcileTokenBalance(ctx context.Context, token, holder common.Address, fromBlock, toBlock rpc.BlockNumber) (*TokenBalanceReconciliation, error)
 }
 
 // ErigonImpl is implementation of the ErigonAPI interface
@@ -58,6 +132,24 @@ type ErigonImpl struct {
 	*BaseAPI
 	db         kv.TemporalRoDB
 	ethBackend rpchelper.ApiBackend
+
+	topContracts *analytics.TopContractsIndex  // nil if analytics disabled
+	stateExpiry  *analytics.StateExpiryTracker // nil if state expiry tracking disabled
+	selectors    *analytics.SelectorIndex      // nil if selector index disabled
+	chainStats   *analytics.ChainStatsIndex    // nil if chain statistics disabled
+	watcher      *watch.Watcher                // nil if watch-lists disabled
+	txPool       txpool.TxpoolClient           // nil if txpool is not available
+
+	txnPropagation *txpool2.PropagationLog // nil if the txpool is not internal
+
+	ots OtterscanAPI // contract creation search
+	eth EthAPI       // access lists of erigon_predictAccessList, proofs of erigon_exportCAR
+
+	ipldDir string // directory of exported CAR files, export is disabled if empty
+	gasCap  uint64 // gas cap of erigon_callWithProof, --rpc.gascap
+
+	syncProgress syncProgressTracker // speeds of erigon_syncStatus
+	syncEvents   *shards.Events      // nil unless the rpcdaemon is embedded into the node
 }
 
 // NewErigonAPI returns ErigonImpl instance
@@ -0,0 +1,285 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"unsafe"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/commitment"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	libstate "github.com/erigontech/erigon-lib/state"
+	"github.com/erigontech/erigon-lib/trie"
+	"github.com/erigontech/erigon-lib/types/accounts"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+// bound the witness of erigon_callWithProof, every account and slot read costs a path of the trie
+const (
+	maxCallWithProofAccounts = 256
+	maxCallWithProofSlots    = 4096
+)
+
+// CallWithProof is the result of erigon_callWithProof: the call result with the Merkle proofs of every account and
+// storage slot the call read and the code it executed. A stateless verifier checks the proofs against the state root
+// of a block it trusts and re-executes the call on the proven state only.
+type CallWithProof struct {
+	BlockNumber hexutil.Uint64                `json:"blockNumber"`
+	BlockHash   common.Hash                   `json:"blockHash"`
+	StateRoot   common.Hash                   `json:"stateRoot"`
+	Output      hexutil.Bytes                 `json:"output"`          // return data, or the revert data
+	Error       string                        `json:"error,omitempty"` // the call reverted or failed
+	GasUsed     hexutil.Uint64                `json:"gasUsed"`
+	Proofs      []*accounts.AccProofResult    `json:"proofs"` // eth_getProof of the accounts read, with the slots read, by address
+	Codes       map[common.Hash]hexutil.Bytes `json:"codes"`  // code read by the call, by code hash
+}
+
+// CallWithProof implements erigon_callWithProof. Executes the call as eth_call on the latest state - the only one
+// proofs are available for - and returns its result with the proofs of the state it read.
+func (api *ErigonImpl) CallWithProof(ctx context.Context, args ethapi.CallArgs) (*CallWithProof, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	header, err := api.headerByRPCNumber(ctx, rpc.LatestBlockNumber, tx)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("latest header not found")
+	}
+	// pinned: the proofs fail rather than prove another state if a new block arrives meanwhile
+	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(header.Number.Uint64()))
+	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Gas == nil || uint64(*args.Gas) == 0 {
+		gas := hexutil.Uint64(header.GasLimit)
+		if api.gasCap != 0 {
+			gas = hexutil.Uint64(min(header.GasLimit, api.gasCap))
+		}
+		args.Gas = &gas
+	}
+	recorder := newReadRecorder(reader)
+	result, err := transactions.DoCall(ctx, api.engine(), args, tx, blockNrOrHash, header, nil, api.gasCap, chainConfig, recorder, api._blockReader, api.evmCallTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(recorder.slots) > maxCallWithProofAccounts {
+		return nil, fmt.Errorf("call read %d accounts, more than %d", len(recorder.slots), maxCallWithProofAccounts)
+	}
+	if recorder.slotCount > maxCallWithProofSlots {
+		return nil, fmt.Errorf("call read %d storage slots, more than %d", recorder.slotCount, maxCallWithProofSlots)
+	}
+	proofs, err := recorder.proofs(ctx, tx, header)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CallWithProof{
+		BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+		BlockHash:   header.Hash(),
+		StateRoot:   header.Root,
+		Output:      result.ReturnData,
+		GasUsed:     hexutil.Uint64(result.UsedGas),
+		Proofs:      proofs,
+		Codes:       recorder.codes,
+	}
+	if result.Err != nil {
+		res.Error = result.Err.Error()
+	}
+	return res, nil
+}
+
+// proofs returns the proofs of the accounts and slots read, by address. All the keys are touched at once, so the
+// paths of the trie are loaded by a single witness computation.
+func (r *readRecorder) proofs(ctx context.Context, tx kv.Tx, header *types.Header) ([]*accounts.AccProofResult, error) {
+	domains, err := libstate.NewSharedDomains(tx, log.New())
+	if err != nil {
+		return nil, err
+	}
+	defer domains.Close()
+	sdCtx := domains.GetCommitmentContext()
+	addrs := r.addresses()
+	for _, addr := range addrs {
+		sdCtx.TouchKey(kv.AccountsDomain, string(addr.Bytes()), nil)
+		for slot := range r.slots[addr] {
+			sdCtx.TouchKey(kv.StorageDomain, string(append(addr.Bytes(), slot.Bytes()...)), nil)
+		}
+	}
+	proofTrie, _, err := sdCtx.Witness(ctx, header.Root[:], "erigon_callWithProof")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*accounts.AccProofResult, 0, len(addrs))
+	for _, addr := range addrs {
+		addrHash := crypto.Keccak256(addr.Bytes())
+		accountProof, err := proofTrie.Prove(addrHash, 0, false)
+		if err != nil {
+			return nil, fmt.Errorf("proof of %x: %w", addr, err)
+		}
+		proof := &accounts.AccProofResult{
+			Address:      addr,
+			Balance:      new(hexutil.Big),
+			AccountProof: *(*[]hexutil.Bytes)(unsafe.Pointer(&accountProof)),
+			StorageHash:  common.BytesToHash(commitment.EmptyRootHash),
+		}
+		if acc, _ := proofTrie.GetAccount(addrHash); acc != nil {
+			proof.Balance = (*hexutil.Big)(acc.Balance.ToBig())
+			proof.Nonce = hexutil.Uint64(acc.Nonce)
+			proof.CodeHash = acc.CodeHash
+			proof.StorageHash = acc.Root
+		}
+		emptyStorage := proof.StorageHash == common.BytesToHash(commitment.EmptyRootHash)
+		for _, slot := range r.sortedSlots(addr) {
+			storageProof := accounts.StorProofResult{
+				Key:   uint256.NewInt(0).SetBytes(slot[:]).Hex(),
+				Value: (*hexutil.Big)(new(big.Int).SetBytes(r.slots[addr][slot])),
+			}
+			if !emptyStorage {
+				p, err := proofTrie.Prove(append(common.CopyBytes(addrHash), crypto.Keccak256(slot[:])...), len(proof.AccountProof), true)
+				if err != nil {
+					return nil, fmt.Errorf("proof of %x slot %x: %w", addr, slot, err)
+				}
+				// 0x80 represents RLP encoding of an empty proof slice
+				storageProof.Proof = []hexutil.Bytes{{0x80}}
+				if len(p) != 0 {
+					storageProof.Proof = *(*[]hexutil.Bytes)(unsafe.Pointer(&p))
+				}
+			}
+			proof.StorageProof = append(proof.StorageProof, storageProof)
+		}
+
+		if err := trie.VerifyAccountProof(header.Root, proof); err != nil {
+			return nil, fmt.Errorf("internal error: failed to verify account proof of %x: %w", addr, err)
+		}
+		for _, storageProof := range proof.StorageProof {
+			if err := trie.VerifyStorageProof(proof.StorageHash, storageProof); err != nil {
+				return nil, fmt.Errorf("internal error: failed to verify storage proof of %x key=%s: %w", addr, storageProof.Key, err)
+			}
+		}
+		res = append(res, proof)
+	}
+	return res, nil
+}
+
+// readRecorder records the accounts, storage slots and code read through the state reader
+type readRecorder struct {
+	state.StateReader
+	slots     map[common.Address]map[common.Hash][]byte // values of the slots read, by address
+	slotCount int
+	codes     map[common.Hash]hexutil.Bytes
+}
+
+func newReadRecorder(reader state.StateReader) *readRecorder {
+	return &readRecorder{StateReader: reader, slots: map[common.Address]map[common.Hash][]byte{}, codes: map[common.Hash]hexutil.Bytes{}}
+}
+
+func (r *readRecorder) touch(address common.Address) map[common.Hash][]byte {
+	slots, ok := r.slots[address]
+	if !ok {
+		slots = map[common.Hash][]byte{}
+		r.slots[address] = slots
+	}
+	return slots
+}
+
+func (r *readRecorder) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	r.touch(address)
+	return r.StateReader.ReadAccountData(address)
+}
+
+func (r *readRecorder) ReadAccountDataForDebug(address common.Address) (*accounts.Account, error) {
+	r.touch(address)
+	return r.StateReader.ReadAccountDataForDebug(address)
+}
+
+func (r *readRecorder) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	value, err := r.StateReader.ReadAccountStorage(address, incarnation, key)
+	if err != nil {
+		return nil, err
+	}
+	slots := r.touch(address)
+	if _, ok := slots[*key]; !ok {
+		slots[*key] = common.CopyBytes(value)
+		r.slotCount++
+	}
+	return value, nil
+}
+
+func (r *readRecorder) ReadAccountCode(address common.Address, incarnation uint64) ([]byte, error) {
+	r.touch(address)
+	code, err := r.StateReader.ReadAccountCode(address, incarnation)
+	if err == nil && len(code) > 0 {
+		r.codes[crypto.Keccak256Hash(code)] = code
+	}
+	return code, err
+}
+
+func (r *readRecorder) ReadAccountCodeSize(address common.Address, incarnation uint64) (int, error) {
+	// the verifier needs the code to know its size
+	code, err := r.ReadAccountCode(address, incarnation)
+	return len(code), err
+}
+
+func (r *readRecorder) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	r.touch(address)
+	return r.StateReader.ReadAccountIncarnation(address)
+}
+
+func (r *readRecorder) addresses() []common.Address {
+	addrs := make([]common.Address, 0, len(r.slots))
+	for addr := range r.slots {
+		addrs = append(addrs, addr)
+	}
+	slices.SortFunc(addrs, func(a, b common.Address) int { return bytes.Compare(a[:], b[:]) })
+	return addrs
+}
+
+func (r *readRecorder) sortedSlots(address common.Address) []common.Hash {
+	slots := make([]common.Hash, 0, len(r.slots[address]))
+	for slot := range r.slots[address] {
+		slots = append(slots, slot)
+	}
+	slices.SortFunc(slots, func(a, b common.Hash) int { return bytes.Compare(a[:], b[:]) })
+	return slots
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon/turbo/adapter/ethapi"
+)
+
+func TestErigonCallWithProof(t *testing.T) {
+	m, _, contractAddr := chainWithDeployedContract(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	api.gasCap = 5_000_000
+	ctx := context.Background()
+
+	retrieve := hexutil.Bytes(crypto.Keccak256([]byte("retrieve()"))[:4])
+	res, err := api.CallWithProof(ctx, ethapi.CallArgs{To: &contractAddr, Data: &retrieve})
+	require.NoError(t, err)
+	require.Empty(t, res.Error)
+	require.Equal(t, uint64(3), uint64(res.BlockNumber))
+	require.Equal(t, common.Hash{31: 2}.Bytes(), []byte(res.Output))
+
+	var contractProven bool
+	for _, proof := range res.Proofs {
+		v := verifyProof(proof, res.StateRoot)
+		require.True(t, v.Valid, "%x: %s", proof.Address, v.Error)
+		if proof.Address != contractAddr {
+			continue
+		}
+		contractProven = true
+		// the call reads _value0x0 only
+		require.Len(t, v.StorageProof, 1)
+		require.Equal(t, uint64(2), v.StorageProof[0].Value.ToInt().Uint64())
+		code, ok := res.Codes[v.CodeHash]
+		require.True(t, ok, "code of the contract")
+		require.Equal(t, v.CodeHash, crypto.Keccak256Hash(code))
+	}
+	require.True(t, contractProven)
+}
@@ -0,0 +1,251 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/consensus/misc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/analytics"
+)
+
+const (
+	chainStatsDateLayout   = "2006-01-02"
+	chainStatsDefaultDays  = 30
+	chainStatsBackfillTime = 5 * time.Second  // max time spent on backfilling between two new heads
+	chainStatsSaveInterval = 10 * time.Minute // how often the index is saved to the file
+)
+
+var errChainStatsDisabled = errors.New("chain statistics are disabled, start rpcdaemon with --rpc.analytics.chainstats")
+
+// ChainStatsResult is the result of erigon_chainStats
+type ChainStatsResult struct {
+	IndexedFromBlock hexutil.Uint64    `json:"indexedFromBlock"`
+	IndexedToBlock   hexutil.Uint64    `json:"indexedToBlock"`
+	Days             []ChainStatsOfDay `json:"days"`
+}
+
+// ChainStatsOfDay are the aggregates of the blocks of one UTC day
+type ChainStatsOfDay struct {
+	Date             string         `json:"date"` // YYYY-MM-DD
+	FirstBlock       hexutil.Uint64 `json:"firstBlock"`
+	LastBlock        hexutil.Uint64 `json:"lastBlock"`
+	Blocks           hexutil.Uint64 `json:"blocks"`
+	Transactions     hexutil.Uint64 `json:"transactions"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	BurntFees        *hexutil.Big   `json:"burntFees"`
+	ContractsCreated hexutil.Uint64 `json:"contractsCreated"`
+	ActiveAddresses  hexutil.Uint64 `json:"activeAddresses"` // estimate, ~2% error
+	// Partial - not all blocks of the day are indexed yet: backfill hasn't reached the start of the day or the day is not over
+	Partial bool `json:"partial"`
+}
+
+// ChainStats implements erigon_chainStats. Returns daily aggregates (transactions, gas used, burnt fees, contracts
+// created, active addresses) of the UTC days within [fromDate, toDate], dates are YYYY-MM-DD. By default - the last
+// 30 days. The aggregates are maintained with every new head and backfilled down to genesis in background.
+func (api *ErigonImpl) ChainStats(ctx context.Context, fromDate, toDate *string) (*ChainStatsResult, error) {
+	if api.chainStats == nil {
+		return nil, errChainStatsDisabled
+	}
+	res := &ChainStatsResult{Days: []ChainStatsOfDay{}}
+	first, last, ok := api.chainStats.Range()
+	if !ok {
+		return res, nil
+	}
+	res.IndexedFromBlock, res.IndexedToBlock = hexutil.Uint64(first), hexutil.Uint64(last)
+
+	toDay := uint64(time.Now().Unix()) / analytics.SecondsPerDay
+	if toDate != nil {
+		day, err := parseChainStatsDate(*toDate)
+		if err != nil {
+			return nil, err
+		}
+		toDay = day
+	}
+	fromDay := toDay - min(toDay, chainStatsDefaultDays-1)
+	if fromDate != nil {
+		day, err := parseChainStatsDate(*fromDate)
+		if err != nil {
+			return nil, err
+		}
+		fromDay = day
+	}
+	if fromDay > toDay {
+		return nil, fmt.Errorf("fromDate %s is after toDate %s", chainStatsDate(fromDay), chainStatsDate(toDay))
+	}
+	if toDay-fromDay >= analyticsMaxLimit {
+		return nil, fmt.Errorf("date range is too wide: max %d days", analyticsMaxLimit)
+	}
+
+	for _, d := range api.chainStats.Days(fromDay, toDay) {
+		res.Days = append(res.Days, ChainStatsOfDay{
+			Date:             chainStatsDate(d.Day),
+			FirstBlock:       hexutil.Uint64(d.FirstBlock),
+			LastBlock:        hexutil.Uint64(d.LastBlock),
+			Blocks:           hexutil.Uint64(d.Blocks),
+			Transactions:     hexutil.Uint64(d.Transactions),
+			GasUsed:          hexutil.Uint64(d.GasUsed),
+			BurntFees:        (*hexutil.Big)(d.BurntFees.ToBig()),
+			ContractsCreated: hexutil.Uint64(d.ContractsCreated),
+			ActiveAddresses:  hexutil.Uint64(d.ActiveAddresses),
+			Partial:          (d.FirstBlock == first && first > 0) || d.LastBlock == last,
+		})
+	}
+	return res, nil
+}
+
+func parseChainStatsDate(s string) (uint64, error) {
+	t, err := time.Parse(chainStatsDateLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	if t.Unix() < 0 {
+		return 0, fmt.Errorf("invalid date %q: before 1970-01-01", s)
+	}
+	return uint64(t.Unix()) / analytics.SecondsPerDay, nil
+}
+
+func chainStatsDate(day uint64) string {
+	return time.Unix(int64(day*analytics.SecondsPerDay), 0).UTC().Format(chainStatsDateLayout)
+}
+
+// chainStatsFollower feeds the chain statistics with every new canonical head. Between heads it backfills
+// the index down to genesis and periodically saves it to the file at `path`.
+func (api *ErigonImpl) chainStatsFollower(path string, logger log.Logger) *headFollower {
+	backfilled := false
+	lastSave := time.Now()
+	return &headFollower{
+		name:       "chain stats",
+		index:      api.chainStats,
+		contiguous: true,
+		indexBlock: func(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (bool, error) {
+			stats, err := api.blockStats(ctx, tx, blockNum)
+			if err != nil || stats == nil {
+				return false, err
+			}
+			api.chainStats.AddBlock(stats)
+			return true, nil
+		},
+		afterHead: func(ctx context.Context) error {
+			if !backfilled {
+				start := time.Now()
+				for !backfilled && time.Since(start) < chainStatsBackfillTime {
+					done, err := api.backfillChainStats(ctx)
+					if err != nil {
+						return err
+					}
+					backfilled = done
+				}
+				if backfilled {
+					first, last, _ := api.chainStats.Range()
+					logger.Info("[rpc] analytics: chain stats backfilled", "from", first, "to", last)
+				}
+			}
+			if time.Since(lastSave) < chainStatsSaveInterval {
+				return nil
+			}
+			lastSave = time.Now()
+			return api.chainStats.Save(path)
+		},
+	}
+}
+
+// backfillChainStats adds next batch of blocks below the indexed range, returns true when genesis
+// (or the first block with pruned body) is reached
+func (api *ErigonImpl) backfillChainStats(ctx context.Context) (bool, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for i := 0; i < analyticsBackfillBatch; i++ {
+		first, _, ok := api.chainStats.Range()
+		if !ok {
+			return false, nil
+		}
+		if first == 0 {
+			return true, nil
+		}
+		stats, err := api.blockStats(ctx, tx, first-1)
+		if err != nil {
+			return false, err
+		}
+		if stats == nil {
+			return true, nil // pruned
+		}
+		api.chainStats.AddHistoricalBlock(stats)
+	}
+	return false, nil
+}
+
+// blockStats returns the contribution of the canonical block to the chain statistics, nil if block is not available
+func (api *ErigonImpl) blockStats(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (*analytics.BlockStats, error) {
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	header := block.HeaderNoCopy()
+	stats := &analytics.BlockStats{
+		Number:       blockNum,
+		Hash:         block.Hash(),
+		Time:         header.Time,
+		Transactions: uint64(len(block.Transactions())),
+		GasUsed:      header.GasUsed,
+		Addresses:    make([]common.Address, 0, 2*len(block.Transactions())),
+	}
+	if header.BaseFee != nil {
+		baseFee, overflow := uint256.FromBig(header.BaseFee)
+		if overflow {
+			return nil, fmt.Errorf("base fee of block %d overflows", blockNum)
+		}
+		stats.BurntFees.Mul(baseFee, uint256.NewInt(header.GasUsed))
+	}
+	if header.BlobGasUsed != nil && header.ExcessBlobGas != nil && *header.BlobGasUsed > 0 {
+		chainConfig, err := api.chainConfig(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		blobGasPrice, err := misc.GetBlobGasPrice(chainConfig, *header.ExcessBlobGas, header.Time)
+		if err != nil {
+			return nil, err
+		}
+		var blobFees uint256.Int
+		blobFees.Mul(blobGasPrice, uint256.NewInt(*header.BlobGasUsed))
+		stats.BurntFees.Add(&stats.BurntFees, &blobFees)
+	}
+	for _, txn := range block.Transactions() {
+		if sender, ok := txn.GetSender(); ok {
+			stats.Addresses = append(stats.Addresses, sender)
+		}
+		if to := txn.GetTo(); to != nil {
+			stats.Addresses = append(stats.Addresses, *to)
+		} else {
+			stats.ContractsCreated++
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+// TransactionConfirmations is the result of erigon_getTransactionConfirmations
+type TransactionConfirmations struct {
+	// Canonical is false if transaction is not included into the canonical chain: not mined yet, unknown or reorged out.
+	// Other fields are empty in that case.
+	Canonical     bool            `json:"canonical"`
+	BlockNumber   *hexutil.Uint64 `json:"blockNumber"`
+	BlockHash     *common.Hash    `json:"blockHash"`
+	Confirmations hexutil.Uint64  `json:"confirmations"` // 1 if transaction is in the head block
+	Safe          bool            `json:"safe"`
+	Finalized     bool            `json:"finalized"`
+}
+
+// GetTransactionConfirmations implements erigon_getTransactionConfirmations. Returns the number of confirmations
+// of the transaction and whether its block is safe and finalized by the consensus layer. Everything is read in one
+// transaction, so the result is consistent even if reorg happens concurrently.
+func (api *ErigonImpl) GetTransactionConfirmations(ctx context.Context, txnHash common.Hash) (*TransactionConfirmations, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res := &TransactionConfirmations{}
+	blockNum, _, ok, err := api.txnLookup(ctx, tx, txnHash)
+	if err != nil || !ok {
+		return res, err
+	}
+	// lookup index is not an evidence of inclusion: it may still point to the orphaned block
+	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+	if err != nil || block == nil {
+		return res, err
+	}
+	if block.Transaction(txnHash) == nil {
+		return res, nil
+	}
+	head, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	if head < blockNum {
+		return res, nil // block is not executed yet
+	}
+
+	blockHash := block.Hash()
+	res.Canonical = true
+	res.BlockNumber, res.BlockHash = (*hexutil.Uint64)(&blockNum), &blockHash
+	res.Confirmations = hexutil.Uint64(head - blockNum + 1)
+	if res.Safe, err = blockReached(tx, rpchelper.GetSafeBlockNumber, blockNum); err != nil {
+		return nil, err
+	}
+	if res.Finalized, err = blockReached(tx, rpchelper.GetFinalizedBlockNumber, blockNum); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// blockReached returns true if the block (safe or finalized, returned by `get`) is at or above `blockNum`.
+// False if consensus layer didn't report such block yet.
+func blockReached(tx kv.Tx, get func(kv.Tx) (uint64, error), blockNum uint64) (bool, error) {
+	n, err := get(tx)
+	if errors.Is(err, rpchelper.UnknownBlockError) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return n >= blockNum, nil
+}
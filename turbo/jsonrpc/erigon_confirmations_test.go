@@ -0,0 +1,90 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestGetTransactionConfirmations(t *testing.T) {
+	var (
+		signer      = types.LatestSignerForChainID(nil)
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{bankAddress: {Balance: big.NewInt(1e18)}},
+		}
+	)
+	m := mock.MockWithGenesis(t, gspec, bankKey, false)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	var txnHash libcommon.Hash
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, block *core.BlockGen) {
+		if i == 1 {
+			txn, err := types.SignTx(types.NewTransaction(block.TxNonce(bankAddress), libcommon.Address{1}, uint256.NewInt(1), 21000, new(uint256.Int), nil), *signer, bankKey)
+			require.NoError(t, err)
+			txnHash = txn.Hash()
+			block.AddTx(txn)
+		}
+	})
+	require.NoError(t, err)
+	// longer fork without the transaction
+	fork, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 4, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(libcommon.Address{2})
+	})
+	require.NoError(t, err)
+
+	res, err := api.GetTransactionConfirmations(m.Ctx, txnHash)
+	require.NoError(t, err)
+	require.Equal(t, &TransactionConfirmations{}, res)
+
+	require.NoError(t, m.InsertChain(chain))
+	res, err = api.GetTransactionConfirmations(m.Ctx, txnHash)
+	require.NoError(t, err)
+	blockNum, blockHash := hexutil.Uint64(2), chain.Blocks[1].Hash()
+	require.Equal(t, &TransactionConfirmations{Canonical: true, BlockNumber: &blockNum, BlockHash: &blockHash, Confirmations: 2}, res)
+
+	require.NoError(t, m.DB.Update(m.Ctx, func(tx kv.RwTx) error {
+		rawdb.WriteForkchoiceSafe(tx, chain.Blocks[1].Hash())
+		rawdb.WriteForkchoiceFinalized(tx, chain.Blocks[0].Hash())
+		return nil
+	}))
+	res, err = api.GetTransactionConfirmations(m.Ctx, txnHash)
+	require.NoError(t, err)
+	require.True(t, res.Safe)
+	require.False(t, res.Finalized)
+
+	require.NoError(t, m.InsertChain(fork))
+	res, err = api.GetTransactionConfirmations(m.Ctx, txnHash)
+	require.NoError(t, err)
+	require.Equal(t, &TransactionConfirmations{}, res)
+}
@@ -0,0 +1,328 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+// maxContractLineageDepth - max amount of factories between the contract and the deployer
+const maxContractLineageDepth = 64
+
+// ContractLineageEntry is a single creation step: `Address` was created by `Creator` in transaction `TxHash`
+type ContractLineageEntry struct {
+	Address     common.Address  `json:"address"`
+	Creator     common.Address  `json:"creator"`
+	TxHash      common.Hash     `json:"transactionHash"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"` // nil if transaction is not found in the lookup index
+}
+
+// ContractLineage is the result of erigon_getContractLineage
+type ContractLineage struct {
+	Lineage []ContractLineageEntry `json:"lineage"` // from the requested contract up to the deployer
+	// Deployer is the root of the lineage: the EOA which sent the first creation transaction.
+	// nil if the lineage is broken: one of the factories doesn't exist anymore (self-destructed) or max depth is reached
+	Deployer *common.Address `json:"deployer"`
+}
+
+// GetContractLineage implements erigon_getContractLineage. Returns the chain of creators of the contract
+// (contract <- factory <- ... <- EOA) with creating transactions, resolved by the same creation search as
+// ots_getContractCreator. Returns nil if `addr` is not a contract.
+func (api *ErigonImpl) GetContractLineage(ctx context.Context, addr common.Address) (*ContractLineage, error) {
+	if api.ots == nil {
+		return nil, errors.New("contract lineage requires ots namespace implementation")
+	}
+
+	res := &ContractLineage{Lineage: []ContractLineageEntry{}}
+	for current := addr; len(res.Lineage) < maxContractLineageDepth; {
+		created, err := api.ots.GetContractCreator(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if created == nil {
+			if len(res.Lineage) == 0 {
+				return nil, nil // not a contract
+			}
+			// creator of the previous step is not a contract: either EOA or self-destructed factory
+			isEOA, err := api.isEOA(ctx, current)
+			if err != nil {
+				return nil, err
+			}
+			if isEOA {
+				res.Deployer = &current
+			}
+			return res, nil
+		}
+
+		entry := ContractLineageEntry{Address: current, Creator: created.Creator, TxHash: created.Tx}
+		blockNum, err := api.txBlockNumber(ctx, created.Tx)
+		if err != nil {
+			return nil, err
+		}
+		entry.BlockNumber = blockNum
+		res.Lineage = append(res.Lineage, entry)
+		current = created.Creator
+	}
+	return res, nil
+}
+
+// isEOA returns true if the account exists and has no code. Self-destructed factory doesn't exist or, if it received
+// ether after destruction, exists without code but with zero nonce - while EOA which sent a transaction has non-zero nonce.
+func (api *ErigonImpl) isEOA(ctx context.Context, addr common.Address) (bool, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	acc, err := rpchelper.NewLatestStateReader(tx).ReadAccountData(addr)
+	if err != nil {
+		return false, err
+	}
+	return acc != nil && acc.IsEmptyCodeHash() && acc.Nonce > 0, nil
+}
+
+func (api *ErigonImpl) txBlockNumber(ctx context.Context, txnHash common.Hash) (*hexutil.Uint64, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	blockNum, _, ok, err := api.txnLookup(ctx, tx, txnHash)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return (*hexutil.Uint64)(&blockNum), nil
+}
+
+const (
+	ContractCreated   = "create"
+	ContractDestroyed = "destroy"
+)
+
+// ContractLifecycleEvent is a creation or destruction of the contract
+type ContractLifecycleEvent struct {
+	Type        string          `json:"type"` // ContractCreated or ContractDestroyed
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	TxIndex     *hexutil.Uint64 `json:"transactionIndex"` // nil if the change is made by a system transaction (genesis, system contracts)
+	TxHash      *common.Hash    `json:"transactionHash"`
+}
+
+// lifecycleMaxScan - max amount of accounts history entries examined by one erigon_getContractLifecycle call.
+// Most of the entries are balance or nonce changes, so the page is cut short (with `next` set) when it's reached.
+const lifecycleMaxScan = 4 * historyMaxPageSize
+
+// ContractLifecycleResult is the result of erigon_getContractLifecycle
+type ContractLifecycleResult struct {
+	Events []ContractLifecycleEvent `json:"events"`
+	Next   *hexutil.Uint64          `json:"next,omitempty"` // opaque position to pass as `after` for the next page, nil if there are no more events
+}
+
+// GetContractLifecycle implements erigon_getContractLifecycle. Returns creations and destructions of the contract
+// at `addr` within the block range (both inclusive) in chain order - a CREATE2 address may be redeployed after
+// self-destruct.
+//
+// Events are found by the accounts history index: a transaction which changed the account creates the contract
+// if the account had no code before it and has code after it, and destroys it if the other way around.
+// Since EIP-6780 SELFDESTRUCT deletes only contracts created in the same transaction - such contract never has code
+// at transaction boundaries, so transactions which delete a code-less account are traced to tell it apart
+// from the removal of an empty account (EIP-161). EIP-7702 delegations are not contract code.
+//
+// At most lifecycleMaxScan history entries are examined per call, so a page may have less than `pageSize` events
+// (even none) and still have `next`. A page may have `pageSize`+1 events if its last transaction both created and
+// destroyed the contract.
+func (api *ErigonImpl) GetContractLifecycle(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage) (*ContractLifecycleResult, error) {
+	return api.contractLifecycle(ctx, addr, fromBlock, toBlock, page, lifecycleMaxScan)
+}
+
+func (api *ErigonImpl) contractLifecycle(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage, maxScan int) (*ContractLifecycleResult, error) {
+	pageSize, err := page.size()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	txNums := api.newTxNumBlocks(ctx, tx)
+	fromTxNum, toTxNum, err := txNums.rangeOf(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && page.After != nil {
+		fromTxNum = max(fromTxNum, uint64(*page.After)+1)
+	}
+
+	res := &ContractLifecycleResult{Events: []ContractLifecycleEvent{}}
+	if fromTxNum >= toTxNum {
+		return res, nil
+	}
+	it, err := tx.IndexRange(kv.AccountsHistoryIdx, addr[:], int(fromTxNum), int(toTxNum), order.Asc, maxScan+1)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var lastTxNum uint64
+	for scanned := 0; it.HasNext(); scanned++ {
+		if len(res.Events) >= pageSize || scanned == maxScan {
+			next := hexutil.Uint64(lastTxNum)
+			res.Next = &next
+			break
+		}
+		txNum, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		lastTxNum = txNum
+
+		codeBefore, err := hasContractCodeAsOf(tx, addr, txNum)
+		if err != nil {
+			return nil, err
+		}
+		codeAfter, err := hasContractCodeAsOf(tx, addr, txNum+1)
+		if err != nil {
+			return nil, err
+		}
+
+		var eventTypes []string
+		switch {
+		case !codeBefore && codeAfter:
+			eventTypes = []string{ContractCreated}
+		case codeBefore && !codeAfter:
+			eventTypes = []string{ContractDestroyed}
+		case !codeBefore && !codeAfter:
+			accAfter, _, err := tx.GetAsOf(kv.AccountsDomain, addr[:], txNum+1)
+			if err != nil {
+				return nil, err
+			}
+			if len(accAfter) > 0 {
+				continue // balance or nonce change
+			}
+			eventTypes = []string{ContractCreated, ContractDestroyed} // if the trace confirms it
+		default:
+			continue
+		}
+
+		event, txn, err := api.lifecycleEventAt(ctx, tx, txNums, txNum)
+		if err != nil {
+			return nil, err
+		}
+		if len(eventTypes) == 2 {
+			if txn == nil {
+				continue
+			}
+			tracer := newLifecycleTracer(addr)
+			if err := api.genericTracer(tx, ctx, uint64(event.BlockNumber), txNum, int(*event.TxIndex), chainConfig, tracer); err != nil {
+				return nil, err
+			}
+			if !tracer.Found() {
+				continue
+			}
+		}
+		for _, typ := range eventTypes {
+			event.Type = typ
+			res.Events = append(res.Events, event)
+		}
+	}
+	return res, nil
+}
+
+// hasContractCodeAsOf returns true if the account has code, other than EIP-7702 delegation, before transaction `txNum`
+func hasContractCodeAsOf(tx kv.TemporalTx, addr common.Address, txNum uint64) (bool, error) {
+	code, _, err := tx.GetAsOf(kv.CodeDomain, addr[:], txNum)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := types.ParseDelegation(code); ok {
+		return false, nil
+	}
+	return len(code) > 0, nil
+}
+
+// lifecycleEventAt returns event (without type) positioned at transaction `txNum` and the transaction itself,
+// nil for system transactions
+func (api *ErigonImpl) lifecycleEventAt(ctx context.Context, tx kv.TemporalTx, txNums *txNumBlocks, txNum uint64) (ContractLifecycleEvent, types.Transaction, error) {
+	blockNum, txIndex, err := txNums.position(txNum)
+	if err != nil {
+		return ContractLifecycleEvent{}, nil, err
+	}
+	event := ContractLifecycleEvent{BlockNumber: hexutil.Uint64(blockNum), TxIndex: txIndex}
+	if txIndex == nil {
+		return event, nil, nil
+	}
+	txn, err := api._txnReader.TxnByIdxInBlock(ctx, tx, blockNum, int(*txIndex))
+	if err != nil || txn == nil {
+		return event, nil, err
+	}
+	txHash := txn.Hash()
+	event.TxHash = &txHash
+	return event, txn, nil
+}
+
+// lifecycleTracer finds the contract created and self-destructed within the traced transaction
+type lifecycleTracer struct {
+	DefaultTracer
+	target    common.Address
+	created   bool
+	destroyed bool
+}
+
+func newLifecycleTracer(target common.Address) *lifecycleTracer {
+	return &lifecycleTracer{target: target}
+}
+
+func (t *lifecycleTracer) SetTransaction(tx types.Transaction) {}
+
+func (t *lifecycleTracer) Found() bool {
+	return t.created && t.destroyed
+}
+
+func (t *lifecycleTracer) captureStartOrEnter(typ vm.OpCode, from, to common.Address, create bool) {
+	if create && to == t.target {
+		t.created = true
+	}
+	if typ == vm.SELFDESTRUCT && from == t.target {
+		t.destroyed = true
+	}
+}
+
+func (t *lifecycleTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.captureStartOrEnter(vm.CALL, from, to, create)
+}
+
+func (t *lifecycleTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.captureStartOrEnter(typ, from, to, create)
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/contracts"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestGetContractLineage(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	base := newBaseApiForTest(m)
+	api := NewErigonAPI(base, m.DB, nil)
+	api.ots = NewOtterscanAPI(base, m.DB, 25)
+
+	addr := libcommon.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	expectCreator := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	expectCredByTx := libcommon.HexToHash("0x6e25f89e24254ba3eb460291393a4715fd3c33d805334cbd05c1b2efe1080f18")
+	t.Run("contract deployed by EOA", func(t *testing.T) {
+		require := require.New(t)
+		res, err := api.GetContractLineage(m.Ctx, addr)
+		require.NoError(err)
+		require.Len(res.Lineage, 1)
+		require.Equal(addr, res.Lineage[0].Address)
+		require.Equal(expectCreator, res.Lineage[0].Creator)
+		require.Equal(expectCredByTx, res.Lineage[0].TxHash)
+		require.NotNil(res.Deployer)
+		require.Equal(expectCreator, *res.Deployer)
+	})
+	t.Run("not a contract", func(t *testing.T) {
+		require := require.New(t)
+		res, err := api.GetContractLineage(m.Ctx, expectCreator)
+		require.NoError(err)
+		require.Nil(res)
+	})
+}
+
+func TestGetContractLineageFactory(t *testing.T) {
+	var (
+		signer      = types.LatestSignerForChainID(nil)
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{bankAddress: {Balance: big.NewInt(1e18)}},
+		}
+		// deploy(uint256 salt) with zero salt: creates child with CREATE2
+		deployInput = append(crypto.Keccak256([]byte("deploy(uint256)"))[:4], make([]byte, 32)...)
+		// init code of the child, see contracts/poly.sol
+		childInitCode = hexutil.MustDecode("0x60606000534360015360ff60025360036000f3")
+	)
+	m := mock.MockWithGenesis(t, gspec, bankKey, false)
+
+	var factoryAddr libcommon.Address
+	var factoryTx, childTx libcommon.Hash
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 2, func(i int, block *core.BlockGen) {
+		nonce := block.TxNonce(bankAddress)
+		switch i {
+		case 0:
+			txn, err := types.SignTx(types.NewContractCreation(nonce, new(uint256.Int), 1e6, new(uint256.Int), hexutil.MustDecode(contracts.PolyBin)), *signer, bankKey)
+			require.NoError(t, err)
+			factoryAddr, factoryTx = crypto.CreateAddress(bankAddress, nonce), txn.Hash()
+			block.AddTx(txn)
+		case 1:
+			txn, err := types.SignTx(types.NewTransaction(nonce, factoryAddr, new(uint256.Int), 1e6, new(uint256.Int), deployInput), *signer, bankKey)
+			require.NoError(t, err)
+			childTx = txn.Hash()
+			block.AddTx(txn)
+		}
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	base := newBaseApiForTest(m)
+	api := NewErigonAPI(base, m.DB, nil)
+	api.ots = NewOtterscanAPI(base, m.DB, 25)
+
+	childAddr := crypto.CreateAddress2(factoryAddr, [32]byte{}, crypto.Keccak256(childInitCode))
+	res, err := api.GetContractLineage(m.Ctx, childAddr)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	require.Len(t, res.Lineage, 2)
+	require.Equal(t, childAddr, res.Lineage[0].Address)
+	require.Equal(t, factoryAddr, res.Lineage[0].Creator)
+	require.Equal(t, childTx, res.Lineage[0].TxHash)
+	require.Equal(t, hexutil.Uint64(2), *res.Lineage[0].BlockNumber)
+	require.Equal(t, factoryAddr, res.Lineage[1].Address)
+	require.Equal(t, bankAddress, res.Lineage[1].Creator)
+	require.Equal(t, factoryTx, res.Lineage[1].TxHash)
+	require.Equal(t, hexutil.Uint64(1), *res.Lineage[1].BlockNumber)
+	require.NotNil(t, res.Deployer)
+	require.Equal(t, bankAddress, *res.Deployer)
+}
+
+func TestGetContractLifecycle(t *testing.T) {
+	var (
+		signer      = types.LatestSignerForChainID(nil)
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{bankAddress: {Balance: big.NewInt(1e18)}},
+		}
+		zeroSalt           = make([]byte, 32)
+		deployInput        = append(crypto.Keccak256([]byte("deploy(uint256)"))[:4], zeroSalt...)
+		deployDestroyInput = append(crypto.Keccak256([]byte("deployAndDestruct(uint256)"))[:4], zeroSalt...)
+		childInitCode      = hexutil.MustDecode("0x60606000534360015360ff60025360036000f3")
+	)
+	m := mock.MockWithGenesis(t, gspec, bankKey, false)
+
+	var factoryAddr, childAddr libcommon.Address
+	txHashes := map[int]libcommon.Hash{}
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 5, func(i int, block *core.BlockGen) {
+		nonce := block.TxNonce(bankAddress)
+		var txn types.Transaction
+		switch i {
+		case 0:
+			txn = types.NewContractCreation(nonce, new(uint256.Int), 1e6, new(uint256.Int), hexutil.MustDecode(contracts.PolyBin))
+			factoryAddr = crypto.CreateAddress(bankAddress, nonce)
+			childAddr = crypto.CreateAddress2(factoryAddr, [32]byte{}, crypto.Keccak256(childInitCode))
+		case 1, 4: // create the child
+			txn = types.NewTransaction(nonce, factoryAddr, new(uint256.Int), 1e6, new(uint256.Int), deployInput)
+		case 2: // child self-destructs when called
+			txn = types.NewTransaction(nonce, childAddr, new(uint256.Int), 1e6, new(uint256.Int), nil)
+		case 3: // create and self-destruct in the same transaction
+			txn = types.NewTransaction(nonce, factoryAddr, new(uint256.Int), 1e6, new(uint256.Int), deployDestroyInput)
+		}
+		signed, err := types.SignTx(txn, *signer, bankKey)
+		require.NoError(t, err)
+		txHashes[i+1] = signed.Hash()
+		block.AddTx(signed)
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	expect := func(typ string, blockNum uint64) ContractLifecycleEvent {
+		txIndex, txHash := hexutil.Uint64(0), txHashes[int(blockNum)]
+		return ContractLifecycleEvent{Type: typ, BlockNumber: hexutil.Uint64(blockNum), TxIndex: &txIndex, TxHash: &txHash}
+	}
+	childEvents := []ContractLifecycleEvent{
+		expect(ContractCreated, 2),
+		expect(ContractDestroyed, 3),
+		expect(ContractCreated, 4),
+		expect(ContractDestroyed, 4),
+		expect(ContractCreated, 5),
+	}
+	res, err := api.GetContractLifecycle(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, nil)
+	require.NoError(t, err)
+	require.Equal(t, childEvents, res.Events)
+	require.Nil(t, res.Next)
+
+	t.Run("block range", func(t *testing.T) {
+		res, err := api.GetContractLifecycle(m.Ctx, childAddr, 3, 4, nil)
+		require.NoError(t, err)
+		require.Equal(t, childEvents[1:4], res.Events)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		pageSize := hexutil.Uint64(2)
+		var events []ContractLifecycleEvent
+		page := &HistoryPage{PageSize: &pageSize}
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5)
+			res, err := api.GetContractLifecycle(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, page)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(res.Events), int(pageSize)+1)
+			events = append(events, res.Events...)
+			if res.Next == nil {
+				break
+			}
+			page = &HistoryPage{PageSize: &pageSize, After: res.Next}
+		}
+		require.Equal(t, childEvents, events)
+	})
+
+	t.Run("scan limit", func(t *testing.T) {
+		var events []ContractLifecycleEvent
+		var page *HistoryPage
+		for calls := 0; ; calls++ {
+			require.Less(t, calls, 20)
+			res, err := api.contractLifecycle(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, page, 1)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(res.Events), 2) // one history entry per call
+			events = append(events, res.Events...)
+			if res.Next == nil {
+				break
+			}
+			page = &HistoryPage{After: res.Next}
+		}
+		require.Equal(t, childEvents, events)
+	})
+
+	res, err = api.GetContractLifecycle(m.Ctx, factoryAddr, 0, rpc.LatestBlockNumber, nil)
+	require.NoError(t, err)
+	require.Equal(t, []ContractLifecycleEvent{expect(ContractCreated, 1)}, res.Events)
+
+	res, err = api.GetContractLifecycle(m.Ctx, bankAddress, 0, rpc.LatestBlockNumber, nil)
+	require.NoError(t, err)
+	require.Empty(t, res.Events)
+}
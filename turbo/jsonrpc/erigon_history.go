@@ -0,0 +1,431 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/types/accounts"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+const (
+	historyDefaultPageSize = 100
+	historyMaxPageSize     = 1000
+)
+
+// HistoryPage selects a page of history RPC results
+type HistoryPage struct {
+	After    *hexutil.Uint64 `json:"after,omitempty"` // `next` of the previous page
+	PageSize *hexutil.Uint64 `json:"pageSize,omitempty"`
+}
+
+func (p *HistoryPage) size() (int, error) {
+	if p == nil || p.PageSize == nil {
+		return historyDefaultPageSize, nil
+	}
+	if *p.PageSize == 0 || *p.PageSize > historyMaxPageSize {
+		return 0, fmt.Errorf("pageSize must be in range [1, %d]", historyMaxPageSize)
+	}
+	return int(*p.PageSize), nil
+}
+
+// StorageChange is a single change of the storage slot
+type StorageChange struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	TxIndex     *hexutil.Uint64 `json:"transactionIndex"` // nil if the change is made by a system transaction
+	PrevValue   common.Hash     `json:"previousValue"`
+	NewValue    common.Hash     `json:"newValue"`
+}
+
+// StorageHistoryResult is the result of erigon_getStorageHistory
+type StorageHistoryResult struct {
+	Changes []StorageChange `json:"changes"`
+	Next    *hexutil.Uint64 `json:"next,omitempty"` // opaque position to pass as `after` for the next page, nil if there are no more changes
+}
+
+// GetStorageHistory implements erigon_getStorageHistory. Returns changes of the storage slot within the block range
+// (both inclusive) in ascending order, read from the storage history.
+func (api *ErigonImpl) GetStorageHistory(ctx context.Context, addr common.Address, slot common.Hash, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage) (*StorageHistoryResult, error) {
+	pageSize, err := page.size()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txNums := api.newTxNumBlocks(ctx, tx)
+	fromTxNum, toTxNum, err := txNums.rangeOf(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && page.After != nil {
+		fromTxNum = max(fromTxNum, uint64(*page.After)+1)
+	}
+
+	res := &StorageHistoryResult{Changes: []StorageChange{}}
+	if fromTxNum >= toTxNum {
+		return res, nil
+	}
+	key := append(addr.Bytes(), slot.Bytes()...)
+	it, err := tx.IndexRange(kv.StorageHistoryIdx, key, int(fromTxNum), int(toTxNum), order.Asc, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var lastTxNum uint64
+	for it.HasNext() {
+		txNum, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Changes) == pageSize {
+			next := hexutil.Uint64(lastTxNum)
+			res.Next = &next
+			break
+		}
+		prev, _, err := tx.GetAsOf(kv.StorageDomain, key, txNum)
+		if err != nil {
+			return nil, err
+		}
+		value, _, err := tx.GetAsOf(kv.StorageDomain, key, txNum+1)
+		if err != nil {
+			return nil, err
+		}
+		blockNum, txIndex, err := txNums.position(txNum)
+		if err != nil {
+			return nil, err
+		}
+		res.Changes = append(res.Changes, StorageChange{
+			BlockNumber: hexutil.Uint64(blockNum),
+			TxIndex:     txIndex,
+			PrevValue:   common.BytesToHash(prev),
+			NewValue:    common.BytesToHash(value),
+		})
+		lastTxNum = txNum
+	}
+	return res, nil
+}
+
+// AccountState is balance and nonce of the account at the end of the block, or after the transaction which changed them
+type AccountState struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	TxIndex     *hexutil.Uint64 `json:"transactionIndex,omitempty"` // set only for changes, nil for system transactions
+	Balance     *hexutil.Big    `json:"balance"`
+	Nonce       hexutil.Uint64  `json:"nonce"`
+}
+
+// AccountHistoryResult is the result of erigon_getAccountHistory
+type AccountHistoryResult struct {
+	States []AccountState  `json:"states"`
+	Next   *hexutil.Uint64 `json:"next,omitempty"` // opaque position to pass as `after` for the next page, nil if there are no more states
+}
+
+// GetAccountHistory implements erigon_getAccountHistory. Returns balance and nonce of the account within the block range
+// (both inclusive) in ascending order, read from the accounts history: sampled at the end of every `stride`-th block
+// starting from `fromBlock`, or after every change of balance or nonce if `stride` is not set or zero.
+func (api *ErigonImpl) GetAccountHistory(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, stride *hexutil.Uint64, page *HistoryPage) (*AccountHistoryResult, error) {
+	pageSize, err := page.size()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txNums := api.newTxNumBlocks(ctx, tx)
+	if stride != nil && *stride > 0 {
+		return accountHistorySampled(tx, txNums, addr, fromBlock, toBlock, uint64(*stride), page, pageSize)
+	}
+
+	fromTxNum, toTxNum, err := txNums.rangeOf(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && page.After != nil {
+		fromTxNum = max(fromTxNum, uint64(*page.After)+1)
+	}
+
+	res := &AccountHistoryResult{States: []AccountState{}}
+	if fromTxNum >= toTxNum {
+		return res, nil
+	}
+	it, err := tx.IndexRange(kv.AccountsHistoryIdx, addr[:], int(fromTxNum), int(toTxNum), order.Asc, kv.Unlim)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var lastTxNum uint64
+	for it.HasNext() {
+		txNum, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		prev, err := accountAsOf(tx, addr, txNum)
+		if err != nil {
+			return nil, err
+		}
+		acc, err := accountAsOf(tx, addr, txNum+1)
+		if err != nil {
+			return nil, err
+		}
+		if acc.Nonce == prev.Nonce && acc.Balance.Eq(&prev.Balance) {
+			continue // code or incarnation change
+		}
+		if len(res.States) == pageSize {
+			next := hexutil.Uint64(lastTxNum)
+			res.Next = &next
+			break
+		}
+		blockNum, txIndex, err := txNums.position(txNum)
+		if err != nil {
+			return nil, err
+		}
+		res.States = append(res.States, AccountState{
+			BlockNumber: hexutil.Uint64(blockNum),
+			TxIndex:     txIndex,
+			Balance:     (*hexutil.Big)(acc.Balance.ToBig()),
+			Nonce:       hexutil.Uint64(acc.Nonce),
+		})
+		lastTxNum = txNum
+	}
+	return res, nil
+}
+
+// accountHistorySampled returns account at the end of every `stride`-th block of the range, `next` is the last sampled block
+func accountHistorySampled(tx kv.TemporalTx, txNums *txNumBlocks, addr common.Address, fromBlock, toBlock rpc.BlockNumber, stride uint64, page *HistoryPage, pageSize int) (*AccountHistoryResult, error) {
+	res := &AccountHistoryResult{States: []AccountState{}}
+	from, to, ok, err := txNums.blockRange(fromBlock, toBlock)
+	if err != nil || !ok {
+		return res, err
+	}
+	if page != nil && page.After != nil {
+		after := uint64(*page.After)
+		if after >= to {
+			return res, nil
+		}
+		if after >= from {
+			from += (after-from)/stride*stride + stride // keep samples aligned to `fromBlock`
+		}
+	}
+
+	for blockNum := from; blockNum <= to; blockNum += stride {
+		if len(res.States) == pageSize {
+			next := res.States[len(res.States)-1].BlockNumber
+			res.Next = &next
+			break
+		}
+		maxTxNum, err := txNums.reader.Max(tx, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		acc, err := accountAsOf(tx, addr, maxTxNum+1)
+		if err != nil {
+			return nil, err
+		}
+		res.States = append(res.States, AccountState{
+			BlockNumber: hexutil.Uint64(blockNum),
+			Balance:     (*hexutil.Big)(acc.Balance.ToBig()),
+			Nonce:       hexutil.Uint64(acc.Nonce),
+		})
+	}
+	return res, nil
+}
+
+// accountAsOf returns the account before transaction `txNum`, empty account if it doesn't exist
+func accountAsOf(tx kv.TemporalTx, addr common.Address, txNum uint64) (*accounts.Account, error) {
+	var acc accounts.Account
+	v, _, err := tx.GetAsOf(kv.AccountsDomain, addr[:], txNum)
+	if err != nil || len(v) == 0 {
+		return &acc, err
+	}
+	if err := accounts.DeserialiseV3(&acc, v); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// CodeChange is a single change of the account code: creation, self-destruct or EIP-7702 delegation
+type CodeChange struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	TxIndex     *hexutil.Uint64 `json:"transactionIndex"` // nil if the change is made by a system transaction
+	CodeHash    common.Hash     `json:"codeHash"`         // empty code hash if the code is deleted
+	CodeSize    hexutil.Uint64  `json:"codeSize"`
+}
+
+// CodeHistoryResult is the result of erigon_getCodeHistory
+type CodeHistoryResult struct {
+	Changes []CodeChange    `json:"changes"`
+	Next    *hexutil.Uint64 `json:"next,omitempty"` // opaque position to pass as `after` for the next page, nil if there are no more changes
+}
+
+// GetCodeHistory implements erigon_getCodeHistory. Returns changes of the account code within the block range
+// (both inclusive) in ascending order, read from the code history. Contract created and self-destructed in the same
+// transaction never had code, see erigon_getContractLifecycle.
+func (api *ErigonImpl) GetCodeHistory(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber, page *HistoryPage) (*CodeHistoryResult, error) {
+	pageSize, err := page.size()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txNums := api.newTxNumBlocks(ctx, tx)
+	fromTxNum, toTxNum, err := txNums.rangeOf(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && page.After != nil {
+		fromTxNum = max(fromTxNum, uint64(*page.After)+1)
+	}
+
+	res := &CodeHistoryResult{Changes: []CodeChange{}}
+	if fromTxNum >= toTxNum {
+		return res, nil
+	}
+	it, err := tx.IndexRange(kv.CodeHistoryIdx, addr[:], int(fromTxNum), int(toTxNum), order.Asc, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var lastTxNum uint64
+	for it.HasNext() {
+		txNum, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Changes) == pageSize {
+			next := hexutil.Uint64(lastTxNum)
+			res.Next = &next
+			break
+		}
+		code, _, err := tx.GetAsOf(kv.CodeDomain, addr[:], txNum+1)
+		if err != nil {
+			return nil, err
+		}
+		blockNum, txIndex, err := txNums.position(txNum)
+		if err != nil {
+			return nil, err
+		}
+		res.Changes = append(res.Changes, CodeChange{
+			BlockNumber: hexutil.Uint64(blockNum),
+			TxIndex:     txIndex,
+			CodeHash:    crypto.Keccak256Hash(code),
+			CodeSize:    hexutil.Uint64(len(code)),
+		})
+		lastTxNum = txNum
+	}
+	return res, nil
+}
+
+// txNumBlocks maps txNums to blocks, remembering the last found block - history is read in ascending order,
+// consecutive changes are often in the same block
+type txNumBlocks struct {
+	tx     kv.TemporalTx
+	reader rawdbv3.TxNumsReader
+
+	found              bool
+	blockNum           uint64
+	minTxNum, maxTxNum uint64
+}
+
+func (api *ErigonImpl) newTxNumBlocks(ctx context.Context, tx kv.TemporalTx) *txNumBlocks {
+	return &txNumBlocks{
+		tx:     tx,
+		reader: rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader)),
+	}
+}
+
+// blockRange resolves the blocks range, both blocks inclusive. Negative block numbers (latest, pending, etc.) mean
+// the latest executed block. Returns false if the range is empty.
+func (b *txNumBlocks) blockRange(fromBlock, toBlock rpc.BlockNumber) (from, to uint64, ok bool, err error) {
+	latest, err := rpchelper.GetLatestBlockNumber(b.tx)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	from, to = latest, latest
+	if fromBlock >= 0 {
+		from = uint64(fromBlock)
+	}
+	if toBlock >= 0 {
+		to = min(uint64(toBlock), latest)
+	}
+	return from, to, from <= to, nil
+}
+
+// rangeOf returns txNums [from, to) of the blocks range, see blockRange
+func (b *txNumBlocks) rangeOf(fromBlock, toBlock rpc.BlockNumber) (fromTxNum, toTxNum uint64, err error) {
+	from, to, ok, err := b.blockRange(fromBlock, toBlock)
+	if err != nil || !ok {
+		return 0, 0, err
+	}
+	if fromTxNum, err = b.reader.Min(b.tx, from); err != nil {
+		return 0, 0, err
+	}
+	if toTxNum, err = b.reader.Max(b.tx, to); err != nil {
+		return 0, 0, err
+	}
+	return fromTxNum, toTxNum + 1, nil
+}
+
+// position returns the block of transaction `txNum` and its index in the block, nil for system transactions
+func (b *txNumBlocks) position(txNum uint64) (blockNum uint64, txIndex *hexutil.Uint64, err error) {
+	if !b.found || txNum < b.minTxNum || txNum > b.maxTxNum {
+		ok, blockNum, err := b.reader.FindBlockNum(b.tx, txNum)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !ok {
+			return 0, nil, fmt.Errorf("block not found by txnID=%d", txNum)
+		}
+		if b.minTxNum, err = b.reader.Min(b.tx, blockNum); err != nil {
+			return 0, nil, err
+		}
+		if b.maxTxNum, err = b.reader.Max(b.tx, blockNum); err != nil {
+			return 0, nil, err
+		}
+		b.found, b.blockNum = true, blockNum
+	}
+	if txNum == b.minTxNum || txNum == b.maxTxNum {
+		return b.blockNum, nil, nil // block-begin and block-end system transactions
+	}
+	idx := hexutil.Uint64(txNum - b.minTxNum - 1)
+	return b.blockNum, &idx, nil
+}
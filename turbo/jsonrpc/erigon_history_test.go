@@ -0,0 +1,236 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/contracts"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestGetStorageHistory(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	token := libcommon.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	holderKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	require.NoError(t, err)
+	holder := crypto.PubkeyToAddress(holderKey.PublicKey)
+	// balanceOf[holder]: minted 10 in block 4, 3 transferred out in block 5
+	balanceSlot := crypto.Keccak256Hash(libcommon.LeftPadBytes(holder[:], 32), libcommon.LeftPadBytes([]byte{1}, 32))
+	txIndex := hexutil.Uint64(0)
+	minted := StorageChange{BlockNumber: 4, TxIndex: &txIndex, PrevValue: libcommon.Hash{}, NewValue: libcommon.BigToHash(big.NewInt(10))}
+	transferred := StorageChange{BlockNumber: 5, TxIndex: &txIndex, PrevValue: libcommon.BigToHash(big.NewInt(10)), NewValue: libcommon.BigToHash(big.NewInt(7))}
+
+	t.Run("all", func(t *testing.T) {
+		res, err := api.GetStorageHistory(m.Ctx, token, balanceSlot, 0, rpc.LatestBlockNumber, nil)
+		require.NoError(t, err)
+		require.Equal(t, []StorageChange{minted, transferred}, res.Changes)
+		require.Nil(t, res.Next)
+	})
+	t.Run("block range", func(t *testing.T) {
+		res, err := api.GetStorageHistory(m.Ctx, token, balanceSlot, 5, 5, nil)
+		require.NoError(t, err)
+		require.Equal(t, []StorageChange{transferred}, res.Changes)
+
+		res, err = api.GetStorageHistory(m.Ctx, token, balanceSlot, 6, rpc.LatestBlockNumber, nil)
+		require.NoError(t, err)
+		require.Empty(t, res.Changes)
+	})
+	t.Run("paginated", func(t *testing.T) {
+		pageSize := hexutil.Uint64(1)
+		res, err := api.GetStorageHistory(m.Ctx, token, balanceSlot, 0, rpc.LatestBlockNumber, &HistoryPage{PageSize: &pageSize})
+		require.NoError(t, err)
+		require.Equal(t, []StorageChange{minted}, res.Changes)
+		require.NotNil(t, res.Next)
+
+		res, err = api.GetStorageHistory(m.Ctx, token, balanceSlot, 0, rpc.LatestBlockNumber, &HistoryPage{PageSize: &pageSize, After: res.Next})
+		require.NoError(t, err)
+		require.Equal(t, []StorageChange{transferred}, res.Changes)
+		require.Nil(t, res.Next)
+
+		pageSize = historyMaxPageSize + 1
+		_, err = api.GetStorageHistory(m.Ctx, token, balanceSlot, 0, rpc.LatestBlockNumber, &HistoryPage{PageSize: &pageSize})
+		require.Error(t, err)
+	})
+}
+
+func TestGetAccountHistory(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	// holder got genesis balance, sent 1 transaction in block 5, 32 in block 7 and 1 in block 8
+	holderKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	require.NoError(t, err)
+	holder := crypto.PubkeyToAddress(holderKey.PublicKey)
+	blocksAndNonces := func(states []AccountState) (blocks, nonces []uint64) {
+		for _, s := range states {
+			blocks, nonces = append(blocks, uint64(s.BlockNumber)), append(nonces, uint64(s.Nonce))
+		}
+		return blocks, nonces
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		res, err := api.GetAccountHistory(m.Ctx, holder, 0, rpc.LatestBlockNumber, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, res.States, 35)
+		require.Nil(t, res.Next)
+		require.Equal(t, AccountState{BlockNumber: 0, Balance: (*hexutil.Big)(big.NewInt(3e17)), Nonce: 0}, res.States[0])
+		require.Equal(t, hexutil.Uint64(5), res.States[1].BlockNumber)
+		require.Equal(t, hexutil.Uint64(0), *res.States[1].TxIndex)
+		require.Equal(t, hexutil.Uint64(1), res.States[1].Nonce)
+		require.Equal(t, -1, res.States[1].Balance.ToInt().Cmp(big.NewInt(3e17)))
+		require.Equal(t, hexutil.Uint64(8), res.States[34].BlockNumber)
+		require.Equal(t, hexutil.Uint64(34), res.States[34].Nonce)
+
+		res, err = api.GetAccountHistory(m.Ctx, holder, 6, 6, nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, res.States)
+	})
+	t.Run("changes paginated", func(t *testing.T) {
+		pageSize := hexutil.Uint64(2)
+		res, err := api.GetAccountHistory(m.Ctx, holder, 0, rpc.LatestBlockNumber, nil, &HistoryPage{PageSize: &pageSize})
+		require.NoError(t, err)
+		blocks, nonces := blocksAndNonces(res.States)
+		require.Equal(t, []uint64{0, 5}, blocks)
+		require.Equal(t, []uint64{0, 1}, nonces)
+		require.NotNil(t, res.Next)
+
+		res, err = api.GetAccountHistory(m.Ctx, holder, 0, rpc.LatestBlockNumber, nil, &HistoryPage{PageSize: &pageSize, After: res.Next})
+		require.NoError(t, err)
+		blocks, nonces = blocksAndNonces(res.States)
+		require.Equal(t, []uint64{7, 7}, blocks)
+		require.Equal(t, []uint64{2, 3}, nonces)
+	})
+	t.Run("sampled", func(t *testing.T) {
+		stride := hexutil.Uint64(3)
+		res, err := api.GetAccountHistory(m.Ctx, holder, 1, rpc.LatestBlockNumber, &stride, nil)
+		require.NoError(t, err)
+		blocks, nonces := blocksAndNonces(res.States)
+		require.Equal(t, []uint64{1, 4, 7, 10}, blocks)
+		require.Equal(t, []uint64{0, 0, 33, 34}, nonces)
+		require.Nil(t, res.States[0].TxIndex)
+		require.Nil(t, res.Next)
+
+		pageSize := hexutil.Uint64(3)
+		res, err = api.GetAccountHistory(m.Ctx, holder, 1, rpc.LatestBlockNumber, &stride, &HistoryPage{PageSize: &pageSize})
+		require.NoError(t, err)
+		blocks, _ = blocksAndNonces(res.States)
+		require.Equal(t, []uint64{1, 4, 7}, blocks)
+		require.NotNil(t, res.Next)
+
+		res, err = api.GetAccountHistory(m.Ctx, holder, 1, rpc.LatestBlockNumber, &stride, &HistoryPage{PageSize: &pageSize, After: res.Next})
+		require.NoError(t, err)
+		blocks, _ = blocksAndNonces(res.States)
+		require.Equal(t, []uint64{10}, blocks)
+		require.Nil(t, res.Next)
+	})
+}
+
+func TestGetCodeHistory(t *testing.T) {
+	var (
+		signer      = types.LatestSignerForChainID(nil)
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{bankAddress: {Balance: big.NewInt(1e18)}},
+		}
+		deployInput = append(crypto.Keccak256([]byte("deploy(uint256)"))[:4], make([]byte, 32)...)
+		// init code of the child, see contracts/poly.sol: deploys `PUSH1 <block number>; SELFDESTRUCT`
+		childInitCode = hexutil.MustDecode("0x60606000534360015360ff60025360036000f3")
+	)
+	m := mock.MockWithGenesis(t, gspec, bankKey, false)
+
+	var factoryAddr, childAddr libcommon.Address
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 4, func(i int, block *core.BlockGen) {
+		nonce := block.TxNonce(bankAddress)
+		var txn types.Transaction
+		switch i {
+		case 0:
+			txn = types.NewContractCreation(nonce, new(uint256.Int), 1e6, new(uint256.Int), hexutil.MustDecode(contracts.PolyBin))
+			factoryAddr = crypto.CreateAddress(bankAddress, nonce)
+			childAddr = crypto.CreateAddress2(factoryAddr, [32]byte{}, crypto.Keccak256(childInitCode))
+		case 1, 3: // deploy the child, its code depends on the block number
+			txn = types.NewTransaction(nonce, factoryAddr, new(uint256.Int), 1e6, new(uint256.Int), deployInput)
+		case 2: // child self-destructs when called
+			txn = types.NewTransaction(nonce, childAddr, new(uint256.Int), 1e6, new(uint256.Int), nil)
+		}
+		signed, err := types.SignTx(txn, *signer, bankKey)
+		require.NoError(t, err)
+		block.AddTx(signed)
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	txIndex := hexutil.Uint64(0)
+	childCode := func(blockNum byte) CodeChange {
+		code := []byte{0x60, blockNum, 0xff}
+		return CodeChange{BlockNumber: hexutil.Uint64(blockNum), TxIndex: &txIndex, CodeHash: crypto.Keccak256Hash(code), CodeSize: 3}
+	}
+	all := []CodeChange{
+		childCode(2),
+		{BlockNumber: 3, TxIndex: &txIndex, CodeHash: crypto.Keccak256Hash(nil), CodeSize: 0},
+		childCode(4),
+	}
+	t.Run("all", func(t *testing.T) {
+		res, err := api.GetCodeHistory(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, nil)
+		require.NoError(t, err)
+		require.Equal(t, all, res.Changes)
+		require.Nil(t, res.Next)
+
+		res, err = api.GetCodeHistory(m.Ctx, bankAddress, 0, rpc.LatestBlockNumber, nil)
+		require.NoError(t, err)
+		require.Empty(t, res.Changes)
+	})
+	t.Run("block range", func(t *testing.T) {
+		res, err := api.GetCodeHistory(m.Ctx, childAddr, 3, 3, nil)
+		require.NoError(t, err)
+		require.Equal(t, all[1:2], res.Changes)
+		require.Nil(t, res.Next)
+	})
+	t.Run("pages", func(t *testing.T) {
+		pageSize := hexutil.Uint64(2)
+		res, err := api.GetCodeHistory(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, &HistoryPage{PageSize: &pageSize})
+		require.NoError(t, err)
+		require.Equal(t, all[:2], res.Changes)
+		require.NotNil(t, res.Next)
+
+		res, err = api.GetCodeHistory(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, &HistoryPage{PageSize: &pageSize, After: res.Next})
+		require.NoError(t, err)
+		require.Equal(t, all[2:], res.Changes)
+		require.Nil(t, res.Next)
+
+		pageSize = historyMaxPageSize + 1
+		_, err = api.GetCodeHistory(m.Ctx, childAddr, 0, rpc.LatestBlockNumber, &HistoryPage{PageSize: &pageSize})
+		require.Error(t, err)
+	})
+}
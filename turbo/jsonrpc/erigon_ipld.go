@@ -0,0 +1,216 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/rlp"
+	"github.com/erigontech/erigon-lib/trie"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/ipld"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+// maxCARExportBlocks - max amount of blocks exported by one erigon_exportCAR call
+const maxCARExportBlocks = 1000
+
+var errCARExportDisabled = errors.New("CAR export is disabled, start the node with --rpc.ipld.dir")
+
+// CARExport is the result of erigon_exportCAR
+type CARExport struct {
+	File   string   `json:"file"`
+	Roots  []string `json:"roots"`  // CIDs of the headers
+	Blocks uint64   `json:"blocks"` // IPLD blocks in the file
+	Bytes  uint64   `json:"bytes"`
+}
+
+// ExportCAR implements erigon_exportCAR. Writes the headers, uncles, transactions, receipts and the nodes of the
+// transactions and receipts tries of the blocks to <ipld dir>/<from>-<to>.car as IPLD blocks, rooted at the headers.
+// The nodes of the state and storage tries on the paths to the accounts and slots of `state` are added as well,
+// as of the last block, which must be the latest one then. Withdrawals have no IPLD codec and are not exported.
+func (api *ErigonImpl) ExportCAR(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, state map[common.Address][]common.Hash) (*CARExport, error) {
+	if api.ipldDir == "" {
+		return nil, errCARExportDisabled
+	}
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	from, _, _, err := rpchelper.GetBlockNumber(ctx, rpc.BlockNumberOrHashWithNumber(fromBlock), tx, api._blockReader, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(ctx, rpc.BlockNumberOrHashWithNumber(toBlock), tx, api._blockReader, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("fromBlock %d is after toBlock %d", from, to)
+	}
+	if to-from >= maxCARExportBlocks {
+		return nil, fmt.Errorf("too many blocks requested: %d, max %d", to-from+1, maxCARExportBlocks)
+	}
+
+	res := &CARExport{File: filepath.Join(api.ipldDir, fmt.Sprintf("%d-%d.car", from, to))}
+	roots := make([]ipld.CID, 0, to-from+1)
+	for blockNum := from; blockNum <= to; blockNum++ {
+		hash, ok, err := api._blockReader.CanonicalHash(ctx, tx, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("block %d not found", blockNum)
+		}
+		root := ipld.NewCID(ipld.CodecHeader, hash)
+		roots = append(roots, root)
+		res.Roots = append(res.Roots, root.String())
+	}
+
+	if err := os.MkdirAll(api.ipldDir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(res.File + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defer os.Remove(res.File + ".tmp") // if not renamed
+	car, err := ipld.NewCARWriter(f, roots)
+	if err != nil {
+		return nil, err
+	}
+	for blockNum := from; blockNum <= to; blockNum++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", blockNum)
+		}
+		if err := putEncoded(car, ipld.CodecHeader, block.Header()); err != nil {
+			return nil, err
+		}
+		if err := putEncoded(car, ipld.CodecUncles, block.Uncles()); err != nil {
+			return nil, err
+		}
+		if err := putList(car, ipld.CodecTx, ipld.CodecTxTrie, block.Transactions(), block.TxHash()); err != nil {
+			return nil, fmt.Errorf("transactions of block %d: %w", blockNum, err)
+		}
+		receipts, err := api.getReceipts(ctx, tx, block)
+		if err != nil {
+			return nil, err
+		}
+		if err := putList(car, ipld.CodecReceipt, ipld.CodecReceiptTrie, receipts, block.ReceiptHash()); err != nil {
+			return nil, fmt.Errorf("receipts of block %d: %w", blockNum, err)
+		}
+	}
+	for addr, keys := range state {
+		storageKeys := make([]hexutil.Bytes, len(keys))
+		for i, k := range keys {
+			storageKeys[i] = k.Bytes()
+		}
+		proof, err := api.eth.GetProof(ctx, addr, storageKeys, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(to)))
+		if err != nil {
+			return nil, fmt.Errorf("state of %x: %w", addr, err)
+		}
+		if err := putProof(car, ipld.CodecStateTrie, proof.AccountProof); err != nil {
+			return nil, err
+		}
+		for _, sp := range proof.StorageProof {
+			if err := putProof(car, ipld.CodecStorageTrie, sp.Proof); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := car.Flush(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(res.File+".tmp", res.File); err != nil {
+		return nil, err
+	}
+	res.Blocks, res.Bytes = car.Blocks, car.Bytes
+	return res, nil
+}
+
+func putEncoded(car *ipld.CARWriter, codec uint64, v any) error {
+	data, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		return err
+	}
+	return car.Put(ipld.Sum(codec, data), data)
+}
+
+// putList writes the items of the list and the nodes of their trie, checking the trie root against the header
+func putList(car *ipld.CARWriter, codec, trieCodec uint64, list types.DerivableList, root common.Hash) error {
+	t := trie.New(trie.EmptyRoot)
+	keys := make([][]byte, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		var buf bytes.Buffer
+		list.EncodeIndex(i, &buf)
+		if err := car.Put(ipld.Sum(codec, buf.Bytes()), buf.Bytes()); err != nil {
+			return err
+		}
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			return err
+		}
+		t.Update(key, buf.Bytes())
+		keys = append(keys, key)
+	}
+	if h := t.Hash(); h != root {
+		return fmt.Errorf("trie root %x doesn't match the header %x", h, root)
+	}
+	nodes, err := ipld.TrieNodes(t, keys, false)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := car.Put(ipld.Sum(trieCodec, node), node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putProof(car *ipld.CARWriter, codec uint64, proof []hexutil.Bytes) error {
+	nodes := make([][]byte, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	for _, node := range ipld.HashedNodes(nodes) {
+		if err := car.Put(ipld.Sum(codec, node), node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
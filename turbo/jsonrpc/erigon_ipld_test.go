@@ -0,0 +1,58 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/turbo/jsonrpc/ipld"
+)
+
+func TestErigonExportCAR(t *testing.T) {
+	m, _, contractAddr := chainWithDeployedContract(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	api.eth = NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+	ctx := context.Background()
+
+	_, err := api.ExportCAR(ctx, 0, 3, nil)
+	require.ErrorIs(t, err, errCARExportDisabled)
+
+	api.ipldDir = t.TempDir()
+	res, err := api.ExportCAR(ctx, 1, 3, map[common.Address][]common.Hash{contractAddr: {{31: 4}}})
+	require.NoError(t, err)
+	require.Len(t, res.Roots, 3)
+
+	tx, err := m.DB.BeginTemporalRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	hash, _, err := m.BlockReader.CanonicalHash(ctx, tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, ipld.NewCID(ipld.CodecHeader, hash).String(), res.Roots[0])
+
+	// 3 x (header, transaction, transactions trie, receipt, receipts trie), the empty uncles list written once,
+	// state and storage trie nodes
+	require.Greater(t, res.Blocks, uint64(3*5+1))
+	info, err := os.Stat(res.File)
+	require.NoError(t, err)
+	require.Equal(t, uint64(info.Size()), res.Bytes)
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+const (
+	LogsStrategyNone              = "none"                // the block hash of the filter is unknown, nothing is read
+	LogsStrategyFullScan          = "full-scan"           // no addresses and topics, every transaction of the range is executed
+	LogsStrategyAddressIndex      = "address-index"       // union of the address index of the addresses
+	LogsStrategyTopicIndex        = "topic-index"         // intersection by topic positions of unions of the topic index
+	LogsStrategyAddressTopicIndex = "address-topic-index" // intersection of the address and topic strategies
+)
+
+const (
+	maxExplainKeys       = 64     // max amount of addresses and topics of the explained filter
+	maxExplainIndexCount = 16_384 // max amount of index entries counted per key, the rest of the range is extrapolated
+	txExecutionCost      = 100    // cost of the execution of a transaction to get its logs, in index entries read
+)
+
+// LogsIndexLookup is a read of the index of one address or topic
+type LogsIndexLookup struct {
+	Index     string         `json:"index"`              // "address" or "topic"
+	Position  *int           `json:"position,omitempty"` // of the topic
+	Key       hexutil.Bytes  `json:"key"`
+	Matches   hexutil.Uint64 `json:"matches"`             // transactions of the range in the index
+	Truncated bool           `json:"truncated,omitempty"` // counting stopped at maxExplainIndexCount, Matches is extrapolated
+}
+
+// GetLogsPlan is the result of erigon_explainGetLogs
+type GetLogsPlan struct {
+	FromBlock       hexutil.Uint64    `json:"fromBlock"`
+	ToBlock         hexutil.Uint64    `json:"toBlock"`
+	Strategy        string            `json:"strategy"` // LogsStrategyFullScan, LogsStrategyAddressIndex, ...
+	Lookups         []LogsIndexLookup `json:"lookups"`
+	RangeTxs        hexutil.Uint64    `json:"rangeTxs"`        // transactions of the block range, including system ones
+	EstimatedTxs    hexutil.Uint64    `json:"estimatedTxs"`    // transactions to execute, an upper bound for index strategies unless Truncated
+	EstimatedBlocks hexutil.Uint64    `json:"estimatedBlocks"` // blocks with matching transactions, an upper bound unless Truncated
+	// Cost is the expected cost of the query: index entries read plus txExecutionCost per executed transaction
+	Cost hexutil.Uint64 `json:"cost"`
+	// Truncated is set if some lookup is truncated: the estimates are built from its extrapolated matches and are
+	// neither upper nor lower bounds
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ExplainGetLogs implements erigon_explainGetLogs. Returns how erigon_getLogs and eth_getLogs would run the filter:
+// the index strategy, estimated amount of matching transactions and blocks, and the expected cost, without running it.
+// Only the index entries of the filter keys in the range are counted: up to maxExplainIndexCount per key, the matches
+// of the rest of the range are extrapolated from the counted part. The filter may have up to maxExplainKeys keys.
+func (api *ErigonImpl) ExplainGetLogs(ctx context.Context, crit filters.FilterCriteria) (*GetLogsPlan, error) {
+	keys := len(crit.Addresses)
+	for _, sub := range crit.Topics {
+		keys += len(sub)
+	}
+	if keys > maxExplainKeys {
+		return nil, fmt.Errorf("too many addresses and topics to explain: %d, max %d", keys, maxExplainKeys)
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	begin, end, found, err := api.logsBlockRange(ctx, tx, crit)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &GetLogsPlan{Strategy: LogsStrategyNone, Lookups: []LogsIndexLookup{}}, nil
+	}
+
+	//[from,to)
+	txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+	var fromTxNum, toTxNum uint64
+	if begin > 0 {
+		if fromTxNum, err = txNumsReader.Min(tx, begin); err != nil {
+			return nil, err
+		}
+	}
+	if toTxNum, err = txNumsReader.Max(tx, end); err != nil {
+		return nil, err
+	}
+	toTxNum++
+
+	plan := &GetLogsPlan{FromBlock: hexutil.Uint64(begin), ToBlock: hexutil.Uint64(end), Lookups: []LogsIndexLookup{}}
+	if toTxNum > fromTxNum {
+		plan.RangeTxs = hexutil.Uint64(toTxNum - fromTxNum)
+	}
+	lookup := func(idx kv.InvertedIdx, index string, position *int, key []byte) (uint64, error) {
+		n, truncated, err := countIndexRange(ctx, tx, idx, key, fromTxNum, toTxNum, maxExplainIndexCount)
+		if err != nil {
+			return 0, err
+		}
+		plan.Lookups = append(plan.Lookups, LogsIndexLookup{Index: index, Position: position, Key: key, Matches: hexutil.Uint64(n), Truncated: truncated})
+		plan.Truncated = plan.Truncated || truncated
+		plan.Cost += hexutil.Uint64(n)
+		return n, nil
+	}
+
+	estimate := uint64(plan.RangeTxs)
+	if len(crit.Addresses) > 0 {
+		var union uint64
+		for _, addr := range crit.Addresses {
+			n, err := lookup(kv.LogAddrIdx, "address", nil, addr.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			union += n
+		}
+		estimate = min(estimate, union)
+		plan.Strategy = LogsStrategyAddressIndex
+	}
+	for i, sub := range crit.Topics {
+		if len(sub) == 0 {
+			continue
+		}
+		var union uint64
+		for _, topic := range sub {
+			n, err := lookup(kv.LogTopicIdx, "topic", &i, topic.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			union += n
+		}
+		estimate = min(estimate, union)
+		if plan.Strategy == LogsStrategyAddressIndex {
+			plan.Strategy = LogsStrategyAddressTopicIndex
+		} else if plan.Strategy == "" {
+			plan.Strategy = LogsStrategyTopicIndex
+		}
+	}
+	if plan.Strategy == "" {
+		plan.Strategy = LogsStrategyFullScan
+	}
+	plan.EstimatedTxs = hexutil.Uint64(estimate)
+	plan.EstimatedBlocks = hexutil.Uint64(min(estimate, end-begin+1))
+	plan.Cost += hexutil.Uint64(estimate * txExecutionCost)
+	return plan, nil
+}
+
+// countIndexRange counts the index entries of the key in [from, to). If there are more than `limit` of them, counting
+// stops and the count is extrapolated from the part of the range covered by the first `limit` entries, truncated is true.
+func countIndexRange(ctx context.Context, tx kv.TemporalTx, idx kv.InvertedIdx, key []byte, from, to uint64, limit int) (n uint64, truncated bool, err error) {
+	it, err := tx.IndexRange(idx, key, int(from), int(to), order.Asc, limit+1)
+	if err != nil {
+		return 0, false, err
+	}
+	defer it.Close()
+	var last uint64
+	for it.HasNext() {
+		if n == uint64(limit) {
+			return extrapolateCount(n, from, last+1, to), true, nil
+		}
+		if last, err = it.Next(); err != nil {
+			return 0, false, err
+		}
+		if n++; n%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, false, err
+			}
+		}
+	}
+	return n, false, nil
+}
+
+// extrapolateCount scales `n` entries found in txNums [from, covered) to the range [from, to)
+func extrapolateCount(n, from, covered, to uint64) uint64 {
+	if covered <= from || covered >= to {
+		return n
+	}
+	return n * (to - from) / (covered - from)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/rawdbv3"
+	"github.com/erigontech/erigon-lib/trie"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// l2ToL1MessagePasser is the OP-stack predeploy recording withdrawals, its storage root is committed to by the output root
+var l2ToL1MessagePasser = common.HexToAddress("0x4200000000000000000000000000000000000016")
+
+// OutputRootResult is the result of erigon_outputAtBlock
+type OutputRootResult struct {
+	Version               common.Hash    `json:"version"`
+	OutputRoot            common.Hash    `json:"outputRoot"`
+	BlockNumber           hexutil.Uint64 `json:"blockNumber"`
+	BlockHash             common.Hash    `json:"blockHash"`
+	StateRoot             common.Hash    `json:"stateRoot"`
+	WithdrawalStorageRoot common.Hash    `json:"withdrawalStorageRoot"`
+}
+
+// OutputAtBlock implements erigon_outputAtBlock. Computes the OP-stack L2 output root of the block (version 0:
+// keccak256(version || state root || storage root of L2ToL1MessagePasser || block hash)), so that rollup nodes and
+// proposers can use Erigon as the execution client without computing it from eth_getProof.
+// The storage root is computed from the storage of the message passer as of the block, for any block with history.
+// Fails on the chains without the message passer predeploy, which are not OP-stack chains.
+func (api *ErigonImpl) OutputAtBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*OutputRootResult, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNum, hash, _, err := rpchelper.GetCanonicalBlockNumber(ctx, blockNrOrHash, tx, api._blockReader, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if err = api.BaseAPI.checkPruneHistory(ctx, tx, blockNum); err != nil {
+		return nil, err
+	}
+	header, err := api._blockReader.Header(ctx, tx, hash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+
+	txNumsReader := rawdbv3.TxNums.WithCustomReadTxNumFunc(freezeblocks.ReadTxNumFuncFromBlockReader(ctx, api._blockReader))
+	maxTxNum, err := txNumsReader.Max(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	code, _, err := tx.GetAsOf(kv.CodeDomain, l2ToL1MessagePasser[:], maxTxNum+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("not an OP-stack chain: no L2ToL1MessagePasser code at %x as of block %d", l2ToL1MessagePasser, blockNum)
+	}
+	withdrawalRoot, err := storageRootAsOf(ctx, tx, l2ToL1MessagePasser, maxTxNum+1)
+	if err != nil {
+		return nil, err
+	}
+	return &OutputRootResult{
+		OutputRoot:            outputRootV0(header.Root, withdrawalRoot, hash),
+		BlockNumber:           hexutil.Uint64(blockNum),
+		BlockHash:             hash,
+		StateRoot:             header.Root,
+		WithdrawalStorageRoot: withdrawalRoot,
+	}, nil
+}
+
+// outputRootV0 is the version 0 output root, the version is 32 zero bytes
+func outputRootV0(stateRoot, withdrawalStorageRoot, blockHash common.Hash) common.Hash {
+	var version common.Hash
+	return crypto.Keccak256Hash(version[:], stateRoot[:], withdrawalStorageRoot[:], blockHash[:])
+}
+
+// storageRootAsOf computes the storage trie root of the account from its storage as of txNum
+func storageRootAsOf(ctx context.Context, tx kv.TemporalTx, addr common.Address, txNum uint64) (common.Hash, error) {
+	t := trie.New(common.Hash{})
+	to, _ := kv.NextSubtree(addr[:])
+	it, err := tx.RangeAsOf(kv.StorageDomain, addr[:], to, txNum, order.Asc, kv.Unlim) // unlim because need skip empty vals
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer it.Close()
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if len(v) == 0 {
+			continue // deleted
+		}
+		if err := ctx.Err(); err != nil {
+			return common.Hash{}, err
+		}
+		h, _ := common.HashData(k[length.Addr:])
+		t.Update(h[:], common.CopyBytes(v))
+	}
+	return t.Hash(), nil
+}
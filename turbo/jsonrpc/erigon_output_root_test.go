@@ -0,0 +1,70 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestOutputRootV0(t *testing.T) {
+	stateRoot, blockHash := libcommon.Hash{1}, libcommon.Hash{2}
+	withdrawalRoot := libcommon.HexToHash("0xfcbdb9e7191a6bc6efbe2e1903a50bd3c79312366db1e46acf7e94788c2b4c3e")
+	require.Equal(t, libcommon.HexToHash("0x59d01015fdcc174f50039df18a8dc3a11421df42839229ab1fb62ae86aa5681f"), outputRootV0(stateRoot, withdrawalRoot, blockHash))
+}
+
+func TestOutputAtBlock(t *testing.T) {
+	var (
+		bankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		bankAddress = crypto.PubkeyToAddress(bankKey.PublicKey)
+		gspec       = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				bankAddress: {Balance: big.NewInt(1e18)},
+				// the message passer predeploy with a single storage slot, 1 = 42
+				l2ToL1MessagePasser: {Balance: new(big.Int), Code: []byte{0x00}, Storage: map[libcommon.Hash]libcommon.Hash{{31: 1}: {31: 42}}},
+			},
+		}
+	)
+	m := mock.MockWithGenesis(t, gspec, bankKey, false)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	res, err := api.OutputAtBlock(context.Background(), rpc.BlockNumberOrHashWithNumber(0))
+	require.NoError(t, err)
+	require.Equal(t, m.Genesis.Hash(), res.BlockHash)
+	require.Equal(t, m.Genesis.Root(), res.StateRoot)
+	// storage root of the single leaf keccak256(slot 1) -> rlp(42)
+	require.Equal(t, libcommon.HexToHash("0xfcbdb9e7191a6bc6efbe2e1903a50bd3c79312366db1e46acf7e94788c2b4c3e"), res.WithdrawalStorageRoot)
+	require.Equal(t, outputRootV0(res.StateRoot, res.WithdrawalStorageRoot, res.BlockHash), res.OutputRoot)
+
+	t.Run("not an OP-stack chain", func(t *testing.T) {
+		m, _, _ := chainWithDeployedContract(t)
+		api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+		_, err := api.OutputAtBlock(context.Background(), rpc.BlockNumberOrHashWithNumber(1))
+		require.ErrorContains(t, err, "not an OP-stack chain")
+	})
+}
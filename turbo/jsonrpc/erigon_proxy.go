@@ -0,0 +1,228 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/types/accounts"
+	"github.com/erigontech/erigon/rpc"
+	ethapi2 "github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+const (
+	ProxyEIP1167       = "eip1167"        // minimal clone, the implementation is in the code
+	ProxyEIP1967       = "eip1967"        // implementation slot
+	ProxyEIP1967Beacon = "eip1967-beacon" // beacon slot, the implementation is returned by the beacon
+	ProxyEIP1822       = "eip1822"        // UUPS "PROXIABLE" slot
+)
+
+const (
+	maxProxyChainDepth = 8       // max amount of proxies resolved in a row
+	beaconCallGas      = 100_000 // gas of the beacon implementation() call
+)
+
+var (
+	// bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1)
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// bytes32(uint256(keccak256('eip1967.proxy.beacon')) - 1)
+	eip1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// keccak256("PROXIABLE")
+	eip1822ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+
+	// EIP-1167 runtime code is eip1167Prefix || implementation || eip1167Suffix
+	eip1167Prefix = common.FromHex("0x363d3d373d3d3d363d73")
+	eip1167Suffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+
+	beaconImplementationSelector = common.FromHex("0x5c60da1b") // implementation()
+)
+
+// ProxyHop is one proxy of the chain, delegating to Implementation
+type ProxyHop struct {
+	Proxy          common.Address  `json:"proxy"`
+	Type           string          `json:"type"` // ProxyEIP1167, ProxyEIP1967, ...
+	Beacon         *common.Address `json:"beacon,omitempty"`
+	Implementation common.Address  `json:"implementation"`
+}
+
+// ProxyResolution is the result of erigon_resolveProxy
+type ProxyResolution struct {
+	Chain []ProxyHop `json:"chain"` // from the requested address, empty if it's not a proxy
+	// Implementation is the end of the chain: the first address which is not a recognized proxy, nil if not a proxy
+	Implementation *common.Address `json:"implementation"`
+}
+
+// proxyReader is the part of the state reader used to detect proxies
+type proxyReader interface {
+	ReadAccountData(address common.Address) (*accounts.Account, error)
+	ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error)
+	ReadAccountCode(address common.Address, incarnation uint64) ([]byte, error)
+}
+
+// beaconCaller returns the implementation of the beacon, nil if the beacon doesn't return one
+type beaconCaller func(beacon common.Address) (*common.Address, error)
+
+// ResolveProxy implements erigon_resolveProxy. Follows standard proxies (EIP-1167 minimal clones, EIP-1967
+// implementation and beacon slots, EIP-1822 UUPS) from `addr` to the implementation, reading the slots and
+// code at the given block. Beacons are asked for the implementation by calling their implementation().
+func (api *ErigonImpl) ResolveProxy(ctx context.Context, addr common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*ProxyResolution, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+
+	callBeacon := func(beacon common.Address) (*common.Address, error) {
+		blockNum, hash, _, err := rpchelper.GetCanonicalBlockNumber(ctx, blockNrOrHash, tx, api._blockReader, api.filters)
+		if err != nil {
+			return nil, err
+		}
+		header, err := api._blockReader.Header(ctx, tx, hash, blockNum)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block %d not found", blockNum)
+		}
+		gas := hexutil.Uint64(beaconCallGas)
+		data := hexutil.Bytes(beaconImplementationSelector)
+		args := ethapi2.CallArgs{To: &beacon, Gas: &gas, Data: &data}
+		result, err := transactions.DoCall(ctx, api.engine(), args, tx, blockNrOrHash, header, nil, beaconCallGas, chainConfig, reader, api._blockReader, api.evmCallTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if result.Failed() || len(result.ReturnData) < 32 {
+			return nil, nil
+		}
+		return addressFromWord(result.ReturnData[:32]), nil
+	}
+	return resolveProxy(reader, callBeacon, addr)
+}
+
+func resolveProxy(reader proxyReader, callBeacon beaconCaller, addr common.Address) (*ProxyResolution, error) {
+	res := &ProxyResolution{Chain: []ProxyHop{}}
+	seen := map[common.Address]struct{}{addr: {}}
+	current := addr
+	for len(res.Chain) < maxProxyChainDepth {
+		hop, err := detectProxy(reader, callBeacon, current)
+		if err != nil {
+			return nil, err
+		}
+		if hop == nil {
+			break
+		}
+		res.Chain = append(res.Chain, *hop)
+		current = hop.Implementation
+		if _, ok := seen[current]; ok { // proxies delegating to each other
+			break
+		}
+		seen[current] = struct{}{}
+	}
+	if len(res.Chain) > 0 {
+		res.Implementation = &current
+	}
+	return res, nil
+}
+
+// detectProxy returns nil if the account is not a recognized proxy
+func detectProxy(reader proxyReader, callBeacon beaconCaller, addr common.Address) (*ProxyHop, error) {
+	acc, err := reader.ReadAccountData(addr)
+	if err != nil {
+		return nil, fmt.Errorf("cant get account %x: %w", addr, err)
+	}
+	if acc == nil || acc.IsEmptyCodeHash() {
+		return nil, nil
+	}
+	code, err := reader.ReadAccountCode(addr, acc.Incarnation)
+	if err != nil {
+		return nil, fmt.Errorf("cant get code of %x: %w", addr, err)
+	}
+	if len(code) == len(eip1167Prefix)+length.Addr+len(eip1167Suffix) &&
+		bytes.HasPrefix(code, eip1167Prefix) && bytes.HasSuffix(code, eip1167Suffix) {
+		return &ProxyHop{Proxy: addr, Type: ProxyEIP1167, Implementation: common.BytesToAddress(code[len(eip1167Prefix) : len(eip1167Prefix)+length.Addr])}, nil
+	}
+
+	readSlot := func(slot common.Hash) (*common.Address, error) {
+		v, err := reader.ReadAccountStorage(addr, acc.Incarnation, &slot)
+		if err != nil {
+			return nil, fmt.Errorf("cant get storage %x of %x: %w", slot, addr, err)
+		}
+		return addressFromWord(v), nil
+	}
+	impl, err := readSlot(eip1967ImplementationSlot)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		return &ProxyHop{Proxy: addr, Type: ProxyEIP1967, Implementation: *impl}, nil
+	}
+	beacon, err := readSlot(eip1967BeaconSlot)
+	if err != nil {
+		return nil, err
+	}
+	if beacon != nil {
+		impl, err := callBeacon(*beacon)
+		if err != nil {
+			return nil, fmt.Errorf("cant get implementation of beacon %x: %w", *beacon, err)
+		}
+		if impl == nil {
+			return nil, nil
+		}
+		return &ProxyHop{Proxy: addr, Type: ProxyEIP1967Beacon, Beacon: beacon, Implementation: *impl}, nil
+	}
+	impl, err = readSlot(eip1822ProxiableSlot)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		return &ProxyHop{Proxy: addr, Type: ProxyEIP1822, Implementation: *impl}, nil
+	}
+	return nil, nil
+}
+
+// addressFromWord returns the address stored in a word (storage value or ABI encoded return value),
+// nil if the word is zero or doesn't fit an address
+func addressFromWord(word []byte) *common.Address {
+	if len(word) > 32 {
+		return nil
+	}
+	for i := 0; i < len(word)-length.Addr; i++ {
+		if word[i] != 0 {
+			return nil
+		}
+	}
+	addr := common.BytesToAddress(word)
+	if addr == (common.Address{}) {
+		return nil
+	}
+	return &addr
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/types/accounts"
+)
+
+type testProxyReader struct {
+	code    map[libcommon.Address][]byte
+	storage map[libcommon.Address]map[libcommon.Hash][]byte
+}
+
+func (r *testProxyReader) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	code, ok := r.code[address]
+	if !ok {
+		return nil, nil
+	}
+	acc := accounts.NewAccount()
+	acc.CodeHash = crypto.Keccak256Hash(code)
+	return &acc, nil
+}
+
+func (r *testProxyReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	return r.storage[address][*key], nil
+}
+
+func (r *testProxyReader) ReadAccountCode(address libcommon.Address, incarnation uint64) ([]byte, error) {
+	return r.code[address], nil
+}
+
+func TestResolveProxy(t *testing.T) {
+	var (
+		clone    = libcommon.HexToAddress("0x01")
+		uups     = libcommon.HexToAddress("0x02")
+		beaconed = libcommon.HexToAddress("0x03")
+		beacon   = libcommon.HexToAddress("0x04")
+		proxy    = libcommon.HexToAddress("0x05")
+		impl     = libcommon.HexToAddress("0x06")
+		eoa      = libcommon.HexToAddress("0x07")
+		loop     = libcommon.HexToAddress("0x08")
+	)
+	someCode := []byte{0x60, 0x00, 0x56}
+	cloneCode := append(append(append([]byte{}, eip1167Prefix...), uups[:]...), eip1167Suffix...)
+	reader := &testProxyReader{
+		code: map[libcommon.Address][]byte{
+			clone: cloneCode, uups: someCode, beaconed: someCode, beacon: someCode, proxy: someCode, impl: someCode, loop: someCode,
+		},
+		storage: map[libcommon.Address]map[libcommon.Hash][]byte{
+			uups:     {eip1822ProxiableSlot: beaconed[:]},
+			beaconed: {eip1967BeaconSlot: beacon[:]},
+			proxy:    {eip1967ImplementationSlot: impl.Bytes()[19:]}, // storage values have no leading zeros
+			loop:     {eip1967ImplementationSlot: loop[:]},
+		},
+	}
+	callBeacon := func(b libcommon.Address) (*libcommon.Address, error) {
+		require.Equal(t, beacon, b)
+		return &proxy, nil
+	}
+
+	res, err := resolveProxy(reader, callBeacon, clone)
+	require.NoError(t, err)
+	require.Equal(t, []ProxyHop{
+		{Proxy: clone, Type: ProxyEIP1167, Implementation: uups},
+		{Proxy: uups, Type: ProxyEIP1822, Implementation: beaconed},
+		{Proxy: beaconed, Type: ProxyEIP1967Beacon, Beacon: &beacon, Implementation: proxy},
+		{Proxy: proxy, Type: ProxyEIP1967, Implementation: impl},
+	}, res.Chain)
+	require.Equal(t, &impl, res.Implementation)
+
+	for _, addr := range []libcommon.Address{impl, eoa} {
+		res, err = resolveProxy(reader, callBeacon, addr)
+		require.NoError(t, err)
+		require.Empty(t, res.Chain)
+		require.Nil(t, res.Implementation)
+	}
+
+	res, err = resolveProxy(reader, callBeacon, loop)
+	require.NoError(t, err)
+	require.Len(t, res.Chain, 1)
+	require.Equal(t, &loop, res.Implementation)
+
+	require.Nil(t, addressFromWord(libcommon.Hash{}.Bytes()))
+	require.Nil(t, addressFromWord(libcommon.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000001").Bytes()))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/RoaringBitmap/roaring/v2"
 
 	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon-lib/kv/order"
 	"github.com/erigontech/erigon-lib/kv/rawdbv3"
 	"github.com/erigontech/erigon-lib/log/v3"
@@ -68,7 +69,6 @@ func (api *ErigonImpl) GetLogsByHash(ctx context.Context, hash common.Hash) ([][
 
 // GetLogs implements erigon_getLogs. Returns an array of logs matching a given filter object.
 func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.ErigonLogs, error) {
-	var begin, end uint64
 	erigonLogs := types.ErigonLogs{}
 
 	tx, beginErr := api.db.BeginTemporalRo(ctx)
@@ -77,10 +77,19 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 	}
 	defer tx.Rollback()
 
+	begin, end, found, err := api.logsBlockRange(ctx, tx, crit)
+	if err != nil || !found {
+		return nil, err
+	}
+	return api.getLogsV3(ctx, tx, begin, end, crit)
+}
+
+// logsBlockRange returns the range of blocks [begin, end] of the filter, found is false if the block hash of the filter is unknown
+func (api *ErigonImpl) logsBlockRange(ctx context.Context, tx kv.Tx, crit filters.FilterCriteria) (begin, end uint64, found bool, err error) {
 	if crit.BlockHash != nil {
 		header, err := api._blockReader.HeaderByHash(ctx, tx, *crit.BlockHash)
 		if header == nil {
-			return nil, err
+			return 0, 0, false, err
 		}
 		begin = header.Number.Uint64()
 		end = header.Number.Uint64()
@@ -89,7 +98,7 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 		// Convert the RPC block numbers into internal representations
 		latest, err := rpchelper.GetLatestBlockNumber(tx)
 		if err != nil {
-			return nil, err
+			return 0, 0, false, err
 		}
 
 		begin = 0
@@ -97,7 +106,7 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 			if crit.FromBlock.Sign() >= 0 {
 				begin = crit.FromBlock.Uint64()
 			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
+				return 0, 0, false, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
 			}
 		}
 		end = latest
@@ -105,18 +114,17 @@ func (api *ErigonImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria)
 			if crit.ToBlock.Sign() >= 0 {
 				end = crit.ToBlock.Uint64()
 			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
+				return 0, 0, false, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
 			}
 		}
 	}
 	if end < begin {
-		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+		return 0, 0, false, fmt.Errorf("end (%d) < begin (%d)", end, begin)
 	}
 	if end > roaring.MaxUint32 {
-		return nil, fmt.Errorf("end (%d) > MaxUint32", end)
+		return 0, 0, false, fmt.Errorf("end (%d) > MaxUint32", end)
 	}
-
-	return api.getLogsV3(ctx, tx, begin, end, crit)
+	return begin, end, true, nil
 }
 
 // GetLatestLogs implements erigon_getLatestLogs.
@@ -171,6 +171,56 @@ var (
 	testAddr = crypto.PubkeyToAddress(testKey.PublicKey)
 )
 
+func TestErigonExplainGetLogs(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	plan, err := api.ExplainGetLogs(m.Ctx, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(10)})
+	require.NoError(t, err)
+	require.Equal(t, LogsStrategyFullScan, plan.Strategy)
+	require.Empty(t, plan.Lookups)
+	require.NotZero(t, plan.RangeTxs)
+	require.Equal(t, plan.RangeTxs, plan.EstimatedTxs)
+	require.Equal(t, uint64(11), uint64(plan.EstimatedBlocks))
+
+	topic := libcommon.HexToHash("0x68f6a0f063c25c6678c443b9a484086f15ba8f91f60218695d32a5251f2050eb")
+	plan, err = api.ExplainGetLogs(m.Ctx, filters.FilterCriteria{
+		FromBlock: big.NewInt(0),
+		ToBlock:   big.NewInt(10),
+		Addresses: libcommon.Addresses{libcommon.HexToAddress("0x3CB5b6E26e0f37F2514D45641F15Bd6fEC2E0c4c")},
+		Topics:    [][]libcommon.Hash{{topic}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, LogsStrategyAddressTopicIndex, plan.Strategy)
+	require.Len(t, plan.Lookups, 2)
+	require.Equal(t, "topic", plan.Lookups[1].Index)
+	require.Equal(t, 0, *plan.Lookups[1].Position)
+	require.NotZero(t, plan.EstimatedTxs)
+	require.LessOrEqual(t, plan.EstimatedTxs, plan.RangeTxs)
+	require.Less(t, plan.Cost, plan.RangeTxs*txExecutionCost)
+
+	plan, err = api.ExplainGetLogs(m.Ctx, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(10), Addresses: libcommon.Addresses{{}}})
+	require.NoError(t, err)
+	require.Equal(t, LogsStrategyAddressIndex, plan.Strategy)
+	require.Zero(t, plan.EstimatedTxs)
+	require.Zero(t, plan.EstimatedBlocks)
+
+	_, err = api.ExplainGetLogs(m.Ctx, filters.FilterCriteria{FromBlock: big.NewInt(5), ToBlock: big.NewInt(4)})
+	require.Error(t, err)
+
+	tooMany := filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(10), Addresses: make(libcommon.Addresses, maxExplainKeys/2)}
+	tooMany.Topics = [][]libcommon.Hash{make([]libcommon.Hash, maxExplainKeys/2+1)}
+	_, err = api.ExplainGetLogs(m.Ctx, tooMany)
+	require.ErrorContains(t, err, "too many addresses and topics")
+}
+
+func TestExtrapolateCount(t *testing.T) {
+	require.Equal(t, uint64(100), extrapolateCount(10, 0, 10, 100))
+	require.Equal(t, uint64(30), extrapolateCount(10, 100, 200, 400))
+	require.Equal(t, uint64(10), extrapolateCount(10, 0, 100, 100)) // the whole range is covered
+	require.Equal(t, uint64(10), extrapolateCount(10, 5, 5, 100))
+}
+
 func TestGetBlockReceiptsByBlockHash(t *testing.T) {
 	// Define three accounts to simulate transactions with
 	acc1Key, _ := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
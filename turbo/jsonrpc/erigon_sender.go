@@ -0,0 +1,221 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	proto_txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon/consensus/misc"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+)
+
+// Problems of the sender's pool transaction, reported by erigon_diagnoseSender
+const (
+	SenderNonceTooLow = "nonceTooLow" // nonce is already used by a mined transaction, the pool will drop it
+	SenderNonceGap    = "nonceGap"    // transaction with a lower nonce is missing, nothing can be mined until it's sent
+	SenderFeeTooLow   = "feeTooLow"   // fee cap is below the base fee of the next block
+)
+
+// maxReportedNonceGaps - max amount of missing nonces reported by erigon_diagnoseSender
+const maxReportedNonceGaps = 100
+
+// ReplacementFees are the minimal fees of a transaction replacing the pool transaction with the same nonce
+type ReplacementFees struct {
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     *hexutil.Big `json:"maxFeePerBlobGas,omitempty"` // blob transactions only
+}
+
+// SenderTransaction is the sender's pool transaction diagnosed by erigon_diagnoseSender
+type SenderTransaction struct {
+	Hash                 common.Hash     `json:"hash"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	SubPool              string          `json:"subPool"`      // pending, baseFee or queued
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"` // gas price for legacy transactions
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	FeeAdequate          bool            `json:"feeAdequate"` // fee cap covers the base fee of the next block
+	Problem              string          `json:"problem,omitempty"`
+	Replacement          ReplacementFees `json:"replacement"`
+}
+
+// SenderDiagnosis is the result of erigon_diagnoseSender
+type SenderDiagnosis struct {
+	NextNonce     hexutil.Uint64      `json:"nextNonce"` // nonce of the next transaction to be mined, from the latest state
+	PoolNonce     hexutil.Uint64      `json:"poolNonce"` // next nonce after the pool transactions following NextNonce without gaps
+	BaseFee       *hexutil.Big        `json:"baseFee"`   // base fee of the next block, nil before London
+	MissingNonces []hexutil.Uint64    `json:"missingNonces"`
+	Transactions  []SenderTransaction `json:"transactions"` // ordered by nonce
+}
+
+// DiagnoseSender implements erigon_diagnoseSender. Explains why the sender's transactions are not mined: reports
+// the next expected nonce, missing nonces (up to 100) blocking pool transactions, pool transactions with fee cap below
+// the base fee of the next block, and the minimal fees to replace each of them. Replacement fees assume the default
+// price bump of the pool and a fee cap of at least twice the base fee plus the tip, as wallets do.
+func (api *ErigonImpl) DiagnoseSender(ctx context.Context, addr common.Address) (*SenderDiagnosis, error) {
+	if api.txPool == nil {
+		return nil, errors.New("txpool is not available")
+	}
+	reply, err := api.txPool.All(ctx, &proto_txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 0, api.filters, api.stateCache, "")
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(addr)
+	if err != nil {
+		return nil, fmt.Errorf("cant get account %x: %w", addr, err)
+	}
+	res := &SenderDiagnosis{MissingNonces: []hexutil.Uint64{}, Transactions: []SenderTransaction{}}
+	if acc != nil {
+		res.NextNonce = hexutil.Uint64(acc.Nonce)
+	}
+
+	cc, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	var baseFee *uint256.Int
+	if header := rawdb.ReadCurrentHeader(tx); header != nil && cc.IsLondon(header.Number.Uint64()+1) {
+		baseFee, _ = uint256.FromBig(misc.CalcBaseFee(cc, header))
+		res.BaseFee = (*hexutil.Big)(baseFee.ToBig())
+	}
+
+	for _, t := range reply.Txs {
+		if gointerfaces.ConvertH160toAddress(t.Sender) != addr {
+			continue
+		}
+		txn, err := types.DecodeWrappedTransaction(t.RlpTx)
+		if err != nil {
+			return nil, fmt.Errorf("decoding transaction from: %x: %w", t.RlpTx, err)
+		}
+		res.Transactions = append(res.Transactions, newSenderTransaction(txn, subPoolName(t.TxnType), baseFee))
+	}
+	sort.Slice(res.Transactions, func(i, j int) bool { return res.Transactions[i].Nonce < res.Transactions[j].Nonce })
+
+	res.PoolNonce = res.NextNonce
+	expected, gap := res.NextNonce, false
+	for i := range res.Transactions {
+		st := &res.Transactions[i]
+		if st.Nonce < res.NextNonce {
+			st.Problem = SenderNonceTooLow
+			continue
+		}
+		for ; expected < st.Nonce; expected++ {
+			gap = true
+			if len(res.MissingNonces) == maxReportedNonceGaps {
+				expected = st.Nonce
+				break
+			}
+			res.MissingNonces = append(res.MissingNonces, expected)
+		}
+		expected++
+		if gap {
+			st.Problem = SenderNonceGap
+			continue
+		}
+		res.PoolNonce = expected
+		if !st.FeeAdequate {
+			st.Problem = SenderFeeTooLow
+		}
+	}
+	return res, nil
+}
+
+func newSenderTransaction(txn types.Transaction, subPool string, baseFee *uint256.Int) SenderTransaction {
+	feeCap, tip := txn.GetFeeCap(), txn.GetTipCap()
+	priceBump := txpoolcfg.DefaultConfig.PriceBump
+	var blobFeeCap *uint256.Int
+	switch t := txn.(type) {
+	case *types.BlobTx:
+		blobFeeCap = t.MaxFeePerBlobGas
+	case *types.BlobTxWrapper:
+		blobFeeCap = t.Tx.MaxFeePerBlobGas
+	}
+	if blobFeeCap != nil {
+		priceBump = txpoolcfg.DefaultConfig.BlobPriceBump
+	}
+
+	// both fee cap and tip must be bumped to replace the transaction
+	newTip := bumpFee(tip, priceBump)
+	newFeeCap := bumpFee(feeCap, priceBump)
+	if baseFee != nil {
+		// stays adequate for ~6 full blocks in a row
+		enough := new(uint256.Int).Add(new(uint256.Int).Mul(baseFee, uint256.NewInt(2)), newTip)
+		if newFeeCap.Lt(enough) {
+			newFeeCap = enough
+		}
+	}
+	if newFeeCap.Lt(newTip) {
+		newFeeCap = newTip
+	}
+
+	st := SenderTransaction{
+		Hash:                 txn.Hash(),
+		Nonce:                hexutil.Uint64(txn.GetNonce()),
+		SubPool:              subPool,
+		MaxFeePerGas:         (*hexutil.Big)(feeCap.ToBig()),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tip.ToBig()),
+		FeeAdequate:          baseFee == nil || !feeCap.Lt(baseFee),
+		Replacement: ReplacementFees{
+			MaxFeePerGas:         (*hexutil.Big)(newFeeCap.ToBig()),
+			MaxPriorityFeePerGas: (*hexutil.Big)(newTip.ToBig()),
+		},
+	}
+	if blobFeeCap != nil {
+		st.Replacement.MaxFeePerBlobGas = (*hexutil.Big)(bumpFee(blobFeeCap, priceBump).ToBig())
+	}
+	return st
+}
+
+// bumpFee returns the min fee accepted by the pool as a replacement of `fee`: increased by `priceBump` percent,
+// rounded down the same way as the pool does
+func bumpFee(fee *uint256.Int, priceBump uint64) *uint256.Int {
+	res := new(uint256.Int).Mul(fee, uint256.NewInt(100+priceBump))
+	return res.Div(res, uint256.NewInt(100))
+}
+
+func subPoolName(t proto_txpool.AllReply_TxnType) string {
+	switch t {
+	case proto_txpool.AllReply_PENDING:
+		return "pending"
+	case proto_txpool.AllReply_BASE_FEE:
+		return "baseFee"
+	default:
+		return "queued"
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv/kvcache"
+
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc/rpccfg"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestDiagnoseSender(t *testing.T) {
+	m, require := mock.MockWithTxPool(t), require.New(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(libcommon.Address{1})
+	})
+	require.NoError(err)
+	require.NoError(m.InsertChain(chain))
+
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	txPool := txpool.NewTxpoolClient(conn)
+	ff := rpchelper.New(ctx, rpchelper.DefaultFiltersConfig, nil, txPool, txpool.NewMiningClient(conn), func() {}, m.Log)
+	api := NewErigonAPI(NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), m.BlockReader, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs, nil), m.DB, nil)
+	api.txPool = txPool
+
+	// nonce 1 is missing: nonce 2 is stuck in the queued sub-pool
+	var hashes []libcommon.Hash
+	for _, nonce := range []uint64{0, 2} {
+		txn, err := types.SignTx(types.NewTransaction(nonce, libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(10*params.GWei), nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), m.Key)
+		require.NoError(err)
+		buf := bytes.NewBuffer(nil)
+		require.NoError(txn.MarshalBinary(buf))
+		reply, err := txPool.Add(ctx, &txpool.AddRequest{RlpTxs: [][]byte{buf.Bytes()}})
+		require.NoError(err)
+		require.Equal(txpool.ImportResult_SUCCESS, reply.Imported[0], reply.Errors)
+		hashes = append(hashes, txn.Hash())
+	}
+
+	res, err := api.DiagnoseSender(ctx, m.Address)
+	require.NoError(err)
+	require.Equal(hexutil.Uint64(0), res.NextNonce)
+	require.Equal(hexutil.Uint64(1), res.PoolNonce)
+	require.Nil(res.BaseFee) // pre-London chain
+	require.Equal([]hexutil.Uint64{1}, res.MissingNonces)
+	require.Len(res.Transactions, 2)
+
+	first, second := res.Transactions[0], res.Transactions[1]
+	require.Equal(hashes[0], first.Hash)
+	require.Equal("pending", first.SubPool)
+	require.True(first.FeeAdequate)
+	require.Empty(first.Problem)
+	require.Equal("11000000000", first.Replacement.MaxFeePerGas.ToInt().String()) // +10%
+	require.Equal("11000000000", first.Replacement.MaxPriorityFeePerGas.ToInt().String())
+	require.Nil(first.Replacement.MaxFeePerBlobGas)
+
+	require.Equal(hashes[1], second.Hash)
+	require.Equal("queued", second.SubPool)
+	require.Equal(SenderNonceGap, second.Problem)
+
+	other, err := api.DiagnoseSender(ctx, libcommon.Address{1})
+	require.NoError(err)
+	require.Empty(other.Transactions)
+	require.Empty(other.MissingNonces)
+}
+
+func TestBumpFee(t *testing.T) {
+	require.Equal(t, uint64(110), bumpFee(uint256.NewInt(100), 10).Uint64())
+	require.Equal(t, uint64(12), bumpFee(uint256.NewInt(11), 10).Uint64()) // rounded down, as the pool does
+	require.Equal(t, uint64(0), bumpFee(uint256.NewInt(0), 10).Uint64())
+	require.Equal(t, uint64(200), bumpFee(uint256.NewInt(100), 100).Uint64())
+}
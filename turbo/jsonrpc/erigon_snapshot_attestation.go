@@ -0,0 +1,28 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/erigontech/erigon/turbo/snapshotsync"
+)
+
+// SnapshotAttestation implements erigon_snapshotAttestation. Returns the result of the last verification of the
+// snapshot files against the signed manifests (see --downloader.manifest), null if they were never verified.
+func (api *ErigonImpl) SnapshotAttestation(ctx context.Context) (*snapshotsync.AttestationStatus, error) {
+	return snapshotsync.ReadAttestationStatus(api.dirs.Snap)
+}
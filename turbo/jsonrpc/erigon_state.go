@@ -0,0 +1,123 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/types/accounts"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+)
+
+// maxBatchStateReads - max amount of accounts or storage slots read by one batched state request
+const maxBatchStateReads = 1024
+
+// AccountInfo is the result item of erigon_getAccounts
+type AccountInfo struct {
+	Address  common.Address `json:"address"`
+	Balance  *hexutil.Big   `json:"balance"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	CodeHash common.Hash    `json:"codeHash"` // empty code hash for EOA and non-existent account
+}
+
+// GetAccounts implements erigon_getAccounts. Returns balance, nonce and code hash of every account, in the order
+// of `addresses`, read from one state view.
+func (api *ErigonImpl) GetAccounts(ctx context.Context, addresses []common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]AccountInfo, error) {
+	if len(addresses) > maxBatchStateReads {
+		return nil, fmt.Errorf("too many addresses: %d, max %d", len(addresses), maxBatchStateReads)
+	}
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, "")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]AccountInfo, 0, len(addresses))
+	for _, addr := range addresses {
+		acc, err := reader.ReadAccountData(addr)
+		if err != nil {
+			return nil, fmt.Errorf("cant get account %x: %w", addr, err)
+		}
+		if acc == nil {
+			empty := accounts.NewAccount()
+			acc = &empty
+		}
+		res = append(res, AccountInfo{
+			Address:  addr,
+			Balance:  (*hexutil.Big)(acc.Balance.ToBig()),
+			Nonce:    hexutil.Uint64(acc.Nonce),
+			CodeHash: acc.CodeHash,
+		})
+	}
+	return res, nil
+}
+
+// StorageSlot is the address and slot of the storage value, the request item of erigon_getStorageAtMany
+type StorageSlot struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+}
+
+// GetStorageAtMany implements erigon_getStorageAtMany. Returns values of storage slots, in the order of `slots`,
+// read from one state view - an alternative to Multicall contracts, which may not exist at historical blocks.
+func (api *ErigonImpl) GetStorageAtMany(ctx context.Context, slots []StorageSlot, blockNrOrHash rpc.BlockNumberOrHash) ([]common.Hash, error) {
+	if len(slots) > maxBatchStateReads {
+		return nil, fmt.Errorf("too many slots: %d, max %d", len(slots), maxBatchStateReads)
+	}
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, "")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]common.Hash, len(slots))
+	incarnations := map[common.Address]*uint64{} // nil if account doesn't exist
+	for i, s := range slots {
+		incarnation, ok := incarnations[s.Address]
+		if !ok {
+			acc, err := reader.ReadAccountData(s.Address)
+			if err != nil {
+				return nil, fmt.Errorf("cant get account %x: %w", s.Address, err)
+			}
+			if acc != nil {
+				incarnation = &acc.Incarnation
+			}
+			incarnations[s.Address] = incarnation
+		}
+		if incarnation == nil {
+			continue
+		}
+		v, err := reader.ReadAccountStorage(s.Address, *incarnation, &s.Slot)
+		if err != nil {
+			return nil, fmt.Errorf("cant get storage %x of %x: %w", s.Slot, s.Address, err)
+		}
+		res[i] = common.BytesToHash(v)
+	}
+	return res, nil
+}
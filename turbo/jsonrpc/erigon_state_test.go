@@ -0,0 +1,101 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/rpc"
+)
+
+func TestGetAccounts(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	base := newBaseApiForTest(m)
+	api := NewErigonAPI(base, m.DB, nil)
+	ethApi := NewEthAPI(base, m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+
+	token := libcommon.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	deployer := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	missing := libcommon.HexToAddress("0x1234")
+	addresses := []libcommon.Address{deployer, token, missing}
+
+	for _, blockNum := range []rpc.BlockNumber{2, 5, rpc.LatestBlockNumber} {
+		blockNrOrHash := rpc.BlockNumberOrHashWithNumber(blockNum)
+		res, err := api.GetAccounts(m.Ctx, addresses, blockNrOrHash)
+		require.NoError(t, err)
+		require.Len(t, res, len(addresses))
+		for i, addr := range addresses {
+			require.Equal(t, addr, res[i].Address)
+			balance, err := ethApi.GetBalance(m.Ctx, addr, blockNrOrHash)
+			require.NoError(t, err)
+			require.Equal(t, balance.String(), res[i].Balance.String())
+			nonce, err := ethApi.GetTransactionCount(m.Ctx, addr, blockNrOrHash)
+			require.NoError(t, err)
+			require.Equal(t, *nonce, res[i].Nonce)
+			code, err := ethApi.GetCode(m.Ctx, addr, blockNrOrHash)
+			require.NoError(t, err)
+			require.Equal(t, crypto.Keccak256Hash(code), res[i].CodeHash)
+		}
+	}
+
+	_, err := api.GetAccounts(m.Ctx, make([]libcommon.Address, maxBatchStateReads+1), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.Error(t, err)
+}
+
+func TestGetStorageAtMany(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	base := newBaseApiForTest(m)
+	api := NewErigonAPI(base, m.DB, nil)
+	ethApi := NewEthAPI(base, m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+
+	token := libcommon.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	holderKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	require.NoError(t, err)
+	holder := crypto.PubkeyToAddress(holderKey.PublicKey)
+	slots := []StorageSlot{
+		{Address: token, Slot: libcommon.Hash{}}, // totalSupply
+		{Address: token, Slot: crypto.Keccak256Hash(libcommon.LeftPadBytes(holder[:], 32), libcommon.LeftPadBytes([]byte{1}, 32))}, // balanceOf[holder]
+		{Address: holder, Slot: libcommon.Hash{}},
+		{Address: libcommon.HexToAddress("0x1234"), Slot: libcommon.Hash{1}},
+	}
+
+	for _, blockNum := range []rpc.BlockNumber{3, 4, rpc.LatestBlockNumber} {
+		blockNrOrHash := rpc.BlockNumberOrHashWithNumber(blockNum)
+		res, err := api.GetStorageAtMany(m.Ctx, slots, blockNrOrHash)
+		require.NoError(t, err)
+		require.Len(t, res, len(slots))
+		for i, s := range slots {
+			expect, err := ethApi.GetStorageAt(m.Ctx, s.Address, s.Slot.Hex(), blockNrOrHash)
+			require.NoError(t, err)
+			require.Equal(t, expect, res[i].Hex())
+		}
+	}
+	res, err := api.GetStorageAtMany(m.Ctx, slots[:2], rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.NoError(t, err)
+	require.Equal(t, []libcommon.Hash{libcommon.BigToHash(big.NewInt(10)), libcommon.BigToHash(big.NewInt(7))}, res)
+
+	_, err = api.GetStorageAtMany(m.Ctx, make([]StorageSlot, maxBatchStateReads+1), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erigontech/erigon-lib/common/debug"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/rpc"
+)
+
+var errSyncEventsUnavailable = errors.New("sync events are available only with the rpcdaemon embedded into the node")
+
+// SyncEvents implements erigon_subscribe("syncEvents"). Sends shards.SyncEvent on start, finish and unwind of every
+// stage, and the milestones: snapshotsDownloaded once snapshot files are downloaded and indexed, rpcServiceable once
+// the first head is committed. Milestones already happened are sent first, so late subscribers don't miss them.
+func (api *ErigonImpl) SyncEvents(ctx context.Context) (*rpc.Subscription, error) {
+	if api.syncEvents == nil {
+		return &rpc.Subscription{}, errSyncEventsUnavailable
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	events, unsubscribe := api.syncEvents.AddSyncEventsSubscription()
+	go func() {
+		defer debug.LogPanic()
+		defer unsubscribe()
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := notifier.Notify(rpcSub.ID, ev); err != nil {
+					log.Warn("[rpc] error while notifying subscription", "err", err)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+const (
+	syncRateWindow     = time.Minute      // time constant of the moving average of the sync speeds
+	syncSampleInterval = 3 * time.Second  // calls more frequent than this reuse the speeds of the last sample
+	syncingDistance    = 8                // same as eth_syncing: the node is synced within this many blocks of the highest one
+	snapSizeRefresh    = 10 * time.Second // the snapshots dir is walked at most this often
+)
+
+// SyncStatus is the result of erigon_syncStatus
+type SyncStatus struct {
+	Syncing      bool           `json:"syncing"`
+	CurrentBlock hexutil.Uint64 `json:"currentBlock"` // executed
+	HighestBlock hexutil.Uint64 `json:"highestBlock"` // seen in the network or frozen in snapshots
+	FrozenBlocks hexutil.Uint64 `json:"frozenBlocks"`
+
+	SnapshotBytes          hexutil.Uint64 `json:"snapshotBytes"` // size of the snapshot files on disk
+	SnapshotBytesPerSecond float64        `json:"snapshotBytesPerSecond"`
+
+	Stages []StageSyncStatus `json:"stages"`
+	// EtaSeconds is the ETA of the slowest stage: stages run in turns, so their speeds already account for each other
+	EtaSeconds *uint64 `json:"etaSeconds"`
+}
+
+// StageSyncStatus is the progress of one stage towards the highest block
+type StageSyncStatus struct {
+	Stage           string         `json:"stage"`
+	Done            hexutil.Uint64 `json:"done"`
+	Total           hexutil.Uint64 `json:"total"`
+	Percent         float64        `json:"percent"`
+	BlocksPerSecond float64        `json:"blocksPerSecond"` // moving average, known from the second call
+	EtaSeconds      *uint64        `json:"etaSeconds"`      // nil if the stage doesn't progress
+}
+
+// syncProgressTracker keeps the moving averages of the sync speeds between erigon_syncStatus calls
+type syncProgressTracker struct {
+	mu        sync.Mutex
+	last      time.Time
+	progress  map[string]uint64
+	rates     map[string]float64
+	snapBytes uint64
+	snapRate  float64
+
+	snapSize snapshotsSizeCache
+}
+
+// snapshotsSizeCache keeps the size of the snapshots dir between the calls, the dir holds thousands of files
+type snapshotsSizeCache struct {
+	mu   sync.Mutex
+	at   time.Time
+	size uint64
+}
+
+// get returns the size of the files in dir, walking it only if the cached size is older than snapSizeRefresh
+func (c *snapshotsSizeCache) get(now time.Time, dir string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.at.IsZero() && now.Sub(c.at) < snapSizeRefresh {
+		return c.size
+	}
+	var size uint64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	c.at, c.size = now, size
+	return size
+}
+
+// update returns the moving averages of the stage speeds (blocks/s) and of the snapshots growth (bytes/s)
+func (t *syncProgressTracker) update(now time.Time, progress map[string]uint64, snapBytes uint64) (map[string]float64, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.progress == nil {
+		t.last, t.progress, t.rates, t.snapBytes = now, progress, map[string]float64{}, snapBytes
+		return t.rates, t.snapRate
+	}
+	dt := now.Sub(t.last)
+	if dt < syncSampleInterval {
+		return t.rates, t.snapRate
+	}
+	// time-weighted, so the averages don't depend on how often the status is polled
+	alpha := 1 - math.Exp(-float64(dt)/float64(syncRateWindow))
+	rates := make(map[string]float64, len(progress))
+	for stage, p := range progress {
+		var rate float64
+		if prev, ok := t.progress[stage]; ok && p > prev {
+			rate = float64(p-prev) / dt.Seconds()
+		}
+		if prevRate, ok := t.rates[stage]; ok {
+			rate = prevRate + alpha*(rate-prevRate)
+		}
+		rates[stage] = rate
+	}
+	var snapRate float64
+	if snapBytes > t.snapBytes {
+		snapRate = float64(snapBytes-t.snapBytes) / dt.Seconds()
+	}
+	t.snapRate += alpha * (snapRate - t.snapRate)
+	t.last, t.progress, t.rates, t.snapBytes = now, progress, rates, snapBytes
+	return rates, t.snapRate
+}
+
+// SyncStatus implements erigon_syncStatus. Returns the progress of every stage towards the highest block with the
+// speeds and ETAs based on the moving averages between the calls, a superset of eth_syncing.
+func (api *ErigonImpl) SyncStatus(ctx context.Context) (*SyncStatus, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res := &SyncStatus{FrozenBlocks: hexutil.Uint64(api._blockReader.FrozenBlocks()), Stages: make([]StageSyncStatus, 0, len(stages.AllStages))}
+	progress := make(map[string]uint64, len(stages.AllStages))
+	var highest uint64
+	for _, stage := range stages.AllStages {
+		p, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		progress[string(stage)] = p
+		if stage == stages.Headers {
+			highest = p
+		}
+		if stage == stages.Execution {
+			res.CurrentBlock = hexutil.Uint64(p)
+		}
+	}
+	if api.ethBackend != nil {
+		reply, err := api.ethBackend.Syncing(ctx)
+		if err != nil {
+			return nil, err
+		}
+		highest = max(highest, reply.LastNewBlockSeen)
+	}
+	highest = max(highest, uint64(res.FrozenBlocks))
+	res.HighestBlock = hexutil.Uint64(highest)
+	res.Syncing = highest > uint64(res.CurrentBlock)+syncingDistance
+
+	now := time.Now()
+	var snapBytes uint64
+	if api.dirs.Snap != "" { // 0 if the rpcdaemon has no access to the datadir
+		snapBytes = api.syncProgress.snapSize.get(now, api.dirs.Snap)
+	}
+	res.SnapshotBytes = hexutil.Uint64(snapBytes)
+	rates, snapRate := api.syncProgress.update(now, progress, snapBytes)
+	res.SnapshotBytesPerSecond = snapRate
+
+	for _, stage := range stages.AllStages {
+		done := progress[string(stage)]
+		s := StageSyncStatus{Stage: string(stage), Done: hexutil.Uint64(done), Total: hexutil.Uint64(highest), Percent: 100, BlocksPerSecond: rates[string(stage)]}
+		if done < highest {
+			s.Percent = 100 * float64(done) / float64(highest)
+			if s.BlocksPerSecond > 0 {
+				eta := uint64(float64(highest-done) / s.BlocksPerSecond)
+				s.EtaSeconds = &eta
+				if res.EtaSeconds == nil || *res.EtaSeconds < eta {
+					res.EtaSeconds = &eta
+				}
+			}
+		} else {
+			eta := uint64(0)
+			s.EtaSeconds = &eta
+		}
+		res.Stages = append(res.Stages, s)
+	}
+	if !res.Syncing {
+		eta := uint64(0)
+		res.EtaSeconds = &eta
+	}
+	return res, nil
+}
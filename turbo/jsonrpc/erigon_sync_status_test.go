@@ -0,0 +1,80 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/eth/stagedsync/stages"
+)
+
+func TestErigonSyncStatus(t *testing.T) {
+	m, _, _ := chainWithDeployedContract(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	res, err := api.SyncStatus(context.Background())
+	require.NoError(t, err)
+	require.False(t, res.Syncing)
+	require.Len(t, res.Stages, len(stages.AllStages))
+	require.NotNil(t, res.EtaSeconds)
+	require.Equal(t, uint64(0), *res.EtaSeconds)
+	for _, s := range res.Stages {
+		if s.Stage == string(stages.Execution) {
+			require.Equal(t, res.CurrentBlock, s.Done)
+			require.Equal(t, 100.0, s.Percent)
+		}
+	}
+}
+
+func TestSyncProgressTracker(t *testing.T) {
+	var tracker syncProgressTracker
+	now := time.Now()
+	rates, _ := tracker.update(now, map[string]uint64{"Execution": 100}, 0)
+	require.Zero(t, rates["Execution"])
+
+	// too early, the previous speeds are reused
+	rates, _ = tracker.update(now.Add(time.Second), map[string]uint64{"Execution": 1000}, 0)
+	require.Zero(t, rates["Execution"])
+
+	rates, snapRate := tracker.update(now.Add(10*time.Second), map[string]uint64{"Execution": 200}, 1000)
+	require.Equal(t, 10.0, rates["Execution"]) // first speed is taken as is
+	require.Greater(t, snapRate, 0.0)
+
+	// the speed drops to 0, the average decays instead of following it
+	rates, _ = tracker.update(now.Add(20*time.Second), map[string]uint64{"Execution": 200}, 1000)
+	require.Greater(t, rates["Execution"], 0.0)
+	require.Less(t, rates["Execution"], 10.0)
+}
+
+func TestSnapshotsSizeCache(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.seg"), make([]byte, 100), 0644))
+
+	var cache snapshotsSizeCache
+	now := time.Now()
+	require.Equal(t, uint64(100), cache.get(now, dir))
+
+	// the dir isn't walked again until the refresh interval passes
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.seg"), make([]byte, 50), 0644))
+	require.Equal(t, uint64(100), cache.get(now.Add(snapSizeRefresh/2), dir))
+	require.Equal(t, uint64(150), cache.get(now.Add(snapSizeRefresh), dir))
+}
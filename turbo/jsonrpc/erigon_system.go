@@ -22,6 +22,7 @@ import (
 
 	"github.com/erigontech/erigon-lib/common/hexutil"
 
+	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/common"
 
 	"github.com/erigontech/erigon/core/forkid"
@@ -55,6 +56,40 @@ func (api *ErigonImpl) Forks(ctx context.Context) (Forks, error) {
 	return Forks{genesis.Hash(), heightForks, timeForks}, nil
 }
 
+// ForkConfig is a data type to record effective fork schedule of the node, including --override.* flags
+type ForkConfig struct {
+	GenesisHash common.Hash   `json:"genesis"`
+	Config      *chain.Config `json:"config"`
+	ActiveEips  []int         `json:"activeEips"` // individually scheduled EIPs active at the latest block
+}
+
+// ForkConfig implements erigon_forkConfig. Returns the chain config the node runs with
+func (api *ErigonImpl) ForkConfig(ctx context.Context) (*ForkConfig, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, genesis, err := api.chainConfigWithGenesis(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	header, err := api._blockReader.HeaderByNumber(ctx, tx, latest)
+	if err != nil {
+		return nil, err
+	}
+	activeEips := []int{}
+	if header != nil {
+		activeEips = append(activeEips, chainConfig.ExtraEips(header.Time)...)
+	}
+	return &ForkConfig{GenesisHash: genesis.Hash(), Config: chainConfig, ActiveEips: activeEips}, nil
+}
+
 // Post the merge eth_blockNumber will return latest forkChoiceHead block number
 // erigon_blockNumber will return latest executed block number or any block number requested
 func (api *ErigonImpl) BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.BlockNumber) (hexutil.Uint64, error) {
@@ -0,0 +1,313 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/rpc"
+	ethapi2 "github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+const (
+	maxReconcileCheckpoints = 1000    // max amount of blocks balanceOf is called at
+	maxReconcileSlots       = 64      // max amount of storage slots read by balanceOf which changes are followed
+	balanceOfCallGas        = 200_000 // gas of the balanceOf(holder) call
+)
+
+const (
+	DiscrepancyChangeWithoutEvent = "changeWithoutEvent" // balance changed without Transfer events, e.g. rebasing or silent mint
+	DiscrepancyEventWithoutChange = "eventWithoutChange" // Transfer events didn't change the balance
+	DiscrepancyAmountMismatch     = "amountMismatch"     // balance changed by another amount than transferred, e.g. fee-on-transfer
+)
+
+var (
+	// keccak256("Transfer(address,address,uint256)")
+	erc20TransferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	balanceOfSelector  = common.FromHex("0x70a08231") // balanceOf(address)
+)
+
+// BalanceDiscrepancy is a block where the storage-derived balance changed by another amount than its Transfer events say
+type BalanceDiscrepancy struct {
+	BlockNumber  hexutil.Uint64 `json:"blockNumber"`
+	Kind         string         `json:"kind"`         // DiscrepancyChangeWithoutEvent, ...
+	StorageDelta *hexutil.Big   `json:"storageDelta"` // change of balanceOf within the block
+	EventDelta   *hexutil.Big   `json:"eventDelta"`   // sum of incoming minus outgoing Transfer values within the block
+}
+
+// TokenBalanceReconciliation is the result of erigon_reconcileTokenBalance
+type TokenBalanceReconciliation struct {
+	FromBlock    hexutil.Uint64 `json:"fromBlock"`
+	ToBlock      hexutil.Uint64 `json:"toBlock"`
+	StartBalance *hexutil.Big   `json:"startBalance"` // balanceOf before fromBlock
+	EndBalance   *hexutil.Big   `json:"endBalance"`   // balanceOf at the end of toBlock
+	EventBalance *hexutil.Big   `json:"eventBalance"` // startBalance plus the Transfer events of the range
+	Transfers    hexutil.Uint64 `json:"transfers"`    // amount of Transfer events from or to the holder
+	// Slots are the storage slots balanceOf reads, blocks changing them are checked in addition to the ones with events
+	Slots         []StorageSlot        `json:"slots"`
+	Discrepancies []BalanceDiscrepancy `json:"discrepancies"`
+}
+
+// ReconcileTokenBalance implements erigon_reconcileTokenBalance. Compares the ERC-20 balance of the holder derived from
+// Transfer events (found by the logs index) with the one returned by balanceOf (storage-derived) over the block range,
+// both inclusive. balanceOf is called before the range, at its end and at the end of every block with a Transfer event
+// of the holder or with a change (found by the storage history index) of a storage slot balanceOf reads. Blocks where
+// the two disagree are reported: rebasing tokens change balances without events, fee-on-transfer tokens by other amounts.
+func (api *ErigonImpl) ReconcileTokenBalance(ctx context.Context, token, holder common.Address, fromBlock, toBlock rpc.BlockNumber) (*TokenBalanceReconciliation, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	txNums := api.newTxNumBlocks(ctx, tx)
+	from, to, ok, err := txNums.blockRange(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+
+	transfers, count, err := api.holderTransfers(ctx, tx, token, holder, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := map[StorageSlot]struct{}{}
+	balanceAt := func(blockNum uint64) (*big.Int, error) {
+		return api.balanceOf(ctx, tx, chainConfig, token, holder, blockNum, slots)
+	}
+	start := new(big.Int)
+	if from > 0 {
+		if start, err = balanceAt(from - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := balanceAt(to)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) > maxReconcileSlots {
+		return nil, fmt.Errorf("balanceOf reads too many storage slots: %d, max %d", len(slots), maxReconcileSlots)
+	}
+
+	blocks := map[uint64]struct{}{to: {}}
+	for blockNum := range transfers {
+		blocks[blockNum] = struct{}{}
+	}
+	fromTxNum, toTxNum, err := txNums.rangeOf(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	res := &TokenBalanceReconciliation{FromBlock: hexutil.Uint64(from), ToBlock: hexutil.Uint64(to), Transfers: hexutil.Uint64(count), Slots: make([]StorageSlot, 0, len(slots))}
+	for slot := range slots {
+		res.Slots = append(res.Slots, slot)
+		if err := storageChangeBlocks(tx, txNums, slot, fromTxNum, toTxNum, blocks); err != nil {
+			return nil, err
+		}
+		if len(blocks) > maxReconcileCheckpoints {
+			return nil, fmt.Errorf("too many blocks to check, max %d: narrow the block range", maxReconcileCheckpoints)
+		}
+	}
+	sort.Slice(res.Slots, func(i, j int) bool {
+		if res.Slots[i].Address != res.Slots[j].Address {
+			return res.Slots[i].Address.Cmp(res.Slots[j].Address) < 0
+		}
+		return res.Slots[i].Slot.Cmp(res.Slots[j].Slot) < 0
+	})
+
+	checkpoints := make([]balanceCheckpoint, 0, len(blocks))
+	for blockNum := range blocks {
+		checkpoints = append(checkpoints, balanceCheckpoint{blockNum: blockNum})
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].blockNum < checkpoints[j].blockNum })
+	for i := range checkpoints {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if checkpoints[i].blockNum == to {
+			checkpoints[i].balance = end
+			continue
+		}
+		if checkpoints[i].balance, err = balanceAt(checkpoints[i].blockNum); err != nil {
+			return nil, err
+		}
+	}
+
+	eventBalance := new(big.Int).Set(start)
+	for _, delta := range transfers {
+		eventBalance.Add(eventBalance, delta)
+	}
+	res.StartBalance, res.EndBalance, res.EventBalance = (*hexutil.Big)(start), (*hexutil.Big)(end), (*hexutil.Big)(eventBalance)
+	res.Discrepancies = reconcileBalances(start, checkpoints, transfers)
+	return res, nil
+}
+
+// holderTransfers returns the sum of incoming minus outgoing Transfer values of the holder by block, and the amount of events
+func (api *ErigonImpl) holderTransfers(ctx context.Context, tx kv.TemporalTx, token, holder common.Address, from, to uint64) (map[uint64]*big.Int, int, error) {
+	holderTopic := common.BytesToHash(holder[:])
+	type logPosition struct{ blockNum, index uint64 }
+	seen := map[logPosition]struct{}{}
+	transfers := map[uint64]*big.Int{}
+	for _, topics := range [][][]common.Hash{{{erc20TransferTopic}, {holderTopic}}, {{erc20TransferTopic}, {}, {holderTopic}}} {
+		logs, err := api.getLogsV3(ctx, tx, from, to, filters.FilterCriteria{Addresses: []common.Address{token}, Topics: topics})
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, l := range logs {
+			if len(l.Topics) != 3 || len(l.Data) != 32 { // ERC-721 Transfer has the token id indexed
+				continue
+			}
+			pos := logPosition{l.BlockNumber, uint64(l.Index)}
+			if _, ok := seen[pos]; ok { // transfer to self
+				continue
+			}
+			seen[pos] = struct{}{}
+			delta, ok := transfers[l.BlockNumber]
+			if !ok {
+				delta = new(big.Int)
+				transfers[l.BlockNumber] = delta
+			}
+			value := new(big.Int).SetBytes(l.Data)
+			if l.Topics[1] == holderTopic {
+				delta.Sub(delta, value)
+			}
+			if l.Topics[2] == holderTopic {
+				delta.Add(delta, value)
+			}
+		}
+	}
+	return transfers, len(seen), nil
+}
+
+// balanceOf calls token.balanceOf(holder) at the end of the block, recording the storage slots read into `slots`
+func (api *ErigonImpl) balanceOf(ctx context.Context, tx kv.TemporalTx, chainConfig *chain.Config, token, holder common.Address, blockNum uint64, slots map[StorageSlot]struct{}) (*big.Int, error) {
+	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNum))
+	header, err := api._blockReader.HeaderByNumber(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	gas := hexutil.Uint64(balanceOfCallGas)
+	data := hexutil.Bytes(append(common.CopyBytes(balanceOfSelector), common.LeftPadBytes(holder[:], 32)...))
+	args := ethapi2.CallArgs{To: &token, Gas: &gas, Data: &data}
+	result, err := transactions.DoCall(ctx, api.engine(), args, tx, blockNrOrHash, header, nil, balanceOfCallGas, chainConfig, &slotRecorder{StateReader: reader, slots: slots}, api._blockReader, api.evmCallTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if result.Failed() {
+		return nil, fmt.Errorf("balanceOf failed at block %d: %w", blockNum, result.Err)
+	}
+	if len(result.ReturnData) < 32 {
+		return nil, fmt.Errorf("balanceOf returned %d bytes at block %d, is %x an ERC-20 token?", len(result.ReturnData), blockNum, token)
+	}
+	return new(big.Int).SetBytes(result.ReturnData[:32]), nil
+}
+
+// slotRecorder records the storage slots read through the state reader
+type slotRecorder struct {
+	state.StateReader
+	slots map[StorageSlot]struct{}
+}
+
+func (r *slotRecorder) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	r.slots[StorageSlot{Address: address, Slot: *key}] = struct{}{}
+	return r.StateReader.ReadAccountStorage(address, incarnation, key)
+}
+
+// storageChangeBlocks adds the blocks changing the slot within txNums [fromTxNum, toTxNum) to `blocks`
+func storageChangeBlocks(tx kv.TemporalTx, txNums *txNumBlocks, slot StorageSlot, fromTxNum, toTxNum uint64, blocks map[uint64]struct{}) error {
+	if fromTxNum >= toTxNum {
+		return nil
+	}
+	key := append(slot.Address.Bytes(), slot.Slot.Bytes()...)
+	it, err := tx.IndexRange(kv.StorageHistoryIdx, key, int(fromTxNum), int(toTxNum), order.Asc, maxReconcileCheckpoints+1)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.HasNext() {
+		txNum, err := it.Next()
+		if err != nil {
+			return err
+		}
+		blockNum, _, err := txNums.position(txNum)
+		if err != nil {
+			return err
+		}
+		blocks[blockNum] = struct{}{}
+	}
+	return nil
+}
+
+type balanceCheckpoint struct {
+	blockNum uint64
+	balance  *big.Int // at the end of the block
+}
+
+// reconcileBalances compares the changes of the balance between consecutive checkpoints (the first one against `start`)
+// with the Transfer events of the blocks in between. Checkpoints must be sorted and include all blocks with events.
+func reconcileBalances(start *big.Int, checkpoints []balanceCheckpoint, transfers map[uint64]*big.Int) []BalanceDiscrepancy {
+	res := []BalanceDiscrepancy{}
+	prev := start
+	for _, c := range checkpoints {
+		storageDelta := new(big.Int).Sub(c.balance, prev)
+		eventDelta := transfers[c.blockNum]
+		if eventDelta == nil {
+			eventDelta = new(big.Int)
+		}
+		prev = c.balance
+		if storageDelta.Cmp(eventDelta) == 0 {
+			continue
+		}
+		kind := DiscrepancyAmountMismatch
+		if _, ok := transfers[c.blockNum]; !ok {
+			kind = DiscrepancyChangeWithoutEvent
+		} else if storageDelta.Sign() == 0 {
+			kind = DiscrepancyEventWithoutChange
+		}
+		res = append(res, BalanceDiscrepancy{
+			BlockNumber:  hexutil.Uint64(c.blockNum),
+			Kind:         kind,
+			StorageDelta: (*hexutil.Big)(storageDelta),
+			EventDelta:   (*hexutil.Big)(eventDelta),
+		})
+	}
+	return res
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/rpc"
+)
+
+func TestReconcileTokenBalance(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+
+	// the test token doesn't emit Transfer events: minted 10 in block 4, 3 transferred out in block 5
+	token := libcommon.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	holderKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	require.NoError(t, err)
+	holder := crypto.PubkeyToAddress(holderKey.PublicKey)
+	balanceSlot := crypto.Keccak256Hash(libcommon.LeftPadBytes(holder[:], 32), libcommon.LeftPadBytes([]byte{1}, 32))
+
+	res, err := api.ReconcileTokenBalance(m.Ctx, token, holder, 4, rpc.LatestBlockNumber)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), uint64(res.FromBlock))
+	require.Zero(t, res.StartBalance.ToInt().Sign())
+	require.Equal(t, big.NewInt(7), res.EndBalance.ToInt())
+	require.Zero(t, res.EventBalance.ToInt().Sign())
+	require.Zero(t, uint64(res.Transfers))
+	require.Contains(t, res.Slots, StorageSlot{Address: token, Slot: balanceSlot})
+	require.Len(t, res.Discrepancies, 2)
+	require.Equal(t, BalanceDiscrepancy{BlockNumber: 4, Kind: DiscrepancyChangeWithoutEvent, StorageDelta: (*hexutil.Big)(big.NewInt(10)), EventDelta: (*hexutil.Big)(new(big.Int))}, res.Discrepancies[0])
+	require.Equal(t, BalanceDiscrepancy{BlockNumber: 5, Kind: DiscrepancyChangeWithoutEvent, StorageDelta: (*hexutil.Big)(big.NewInt(-3)), EventDelta: (*hexutil.Big)(new(big.Int))}, res.Discrepancies[1])
+
+	res, err = api.ReconcileTokenBalance(m.Ctx, token, holder, 6, rpc.LatestBlockNumber)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(7), res.StartBalance.ToInt())
+	require.Empty(t, res.Discrepancies)
+
+	_, err = api.ReconcileTokenBalance(m.Ctx, holder, holder, 4, 5) // not a contract
+	require.Error(t, err)
+}
+
+func TestReconcileBalances(t *testing.T) {
+	checkpoints := []balanceCheckpoint{
+		{blockNum: 1, balance: big.NewInt(100)}, // transfer in, matches
+		{blockNum: 2, balance: big.NewInt(85)},  // fee-on-transfer: 10 sent, 5 more taken
+		{blockNum: 3, balance: big.NewInt(89)},  // rebase
+		{blockNum: 4, balance: big.NewInt(89)},  // event without change
+	}
+	transfers := map[uint64]*big.Int{1: big.NewInt(100), 2: big.NewInt(-10), 4: big.NewInt(1)}
+
+	res := reconcileBalances(new(big.Int), checkpoints, transfers)
+	require.Len(t, res, 3)
+	require.Equal(t, BalanceDiscrepancy{BlockNumber: 2, Kind: DiscrepancyAmountMismatch, StorageDelta: (*hexutil.Big)(big.NewInt(-15)), EventDelta: (*hexutil.Big)(big.NewInt(-10))}, res[0])
+	require.Equal(t, DiscrepancyChangeWithoutEvent, res[1].Kind)
+	require.Equal(t, big.NewInt(4), res[1].StorageDelta.ToInt())
+	require.Equal(t, DiscrepancyEventWithoutChange, res[2].Kind)
+}
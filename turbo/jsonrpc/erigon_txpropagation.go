@@ -0,0 +1,33 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erigontech/erigon-lib/common"
+	txpool2 "github.com/erigontech/erigon/txnprovider/txpool"
+)
+
+// TraceTxPropagation implements erigon_traceTxPropagation. Returns which peer the pool transaction was first seen
+// from, when it was sent onward and when it was mined, nil if the transaction is not among the recent ones.
+func (api *ErigonImpl) TraceTxPropagation(ctx context.Context, hash common.Hash) (*txpool2.TxnPropagation, error) {
+	if api.txnPropagation == nil {
+		return nil, errors.New("transaction propagation is recorded only by the internal txpool of the embedded rpcdaemon")
+	}
+	return api.txnPropagation.Get(hash), nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/rlp"
+	"github.com/erigontech/erigon-lib/trie"
+	"github.com/erigontech/erigon-lib/types/accounts"
+)
+
+// ProofVerification is the result of erigon_verifyProof. The account and storage values are decoded from the proofs,
+// not copied from the claims of the verified response.
+type ProofVerification struct {
+	Valid bool   `json:"valid"`           // the account proof and all storage proofs are valid and match the claims
+	Error string `json:"error,omitempty"` // why the account proof is invalid
+
+	Exists       bool                  `json:"exists"` // false if the proof proves the account is absent
+	Nonce        hexutil.Uint64        `json:"nonce"`
+	Balance      *hexutil.Big          `json:"balance"`
+	StorageHash  common.Hash           `json:"storageHash"`
+	CodeHash     common.Hash           `json:"codeHash"`
+	StorageProof []StorageVerification `json:"storageProof"`
+}
+
+// StorageVerification is the result of the verification of one storage proof of erigon_verifyProof
+type StorageVerification struct {
+	Key   string       `json:"key"`
+	Valid bool         `json:"valid"`
+	Error string       `json:"error,omitempty"`
+	Value *hexutil.Big `json:"value"` // decoded from the proof, null if the proof is malformed
+}
+
+// VerifyProof implements erigon_verifyProof. Verifies an eth_getProof response against the state root and decodes
+// the proven account and storage values. A malformed or mismatching proof is reported in the result, not as an error.
+func (api *ErigonImpl) VerifyProof(_ context.Context, proof accounts.AccProofResult, stateRoot common.Hash) (*ProofVerification, error) {
+	return verifyProof(&proof, stateRoot), nil
+}
+
+func verifyProof(proof *accounts.AccProofResult, stateRoot common.Hash) *ProofVerification {
+	if proof.Balance == nil {
+		proof.Balance = new(hexutil.Big)
+	}
+	res := &ProofVerification{Valid: true, Balance: new(hexutil.Big), StorageProof: make([]StorageVerification, 0, len(proof.StorageProof))}
+
+	value, err := trie.ProvenValue(stateRoot, crypto.Keccak256Hash(proof.Address[:]), proof.AccountProof)
+	if err == nil && value != nil {
+		var acc struct {
+			Nonce       uint64
+			Balance     *big.Int
+			StorageHash common.Hash
+			CodeHash    common.Hash
+		}
+		if err = rlp.DecodeBytes(value, &acc); err != nil {
+			err = fmt.Errorf("decode account: %w", err)
+		} else {
+			res.Exists = true
+			res.Nonce, res.Balance = hexutil.Uint64(acc.Nonce), (*hexutil.Big)(acc.Balance)
+			res.StorageHash, res.CodeHash = acc.StorageHash, acc.CodeHash
+		}
+	}
+	if err == nil {
+		err = trie.VerifyAccountProof(stateRoot, proof)
+	}
+	if err != nil {
+		res.Valid, res.Error = false, err.Error()
+	}
+
+	for _, sp := range proof.StorageProof {
+		v := verifyStorageProof(res.StorageHash, sp)
+		res.Valid = res.Valid && v.Valid
+		res.StorageProof = append(res.StorageProof, v)
+	}
+	return res
+}
+
+// verifyStorageProof verifies the storage proof against the proven storage root of the account
+func verifyStorageProof(storageRoot common.Hash, sp accounts.StorProofResult) StorageVerification {
+	res := StorageVerification{Key: sp.Key}
+	if sp.Value == nil {
+		sp.Value = new(hexutil.Big)
+	}
+	var err error
+	if storageRoot == trie.EmptyRoot || storageRoot == (common.Hash{}) {
+		res.Value = new(hexutil.Big)
+	} else {
+		var key common.Hash
+		key.SetBytes(hexutil.FromHex(sp.Key))
+		var value []byte
+		if value, err = trie.ProvenValue(storageRoot, crypto.Keccak256Hash(key[:]), sp.Proof); err == nil {
+			var decoded []byte
+			if value != nil {
+				err = rlp.DecodeBytes(value, &decoded)
+			}
+			if err == nil {
+				res.Value = (*hexutil.Big)(new(big.Int).SetBytes(decoded))
+			}
+		}
+	}
+	if err == nil {
+		err = trie.VerifyStorageProof(storageRoot, sp)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Valid = true
+	}
+	return res
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/rpc"
+)
+
+func TestErigonVerifyProof(t *testing.T) {
+	m, bankAddr, contractAddr := chainWithDeployedContract(t)
+	ethApi := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 1, 128, log.New())
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	ctx := context.Background()
+
+	tx, err := m.DB.BeginTemporalRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	header, err := ethApi.headerByRPCNumber(ctx, rpc.BlockNumber(3), tx)
+	require.NoError(t, err)
+
+	key := common.Hash{31: 4}
+	proof, err := ethApi.GetProof(ctx, contractAddr, []hexutil.Bytes{key.Bytes()}, rpc.BlockNumberOrHashWithNumber(3))
+	require.NoError(t, err)
+	res, err := api.VerifyProof(ctx, *proof, header.Root)
+	require.NoError(t, err)
+	require.True(t, res.Valid, res.Error)
+	require.True(t, res.Exists)
+	require.Equal(t, proof.Nonce, res.Nonce)
+	require.Equal(t, proof.StorageHash, res.StorageHash)
+	require.Len(t, res.StorageProof, 1)
+	require.True(t, res.StorageProof[0].Valid, res.StorageProof[0].Error)
+	require.Equal(t, uint64(2), res.StorageProof[0].Value.ToInt().Uint64())
+
+	// wrong claims are reported, the proven values are returned
+	proof, err = ethApi.GetProof(ctx, bankAddr, nil, rpc.BlockNumberOrHashWithNumber(3))
+	require.NoError(t, err)
+	balance := proof.Balance.ToInt()
+	tampered := *proof
+	tampered.Balance = (*hexutil.Big)(new(big.Int).Add(balance, big.NewInt(1)))
+	res, err = api.VerifyProof(ctx, tampered, header.Root)
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.NotEmpty(t, res.Error)
+	require.Equal(t, balance, res.Balance.ToInt())
+
+	// proof against another root
+	res, err = api.VerifyProof(ctx, *proof, common.Hash{1})
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.False(t, res.Exists)
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/debug"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/jsonrpc/watch"
+)
+
+var errWatchDisabled = errors.New("watch-lists are disabled, start rpcdaemon with --rpc.watchlists")
+
+// AddWatchList implements erigon_addWatchList. Registers watch-list of addresses: events about transactions
+// sent from or to them are delivered to `erigon_subscribe("watchEvents")` subscribers and to the webhook, if set.
+func (api *ErigonImpl) AddWatchList(ctx context.Context, addresses []common.Address, webhook *string) (*watch.List, error) {
+	if api.watcher == nil {
+		return nil, errWatchDisabled
+	}
+	var url string
+	if webhook != nil {
+		url = *webhook
+	}
+	return api.watcher.AddList(addresses, url)
+}
+
+// RemoveWatchList implements erigon_removeWatchList. Returns false if the watch-list is not found.
+func (api *ErigonImpl) RemoveWatchList(ctx context.Context, id string) (bool, error) {
+	if api.watcher == nil {
+		return false, errWatchDisabled
+	}
+	return api.watcher.RemoveList(id)
+}
+
+// GetWatchLists implements erigon_getWatchLists
+func (api *ErigonImpl) GetWatchLists(ctx context.Context) ([]watch.List, error) {
+	if api.watcher == nil {
+		return nil, errWatchDisabled
+	}
+	return api.watcher.Lists(), nil
+}
+
+// WatchEvents implements erigon_subscribe("watchEvents", id). Sends events of the watch-list, or of all watch-lists
+// if id is not set: `pending` when transaction enters the pool, `mined` when it's included into canonical block
+// and `reorged` when that block is orphaned.
+func (api *ErigonImpl) WatchEvents(ctx context.Context, id *string) (*rpc.Subscription, error) {
+	if api.watcher == nil {
+		return &rpc.Subscription{}, errWatchDisabled
+	}
+	var list string
+	if id != nil {
+		list = *id
+		if !api.watcher.HasList(list) {
+			return &rpc.Subscription{}, fmt.Errorf("watch-list %s not found", list)
+		}
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	events, unsubscribe := api.watcher.Subscribe(list)
+	go func() {
+		defer debug.LogPanic()
+		defer unsubscribe()
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := notifier.Notify(rpcSub.ID, ev); err != nil {
+					log.Warn("[rpc] error while notifying subscription", "err", err)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// watchFollower checks every new canonical block for transactions matching watch-lists
+func (api *ErigonImpl) watchFollower() *headFollower {
+	return &headFollower{
+		name:  "watch",
+		index: api.watcher,
+		indexBlock: func(ctx context.Context, tx kv.TemporalTx, blockNum uint64) (bool, error) {
+			block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
+			if err != nil || block == nil {
+				return false, err
+			}
+			txs := make([]watch.Tx, 0, len(block.Transactions()))
+			for _, txn := range block.Transactions() {
+				sender, _ := txn.GetSender()
+				txs = append(txs, watch.Tx{Hash: txn.Hash(), From: sender, To: txn.GetTo()})
+			}
+			return true, api.watcher.AddBlock(blockNum, block.Hash(), txs)
+		},
+	}
+}
+
+// followPendingTxs checks every transaction entering the pool for matching watch-lists until ctx is cancelled
+func (api *ErigonImpl) followPendingTxs(ctx context.Context, logger log.Logger) {
+	pending, id := api.filters.SubscribePendingTxs(256)
+	defer api.filters.UnsubscribePendingTxs(id)
+
+	var signer *types.Signer
+	for {
+		var txns []types.Transaction
+		select {
+		case <-ctx.Done():
+			return
+		case t, ok := <-pending:
+			if !ok {
+				return
+			}
+			txns = t
+		}
+		if signer == nil {
+			tx, err := api.db.BeginTemporalRo(ctx)
+			if err != nil {
+				logger.Warn("[rpc] watch: failed to read chain config", "err", err)
+				continue
+			}
+			chainConfig, err := api.chainConfig(ctx, tx)
+			tx.Rollback()
+			if err != nil {
+				logger.Warn("[rpc] watch: failed to read chain config", "err", err)
+				continue
+			}
+			signer = types.LatestSignerForChainID(chainConfig.ChainID)
+		}
+
+		txs := make([]watch.Tx, 0, len(txns))
+		for _, txn := range txns {
+			if txn == nil {
+				continue
+			}
+			sender, err := txn.Sender(*signer)
+			if err != nil {
+				continue
+			}
+			txs = append(txs, watch.Tx{Hash: txn.Hash(), From: sender, To: txn.GetTo()})
+		}
+		api.watcher.AddPendingTxs(txs)
+	}
+}
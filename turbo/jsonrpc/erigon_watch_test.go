@@ -0,0 +1,77 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/turbo/jsonrpc/watch"
+)
+
+func TestWatchFollowerMinedEvents(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewErigonAPI(newBaseApiForTest(m), m.DB, nil)
+	ctx := context.Background()
+
+	_, err := api.AddWatchList(ctx, []libcommon.Address{{1}}, nil)
+	require.ErrorIs(t, err, errWatchDisabled)
+
+	api.watcher, err = watch.NewWatcher(filepath.Join(t.TempDir(), "lists.json"), log.New())
+	require.NoError(t, err)
+	defer api.watcher.Close()
+	token := libcommon.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	list, err := api.AddWatchList(ctx, []libcommon.Address{token}, nil)
+	require.NoError(t, err)
+	lists, err := api.GetWatchLists(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []watch.List{*list}, lists)
+	events, unsubscribe := api.watcher.Subscribe(list.ID)
+	defer unsubscribe()
+
+	// pretend genesis was checked before restart: the follower catches up from block 1
+	tx, err := m.DB.BeginTemporalRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	genesis, _, err := m.BlockReader.CanonicalHash(ctx, tx, 0)
+	require.NoError(t, err)
+	require.NoError(t, api.watcher.AddBlock(0, genesis, nil))
+	require.NoError(t, api.indexHead(ctx, api.watchFollower(), 11))
+
+	// token is called by mint (block 4) and transfer (block 5), its creation has no `to`
+	var mined []uint64
+	for len(events) > 0 {
+		ev := <-events
+		require.Equal(t, watch.EventMined, ev.Type)
+		require.Equal(t, token, *ev.To)
+		mined = append(mined, uint64(*ev.BlockNumber))
+	}
+	require.Equal(t, []uint64{4, 5}, mined)
+	last, ok := api.watcher.LastBlock()
+	require.True(t, ok)
+	require.Equal(t, uint64(11), last)
+
+	removed, err := api.RemoveWatchList(ctx, list.ID)
+	require.NoError(t, err)
+	require.True(t, removed)
+}
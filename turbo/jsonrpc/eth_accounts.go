@@ -26,8 +26,8 @@ import (
 	"github.com/erigontech/erigon-lib/gointerfaces"
 	txpool_proto "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/rawdb"
 	"github.com/erigontech/erigon/rpc"
-	"github.com/erigontech/erigon/turbo/rpchelper"
 	"google.golang.org/grpc"
 )
 
@@ -38,7 +38,7 @@ func (api *APIImpl) GetBalance(ctx context.Context, address libcommon.Address, b
 		return nil, fmt.Errorf("getBalance cannot open tx: %w", err1)
 	}
 	defer tx.Rollback()
-	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, "")
+	reader, err := api.createStateReader(ctx, tx, blockNrOrHash, "")
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +57,7 @@ func (api *APIImpl) GetBalance(ctx context.Context, address libcommon.Address, b
 
 // GetTransactionCount implements eth_getTransactionCount. Returns the number of transactions sent from an address (the nonce).
 func (api *APIImpl) GetTransactionCount(ctx context.Context, address libcommon.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
-	if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
+	if isPendingBlock(blockNrOrHash) && api.txPool != nil {
 		reply, err := api.txPool.Nonce(ctx, &txpool_proto.NonceRequest{
 			Address: gointerfaces.ConvertAddressToH160(address),
 		}, &grpc.EmptyCallOption{})
@@ -74,7 +74,7 @@ func (api *APIImpl) GetTransactionCount(ctx context.Context, address libcommon.A
 		return nil, fmt.Errorf("getTransactionCount cannot open tx: %w", err1)
 	}
 	defer tx.Rollback()
-	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, "")
+	reader, err := api.createStateReader(ctx, tx, blockNrOrHash, "")
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +97,7 @@ func (api *APIImpl) GetCode(ctx context.Context, address libcommon.Address, bloc
 	if err != nil {
 		return nil, fmt.Errorf("read chain config: %v", err)
 	}
-	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+	reader, err := api.createStateReader(ctx, tx, blockNrOrHash, chainConfig.ChainName)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +129,7 @@ func (api *APIImpl) GetStorageAt(ctx context.Context, address libcommon.Address,
 	}
 	defer tx.Rollback()
 
-	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, "")
+	reader, err := api.createStateReader(ctx, tx, blockNrOrHash, "")
 	if err != nil {
 		return hexutil.Encode(libcommon.LeftPadBytes(empty, 32)), err
 	}
@@ -154,7 +154,7 @@ func (api *APIImpl) Exist(ctx context.Context, address libcommon.Address, blockN
 	}
 	defer tx.Rollback()
 
-	reader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, "")
+	reader, err := api.createStateReader(ctx, tx, blockNrOrHash, "")
 	if err != nil {
 		return false, err
 	}
@@ -168,3 +168,22 @@ func (api *APIImpl) Exist(ctx context.Context, address libcommon.Address, blockN
 
 	return true, nil
 }
+
+// GetKeyPreimage implements eth_getKeyPreimage. Returns the preimage of a hashed account address or storage key.
+// Preimages are recorded only by nodes started with --cache.preimages, returns null if preimage is unknown.
+func (api *APIImpl) GetKeyPreimage(ctx context.Context, hash libcommon.Hash) (hexutil.Bytes, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	preimage, err := rawdb.ReadPreimage(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(preimage) == 0 {
+		return nil, nil
+	}
+	return libcommon.CopyBytes(preimage), nil
+}
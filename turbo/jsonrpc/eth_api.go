@@ -52,6 +52,8 @@ import (
 	"github.com/erigontech/erigon/turbo/jsonrpc/receipts"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
+	"github.com/erigontech/erigon/turbo/shards"
+	"github.com/erigontech/erigon/txnprovider/userop"
 )
 
 // EthAPI is a collection of functions that are exposed in the
@@ -89,6 +91,13 @@ type EthAPI interface {
 	GetFilterChanges(_ context.Context, index string) ([]any, error)
 	GetFilterLogs(_ context.Context, index string) ([]*types.Log, error)
 	Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error)
+	SoftConfirmations(ctx context.Context) (*rpc.Subscription, error)                        // see ./eth_soft_confirmations.go
+	PendingLogs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) // see ./eth_pending_logs.go
+
+	// User operation related (see ./eth_userop.go)
+	SendUserOperation(ctx context.Context, op userop.UserOperation, entryPoint common.Address) (common.Hash, error)
+	SupportedEntryPoints(_ context.Context) ([]common.Address, error)
+	GetUserOperationReceipt(ctx context.Context, hash common.Hash) (*userop.Receipt, error)
 
 	// Account related (see ./eth_accounts.go)
 	Accounts(ctx context.Context) ([]common.Address, error)
@@ -96,13 +105,14 @@ type EthAPI interface {
 	GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error)
 	GetStorageAt(ctx context.Context, address common.Address, index string, blockNrOrHash rpc.BlockNumberOrHash) (string, error)
 	GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error)
+	GetKeyPreimage(ctx context.Context, hash common.Hash) (hexutil.Bytes, error)
 
 	// System related (see ./eth_system.go)
 	BlockNumber(ctx context.Context) (hexutil.Uint64, error)
 	Syncing(ctx context.Context) (interface{}, error)
 	ChainId(ctx context.Context) (hexutil.Uint64, error) /* called eth_protocolVersion elsewhere */
 	ProtocolVersion(_ context.Context) (hexutil.Uint, error)
-	GasPrice(_ context.Context) (*hexutil.Big, error)
+	GasPrice(_ context.Context, strategy *string) (*hexutil.Big, error)
 
 	// Sending related (see ./eth_call.go)
 	Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Bytes, error)
@@ -144,6 +154,9 @@ type BaseAPI struct {
 	dirs                datadir.Dirs
 	receiptsGenerator   *receipts.Generator
 	borReceiptGenerator *receipts.BorGenerator
+
+	nonCanonicalBlocks *shards.NonCanonicalBlocks // recently displaced by reorgs, nil if not embedded
+	recentReceipts     *shards.RecentReceipts     // of the latest executed blocks, nil if not embedded
 }
 
 func NewBaseApi(f *rpchelper.Filters, stateCache kvcache.Cache, blockReader services.FullBlockReader, singleNodeMode bool, evmCallTimeout time.Duration, engine consensus.EngineReader, dirs datadir.Dirs, bridgeReader bridgeReader) *BaseAPI {
@@ -319,6 +332,19 @@ func (api *BaseAPI) stateSyncEvents(ctx context.Context, tx kv.Tx, blockHash com
 	return stateSyncEvents, nil
 }
 
+// markImmutableIfFinalized lets the HTTP response cache (--http.responsecache) store the result
+// of the request if it's the data of the finalized block
+func (api *BaseAPI) markImmutableIfFinalized(ctx context.Context, tx kv.Tx, blockNum uint64) {
+	if !rpc.ImmutableResultCacheable(ctx) {
+		return
+	}
+	finalized, err := rpchelper.GetFinalizedBlockNumber(tx)
+	if err != nil || blockNum > finalized {
+		return
+	}
+	rpc.MarkImmutable(ctx)
+}
+
 // checks the pruning state to see if we would hold information about this
 // block in state history or not.  Some strange issues arise getting account
 // history for blocks that have been pruned away giving nonce too low errors
@@ -382,8 +408,14 @@ type APIImpl struct {
 	FeeCap                      float64
 	ReturnDataLimit             int
 	AllowUnprotectedTxs         bool
+	GethCompatErrors            bool
+	GasPriceStrategy            string // default gas price oracle strategy, overridable per request
 	MaxGetProofRewindBlockCount int
 	SubscribeLogsChannelSize    int
+	softConfirmations           *shards.Events // block builder in sequencer mode, nil otherwise
+	userOps                     *userop.Pool   // ERC-4337 user operation mempool, nil if disabled
+	pending                     *pendingStateCache
+	pendingLogs                 *pendingLogsFeed
 	logger                      log.Logger
 }
 
@@ -410,6 +442,8 @@ func NewEthAPI(base *BaseAPI, db kv.TemporalRoDB, eth rpchelper.ApiBackend, txPo
 		ReturnDataLimit:             returnDataLimit,
 		MaxGetProofRewindBlockCount: maxGetProofRewindBlockCount,
 		SubscribeLogsChannelSize:    subscribeLogsChannelSize,
+		pending:                     &pendingStateCache{},
+		pendingLogs:                 &pendingLogsFeed{},
 		logger:                      logger,
 	}
 }
@@ -434,32 +468,35 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) (hexutil.B
 	return buf.Bytes(), err
 }
 
+// GasPriceCache keeps the latest suggested gas price of each oracle strategy
 type GasPriceCache struct {
-	latestPrice *big.Int
-	latestHash  common.Hash
-	mtx         sync.Mutex
+	latest map[string]gasPriceCacheEntry
+	mtx    sync.Mutex
+}
+
+type gasPriceCacheEntry struct {
+	hash  common.Hash
+	price *big.Int
 }
 
 func NewGasPriceCache() *GasPriceCache {
 	return &GasPriceCache{
-		latestPrice: big.NewInt(0),
-		latestHash:  common.Hash{},
+		latest: map[string]gasPriceCacheEntry{},
 	}
 }
 
-func (c *GasPriceCache) GetLatest() (common.Hash, *big.Int) {
-	var hash common.Hash
-	var price *big.Int
+func (c *GasPriceCache) GetLatest(strategy string) (common.Hash, *big.Int) {
 	c.mtx.Lock()
-	hash = c.latestHash
-	price = c.latestPrice
-	c.mtx.Unlock()
-	return hash, price
+	defer c.mtx.Unlock()
+	e, ok := c.latest[strategy]
+	if !ok {
+		return common.Hash{}, big.NewInt(0)
+	}
+	return e.hash, e.price
 }
 
-func (c *GasPriceCache) SetLatest(hash common.Hash, price *big.Int) {
+func (c *GasPriceCache) SetLatest(strategy string, hash common.Hash, price *big.Int) {
 	c.mtx.Lock()
-	c.latestPrice = price
-	c.latestHash = hash
+	c.latest[strategy] = gasPriceCacheEntry{hash: hash, price: price}
 	c.mtx.Unlock()
 }
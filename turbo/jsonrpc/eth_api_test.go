@@ -19,6 +19,7 @@ package jsonrpc
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/datadir"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
 	"github.com/erigontech/erigon-lib/kv/kvcache"
 	"github.com/erigontech/erigon-lib/log/v3"
 	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
@@ -253,6 +255,33 @@ func TestCall_ByBlockHash_WithRequireCanonicalTrue_NonCanonicalBlock(t *testing.
 	}
 }
 
+func TestGetKeyPreimage(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t, mock.WithPreimages())
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+	contract := common.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+
+	tx, err := m.DB.BeginTemporalRo(m.Ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	storage, err := storageRangeAt(tx, contract, nil, math.MaxUint64, 16)
+	require.NoError(t, err)
+	require.NotEmpty(t, storage.Storage)
+
+	for seckey, entry := range storage.Storage {
+		preimage, err := api.GetKeyPreimage(m.Ctx, seckey)
+		require.NoError(t, err)
+		require.Equal(t, entry.Key.Bytes(), []byte(preimage))
+	}
+
+	preimage, err := api.GetKeyPreimage(m.Ctx, crypto.Keccak256Hash(contract[:]))
+	require.NoError(t, err)
+	require.Equal(t, contract.Bytes(), []byte(preimage))
+
+	preimage, err = api.GetKeyPreimage(m.Ctx, common.Hash{1})
+	require.NoError(t, err)
+	require.Nil(t, preimage)
+}
+
 func TestUseBridgeReader(t *testing.T) {
 	// test for Go's interface nil-ness caveat - https://codefibershq.com/blog/golang-why-nil-is-not-always-nil
 	var br *mockBridgeReader
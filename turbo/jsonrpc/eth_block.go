@@ -184,6 +184,7 @@ func (api *APIImpl) CallBundle(ctx context.Context, txHashes []common.Hash, stat
 		if err != nil {
 			return nil, err
 		}
+		rpc.CostFromContext(ctx).AddGas(result.UsedGas)
 		// If the timer caused an abort, return an appropriate error message
 		if evm.Cancelled() {
 			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
@@ -288,17 +289,29 @@ func (api *APIImpl) GetBlockByHash(ctx context.Context, numberOrHash rpc.BlockNu
 		return nil, err
 	}
 	if block == nil {
-		return nil, nil // not error, see https://github.com/erigontech/erigon/issues/1645
+		// displaced by a reorg and already gone from the db
+		if block = api.nonCanonicalBlocks.Get(hash); block == nil {
+			return nil, nil // not error, see https://github.com/erigontech/erigon/issues/1645
+		}
 	}
 	number := block.NumberU64()
 
+	canonicalHash, ok, err := api._blockReader.CanonicalHash(ctx, tx, number)
+	if err != nil {
+		return nil, err
+	}
+	canonical := ok && canonicalHash == hash
+	if !canonical {
+		additionalFields["canonical"] = false
+	}
+
 	chainConfig, err := api.chainConfig(ctx, tx)
 	if err != nil {
 		return nil, err
 	}
 	var borTx types.Transaction
 	var borTxHash common.Hash
-	if chainConfig.Bor != nil {
+	if chainConfig.Bor != nil && canonical {
 		if api.useBridgeReader {
 			possibleBorTxnHash := bortypes.ComputeBorTxHash(block.NumberU64(), block.Hash())
 			_, ok, err := api.bridgeReader.EventTxnLookup(ctx, possibleBorTxnHash)
@@ -325,6 +338,7 @@ func (api *APIImpl) GetBlockByHash(ctx context.Context, numberOrHash rpc.BlockNu
 		}
 	}
 
+	api.markImmutableIfFinalized(ctx, tx, number)
 	return response, err
 }
 
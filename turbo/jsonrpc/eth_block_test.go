@@ -39,6 +39,7 @@ import (
 	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/rpc/rpccfg"
 	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/shards"
 	"github.com/erigontech/erigon/turbo/stages/mock"
 )
 
@@ -191,6 +192,32 @@ func TestGetBlockByNumber_WithSafeTag_WithSafeBlockInDb(t *testing.T) {
 	assert.Equal(t, expectedHash, block["hash"])
 }
 
+func TestGetBlockByHash_NonCanonical(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx := context.Background()
+	base := newBaseApiForTest(m)
+	base.nonCanonicalBlocks = shards.NewNonCanonicalBlocks(8)
+	api := NewEthAPI(base, m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+
+	canonical, err := api.GetBlockByNumber(ctx, rpc.BlockNumber(5), false)
+	assert.NoError(t, err)
+	b, err := api.GetBlockByHash(ctx, rpc.BlockNumberOrHashWithHash(canonical["hash"].(common.Hash), false), false)
+	assert.NoError(t, err)
+	assert.NotContains(t, b, "canonical")
+
+	// displaced by a reorg and gone from the db
+	displaced := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(5), Extra: []byte("fork")})
+	b, err = api.GetBlockByHash(ctx, rpc.BlockNumberOrHashWithHash(displaced.Hash(), false), false)
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+
+	base.nonCanonicalBlocks.Add(displaced)
+	b, err = api.GetBlockByHash(ctx, rpc.BlockNumberOrHashWithHash(displaced.Hash(), false), false)
+	assert.NoError(t, err)
+	assert.Equal(t, displaced.Hash(), b["hash"])
+	assert.Equal(t, false, b["canonical"])
+}
+
 func TestGetBlockTransactionCountByHash(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	ctx := context.Background()
@@ -78,25 +78,36 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 		args.Gas = (*hexutil.Uint64)(&api.GasCap)
 	}
 
-	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(ctx, blockNrOrHash, tx, api._blockReader, api.filters) // DoCall cannot be executed on non-canonical blocks
-	if err != nil {
-		return nil, err
-	}
-	block, err := api.blockWithSenders(ctx, tx, hash, blockNumber)
-	if err != nil {
-		return nil, err
-	}
-	if block == nil {
-		return nil, nil
-	}
+	var header *types.Header
+	var stateReader state.StateReader
+	if isPendingBlock(blockNrOrHash) {
+		if stateReader, header, err = api.pendingState(ctx, tx, chainConfig); err != nil {
+			return nil, err
+		}
+	} else {
+		blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(ctx, blockNrOrHash, tx, api._blockReader, api.filters) // DoCall cannot be executed on non-canonical blocks
+		if err != nil {
+			return nil, err
+		}
+		block, err := api.blockWithSenders(ctx, tx, hash, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, nil
+		}
 
-	stateReader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
-	if err != nil {
-		return nil, err
+		stateReader, err = rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+		if err != nil {
+			return nil, err
+		}
+		header = block.HeaderNoCopy()
 	}
-	header := block.HeaderNoCopy()
 	result, err := transactions.DoCall(ctx, engine, args, tx, blockNrOrHash, header, overrides, api.GasCap, chainConfig, stateReader, api._blockReader, api.evmCallTimeout)
 	if err != nil {
+		if api.GethCompatErrors {
+			return nil, gethCallError(err, uint64(*args.Gas))
+		}
 		return nil, err
 	}
 
@@ -105,7 +116,8 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 	}
 
 	// If the result contains a revert reason, try to unpack and return it.
-	if len(result.Revert()) > 0 {
+	// geth returns revert error (code 3, data "0x") even if there is no revert reason
+	if len(result.Revert()) > 0 || (api.GethCompatErrors && errors.Is(result.Err, vm.ErrExecutionReverted)) {
 		return nil, ethapi2.NewRevertError(result)
 	}
 
@@ -258,11 +270,14 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 	// First try with highest gas possible
 	result, err := caller.DoCallWithNewGas(ctx, hi, engine, overrides)
 	if err != nil || result == nil {
+		if err != nil && api.GethCompatErrors {
+			return 0, gethEstimateGasError(err)
+		}
 		return 0, err
 	}
 	if result.Failed() {
 		if !errors.Is(result.Err, vm.ErrOutOfGas) {
-			if len(result.Revert()) > 0 {
+			if len(result.Revert()) > 0 || (api.GethCompatErrors && errors.Is(result.Err, vm.ErrExecutionReverted)) {
 				return 0, ethapi2.NewRevertError(result)
 			}
 			return 0, result.Err
@@ -285,6 +300,9 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 		// call or transaction will never be accepted no matter how much gas it is
 		// assigened. Return the error directly, don't struggle any more.
 		if err != nil {
+			if api.GethCompatErrors {
+				return 0, gethEstimateGasError(err)
+			}
 			return 0, err
 		}
 		if result.Failed() || result.EvmGasUsed < trueGas {
@@ -828,6 +846,7 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi2.CallArgs,
 		if err != nil {
 			return nil, err
 		}
+		rpc.CostFromContext(ctx).AddGas(res.UsedGas)
 		if tracer.Equal(prevTracer) {
 			var errString string
 			if res.Err != nil {
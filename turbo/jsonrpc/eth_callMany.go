@@ -217,10 +217,11 @@ func (api *APIImpl) CallMany(ctx context.Context, bundles []Bundle, simulateCont
 		txCtx = core.NewEVMTxContext(msg)
 		evm = vm.NewEVM(blockCtx, txCtx, evm.IntraBlockState(), chainConfig, vm.Config{Debug: false})
 		// Execute the transaction message
-		_, err = core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, api.engine())
+		res, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, api.engine())
 		if err != nil {
 			return nil, err
 		}
+		rpc.CostFromContext(ctx).AddGas(res.UsedGas)
 
 		_ = st.FinalizeTx(rules, state.NewNoopWriter())
 
@@ -281,6 +282,7 @@ func (api *APIImpl) CallMany(ctx context.Context, bundles []Bundle, simulateCont
 			if err != nil {
 				return nil, err
 			}
+			rpc.CostFromContext(ctx).AddGas(result.UsedGas)
 
 			_ = st.FinalizeTx(rules, state.NewNoopWriter())
 
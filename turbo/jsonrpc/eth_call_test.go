@@ -70,6 +70,22 @@ func TestEstimateGas(t *testing.T) {
 	}
 }
 
+func TestEstimateGasGethCompatErrors(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+	api.GethCompatErrors = true
+	// account without balance: gas allowance is capped below intrinsic gas
+	var from = libcommon.HexToAddress("0x000000000000000000000000000000000000dead")
+	var to = libcommon.HexToAddress("0x0d3ab14bbad3d99f4203bd7a11acb94882050e7e")
+	_, err := api.EstimateGas(context.Background(), &ethapi.CallArgs{
+		From:     &from,
+		To:       &to,
+		GasPrice: (*hexutil.Big)(big.NewInt(1)),
+	}, nil, nil)
+	// unlike eth_call, geth doesn't wrap pre-check errors of eth_estimateGas into "err: ... (supplied gas N)"
+	require.EqualError(t, err, "insufficient funds for gas * price + value: address 0x000000000000000000000000000000000000dEaD have 0 want 5000000")
+}
+
 func TestEthCallNonCanonical(t *testing.T) {
 	m, _, _ := rpcdaemontest.CreateTestSentry(t)
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
@@ -0,0 +1,267 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+	txpool_proto "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/types/accounts"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/shards"
+)
+
+func isPendingBlock(blockNrOrHash rpc.BlockNumberOrHash) bool {
+	return blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber
+}
+
+// createStateReader is rpchelper.CreateStateReader with the "pending" block tag materialized, see pendingState
+func (api *APIImpl) createStateReader(ctx context.Context, tx kv.TemporalTx, blockNrOrHash rpc.BlockNumberOrHash, chainName string) (state.StateReader, error) {
+	if !isPendingBlock(blockNrOrHash) {
+		return rpchelper.CreateStateReader(ctx, tx, api._blockReader, blockNrOrHash, 0, api.filters, api.stateCache, chainName)
+	}
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	reader, _, err := api.pendingState(ctx, tx, chainConfig)
+	return reader, err
+}
+
+// pendingStateRefresh is how often at most the pending state assembled from the pool is rebuilt, while the head and
+// the block builder don't change
+const pendingStateRefresh = time.Second
+
+// pendingStateCache keeps the writes of the pending transactions on top of one head, shared by the "pending" requests.
+// Once built, the writes are never modified: a change of the head, of the builder's block or of the pool builds new ones.
+type pendingStateCache struct {
+	mu          sync.Mutex // held while building, so that concurrent requests build once
+	head        common.Hash
+	block       common.Hash // of the builder's block the writes are from, zero if assembled from the pool
+	poolVersion uint64
+	built       time.Time
+	header      *types.Header
+	writes      *shards.StateCache
+}
+
+func (c *pendingStateCache) valid(head common.Hash, block *types.Block, poolVersion uint64) bool {
+	if c.writes == nil || c.head != head {
+		return false
+	}
+	if block != nil {
+		return c.block == block.Hash()
+	}
+	return c.block == (common.Hash{}) && (c.poolVersion == poolVersion || time.Since(c.built) < pendingStateRefresh)
+}
+
+// pendingState materializes the state as of the pending block: the latest state with the transactions of the block
+// being built by the local block builder applied, or - when not building - the ones of a block speculatively
+// assembled from the pending sub-pool. Returns the header of the pending block too. The state is built once per
+// head and builder's block or pool change, see pendingStateCache.
+func (api *APIImpl) pendingState(ctx context.Context, tx kv.TemporalTx, chainConfig *chain.Config) (state.StateReader, *types.Header, error) {
+	latest, err := api.headerByRPCNumber(ctx, rpc.LatestBlockNumber, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if latest == nil {
+		return nil, nil, errors.New("latest header not found")
+	}
+	latestReader, err := rpchelper.CreateStateReader(ctx, tx, api._blockReader, latestNumOrHash, 0, api.filters, api.stateCache, chainConfig.ChainName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var block *types.Block
+	if b := api.pendingBlock(); b != nil && b.ParentHash() == latest.Hash() {
+		block = b
+	}
+	var poolVersion uint64
+	if api.filters != nil {
+		poolVersion = api.filters.PendingTxsVersion()
+	}
+
+	c := api.pending
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.valid(latest.Hash(), block, poolVersion) {
+		header, writes, err := api.buildPendingState(ctx, tx, chainConfig, latest, latestReader, block)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.head, c.poolVersion, c.built, c.header, c.writes = latest.Hash(), poolVersion, time.Now(), header, writes
+		c.block = common.Hash{}
+		if block != nil {
+			c.block = block.Hash()
+		}
+	}
+	return &pendingReader{StateReader: latestReader, writes: c.writes}, types.CopyHeader(c.header), nil
+}
+
+// buildPendingState applies the transactions of the pending block on top of the latest state. Like the block builder,
+// it runs no block level system calls and skips the transactions that fail.
+func (api *APIImpl) buildPendingState(ctx context.Context, tx kv.TemporalTx, chainConfig *chain.Config, latest *types.Header, latestReader state.StateReader, block *types.Block) (*types.Header, *shards.StateCache, error) {
+	var header *types.Header
+	var txs types.Transactions
+	if block != nil {
+		header, txs = block.Header(), block.Transactions()
+	} else {
+		header = core.MakeEmptyHeader(latest, chainConfig, max(latest.Time+1, uint64(time.Now().Unix())), nil)
+		var err error
+		if txs, err = api.pendingPoolTxns(ctx, header); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	writes := shards.NewStateCache(32, 0 /* no limit */)
+	reader := state.NewCachedReader(latestReader, writes)
+	writer := state.NewCachedWriter(state.NewNoopWriter(), writes)
+	ibs := state.New(reader)
+
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		h, _ := api._blockReader.Header(ctx, tx, hash, number)
+		return h
+	}
+	getHashFn := core.GetHashFn(header, getHeader)
+	gp := new(core.GasPool).AddGas(header.GasLimit).AddBlobGas(chainConfig.GetMaxBlobGasPerBlock(header.Time))
+	var usedGas, usedBlobGas uint64
+	for i, txn := range txs {
+		if err := common.Stopped(ctx.Done()); err != nil {
+			return nil, nil, err
+		}
+		snapshot := ibs.Snapshot()
+		ibs.SetTxContext(i)
+		if _, _, err := core.ApplyTransaction(chainConfig, getHashFn, api.engine(), &header.Coinbase, gp, ibs, writer, header, txn, &usedGas, &usedBlobGas, vm.Config{}); err != nil {
+			// doesn't fit into the block or isn't valid on top of the previous ones, the block builder would skip it too
+			ibs.RevertToSnapshot(snapshot)
+		}
+	}
+	return header, writes, nil
+}
+
+// pendingReader reads the pending state: the writes of the pending transactions, then the latest state. The writes
+// are shared between requests, so unlike state.CachedReader it doesn't cache what it reads into them.
+type pendingReader struct {
+	state.StateReader // latest state
+	writes            *shards.StateCache
+}
+
+func (r *pendingReader) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	if a, ok := r.writes.GetAccount(address.Bytes()); ok {
+		if a == nil {
+			return nil, nil
+		}
+		var acc accounts.Account
+		acc.Copy(a)
+		return &acc, nil
+	}
+	return r.StateReader.ReadAccountData(address)
+}
+
+func (r *pendingReader) ReadAccountDataForDebug(address common.Address) (*accounts.Account, error) {
+	return r.ReadAccountData(address)
+}
+
+func (r *pendingReader) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	if v, ok := r.writes.GetStorage(address.Bytes(), incarnation, key.Bytes()); ok {
+		return v, nil
+	}
+	return r.StateReader.ReadAccountStorage(address, incarnation, key)
+}
+
+func (r *pendingReader) ReadAccountCode(address common.Address, incarnation uint64) ([]byte, error) {
+	if c, ok := r.writes.GetCode(address.Bytes(), incarnation); ok {
+		return bytes.Clone(c), nil
+	}
+	return r.StateReader.ReadAccountCode(address, incarnation)
+}
+
+func (r *pendingReader) ReadAccountCodeSize(address common.Address, incarnation uint64) (int, error) {
+	c, err := r.ReadAccountCode(address, incarnation)
+	return len(c), err
+}
+
+func (r *pendingReader) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	if deleted := r.writes.GetDeletedAccount(address.Bytes()); deleted != nil {
+		return deleted.Incarnation, nil
+	}
+	return r.StateReader.ReadAccountIncarnation(address)
+}
+
+// pendingPoolTxns returns the transactions of the pending sub-pool: in nonce order per sender, senders ordered by
+// the tip of their first transaction
+func (api *APIImpl) pendingPoolTxns(ctx context.Context, header *types.Header) (types.Transactions, error) {
+	if api.txPool == nil {
+		return nil, nil
+	}
+	reply, err := api.txPool.All(ctx, &txpool_proto.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+	bySender := make(map[common.Address]types.Transactions)
+	for _, t := range reply.Txs {
+		if t.TxnType != txpool_proto.AllReply_PENDING {
+			continue
+		}
+		txn, err := types.DecodeWrappedTransaction(t.RlpTx)
+		if err != nil {
+			return nil, fmt.Errorf("decoding transaction from: %x: %w", t.RlpTx, err)
+		}
+		sender := gointerfaces.ConvertH160toAddress(t.Sender)
+		txn.SetSender(sender)
+		bySender[sender] = append(bySender[sender], txn)
+	}
+
+	var baseFee *uint256.Int
+	if header.BaseFee != nil {
+		baseFee, _ = uint256.FromBig(header.BaseFee)
+	}
+	senders := make([]common.Address, 0, len(bySender))
+	for sender, txns := range bySender {
+		slices.SortFunc(txns, func(a, b types.Transaction) int { return cmp.Compare(a.GetNonce(), b.GetNonce()) })
+		senders = append(senders, sender)
+	}
+	slices.SortFunc(senders, func(a, b common.Address) int {
+		if c := bySender[b][0].GetEffectiveGasTip(baseFee).Cmp(bySender[a][0].GetEffectiveGasTip(baseFee)); c != 0 {
+			return c
+		}
+		return bytes.Compare(a[:], b[:])
+	})
+
+	txs := make(types.Transactions, 0, len(reply.Txs))
+	for _, sender := range senders {
+		txs = append(txs, bySender[sender]...)
+	}
+	return txs, nil
+}
@@ -0,0 +1,330 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/debug"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/math"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+const (
+	pendingLogsQueueSize = 1024                   // pool txs waiting for the speculative execution, newer ones are dropped
+	pendingLogsBatchSize = 64                     // txs executed on the same head state
+	pendingLogsTxTimeout = 100 * time.Millisecond // of the execution of one tx
+)
+
+// SpeculativeLog is a log a pool transaction would emit if it was included on top of the head block.
+// It has no block hash, number and index: the transaction may be included later, differently or not at all.
+type SpeculativeLog struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`         // within the transaction
+	StateBlock  hexutil.Uint64 `json:"stateBlockNumber"` // the head the transaction was executed on
+	Speculative bool           `json:"speculative"`      // always true, to tell them from the logs of "logs" subscriptions
+}
+
+// PendingLogs implements eth_subscribe("pendingLogs", filter). Executes every transaction entering the pool on top
+// of the head state, each one separately, and sends the logs matching the filter it would emit. Reverted transactions
+// emit nothing. Execution is low priority: transactions arriving faster than they are executed are dropped.
+// The block range of the filter is ignored.
+func (api *APIImpl) PendingLogs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	api.pendingLogs.subscribe(api, rpcSub.ID, newPendingLogsSub(notifier, crit))
+	go func() {
+		defer debug.LogPanic()
+		<-rpcSub.Err()
+		api.pendingLogs.unsubscribe(rpcSub.ID)
+	}()
+	return rpcSub, nil
+}
+
+type pendingLogsSub struct {
+	notifier *rpc.Notifier
+	crit     filters.FilterCriteria
+	addrMap  map[common.Address]struct{}
+}
+
+func newPendingLogsSub(notifier *rpc.Notifier, crit filters.FilterCriteria) *pendingLogsSub {
+	addrMap := make(map[common.Address]struct{}, len(crit.Addresses))
+	for _, addr := range crit.Addresses {
+		addrMap[addr] = struct{}{}
+	}
+	return &pendingLogsSub{notifier: notifier, crit: crit, addrMap: addrMap}
+}
+
+// pendingLogsFeed executes every pool transaction once for all the pendingLogs subscriptions and fans the logs out
+// to them. The execution runs while there are subscriptions, and takes at most one core away from the regular requests.
+type pendingLogsFeed struct {
+	mu     sync.Mutex
+	subs   map[rpc.ID]*pendingLogsSub
+	cancel context.CancelFunc // of the execution
+}
+
+func (f *pendingLogsFeed) subscribe(api *APIImpl, id rpc.ID, sub *pendingLogsSub) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = map[rpc.ID]*pendingLogsSub{}
+	}
+	if len(f.subs) == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		f.cancel = cancel
+		go f.run(ctx, api)
+	}
+	f.subs[id] = sub
+}
+
+func (f *pendingLogsFeed) unsubscribe(id rpc.ID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs, id)
+	if len(f.subs) == 0 && f.cancel != nil {
+		f.cancel()
+		f.cancel = nil
+	}
+}
+
+func (f *pendingLogsFeed) run(ctx context.Context, api *APIImpl) {
+	queue := make(chan types.Transaction, pendingLogsQueueSize)
+	go func() {
+		defer debug.LogPanic()
+		defer close(queue)
+		txsCh, id := api.filters.SubscribePendingTxs(256)
+		defer api.filters.UnsubscribePendingTxs(id)
+
+		var dropped int
+		defer func() {
+			if dropped > 0 {
+				log.Debug("[rpc] pending logs lagged behind the pool", "dropped", dropped)
+			}
+		}()
+		for {
+			select {
+			case txs, ok := <-txsCh:
+				for _, t := range txs {
+					if t == nil {
+						continue
+					}
+					select {
+					case queue <- t:
+					default:
+						dropped++
+					}
+				}
+				if !ok {
+					log.Warn("[rpc] new pending transactions channel was closed")
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	defer debug.LogPanic()
+	batch := make([]types.Transaction, 0, pendingLogsBatchSize)
+	for t := range queue {
+		batch = append(batch[:0], t)
+	fill:
+		for len(batch) < pendingLogsBatchSize {
+			select {
+			case t, ok := <-queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, t)
+			default:
+				break fill
+			}
+		}
+		executed, err := api.speculateLogs(ctx, batch)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn("[rpc] speculative execution of pending transactions", "err", err)
+			}
+			continue
+		}
+		f.notify(executed)
+	}
+}
+
+func (f *pendingLogsFeed) notify(executed []speculatedTx) {
+	if len(executed) == 0 {
+		return
+	}
+	f.mu.Lock()
+	subs := make(map[rpc.ID]*pendingLogsSub, len(f.subs))
+	maps.Copy(subs, f.subs)
+	f.mu.Unlock()
+	for id, sub := range subs {
+		for _, l := range matchSpeculativeLogs(executed, sub.crit, sub.addrMap) {
+			if err := sub.notifier.Notify(id, l); err != nil {
+				log.Warn("[rpc] error while notifying subscription", "err", err)
+			}
+		}
+	}
+}
+
+// speculatedTx is a pool transaction executed on top of the head state, that emitted logs
+type speculatedTx struct {
+	hash       common.Hash
+	logs       types.Logs
+	bloom      types.Bloom
+	stateBlock uint64
+}
+
+// speculateLogs executes the transactions on top of the head state, each one separately
+func (api *APIImpl) speculateLogs(ctx context.Context, txns []types.Transaction) ([]speculatedTx, error) {
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := api.headerByRPCNumber(ctx, rpc.LatestBlockNumber, tx)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, nil
+	}
+	cacheView, err := api.stateCache.View(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	stateReader := rpchelper.CreateLatestCachedStateReader(cacheView, tx)
+
+	header := core.MakeEmptyHeader(parent, chainConfig, parent.Time+chainConfig.SecondsPerSlot(), nil)
+	header.Coinbase = parent.Coinbase
+	engine := api.engine()
+	blockCtx := transactions.NewEVMBlockContext(engine, header, true, tx, api._blockReader, chainConfig)
+	signer := types.MakeSigner(chainConfig, header.Number.Uint64(), header.Time)
+	rules := chainConfig.Rules(header.Number.Uint64(), header.Time)
+
+	var res []speculatedTx
+	for _, txn := range txns {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		msg, err := txn.AsMessage(*signer, header.BaseFee, rules)
+		if err != nil { // can't be included
+			continue
+		}
+		msg.SetCheckNonce(false)
+		ibs := state.New(stateReader)
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), ibs, chainConfig, vm.Config{})
+		execCtx, cancel := context.WithTimeout(ctx, pendingLogsTxTimeout)
+		go func() {
+			<-execCtx.Done()
+			evm.Cancel()
+		}()
+		gp := new(core.GasPool).AddGas(header.GasLimit).AddBlobGas(math.MaxUint64)
+		result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, engine)
+		cancel()
+		if err != nil || result.Failed() || evm.Cancelled() {
+			continue
+		}
+		if logs := ibs.Logs(); len(logs) > 0 {
+			res = append(res, speculatedTx{hash: txn.Hash(), logs: logs, bloom: types.BytesToBloom(types.LogsBloom(logs)), stateBlock: parent.Number.Uint64()})
+		}
+	}
+	return res, nil
+}
+
+// matchSpeculativeLogs returns the logs of the executed transactions matching the filter
+func matchSpeculativeLogs(executed []speculatedTx, crit filters.FilterCriteria, addrMap map[common.Address]struct{}) []*SpeculativeLog {
+	var res []*SpeculativeLog
+	for _, t := range executed {
+		if !bloomMatchesFilter(t.bloom, crit) {
+			continue
+		}
+		index := make(map[*types.Log]int, len(t.logs))
+		for i, l := range t.logs {
+			index[l] = i
+		}
+		for _, l := range t.logs.Filter(addrMap, crit.Topics, 0) {
+			res = append(res, &SpeculativeLog{
+				Address:     l.Address,
+				Topics:      l.Topics,
+				Data:        l.Data,
+				TxHash:      t.hash,
+				LogIndex:    hexutil.Uint(index[l]),
+				StateBlock:  hexutil.Uint64(t.stateBlock),
+				Speculative: true,
+			})
+		}
+	}
+	return res
+}
+
+// bloomMatchesFilter rejects the logs of a transaction by their bloom before the logs are matched one by one:
+// some of the filter addresses and, for every topic position, some of the topics must be in the bloom
+func bloomMatchesFilter(bloom types.Bloom, crit filters.FilterCriteria) bool {
+	if len(crit.Addresses) > 0 {
+		var included bool
+		for _, addr := range crit.Addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, sub := range crit.Topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/core/vm"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestSpeculateLogs(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	ctx := context.Background()
+
+	// init code emitting a log with the topic
+	topic := common.HexToHash("0x1234")
+	initCode := append([]byte{byte(vm.PUSH32)}, topic[:]...)
+	initCode = append(initCode, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG1))
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	// the nonce is ahead of the head state, as of a transaction queued in the pool
+	txn, err := types.SignTx(types.NewContractCreation(1000, new(uint256.Int), 100_000, uint256.NewInt(100*params.GWei), initCode), *signer, key)
+	require.NoError(t, err)
+
+	executed, err := api.speculateLogs(ctx, []types.Transaction{txn})
+	require.NoError(t, err)
+	require.Len(t, executed, 1)
+
+	// executed once, matched against every subscription filter
+	crit := filters.FilterCriteria{Topics: [][]common.Hash{{topic}}}
+	logs := matchSpeculativeLogs(executed, crit, newPendingLogsSub(nil, crit).addrMap)
+	require.Len(t, logs, 1)
+	require.True(t, logs[0].Speculative)
+	require.Equal(t, txn.Hash(), logs[0].TxHash)
+	require.Equal(t, []common.Hash{topic}, logs[0].Topics)
+
+	crit = filters.FilterCriteria{Addresses: []common.Address{sender}}
+	require.Empty(t, matchSpeculativeLogs(executed, crit, newPendingLogsSub(nil, crit).addrMap))
+}
+
+func TestBloomMatchesFilter(t *testing.T) {
+	addr, topic := common.HexToAddress("0x01"), common.HexToHash("0x02")
+	bloom := types.BytesToBloom(types.LogsBloom([]*types.Log{{Address: addr, Topics: []common.Hash{topic}}}))
+
+	require.True(t, bloomMatchesFilter(bloom, filters.FilterCriteria{}))
+	require.True(t, bloomMatchesFilter(bloom, filters.FilterCriteria{Addresses: []common.Address{common.HexToAddress("0x03"), addr}}))
+	require.True(t, bloomMatchesFilter(bloom, filters.FilterCriteria{Topics: [][]common.Hash{{}, {topic}}}))
+	require.False(t, bloomMatchesFilter(bloom, filters.FilterCriteria{Addresses: []common.Address{common.HexToAddress("0x03")}}))
+	require.False(t, bloomMatchesFilter(bloom, filters.FilterCriteria{Topics: [][]common.Hash{{common.HexToHash("0x03")}}}))
+}
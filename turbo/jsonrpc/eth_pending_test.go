@@ -0,0 +1,105 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
+	"github.com/erigontech/erigon-lib/kv/kvcache"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon-lib/rlp"
+	"github.com/erigontech/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/rpc/rpccfg"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestPendingState(t *testing.T) {
+	m := mock.Mock(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	mining := txpool.NewMiningClient(conn)
+	ff := rpchelper.New(ctx, rpchelper.DefaultFiltersConfig, nil, nil, mining, func() {}, m.Log)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(ff, stateCache, m.BlockReader, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs, nil), m.DB, nil, nil, mining, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
+
+	to := common.Address{1}
+	pending := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	t.Run("NotBuilding", func(t *testing.T) {
+		// no pending block and no pool: pending is latest
+		balance, err := api.GetBalance(context.Background(), m.Address, pending)
+		require.NoError(t, err)
+		expected, err := api.GetBalance(context.Background(), m.Address, latest)
+		require.NoError(t, err)
+		require.Equal(t, expected.ToInt(), balance.ToInt())
+	})
+
+	t.Run("Building", func(t *testing.T) {
+		tx, err := m.DB.BeginTemporalRo(context.Background())
+		require.NoError(t, err)
+		defer tx.Rollback()
+		head, err := api.headerByRPCNumber(context.Background(), rpc.LatestBlockNumber, tx)
+		require.NoError(t, err)
+		header := core.MakeEmptyHeader(head, m.ChainConfig, head.Time+1, nil)
+
+		signer := types.LatestSigner(m.ChainConfig)
+		value := uint256.NewInt(params.GWei)
+		txn, err := types.SignTx(types.NewTransaction(0, to, value, 21_000, uint256.NewInt(params.GWei), nil), *signer, m.Key)
+		require.NoError(t, err)
+		b, err := rlp.EncodeToBytes(types.NewBlock(header, types.Transactions{txn}, nil, nil, nil))
+		require.NoError(t, err)
+		ff.HandlePendingBlock(&txpool.OnPendingBlockReply{RplBlock: b})
+
+		balance, err := api.GetBalance(context.Background(), to, pending)
+		require.NoError(t, err)
+		require.Equal(t, value.ToBig(), balance.ToInt())
+		balance, err = api.GetBalance(context.Background(), to, latest)
+		require.NoError(t, err)
+		require.Zero(t, balance.ToInt().Sign())
+
+		nonce, err := api.GetTransactionCount(context.Background(), m.Address, pending)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), uint64(*nonce))
+		nonce, err = api.GetTransactionCount(context.Background(), m.Address, latest)
+		require.NoError(t, err)
+		require.Zero(t, uint64(*nonce))
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		tx, err := m.DB.BeginTemporalRo(context.Background())
+		require.NoError(t, err)
+		defer tx.Rollback()
+		// the pending block is unchanged: built once
+		first, _, err := api.pendingState(context.Background(), tx, m.ChainConfig)
+		require.NoError(t, err)
+		second, _, err := api.pendingState(context.Background(), tx, m.ChainConfig)
+		require.NoError(t, err)
+		require.Same(t, first.(*pendingReader).writes, second.(*pendingReader).writes)
+	})
+}
@@ -44,6 +44,9 @@ import (
 
 // getReceipts - checking in-mem cache, or else fallback to db, or else fallback to re-exec of block to re-gen receipts
 func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.TemporalTx, block *types.Block) (types.Receipts, error) {
+	if receipts, ok := api.recentReceipts.Get(block.Hash()); ok {
+		return receipts, nil
+	}
 	chainConfig, err := api.chainConfig(ctx, tx)
 	if err != nil {
 		return nil, err
@@ -53,6 +56,9 @@ func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.TemporalTx, block *ty
 }
 
 func (api *BaseAPI) getReceipt(ctx context.Context, cc *chain.Config, tx kv.TemporalTx, header *types.Header, txn types.Transaction, index int, txNum uint64) (*types.Receipt, error) {
+	if receipts, ok := api.recentReceipts.Get(header.Hash()); ok && len(receipts) > index {
+		return receipts[index], nil
+	}
 	return api.receiptsGenerator.GetReceipt(ctx, cc, tx, header, txn, index, txNum)
 }
 
@@ -66,6 +72,9 @@ func (api *BaseAPI) getCachedReceipt(ctx context.Context, hash common.Hash) (*ty
 }
 
 func (api *BaseAPI) getCachedReceipts(ctx context.Context, hash common.Hash) (types.Receipts, bool) {
+	if receipts, ok := api.recentReceipts.Get(hash); ok {
+		return receipts, true
+	}
 	return api.receiptsGenerator.GetCachedReceipts(ctx, hash)
 }
 
@@ -551,6 +560,7 @@ func (api *APIImpl) GetTransactionReceipt(ctx context.Context, txnHash common.Ha
 		return nil, fmt.Errorf("getReceipt error: %w", err)
 	}
 
+	api.markImmutableIfFinalized(ctx, tx, blockNum)
 	return ethutils.MarshalReceipt(receipt, txn, chainConfig, header, txnHash, true), nil
 }
 
@@ -0,0 +1,84 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common/debug"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/eth/ethutils"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/shards"
+)
+
+// SoftConfirmations implements eth_subscribe("softConfirmations"). Sends every transaction as the local block builder
+// includes it into the block being built, in the block order, with its provisional receipt. The receipt has no block
+// hash and is valid only if the payload with the "payloadId" is sealed: a rebuilt payload may include the
+// transaction again at another position. The block builder doesn't wait for a subscriber that falls behind: the
+// confirmations it missed are replaced by a single {"dropped": <count>} notification.
+func (api *APIImpl) SoftConfirmations(ctx context.Context) (*rpc.Subscription, error) {
+	if api.softConfirmations == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	ch, unsubscribe := api.softConfirmations.AddSoftConfirmationsSubscription()
+	go func() {
+		defer debug.LogPanic()
+		defer unsubscribe()
+		for {
+			select {
+			case batch := <-ch:
+				if batch.Dropped > 0 {
+					if err := notifier.Notify(rpcSub.ID, map[string]interface{}{"dropped": hexutil.Uint64(batch.Dropped)}); err != nil {
+						log.Warn("[rpc] error while notifying subscription", "err", err)
+					}
+				}
+				for _, c := range batch.Confirmations {
+					if err := notifier.Notify(rpcSub.ID, softConfirmationFields(c, chainConfig)); err != nil {
+						log.Warn("[rpc] error while notifying subscription", "err", err)
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+func softConfirmationFields(c shards.SoftConfirmation, chainConfig *chain.Config) map[string]interface{} {
+	fields := ethutils.MarshalReceipt(c.Receipt, c.Txn, chainConfig, c.Header, c.Txn.Hash(), true)
+	delete(fields, "blockHash") // not sealed yet
+	fields["payloadId"] = hexutil.Uint64(c.PayloadId)
+	return fields
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+	"github.com/erigontech/erigon/turbo/stages"
+	"github.com/erigontech/erigon/turbo/stages/mock"
+)
+
+func TestSoftConfirmationsOfMiningExec(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	gspec := &types.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+	}
+	m := mock.MockWithGenesis(t, gspec, key, false, mock.WithSoftConfirmations())
+	logger := log.New()
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, logger)
+	api.softConfirmations = m.Notifications.Events
+
+	server := rpc.NewServer(50, false, false, true, logger, 0)
+	require.NoError(t, server.RegisterName("eth", api))
+	client := rpc.DialInProc(server, logger)
+	defer client.Close()
+
+	ctx := context.Background()
+	ch := make(chan map[string]interface{}, 16)
+	sub, err := client.Subscribe(ctx, "eth", ch, "softConfirmations")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	var txns types.Transactions
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		txn, err := types.SignTx(types.NewTransaction(nonce, common.Address{1}, uint256.NewInt(10_000), params.TxGas, uint256.NewInt(params.GWei), nil), *signer, key)
+		require.NoError(t, err)
+		txns = append(txns, txn)
+	}
+	m.MiningBlock.PreparedTxns = txns
+	require.NoError(t, stages.MiningStep(m.Ctx, m.DB, m.MiningSync, "", logger))
+	require.Equal(t, len(txns), (<-m.PendingBlocks).Transactions().Len())
+
+	// every included transaction in the block order, with its provisional receipt of the unsealed block
+	for i, txn := range txns {
+		fields := <-ch
+		require.NotContains(t, fields, "dropped")
+		require.NotContains(t, fields, "blockHash")
+		require.Equal(t, txn.Hash().Hex(), fields["transactionHash"])
+		require.Equal(t, hexutil.Uint64(i).String(), fields["transactionIndex"])
+		require.Equal(t, "0x1", fields["blockNumber"])
+		require.Equal(t, "0x1", fields["status"])
+		require.Equal(t, hexutil.Uint64((i+1)*int(params.TxGas)).String(), fields["cumulativeGasUsed"])
+		require.Equal(t, "0x0", fields["payloadId"])
+	}
+	require.Empty(t, ch)
+}
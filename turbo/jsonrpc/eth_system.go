@@ -18,11 +18,16 @@ package jsonrpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 
+	"github.com/holiman/uint256"
+
 	"github.com/erigontech/erigon-lib/chain"
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	txpool "github.com/erigontech/erigon-lib/gointerfaces/txpoolproto"
 	"github.com/erigontech/erigon-lib/kv"
 	"github.com/erigontech/erigon/consensus/misc"
 	"github.com/erigontech/erigon/core/rawdb"
@@ -108,20 +113,19 @@ func (api *APIImpl) ProtocolVersion(ctx context.Context) (hexutil.Uint, error) {
 }
 
 // GasPrice implements eth_gasPrice. Returns the current price per gas in wei.
-func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+// The optional strategy selects the gas price oracle strategy instead of the configured one.
+func (api *APIImpl) GasPrice(ctx context.Context, strategy *string) (*hexutil.Big, error) {
 	tx, err := api.db.BeginTemporalRo(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI), ethconfig.Defaults.GPO, api.gasCache, api.logger.New("app", "gasPriceOracle"))
+	oracle := api.newSuggestOracle(tx, strategy)
 	tipcap, err := oracle.SuggestTipCap(ctx)
-	gasResult := big.NewInt(0)
-
-	gasResult.Set(tipcap)
 	if err != nil {
 		return nil, err
 	}
+	gasResult := new(big.Int).Set(tipcap)
 	if head := rawdb.ReadCurrentHeader(tx); head != nil && head.BaseFee != nil {
 		gasResult.Add(tipcap, head.BaseFee)
 	}
@@ -130,13 +134,14 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 }
 
 // MaxPriorityFeePerGas returns a suggestion for a gas tip cap for dynamic fee transactions.
-func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+// The optional strategy selects the gas price oracle strategy instead of the configured one.
+func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context, strategy *string) (*hexutil.Big, error) {
 	tx, err := api.db.BeginTemporalRo(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, api.BaseAPI), ethconfig.Defaults.GPO, api.gasCache, api.logger.New("app", "gasPriceOracle"))
+	oracle := api.newSuggestOracle(tx, strategy)
 	tipcap, err := oracle.SuggestTipCap(ctx)
 	if err != nil {
 		return nil, err
@@ -144,6 +149,21 @@ func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, err
 	return (*hexutil.Big)(tipcap), err
 }
 
+// newSuggestOracle returns a gas price oracle using the requested strategy,
+// the configured default one if strategy is nil
+func (api *APIImpl) newSuggestOracle(tx kv.TemporalTx, strategy *string) *gasprice.Oracle {
+	cfg := ethconfig.Defaults.GPO
+	if api.GasPriceStrategy != "" {
+		cfg.Strategy = api.GasPriceStrategy
+	}
+	if strategy != nil {
+		cfg.Strategy = *strategy
+	}
+	backend := NewGasPriceOracleBackend(tx, api.BaseAPI)
+	backend.txPool = api.txPool
+	return gasprice.NewOracle(backend, cfg, api.gasCache, api.logger.New("app", "gasPriceOracle"))
+}
+
 type feeHistoryResult struct {
 	OldestBlock      *hexutil.Big     `json:"oldestBlock"`
 	Reward           [][]*hexutil.Big `json:"reward,omitempty"`
@@ -251,6 +271,7 @@ func (api *APIImpl) BaseFee(ctx context.Context) (*hexutil.Big, error) {
 type GasPriceOracleBackend struct {
 	tx      kv.TemporalTx
 	baseApi *BaseAPI
+	txPool  txpool.TxpoolClient // nil if txpool is not available
 }
 
 func NewGasPriceOracleBackend(tx kv.TemporalTx, baseApi *BaseAPI) *GasPriceOracleBackend {
@@ -284,3 +305,26 @@ func (b *GasPriceOracleBackend) PendingBlockAndReceipts() (*types.Block, types.R
 func (b *GasPriceOracleBackend) GetReceiptsGasUsed(ctx context.Context, block *types.Block) ([]uint64, error) {
 	return b.baseApi.getReceiptsGasUsed(ctx, b.tx, block)
 }
+
+// PendingTips implements gasprice.PoolBackend
+func (b *GasPriceOracleBackend) PendingTips(ctx context.Context, baseFee *uint256.Int) ([]*uint256.Int, error) {
+	if b.txPool == nil {
+		return nil, errors.New("txpool is not available")
+	}
+	reply, err := b.txPool.All(ctx, &txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+	tips := make([]*uint256.Int, 0, len(reply.Txs))
+	for _, t := range reply.Txs {
+		if t.TxnType != txpool.AllReply_PENDING {
+			continue
+		}
+		txn, err := types.DecodeWrappedTransaction(t.RlpTx)
+		if err != nil {
+			return nil, fmt.Errorf("decoding transaction from: %x: %w", t.RlpTx, err)
+		}
+		tips = append(tips, txn.GetEffectiveGasTip(baseFee))
+	}
+	return tips, nil
+}
@@ -61,7 +61,7 @@ func TestGasPrice(t *testing.T) {
 			eth := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, ethconfig.Defaults.RPCTxFeeCap, 100_000, false, 100_000, 128, log.New())
 
 			ctx := context.Background()
-			result, err := eth.GasPrice(ctx)
+			result, err := eth.GasPrice(ctx, nil)
 			if err != nil {
 				t.Fatalf("error getting gas price: %s", err)
 			}
@@ -122,6 +122,7 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, txnHash common.Has
 			return ethapi.NewRPCBorTransaction(borTx, txnHash, blockHash, blockNum, uint64(txCount), chainConfig.ChainID), nil
 		}
 
+		api.markImmutableIfFinalized(ctx, tx, blockNum)
 		return ethapi.NewRPCTransaction(txn, blockHash, blockNum, txnIndex, baseFee), nil
 	}
 
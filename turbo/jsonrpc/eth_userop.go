@@ -0,0 +1,95 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/txnprovider/userop"
+)
+
+var errUserOpsDisabled = errors.New("user operation mempool is disabled, see --userops")
+
+// SendUserOperation implements eth_sendUserOperation. Validates the ERC-4337 user operation on the latest state
+// and adds it to the user operation mempool, returns the hash of the operation.
+func (api *APIImpl) SendUserOperation(ctx context.Context, op userop.UserOperation, entryPoint common.Address) (common.Hash, error) {
+	if api.userOps == nil {
+		return common.Hash{}, errUserOpsDisabled
+	}
+	return api.userOps.Add(ctx, &op, entryPoint)
+}
+
+// SupportedEntryPoints implements eth_supportedEntryPoints
+func (api *APIImpl) SupportedEntryPoints(_ context.Context) ([]common.Address, error) {
+	if api.userOps == nil {
+		return nil, errUserOpsDisabled
+	}
+	return api.userOps.EntryPoints(), nil
+}
+
+// GetUserOperationReceipt implements eth_getUserOperationReceipt. Returns nil if the operation is not included yet.
+func (api *APIImpl) GetUserOperationReceipt(ctx context.Context, hash common.Hash) (*userop.Receipt, error) {
+	if api.userOps == nil {
+		return nil, errUserOpsDisabled
+	}
+	tx, err := api.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	latest, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := api.getLogsV3(ctx, tx, 0, latest, filters.FilterCriteria{
+		Addresses: api.userOps.EntryPoints(),
+		Topics:    [][]common.Hash{{userop.UserOperationEventTopic}, {hash}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	event := events[len(events)-1]
+	tx.Rollback()
+
+	receipt, err := api.GetTransactionReceipt(ctx, event.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, nil
+	}
+	logs, _ := receipt["logs"].(types.Logs)
+	for i, l := range logs {
+		if l.Address == event.Address && len(l.Topics) > 1 && l.Topics[0] == userop.UserOperationEventTopic && l.Topics[1] == hash {
+			res, err := userop.NewReceipt(logs, i)
+			if err != nil {
+				return nil, err
+			}
+			res.Receipt = receipt
+			return res, nil
+		}
+	}
+	return nil, nil
+}
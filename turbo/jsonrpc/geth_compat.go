@@ -0,0 +1,114 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+)
+
+// gethTxPoolErrors - messages returned by geth's eth_sendRawTransaction for the same txpool rejections.
+// Used with --rpc.gethcompat.errors, because many SDKs match these strings.
+var gethTxPoolErrors = map[string]string{
+	txpoolcfg.AlreadyKnown.String():        "already known",
+	txpoolcfg.DuplicateHash.String():       "already known",
+	txpoolcfg.UnderPriced.String():         "transaction underpriced",
+	txpoolcfg.FeeTooLow.String():           "transaction underpriced",
+	txpoolcfg.ReplaceUnderpriced.String():  "replacement transaction underpriced",
+	txpoolcfg.NotReplaced.String():         "replacement transaction underpriced",
+	txpoolcfg.OversizedData.String():       "oversized data",
+	txpoolcfg.RLPTooLong.String():          "oversized data",
+	txpoolcfg.InvalidSender.String():       "invalid sender",
+	txpoolcfg.NegativeValue.String():       "negative value",
+	txpoolcfg.GasUintOverflow.String():     "gas uint64 overflow",
+	txpoolcfg.IntrinsicGas.String():        "intrinsic gas too low",
+	txpoolcfg.NonceTooLow.String():         "nonce too low",
+	txpoolcfg.InsufficientFunds.String():   "insufficient funds for gas * price + value",
+	txpoolcfg.InitCodeTooLarge.String():    "max initcode size exceeded",
+	txpoolcfg.TypeNotActivated.String():    "transaction type not supported",
+	txpoolcfg.GasLimitTooHigh.String():     "exceeds block gas limit",
+	txpoolcfg.Spammer.String():             "account limit exceeded",
+	txpoolcfg.PendingPoolOverflow.String(): "txpool is full",
+	txpoolcfg.BaseFeePoolOverflow.String(): "txpool is full",
+	txpoolcfg.QueuedPoolOverflow.String():  "txpool is full",
+	txpoolcfg.BlobPoolOverflow.String():    "txpool is full",
+}
+
+// gethTxPoolError formats txpool rejection reason the way geth does: plain message, without import result prefix
+func gethTxPoolError(reason string) error {
+	if msg, ok := gethTxPoolErrors[reason]; ok {
+		return errors.New(msg)
+	}
+	return errors.New(reason)
+}
+
+// gethPreCheckErrors - errors of pre-execution checks of a message, geth reports them as
+// "err: <message> (supplied gas N)". Non-empty value is geth's wording if it differs from Erigon's.
+var gethPreCheckErrors = []struct {
+	err  error
+	geth string
+}{
+	{err: core.ErrNonceTooLow},
+	{err: core.ErrNonceTooHigh},
+	{err: core.ErrNonceMax},
+	{err: core.ErrInsufficientFunds},
+	{err: core.ErrIntrinsicGas},
+	{err: core.ErrGasUintOverflow},
+	{err: core.ErrSenderNoEOA},
+	{err: core.ErrMaxInitCodeSizeExceeded},
+	{err: core.ErrMaxFeePerBlobGas},
+	{err: core.ErrFeeCapTooLow, geth: "max fee per gas less than block base fee"},
+	{err: core.ErrTipAboveFeeCap, geth: "max priority fee per gas higher than max fee per gas"},
+	{err: core.ErrTipVeryHigh, geth: "max priority fee per gas higher than 2^256-1"},
+	{err: core.ErrFeeCapVeryHigh, geth: "max fee per gas higher than 2^256-1"},
+}
+
+// gethPreCheckError rewords error of message pre-checks the way geth does, ok is false for other errors
+func gethPreCheckError(err error) (msg string, ok bool) {
+	for _, e := range gethPreCheckErrors {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+		msg = err.Error()
+		if e.geth != "" {
+			msg = strings.Replace(msg, e.err.Error(), e.geth, 1)
+		}
+		return msg, true
+	}
+	return "", false
+}
+
+// gethCallError converts error of eth_call to geth's format, errors not produced by message pre-checks are returned as-is
+func gethCallError(err error, gas uint64) error {
+	if msg, ok := gethPreCheckError(err); ok {
+		return fmt.Errorf("err: %s (supplied gas %d)", msg, gas)
+	}
+	return err
+}
+
+// gethEstimateGasError converts error of eth_estimateGas to geth's format: unlike eth_call, geth's
+// estimator returns pre-check errors without "err:" prefix and supplied gas
+func gethEstimateGasError(err error) error {
+	if msg, ok := gethPreCheckError(err); ok {
+		return errors.New(msg)
+	}
+	return err
+}
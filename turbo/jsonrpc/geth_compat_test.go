@@ -0,0 +1,54 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/txnprovider/txpool/txpoolcfg"
+)
+
+func TestGethTxPoolError(t *testing.T) {
+	require.EqualError(t, gethTxPoolError(txpoolcfg.NonceTooLow.String()), "nonce too low")
+	require.EqualError(t, gethTxPoolError(txpoolcfg.ReplaceUnderpriced.String()), "replacement transaction underpriced")
+	require.EqualError(t, gethTxPoolError(txpoolcfg.InsufficientFunds.String()), "insufficient funds for gas * price + value")
+	require.EqualError(t, gethTxPoolError("some unknown reason"), "some unknown reason")
+}
+
+func TestGethCallError(t *testing.T) {
+	err := fmt.Errorf("%w: address %v, tx: %d state: %d", core.ErrNonceTooLow, "0x01", 1, 2)
+	require.EqualError(t, gethCallError(err, 21000), "err: nonce too low: address 0x01, tx: 1 state: 2 (supplied gas 21000)")
+
+	err = fmt.Errorf("%w: address %v, gasFeeCap: %s baseFee: %s", core.ErrFeeCapTooLow, "0x01", "1", "7")
+	require.EqualError(t, gethCallError(err, 100), "err: max fee per gas less than block base fee: address 0x01, gasFeeCap: 1 baseFee: 7 (supplied gas 100)")
+
+	err = errors.New("execution aborted (timeout = 5s)")
+	require.Equal(t, err, gethCallError(err, 100))
+}
+
+func TestGethEstimateGasError(t *testing.T) {
+	err := fmt.Errorf("%w: address %v, gasFeeCap: %s baseFee: %s", core.ErrFeeCapTooLow, "0x01", "1", "7")
+	require.EqualError(t, gethEstimateGasError(err), "max fee per gas less than block base fee: address 0x01, gasFeeCap: 1 baseFee: 7")
+
+	err = errors.New("execution aborted (timeout = 5s)")
+	require.Equal(t, err, gethEstimateGasError(err))
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+// Package ipld encodes Ethereum data as IPLD blocks of the Ethereum codecs (the hash of a block is the keccak-256
+// hash Ethereum itself uses: a header CID is the block hash, a trie node CID is the node hash) and writes them to
+// CAR (content addressable archive, v1) files, which IPFS and Filecoin import directly.
+//
+// CIDs are binary CIDv1: version:uvarint codec:uvarint multihash, multihash = 0x1b:uvarint 32:uvarint digest:32.
+// CAR v1: uvarint-prefixed DAG-CBOR header {"roots": [CID], "version": 1}, then for every block
+// uvarint(len(cid)+len(data)) cid data.
+package ipld
+
+import (
+	"bufio"
+	"encoding/base32"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/trie"
+)
+
+// Multicodecs of the Ethereum IPLD formats, see https://github.com/multiformats/multicodec/blob/master/table.csv
+const (
+	CodecHeader      uint64 = 0x90 // eth-block: RLP of the header
+	CodecUncles      uint64 = 0x91 // eth-block-list: RLP list of the uncle headers
+	CodecTxTrie      uint64 = 0x92 // eth-tx-trie: node of the transactions trie
+	CodecTx          uint64 = 0x93 // eth-tx: binary encoding of the transaction
+	CodecReceiptTrie uint64 = 0x94 // eth-tx-receipt-trie: node of the receipts trie
+	CodecReceipt     uint64 = 0x95 // eth-tx-receipt: binary encoding of the receipt
+	CodecStateTrie   uint64 = 0x96 // eth-state-trie: node of the accounts trie
+	CodecStorageTrie uint64 = 0x98 // eth-storage-trie: node of a storage trie
+
+	multihashKeccak256 = 0x1b
+)
+
+// CID is a binary CIDv1 with the keccak-256 multihash
+type CID []byte
+
+// NewCID returns the CID of the block of the codec with the keccak-256 hash
+func NewCID(codec uint64, hash common.Hash) CID {
+	c := make([]byte, 0, 2*binary.MaxVarintLen64+2+len(hash))
+	c = binary.AppendUvarint(c, 1)
+	c = binary.AppendUvarint(c, codec)
+	c = binary.AppendUvarint(c, multihashKeccak256)
+	c = binary.AppendUvarint(c, uint64(len(hash)))
+	return append(c, hash[:]...)
+}
+
+// Sum returns the CID of the block data
+func Sum(codec uint64, data []byte) CID {
+	return NewCID(codec, crypto.Keccak256Hash(data))
+}
+
+// String returns the CID in the base32 multibase, the usual text form of CIDv1
+func (c CID) String() string {
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(c))
+}
+
+// CARWriter writes a CAR v1 file, blocks put more than once are written once
+type CARWriter struct {
+	w    *bufio.Writer
+	seen map[string]struct{}
+
+	Blocks uint64
+	Bytes  uint64 // including the header
+}
+
+// NewCARWriter writes the header with the roots of the DAG
+func NewCARWriter(w io.Writer, roots []CID) (*CARWriter, error) {
+	// DAG-CBOR, keys sorted by length first: "roots" before "version"
+	var header []byte
+	header = cborHead(header, 5, 2) // map
+	header = cborText(header, "roots")
+	header = cborHead(header, 4, uint64(len(roots))) // array
+	for _, root := range roots {
+		header = cborHead(header, 6, 42) // tag of CIDs
+		header = cborHead(header, 2, uint64(len(root)+1))
+		header = append(header, 0) // identity multibase prefix
+		header = append(header, root...)
+	}
+	header = cborText(header, "version")
+	header = cborHead(header, 0, 1)
+
+	c := &CARWriter{w: bufio.NewWriter(w), seen: map[string]struct{}{}}
+	if err := c.write(binary.AppendUvarint(nil, uint64(len(header))), header); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Put writes the block unless it was already written
+func (c *CARWriter) Put(cid CID, data []byte) error {
+	if _, ok := c.seen[string(cid)]; ok {
+		return nil
+	}
+	c.seen[string(cid)] = struct{}{}
+	c.Blocks++
+	return c.write(binary.AppendUvarint(nil, uint64(len(cid)+len(data))), cid, data)
+}
+
+// Flush must be called after the last block
+func (c *CARWriter) Flush() error {
+	return c.w.Flush()
+}
+
+func (c *CARWriter) write(chunks ...[]byte) error {
+	for _, chunk := range chunks {
+		n, err := c.w.Write(chunk)
+		c.Bytes += uint64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cborHead appends the head of a CBOR item of the major type
+func cborHead(buf []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= 0xff:
+		return append(buf, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+	}
+}
+
+func cborText(buf []byte, s string) []byte {
+	return append(cborHead(buf, 3, uint64(len(s))), s...)
+}
+
+// TrieNodes returns the encoded nodes of the trie on the paths to the keys (all the nodes if all the keys are given),
+// except the nodes embedded into their parents, which are not separate IPLD blocks
+func TrieNodes(t *trie.Trie, keys [][]byte, storage bool) ([][]byte, error) {
+	var nodes [][]byte
+	seen := map[common.Hash]struct{}{}
+	for _, key := range keys {
+		proof, err := t.Prove(key, 0, storage)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range HashedNodes(proof) {
+			h := crypto.Keccak256Hash(node)
+			if _, ok := seen[h]; ok {
+				continue
+			}
+			seen[h] = struct{}{}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// HashedNodes filters the nodes of a merkle proof (as of eth_getProof) which are referenced by hash:
+// the root and the nodes of 32 bytes or more
+func HashedNodes(proof [][]byte) [][]byte {
+	res := make([][]byte, 0, len(proof))
+	for i, node := range proof {
+		if i == 0 || len(node) >= 32 {
+			res = append(res, node)
+		}
+	}
+	return res
+}
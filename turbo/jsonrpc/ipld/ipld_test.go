@@ -0,0 +1,102 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package ipld
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/trie"
+)
+
+func TestCID(t *testing.T) {
+	hash := common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	c := NewCID(CodecHeader, hash)
+	require.Equal(t, []byte{0x01, 0x90, 0x01, 0x1b, 0x20}, []byte(c[:5]))
+	require.Equal(t, hash[:], []byte(c[5:]))
+	require.True(t, strings.HasPrefix(c.String(), "bagiacgza"), c.String())
+	require.Equal(t, NewCID(CodecTx, crypto.Keccak256Hash([]byte{1})), Sum(CodecTx, []byte{1}))
+}
+
+func TestCARWriter(t *testing.T) {
+	root := Sum(CodecHeader, []byte("header"))
+	var buf bytes.Buffer
+	car, err := NewCARWriter(&buf, []CID{root})
+	require.NoError(t, err)
+	require.NoError(t, car.Put(root, []byte("header")))
+	require.NoError(t, car.Put(root, []byte("header"))) // written once
+	require.NoError(t, car.Put(Sum(CodecTx, []byte("tx")), []byte("tx")))
+	require.NoError(t, car.Flush())
+	require.Equal(t, uint64(2), car.Blocks)
+	require.Equal(t, uint64(buf.Len()), car.Bytes)
+
+	var header []byte
+	header = append(header, 0xa2, 0x65)
+	header = append(header, "roots"...)
+	header = append(header, 0x81, 0xd8, 0x2a, 0x58, byte(len(root)+1), 0x00)
+	header = append(header, root...)
+	header = append(header, 0x67)
+	header = append(header, "version"...)
+	header = append(header, 0x01)
+
+	data := buf.Bytes()
+	n, l := binary.Uvarint(data)
+	require.Equal(t, uint64(len(header)), n)
+	require.Equal(t, header, data[l:l+len(header)])
+	data = data[l+len(header):]
+
+	n, l = binary.Uvarint(data)
+	require.Equal(t, uint64(len(root)+len("header")), n)
+	require.Equal(t, []byte(root), data[l:l+len(root)])
+	require.Equal(t, "header", string(data[l+len(root):l+int(n)]))
+	data = data[l+int(n):]
+
+	n, l = binary.Uvarint(data)
+	require.Equal(t, len(data), l+int(n)) // the last block
+}
+
+func TestTrieNodes(t *testing.T) {
+	tr := trie.New(trie.EmptyRoot)
+	var keys [][]byte
+	for i := 0; i < 100; i++ {
+		key := crypto.Keccak256([]byte{byte(i)})
+		tr.Update(key, bytes.Repeat([]byte{byte(i)}, 40))
+		keys = append(keys, key)
+	}
+	nodes, err := TrieNodes(tr, keys, false)
+	require.NoError(t, err)
+	require.Equal(t, tr.Hash(), crypto.Keccak256Hash(nodes[0]))
+
+	// every node but the root is referenced by hash from another node
+	for _, node := range nodes[1:] {
+		h := crypto.Keccak256(node)
+		var referenced bool
+		for _, parent := range nodes {
+			referenced = referenced || bytes.Contains(parent, h)
+		}
+		require.True(t, referenced)
+	}
+
+	few, err := TrieNodes(tr, keys[:1], false)
+	require.NoError(t, err)
+	require.Less(t, len(few), len(nodes))
+}
@@ -167,6 +167,7 @@ func (api *OtterscanAPIImpl) runTracer(ctx context.Context, tx kv.TemporalTx, ha
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %v", err)
 	}
+	rpc.CostFromContext(ctx).AddGas(result.UsedGas)
 
 	return result, nil
 }
@@ -60,6 +60,9 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.By
 	}
 
 	if res.Imported[0] != txPoolProto.ImportResult_SUCCESS {
+		if api.GethCompatErrors {
+			return hash, gethTxPoolError(res.Errors[0])
+		}
 		return hash, fmt.Errorf("%s: %s", txPoolProto.ImportResult_name[int32(res.Imported[0])], res.Errors[0])
 	}
 
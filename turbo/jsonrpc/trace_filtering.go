@@ -51,7 +51,12 @@ import (
 )
 
 // Transaction implements trace_transaction
-func (api *TraceAPIImpl) Transaction(ctx context.Context, txHash common.Hash, gasBailOut *bool, traceConfig *config.TraceConfig) (ParityTraces, error) {
+func (api *TraceAPIImpl) Transaction(ctx context.Context, txHash common.Hash, gasBailOut *bool, traceConfig *config.TraceConfig) (any, error) {
+	res, err := api.traceTransaction(ctx, txHash, gasBailOut, traceConfig)
+	return withSchemaVersion(traceConfig, res, err)
+}
+
+func (api *TraceAPIImpl) traceTransaction(ctx context.Context, txHash common.Hash, gasBailOut *bool, traceConfig *config.TraceConfig) (ParityTraces, error) {
 	if gasBailOut == nil {
 		gasBailOut = new(bool) // false by default
 	}
@@ -100,6 +105,7 @@ func (api *TraceAPIImpl) Transaction(ctx context.Context, txHash common.Hash, ga
 
 		isBorStateSyncTxn = true
 	}
+	api.markImmutableIfFinalized(ctx, tx, blockNumber)
 
 	header, err := api.headerByRPCNumber(ctx, rpc.BlockNumber(blockNumber), tx)
 	if err != nil {
@@ -148,12 +154,17 @@ func (api *TraceAPIImpl) Transaction(ctx context.Context, txHash common.Hash, ga
 }
 
 // Get implements trace_get
-func (api *TraceAPIImpl) Get(ctx context.Context, txHash common.Hash, indicies []hexutil.Uint64, gasBailOut *bool, traceConfig *config.TraceConfig) (*ParityTrace, error) {
+func (api *TraceAPIImpl) Get(ctx context.Context, txHash common.Hash, indicies []hexutil.Uint64, gasBailOut *bool, traceConfig *config.TraceConfig) (any, error) {
+	res, err := api.traceGet(ctx, txHash, indicies, gasBailOut, traceConfig)
+	return withSchemaVersion(traceConfig, res, err)
+}
+
+func (api *TraceAPIImpl) traceGet(ctx context.Context, txHash common.Hash, indicies []hexutil.Uint64, gasBailOut *bool, traceConfig *config.TraceConfig) (*ParityTrace, error) {
 	// Parity fails if it gets more than a single index. It returns nothing in this case. Must we?
 	if len(indicies) > 1 {
 		return nil, nil
 	}
-	traces, err := api.Transaction(ctx, txHash, gasBailOut, traceConfig)
+	traces, err := api.traceTransaction(ctx, txHash, gasBailOut, traceConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +195,12 @@ func rewardKindToString(kind consensus.RewardKind) string {
 }
 
 // Block implements trace_block
-func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber, gasBailOut *bool, traceConfig *config.TraceConfig) (ParityTraces, error) {
+func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber, gasBailOut *bool, traceConfig *config.TraceConfig) (any, error) {
+	res, err := api.traceBlock(ctx, blockNr, gasBailOut, traceConfig)
+	return withSchemaVersion(traceConfig, res, err)
+}
+
+func (api *TraceAPIImpl) traceBlock(ctx context.Context, blockNr rpc.BlockNumber, gasBailOut *bool, traceConfig *config.TraceConfig) (ParityTraces, error) {
 	if gasBailOut == nil {
 		gasBailOut = new(bool) // false by default
 	}
@@ -257,6 +273,21 @@ func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber, gas
 	return out, err
 }
 
+// BlockDedupedCode implements trace_blockDedupedCode: trace_block with the repeated CREATE init code and deployed code
+// referenced by hash, see DedupedParityTraces
+func (api *TraceAPIImpl) BlockDedupedCode(ctx context.Context, blockNr rpc.BlockNumber, gasBailOut *bool, traceConfig *config.TraceConfig) (any, error) {
+	res, err := api.traceBlockDedupedCode(ctx, blockNr, gasBailOut, traceConfig)
+	return withSchemaVersion(traceConfig, res, err)
+}
+
+func (api *TraceAPIImpl) traceBlockDedupedCode(ctx context.Context, blockNr rpc.BlockNumber, gasBailOut *bool, traceConfig *config.TraceConfig) (*DedupedParityTraces, error) {
+	traces, err := api.traceBlock(ctx, blockNr, gasBailOut, traceConfig)
+	if err != nil {
+		return nil, err
+	}
+	return dedupCode(traces), nil
+}
+
 func traceFilterBitmapsV3(tx kv.TemporalTx, req TraceFilterRequest, from, to uint64) (fromAddresses, toAddresses map[common.Address]struct{}, allBlocks stream.U64, err error) {
 	fromAddresses = make(map[common.Address]struct{}, len(req.FromAddress))
 	toAddresses = make(map[common.Address]struct{}, len(req.ToAddress))
@@ -308,6 +339,7 @@ func traceFilterBitmapsV3(tx kv.TemporalTx, req TraceFilterRequest, from, to uin
 // NOTE: We do not store full traces - we just store index for each address
 // Pull blocks which have txs with matching address
 func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, gasBailOut *bool, traceConfig *config.TraceConfig, stream *jsoniter.Stream) error {
+	defer transactions.WriteSchemaVersion(traceConfig, stream)()
 	if gasBailOut == nil {
 		//nolint
 		gasBailOut = new(bool) // false by default
@@ -625,6 +657,7 @@ func (api *TraceAPIImpl) filterV3(ctx context.Context, dbtx kv.TemporalTx, fromB
 			stream.WriteObjectEnd()
 			continue
 		}
+		rpc.CostFromContext(ctx).AddGas(execResult.UsedGas)
 		traceResult.Output = common.Copy(execResult.ReturnData)
 		if err = ibs.FinalizeTx(evm.ChainRules(), noop); err != nil {
 			if first {
@@ -21,6 +21,8 @@ import (
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/crypto"
 	"github.com/erigontech/erigon/core/types"
 )
 
@@ -95,10 +97,11 @@ type CallTraceAction struct {
 }
 
 type CreateTraceAction struct {
-	From  common.Address `json:"from"`
-	Gas   hexutil.Big    `json:"gas"`
-	Init  hexutil.Bytes  `json:"init"`
-	Value hexutil.Big    `json:"value"`
+	From     common.Address `json:"from"`
+	Gas      hexutil.Big    `json:"gas"`
+	Init     hexutil.Bytes  `json:"init"`
+	InitHash *common.Hash   `json:"initHash,omitempty"` // set instead of Init by the code deduplication
+	Value    hexutil.Big    `json:"value"`
 }
 
 type SuicideTraceAction struct {
@@ -115,9 +118,10 @@ type RewardTraceAction struct {
 
 type CreateTraceResult struct {
 	// Do not change the ordering of these fields -- allows for easier comparison with other clients
-	Address *common.Address `json:"address,omitempty"`
-	Code    hexutil.Bytes   `json:"code"`
-	GasUsed *hexutil.Big    `json:"gasUsed"`
+	Address  *common.Address `json:"address,omitempty"`
+	Code     hexutil.Bytes   `json:"code"`
+	CodeHash *common.Hash    `json:"codeHash,omitempty"` // set instead of Code by the code deduplication
+	GasUsed  *hexutil.Big    `json:"gasUsed"`
 }
 
 // TraceResult A parity formatted trace result
@@ -127,6 +131,47 @@ type TraceResult struct {
 	Output  hexutil.Bytes `json:"output"`
 }
 
+// DedupedParityTraces is the block traces output with the code deduplication: the init code of a CREATE action and the
+// code deployed by it, when occurring more than once in the block, are replaced by their hash everywhere and kept once
+// in Codes. Shrinks the traces of the blocks with mass CREATE2 deployments of the same contract.
+type DedupedParityTraces struct {
+	Traces ParityTraces                  `json:"traces"`
+	Codes  map[common.Hash]hexutil.Bytes `json:"codes"` // referenced codes by their keccak256 hash
+}
+
+func dedupCode(traces ParityTraces) *DedupedParityTraces {
+	out := &DedupedParityTraces{Traces: traces, Codes: map[common.Hash]hexutil.Bytes{}}
+	forEachCode := func(f func(code *hexutil.Bytes, hash **common.Hash)) {
+		for _, trace := range traces {
+			if action, ok := trace.Action.(*CreateTraceAction); ok {
+				f(&action.Init, &action.InitHash)
+			}
+			if result, ok := trace.Result.(*CreateTraceResult); ok {
+				f(&result.Code, &result.CodeHash)
+			}
+		}
+	}
+
+	occurrences := map[common.Hash]int{}
+	forEachCode(func(code *hexutil.Bytes, _ **common.Hash) {
+		if len(*code) > length.Hash { // referencing is not shorter otherwise
+			occurrences[crypto.Keccak256Hash(*code)]++
+		}
+	})
+	forEachCode(func(code *hexutil.Bytes, hash **common.Hash) {
+		if len(*code) <= length.Hash {
+			return
+		}
+		h := crypto.Keccak256Hash(*code)
+		if occurrences[h] < 2 {
+			return
+		}
+		out.Codes[h] = *code
+		*code, *hash = nil, &h
+	})
+	return out
+}
+
 // Allows for easy printing of a geth trace for debugging
 func (p GethTrace) String() string {
 	var ret string
@@ -49,15 +49,21 @@ import (
 
 // TraceBlockByNumber implements debug_traceBlockByNumber. Returns Geth style block traces.
 func (api *PrivateDebugAPIImpl) TraceBlockByNumber(ctx context.Context, blockNum rpc.BlockNumber, config *tracersConfig.TraceConfig, stream *jsoniter.Stream) error {
+	defer transactions.WriteSchemaVersion(config, stream)()
 	return api.traceBlock(ctx, rpc.BlockNumberOrHashWithNumber(blockNum), config, stream)
 }
 
 // TraceBlockByHash implements debug_traceBlockByHash. Returns Geth style block traces.
 func (api *PrivateDebugAPIImpl) TraceBlockByHash(ctx context.Context, hash common.Hash, config *tracersConfig.TraceConfig, stream *jsoniter.Stream) error {
+	defer transactions.WriteSchemaVersion(config, stream)()
 	return api.traceBlock(ctx, rpc.BlockNumberOrHashWithHash(hash, true), config, stream)
 }
 
 func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *tracersConfig.TraceConfig, stream *jsoniter.Stream) error {
+	if err := api.withSourceMaps(config); err != nil {
+		stream.WriteNil()
+		return err
+	}
 	tx, err := api.db.BeginTemporalRo(ctx)
 	if err != nil {
 		stream.WriteNil()
@@ -238,6 +244,11 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 
 // TraceTransaction implements debug_traceTransaction. Returns Geth style transaction traces.
 func (api *PrivateDebugAPIImpl) TraceTransaction(ctx context.Context, hash common.Hash, config *tracersConfig.TraceConfig, stream *jsoniter.Stream) error {
+	defer transactions.WriteSchemaVersion(config, stream)()
+	if err := api.withSourceMaps(config); err != nil {
+		stream.WriteNil()
+		return err
+	}
 	tx, err := api.db.BeginTemporalRo(ctx)
 	if err != nil {
 		stream.WriteNil()
@@ -290,6 +301,9 @@ func (api *PrivateDebugAPIImpl) TraceTransaction(ctx context.Context, hash commo
 		stream.WriteNil()
 		return err
 	}
+	if config == nil || config.SourceLocator == nil { // source maps can be replaced, so annotated traces can change
+		api.markImmutableIfFinalized(ctx, tx, blockNum)
+	}
 
 	block, err := api.blockByNumberWithSenders(ctx, tx, blockNum)
 	if err != nil {
@@ -364,6 +378,10 @@ func (api *PrivateDebugAPIImpl) TraceTransaction(ctx context.Context, hash commo
 
 // TraceCall implements debug_traceCall. Returns Geth style call traces.
 func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *tracersConfig.TraceConfig, stream *jsoniter.Stream) error {
+	defer transactions.WriteSchemaVersion(config, stream)()
+	if err := api.withSourceMaps(config); err != nil {
+		return err
+	}
 	dbtx, err := api.db.BeginTemporalRo(ctx)
 	if err != nil {
 		return fmt.Errorf("create ro transaction: %v", err)
@@ -432,6 +450,7 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 }
 
 func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bundle, simulateContext StateContext, config *tracersConfig.TraceConfig, stream *jsoniter.Stream) error {
+	defer transactions.WriteSchemaVersion(config, stream)()
 	var (
 		hash              common.Hash
 		evm               *vm.EVM
@@ -443,6 +462,10 @@ func (api *PrivateDebugAPIImpl) TraceCallMany(ctx context.Context, bundles []Bun
 	if config == nil {
 		config = &tracersConfig.TraceConfig{}
 	}
+	if err := api.withSourceMaps(config); err != nil {
+		stream.WriteNil()
+		return err
+	}
 
 	overrideBlockHash = make(map[uint64]common.Hash)
 	tx, err := api.db.BeginTemporalRo(ctx)
@@ -0,0 +1,416 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package watch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/turbo/jsonrpc/analytics"
+)
+
+// Event types
+const (
+	EventPending = "pending" // transaction entered the pool
+	EventMined   = "mined"   // transaction was included into canonical block
+	EventReorged = "reorged" // block of the mined transaction was orphaned by reorg, `mined` follows if it's included again
+)
+
+// maxListAddresses - max amount of addresses in one watch-list
+const maxListAddresses = 10_000
+
+// maxLists - max amount of watch-lists
+const maxLists = 1_000
+
+// saveBlocksInterval - the last processed block is saved every saveBlocksInterval blocks, not to rewrite the file
+// with every block
+const saveBlocksInterval = 64
+
+// subscriberBuffer - amount of events buffered for a slow subscriber, next events are dropped
+const subscriberBuffer = 256
+
+// List is a watch-list: notifies about transactions sent from or to any of `Addresses`
+type List struct {
+	ID        string           `json:"id"`
+	Addresses []common.Address `json:"addresses"`
+	Webhook   string           `json:"webhook,omitempty"` // URL which receives every event of the list by POST request, optional
+}
+
+// Event is a notification about transaction matching the watch-list
+type Event struct {
+	Type        string          `json:"type"`
+	List        string          `json:"watchList"`
+	TxHash      common.Hash     `json:"transactionHash"`
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"` // nil for contract creation
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash    `json:"blockHash,omitempty"`
+}
+
+// Tx is a transaction checked against watch-lists
+type Tx struct {
+	Hash common.Hash
+	From common.Address
+	To   *common.Address
+}
+
+// fileState is persisted to the watch-lists file on every change
+type fileState struct {
+	Lists     []*List `json:"lists"`
+	LastBlock *uint64 `json:"lastBlock,omitempty"` // last block checked for mined transactions
+}
+
+type subscriber struct {
+	list string // empty - events of all lists
+	ch   chan Event
+}
+
+// Watcher matches pool and canonical chain transactions against watch-lists and delivers events to subscribers
+// and webhooks. Watch-lists and the last processed block are persisted to the file, so lists survive restarts
+// and blocks mined while node was down are caught up (within the catch-up limit of the follower). The last block
+// is saved on list changes, every saveBlocksInterval blocks and on Close: after a crash up to saveBlocksInterval
+// blocks are checked again and their `mined` events are repeated.
+type Watcher struct {
+	path         string
+	webhookHosts map[string]struct{}
+	logger       log.Logger
+
+	mu        sync.Mutex
+	lists     map[string]*List
+	byAddress map[common.Address]map[string]struct{}
+	lastBlock *uint64
+	unsaved   int                             // blocks added since the last save
+	recent    analytics.RecentBlocks[[]Event] // mined events of recent blocks, reported as reorged when block is orphaned
+	subs      map[uint64]*subscriber
+	nextSubID uint64
+
+	webhooks *webhookSender
+}
+
+// NewWatcher loads watch-lists from the file at `path`, the file is created on the first change if it doesn't exist.
+// Webhooks are only sent to `webhookHosts` (host or host:port), watch-lists with webhooks are rejected if it's empty.
+func NewWatcher(path string, webhookHosts []string, logger log.Logger) (*Watcher, error) {
+	w := &Watcher{
+		path:         path,
+		webhookHosts: map[string]struct{}{},
+		logger:       logger,
+		lists:        map[string]*List{},
+		byAddress:    map[common.Address]map[string]struct{}{},
+		subs:         map[uint64]*subscriber{},
+		webhooks:     newWebhookSender(logger),
+	}
+	for _, h := range webhookHosts {
+		w.webhookHosts[strings.ToLower(h)] = struct{}{}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state fileState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse watch-lists file %s: %w", path, err)
+	}
+	for _, l := range state.Lists {
+		if err := w.validateList(l); errors.Is(err, errWebhookNotAllowed) {
+			logger.Warn("[rpc] watch: webhook of the watch-list is not allowed, dropped", "list", l.ID, "webhook", l.Webhook)
+			l.Webhook = ""
+		} else if err != nil {
+			return nil, fmt.Errorf("watch-list %s: %w", l.ID, err)
+		}
+		if l.ID == "" {
+			l.ID = newListID()
+		}
+		w.addList(l)
+	}
+	w.lastBlock = state.LastBlock
+	return w, nil
+}
+
+// AddList registers new watch-list
+func (w *Watcher) AddList(addresses []common.Address, webhook string) (*List, error) {
+	l := &List{ID: newListID(), Addresses: addresses, Webhook: webhook}
+	if err := w.validateList(l); err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.lists) >= maxLists {
+		return nil, fmt.Errorf("too many watch-lists, max %d", maxLists)
+	}
+	w.addList(l)
+	if err := w.save(); err != nil {
+		w.removeList(l.ID)
+		return nil, err
+	}
+	return l, nil
+}
+
+// RemoveList removes watch-list, returns false if it's not found
+func (w *Watcher) RemoveList(id string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	l, ok := w.lists[id]
+	if !ok {
+		return false, nil
+	}
+	w.removeList(id)
+	if err := w.save(); err != nil {
+		w.addList(l)
+		return false, err
+	}
+	return true, nil
+}
+
+// Lists returns all watch-lists
+func (w *Watcher) Lists() []List {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	res := make([]List, 0, len(w.lists))
+	for _, l := range w.lists {
+		res = append(res, *l)
+	}
+	return res
+}
+
+// HasList returns true if watch-list with given id is registered
+func (w *Watcher) HasList(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.lists[id]
+	return ok
+}
+
+// AddPendingTxs notifies about pool transactions matching watch-lists
+func (w *Watcher) AddPendingTxs(txs []Tx) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, tx := range txs {
+		for _, ev := range w.match(EventPending, tx) {
+			w.notify(ev)
+		}
+	}
+}
+
+// LastBlock returns the last block checked for mined transactions
+func (w *Watcher) LastBlock() (uint64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lastBlock == nil {
+		return 0, false
+	}
+	return *w.lastBlock, true
+}
+
+// BlockHash returns hash of the checked block with given number, if it is still within reorg depth
+func (w *Watcher) BlockHash(blockNum uint64) (common.Hash, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.recent.Hash(blockNum)
+}
+
+// AddBlock notifies about canonical block transactions matching watch-lists. Transactions mined in previously
+// added blocks with number >= blockNum are reported as reorged.
+func (w *Watcher) AddBlock(blockNum uint64, hash common.Hash, txs []Tx) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.recent.UnwindTo(blockNum, func(_ uint64, mined []Event) {
+		for i := len(mined) - 1; i >= 0; i-- {
+			ev := mined[i]
+			ev.Type = EventReorged
+			w.notify(ev)
+		}
+	})
+
+	var mined []Event
+	blockNumHex := hexutil.Uint64(blockNum)
+	for _, tx := range txs {
+		for _, ev := range w.match(EventMined, tx) {
+			ev.BlockNumber, ev.BlockHash = &blockNumHex, &hash
+			w.notify(ev)
+			mined = append(mined, ev)
+		}
+	}
+	w.recent.Push(blockNum, hash, mined)
+	w.lastBlock = &blockNum
+	if w.unsaved++; w.unsaved < saveBlocksInterval {
+		return nil
+	}
+	return w.save()
+}
+
+// Subscribe returns channel of events of the watch-list, or of all watch-lists if `list` is empty.
+// Events are dropped if the subscriber doesn't keep up. Returned func unsubscribes and closes the channel.
+func (w *Watcher) Subscribe(list string) (<-chan Event, func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextSubID
+	w.nextSubID++
+	sub := &subscriber{list: list, ch: make(chan Event, subscriberBuffer)}
+	w.subs[id] = sub
+	return sub.ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.subs[id]; ok {
+			delete(w.subs, id)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close stops webhook deliveries and saves the last processed block
+func (w *Watcher) Close() {
+	w.webhooks.close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.unsaved > 0 {
+		if err := w.save(); err != nil {
+			w.logger.Warn("[rpc] watch: could not save the last block", "err", err)
+		}
+	}
+}
+
+func (w *Watcher) match(typ string, tx Tx) []Event {
+	var res []Event
+	seen := map[string]struct{}{}
+	for _, addr := range []*common.Address{&tx.From, tx.To} {
+		if addr == nil {
+			continue
+		}
+		for id := range w.byAddress[*addr] {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			res = append(res, Event{Type: typ, List: id, TxHash: tx.Hash, From: tx.From, To: tx.To})
+		}
+	}
+	return res
+}
+
+func (w *Watcher) notify(ev Event) {
+	for _, sub := range w.subs {
+		if sub.list != "" && sub.list != ev.List {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			w.logger.Debug("[rpc] watch: subscriber is too slow, event dropped", "list", ev.List, "tx", ev.TxHash)
+		}
+	}
+	if l, ok := w.lists[ev.List]; ok && l.Webhook != "" {
+		w.webhooks.send(l.Webhook, ev)
+	}
+}
+
+func (w *Watcher) addList(l *List) {
+	w.lists[l.ID] = l
+	for _, addr := range l.Addresses {
+		ids, ok := w.byAddress[addr]
+		if !ok {
+			ids = map[string]struct{}{}
+			w.byAddress[addr] = ids
+		}
+		ids[l.ID] = struct{}{}
+	}
+}
+
+func (w *Watcher) removeList(id string) {
+	l, ok := w.lists[id]
+	if !ok {
+		return
+	}
+	delete(w.lists, id)
+	for _, addr := range l.Addresses {
+		delete(w.byAddress[addr], id)
+		if len(w.byAddress[addr]) == 0 {
+			delete(w.byAddress, addr)
+		}
+	}
+}
+
+// save writes state to a temporary file and renames it, to never leave a partially written file
+func (w *Watcher) save() error {
+	state := fileState{Lists: make([]*List, 0, len(w.lists)), LastBlock: w.lastBlock}
+	for _, l := range w.lists {
+		state.Lists = append(state.Lists, l)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := w.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return err
+	}
+	w.unsaved = 0
+	return nil
+}
+
+var errWebhookNotAllowed = errors.New("webhook host is not allowed")
+
+func (w *Watcher) validateList(l *List) error {
+	if len(l.Addresses) == 0 {
+		return errors.New("watch-list must have at least one address")
+	}
+	if len(l.Addresses) > maxListAddresses {
+		return fmt.Errorf("too many addresses: %d, max %d", len(l.Addresses), maxListAddresses)
+	}
+	if l.Webhook != "" {
+		u, err := url.Parse(l.Webhook)
+		if err != nil {
+			return fmt.Errorf("invalid webhook: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid webhook %s: only http and https are supported", l.Webhook)
+		}
+		_, hostAllowed := w.webhookHosts[strings.ToLower(u.Host)]
+		_, hostnameAllowed := w.webhookHosts[strings.ToLower(u.Hostname())]
+		if !hostAllowed && !hostnameAllowed {
+			return fmt.Errorf("%w: %s", errWebhookNotAllowed, u.Host)
+		}
+	}
+	return nil
+}
+
+func newListID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package watch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+func receive(t *testing.T, ch <-chan Event, n int) []Event {
+	t.Helper()
+	var res []Event
+	for len(res) < n {
+		select {
+		case ev := <-ch:
+			res = append(res, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d events of %d", len(res), n)
+		}
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+	return res
+}
+
+func TestWatcherPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch", "lists.json")
+	w, err := NewWatcher(path, []string{"localhost"}, log.New())
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.AddList(nil, "")
+	require.Error(t, err)
+	_, err = w.AddList([]common.Address{{1}}, "ftp://localhost")
+	require.Error(t, err)
+	_, err = w.AddList([]common.Address{{1}}, "http://169.254.169.254/latest/meta-data")
+	require.ErrorIs(t, err, errWebhookNotAllowed)
+
+	a, err := w.AddList([]common.Address{{1}, {2}}, "http://localhost/hook")
+	require.NoError(t, err)
+	b, err := w.AddList([]common.Address{{3}}, "")
+	require.NoError(t, err)
+	require.NoError(t, w.AddBlock(10, common.Hash{10}, nil))
+	removed, err := w.RemoveList(b.ID)
+	require.NoError(t, err)
+	require.True(t, removed)
+	removed, err = w.RemoveList(b.ID)
+	require.NoError(t, err)
+	require.False(t, removed)
+
+	restarted, err := NewWatcher(path, []string{"localhost"}, log.New())
+	require.NoError(t, err)
+	require.Equal(t, []List{*a}, restarted.Lists())
+	last, ok := restarted.LastBlock()
+	require.True(t, ok)
+	require.Equal(t, uint64(10), last)
+	_, ok = restarted.BlockHash(10) // hashes are not persisted, the follower continues from the next block
+	require.False(t, ok)
+
+	// blocks are saved every saveBlocksInterval blocks and on close
+	for i := uint64(1); i < saveBlocksInterval; i++ {
+		require.NoError(t, restarted.AddBlock(10+i, common.Hash{}, nil))
+	}
+	reopened, err := NewWatcher(path, []string{"localhost"}, log.New())
+	require.NoError(t, err)
+	last, _ = reopened.LastBlock()
+	require.Equal(t, uint64(10), last)
+	reopened.Close()
+	restarted.Close()
+	reopened, err = NewWatcher(path, nil, log.New())
+	require.NoError(t, err)
+	defer reopened.Close()
+	last, _ = reopened.LastBlock()
+	require.Equal(t, uint64(10+saveBlocksInterval-1), last)
+	require.Empty(t, reopened.Lists()[0].Webhook) // not allowed anymore
+}
+
+func TestWatcherEvents(t *testing.T) {
+	w, err := NewWatcher(filepath.Join(t.TempDir(), "lists.json"), nil, log.New())
+	require.NoError(t, err)
+	defer w.Close()
+
+	alice, bob, carol := common.Address{1}, common.Address{2}, common.Address{3}
+	a, err := w.AddList([]common.Address{alice}, "")
+	require.NoError(t, err)
+	b, err := w.AddList([]common.Address{alice, bob}, "")
+	require.NoError(t, err)
+	all, unsubscribeAll := w.Subscribe("")
+	defer unsubscribeAll()
+	onlyA, unsubscribeA := w.Subscribe(a.ID)
+	defer unsubscribeA()
+
+	aliceToBob := Tx{Hash: common.Hash{1}, From: alice, To: &bob}
+	bobCreates := Tx{Hash: common.Hash{2}, From: bob}
+	carolToCarol := Tx{Hash: common.Hash{3}, From: carol, To: &carol}
+
+	w.AddPendingTxs([]Tx{aliceToBob, bobCreates, carolToCarol})
+	events := receive(t, all, 3) // one per matching list, a transaction matching a list twice is reported once
+	require.ElementsMatch(t, []Event{
+		{Type: EventPending, List: a.ID, TxHash: aliceToBob.Hash, From: alice, To: &bob},
+		{Type: EventPending, List: b.ID, TxHash: aliceToBob.Hash, From: alice, To: &bob},
+		{Type: EventPending, List: b.ID, TxHash: bobCreates.Hash, From: bob},
+	}, events)
+	require.Equal(t, EventPending, receive(t, onlyA, 1)[0].Type)
+
+	// mined in block 5, which gets orphaned by block 5' where only bobCreates is mined
+	require.NoError(t, w.AddBlock(5, common.Hash{5}, []Tx{aliceToBob, bobCreates}))
+	events = receive(t, onlyA, 1)
+	require.Equal(t, EventMined, events[0].Type)
+	require.Equal(t, common.Hash{5}, *events[0].BlockHash)
+	require.Equal(t, uint64(5), uint64(*events[0].BlockNumber))
+	receive(t, all, 3)
+
+	require.NoError(t, w.AddBlock(5, common.Hash{0x55}, []Tx{bobCreates}))
+	require.Equal(t, EventReorged, receive(t, onlyA, 1)[0].Type)
+	events = receive(t, all, 4)
+	for _, ev := range events[:3] {
+		require.Equal(t, EventReorged, ev.Type)
+		require.Equal(t, common.Hash{5}, *ev.BlockHash)
+	}
+	require.Equal(t, EventMined, events[3].Type)
+	require.Equal(t, bobCreates.Hash, events[3].TxHash)
+	require.Equal(t, common.Hash{0x55}, *events[3].BlockHash)
+	hash, ok := w.BlockHash(5)
+	require.True(t, ok)
+	require.Equal(t, common.Hash{0x55}, hash)
+
+	unsubscribeA()
+	unsubscribeA() // idempotent
+	_, ok = <-onlyA
+	require.False(t, ok)
+}
+
+func TestWatcherWebhook(t *testing.T) {
+	received := make(chan Event, 10)
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 { // delivery is retried
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+		received <- ev
+	}))
+	defer srv.Close()
+
+	w, err := NewWatcher(filepath.Join(t.TempDir(), "lists.json"), []string{"127.0.0.1"}, log.New())
+	require.NoError(t, err)
+	defer w.Close()
+	withHook, err := w.AddList([]common.Address{{1}}, srv.URL)
+	require.NoError(t, err)
+	_, err = w.AddList([]common.Address{{1}}, "")
+	require.NoError(t, err)
+
+	w.AddPendingTxs([]Tx{{Hash: common.Hash{1}, From: common.Address{1}}})
+	events := receive(t, received, 1)
+	require.Equal(t, withHook.ID, events[0].List)
+	require.Equal(t, EventPending, events[0].Type)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+const (
+	webhookQueueSize = 4096
+	webhookTimeout   = 10 * time.Second
+	webhookAttempts  = 3
+	webhookBackoff   = time.Second
+)
+
+type webhookCall struct {
+	url   string
+	event Event
+}
+
+// webhookSender delivers events in order of arrival by one background worker. Delivery is retried on failure,
+// events are dropped if the queue is full (receiver is down or too slow).
+type webhookSender struct {
+	logger log.Logger
+	client *http.Client
+	queue  chan webhookCall
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWebhookSender(logger log.Logger) *webhookSender {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &webhookSender{
+		logger: logger,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// redirects could lead to hosts which are not allowed
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		queue:  make(chan webhookCall, webhookQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go s.run()
+	return s
+}
+
+func (s *webhookSender) send(url string, ev Event) {
+	select {
+	case s.queue <- webhookCall{url: url, event: ev}:
+	default:
+		s.logger.Warn("[rpc] watch: webhook queue is full, event dropped", "list", ev.List, "tx", ev.TxHash)
+	}
+}
+
+func (s *webhookSender) close() {
+	s.cancel()
+}
+
+func (s *webhookSender) run() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case call := <-s.queue:
+			var err error
+			for attempt := 0; attempt < webhookAttempts; attempt++ {
+				if attempt > 0 {
+					select {
+					case <-s.ctx.Done():
+						return
+					case <-time.After(webhookBackoff << (attempt - 1)):
+					}
+				}
+				if err = s.post(call); err == nil {
+					break
+				}
+			}
+			if err != nil {
+				s.logger.Warn("[rpc] watch: webhook delivery failed", "url", call.url, "list", call.event.List, "tx", call.event.TxHash, "err", err)
+			}
+		}
+	}
+}
+
+func (s *webhookSender) post(call webhookCall) error {
+	body, err := json.Marshal(call.event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, call.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
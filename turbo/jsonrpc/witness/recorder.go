@@ -0,0 +1,241 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package witness
+
+import (
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/types/accounts"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/vm"
+)
+
+type slotKey struct {
+	addr common.Address
+	key  common.Hash
+}
+
+// Recorder is a state reader recording the first read of every account, storage slot and code
+// from the reader of the state before the transaction. Together with the tracer (see Tracer)
+// it collects the witness of one transaction, it's not safe for concurrent use.
+type Recorder struct {
+	reader state.StateReader
+
+	accounts map[common.Address]*accounts.Account
+	storage  map[slotKey][]byte
+	codes    map[common.Hash][]byte
+	touched  map[common.Hash]map[uint64]struct{} // indexes of executed or copied chunks, by code hash
+}
+
+var _ state.StateReader = (*Recorder)(nil)
+
+func NewRecorder(reader state.StateReader) *Recorder {
+	return &Recorder{
+		reader:   reader,
+		accounts: map[common.Address]*accounts.Account{},
+		storage:  map[slotKey][]byte{},
+		codes:    map[common.Hash][]byte{},
+		touched:  map[common.Hash]map[uint64]struct{}{},
+	}
+}
+
+func (r *Recorder) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	if acc, ok := r.accounts[address]; ok {
+		return copyAccount(acc), nil
+	}
+	acc, err := r.reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	r.accounts[address] = copyAccount(acc)
+	return acc, nil
+}
+
+func (r *Recorder) ReadAccountDataForDebug(address common.Address) (*accounts.Account, error) {
+	return r.ReadAccountData(address)
+}
+
+func (r *Recorder) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	k := slotKey{addr: address, key: *key}
+	if v, ok := r.storage[k]; ok {
+		return common.CopyBytes(v), nil
+	}
+	v, err := r.reader.ReadAccountStorage(address, incarnation, key)
+	if err != nil {
+		return nil, err
+	}
+	r.storage[k] = common.CopyBytes(v)
+	return v, nil
+}
+
+func (r *Recorder) ReadAccountCode(address common.Address, incarnation uint64) ([]byte, error) {
+	var hash common.Hash
+	acc, err := r.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if acc != nil {
+		hash = acc.CodeHash
+		if code, ok := r.codes[hash]; ok {
+			return code, nil
+		}
+	}
+	code, err := r.reader.ReadAccountCode(address, incarnation)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return code, nil
+	}
+	if acc == nil {
+		hash = crypto.Keccak256Hash(code)
+	}
+	r.codes[hash] = code
+	return code, nil
+}
+
+// ReadAccountCodeSize reads the whole code, as the prover needs it to check the code hash
+func (r *Recorder) ReadAccountCodeSize(address common.Address, incarnation uint64) (int, error) {
+	code, err := r.ReadAccountCode(address, incarnation)
+	return len(code), err
+}
+
+func (r *Recorder) ReadAccountIncarnation(address common.Address) (uint64, error) {
+	return r.reader.ReadAccountIncarnation(address)
+}
+
+// Tracer returns the tracer marking the chunks of code executed or copied by the transaction
+func (r *Recorder) Tracer() vm.EVMLogger {
+	return &chunkTracer{r: r}
+}
+
+// Witness returns the recorded pre-state
+func (r *Recorder) Witness() *Witness {
+	w := &Witness{}
+	for addr, acc := range r.accounts {
+		a := Account{Address: addr}
+		if acc != nil {
+			a.Exists, a.Nonce, a.Balance, a.CodeHash = true, acc.Nonce, acc.Balance, acc.CodeHash
+		}
+		w.Accounts = append(w.Accounts, a)
+	}
+	for k, v := range r.storage {
+		w.Storage = append(w.Storage, Slot{Address: k.addr, Key: k.key, Value: v})
+	}
+	for hash, code := range r.codes {
+		c := Code{Hash: hash, Size: uint64(len(code))}
+		if touched := r.touched[hash]; len(touched) > 0 {
+			for _, ch := range Chunkify(code) {
+				if _, ok := touched[ch.Index]; ok {
+					c.Chunks = append(c.Chunks, ch)
+				}
+			}
+		}
+		w.Codes = append(w.Codes, c)
+	}
+	sortWitness(w)
+	return w
+}
+
+// touch marks chunks covering code[offset:offset+length], clipped to the code size
+func (r *Recorder) touch(hash common.Hash, offset, length *uint256.Int, size uint64) {
+	if length.IsZero() || !offset.IsUint64() || offset.Uint64() >= size {
+		return
+	}
+	from, to := offset.Uint64(), size
+	if length.IsUint64() && length.Uint64() < size-from {
+		to = from + length.Uint64()
+	}
+	r.touchRange(hash, from, to)
+}
+
+func (r *Recorder) touchRange(hash common.Hash, from, to uint64) {
+	chunks := r.touched[hash]
+	if chunks == nil {
+		chunks = map[uint64]struct{}{}
+		r.touched[hash] = chunks
+	}
+	for i := from / chunkCodeSize; i <= (to-1)/chunkCodeSize; i++ {
+		chunks[i] = struct{}{}
+	}
+}
+
+func copyAccount(acc *accounts.Account) *accounts.Account {
+	if acc == nil {
+		return nil
+	}
+	res := *acc
+	return &res
+}
+
+// chunkTracer marks the chunks of executed instructions (with their push data) and the chunks copied by
+// CODECOPY and EXTCODECOPY. Chunks of code which is not in the pre-state (init code, code created by the
+// transaction) are not included in the witness.
+type chunkTracer struct {
+	r   *Recorder
+	env *vm.EVM
+}
+
+func (t *chunkTracer) CaptureTxStart(gasLimit uint64) {}
+func (t *chunkTracer) CaptureTxEnd(restGas uint64)    {}
+func (t *chunkTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.env = env
+}
+func (t *chunkTracer) CaptureEnd(output []byte, usedGas uint64, err error) {}
+func (t *chunkTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+}
+func (t *chunkTracer) CaptureExit(output []byte, usedGas uint64, err error) {}
+func (t *chunkTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *chunkTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	contract := scope.Contract
+	size := uint64(len(contract.Code))
+	if pc < size {
+		end := pc + 1
+		if op.IsPushWithImmediateArgs() {
+			end = min(end+uint64(op-vm.PUSH1)+1, size)
+		}
+		t.r.touchRange(contract.CodeHash, pc, end)
+	}
+	st := scope.Stack
+	switch op {
+	case vm.CODECOPY:
+		if st.Len() >= 3 {
+			t.r.touch(contract.CodeHash, st.Back(1), st.Back(2), size)
+		}
+	case vm.EXTCODECOPY:
+		if st.Len() >= 4 && t.env != nil {
+			// the code is read ahead of the instruction, so that it's recorded before marking its chunks
+			addr := common.Address(st.Back(0).Bytes20())
+			ibs := t.env.IntraBlockState()
+			code, err := ibs.GetCode(addr)
+			if err != nil {
+				return
+			}
+			hash, err := ibs.GetCodeHash(addr)
+			if err != nil {
+				return
+			}
+			if _, ok := t.r.codes[hash]; ok {
+				t.r.touch(hash, st.Back(2), st.Back(3), uint64(len(code)))
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package witness records the pre-state read by a transaction (accounts, storage slots and the chunks of code
+// executed or copied) and encodes it in a compact binary format, as input for provers re-executing the transaction.
+//
+// Format, version 1 (uvarint - unsigned LEB128 varint, bytes - uvarint length followed by the data):
+//
+//	witness  = version:byte accounts storage codes
+//	accounts = count:uvarint { address:20 exists:byte [ nonce:uvarint balance:bytes codeHash:32 ] }
+//	storage  = count:uvarint { address:20 key:32 value:bytes }
+//	codes    = count:uvarint { codeHash:32 size:uvarint chunks:uvarint { index:uvarint chunk:32 } }
+//
+// Entries are sorted by address, key, code hash and chunk index. Values are the ones before the transaction.
+// Storage values have no leading zeros. Code is split into chunks of 31 bytes, every chunk is prefixed by the
+// amount of its leading bytes which are PUSH data (as in EIP-6800), only chunks which were executed or copied
+// (CODECOPY, EXTCODECOPY) are included.
+package witness
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+const (
+	Version = 1
+
+	ChunkSize     = 32
+	chunkCodeSize = ChunkSize - 1
+)
+
+// Account is the account before the transaction, only the address is set if it didn't exist
+type Account struct {
+	Address  common.Address
+	Exists   bool
+	Nonce    uint64
+	Balance  uint256.Int
+	CodeHash common.Hash
+}
+
+// Slot is the storage slot before the transaction
+type Slot struct {
+	Address common.Address
+	Key     common.Hash
+	Value   []byte
+}
+
+// Chunk is a piece of code, see package doc
+type Chunk struct {
+	Index uint64
+	Data  [ChunkSize]byte
+}
+
+// Code is the code read by the transaction, Chunks are the executed or copied ones
+type Code struct {
+	Hash   common.Hash
+	Size   uint64
+	Chunks []Chunk
+}
+
+// Witness is the pre-state read by one transaction
+type Witness struct {
+	Accounts []Account
+	Storage  []Slot
+	Codes    []Code
+}
+
+// Encode returns the binary witness, see package doc
+func (w *Witness) Encode() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(Version)
+	writeUvarint(&buf, uint64(len(w.Accounts)))
+	for _, a := range w.Accounts {
+		buf.Write(a.Address[:])
+		if !a.Exists {
+			buf.WriteByte(0)
+			continue
+		}
+		buf.WriteByte(1)
+		writeUvarint(&buf, a.Nonce)
+		writeBytes(&buf, a.Balance.Bytes())
+		buf.Write(a.CodeHash[:])
+	}
+	writeUvarint(&buf, uint64(len(w.Storage)))
+	for _, s := range w.Storage {
+		buf.Write(s.Address[:])
+		buf.Write(s.Key[:])
+		writeBytes(&buf, s.Value)
+	}
+	writeUvarint(&buf, uint64(len(w.Codes)))
+	for _, c := range w.Codes {
+		buf.Write(c.Hash[:])
+		writeUvarint(&buf, c.Size)
+		writeUvarint(&buf, uint64(len(c.Chunks)))
+		for _, ch := range c.Chunks {
+			writeUvarint(&buf, ch.Index)
+			buf.Write(ch.Data[:])
+		}
+	}
+	return buf.Bytes()
+}
+
+// Decode parses the binary witness
+func Decode(data []byte) (*Witness, error) {
+	r := bytes.NewReader(data)
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != Version {
+		return nil, fmt.Errorf("unsupported witness version %d", version)
+	}
+	w := &Witness{}
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < n; i++ {
+		var a Account
+		if err := readFull(r, a.Address[:]); err != nil {
+			return nil, err
+		}
+		exists, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 1 {
+			a.Exists = true
+			if a.Nonce, err = binary.ReadUvarint(r); err != nil {
+				return nil, err
+			}
+			balance, err := readBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			if len(balance) > 32 {
+				return nil, errors.New("balance is longer than 32 bytes")
+			}
+			a.Balance.SetBytes(balance)
+			if err := readFull(r, a.CodeHash[:]); err != nil {
+				return nil, err
+			}
+		}
+		w.Accounts = append(w.Accounts, a)
+	}
+	if n, err = readCount(r); err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < n; i++ {
+		var s Slot
+		if err := readFull(r, s.Address[:]); err != nil {
+			return nil, err
+		}
+		if err := readFull(r, s.Key[:]); err != nil {
+			return nil, err
+		}
+		if s.Value, err = readBytes(r); err != nil {
+			return nil, err
+		}
+		w.Storage = append(w.Storage, s)
+	}
+	if n, err = readCount(r); err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < n; i++ {
+		var c Code
+		if err := readFull(r, c.Hash[:]); err != nil {
+			return nil, err
+		}
+		if c.Size, err = binary.ReadUvarint(r); err != nil {
+			return nil, err
+		}
+		chunks, err := readCount(r)
+		if err != nil {
+			return nil, err
+		}
+		for j := uint64(0); j < chunks; j++ {
+			var ch Chunk
+			if ch.Index, err = binary.ReadUvarint(r); err != nil {
+				return nil, err
+			}
+			if err := readFull(r, ch.Data[:]); err != nil {
+				return nil, err
+			}
+			c.Chunks = append(c.Chunks, ch)
+		}
+		w.Codes = append(w.Codes, c)
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d trailing bytes", r.Len())
+	}
+	return w, nil
+}
+
+// Chunkify splits the code into chunks, see package doc
+func Chunkify(code []byte) []Chunk {
+	chunks := make([]Chunk, (len(code)+chunkCodeSize-1)/chunkCodeSize)
+	var pushDataEnd int // end of the push data of the last PUSH
+	for pc := 0; pc < len(code); pc++ {
+		if pc%chunkCodeSize == 0 {
+			ch := &chunks[pc/chunkCodeSize]
+			ch.Index = uint64(pc / chunkCodeSize)
+			ch.Data[0] = byte(min(max(pushDataEnd-pc, 0), chunkCodeSize))
+		}
+		chunks[pc/chunkCodeSize].Data[1+pc%chunkCodeSize] = code[pc]
+		if pc < pushDataEnd {
+			continue
+		}
+		if op := code[pc]; op >= 0x60 && op <= 0x7f { // PUSH1..PUSH32
+			pushDataEnd = pc + 1 + int(op) - 0x5f
+		}
+	}
+	return chunks
+}
+
+func sortWitness(w *Witness) {
+	sort.Slice(w.Accounts, func(i, j int) bool {
+		return bytes.Compare(w.Accounts[i].Address[:], w.Accounts[j].Address[:]) < 0
+	})
+	sort.Slice(w.Storage, func(i, j int) bool {
+		if c := bytes.Compare(w.Storage[i].Address[:], w.Storage[j].Address[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(w.Storage[i].Key[:], w.Storage[j].Key[:]) < 0
+	})
+	sort.Slice(w.Codes, func(i, j int) bool { return bytes.Compare(w.Codes[i].Hash[:], w.Codes[j].Hash[:]) < 0 })
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func writeBytes(buf *bytes.Buffer, data []byte) {
+	writeUvarint(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+// readCount reads the amount of entries, which can't exceed the amount of remaining bytes
+func readCount(r *bytes.Reader) (uint64, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(r.Len()) {
+		return 0, fmt.Errorf("bad entries count %d", n)
+	}
+	return n, nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	return data, readFull(r, data)
+}
+
+func readFull(r *bytes.Reader, data []byte) error {
+	_, err := io.ReadFull(r, data)
+	return err
+}
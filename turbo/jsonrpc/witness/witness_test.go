@@ -0,0 +1,119 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package witness
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/types/accounts"
+)
+
+func TestChunkify(t *testing.T) {
+	// 30 bytes of JUMPDEST, then PUSH4 whose data spans the chunk boundary
+	code := append(bytes.Repeat([]byte{0x5b}, 30), 0x63, 1, 2, 3, 4, 0x00)
+	chunks := Chunkify(code)
+	require.Len(t, chunks, 2)
+	require.Equal(t, byte(0), chunks[0].Data[0])
+	require.Equal(t, code[:31], chunks[0].Data[1:])
+	require.Equal(t, uint64(1), chunks[1].Index)
+	require.Equal(t, byte(4), chunks[1].Data[0]) // 4 bytes of PUSH4 data
+	require.Equal(t, code[31:], chunks[1].Data[1:1+len(code)-31])
+}
+
+func TestEncodeDecode(t *testing.T) {
+	code := bytes.Repeat([]byte{0x5b}, 70)
+	w := &Witness{
+		Accounts: []Account{
+			{Address: common.Address{1}, Exists: true, Nonce: 7, Balance: *uint256.NewInt(1e18), CodeHash: crypto.Keccak256Hash(code)},
+			{Address: common.Address{2}},
+		},
+		Storage: []Slot{{Address: common.Address{1}, Key: common.Hash{3}, Value: []byte{0x12, 0x34}}},
+		Codes:   []Code{{Hash: crypto.Keccak256Hash(code), Size: uint64(len(code)), Chunks: Chunkify(code)[1:2]}},
+	}
+	data := w.Encode()
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, w, decoded)
+
+	_, err = Decode(data[:len(data)-1])
+	require.Error(t, err)
+	_, err = Decode(append(data, 0))
+	require.Error(t, err)
+}
+
+type testReader struct {
+	accounts map[common.Address]*accounts.Account
+	code     map[common.Address][]byte
+	reads    int
+}
+
+func (r *testReader) ReadAccountData(address common.Address) (*accounts.Account, error) {
+	r.reads++
+	return r.accounts[address], nil
+}
+func (r *testReader) ReadAccountDataForDebug(address common.Address) (*accounts.Account, error) {
+	return r.ReadAccountData(address)
+}
+func (r *testReader) ReadAccountStorage(address common.Address, incarnation uint64, key *common.Hash) ([]byte, error) {
+	r.reads++
+	return key[31:], nil
+}
+func (r *testReader) ReadAccountCode(address common.Address, incarnation uint64) ([]byte, error) {
+	r.reads++
+	return r.code[address], nil
+}
+func (r *testReader) ReadAccountCodeSize(address common.Address, incarnation uint64) (int, error) {
+	return len(r.code[address]), nil
+}
+func (r *testReader) ReadAccountIncarnation(address common.Address) (uint64, error) { return 0, nil }
+
+func TestRecorder(t *testing.T) {
+	code := bytes.Repeat([]byte{0x5b}, 100)
+	hash := crypto.Keccak256Hash(code)
+	contract := common.Address{1}
+	reader := &testReader{
+		accounts: map[common.Address]*accounts.Account{contract: {Nonce: 1, CodeHash: hash}},
+		code:     map[common.Address][]byte{contract: code},
+	}
+	r := NewRecorder(reader)
+	for i := 0; i < 2; i++ {
+		_, err := r.ReadAccountData(contract)
+		require.NoError(t, err)
+		_, err = r.ReadAccountData(common.Address{2})
+		require.NoError(t, err)
+		_, err = r.ReadAccountStorage(contract, 1, &common.Hash{31: 5})
+		require.NoError(t, err)
+		_, err = r.ReadAccountCode(contract, 1)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, reader.reads)
+	r.touchRange(hash, 30, 32) // chunks 0 and 1
+
+	w := r.Witness()
+	require.Equal(t, []Account{{Address: contract, Exists: true, Nonce: 1, CodeHash: hash}, {Address: common.Address{2}}}, w.Accounts)
+	require.Equal(t, []Slot{{Address: contract, Key: common.Hash{31: 5}, Value: []byte{5}}}, w.Storage)
+	require.Len(t, w.Codes, 1)
+	require.Equal(t, uint64(100), w.Codes[0].Size)
+	require.Len(t, w.Codes[0].Chunks, 2)
+	require.Equal(t, []uint64{0, 1}, []uint64{w.Codes[0].Chunks[0].Index, w.Codes[0].Chunks[1].Index})
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpchelper
+
+import (
+	"context"
+
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon-lib/kv/order"
+	"github.com/erigontech/erigon-lib/kv/stream"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// NewCostAccountingDB counts reads of the transactions opened for requests with cost accounting
+// (see rpc.CostFromContext): every point read and every opened cursor or range is one read.
+func NewCostAccountingDB(db kv.TemporalRoDB) kv.TemporalRoDB {
+	return &costAccountingDB{TemporalRoDB: db}
+}
+
+type costAccountingDB struct {
+	kv.TemporalRoDB
+}
+
+func (db *costAccountingDB) BeginTemporalRo(ctx context.Context) (kv.TemporalTx, error) {
+	tx, err := db.TemporalRoDB.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cost := rpc.CostFromContext(ctx); cost != nil {
+		return &costAccountingTx{TemporalTx: tx, cost: cost}, nil
+	}
+	return tx, nil
+}
+
+func (db *costAccountingDB) ViewTemporal(ctx context.Context, f func(tx kv.TemporalTx) error) error {
+	cost := rpc.CostFromContext(ctx)
+	if cost == nil {
+		return db.TemporalRoDB.ViewTemporal(ctx, f)
+	}
+	return db.TemporalRoDB.ViewTemporal(ctx, func(tx kv.TemporalTx) error {
+		return f(&costAccountingTx{TemporalTx: tx, cost: cost})
+	})
+}
+
+func (db *costAccountingDB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	tx, err := db.TemporalRoDB.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return wrapCostAccountingTx(ctx, tx), nil
+}
+
+func (db *costAccountingDB) View(ctx context.Context, f func(tx kv.Tx) error) error {
+	return db.TemporalRoDB.View(ctx, func(tx kv.Tx) error {
+		return f(wrapCostAccountingTx(ctx, tx))
+	})
+}
+
+// wrapCostAccountingTx keeps the transaction as is if it's not temporal, so that type assertions on it still work
+func wrapCostAccountingTx(ctx context.Context, tx kv.Tx) kv.Tx {
+	cost := rpc.CostFromContext(ctx)
+	ttx, ok := tx.(kv.TemporalTx)
+	if cost == nil || !ok {
+		return tx
+	}
+	return &costAccountingTx{TemporalTx: ttx, cost: cost}
+}
+
+type costAccountingTx struct {
+	kv.TemporalTx
+	cost *rpc.Cost
+}
+
+// AggTx exposes the aggregator of the wrapped transaction, see state.HasAggTx
+func (tx *costAccountingTx) AggTx() any {
+	if aggTx, ok := tx.TemporalTx.(interface{ AggTx() any }); ok {
+		return aggTx.AggTx()
+	}
+	return nil
+}
+
+func (tx *costAccountingTx) Has(table string, key []byte) (bool, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.Has(table, key)
+}
+
+func (tx *costAccountingTx) GetOne(table string, key []byte) ([]byte, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.GetOne(table, key)
+}
+
+func (tx *costAccountingTx) Cursor(table string) (kv.Cursor, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.Cursor(table)
+}
+
+func (tx *costAccountingTx) CursorDupSort(table string) (kv.CursorDupSort, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.CursorDupSort(table)
+}
+
+func (tx *costAccountingTx) Range(table string, fromPrefix, toPrefix []byte, asc order.By, limit int) (stream.KV, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.Range(table, fromPrefix, toPrefix, asc, limit)
+}
+
+func (tx *costAccountingTx) Prefix(table string, prefix []byte) (stream.KV, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.Prefix(table, prefix)
+}
+
+func (tx *costAccountingTx) GetLatest(name kv.Domain, k []byte) ([]byte, uint64, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.GetLatest(name, k)
+}
+
+func (tx *costAccountingTx) GetAsOf(name kv.Domain, k []byte, ts uint64) ([]byte, bool, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.GetAsOf(name, k, ts)
+}
+
+func (tx *costAccountingTx) HistorySeek(name kv.Domain, k []byte, ts uint64) ([]byte, bool, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.HistorySeek(name, k, ts)
+}
+
+func (tx *costAccountingTx) RangeAsOf(name kv.Domain, fromKey, toKey []byte, ts uint64, asc order.By, limit int) (stream.KV, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.RangeAsOf(name, fromKey, toKey, ts, asc, limit)
+}
+
+func (tx *costAccountingTx) IndexRange(name kv.InvertedIdx, k []byte, fromTs, toTs int, asc order.By, limit int) (stream.U64, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.IndexRange(name, k, fromTs, toTs, asc, limit)
+}
+
+func (tx *costAccountingTx) HistoryRange(name kv.Domain, fromTs, toTs int, asc order.By, limit int) (stream.KV, error) {
+	tx.cost.AddDBReads(1)
+	return tx.TemporalTx.HistoryRange(name, fromTs, toTs, asc, limit)
+}
@@ -52,7 +52,8 @@ import (
 type Filters struct {
 	mu sync.RWMutex
 
-	pendingBlock *types.Block
+	pendingBlock      *types.Block
+	pendingTxsVersion atomic.Uint64 // bumped on every batch of transactions added to the pool
 
 	headsSubs        *concurrent.SyncMap[HeadsSubID, Sub[*types.Header]]
 	pendingLogsSubs  *concurrent.SyncMap[PendingLogsSubID, Sub[types.Logs]]
@@ -644,12 +645,18 @@ func (ff *Filters) OnNewTx(reply *txpool.OnAddReply) {
 			break
 		}
 	}
+	ff.pendingTxsVersion.Add(1)
 	ff.pendingTxsSubs.Range(func(k PendingTxsSubID, v Sub[[]types.Transaction]) error {
 		v.Send(txs)
 		return nil
 	})
 }
 
+// PendingTxsVersion changes whenever transactions are added to the pool, derived views of the pool are stale then
+func (ff *Filters) PendingTxsVersion() uint64 {
+	return ff.pendingTxsVersion.Load()
+}
+
 // OnNewLogs handles a new log event from the remote and processes it.
 func (ff *Filters) OnNewLogs(reply *remote.SubscribeLogsReply) {
 	ff.logsSubs.distributeLog(reply)
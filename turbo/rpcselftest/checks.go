@@ -0,0 +1,541 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpcselftest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+)
+
+const (
+	maxTxSearchDepth = 128 // blocks searched back from the latest one for a transaction to check
+	invalidParams    = -32602
+	methodNotFound   = -32601
+)
+
+var zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// builtinChecks are chain independent: they check consistency of responses of different methods for the same data
+func builtinChecks(erigon bool) []check {
+	checks := []check{
+		{"web3_clientVersion", "web3", checkClientVersion},
+		{"eth_chainId", "eth", checkChainID},
+		{"net_version", "net", checkNetVersion},
+		{"eth_blockNumber", "eth", checkBlockNumber},
+		{"eth_getBlockByNumber", "eth", checkBlockByNumber},
+		{"eth_getBlockByHash", "eth", checkBlockByHash},
+		{"eth_getBlockTransactionCountByHash", "eth", checkBlockTransactionCount},
+		{"eth_getTransactionByHash", "eth", checkTransactionByHash},
+		{"eth_getTransactionReceipt", "eth", checkTransactionReceipt},
+		{"eth_getBlockReceipts", "eth", checkBlockReceipts},
+		{"eth_getLogs", "eth", checkLogs},
+		{"eth_getBalance", "eth", checkBalance},
+		{"eth_getCode", "eth", checkCode},
+		{"eth_getStorageAt", "eth", checkStorageAt},
+		{"eth_call", "eth", checkCall},
+		{"eth_estimateGas", "eth", checkEstimateGas},
+		{"eth_gasPrice", "eth", checkGasPrice},
+		{"eth_feeHistory", "eth", checkFeeHistory},
+		{"eth_syncing", "eth", checkSyncing},
+		{"unknown method", "errors", checkUnknownMethod},
+		{"malformed params", "errors", checkMalformedParams},
+	}
+	if erigon {
+		checks = append(checks,
+			check{"erigon_blockNumber", "erigon", checkErigonBlockNumber},
+			check{"erigon_getHeaderByNumber", "erigon", checkErigonHeaderByNumber},
+			check{"erigon_getLogsByHash", "erigon", checkErigonLogsByHash},
+			check{"erigon_forks", "erigon", checkErigonForks},
+		)
+	}
+	return checks
+}
+
+func checkClientVersion(ctx context.Context, s *session) error {
+	var v string
+	if err := s.call(ctx, &v, "web3_clientVersion"); err != nil {
+		return err
+	}
+	if v == "" {
+		return errors.New("empty client version")
+	}
+	return nil
+}
+
+func checkChainID(ctx context.Context, s *session) error {
+	var id hexutil.Uint64
+	if err := s.call(ctx, &id, "eth_chainId"); err != nil {
+		return err
+	}
+	s.chainID = uint64(id)
+	return nil
+}
+
+func checkNetVersion(ctx context.Context, s *session) error {
+	var v string
+	if err := s.call(ctx, &v, "net_version"); err != nil {
+		return err
+	}
+	if s.chainID != 0 && v != strconv.FormatUint(s.chainID, 10) {
+		return fmt.Errorf("net_version %s != eth_chainId %d", v, s.chainID)
+	}
+	return nil
+}
+
+func checkBlockNumber(ctx context.Context, s *session) error {
+	var n hexutil.Uint64
+	if err := s.call(ctx, &n, "eth_blockNumber"); err != nil {
+		return err
+	}
+	s.blockNumber = uint64(n)
+	return nil
+}
+
+func checkBlockByNumber(ctx context.Context, s *session) error {
+	var block map[string]json.RawMessage
+	if err := s.call(ctx, &block, "eth_getBlockByNumber", "latest", false); err != nil {
+		return err
+	}
+	if block == nil {
+		return errors.New("latest block is null")
+	}
+	for _, field := range []string{"number", "hash", "parentHash", "stateRoot", "transactionsRoot", "receiptsRoot", "miner", "gasLimit", "gasUsed", "timestamp", "transactions"} {
+		if _, ok := block[field]; !ok {
+			return fmt.Errorf("no %q in the block", field)
+		}
+	}
+	n, err := quantity(block["number"])
+	if err != nil {
+		return err
+	}
+	if n < s.blockNumber {
+		return fmt.Errorf("latest block %d < eth_blockNumber %d", n, s.blockNumber)
+	}
+	s.block = block
+	return nil
+}
+
+func checkBlockByHash(ctx context.Context, s *session) error {
+	if err := s.needBlock(); err != nil {
+		return err
+	}
+	var block map[string]json.RawMessage
+	if err := s.call(ctx, &block, "eth_getBlockByHash", str(s.block["hash"]), false); err != nil {
+		return err
+	}
+	return requireSameJSON("block by hash", block, s.block)
+}
+
+func checkBlockTransactionCount(ctx context.Context, s *session) error {
+	if err := s.needBlock(); err != nil {
+		return err
+	}
+	var txs []string
+	if err := json.Unmarshal(s.block["transactions"], &txs); err != nil {
+		return fmt.Errorf("transactions of the block: %w", err)
+	}
+	var n hexutil.Uint64
+	if err := s.call(ctx, &n, "eth_getBlockTransactionCountByHash", str(s.block["hash"])); err != nil {
+		return err
+	}
+	if int(n) != len(txs) {
+		return fmt.Errorf("%d transactions, the block has %d", n, len(txs))
+	}
+	return nil
+}
+
+func checkTransactionByHash(ctx context.Context, s *session) error {
+	if err := s.findTx(ctx); err != nil {
+		return err
+	}
+	var txn map[string]json.RawMessage
+	if err := s.call(ctx, &txn, "eth_getTransactionByHash", s.txHash); err != nil {
+		return err
+	}
+	if txn == nil {
+		return fmt.Errorf("transaction %s is null", s.txHash)
+	}
+	if !strings.EqualFold(str(txn["hash"]), s.txHash) {
+		return fmt.Errorf("hash %s, requested %s", str(txn["hash"]), s.txHash)
+	}
+	if n, err := quantity(txn["blockNumber"]); err != nil || n != s.txBlock {
+		return fmt.Errorf("block number %s, the transaction is in %d", txn["blockNumber"], s.txBlock)
+	}
+	if i, err := quantity(txn["transactionIndex"]); err != nil || i != 0 {
+		return fmt.Errorf("transaction index %s, the transaction is the first one", txn["transactionIndex"])
+	}
+	return nil
+}
+
+func checkTransactionReceipt(ctx context.Context, s *session) error {
+	if err := s.findTx(ctx); err != nil {
+		return err
+	}
+	var receipt map[string]json.RawMessage
+	if err := s.call(ctx, &receipt, "eth_getTransactionReceipt", s.txHash); err != nil {
+		return err
+	}
+	if receipt == nil {
+		return fmt.Errorf("receipt of %s is null", s.txHash)
+	}
+	for _, field := range []string{"transactionHash", "blockHash", "blockNumber", "gasUsed", "cumulativeGasUsed", "logs", "logsBloom", "status"} {
+		if _, ok := receipt[field]; !ok {
+			return fmt.Errorf("no %q in the receipt", field)
+		}
+	}
+	if !strings.EqualFold(str(receipt["transactionHash"]), s.txHash) {
+		return fmt.Errorf("transaction hash %s, requested %s", str(receipt["transactionHash"]), s.txHash)
+	}
+	return nil
+}
+
+func checkBlockReceipts(ctx context.Context, s *session) error {
+	if err := s.findTx(ctx); err != nil {
+		return err
+	}
+	var block struct {
+		Transactions []string `json:"transactions"`
+	}
+	if err := s.call(ctx, &block, "eth_getBlockByNumber", hexutil.Uint64(s.txBlock), false); err != nil {
+		return err
+	}
+	receipts, err := s.blockReceipts(ctx, s.txBlock)
+	if err != nil {
+		return err
+	}
+	if len(receipts) != len(block.Transactions) {
+		return fmt.Errorf("%d receipts, the block has %d transactions", len(receipts), len(block.Transactions))
+	}
+	for i, r := range receipts {
+		if !strings.EqualFold(r.TransactionHash, block.Transactions[i]) {
+			return fmt.Errorf("receipt %d is of %s, the transaction is %s", i, r.TransactionHash, block.Transactions[i])
+		}
+	}
+	return nil
+}
+
+func checkLogs(ctx context.Context, s *session) error {
+	if err := s.findTx(ctx); err != nil {
+		return err
+	}
+	receipts, err := s.blockReceipts(ctx, s.txBlock)
+	if err != nil {
+		return err
+	}
+	var expected int
+	for _, r := range receipts {
+		expected += len(r.Logs)
+	}
+	var logs []struct {
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	}
+	n := hexutil.Uint64(s.txBlock)
+	if err := s.call(ctx, &logs, "eth_getLogs", map[string]interface{}{"fromBlock": n, "toBlock": n}); err != nil {
+		return err
+	}
+	if len(logs) != expected {
+		return fmt.Errorf("%d logs, receipts of block %d have %d", len(logs), s.txBlock, expected)
+	}
+	for _, l := range logs {
+		if l.BlockNumber != n {
+			return fmt.Errorf("log of block %d, requested %d", l.BlockNumber, n)
+		}
+	}
+	return nil
+}
+
+func checkBalance(ctx context.Context, s *session) error {
+	if err := s.needBlock(); err != nil {
+		return err
+	}
+	var balance hexutil.Big
+	return s.call(ctx, &balance, "eth_getBalance", str(s.block["miner"]), "latest")
+}
+
+func checkCode(ctx context.Context, s *session) error {
+	var code hexutil.Bytes
+	return s.call(ctx, &code, "eth_getCode", zeroAddress, "latest")
+}
+
+func checkStorageAt(ctx context.Context, s *session) error {
+	var v hexutil.Bytes
+	if err := s.call(ctx, &v, "eth_getStorageAt", zeroAddress, "0x0", "latest"); err != nil {
+		return err
+	}
+	if len(v) != 32 {
+		return fmt.Errorf("%d bytes, expected 32", len(v))
+	}
+	return nil
+}
+
+func checkCall(ctx context.Context, s *session) error {
+	var v hexutil.Bytes
+	if err := s.call(ctx, &v, "eth_call", map[string]interface{}{"to": zeroAddress}, "latest"); err != nil {
+		return err
+	}
+	if len(v) != 0 {
+		return fmt.Errorf("call of an account without code returned %x", []byte(v))
+	}
+	return nil
+}
+
+func checkEstimateGas(ctx context.Context, s *session) error {
+	var gas hexutil.Uint64
+	if err := s.call(ctx, &gas, "eth_estimateGas", map[string]interface{}{"from": zeroAddress, "to": zeroAddress, "value": "0x0"}); err != nil {
+		return err
+	}
+	if uint64(gas) != params.TxGas {
+		return fmt.Errorf("plain transfer estimated %d gas, expected %d", gas, params.TxGas)
+	}
+	return nil
+}
+
+func checkGasPrice(ctx context.Context, s *session) error {
+	var price hexutil.Big
+	return s.call(ctx, &price, "eth_gasPrice")
+}
+
+func checkFeeHistory(ctx context.Context, s *session) error {
+	var history struct {
+		OldestBlock   hexutil.Uint64  `json:"oldestBlock"`
+		BaseFeePerGas []hexutil.Big   `json:"baseFeePerGas"`
+		GasUsedRatio  []float64       `json:"gasUsedRatio"`
+		Reward        [][]hexutil.Big `json:"reward"`
+	}
+	if err := s.call(ctx, &history, "eth_feeHistory", hexutil.Uint64(4), "latest", []float64{50}); err != nil {
+		return err
+	}
+	blocks := len(history.GasUsedRatio)
+	if blocks == 0 || blocks > 4 {
+		return fmt.Errorf("%d blocks, requested 4", blocks)
+	}
+	if len(history.Reward) != blocks {
+		return fmt.Errorf("%d rewards for %d blocks", len(history.Reward), blocks)
+	}
+	if len(history.BaseFeePerGas) != 0 && len(history.BaseFeePerGas) != blocks+1 {
+		return fmt.Errorf("%d base fees for %d blocks, expected one more", len(history.BaseFeePerGas), blocks)
+	}
+	return nil
+}
+
+func checkSyncing(ctx context.Context, s *session) error {
+	var v json.RawMessage
+	if err := s.call(ctx, &v, "eth_syncing"); err != nil {
+		return err
+	}
+	if !bytes.Equal(v, []byte("false")) && (len(v) == 0 || v[0] != '{') {
+		return fmt.Errorf("%s is neither false nor a sync status", v)
+	}
+	return nil
+}
+
+func checkUnknownMethod(ctx context.Context, s *session) error {
+	return s.expectError(ctx, methodNotFound, "selftest_noSuchMethod")
+}
+
+func checkMalformedParams(ctx context.Context, s *session) error {
+	cases := []struct {
+		method string
+		args   []interface{}
+	}{
+		{"eth_getBlockByNumber", []interface{}{"0xzz", false}},
+		{"eth_getBlockByNumber", nil},
+		{"eth_getBlockByHash", []interface{}{123, false}},
+		{"eth_getBalance", []interface{}{"0x1234", "latest"}},
+		{"eth_getTransactionByHash", []interface{}{"not a hash"}},
+		{"eth_getLogs", []interface{}{"not a filter"}},
+	}
+	for _, c := range cases {
+		if err := s.expectError(ctx, invalidParams, c.method, c.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkErigonBlockNumber(ctx context.Context, s *session) error {
+	var n hexutil.Uint64
+	return s.call(ctx, &n, "erigon_blockNumber")
+}
+
+func checkErigonHeaderByNumber(ctx context.Context, s *session) error {
+	if err := s.needBlock(); err != nil {
+		return err
+	}
+	var header map[string]json.RawMessage
+	if err := s.call(ctx, &header, "erigon_getHeaderByNumber", str(s.block["number"])); err != nil {
+		return err
+	}
+	if header == nil {
+		return errors.New("header is null")
+	}
+	if !strings.EqualFold(str(header["hash"]), str(s.block["hash"])) {
+		return fmt.Errorf("header hash %s, block hash %s", str(header["hash"]), str(s.block["hash"]))
+	}
+	return nil
+}
+
+func checkErigonLogsByHash(ctx context.Context, s *session) error {
+	if err := s.findTx(ctx); err != nil {
+		return err
+	}
+	receipts, err := s.blockReceipts(ctx, s.txBlock)
+	if err != nil {
+		return err
+	}
+	if len(receipts) == 0 {
+		return fmt.Errorf("no receipts of block %d", s.txBlock)
+	}
+	var logs [][]json.RawMessage
+	if err := s.call(ctx, &logs, "erigon_getLogsByHash", receipts[0].BlockHash); err != nil {
+		return err
+	}
+	if len(logs) != len(receipts) {
+		return fmt.Errorf("logs of %d transactions, the block has %d", len(logs), len(receipts))
+	}
+	for i := range logs {
+		if len(logs[i]) != len(receipts[i].Logs) {
+			return fmt.Errorf("%d logs of transaction %d, the receipt has %d", len(logs[i]), i, len(receipts[i].Logs))
+		}
+	}
+	return nil
+}
+
+func checkErigonForks(ctx context.Context, s *session) error {
+	var forks struct {
+		Genesis string `json:"genesis"`
+	}
+	if err := s.call(ctx, &forks, "erigon_forks"); err != nil {
+		return err
+	}
+	var genesis struct {
+		Hash string `json:"hash"`
+	}
+	if err := s.call(ctx, &genesis, "eth_getBlockByNumber", "0x0", false); err != nil {
+		return err
+	}
+	if !strings.EqualFold(forks.Genesis, genesis.Hash) {
+		return fmt.Errorf("genesis %s, block 0 is %s", forks.Genesis, genesis.Hash)
+	}
+	return nil
+}
+
+func (s *session) needBlock() error {
+	if s.block == nil {
+		return fmt.Errorf("%w: eth_getBlockByNumber failed", errSkip)
+	}
+	return nil
+}
+
+// findTx finds the latest block with transactions, within maxTxSearchDepth blocks
+func (s *session) findTx(ctx context.Context) error {
+	if s.txHash != "" {
+		return nil
+	}
+	if err := s.needBlock(); err != nil {
+		return err
+	}
+	latest, err := quantity(s.block["number"])
+	if err != nil {
+		return err
+	}
+	for n := latest; n+maxTxSearchDepth > latest; n-- {
+		var block struct {
+			Transactions []string `json:"transactions"`
+		}
+		if err := s.call(ctx, &block, "eth_getBlockByNumber", hexutil.Uint64(n), false); err != nil {
+			return err
+		}
+		if len(block.Transactions) > 0 {
+			s.txBlock, s.txHash = n, block.Transactions[0]
+			return nil
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return fmt.Errorf("%w: no transactions in the last %d blocks", errSkip, maxTxSearchDepth)
+}
+
+type receipt struct {
+	TransactionHash string            `json:"transactionHash"`
+	BlockHash       string            `json:"blockHash"`
+	Logs            []json.RawMessage `json:"logs"`
+}
+
+func (s *session) blockReceipts(ctx context.Context, n uint64) ([]receipt, error) {
+	var receipts []receipt
+	if err := s.call(ctx, &receipts, "eth_getBlockReceipts", hexutil.Uint64(n)); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// expectError checks that the call fails with the JSON-RPC error code
+func (s *session) expectError(ctx context.Context, code int, method string, args ...interface{}) error {
+	var v json.RawMessage
+	err := s.client.CallContext(ctx, &v, method, args...)
+	if err == nil {
+		return fmt.Errorf("%s%v: returned %s, expected error %d", method, args, v, code)
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return fmt.Errorf("%s%v: %w", method, args, err)
+	}
+	if rpcErr.ErrorCode() != code {
+		return fmt.Errorf("%s%v: error code %d (%s), expected %d", method, args, rpcErr.ErrorCode(), rpcErr.Error(), code)
+	}
+	return nil
+}
+
+func quantity(raw json.RawMessage) (uint64, error) {
+	var n hexutil.Uint64
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0, fmt.Errorf("quantity %s: %w", raw, err)
+	}
+	return uint64(n), nil
+}
+
+// str returns the JSON string, empty if it's not a string
+func str(raw json.RawMessage) string {
+	var s string
+	_ = json.Unmarshal(raw, &s)
+	return s
+}
+
+func requireSameJSON(what string, got, expected interface{}) error {
+	g, err := json.Marshal(got)
+	if err != nil {
+		return err
+	}
+	e, err := json.Marshal(expected)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(g, e) {
+		return fmt.Errorf("%s differs: %s, expected %s", what, g, e)
+	}
+	return nil
+}
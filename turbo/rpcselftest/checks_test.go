@@ -0,0 +1,309 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcselftest
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/rpc"
+)
+
+// testNode is an in-process node of blocks 0, 1 and 2, block 2 has one transaction with one log
+type testNode struct {
+	brokenNetVersion bool // net_version doesn't match eth_chainId
+	brokenLogs       bool // eth_getLogs misses logs
+	crashGasPrice    bool // eth_gasPrice handler panics
+}
+
+const (
+	testChainID = 1337
+	testLatest  = 2
+)
+
+var testTxHash = common.Hash{0xaa}
+
+func testBlockHash(n uint64) common.Hash { return common.Hash{byte(n) + 1} }
+
+func (n *testNode) block(num uint64) map[string]interface{} {
+	if num > testLatest {
+		return nil
+	}
+	txs := []common.Hash{}
+	if num == testLatest {
+		txs = append(txs, testTxHash)
+	}
+	return map[string]interface{}{
+		"number": hexutil.Uint64(num), "hash": testBlockHash(num), "parentHash": testBlockHash(num - 1),
+		"stateRoot": common.Hash{}, "transactionsRoot": common.Hash{}, "receiptsRoot": common.Hash{},
+		"miner": common.Address{}, "gasLimit": hexutil.Uint64(30_000_000), "gasUsed": hexutil.Uint64(len(txs) * 21000),
+		"timestamp": hexutil.Uint64(num * 12), "transactions": txs,
+	}
+}
+
+func (n *testNode) log() map[string]interface{} {
+	return map[string]interface{}{"blockNumber": hexutil.Uint64(testLatest), "transactionHash": testTxHash}
+}
+
+func (n *testNode) receipts(num uint64) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	if num == testLatest {
+		res = append(res, map[string]interface{}{
+			"transactionHash": testTxHash, "blockHash": testBlockHash(num), "blockNumber": hexutil.Uint64(num),
+			"gasUsed": hexutil.Uint64(21000), "cumulativeGasUsed": hexutil.Uint64(21000),
+			"logs": []interface{}{n.log()}, "logsBloom": hexutil.Bytes(make([]byte, 256)), "status": hexutil.Uint64(1),
+		})
+	}
+	return res
+}
+
+func number(bn rpc.BlockNumber) uint64 {
+	if bn < 0 {
+		return testLatest
+	}
+	return uint64(bn)
+}
+
+type testEth struct{ n *testNode }
+
+func (e testEth) ChainId() hexutil.Uint64     { return testChainID }
+func (e testEth) BlockNumber() hexutil.Uint64 { return testLatest }
+func (e testEth) GetBlockByNumber(bn rpc.BlockNumber, full bool) map[string]interface{} {
+	return e.n.block(number(bn))
+}
+func (e testEth) GetBlockByHash(hash common.Hash, full bool) map[string]interface{} {
+	for num := uint64(0); num <= testLatest; num++ {
+		if testBlockHash(num) == hash {
+			return e.n.block(num)
+		}
+	}
+	return nil
+}
+func (e testEth) GetBlockTransactionCountByHash(hash common.Hash) *hexutil.Uint64 {
+	block := e.GetBlockByHash(hash, false)
+	if block == nil {
+		return nil
+	}
+	count := hexutil.Uint64(len(block["transactions"].([]common.Hash)))
+	return &count
+}
+func (e testEth) GetTransactionByHash(hash common.Hash) map[string]interface{} {
+	if hash != testTxHash {
+		return nil
+	}
+	return map[string]interface{}{"hash": hash, "blockNumber": hexutil.Uint64(testLatest), "transactionIndex": hexutil.Uint64(0)}
+}
+func (e testEth) GetTransactionReceipt(hash common.Hash) map[string]interface{} {
+	if hash != testTxHash {
+		return nil
+	}
+	return e.n.receipts(testLatest)[0]
+}
+func (e testEth) GetBlockReceipts(bn rpc.BlockNumber) []map[string]interface{} {
+	return e.n.receipts(number(bn))
+}
+
+type testFilter struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+}
+
+func (e testEth) GetLogs(filter testFilter) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	if e.n.brokenLogs {
+		return res
+	}
+	from, to := rpc.BlockNumber(0), rpc.LatestBlockNumber
+	if filter.FromBlock != nil {
+		from = *filter.FromBlock
+	}
+	if filter.ToBlock != nil {
+		to = *filter.ToBlock
+	}
+	if number(from) <= testLatest && number(to) >= testLatest {
+		res = append(res, e.n.log())
+	}
+	return res
+}
+func (e testEth) GetBalance(addr common.Address, block rpc.BlockNumberOrHash) *hexutil.Big {
+	return (*hexutil.Big)(common.Big1)
+}
+func (e testEth) GetCode(addr common.Address, block rpc.BlockNumberOrHash) hexutil.Bytes {
+	return hexutil.Bytes{}
+}
+func (e testEth) GetStorageAt(addr common.Address, key string, block rpc.BlockNumberOrHash) common.Hash {
+	return common.Hash{}
+}
+func (e testEth) Call(args map[string]interface{}, block rpc.BlockNumberOrHash) hexutil.Bytes {
+	return hexutil.Bytes{}
+}
+func (e testEth) EstimateGas(args map[string]interface{}, block *rpc.BlockNumberOrHash) hexutil.Uint64 {
+	return hexutil.Uint64(params.TxGas)
+}
+func (e testEth) GasPrice() *hexutil.Big {
+	if e.n.crashGasPrice {
+		panic("gas price")
+	}
+	return (*hexutil.Big)(common.Big1)
+}
+func (e testEth) FeeHistory(count hexutil.Uint64, last rpc.BlockNumber, percentiles []float64) map[string]interface{} {
+	newest := min(number(last), testLatest)
+	blocks := min(uint64(count), newest+1)
+	res := map[string]interface{}{
+		"oldestBlock":   hexutil.Uint64(newest + 1 - blocks),
+		"baseFeePerGas": make([]*hexutil.Big, 0, blocks+1),
+		"gasUsedRatio":  make([]float64, blocks),
+		"reward":        make([][]*hexutil.Big, blocks),
+	}
+	for i := uint64(0); i <= blocks; i++ {
+		res["baseFeePerGas"] = append(res["baseFeePerGas"].([]*hexutil.Big), (*hexutil.Big)(common.Big1))
+	}
+	for i := range res["reward"].([][]*hexutil.Big) {
+		res["reward"].([][]*hexutil.Big)[i] = []*hexutil.Big{(*hexutil.Big)(common.Big0)}
+	}
+	return res
+}
+func (e testEth) Syncing() bool { return false }
+
+type testNet struct{ n *testNode }
+
+func (t testNet) Version() string {
+	if t.n.brokenNetVersion {
+		return "1"
+	}
+	return strconv.Itoa(testChainID)
+}
+
+type testWeb3 struct{}
+
+func (testWeb3) ClientVersion() string { return "test/v1.0.0" }
+
+type testErigon struct{ n *testNode }
+
+func (e testErigon) BlockNumber() hexutil.Uint64 { return testLatest }
+func (e testErigon) GetHeaderByNumber(bn rpc.BlockNumber) map[string]interface{} {
+	return e.n.block(number(bn))
+}
+func (e testErigon) GetLogsByHash(hash common.Hash) [][]map[string]interface{} {
+	res := [][]map[string]interface{}{}
+	if hash == testBlockHash(testLatest) {
+		res = append(res, []map[string]interface{}{e.n.log()})
+	}
+	return res
+}
+func (e testErigon) Forks() map[string]interface{} {
+	return map[string]interface{}{"genesis": testBlockHash(0)}
+}
+
+func (n *testNode) dial(t *testing.T) *rpc.Client {
+	logger := log.New()
+	server := rpc.NewServer(50, false, false, true, logger, 0)
+	require.NoError(t, server.RegisterName("eth", testEth{n}))
+	require.NoError(t, server.RegisterName("net", testNet{n}))
+	require.NoError(t, server.RegisterName("web3", testWeb3{}))
+	require.NoError(t, server.RegisterName("erigon", testErigon{n}))
+	client := rpc.DialInProc(server, logger)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func statuses(report *Report) map[string]string {
+	res := map[string]string{}
+	for _, r := range report.Results {
+		res[r.Name] = r.Status
+	}
+	return res
+}
+
+func TestBuiltinChecks(t *testing.T) {
+	ctx := context.Background()
+	t.Run("consistent node", func(t *testing.T) {
+		report, err := run(ctx, (&testNode{}).dial(t), Config{Erigon: true})
+		require.NoError(t, err)
+		for _, r := range report.Results {
+			require.Equal(t, StatusPass, r.Status, "%s: %s", r.Name, r.Error)
+		}
+		require.Len(t, report.Results, len(builtinChecks(true)))
+		require.True(t, report.OK())
+		require.Equal(t, "test/v1.0.0", report.ClientVersion)
+		require.Zero(t, report.FuzzSeed)
+	})
+	t.Run("inconsistent node", func(t *testing.T) {
+		report, err := run(ctx, (&testNode{brokenNetVersion: true, brokenLogs: true}).dial(t), Config{})
+		require.NoError(t, err)
+		require.False(t, report.OK())
+		require.Equal(t, 2, report.Failed)
+		st := statuses(report)
+		require.Equal(t, StatusFail, st["net_version"])
+		require.Equal(t, StatusFail, st["eth_getLogs"])
+		require.NotContains(t, st, "erigon_forks")
+	})
+}
+
+func TestFuzzChecks(t *testing.T) {
+	ctx := context.Background()
+	t.Run("node answers", func(t *testing.T) {
+		report, err := run(ctx, (&testNode{}).dial(t), Config{Erigon: true, Fuzz: 50})
+		require.NoError(t, err)
+		require.NotZero(t, report.FuzzSeed)
+		st := statuses(report)
+		for _, m := range append(fuzzMethods, erigonFuzzMethods...) {
+			require.Equal(t, StatusPass, st["fuzz "+m.name], m.name)
+		}
+		require.True(t, report.OK())
+	})
+	t.Run("handler crashes", func(t *testing.T) {
+		client := (&testNode{crashGasPrice: true}).dial(t)
+		var errs []string
+		for i := 0; i < 2; i++ {
+			report, err := run(ctx, client, Config{Fuzz: 20, Seed: 42})
+			require.NoError(t, err)
+			require.Equal(t, int64(42), report.FuzzSeed)
+			for _, r := range report.Results {
+				if r.Group == "fuzz" {
+					errs = append(errs, r.Error)
+				}
+				if r.Name == "fuzz eth_gasPrice" || r.Name == "eth_gasPrice" {
+					require.Equal(t, StatusFail, r.Status)
+					require.Contains(t, r.Error, "method handler crashed")
+				} else {
+					require.NotEqual(t, StatusFail, r.Status, "%s: %s", r.Name, r.Error)
+				}
+			}
+		}
+		require.Equal(t, errs[:len(errs)/2], errs[len(errs)/2:]) // same seed, same requests
+	})
+}
+
+func TestRandomValue(t *testing.T) {
+	a, b := rand.New(rand.NewSource(1)), rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		va, vb := randomValue(a, 0), randomValue(b, 0)
+		require.Equal(t, va, vb)
+		_, err := json.Marshal(va)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpcselftest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+// exchange is a request and the expected response of an execution-apis test case
+type exchange struct {
+	request struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+}
+
+// loadExecutionAPIs loads test cases of https://github.com/ethereum/execution-apis/tree/main/tests from dir:
+// .io files of lines ">> request" followed by "<< response". The cases expect the chain of the repository,
+// so they pass only against a node running it.
+func loadExecutionAPIs(dir string) ([]check, error) {
+	var checks []check
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".io" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		exchanges, err := parseIO(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		name, _ := filepath.Rel(dir, path)
+		checks = append(checks, check{
+			name:  strings.TrimSuffix(filepath.ToSlash(name), ".io"),
+			group: "execution-apis",
+			run: func(ctx context.Context, s *session) error {
+				for i := range exchanges {
+					if err := exchanges[i].run(ctx, s.client); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(checks) == 0 {
+		return nil, fmt.Errorf("no .io test cases in %s", dir)
+	}
+	return checks, nil
+}
+
+func parseIO(data []byte) ([]exchange, error) {
+	var res []exchange
+	var pending *exchange
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "//"):
+		case strings.HasPrefix(text, ">>"):
+			if pending != nil {
+				return nil, fmt.Errorf("line %d: request without a response", line)
+			}
+			pending = &exchange{}
+			if err := json.Unmarshal([]byte(text[2:]), &pending.request); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case strings.HasPrefix(text, "<<"):
+			if pending == nil {
+				return nil, fmt.Errorf("line %d: response without a request", line)
+			}
+			if err := json.Unmarshal([]byte(text[2:]), &pending.response); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			res = append(res, *pending)
+			pending = nil
+		default:
+			return nil, fmt.Errorf("line %d: neither request nor response", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, errors.New("request without a response at the end")
+	}
+	return res, nil
+}
+
+func (e *exchange) run(ctx context.Context, client *rpc.Client) error {
+	args := make([]interface{}, len(e.request.Params))
+	for i, p := range e.request.Params {
+		args[i] = p
+	}
+	var result json.RawMessage
+	err := client.CallContext(ctx, &result, e.request.Method, args...)
+	if e.response.Error != nil {
+		if err == nil {
+			return fmt.Errorf("%s: returned %s, expected error %d", e.request.Method, result, e.response.Error.Code)
+		}
+		var rpcErr rpc.Error
+		if !errors.As(err, &rpcErr) {
+			return fmt.Errorf("%s: %w", e.request.Method, err)
+		}
+		if rpcErr.ErrorCode() != e.response.Error.Code {
+			return fmt.Errorf("%s: error code %d (%s), expected %d", e.request.Method, rpcErr.ErrorCode(), rpcErr.Error(), e.response.Error.Code)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.request.Method, err)
+	}
+	same, err := sameJSON(result, e.response.Result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.request.Method, err)
+	}
+	if !same {
+		return fmt.Errorf("%s: result %s, expected %s", e.request.Method, result, e.response.Result)
+	}
+	return nil
+}
+
+// sameJSON compares JSON values ignoring formatting and order of object keys
+func sameJSON(a, b json.RawMessage) (bool, error) {
+	if len(a) == 0 {
+		a = json.RawMessage("null")
+	}
+	if len(b) == 0 {
+		b = json.RawMessage("null")
+	}
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(va, vb), nil
+}
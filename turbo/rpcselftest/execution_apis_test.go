@@ -0,0 +1,94 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package rpcselftest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/rpc"
+)
+
+type testService struct{}
+
+func (testService) BlockNumber() hexutil.Uint64 { return 45 }
+
+func (testService) GetBlockByNumber(n hexutil.Uint64, full bool) map[string]interface{} {
+	return map[string]interface{}{"number": n, "transactions": []string{}}
+}
+
+func TestExecutionAPIs(t *testing.T) {
+	logger := log.New()
+	server := rpc.NewServer(50, false, false, true, logger, 0)
+	require.NoError(t, server.RegisterName("eth", testService{}))
+	client := rpc.DialInProc(server, logger)
+	defer client.Close()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("eth_blockNumber/simple-test.io", `// returns the latest block
+>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}
+<< {"jsonrpc":"2.0","id":1,"result":"0x2d"}
+`)
+	write("eth_getBlockByNumber/get-block.io", `>> {"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x1",false]}
+<< {"jsonrpc":"2.0","id":1,"result":{"transactions":[],"number":"0x1"}}
+>> {"jsonrpc":"2.0","id":2,"method":"eth_getBlockByNumber","params":["0x1"]}
+<< {"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"missing value for required argument 1"}}
+`)
+	write("eth_getBlockByNumber/wrong.io", `>> {"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x2",false]}
+<< {"jsonrpc":"2.0","id":1,"result":{"number":"0x1","transactions":[]}}
+`)
+	write("README.md", "not a test case")
+
+	checks, err := loadExecutionAPIs(dir)
+	require.NoError(t, err)
+	require.Len(t, checks, 3)
+
+	s := &session{client: client}
+	results := map[string]Result{}
+	for _, c := range checks {
+		require.Equal(t, "execution-apis", c.group)
+		results[c.name] = runCheck(context.Background(), s, c, 0)
+	}
+	require.Equal(t, StatusPass, results["eth_blockNumber/simple-test"].Status)
+	require.Equal(t, StatusPass, results["eth_getBlockByNumber/get-block"].Status, results["eth_getBlockByNumber/get-block"].Error)
+	require.Equal(t, StatusFail, results["eth_getBlockByNumber/wrong"].Status)
+	require.Contains(t, results["eth_getBlockByNumber/wrong"].Error, "expected")
+}
+
+func TestParseIO(t *testing.T) {
+	_, err := parseIO([]byte(">> {\"method\":\"eth_blockNumber\"}\n"))
+	require.Error(t, err)
+	_, err = parseIO([]byte("<< {\"result\":\"0x1\"}\n"))
+	require.Error(t, err)
+	_, err = parseIO([]byte("garbage\n"))
+	require.Error(t, err)
+
+	exchanges, err := parseIO([]byte("// comment\n\n>> {\"method\":\"eth_chainId\",\"params\":[]}\n<< {\"result\":\"0x1\"}\n"))
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+	require.Equal(t, "eth_chainId", exchanges[0].request.Method)
+	require.Nil(t, exchanges[0].response.Error)
+}
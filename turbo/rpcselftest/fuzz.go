@@ -0,0 +1,176 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcselftest
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/erigontech/erigon/rpc"
+)
+
+const (
+	maxFuzzDepth   = 3   // of nested arrays and objects in random params
+	maxFuzzRequest = 256 // failed requests are reported truncated to this length
+)
+
+// fuzzMethods are called with random params, mostly malformed, by the fuzz checks
+var fuzzMethods = []struct {
+	name   string
+	params int // amount of params the method takes, calls have 0 to params+1 of them
+}{
+	{"eth_getBlockByNumber", 2},
+	{"eth_getBlockByHash", 2},
+	{"eth_getTransactionByHash", 1},
+	{"eth_getTransactionReceipt", 1},
+	{"eth_getBlockReceipts", 1},
+	{"eth_getLogs", 1},
+	{"eth_getBalance", 2},
+	{"eth_getCode", 2},
+	{"eth_getStorageAt", 3},
+	{"eth_call", 2},
+	{"eth_estimateGas", 2},
+	{"eth_gasPrice", 0},
+	{"eth_feeHistory", 3},
+}
+
+var erigonFuzzMethods = []struct {
+	name   string
+	params int
+}{
+	{"erigon_getHeaderByNumber", 1},
+	{"erigon_getLogsByHash", 1},
+}
+
+// fuzzChecks call every method with `requests` random params each. A check fails if the node answers with anything
+// but a result or a JSON-RPC error, if a method handler crashes, or if the node stops answering. Params of a method
+// depend only on the seed and the method name, so a failure is reproduced with the same seed.
+func fuzzChecks(erigon bool, requests int, seed int64) []check {
+	methods := fuzzMethods
+	if erigon {
+		methods = append(methods[:len(methods):len(methods)], erigonFuzzMethods...)
+	}
+	checks := make([]check, 0, len(methods))
+	for _, m := range methods {
+		checks = append(checks, check{
+			name:  "fuzz " + m.name,
+			group: "fuzz",
+			run: func(ctx context.Context, s *session) error {
+				return fuzzMethod(ctx, s, m.name, m.params, requests, seed)
+			},
+		})
+	}
+	return checks
+}
+
+func fuzzMethod(ctx context.Context, s *session, method string, params, requests int, seed int64) error {
+	h := fnv.New64a()
+	h.Write([]byte(method))
+	rnd := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+	for i := 0; i < requests; i++ {
+		args := make([]interface{}, rnd.Intn(params+2))
+		for j := range args {
+			args[j] = randomValue(rnd, 0)
+		}
+		var v json.RawMessage
+		if err := fuzzFailure(s.client.CallContext(ctx, &v, method, args...)); err != nil {
+			request, _ := json.Marshal(args)
+			if len(request) > maxFuzzRequest {
+				request = append(request[:maxFuzzRequest], "..."...)
+			}
+			return fmt.Errorf("%s%s: %w", method, request, err)
+		}
+	}
+	var version string
+	if err := s.call(ctx, &version, "web3_clientVersion"); err != nil {
+		return fmt.Errorf("node stopped answering: %w", err)
+	}
+	return nil
+}
+
+// fuzzFailure returns the error unless it's a regular JSON-RPC error
+func fuzzFailure(err error) error {
+	if err == nil {
+		return nil
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	if strings.Contains(rpcErr.Error(), "method handler crashed") {
+		return err
+	}
+	return nil
+}
+
+var (
+	fuzzTags   = []string{"latest", "earliest", "pending", "safe", "finalized", "0x0", "-0x1", "0x", ""}
+	fuzzFields = []string{"from", "to", "data", "input", "value", "gas", "gasPrice", "nonce", "fromBlock", "toBlock", "blockHash", "address", "topics", "blockNumber", "requireCanonical"}
+)
+
+// randomValue returns a JSON value: valid looking hex strings and block tags, as well as values of wrong types
+func randomValue(rnd *rand.Rand, depth int) interface{} {
+	kinds := 10
+	if depth >= maxFuzzDepth {
+		kinds = 8 // no nesting
+	}
+	switch rnd.Intn(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return rnd.Intn(2) == 0
+	case 2:
+		return []interface{}{-1, 0, rnd.Int63(), uint64(math.MaxUint64), rnd.NormFloat64() * 1e30}[rnd.Intn(5)]
+	case 3: // quantity, possibly with leading zeros or too long
+		return "0x" + randomHex(rnd, rnd.Intn(70))
+	case 4: // address or hash
+		return "0x" + randomHex(rnd, []int{40, 64}[rnd.Intn(2)])
+	case 5: // data, possibly of odd length
+		return "0x" + randomHex(rnd, rnd.Intn(600))
+	case 6:
+		return fuzzTags[rnd.Intn(len(fuzzTags))]
+	case 7:
+		b := make([]byte, rnd.Intn(64))
+		rnd.Read(b)
+		return string(b)
+	case 8:
+		a := make([]interface{}, rnd.Intn(4))
+		for i := range a {
+			a[i] = randomValue(rnd, depth+1)
+		}
+		return a
+	default:
+		o := map[string]interface{}{}
+		for i := rnd.Intn(5); i > 0; i-- {
+			o[fuzzFields[rnd.Intn(len(fuzzFields))]] = randomValue(rnd, depth+1)
+		}
+		return o
+	}
+}
+
+func randomHex(rnd *rand.Rand, digits int) string {
+	b := make([]byte, (digits+1)/2)
+	rnd.Read(b)
+	return hex.EncodeToString(b)[:digits]
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+// Package rpcselftest is a JSON-RPC conformance suite run against a running node: built-in chain independent
+// checks of the eth, net, web3 and erigon methods, plus execution-apis test cases (.io files) if given, and fuzzing of
+// the methods with random params if enabled.
+package rpcselftest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/rpc"
+)
+
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip" // the node has no data to check the method with, e.g. no transactions in recent blocks
+)
+
+// errSkip is returned by checks to skip them, wrapped with the reason
+var errSkip = errors.New("skip")
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string `json:"name"`
+	Group  string `json:"group"` // "eth", "erigon", "errors", "execution-apis", "fuzz"
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	TookMs int64  `json:"tookMs"`
+}
+
+// Report is the machine-readable outcome of the suite
+type Report struct {
+	URL           string    `json:"url"`
+	ClientVersion string    `json:"clientVersion,omitempty"`
+	StartedAt     time.Time `json:"startedAt"`
+	FuzzSeed      int64     `json:"fuzzSeed,omitempty"` // pass as the seed to reproduce failures of fuzz checks
+	Passed        int       `json:"passed"`
+	Failed        int       `json:"failed"`
+	Skipped       int       `json:"skipped"`
+	Results       []Result  `json:"results"`
+}
+
+// OK is true if no check failed
+func (r *Report) OK() bool { return r.Failed == 0 }
+
+// check is one test case of the suite, checks of the built-in suite share the session in order
+type check struct {
+	name  string
+	group string
+	run   func(ctx context.Context, s *session) error
+}
+
+// session is what the built-in checks learned about the node so far
+type session struct {
+	client *rpc.Client
+
+	chainID     uint64
+	blockNumber uint64
+	block       map[string]json.RawMessage // latest block, without transactions
+	txBlock     uint64                     // recent block with transactions
+	txHash      string                     // first transaction of txBlock
+}
+
+func (s *session) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := s.client.CallContext(ctx, result, method, args...); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+// Config of the suite
+type Config struct {
+	URL              string
+	ExecutionAPIsDir string        // directory of execution-apis test cases, skipped if empty
+	Erigon           bool          // run checks of the erigon_ namespace
+	Timeout          time.Duration // of one check
+	Fuzz             int           // random requests per method of the fuzz checks, no fuzz checks if zero
+	Seed             int64         // of the fuzz checks, random if zero
+}
+
+// Run runs the suite against the node at cfg.URL. Errors are returned only if the suite can't be run,
+// failed checks are reported in the Report.
+func Run(ctx context.Context, cfg Config, logger log.Logger) (*Report, error) {
+	client, err := rpc.DialContext(ctx, cfg.URL, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return run(ctx, client, cfg)
+}
+
+func run(ctx context.Context, client *rpc.Client, cfg Config) (*Report, error) {
+	report := &Report{URL: cfg.URL, StartedAt: time.Now().UTC()}
+	checks := builtinChecks(cfg.Erigon)
+	if cfg.ExecutionAPIsDir != "" {
+		cases, err := loadExecutionAPIs(cfg.ExecutionAPIsDir)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, cases...)
+	}
+	if cfg.Fuzz > 0 {
+		report.FuzzSeed = cfg.Seed
+		if report.FuzzSeed == 0 {
+			report.FuzzSeed = time.Now().UnixNano()
+		}
+		checks = append(checks, fuzzChecks(cfg.Erigon, cfg.Fuzz, report.FuzzSeed)...)
+	}
+
+	report.Results = make([]Result, 0, len(checks))
+	s := &session{client: client}
+	for _, c := range checks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.add(runCheck(ctx, s, c, cfg.Timeout))
+	}
+	_ = client.CallContext(ctx, &report.ClientVersion, "web3_clientVersion")
+	return report, nil
+}
+
+func runCheck(ctx context.Context, s *session, c check, timeout time.Duration) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := c.run(ctx, s)
+	res := Result{Name: c.name, Group: c.group, Status: StatusPass, TookMs: time.Since(start).Milliseconds()}
+	switch {
+	case errors.Is(err, errSkip):
+		res.Status, res.Error = StatusSkip, err.Error()
+	case err != nil:
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
+
+func (r *Report) add(res Result) {
+	switch res.Status {
+	case StatusPass:
+		r.Passed++
+	case StatusFail:
+		r.Failed++
+	case StatusSkip:
+		r.Skipped++
+	}
+	r.Results = append(r.Results, res)
+}
@@ -17,8 +17,10 @@
 package shards
 
 import (
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/gointerfaces"
@@ -36,6 +38,44 @@ type PendingBlockSubscription func(*types.Block) error
 type PendingTxsSubscription func([]types.Transaction) error
 type LogsSubscription func([]*remote.SubscribeLogsReply) error
 
+// SoftConfirmation is a transaction included by the local block builder before the block is sealed. The receipt is
+// provisional: the payload may be rebuilt with another order or never sealed.
+type SoftConfirmation struct {
+	PayloadId uint64
+	Header    *types.Header // copy of the header of the block being built, as of the inclusion
+	Txn       types.Transaction
+	Receipt   *types.Receipt
+}
+
+// SoftConfirmationsBatch is what a soft confirmations subscriber receives. The block builder never waits for a
+// subscriber: one that falls behind loses its queued batches, and the next batch counts them in Dropped.
+type SoftConfirmationsBatch struct {
+	Dropped       int // soft confirmations lost right before this batch
+	Confirmations []SoftConfirmation
+}
+
+const (
+	SyncEventStageStart          = "stageStart"
+	SyncEventStageFinish         = "stageFinish"
+	SyncEventStageUnwind         = "stageUnwind"
+	SyncEventSnapshotsDownloaded = "snapshotsDownloaded" // all snapshot files are downloaded and indexed
+	SyncEventRpcServiceable      = "rpcServiceable"      // the first sync cycle is done, Block can be served over RPC
+)
+
+// SyncEvent is a stage transition or a sync milestone. Milestones happen once per process, late subscribers
+// receive the ones already happened first.
+type SyncEvent struct {
+	Type     string        `json:"type"`
+	Stage    string        `json:"stage,omitempty"`
+	Block    uint64        `json:"block"`              // progress of the stage (as of the start, after the finish or unwind), or of the node for milestones
+	Duration time.Duration `json:"duration,omitempty"` // of the finished stage
+	Time     time.Time     `json:"time"`
+}
+
+func (e SyncEvent) milestone() bool {
+	return e.Type == SyncEventSnapshotsDownloaded || e.Type == SyncEventRpcServiceable
+}
+
 // Events manages event subscriptions and dissimination. Thread-safe
 type Events struct {
 	id                        int
@@ -45,6 +85,9 @@ type Events struct {
 	pendingBlockSubscriptions map[int]PendingBlockSubscription
 	pendingTxsSubscriptions   map[int]PendingTxsSubscription
 	logsSubscriptions         map[int]chan []*remote.SubscribeLogsReply
+	softConfirmationSubs      map[int]chan SoftConfirmationsBatch
+	syncEventSubs             map[int]chan SyncEvent
+	syncMilestones            []SyncEvent
 	hasLogSubscriptions       bool
 	lock                      sync.RWMutex
 }
@@ -57,6 +100,8 @@ func NewEvents() *Events {
 		pendingTxsSubscriptions:   map[int]PendingTxsSubscription{},
 		logsSubscriptions:         map[int]chan []*remote.SubscribeLogsReply{},
 		newSnapshotSubscription:   map[int]chan struct{}{},
+		softConfirmationSubs:      map[int]chan SoftConfirmationsBatch{},
+		syncEventSubs:             map[int]chan SyncEvent{},
 	}
 }
 
@@ -99,6 +144,47 @@ func (e *Events) AddLogsSubscription() (chan []*remote.SubscribeLogsReply, func(
 	}
 }
 
+func (e *Events) AddSoftConfirmationsSubscription() (chan SoftConfirmationsBatch, func()) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	ch := make(chan SoftConfirmationsBatch, 8)
+	e.id++
+	id := e.id
+	e.softConfirmationSubs[id] = ch
+	return ch, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.softConfirmationSubs, id)
+		close(ch)
+	}
+}
+
+// AddSyncEventsSubscription returns the channel of sync events, starting with the milestones already happened
+func (e *Events) AddSyncEventsSubscription() (chan SyncEvent, func()) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	ch := make(chan SyncEvent, 64)
+	for _, m := range e.syncMilestones {
+		ch <- m
+	}
+	e.id++
+	id := e.id
+	e.syncEventSubs[id] = ch
+	return ch, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.syncEventSubs, id)
+		close(ch)
+	}
+}
+
+// HasSoftConfirmationSubscriptions lets the block builder skip preparing soft confirmations nobody listens to
+func (e *Events) HasSoftConfirmationSubscriptions() bool {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return len(e.softConfirmationSubs) > 0
+}
+
 func (e *Events) EmptyLogSubsctiption(empty bool) {
 	e.lock.Lock()
 	defer e.lock.Unlock()
@@ -157,11 +243,44 @@ func (e *Events) OnLogs(logs []*remote.SubscribeLogsReply) {
 	}
 }
 
+// OnSoftConfirmations doesn't block: the lock only keeps the subscriptions from being closed while sending
+func (e *Events) OnSoftConfirmations(confirmations []SoftConfirmation) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	for _, ch := range e.softConfirmationSubs {
+		sendSoftConfirmations(ch, confirmations)
+	}
+}
+
+// sendSoftConfirmations drops all the queued batches if the channel is full, unlike PrioritizedSend dropping half
+// of them silently, so the subscriber learns about the gap from the Dropped count of the batch following it
+func sendSoftConfirmations(ch chan SoftConfirmationsBatch, confirmations []SoftConfirmation) {
+	batch := SoftConfirmationsBatch{Confirmations: confirmations}
+	for {
+		select {
+		case ch <- batch:
+			return
+		default:
+		}
+		for drained := false; !drained; {
+			select {
+			case queued := <-ch:
+				batch.Dropped += queued.Dropped + len(queued.Confirmations)
+			default:
+				drained = true
+			}
+		}
+	}
+}
+
 type Notifications struct {
 	Events               *Events
 	Accumulator          *Accumulator // StateAccumulator
 	StateChangesConsumer StateChangeConsumer
 	RecentLogs           *RecentLogs
+	RecentReceipts       *RecentReceipts
+	HeadEvents           *HeadEvents
+	NonCanonicalBlocks   *NonCanonicalBlocks
 	LastNewBlockSeen     atomic.Uint64 // This is used by eth_syncing as an heuristic to determine if the node is syncing or not.
 }
 
@@ -174,6 +293,9 @@ func NewNotifications(StateChangesConsumer StateChangeConsumer) *Notifications {
 		Events:               NewEvents(),
 		Accumulator:          NewAccumulator(),
 		RecentLogs:           NewRecentLogs(512),
+		RecentReceipts:       NewRecentReceipts(RecentReceiptsLimit),
+		HeadEvents:           NewHeadEvents(HeadEventsLimit),
+		NonCanonicalBlocks:   NewNonCanonicalBlocks(NonCanonicalBlocksLimit),
 		StateChangesConsumer: StateChangesConsumer,
 	}
 }
@@ -197,9 +319,17 @@ func (r *RecentLogs) Notify(n *Events, from, to uint64, isUnwind bool) {
 	if !n.HasLogSubsriptions() {
 		return
 	}
+	if reply := r.Logs(from, to, isUnwind); len(reply) > 0 {
+		n.OnLogs(reply)
+	}
+}
+
+// Logs returns the logs of the kept blocks of [from,to), ordered by block
+func (r *RecentLogs) Logs(from, to uint64, isUnwind bool) []*remote.SubscribeLogsReply {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	for bn, receipts := range r.receipts {
+	blockNums := make([]uint64, 0, len(r.receipts))
+	for bn := range r.receipts {
 		if bn+r.limit < from { //evict old
 			delete(r.receipts, bn)
 			continue
@@ -207,15 +337,18 @@ func (r *RecentLogs) Notify(n *Events, from, to uint64, isUnwind bool) {
 		if bn < from || bn >= to {
 			continue
 		}
+		blockNums = append(blockNums, bn)
+	}
+	slices.Sort(blockNums)
 
-		var blockNum uint64
-		reply := make([]*remote.SubscribeLogsReply, 0, len(receipts))
-		for _, receipt := range receipts {
+	var reply []*remote.SubscribeLogsReply
+	for _, bn := range blockNums {
+		for _, receipt := range r.receipts[bn] {
 			if receipt == nil {
 				continue
 			}
 
-			blockNum = receipt.BlockNumber.Uint64()
+			blockNum := receipt.BlockNumber.Uint64()
 			//txIndex++
 			//// bor transactions are at the end of the bodies transactions (added manually but not actually part of the block)
 			//if txIndex == uint64(len(block.Transactions())) {
@@ -242,9 +375,8 @@ func (r *RecentLogs) Notify(n *Events, from, to uint64, isUnwind bool) {
 				reply = append(reply, res)
 			}
 		}
-
-		n.OnLogs(reply)
 	}
+	return reply
 }
 
 func (r *RecentLogs) Add(receipts types.Receipts) {
@@ -278,3 +410,23 @@ func (r *RecentLogs) Add(receipts types.Receipts) {
 		}
 	}
 }
+
+// OnSyncEvent sends the event to subscribers, a milestone is sent only the first time
+func (e *Events) OnSyncEvent(ev SyncEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if ev.milestone() {
+		for _, m := range e.syncMilestones {
+			if m.Type == ev.Type {
+				return
+			}
+		}
+		e.syncMilestones = append(e.syncMilestones, ev)
+	}
+	for _, ch := range e.syncEventSubs {
+		common.PrioritizedSend(ch, ev)
+	}
+}
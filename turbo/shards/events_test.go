@@ -53,3 +53,55 @@ func TestRecentLogs(t *testing.T) {
 		require.Equal(t, 2, len(e.receipts))
 	})
 }
+
+func TestSoftConfirmationsSubscription(t *testing.T) {
+	t.Parallel()
+	e := NewEvents()
+	require.False(t, e.HasSoftConfirmationSubscriptions())
+
+	ch, unsubscribe := e.AddSoftConfirmationsSubscription()
+	require.True(t, e.HasSoftConfirmationSubscriptions())
+	e.OnSoftConfirmations([]SoftConfirmation{{PayloadId: 1}, {PayloadId: 1}})
+	batch := <-ch
+	require.Zero(t, batch.Dropped)
+	require.Len(t, batch.Confirmations, 2)
+
+	// slow subscriber: the builder doesn't wait, the queued batches are replaced by the count of their confirmations
+	for i := 0; i < cap(ch); i++ {
+		e.OnSoftConfirmations([]SoftConfirmation{{PayloadId: 2}, {PayloadId: 2}})
+	}
+	e.OnSoftConfirmations([]SoftConfirmation{{PayloadId: 3}})
+	require.Len(t, ch, 1)
+	batch = <-ch
+	require.Equal(t, 2*cap(ch), batch.Dropped)
+	require.Equal(t, []SoftConfirmation{{PayloadId: 3}}, batch.Confirmations)
+
+	unsubscribe()
+	require.False(t, e.HasSoftConfirmationSubscriptions())
+	e.OnSoftConfirmations([]SoftConfirmation{{PayloadId: 2}})
+	_, ok := <-ch
+	require.False(t, ok)
+}
+
+func TestSyncEventsSubscription(t *testing.T) {
+	t.Parallel()
+	e := NewEvents()
+	e.OnSyncEvent(SyncEvent{Type: SyncEventStageStart, Stage: "Headers"})
+	e.OnSyncEvent(SyncEvent{Type: SyncEventSnapshotsDownloaded, Block: 100})
+
+	ch, unsubscribe := e.AddSyncEventsSubscription()
+	ev := <-ch // milestone replayed, stage events are not
+	require.Equal(t, SyncEventSnapshotsDownloaded, ev.Type)
+	require.Equal(t, uint64(100), ev.Block)
+	require.False(t, ev.Time.IsZero())
+
+	e.OnSyncEvent(SyncEvent{Type: SyncEventSnapshotsDownloaded, Block: 200}) // not a milestone anymore
+	e.OnSyncEvent(SyncEvent{Type: SyncEventStageFinish, Stage: "Headers", Block: 5})
+	ev = <-ch
+	require.Equal(t, SyncEventStageFinish, ev.Type)
+	require.Equal(t, uint64(5), ev.Block)
+
+	unsubscribe()
+	_, ok := <-ch
+	require.False(t, ok)
+}
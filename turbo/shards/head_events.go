@@ -0,0 +1,124 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package shards
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	remote "github.com/erigontech/erigon-lib/gointerfaces/remoteproto"
+)
+
+// HeadEventsLimit - amount of the latest head events kept for the replay. Events hold state changes, so it's
+// small: a consumer lagging behind more than that has to start over anyway
+const HeadEventsLimit = 64
+
+var ErrHeadEventsGap = errors.New("head events are not kept anymore")
+
+// HeadEvent is everything a head change brings to the internal consumers. The parts are applied in the field order:
+// state changes first, so that the consumers receiving the headers and logs already see the new state.
+type HeadEvent struct {
+	Seq          uint64                       // assigned by the bus: 1, 2, 3...
+	StateChanges *remote.StateChangeBatch     // nil if there are no state changes
+	Headers      [][]byte                     // RLP of the new canonical headers, ascending
+	Logs         []*remote.SubscribeLogsReply // of the new canonical blocks, or the removed ones on unwind
+}
+
+// HeadEvents is the ordered bus of head events. Every subscriber receives the events in the publishing order without
+// gaps. Publishing never blocks: a subscriber not keeping up is dropped (its channel is closed) and can resubscribe
+// from the next sequence number it expects. Thread-safe
+type HeadEvents struct {
+	seq    uint64
+	recent []HeadEvent // latest `limit` events, ascending
+	limit  int
+	id     int
+	subs   map[int]chan HeadEvent
+	lock   sync.Mutex
+}
+
+func NewHeadEvents(limit int) *HeadEvents {
+	return &HeadEvents{limit: limit, subs: map[int]chan HeadEvent{}}
+}
+
+// Publish assigns the next sequence number to the event and sends it to the subscribers
+func (h *HeadEvents) Publish(ev HeadEvent) uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.seq++
+	ev.Seq = h.seq
+	h.recent = append(h.recent, ev)
+	if len(h.recent) > h.limit {
+		h.recent[0] = HeadEvent{} // for GC
+		h.recent = h.recent[1:]
+	}
+	for id, ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(h.subs, id)
+			close(ch)
+		}
+	}
+	return ev.Seq
+}
+
+// Subscribe returns the channel of the events starting from the sequence number `from`, the kept ones are replayed
+// first. `from` 0 means only the events published after the subscription. ErrHeadEventsGap if `from` is not kept anymore
+func (h *HeadEvents) Subscribe(from uint64, size int) (<-chan HeadEvent, func(), error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var replay []HeadEvent
+	if from > 0 && from <= h.seq {
+		if len(h.recent) == 0 || from < h.recent[0].Seq {
+			return nil, nil, fmt.Errorf("%w: seq=%d, oldest=%d", ErrHeadEventsGap, from, h.seq-uint64(len(h.recent))+1)
+		}
+		replay = h.recent[from-h.recent[0].Seq:]
+	}
+	ch := make(chan HeadEvent, size+len(replay))
+	for _, ev := range replay {
+		ch <- ev
+	}
+	h.id++
+	id := h.id
+	h.subs[id] = ch
+	return ch, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		if _, ok := h.subs[id]; ok { // not dropped
+			delete(h.subs, id)
+			close(ch)
+		}
+	}, nil
+}
+
+// Seq returns the sequence number of the latest event
+func (h *HeadEvents) Seq() uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.seq
+}
+
+// HasSubscribers lets the publisher skip preparing the parts nobody listens to
+func (h *HeadEvents) HasSubscribers() bool {
+	if h == nil {
+		return false
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.subs) > 0
+}
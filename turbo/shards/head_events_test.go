@@ -0,0 +1,98 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package shards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadEvents(t *testing.T) {
+	t.Parallel()
+	publish := func(h *HeadEvents, n int) {
+		for i := 0; i < n; i++ {
+			h.Publish(HeadEvent{Headers: [][]byte{{byte(i)}}})
+		}
+	}
+	receive := func(t *testing.T, ch <-chan HeadEvent, seqs ...uint64) {
+		for _, seq := range seqs {
+			ev, ok := <-ch
+			require.True(t, ok)
+			require.Equal(t, seq, ev.Seq)
+		}
+		require.Empty(t, ch)
+	}
+
+	t.Run("Live", func(t *testing.T) {
+		h := NewHeadEvents(4)
+		publish(h, 2)
+		ch, unsubscribe, err := h.Subscribe(0, 8)
+		require.NoError(t, err)
+		require.True(t, h.HasSubscribers())
+		publish(h, 2)
+		receive(t, ch, 3, 4)
+
+		unsubscribe()
+		_, ok := <-ch
+		require.False(t, ok)
+		require.False(t, h.HasSubscribers())
+		unsubscribe() // no double close
+	})
+	t.Run("Replay", func(t *testing.T) {
+		h := NewHeadEvents(4)
+		publish(h, 6)
+		ch, unsubscribe, err := h.Subscribe(4, 8)
+		require.NoError(t, err)
+		defer unsubscribe()
+		publish(h, 1)
+		receive(t, ch, 4, 5, 6, 7)
+	})
+	t.Run("Gap", func(t *testing.T) {
+		h := NewHeadEvents(4)
+		publish(h, 6)
+		_, _, err := h.Subscribe(2, 8)
+		require.ErrorIs(t, err, ErrHeadEventsGap)
+	})
+	t.Run("DropLagging", func(t *testing.T) {
+		h := NewHeadEvents(4)
+		slow, unsubscribeSlow, err := h.Subscribe(0, 1)
+		require.NoError(t, err)
+		fast, unsubscribeFast, err := h.Subscribe(0, 8)
+		require.NoError(t, err)
+		defer unsubscribeFast()
+		publish(h, 3)
+		receive(t, fast, 1, 2, 3)
+
+		ev, ok := <-slow
+		require.True(t, ok)
+		require.Equal(t, uint64(1), ev.Seq)
+		_, ok = <-slow
+		require.False(t, ok)
+		unsubscribeSlow()
+
+		// resubscribing from the next expected one loses nothing
+		slow, unsubscribeSlow, err = h.Subscribe(ev.Seq+1, 1)
+		require.NoError(t, err)
+		defer unsubscribeSlow()
+		receive(t, slow, 2, 3)
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var h *HeadEvents
+		require.False(t, h.HasSubscribers())
+	})
+}
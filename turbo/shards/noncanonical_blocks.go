@@ -0,0 +1,58 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package shards
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/core/types"
+)
+
+// NonCanonicalBlocksLimit - amount of recently displaced blocks kept in memory
+const NonCanonicalBlocksLimit = 128
+
+// NonCanonicalBlocks keeps blocks recently displaced from the canonical chain by reorgs, so that RPC can serve
+// them by hash after they are gone from the db. Thread-safe, methods of nil do nothing.
+type NonCanonicalBlocks struct {
+	blocks *lru.Cache[common.Hash, *types.Block]
+}
+
+func NewNonCanonicalBlocks(limit int) *NonCanonicalBlocks {
+	blocks, err := lru.New[common.Hash, *types.Block](limit)
+	if err != nil {
+		panic(err)
+	}
+	return &NonCanonicalBlocks{blocks: blocks}
+}
+
+func (b *NonCanonicalBlocks) Add(blocks ...*types.Block) {
+	if b == nil {
+		return
+	}
+	for _, block := range blocks {
+		b.blocks.Add(block.Hash(), block)
+	}
+}
+
+// Get returns nil if the block is unknown
+func (b *NonCanonicalBlocks) Get(hash common.Hash) *types.Block {
+	if b == nil {
+		return nil
+	}
+	block, _ := b.blocks.Get(hash)
+	return block
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package shards
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/ethconfig/estimate"
+)
+
+// RecentReceiptsLimit - amount of latest executed blocks whose receipts are kept in memory
+const RecentReceiptsLimit = 128
+
+// RecentReceipts keeps fully derived receipts (block location, log indices, contract addresses) of the latest
+// executed blocks, so that RPC serves them at head without re-executing the blocks. Keyed by block hash: receipts
+// of a block displaced by a reorg are never returned for its canonical replacement.
+// Thread-safe, methods of nil do nothing.
+type RecentReceipts struct {
+	receipts *lru.Cache[common.Hash, types.Receipts]
+}
+
+func NewRecentReceipts(limit int) *RecentReceipts {
+	receipts, err := lru.New[common.Hash, types.Receipts](limit)
+	if err != nil {
+		panic(err)
+	}
+	return &RecentReceipts{receipts: receipts}
+}
+
+// Add derives the fields of the receipts produced by the execution of the block, transactions are processed in
+// parallel. `receipts` are copied, execution keeps its own ones.
+func (r *RecentReceipts) Add(cfg *chain.Config, header *types.Header, txs types.Transactions, receipts types.Receipts) error {
+	if r == nil {
+		return nil
+	}
+	if len(receipts) != len(txs) {
+		return fmt.Errorf("RecentReceipts: transaction and receipt count mismatch, txn count = %d, receipts count = %d", len(txs), len(receipts))
+	}
+	blockHash, blockNum := header.Hash(), header.Number.Uint64()
+	signer := types.MakeSigner(cfg, blockNum, header.Time)
+
+	derived := make(types.Receipts, len(receipts))
+	var eg errgroup.Group
+	eg.SetLimit(estimate.AlmostAllCPUs())
+	for i, receipt := range receipts {
+		if receipt == nil {
+			return fmt.Errorf("RecentReceipts: bn=%d, txnIdx=%d, missing receipt", blockNum, i)
+		}
+		var prevCumulativeGasUsed uint64
+		if i > 0 {
+			prevCumulativeGasUsed = receipts[i-1].CumulativeGasUsed
+		}
+		eg.Go(func() error {
+			// sender is cached by the transaction, contract address derivation needs it
+			if _, err := txs[i].Sender(*signer); err != nil {
+				return fmt.Errorf("RecentReceipts: bn=%d, txnIdx=%d, %w", blockNum, i, err)
+			}
+			derived[i] = receipt.Copy()
+			derived[i].FirstLogIndexWithinBlock = receipt.FirstLogIndexWithinBlock
+			return derived[i].DeriveFieldsV3ForSingleReceipt(i, blockHash, blockNum, txs[i], prevCumulativeGasUsed)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	r.receipts.Add(blockHash, derived)
+	return nil
+}
+
+// Get returns the receipts of the block, false if the block is not among the recent ones
+func (r *RecentReceipts) Get(blockHash common.Hash) (types.Receipts, bool) {
+	if r == nil {
+		return nil, false
+	}
+	return r.receipts.Get(blockHash)
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package shards
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+)
+
+func TestRecentReceipts(t *testing.T) {
+	t.Parallel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	cfg := params.TestChainConfig
+	header := &types.Header{Number: big.NewInt(10), Time: 1}
+	signer := types.MakeSigner(cfg, header.Number.Uint64(), header.Time)
+
+	create, err := types.SignTx(types.NewContractCreation(0, uint256.NewInt(0), 100_000, uint256.NewInt(1), nil), *signer, key)
+	require.NoError(t, err)
+	transfer, err := types.SignTx(types.NewTransaction(1, common.Address{1}, uint256.NewInt(1), 21_000, uint256.NewInt(1), nil), *signer, key)
+	require.NoError(t, err)
+	txs := types.Transactions{create, transfer}
+
+	// as produced by execution
+	receipts := types.Receipts{
+		{BlockNumber: header.Number, CumulativeGasUsed: 60_000, Logs: types.Logs{{}, {}}},
+		{BlockNumber: header.Number, CumulativeGasUsed: 81_000, Logs: types.Logs{{}}, FirstLogIndexWithinBlock: 2},
+	}
+
+	t.Run("Derive", func(t *testing.T) {
+		r := NewRecentReceipts(2)
+		require.NoError(t, r.Add(cfg, header, txs, receipts))
+
+		derived, ok := r.Get(header.Hash())
+		require.True(t, ok)
+		require.Len(t, derived, 2)
+		require.Equal(t, crypto.CreateAddress(sender, 0), derived[0].ContractAddress)
+		require.Equal(t, common.Address{}, derived[1].ContractAddress)
+		require.Equal(t, uint64(60_000), derived[0].GasUsed)
+		require.Equal(t, uint64(21_000), derived[1].GasUsed)
+		require.Equal(t, uint(1), derived[1].TransactionIndex)
+		require.Equal(t, transfer.Hash(), derived[1].TxHash)
+		require.Equal(t, header.Hash(), derived[1].BlockHash)
+		require.Equal(t, uint(0), derived[0].Logs[0].Index)
+		require.Equal(t, uint(1), derived[0].Logs[1].Index)
+		require.Equal(t, uint(2), derived[1].Logs[0].Index)
+		require.Equal(t, uint(1), derived[1].Logs[0].TxIndex)
+		require.Equal(t, header.Hash(), derived[1].Logs[0].BlockHash)
+
+		// execution receipts are untouched
+		require.Equal(t, common.Hash{}, receipts[1].BlockHash)
+		require.Equal(t, uint(0), receipts[1].Logs[0].Index)
+	})
+	t.Run("Evict", func(t *testing.T) {
+		r := NewRecentReceipts(2)
+		hashes := make([]common.Hash, 3)
+		for i := range hashes {
+			h := &types.Header{Number: big.NewInt(int64(i)), Time: 1}
+			hashes[i] = h.Hash()
+			require.NoError(t, r.Add(cfg, h, types.Transactions{}, types.Receipts{}))
+		}
+		_, ok := r.Get(hashes[0])
+		require.False(t, ok)
+		_, ok = r.Get(hashes[2])
+		require.True(t, ok)
+	})
+	t.Run("Mismatch", func(t *testing.T) {
+		r := NewRecentReceipts(2)
+		require.Error(t, r.Add(cfg, header, txs[:1], receipts))
+		require.Error(t, r.Add(cfg, header, txs, types.Receipts{receipts[0], nil}))
+		_, ok := r.Get(header.Hash())
+		require.False(t, ok)
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var r *RecentReceipts
+		require.NoError(t, r.Add(cfg, header, txs, receipts))
+		_, ok := r.Get(header.Hash())
+		require.False(t, ok)
+	})
+}
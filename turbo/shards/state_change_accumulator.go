@@ -50,13 +50,15 @@ func (a *Accumulator) Reset(plainStateID uint64) {
 	a.plainStateID = plainStateID
 }
 
-func (a *Accumulator) SendAndReset(ctx context.Context, c StateChangeConsumer, pendingBaseFee uint64, pendingBlobFee uint64, blockGasLimit uint64, finalizedBlock uint64) {
+// SendAndReset returns the sent batch, nil if nothing was sent
+func (a *Accumulator) SendAndReset(ctx context.Context, c StateChangeConsumer, pendingBaseFee uint64, pendingBlobFee uint64, blockGasLimit uint64, finalizedBlock uint64) *remote.StateChangeBatch {
 	if a == nil || c == nil || len(a.changes) == 0 {
-		return
+		return nil
 	}
 	sc := &remote.StateChangeBatch{StateVersionId: a.plainStateID, ChangeBatch: a.changes, PendingBlockBaseFee: pendingBaseFee, BlockGasLimit: blockGasLimit, FinalizedBlock: finalizedBlock, PendingBlobFeePerGas: pendingBlobFee}
 	c.SendStateChanges(ctx, sc)
 	a.Reset(0) // reset here for GC, but there will be another Reset with correct viewID
+	return sc
 }
 
 func (a *Accumulator) SetStateID(stateID uint64) {
@@ -0,0 +1,358 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package snapshotsync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/downloader"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+)
+
+const (
+	AttestationVerified = "verified" // the file matches every manifest listing it
+	AttestationMismatch = "mismatch" // the file doesn't match a manifest listing it
+
+	// AttestationStatusFile is kept in the snapshots dir, it caches the hashes of verified files between restarts
+	AttestationStatusFile = "snapshot-attestation.json"
+	// QuarantineDir is the subdir of the snapshots dir which mismatching files are moved to
+	QuarantineDir = "quarantine"
+
+	manifestSignPrefix  = "\x19Erigon Snapshot Manifest:\n"
+	maxManifestSize     = 64 << 20
+	manifestLoadTimeout = time.Minute
+	attestationHashers  = 4
+)
+
+// Manifest lists the info hashes of snapshot files of a chain, in the format of preverified.toml
+type Manifest struct {
+	Chain string            `json:"chain"`
+	Files map[string]string `json:"files"` // info hashes by file name (relative to the snapshots dir)
+}
+
+// SignedManifest is a manifest as published. The signed bytes are the manifest json exactly as in the file,
+// so that it doesn't have to be re-encoded the same way to be verified.
+type SignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature hexutil.Bytes   `json:"signature"` // secp256k1 signature of ManifestHash(Manifest)
+}
+
+// ManifestHash is the hash the publisher signs
+func ManifestHash(manifest []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte(manifestSignPrefix), manifest)
+}
+
+// SignManifest is used by the publishers to produce the manifest file
+func SignManifest(m *Manifest, key *ecdsa.PrivateKey) (*SignedManifest, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	hash := ManifestHash(data)
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedManifest{Manifest: data, Signature: sig}, nil
+}
+
+// attestedManifest is a manifest with the verified signature of a trusted publisher
+type attestedManifest struct {
+	source    string
+	publisher common.Address
+	files     map[string]string
+}
+
+// openManifest checks the signature of the manifest against the trusted publishers
+func openManifest(source string, data []byte, publishers []common.Address, chain string) (*attestedManifest, error) {
+	var signed SignedManifest
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", source, err)
+	}
+	hash := ManifestHash(signed.Manifest)
+	pub, err := crypto.SigToPub(hash[:], signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: invalid signature: %w", source, err)
+	}
+	publisher := crypto.PubkeyToAddress(*pub)
+	trusted := false
+	for _, p := range publishers {
+		trusted = trusted || p == publisher
+	}
+	if !trusted {
+		return nil, fmt.Errorf("manifest %s: signed by %x, which is not a trusted publisher", source, publisher)
+	}
+	var m Manifest
+	if err := json.Unmarshal(signed.Manifest, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", source, err)
+	}
+	if m.Chain != chain {
+		return nil, fmt.Errorf("manifest %s is for chain %q, expected %q", source, m.Chain, chain)
+	}
+	return &attestedManifest{source: source, publisher: publisher, files: m.Files}, nil
+}
+
+// loadManifest reads the manifest from the url or the local path
+func loadManifest(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+	ctx, cancel := context.WithTimeout(ctx, manifestLoadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+}
+
+// ManifestStatus is a manifest the files were verified against
+type ManifestStatus struct {
+	Source    string         `json:"source"`
+	Publisher common.Address `json:"publisher"`
+	Files     int            `json:"files"`
+}
+
+// FileAttestation is the verification result of a file listed in the manifests
+type FileAttestation struct {
+	Name        string           `json:"name"`
+	Status      string           `json:"status"` // AttestationVerified or AttestationMismatch
+	Hash        string           `json:"hash"`   // of the local file
+	Attested    []common.Address `json:"attestedBy"`
+	Disputed    []common.Address `json:"disputedBy,omitempty"` // publishers listing another hash
+	Quarantined bool             `json:"quarantined,omitempty"`
+
+	// the hash is not recomputed while the file doesn't change
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// AttestationStatus is the result of the last verification, reported by erigon_snapshotAttestation
+type AttestationStatus struct {
+	VerifiedAt time.Time         `json:"verifiedAt"`
+	Manifests  []ManifestStatus  `json:"manifests"`
+	Files      []FileAttestation `json:"files"`
+	Verified   int               `json:"verified"`
+	Mismatched int               `json:"mismatched"`
+}
+
+// ReadAttestationStatus returns nil if the snapshots were never verified against manifests
+func ReadAttestationStatus(snapDir string) (*AttestationStatus, error) {
+	data, err := os.ReadFile(filepath.Join(snapDir, AttestationStatusFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var status AttestationStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", AttestationStatusFile, err)
+	}
+	return &status, nil
+}
+
+func writeAttestationStatus(snapDir string, status *AttestationStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(snapDir, AttestationStatusFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// VerifyAttestations checks the snapshot files against the manifests signed by the trusted publishers
+// (see BlocksFreezing.Manifests). It must run before the files are opened: in setUpBlockReader on restart, after
+// the download in WaitForDownloader, and at start of the standalone rpcdaemon. A file mismatches if any manifest
+// listing it has another hash. Mismatching files are moved to the quarantine dir, or if quarantine is off, an error
+// is returned and the snapshots must not be used. Files listed in no manifest are not checked.
+func VerifyAttestations(ctx context.Context, dirs datadir.Dirs, cfg ethconfig.BlocksFreezing, logger log.Logger) error {
+	if len(cfg.Manifests) == 0 {
+		return nil
+	}
+	publishers := make([]common.Address, 0, len(cfg.ManifestPublishers))
+	for _, p := range cfg.ManifestPublishers {
+		publishers = append(publishers, common.HexToAddress(p))
+	}
+	manifests := make([]*attestedManifest, 0, len(cfg.Manifests))
+	for _, source := range cfg.Manifests {
+		data, err := loadManifest(ctx, source)
+		if err != nil {
+			return fmt.Errorf("load snapshot manifest: %w", err)
+		}
+		m, err := openManifest(source, data, publishers, cfg.ChainName)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, m)
+	}
+	status, err := verifyAttestations(ctx, dirs.Snap, manifests, cfg.QuarantineMismatches, logger)
+	if err != nil {
+		return err
+	}
+	if status.Mismatched > 0 && !cfg.QuarantineMismatches {
+		var names []string
+		for _, f := range status.Files {
+			if f.Status == AttestationMismatch {
+				names = append(names, f.Name)
+			}
+		}
+		return fmt.Errorf("snapshot files don't match the signed manifests (remove them to re-download, or see --downloader.manifest.quarantine): %s",
+			strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func verifyAttestations(ctx context.Context, snapDir string, manifests []*attestedManifest, quarantine bool, logger log.Logger) (*AttestationStatus, error) {
+	prev, err := ReadAttestationStatus(snapDir)
+	if err != nil {
+		logger.Warn("[snapshots] ignoring previous attestation status", "err", err)
+	}
+	cached := map[string]FileAttestation{}
+	if prev != nil {
+		for _, f := range prev.Files {
+			cached[f.Name] = f
+		}
+	}
+
+	status := &AttestationStatus{VerifiedAt: time.Now().UTC(), Manifests: make([]ManifestStatus, 0, len(manifests)), Files: []FileAttestation{}}
+	names := map[string]struct{}{}
+	for _, m := range manifests {
+		status.Manifests = append(status.Manifests, ManifestStatus{Source: m.source, Publisher: m.publisher, Files: len(m.files)})
+		for name := range m.files {
+			names[name] = struct{}{}
+		}
+	}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(attestationHashers)
+	for name := range names {
+		g.Go(func() error {
+			path := filepath.Join(snapDir, filepath.FromSlash(name))
+			info, err := os.Stat(path)
+			if errors.Is(err, os.ErrNotExist) { // not downloaded
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			f := FileAttestation{Name: name, Size: info.Size(), ModTime: info.ModTime().UTC(), Attested: []common.Address{}}
+			if c, ok := cached[name]; ok && !c.Quarantined && c.Size == f.Size && c.ModTime.Equal(f.ModTime) {
+				f.Hash = c.Hash
+			} else {
+				select {
+				case <-gctx.Done():
+					return gctx.Err()
+				default:
+				}
+				hash, err := downloader.FileInfoHash(path, name)
+				if err != nil {
+					return fmt.Errorf("hash %s: %w", name, err)
+				}
+				f.Hash = hash.HexString()
+			}
+			for _, m := range manifests {
+				expected, ok := m.files[name]
+				if !ok {
+					continue
+				}
+				if strings.EqualFold(strings.TrimPrefix(expected, "0x"), f.Hash) {
+					f.Attested = append(f.Attested, m.publisher)
+				} else {
+					f.Disputed = append(f.Disputed, m.publisher)
+				}
+			}
+			f.Status = AttestationVerified
+			if len(f.Disputed) > 0 {
+				f.Status = AttestationMismatch
+				logger.Warn("[snapshots] file doesn't match the signed manifest", "file", name, "hash", f.Hash, "disputedBy", f.Disputed)
+				if quarantine {
+					if err := quarantineFile(snapDir, name); err != nil {
+						return err
+					}
+					f.Quarantined = true
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			status.Files = append(status.Files, f)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	sort.Slice(status.Files, func(i, j int) bool { return status.Files[i].Name < status.Files[j].Name })
+	for _, f := range status.Files {
+		if f.Status == AttestationVerified {
+			status.Verified++
+		} else {
+			status.Mismatched++
+		}
+	}
+	if err := writeAttestationStatus(snapDir, status); err != nil {
+		return nil, err
+	}
+	logger.Info("[snapshots] verified against the signed manifests", "manifests", len(manifests), "verified", status.Verified, "mismatched", status.Mismatched)
+	return status, nil
+}
+
+// quarantineFile moves the file and its .torrent out of the snapshots, keeping the relative path
+func quarantineFile(snapDir, name string) error {
+	for _, n := range []string{name, name + ".torrent"} {
+		from := filepath.Join(snapDir, filepath.FromSlash(n))
+		to := filepath.Join(snapDir, QuarantineDir, filepath.FromSlash(n))
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(from, to); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("quarantine %s: %w", n, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package snapshotsync
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common/datadir"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/downloader"
+	"github.com/erigontech/erigon-lib/log/v3"
+
+	"github.com/erigontech/erigon/eth/ethconfig"
+)
+
+func TestVerifyAttestations(t *testing.T) {
+	dirs := datadir.New(t.TempDir())
+	const good, bad = "v1-000000-000500-headers.seg", "domain/v1-accounts.0-32.kv"
+	for name, content := range map[string]string{good: "headers", bad: "poisoned"} {
+		path := filepath.Join(dirs.Snap, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	goodHash, err := downloader.FileInfoHash(filepath.Join(dirs.Snap, good), good)
+	require.NoError(t, err)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publisher := crypto.PubkeyToAddress(key.PublicKey)
+	writeManifest := func(files map[string]string) string {
+		signed, err := SignManifest(&Manifest{Chain: "mainnet", Files: files}, key)
+		require.NoError(t, err)
+		data, err := json.Marshal(signed)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		require.NoError(t, os.WriteFile(path, data, 0644))
+		return path
+	}
+	ctx := context.Background()
+	cfg := ethconfig.BlocksFreezing{
+		ChainName:          "mainnet",
+		Manifests:          []string{writeManifest(map[string]string{good: goodHash.HexString(), "v1-000500-001000-headers.seg": goodHash.HexString()})},
+		ManifestPublishers: []string{publisher.Hex()},
+	}
+	require.NoError(t, VerifyAttestations(ctx, dirs, cfg, log.New()))
+	status, err := ReadAttestationStatus(dirs.Snap)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.Verified) // not downloaded files are skipped
+	require.Equal(t, AttestationVerified, status.Files[0].Status)
+
+	// untrusted publisher
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	untrusted := cfg
+	untrusted.ManifestPublishers = []string{crypto.PubkeyToAddress(other.PublicKey).Hex()}
+	require.ErrorContains(t, VerifyAttestations(ctx, dirs, untrusted, log.New()), "not a trusted publisher")
+
+	// mismatch is refused
+	cfg.Manifests = append(cfg.Manifests, writeManifest(map[string]string{bad: goodHash.HexString()}))
+	require.ErrorContains(t, VerifyAttestations(ctx, dirs, cfg, log.New()), bad)
+	status, err = ReadAttestationStatus(dirs.Snap)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.Mismatched)
+	require.FileExists(t, filepath.Join(dirs.Snap, bad))
+
+	// or quarantined
+	cfg.QuarantineMismatches = true
+	require.NoError(t, VerifyAttestations(ctx, dirs, cfg, log.New()))
+	require.NoFileExists(t, filepath.Join(dirs.Snap, bad))
+	require.FileExists(t, filepath.Join(dirs.Snap, QuarantineDir, bad))
+	require.FileExists(t, filepath.Join(dirs.Snap, good))
+}
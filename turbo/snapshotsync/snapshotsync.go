@@ -290,6 +290,9 @@ func WaitForDownloader(ctx context.Context, logPrefix string, dirs datadir.Dirs,
 
 	// Find minimum block to download.
 	if blockReader.FreezingCfg().NoDownloader || snapshotDownloader == nil {
+		if err := VerifyAttestations(ctx, dirs, blockReader.FreezingCfg(), log.Root()); err != nil {
+			return err
+		}
 		if err := snapshots.OpenFolder(); err != nil {
 			return err
 		}
@@ -409,6 +412,11 @@ func WaitForDownloader(ctx context.Context, logPrefix string, dirs datadir.Dirs,
 		}
 	}
 
+	// before the downloaded files are opened, so that a poisoned webseed can't get them used
+	if err := VerifyAttestations(ctx, dirs, blockReader.FreezingCfg(), log.Root()); err != nil {
+		return err
+	}
+
 	if err := snapshots.OpenFolder(); err != nil {
 		return err
 	}
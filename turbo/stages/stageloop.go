@@ -404,6 +404,14 @@ func (h *Hook) LastNewBlockSeen(n uint64) {
 	}
 	h.notifications.NewLastBlockSeen(n)
 }
+
+// BlocksDisplaced keeps blocks removed from the canonical chain by a reorg for RPC
+func (h *Hook) BlocksDisplaced(blocks []*types.Block) {
+	if h == nil || h.notifications == nil {
+		return
+	}
+	h.notifications.NonCanonicalBlocks.Add(blocks...)
+}
 func (h *Hook) BeforeRun(tx kv.Tx, inSync bool) error {
 	if h == nil {
 		return nil
@@ -435,6 +443,9 @@ func (h *Hook) sendNotifications(tx kv.Tx, finishStageBeforeSync uint64) error {
 		return nil
 	}
 
+	// the parts of the head change, published together to the ordered bus of the internal consumers
+	var headEvent shards.HeadEvent
+
 	// update the accumulator with a new plain state version so the cache can be notified that
 	// state has moved on
 	if h.notifications.Accumulator != nil {
@@ -469,10 +480,16 @@ func (h *Hook) sendNotifications(tx kv.Tx, finishStageBeforeSync uint64) error {
 		notifyFrom++
 		notifyTo := finishStageAfterSync + 1 //[from, to)
 
-		if err = stagedsync.NotifyNewHeaders(h.ctx, notifyFrom, notifyTo, h.notifications.Events, tx, h.logger); err != nil {
+		if headEvent.Headers, err = stagedsync.NotifyNewHeaders(h.ctx, notifyFrom, notifyTo, h.notifications.Events, tx, h.logger); err != nil {
 			return nil
 		}
 		h.notifications.RecentLogs.Notify(h.notifications.Events, notifyFrom, notifyTo, isUnwind)
+		if h.notifications.HeadEvents.HasSubscribers() {
+			headEvent.Logs = h.notifications.RecentLogs.Logs(notifyFrom, notifyTo, isUnwind)
+		}
+		if finishStageAfterSync > 0 { // sent once, the first committed head
+			h.notifications.Events.OnSyncEvent(shards.SyncEvent{Type: shards.SyncEventRpcServiceable, Block: finishStageAfterSync})
+		}
 	}
 
 	currentHeader := rawdb.ReadCurrentHeader(tx)
@@ -499,7 +516,11 @@ func (h *Hook) sendNotifications(tx kv.Tx, finishStageBeforeSync uint64) error {
 		}
 
 		//h.logger.Debug("[hook] Sending state changes", "currentBlock", currentHeader.Number.Uint64(), "finalizedBlock", finalizedBlock)
-		h.notifications.Accumulator.SendAndReset(h.ctx, h.notifications.StateChangesConsumer, pendingBaseFee.Uint64(), pendingBlobFee, currentHeader.GasLimit, finalizedBlock)
+		headEvent.StateChanges = h.notifications.Accumulator.SendAndReset(h.ctx, h.notifications.StateChangesConsumer, pendingBaseFee.Uint64(), pendingBlobFee, currentHeader.GasLimit, finalizedBlock)
+	}
+
+	if h.notifications.HeadEvents != nil && (headEvent.StateChanges != nil || len(headEvent.Headers) > 0) {
+		h.notifications.HeadEvents.Publish(headEvent)
 	}
 	return nil
 }
@@ -54,14 +54,7 @@ func DoCall(
 	headerReader services.HeaderReader,
 	callTimeout time.Duration,
 ) (*evmtypes.ExecutionResult, error) {
-	// todo: Pending state is only known by the miner
-	/*
-		if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
-			block, state, _ := b.eth.miner.Pending()
-			return state, block.Header(), nil
-		}
-	*/
-
+	// for the pending block `stateReader` and `header` are materialized by the caller
 	state := state.New(stateReader)
 
 	// Override the fields of specified contracts before execution.
@@ -114,6 +107,7 @@ func DoCall(
 	if err != nil {
 		return nil, err
 	}
+	rpc.CostFromContext(ctx).AddGas(result.UsedGas)
 
 	// If the timer caused an abort, return an appropriate error message
 	if evm.Cancelled() {
@@ -192,6 +186,7 @@ func (r *ReusableCaller) DoCallWithNewGas(
 	if err != nil {
 		return nil, err
 	}
+	rpc.CostFromContext(ctx).AddGas(result.UsedGas)
 
 	// If the timer caused an abort, return an appropriate error message
 	if timedOut {
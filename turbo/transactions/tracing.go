@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -40,6 +41,8 @@ import (
 	"github.com/erigontech/erigon/eth/tracers"
 	tracersConfig "github.com/erigontech/erigon/eth/tracers/config"
 	"github.com/erigontech/erigon/eth/tracers/logger"
+	"github.com/erigontech/erigon/eth/tracers/native"
+	"github.com/erigontech/erigon/rpc"
 	"github.com/erigontech/erigon/turbo/rpchelper"
 	"github.com/erigontech/erigon/turbo/services"
 )
@@ -52,6 +55,26 @@ type BlockGetter interface {
 	GetBlock(hash libcommon.Hash, number uint64) *types.Block
 }
 
+// WriteSchemaVersion starts the {"schemaVersion": tracers.SchemaVersion, "result": ...} wrapper of the streamed trace
+// output if the config asks for it, the returned function ends it. The output in between is a single JSON value,
+// nil is written if the method failed before writing anything.
+func WriteSchemaVersion(config *tracersConfig.TraceConfig, stream *jsoniter.Stream) (end func()) {
+	if !config.WithSchemaVersion() {
+		return func() {}
+	}
+	stream.WriteObjectStart()
+	stream.WriteObjectField("schemaVersion")
+	stream.WriteInt(tracers.SchemaVersion)
+	stream.WriteMore()
+	stream.WriteObjectField("result")
+	return func() {
+		if b := stream.Buffer(); len(b) > 0 && b[len(b)-1] == ':' {
+			stream.WriteNil()
+		}
+		stream.WriteObjectEnd()
+	}
+}
+
 // ComputeBlockContext returns the execution environment of a certain block.
 func ComputeBlockContext(ctx context.Context, engine consensus.EngineReader, header *types.Header, cfg *chain.Config,
 	headerReader services.HeaderReader, txNumsReader rawdbv3.TxNumsReader, dbtx kv.TemporalTx,
@@ -119,6 +142,7 @@ func TraceTx(
 			return res, err
 		}
 		usedGas = res.UsedGas
+		rpc.CostFromContext(ctx).AddGas(res.UsedGas)
 		return res, nil
 	}
 
@@ -137,7 +161,7 @@ func AssembleTracer(
 ) (vm.EVMLogger, bool, context.CancelFunc, error) {
 	// Assemble the structured logger or the JavaScript tracer
 	switch {
-	case config != nil && config.Tracer != nil:
+	case config != nil && (config.Tracer != nil || len(config.Tracers) > 0):
 		// Define a meaningful timeout of a single transaction trace
 		timeout := callTimeout
 		if config.Timeout != nil {
@@ -149,11 +173,18 @@ func AssembleTracer(
 		}
 
 		// Construct the JavaScript tracer to execute with
-		cfg := json.RawMessage("{}")
-		if config != nil && config.TracerConfig != nil {
-			cfg = *config.TracerConfig
+		tracerCtx := &tracers.Context{TxHash: txHash, TxIndex: txnIndex, BlockHash: blockHash, SourceLocator: config.SourceLocator}
+		var tracer tracers.Tracer
+		var err error
+		if len(config.Tracers) > 0 {
+			tracer, err = newMultiTracer(config, tracerCtx)
+		} else {
+			cfg := json.RawMessage("{}")
+			if config.TracerConfig != nil {
+				cfg = *config.TracerConfig
+			}
+			tracer, err = tracers.New(*config.Tracer, tracerCtx, cfg)
 		}
-		tracer, err := tracers.New(*config.Tracer, &tracers.Context{TxHash: txHash, TxIndex: txnIndex, BlockHash: blockHash}, cfg)
 		if err != nil {
 			return nil, false, func() {}, err
 		}
@@ -169,8 +200,42 @@ func AssembleTracer(
 	case config == nil:
 		return logger.NewJsonStreamLogger(nil, ctx, stream), true, func() {}, nil
 	default:
-		return logger.NewJsonStreamLogger(config.LogConfig, ctx, stream), true, func() {}, nil
+		l := logger.NewJsonStreamLogger(config.LogConfig, ctx, stream)
+		if config.SourceLocator != nil {
+			l.SetSourceLocator(config.SourceLocator)
+		}
+		return l, true, func() {}, nil
+	}
+}
+
+// newMultiTracer assembles the tracers of the config into one, fed by a single replay. The
+// struct logger streams its output and can't be one of them.
+func newMultiTracer(config *tracersConfig.TraceConfig, ctx *tracers.Context) (tracers.Tracer, error) {
+	if config.Tracer != nil {
+		return nil, errors.New("tracer and tracers are mutually exclusive")
+	}
+	keys := make([]string, 0, len(config.Tracers))
+	objects := make([]tracers.Tracer, 0, len(config.Tracers))
+	for _, spec := range config.Tracers {
+		if spec.Tracer == "" {
+			return nil, errors.New("tracers: missing tracer name")
+		}
+		key := spec.ResultKey()
+		if slices.Contains(keys, key) {
+			return nil, fmt.Errorf("tracers: duplicate result key %q, set a distinct key", key)
+		}
+		cfg := json.RawMessage("{}")
+		if spec.TracerConfig != nil {
+			cfg = *spec.TracerConfig
+		}
+		tracer, err := tracers.New(spec.Tracer, ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("tracers: %s: %w", key, err)
+		}
+		keys = append(keys, key)
+		objects = append(objects, tracer)
 	}
+	return native.NewMuxTracer(keys, objects), nil
 }
 
 func ExecuteTraceTx(
@@ -0,0 +1,117 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package ordering
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/txnprovider"
+)
+
+// grpcOrderTimeout - if the service doesn't reply in time, the block is built in the provided order
+const grpcOrderTimeout = 500 * time.Millisecond
+
+// OrderMethod is the unary gRPC method of the external ordering service. Its messages are OrderRequest and
+// OrderReply encoded as JSON (content-subtype "json"), so that the service needs no generated code.
+const OrderMethod = "/txpool.Ordering/Order"
+
+// OrderRequest carries the candidate transactions of the block being built
+type OrderRequest struct {
+	ParentBlockNum uint64          `json:"parentBlockNum"`
+	BlockTime      uint64          `json:"blockTime"`
+	BaseFee        *hexutil.Big    `json:"baseFee,omitempty"` // nil before London
+	Txns           []hexutil.Bytes `json:"txns"`              // binary (EIP-2718) encoded
+}
+
+// OrderReply lists the indexes of the request transactions in the chosen order, the omitted ones are not included
+type OrderReply struct {
+	Indexes []uint32 `json:"indexes"`
+}
+
+// JSONCodec is the gRPC codec of the ordering service messages
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (JSONCodec) Name() string                       { return "json" }
+
+// grpcPolicy asks the external ordering service for the order of the transactions
+type grpcPolicy struct {
+	conn   *grpc.ClientConn
+	logger log.Logger
+}
+
+func newGrpcPolicy(ctx context.Context, addr string, logger log.Logger) (*grpcPolicy, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("ordering service %s: %w", addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	return &grpcPolicy{conn: conn, logger: logger}, nil
+}
+
+func (p *grpcPolicy) Order(ctx context.Context, txns []types.Transaction, opts txnprovider.ProvideOptions) ([]types.Transaction, error) {
+	ordered, err := p.order(ctx, txns, opts)
+	if err != nil {
+		p.logger.Warn("[txnprovider] ordering service failed, using the provided order", "err", err)
+		return txns, nil
+	}
+	return ordered, nil
+}
+
+func (p *grpcPolicy) order(ctx context.Context, txns []types.Transaction, opts txnprovider.ProvideOptions) ([]types.Transaction, error) {
+	req := &OrderRequest{ParentBlockNum: opts.ParentBlockNum, BlockTime: opts.BlockTime, Txns: make([]hexutil.Bytes, len(txns))}
+	if opts.BaseFee != nil {
+		req.BaseFee = (*hexutil.Big)(opts.BaseFee.ToBig())
+	}
+	for i, txn := range txns {
+		var buf bytes.Buffer
+		if err := txn.MarshalBinary(&buf); err != nil {
+			return nil, err
+		}
+		req.Txns[i] = buf.Bytes()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, grpcOrderTimeout)
+	defer cancel()
+	var reply OrderReply
+	if err := p.conn.Invoke(ctx, OrderMethod, req, &reply, grpc.ForceCodec(JSONCodec{})); err != nil {
+		return nil, err
+	}
+	used := make([]bool, len(txns))
+	res := make([]types.Transaction, 0, len(reply.Indexes))
+	for _, i := range reply.Indexes {
+		if i >= uint32(len(txns)) || used[i] {
+			return nil, fmt.Errorf("invalid or repeated index %d of %d transactions", i, len(txns))
+		}
+		used[i] = true
+		res = append(res, txns[i])
+	}
+	return res, nil
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+// Package ordering implements transaction ordering policies of locally built blocks, see --miner.ordering
+package ordering
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/fixedgas"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/txnprovider"
+)
+
+const (
+	PriceTime  = "price-time" // highest effective tip first, earlier provided first among equal tips
+	FIFO       = "fifo"       // first seen by the txpool first, for fair-sequencing devnets
+	GrpcPrefix = "grpc://"    // external ordering service at "grpc://host:port", see ./grpc.go
+)
+
+// Policy orders the transactions provided for the block being built, it may also drop transactions.
+// Transactions of the same sender must stay in nonce order: the builder skips a transaction after a nonce gap.
+type Policy interface {
+	Order(ctx context.Context, txns []types.Transaction, opts txnprovider.ProvideOptions) ([]types.Transaction, error)
+}
+
+// ArrivalLog returns when the txpool first saw the transaction, used by the FIFO policy
+type ArrivalLog interface {
+	FirstSeen(hash common.Hash) (time.Time, bool)
+}
+
+// New returns the policy by its name. arrivals may be nil unless the policy is FIFO.
+func New(ctx context.Context, name string, arrivals ArrivalLog, logger log.Logger) (Policy, error) {
+	switch {
+	case name == PriceTime:
+		return priceTime{}, nil
+	case name == FIFO:
+		if arrivals == nil {
+			return nil, fmt.Errorf("%s ordering requires the internal txpool", FIFO)
+		}
+		return fifo{arrivals: arrivals}, nil
+	case strings.HasPrefix(name, GrpcPrefix):
+		return newGrpcPolicy(ctx, strings.TrimPrefix(name, GrpcPrefix), logger)
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q, supported: %s, %s, %shost:port", name, PriceTime, FIFO, GrpcPrefix)
+	}
+}
+
+// candidatesFactor - the policy chooses from the candidates worth this many times the targets of the block
+// (gas, blob gas, amount of transactions)
+const candidatesFactor = 4
+
+// Provider applies the policy to the transactions of another provider. The inner provider is asked for more
+// candidates than fit the targets, the policy orders them, then the block is filled in that order up to the targets:
+// the policy decides which of the candidates get included, not only their order.
+type Provider struct {
+	inner       txnprovider.TxnProvider
+	policy      Policy
+	chainConfig *chain.Config
+}
+
+func NewProvider(inner txnprovider.TxnProvider, policy Policy, chainConfig *chain.Config) *Provider {
+	return &Provider{inner: inner, policy: policy, chainConfig: chainConfig}
+}
+
+func (p *Provider) ProvideTxns(ctx context.Context, opts ...txnprovider.ProvideOption) ([]types.Transaction, error) {
+	provideOpts := txnprovider.ApplyProvideOptions(opts...)
+	// the inner provider marks what it provides as yielded, the candidates left out have to be provided again
+	candidatesYielded := provideOpts.TxnIdsFilter.Clone()
+	candidateOpts := append(slices.Clone(opts),
+		txnprovider.WithAmount(scaleAmount(provideOpts.Amount)),
+		txnprovider.WithGasTarget(scaleGas(provideOpts.GasTarget)),
+		txnprovider.WithBlobGasTarget(scaleGas(provideOpts.BlobGasTarget)),
+		txnprovider.WithTxnIdsFilter(candidatesYielded),
+	)
+	candidates, err := p.inner.ProvideTxns(ctx, candidateOpts...)
+	if err != nil || len(candidates) == 0 {
+		return candidates, err
+	}
+	ordered, err := p.policy.Order(ctx, candidates, provideOpts)
+	if err != nil {
+		return nil, err
+	}
+	txns := p.fill(ordered, provideOpts)
+	for _, txn := range txns {
+		provideOpts.TxnIdsFilter.Add(txn.Hash())
+	}
+	return txns, nil
+}
+
+// fill takes the ordered transactions up to the targets, accounting the gas as the txpool does: by the intrinsic gas.
+// A skipped transaction skips the later ones of its sender, they would fail on the nonce gap.
+func (p *Provider) fill(ordered []types.Transaction, opts txnprovider.ProvideOptions) []types.Transaction {
+	isShanghai, isPrague := p.chainConfig.IsShanghai(opts.BlockTime), p.chainConfig.IsPrague(opts.BlockTime)
+	availableGas, availableBlobGas := opts.GasTarget, opts.BlobGasTarget
+	skippedSenders := map[common.Address]struct{}{}
+	skip := func(txn types.Transaction) {
+		if sender, ok := txn.GetSender(); ok {
+			skippedSenders[sender] = struct{}{}
+		}
+	}
+	res := make([]types.Transaction, 0, min(len(ordered), opts.Amount))
+	for _, txn := range ordered {
+		if len(res) >= opts.Amount || availableGas < fixedgas.TxGas {
+			break
+		}
+		if sender, ok := txn.GetSender(); ok {
+			if _, skipped := skippedSenders[sender]; skipped {
+				continue
+			}
+		}
+		blobGas := uint64(len(txn.GetBlobHashes())) * fixedgas.BlobGasPerBlob
+		accessList := txn.GetAccessList()
+		var authorizations uint64
+		if setCode, ok := txn.Unwrap().(*types.SetCodeTransaction); ok {
+			authorizations = uint64(len(setCode.GetAuthorizations()))
+		}
+		intrinsicGas, floorGas, overflow := fixedgas.IntrinsicGas(txn.GetData(), uint64(len(accessList)), uint64(accessList.StorageKeys()), txn.GetTo() == nil, true, true, isShanghai, isPrague, authorizations)
+		if isPrague && floorGas > intrinsicGas {
+			intrinsicGas = floorGas
+		}
+		if overflow || intrinsicGas > availableGas || blobGas > availableBlobGas {
+			skip(txn)
+			continue
+		}
+		availableGas -= intrinsicGas
+		availableBlobGas -= blobGas
+		res = append(res, txn)
+	}
+	return res
+}
+
+func scaleGas(target uint64) uint64 {
+	if target > math.MaxUint64/candidatesFactor {
+		return math.MaxUint64
+	}
+	return target * candidatesFactor
+}
+
+func scaleAmount(amount int) int {
+	if amount > math.MaxInt/candidatesFactor {
+		return math.MaxInt
+	}
+	return amount * candidatesFactor
+}
+
+type priceTime struct{}
+
+func (priceTime) Order(_ context.Context, txns []types.Transaction, opts txnprovider.ProvideOptions) ([]types.Transaction, error) {
+	tips := make([]uint64, len(txns))
+	for i, txn := range txns {
+		tip := txn.GetEffectiveGasTip(opts.BaseFee)
+		if tip.IsUint64() {
+			tips[i] = tip.Uint64()
+		} else {
+			tips[i] = ^uint64(0)
+		}
+	}
+	return orderBySender(txns, func(i, j int) bool {
+		if tips[i] != tips[j] {
+			return tips[i] > tips[j]
+		}
+		return i < j
+	}), nil
+}
+
+type fifo struct {
+	arrivals ArrivalLog
+}
+
+func (f fifo) Order(_ context.Context, txns []types.Transaction, _ txnprovider.ProvideOptions) ([]types.Transaction, error) {
+	seen := make([]time.Time, len(txns))
+	known := make([]bool, len(txns))
+	for i, txn := range txns {
+		seen[i], known[i] = f.arrivals.FirstSeen(txn.Hash())
+	}
+	// transactions the pool doesn't remember arrived before the remembered ones
+	return orderBySender(txns, func(i, j int) bool {
+		if known[i] != known[j] {
+			return !known[i]
+		}
+		if !seen[i].Equal(seen[j]) {
+			return seen[i].Before(seen[j])
+		}
+		return i < j
+	}), nil
+}
+
+// orderBySender orders the transactions by less (of their indexes), keeping transactions of the same sender in
+// nonce order: the next transaction of a sender is considered only after the previous one is placed
+func orderBySender(txns []types.Transaction, less func(i, j int) bool) []types.Transaction {
+	bySender := map[common.Address][]int{}
+	var queues [][]int
+	for i, txn := range txns {
+		sender, ok := txn.GetSender()
+		if !ok {
+			queues = append(queues, []int{i})
+			continue
+		}
+		bySender[sender] = append(bySender[sender], i)
+	}
+	for _, q := range bySender {
+		sort.SliceStable(q, func(a, b int) bool { return txns[q[a]].GetNonce() < txns[q[b]].GetNonce() })
+		queues = append(queues, q)
+	}
+	h := &senderHeads{queues: queues, less: less}
+	heap.Init(h)
+	res := make([]types.Transaction, 0, len(txns))
+	for h.Len() > 0 {
+		q := h.queues[0]
+		res = append(res, txns[q[0]])
+		if len(q) > 1 {
+			h.queues[0] = q[1:]
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return res
+}
+
+// senderHeads is a heap of per-sender queues of transaction indexes, by their first transactions
+type senderHeads struct {
+	queues [][]int
+	less   func(i, j int) bool
+}
+
+func (h *senderHeads) Len() int           { return len(h.queues) }
+func (h *senderHeads) Less(i, j int) bool { return h.less(h.queues[i][0], h.queues[j][0]) }
+func (h *senderHeads) Swap(i, j int)      { h.queues[i], h.queues[j] = h.queues[j], h.queues[i] }
+func (h *senderHeads) Push(x any)         { h.queues = append(h.queues, x.([]int)) }
+func (h *senderHeads) Pop() any {
+	last := h.queues[len(h.queues)-1]
+	h.queues = h.queues[:len(h.queues)-1]
+	return last
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package ordering
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/fixedgas"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/params"
+	"github.com/erigontech/erigon/txnprovider"
+)
+
+func testTxn(sender byte, nonce, gasPrice uint64) types.Transaction {
+	txn := types.NewTransaction(nonce, common.Address{0xff}, uint256.NewInt(0), 21_000, uint256.NewInt(gasPrice), nil)
+	txn.SetSender(common.Address{sender})
+	return txn
+}
+
+type testArrivals map[common.Hash]time.Time
+
+func (a testArrivals) FirstSeen(hash common.Hash) (time.Time, bool) {
+	t, ok := a[hash]
+	return t, ok
+}
+
+func TestPriceTime(t *testing.T) {
+	a0, a1 := testTxn(1, 0, 1), testTxn(1, 1, 100)
+	b0, b1 := testTxn(2, 0, 10), testTxn(2, 1, 10)
+	c0 := testTxn(3, 0, 10)
+
+	ordered, err := priceTime{}.Order(context.Background(), []types.Transaction{a1, b0, c0, a0, b1}, txnprovider.ProvideOptions{})
+	require.NoError(t, err)
+	// a1 pays the most but waits for a0, which pays the least; b0 and c0 tie and keep the provided order
+	require.Equal(t, []types.Transaction{b0, c0, b1, a0, a1}, ordered)
+}
+
+func TestFIFO(t *testing.T) {
+	a0, a1 := testTxn(1, 0, 1), testTxn(1, 1, 100)
+	b0 := testTxn(2, 0, 10)
+	unknown := testTxn(3, 0, 2) // hashes of unsigned txns don't depend on the sender
+	now := time.Now()
+	arrivals := testArrivals{
+		a0.Hash(): now.Add(2 * time.Second),
+		a1.Hash(): now,
+		b0.Hash(): now.Add(time.Second),
+	}
+
+	policy, err := New(context.Background(), FIFO, arrivals, nil)
+	require.NoError(t, err)
+	ordered, err := policy.Order(context.Background(), []types.Transaction{a1, a0, b0, unknown}, txnprovider.ProvideOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []types.Transaction{unknown, b0, a0, a1}, ordered)
+
+	_, err = New(context.Background(), FIFO, nil, nil)
+	require.Error(t, err)
+	_, err = New(context.Background(), "random", nil, nil)
+	require.Error(t, err)
+}
+
+// reverseOrdering includes the transactions in the reverse order but the first one
+type reverseOrdering struct {
+	baseFees chan *big.Int
+}
+
+func (s *reverseOrdering) order(req *OrderRequest) *OrderReply {
+	s.baseFees <- req.BaseFee.ToInt()
+	reply := &OrderReply{}
+	for i := len(req.Txns) - 1; i > 0; i-- {
+		reply.Indexes = append(reply.Indexes, uint32(i))
+	}
+	return reply
+}
+
+var orderingServiceDesc = grpc.ServiceDesc{
+	ServiceName: "txpool.Ordering",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Order",
+		Handler: func(srv any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+			var req OrderRequest
+			if err := dec(&req); err != nil {
+				return nil, err
+			}
+			return srv.(*reverseOrdering).order(&req), nil
+		},
+	}},
+}
+
+func TestGrpc(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.ForceServerCodec(JSONCodec{}))
+	service := &reverseOrdering{baseFees: make(chan *big.Int, 1)}
+	server.RegisterService(&orderingServiceDesc, service)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	policy, err := New(ctx, GrpcPrefix+lis.Addr().String(), nil, log.New())
+	require.NoError(t, err)
+	a, b, c := testTxn(1, 0, 1), testTxn(2, 0, 1), testTxn(3, 0, 1)
+	ordered, err := policy.Order(ctx, []types.Transaction{a, b, c}, txnprovider.ProvideOptions{BaseFee: uint256.NewInt(7)})
+	require.NoError(t, err)
+	require.Equal(t, []types.Transaction{c, b}, ordered)
+	require.Equal(t, big.NewInt(7), <-service.baseFees)
+
+	// the provided order is kept if the service fails
+	server.Stop()
+	ordered, err = policy.Order(ctx, []types.Transaction{a, b, c}, txnprovider.ProvideOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []types.Transaction{a, b, c}, ordered)
+}
+
+// testPool provides its transactions in the stored order up to the targets, like the txpool
+type testPool []types.Transaction
+
+func (p testPool) ProvideTxns(_ context.Context, opts ...txnprovider.ProvideOption) ([]types.Transaction, error) {
+	provideOpts := txnprovider.ApplyProvideOptions(opts...)
+	var res []types.Transaction
+	gas := provideOpts.GasTarget
+	for _, txn := range p {
+		if len(res) >= provideOpts.Amount || gas < fixedgas.TxGas {
+			break
+		}
+		if provideOpts.TxnIdsFilter.Contains(txn.Hash()) {
+			continue
+		}
+		gas -= fixedgas.TxGas
+		provideOpts.TxnIdsFilter.Add(txn.Hash())
+		res = append(res, txn)
+	}
+	return res, nil
+}
+
+func TestProviderChoosesIncluded(t *testing.T) {
+	a, b, c, d := testTxn(1, 0, 1), testTxn(2, 0, 2), testTxn(3, 0, 10), testTxn(4, 0, 20)
+	pool := testPool{a, b, c, d}
+	provider := NewProvider(pool, priceTime{}, params.TestChainConfig)
+
+	// without the policy the block would get a and b, which pay the least
+	yielded := mapset.NewSet[[32]byte]()
+	txns, err := provider.ProvideTxns(context.Background(), txnprovider.WithGasTarget(2*fixedgas.TxGas), txnprovider.WithTxnIdsFilter(yielded))
+	require.NoError(t, err)
+	require.Equal(t, []types.Transaction{d, c}, txns)
+	require.Equal(t, mapset.NewSet[[32]byte](d.Hash(), c.Hash()), yielded)
+
+	// the candidates left out are provided on the next call
+	txns, err = provider.ProvideTxns(context.Background(), txnprovider.WithGasTarget(2*fixedgas.TxGas), txnprovider.WithTxnIdsFilter(yielded))
+	require.NoError(t, err)
+	require.Equal(t, []types.Transaction{b, a}, txns)
+
+	// a skipped transaction skips the later ones of its sender: e0 carries too much data to fit
+	e0 := types.NewTransaction(0, common.Address{0xff}, uint256.NewInt(0), 100_000, uint256.NewInt(30), make([]byte, 10_000))
+	e0.SetSender(common.Address{5})
+	e1, f := testTxn(5, 1, 30), testTxn(6, 0, 1)
+	provider = NewProvider(testPool{e0, e1, f}, priceTime{}, params.TestChainConfig)
+	txns, err = provider.ProvideTxns(context.Background(), txnprovider.WithGasTarget(2*fixedgas.TxGas), txnprovider.WithTxnIdsFilter(mapset.NewSet[[32]byte]()))
+	require.NoError(t, err)
+	require.Equal(t, []types.Transaction{f}, txns)
+}
@@ -21,6 +21,7 @@ import (
 	"math"
 
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/holiman/uint256"
 
 	"github.com/erigontech/erigon/core/types"
 )
@@ -32,6 +33,7 @@ type TxnProvider interface {
 	//   - WithGasTarget
 	//   - WithBlobGasTarget
 	//   - WithTxnIdsFilter
+	//   - WithBaseFee
 	ProvideTxns(ctx context.Context, opts ...ProvideOption) ([]types.Transaction, error)
 }
 
@@ -73,6 +75,12 @@ func WithTxnIdsFilter(txnIdsFilter mapset.Set[[32]byte]) ProvideOption {
 	}
 }
 
+func WithBaseFee(baseFee *uint256.Int) ProvideOption {
+	return func(opt *ProvideOptions) {
+		opt.BaseFee = baseFee
+	}
+}
+
 type ProvideOptions struct {
 	BlockTime      uint64
 	ParentBlockNum uint64
@@ -80,6 +88,7 @@ type ProvideOptions struct {
 	GasTarget      uint64
 	BlobGasTarget  uint64
 	TxnIdsFilter   mapset.Set[[32]byte]
+	BaseFee        *uint256.Int // of the block being built, nil before London
 }
 
 func ApplyProvideOptions(opts ...ProvideOption) ProvideOptions {
@@ -57,15 +57,27 @@ func Assemble(
 	chainID, _ := uint256.FromBig(chainConfig.ChainID)
 
 	shanghaiTime := chainConfig.ShanghaiTime
+	if cfg.OverrideShanghaiTime != nil {
+		shanghaiTime = cfg.OverrideShanghaiTime
+	}
 	var agraBlock *big.Int
 	if chainConfig.Bor != nil {
 		agraBlock = chainConfig.Bor.GetAgraBlock()
 	}
 	cancunTime := chainConfig.CancunTime
+	if cfg.OverrideCancunTime != nil {
+		cancunTime = cfg.OverrideCancunTime
+	}
 	pragueTime := chainConfig.PragueTime
 	if cfg.OverridePragueTime != nil {
 		pragueTime = cfg.OverridePragueTime
 	}
+	if cfg.OverrideOsakaTime != nil {
+		// txpool doesn't have Osaka-specific rules yet, just make the override visible to it
+		cc := *chainConfig
+		cc.OsakaTime = cfg.OverrideOsakaTime
+		chainConfig = &cc
+	}
 
 	newTxns := make(chan Announcements, 1024)
 	newSlotsStreams := &NewSlotsStreams{}
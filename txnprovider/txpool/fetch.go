@@ -52,6 +52,7 @@ type Fetch struct {
 	sentryClients            []sentry.SentryClient // sentry clients that will be used for accessing the network
 	stateChangesParseCtxLock sync.Mutex
 	pooledTxnsParseCtxLock   sync.Mutex
+	propagation              *PropagationLog // nil if not recorded
 	logger                   log.Logger
 }
 
@@ -235,6 +236,7 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 		if err != nil {
 			return err
 		}
+		f.propagation.seen(unknownHashes, PropagationSourceAnnouncement, req.PeerId)
 		if len(unknownHashes) > 0 {
 			var encodedRequest []byte
 			var messageID sentry.MessageId
@@ -258,6 +260,7 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 		if err != nil {
 			return err
 		}
+		f.propagation.seen(unknownHashes, PropagationSourceAnnouncement, req.PeerId)
 
 		if len(unknownHashes) > 0 {
 			var encodedRequest []byte
@@ -404,6 +407,17 @@ func (f *Fetch) handleInboundMessage(ctx context.Context, req *sentry.InboundMes
 			IsLocal:   txns.IsLocal,
 			KnownTxns: knownTxns,
 		})
+		if f.propagation != nil {
+			source := PropagationSourceAnnouncement
+			if req.Id == sentry.MessageId_TRANSACTIONS_66 {
+				source = PropagationSourceBroadcast
+			}
+			hashes := make(Hashes, 0, 32*len(txns.Txns))
+			for _, txn := range txns.Txns {
+				hashes = append(hashes, txn.IDHash[:]...)
+			}
+			f.propagation.seen(hashes, source, req.PeerId)
+		}
 		f.pool.AddRemoteTxns(ctx, txns)
 	default:
 		defer f.logger.Trace("[txpool] dropped p2p message", "id", req.Id)
@@ -0,0 +1,247 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package txpool
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// ingressRulesReloadEvery - how often the rules file is checked for changes
+const ingressRulesReloadEvery = 5 * time.Second
+
+// IngressRules is the operator's policy on transactions entering the pool, read from the file set by
+// --txpool.ingressrules. The rules apply to local and remote transactions as they are added, a reload doesn't
+// affect the transactions already in the pool.
+type IngressRules struct {
+	Reject       IngressRuleSet `json:"reject"`       // matching transactions are discarded with txpoolcfg.IngressDenied
+	Deprioritize IngressRuleSet `json:"deprioritize"` // matching transactions are ordered after others of their sub-pool
+	// AuditLog is the file rejections are appended to as JSON lines, relative to the rules file. Not written if empty.
+	AuditLog string `json:"auditLog,omitempty"`
+}
+
+// IngressRuleSet matches transactions calling one of the addresses or, in any contract, one of the selectors
+type IngressRuleSet struct {
+	To        []common.Address `json:"to,omitempty"`
+	Selectors []hexutil.Bytes  `json:"selectors,omitempty"` // 4 bytes each
+}
+
+// IngressRejection is an entry of the audit log
+type IngressRejection struct {
+	Time     time.Time      `json:"time"`
+	Hash     common.Hash    `json:"hash"`
+	Sender   common.Address `json:"sender"`
+	To       common.Address `json:"to"`
+	Selector string         `json:"selector,omitempty"`
+	Rule     string         `json:"rule"` // ingressRuleTo or ingressRuleSelector
+	Local    bool           `json:"local"`
+}
+
+const (
+	ingressRuleTo       = "to"
+	ingressRuleSelector = "selector"
+)
+
+type ingressAction uint8
+
+const (
+	ingressAccept ingressAction = iota
+	ingressDeprioritize
+	ingressReject
+)
+
+type ingressMatcher struct {
+	to        map[common.Address]struct{}
+	selectors map[[4]byte]struct{}
+}
+
+func newIngressMatcher(set IngressRuleSet) (*ingressMatcher, error) {
+	m := &ingressMatcher{to: make(map[common.Address]struct{}, len(set.To)), selectors: make(map[[4]byte]struct{}, len(set.Selectors))}
+	for _, addr := range set.To {
+		m.to[addr] = struct{}{}
+	}
+	for _, sel := range set.Selectors {
+		if len(sel) != 4 {
+			return nil, fmt.Errorf("selector %s: expected 4 bytes, got %d", sel, len(sel))
+		}
+		m.selectors[[4]byte(sel)] = struct{}{}
+	}
+	return m, nil
+}
+
+// match returns the matched rule, empty if none
+func (m *ingressMatcher) match(txn *TxnSlot) string {
+	if txn.Creation {
+		return ""
+	}
+	if _, ok := m.to[txn.To]; ok {
+		return ingressRuleTo
+	}
+	if txn.DataLen >= len(txn.Selector) {
+		if _, ok := m.selectors[txn.Selector]; ok {
+			return ingressRuleSelector
+		}
+	}
+	return ""
+}
+
+// ingressFilter applies IngressRules, the rules file is reloaded when it changes
+type ingressFilter struct {
+	path   string
+	logger log.Logger
+
+	mu           sync.RWMutex
+	reject       *ingressMatcher
+	deprioritize *ingressMatcher
+	audit        *os.File // nil if the audit log is not set
+	auditPath    string
+
+	// of the loaded file, only used by reloadIfChanged
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+// newIngressFilter loads the rules, the error is returned if the file can't be read or is invalid
+func newIngressFilter(path string, logger log.Logger) (*ingressFilter, error) {
+	f := &ingressFilter{path: path, logger: logger}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("txpool ingress rules: %w", err)
+	}
+	if err := f.load(); err != nil {
+		return nil, fmt.Errorf("txpool ingress rules %s: %w", path, err)
+	}
+	f.modTime, f.size = info.ModTime(), info.Size()
+	return f, nil
+}
+
+func (f *ingressFilter) load() error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	var rules IngressRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	reject, err := newIngressMatcher(rules.Reject)
+	if err != nil {
+		return fmt.Errorf("reject: %w", err)
+	}
+	deprioritize, err := newIngressMatcher(rules.Deprioritize)
+	if err != nil {
+		return fmt.Errorf("deprioritize: %w", err)
+	}
+	auditPath := rules.AuditLog
+	if auditPath != "" && !filepath.IsAbs(auditPath) {
+		auditPath = filepath.Join(filepath.Dir(f.path), auditPath)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if auditPath != f.auditPath {
+		var audit *os.File
+		if auditPath != "" {
+			if audit, err = os.OpenFile(auditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+				return fmt.Errorf("audit log: %w", err)
+			}
+		}
+		if f.audit != nil {
+			f.audit.Close()
+		}
+		f.audit, f.auditPath = audit, auditPath
+	}
+	f.reject, f.deprioritize = reject, deprioritize
+	f.logger.Info("[txpool] ingress rules loaded", "reject.to", len(reject.to), "reject.selectors", len(reject.selectors),
+		"deprioritize.to", len(deprioritize.to), "deprioritize.selectors", len(deprioritize.selectors), "audit", auditPath)
+	return nil
+}
+
+// reloadIfChanged reloads the rules if the file was modified, invalid rules are logged and the previous ones are kept
+func (f *ingressFilter) reloadIfChanged() {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if !f.missing {
+			f.logger.Warn("[txpool] ingress rules file is unavailable, keeping the loaded rules", "err", err)
+		}
+		f.missing = true
+		return
+	}
+	f.missing = false
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return
+	}
+	f.modTime, f.size = info.ModTime(), info.Size()
+	if err := f.load(); err != nil {
+		f.logger.Warn("[txpool] invalid ingress rules, keeping the loaded rules", "path", f.path, "err", err)
+	}
+}
+
+// check returns what to do with the transaction, rejections are counted and written to the audit log
+func (f *ingressFilter) check(txn *TxnSlot, sender common.Address, isLocal bool) ingressAction {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if rule := f.reject.match(txn); rule != "" {
+		ingressRejectedCounter(rule).Inc()
+		f.writeAudit(txn, sender, rule, isLocal)
+		return ingressReject
+	}
+	if rule := f.deprioritize.match(txn); rule != "" {
+		ingressDeprioritizedCounter(rule).Inc()
+		return ingressDeprioritize
+	}
+	return ingressAccept
+}
+
+// writeAudit must be called with f.mu held, a read lock is enough: appends of single lines don't interleave
+func (f *ingressFilter) writeAudit(txn *TxnSlot, sender common.Address, rule string, isLocal bool) {
+	entry := IngressRejection{Time: time.Now().UTC(), Hash: txn.IDHash, Sender: sender, To: txn.To, Rule: rule, Local: isLocal}
+	if txn.DataLen >= len(txn.Selector) {
+		entry.Selector = "0x" + hex.EncodeToString(txn.Selector[:])
+	}
+	f.logger.Debug("[txpool] ingress rejected", "hash", entry.Hash, "sender", sender, "to", txn.To, "rule", rule)
+	if f.audit == nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if _, err := f.audit.Write(append(line, '\n')); err != nil {
+		f.logger.Warn("[txpool] ingress audit log write failed", "err", err)
+	}
+}
+
+func (f *ingressFilter) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.audit == nil {
+		return nil
+	}
+	err := f.audit.Close()
+	f.audit = nil
+	return err
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package txpool
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+)
+
+func writeIngressRules(t *testing.T, path string, rules string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestIngressFilter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.json")
+	denied, slow, other := common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")
+	writeIngressRules(t, path, `{
+		"reject": {"to": ["0x0000000000000000000000000000000000000001"], "selectors": ["0xa9059cbb"]},
+		"deprioritize": {"to": ["0x0000000000000000000000000000000000000002"]},
+		"auditLog": "audit.jsonl"
+	}`, time.Now().Add(-time.Hour))
+
+	f, err := newIngressFilter(path, log.New())
+	require.NoError(t, err)
+	defer f.close()
+
+	sender := common.HexToAddress("0xff")
+	transfer := [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+	require.Equal(t, ingressReject, f.check(&TxnSlot{To: denied, IDHash: [32]byte{1}}, sender, true))
+	require.Equal(t, ingressReject, f.check(&TxnSlot{To: other, Selector: transfer, DataLen: 68}, sender, false))
+	require.Equal(t, ingressAccept, f.check(&TxnSlot{To: other, Selector: [4]byte{0xa9, 0x05}, DataLen: 2}, sender, false))
+	require.Equal(t, ingressAccept, f.check(&TxnSlot{Creation: true, Selector: transfer, DataLen: 68}, sender, false))
+	require.Equal(t, ingressDeprioritize, f.check(&TxnSlot{To: slow}, sender, false))
+
+	audit, err := os.ReadFile(filepath.Join(dir, "audit.jsonl"))
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(audit), []byte{'\n'})
+	require.Len(t, lines, 2)
+	var entry IngressRejection
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	require.Equal(t, IngressRejection{Time: entry.Time, Hash: common.Hash{1}, Sender: sender, To: denied, Rule: ingressRuleTo, Local: true}, entry)
+	require.NoError(t, json.Unmarshal(lines[1], &entry))
+	require.Equal(t, ingressRuleSelector, entry.Rule)
+	require.Equal(t, "0xa9059cbb", entry.Selector)
+
+	// invalid change keeps the loaded rules
+	writeIngressRules(t, path, `{"reject": {"selectors": ["0xa9"]}}`, time.Now().Add(-time.Minute))
+	f.reloadIfChanged()
+	require.Equal(t, ingressReject, f.check(&TxnSlot{To: denied}, sender, false))
+
+	writeIngressRules(t, path, `{"reject": {"to": ["0x0000000000000000000000000000000000000003"]}}`, time.Now())
+	f.reloadIfChanged()
+	require.Equal(t, ingressAccept, f.check(&TxnSlot{To: denied}, sender, false))
+	require.Equal(t, ingressReject, f.check(&TxnSlot{To: other}, sender, false))
+	require.Equal(t, ingressAccept, f.check(&TxnSlot{To: slow}, sender, false))
+
+	_, err = newIngressFilter(filepath.Join(dir, "missing.json"), log.New())
+	require.Error(t, err)
+}
+
+func TestDeprioritizedOrdering(t *testing.T) {
+	var baseFee, blobFee uint256.Int
+	mt := &metaTxn{TxnSlot: &TxnSlot{}, currentSubPool: PendingSubPool, timestamp: 1}
+	deprioritized := &metaTxn{TxnSlot: &TxnSlot{deprioritized: true}, currentSubPool: PendingSubPool, timestamp: 0}
+	require.True(t, mt.better(deprioritized, baseFee, blobFee))
+	require.False(t, deprioritized.better(mt, baseFee, blobFee))
+	require.True(t, deprioritized.worse(mt, baseFee, blobFee))
+	require.False(t, mt.worse(deprioritized, baseFee, blobFee))
+}
+
+func TestParseDestinationAndSelector(t *testing.T) {
+	to := common.HexToAddress("0x1234")
+	data := []byte{0xa9, 0x05, 0x9c, 0xbb, 0x01}
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	encode := func(txn types.Transaction) []byte {
+		signed, err := types.SignTx(txn, *signer, key)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, signed.MarshalBinary(&buf))
+		return buf.Bytes()
+	}
+	call := encode(types.NewTransaction(0, to, uint256.NewInt(0), 100_000, uint256.NewInt(1), data))
+	create := encode(types.NewContractCreation(0, uint256.NewInt(0), 100_000, uint256.NewInt(1), []byte{0x60}))
+
+	ctx := NewTxnParseContext(*uint256.NewInt(1))
+	ctx.WithSender(false)
+	var slot TxnSlot
+	_, err = ctx.ParseTransaction(call, 0, &slot, nil, false, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, to, slot.To)
+	require.Equal(t, [4]byte{0xa9, 0x05, 0x9c, 0xbb}, slot.Selector)
+
+	slot = TxnSlot{}
+	_, err = ctx.ParseTransaction(create, 0, &slot, nil, false, true, nil)
+	require.NoError(t, err)
+	require.True(t, slot.Creation)
+	require.Equal(t, common.Address{}, slot.To)
+	require.Equal(t, [4]byte{0x60}, slot.Selector)
+}
@@ -41,24 +41,37 @@ type metaTxn struct {
 	minedBlockNum             uint64
 }
 
+// enoughFeeCap returns true if the txn and all txns of the sender with lower nonces pay at least the base fee of
+// the pending block, and the blob txn also pays at least the pending blob fee
+func (mt *metaTxn) enoughFeeCap(pendingBaseFee, pendingBlobFee *uint256.Int) bool {
+	if mt.minFeeCap.Lt(pendingBaseFee) {
+		return false
+	}
+	return mt.TxnSlot.Type != BlobTxnType || !mt.TxnSlot.BlobFeeCap.Lt(pendingBlobFee)
+}
+
 // Returns true if the txn "mt" is better than the parameter txn "than"
-// it first compares the subpool markers of the two meta txns, then,
+// it first compares the subpool markers of the two meta txns, then
+// puts txns deprioritized by the ingress rules after others, then,
 // (since they have the same subpool marker, and thus same pool)
 // depending on the pool - pending (P), basefee (B), queued (Q) -
 // it compares the effective tip (for P), nonceDistance (for both P,Q)
 // minFeeCap (for B), and cumulative balance distance (for P, Q)
-func (mt *metaTxn) better(than *metaTxn, pendingBaseFee uint256.Int) bool {
+func (mt *metaTxn) better(than *metaTxn, pendingBaseFee, pendingBlobFee uint256.Int) bool {
 	subPool := mt.subPool
 	thanSubPool := than.subPool
-	if mt.minFeeCap.Cmp(&pendingBaseFee) >= 0 {
+	if mt.enoughFeeCap(&pendingBaseFee, &pendingBlobFee) {
 		subPool |= EnoughFeeCapBlock
 	}
-	if than.minFeeCap.Cmp(&pendingBaseFee) >= 0 {
+	if than.enoughFeeCap(&pendingBaseFee, &pendingBlobFee) {
 		thanSubPool |= EnoughFeeCapBlock
 	}
 	if subPool != thanSubPool {
 		return subPool > thanSubPool
 	}
+	if mt.TxnSlot.deprioritized != than.TxnSlot.deprioritized {
+		return than.TxnSlot.deprioritized
+	}
 
 	switch mt.currentSubPool {
 	case PendingSubPool:
@@ -109,18 +122,21 @@ func (mt *metaTxn) better(than *metaTxn, pendingBaseFee uint256.Int) bool {
 	return mt.timestamp < than.timestamp
 }
 
-func (mt *metaTxn) worse(than *metaTxn, pendingBaseFee uint256.Int) bool {
+func (mt *metaTxn) worse(than *metaTxn, pendingBaseFee, pendingBlobFee uint256.Int) bool {
 	subPool := mt.subPool
 	thanSubPool := than.subPool
-	if mt.minFeeCap.Cmp(&pendingBaseFee) >= 0 {
+	if mt.enoughFeeCap(&pendingBaseFee, &pendingBlobFee) {
 		subPool |= EnoughFeeCapBlock
 	}
-	if than.minFeeCap.Cmp(&pendingBaseFee) >= 0 {
+	if than.enoughFeeCap(&pendingBaseFee, &pendingBlobFee) {
 		thanSubPool |= EnoughFeeCapBlock
 	}
 	if subPool != thanSubPool {
 		return subPool < thanSubPool
 	}
+	if mt.TxnSlot.deprioritized != than.TxnSlot.deprioritized {
+		return mt.TxnSlot.deprioritized
+	}
 
 	switch mt.currentSubPool {
 	case PendingSubPool:
@@ -22,6 +22,7 @@ var (
 	processBatchTxnsTimer   = metrics.NewSummary(`pool_process_remote_txs`)
 	addRemoteTxnsTimer      = metrics.NewSummary(`pool_add_remote_txs`)
 	newBlockTimer           = metrics.NewSummary(`pool_new_block`)
+	repriceTimer            = metrics.NewSummary(`pool_reprice`)
 	writeToDBTimer          = metrics.NewSummary(`pool_write_to_db`)
 	propagateToNewPeerTimer = metrics.NewSummary(`pool_propagate_to_new_peer`)
 	propagateNewTxnsTimer   = metrics.NewSummary(`pool_propagate_new_txs`)
@@ -30,3 +31,24 @@ var (
 	queuedSubCounter        = metrics.GetOrCreateGauge(`txpool_queued`)
 	basefeeSubCounter       = metrics.GetOrCreateGauge(`txpool_basefee`)
 )
+
+var (
+	ingressRejectedByTo            = metrics.GetOrCreateCounter(`txpool_ingress_rejected{rule="to"}`)
+	ingressRejectedBySelector      = metrics.GetOrCreateCounter(`txpool_ingress_rejected{rule="selector"}`)
+	ingressDeprioritizedByTo       = metrics.GetOrCreateCounter(`txpool_ingress_deprioritized{rule="to"}`)
+	ingressDeprioritizedBySelector = metrics.GetOrCreateCounter(`txpool_ingress_deprioritized{rule="selector"}`)
+)
+
+func ingressRejectedCounter(rule string) metrics.Counter {
+	if rule == ingressRuleTo {
+		return ingressRejectedByTo
+	}
+	return ingressRejectedBySelector
+}
+
+func ingressDeprioritizedCounter(rule string) metrics.Counter {
+	if rule == ingressRuleTo {
+		return ingressDeprioritizedByTo
+	}
+	return ingressDeprioritizedBySelector
+}
@@ -146,7 +146,10 @@ type TxPool struct {
 	p2pFetcher              *Fetch
 	p2pSender               *Send
 	newSlotsStreams         *NewSlotsStreams
+	demotions               demotionStreams // txns demoted from pending and base fee sub-pools
 	builderNotifyNewTxns    func()
+	propagation             *PropagationLog
+	ingress                 *ingressFilter // nil if ingress rules are not set
 	logger                  log.Logger
 	auths                   map[common.Address]*metaTxn // All accounts with a pooled authorization
 	blobHashToTxn           map[common.Hash]struct {
@@ -188,6 +191,10 @@ func New(
 	if err != nil {
 		return nil, err
 	}
+	propagation, err := NewPropagationLog(propagationLogSize)
+	if err != nil {
+		return nil, err
+	}
 
 	byNonce := &BySenderAndNonce{
 		tree:              btree.NewG[*metaTxn](32, SortByNonceLess),
@@ -227,6 +234,7 @@ func New(
 		blobSchedule:            blobSchedule,
 		feeCalculator:           options.feeCalculator,
 		builderNotifyNewTxns:    builderNotifyNewTxns,
+		propagation:             propagation,
 		newSlotsStreams:         newSlotsStreams,
 		logger:                  logger,
 		auths:                   map[common.Address]*metaTxn{},
@@ -236,6 +244,12 @@ func New(
 		}{},
 	}
 
+	if cfg.IngressRules != "" {
+		if res.ingress, err = newIngressFilter(cfg.IngressRules, logger); err != nil {
+			return nil, err
+		}
+	}
+
 	if shanghaiTime != nil {
 		if !shanghaiTime.IsUint64() {
 			return nil, errors.New("shanghaiTime overflow")
@@ -266,6 +280,7 @@ func New(
 	}
 
 	res.p2pFetcher = NewFetch(ctx, sentryClients, res, stateChangesClient, poolDB, chainID, logger, opts...)
+	res.p2pFetcher.propagation = propagation
 	res.p2pSender = NewSend(ctx, sentryClients, logger, opts...)
 	return res, nil
 }
@@ -344,19 +359,9 @@ func (p *TxPool) OnNewBlock(ctx context.Context, stateChanges *remote.StateChang
 		}
 	}
 
-	pendingBaseFee, baseFeeChanged := p.setBaseFee(baseFee)
-	// Update pendingBase for all pool queues and slices
-	if baseFeeChanged {
-		p.pending.best.pendingBaseFee = pendingBaseFee
-		p.pending.worst.pendingBaseFee = pendingBaseFee
-		p.baseFee.best.pendingBastFee = pendingBaseFee
-		p.baseFee.worst.pendingBaseFee = pendingBaseFee
-		p.queued.best.pendingBastFee = pendingBaseFee
-		p.queued.worst.pendingBaseFee = pendingBaseFee
-	}
-
-	pendingBlobFee := stateChanges.PendingBlobFeePerGas
-	p.setBlobFee(pendingBlobFee)
+	pendingBaseFee := p.setBaseFee(baseFee)
+	pendingBlobFee := p.setBlobFee(stateChanges.PendingBlobFeePerGas)
+	p.reprice(pendingBaseFee, pendingBlobFee)
 
 	oldGasLimit := p.blockGasLimit.Swap(stateChanges.BlockGasLimit)
 	if oldGasLimit != stateChanges.BlockGasLimit {
@@ -430,6 +435,9 @@ func (p *TxPool) OnNewBlock(ctx context.Context, stateChanges *remote.StateChang
 	if err = p.removeMined(p.all, minedTxns.Txns); err != nil {
 		return err
 	}
+	for _, txn := range minedTxns.Txns {
+		p.propagation.mined(txn.IDHash[:], block)
+	}
 
 	// remove auths from pool map
 	for _, mt := range minedTxns.Txns {
@@ -455,9 +463,6 @@ func (p *TxPool) OnNewBlock(ctx context.Context, stateChanges *remote.StateChang
 		return err
 	}
 
-	p.pending.EnforceWorstInvariants()
-	p.baseFee.EnforceInvariants()
-	p.queued.EnforceInvariants()
 	p.promote(pendingBaseFee, pendingBlobFee, &announcements, p.logger)
 	p.pending.EnforceBestInvariants()
 	p.promoted.Reset()
@@ -867,6 +872,37 @@ func toBlobs(_blobs [][]byte) []gokzg4844.BlobRef {
 	return blobs
 }
 
+// verifyBlobProofs verifies KZG proofs of all blob transactions of the batch at once, which costs about as much as
+// verifying one transaction. If the batch fails, the transactions are left to validateTx to find the invalid ones.
+func (p *TxPool) verifyBlobProofs(txns []*TxnSlot) {
+	if !p.isCancun() {
+		return
+	}
+	var blobTxns []*TxnSlot
+	var blobs []gokzg4844.BlobRef
+	var commitments []gokzg4844.KZGCommitment
+	var proofs []gokzg4844.KZGProof
+	for _, txn := range txns {
+		if txn.Type != BlobTxnType || len(txn.Blobs) == 0 ||
+			len(txn.Blobs) != len(txn.Commitments) || len(txn.Commitments) != len(txn.Proofs) {
+			continue
+		}
+		blobTxns = append(blobTxns, txn)
+		blobs = append(blobs, toBlobs(txn.Blobs)...)
+		commitments = append(commitments, txn.Commitments...)
+		proofs = append(proofs, txn.Proofs...)
+	}
+	if len(blobTxns) < 2 {
+		return
+	}
+	if err := libkzg.Verifier().VerifyBlobKZGProofBatch(blobs, commitments, proofs); err != nil {
+		return
+	}
+	for _, txn := range blobTxns {
+		txn.blobProofsVerified = true
+	}
+}
+
 func (p *TxPool) validateTx(txn *TxnSlot, isLocal bool, stateCache kvcache.CacheView) txpoolcfg.DiscardReason {
 	isShanghai := p.isShanghai() || p.isAgra()
 	if isShanghai && txn.Creation && txn.DataLen > fixedgas.MaxInitCodeSize {
@@ -901,10 +937,11 @@ func (p *TxPool) validateTx(txn *TxnSlot, isLocal bool, stateCache kvcache.Cache
 		}
 
 		// https://github.com/ethereum/consensus-specs/blob/017a8495f7671f5fff2075a9bfc9238c1a0982f8/specs/deneb/polynomial-commitments.md#verify_blob_kzg_proof_batch
-		kzgCtx := libkzg.Ctx()
-		err := kzgCtx.VerifyBlobKZGProofBatch(toBlobs(txn.Blobs), txn.Commitments, txn.Proofs)
-		if err != nil {
-			return txpoolcfg.UnmatchedBlobTxExt
+		if !txn.blobProofsVerified {
+			err := libkzg.Verifier().VerifyBlobKZGProofBatch(toBlobs(txn.Blobs), txn.Commitments, txn.Proofs)
+			if err != nil {
+				return txpoolcfg.UnmatchedBlobTxExt
+			}
 		}
 
 		if !isLocal && (p.all.blobCount(txn.SenderID)+uint64(len(txn.BlobHashes))) > p.cfg.BlobSlots {
@@ -1145,8 +1182,18 @@ func (p *TxPool) validateTxns(txns *TxnSlots, stateCache kvcache.CacheView) (rea
 		return reasons, goodTxns, err
 	}
 
+	p.verifyBlobProofs(txns.Txns)
 	goodCount := 0
 	for i, txn := range txns.Txns {
+		if p.ingress != nil {
+			switch p.ingress.check(txn, common.BytesToAddress(txns.Senders.At(i)), txns.IsLocal[i]) {
+			case ingressReject:
+				reasons[i] = txpoolcfg.IngressDenied
+				continue
+			case ingressDeprioritize:
+				txn.deprioritized = true
+			}
+		}
 		reason := p.validateTx(txn, txns.IsLocal[i], stateCache)
 		if reason == txpoolcfg.Success {
 			goodCount++
@@ -1262,6 +1309,7 @@ func (p *TxPool) AddLocalTxns(ctx context.Context, newTxns TxnSlots) ([]txpoolcf
 			if txn.Traced {
 				p.logger.Info(fmt.Sprintf("TX TRACING: AddLocalTxns promotes idHash=%x, senderId=%d", txn.IDHash, txn.SenderID))
 			}
+			p.propagation.seen(txn.IDHash[:], PropagationSourceLocal, nil)
 			p.promoted.Append(txn.Type, txn.Size, txn.IDHash[:])
 		}
 	}
@@ -1274,6 +1322,11 @@ func (p *TxPool) AddLocalTxns(ctx context.Context, newTxns TxnSlots) ([]txpoolcf
 	return reasons, nil
 }
 
+// PropagationLog returns how the recent transactions went through the pool, see erigon_traceTxPropagation
+func (p *TxPool) PropagationLog() *PropagationLog {
+	return p.propagation
+}
+
 func (p *TxPool) chainDB() (kv.RoDB, kvcache.Cache) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -1399,19 +1452,18 @@ func (p *TxPool) addTxnsOnNewBlock(blockNum uint64, cacheView kvcache.CacheView,
 	return announcements, nil
 }
 
-func (p *TxPool) setBaseFee(baseFee uint64) (uint64, bool) {
-	changed := false
+func (p *TxPool) setBaseFee(baseFee uint64) uint64 {
 	if baseFee > 0 {
-		changed = baseFee != p.pendingBaseFee.Load()
 		p.pendingBaseFee.Store(baseFee)
 	}
-	return p.pendingBaseFee.Load(), changed
+	return p.pendingBaseFee.Load()
 }
 
-func (p *TxPool) setBlobFee(blobFee uint64) {
+func (p *TxPool) setBlobFee(blobFee uint64) uint64 {
 	if blobFee > 0 {
 		p.pendingBlobFee.Store(blobFee)
 	}
+	return p.pendingBlobFee.Load()
 }
 
 func (p *TxPool) addLocked(mt *metaTxn, announcements *Announcements) txpoolcfg.DiscardReason {
@@ -1825,21 +1877,28 @@ func (p *TxPool) onSenderStateChange(senderID uint64, senderNonce uint64, sender
 }
 
 // promote reasserts invariants of the subpool and returns the list of transactions that ended up
-// being promoted to the pending or basefee pool, for re-broadcasting
+// being promoted to the pending or basefee pool, for re-broadcasting. Demoted transactions are sent
+// to demotion subscribers.
 func (p *TxPool) promote(pendingBaseFee uint64, pendingBlobFee uint64, announcements *Announcements, logger log.Logger) {
+	baseFee, blobFee := uint256.NewInt(pendingBaseFee), uint256.NewInt(pendingBlobFee)
+	var demoted []Demotion
+
 	// Demote worst transactions that do not qualify for pending sub pool anymore, to other sub pools, or discard
-	for worst := p.pending.Worst(); p.pending.Len() > 0 && (worst.subPool < BaseFeePoolBits || worst.minFeeCap.LtUint64(pendingBaseFee) || (worst.TxnSlot.Type == BlobTxnType && worst.TxnSlot.BlobFeeCap.LtUint64(pendingBlobFee))); worst = p.pending.Worst() {
+	for worst := p.pending.Worst(); p.pending.Len() > 0 && (worst.subPool < BaseFeePoolBits || !worst.enoughFeeCap(baseFee, blobFee)); worst = p.pending.Worst() {
+		reason := demotionReason(worst, baseFee, blobFee)
 		if worst.subPool >= BaseFeePoolBits {
+			demoted = append(demoted, p.newDemotion(worst, BaseFeeSubPool, reason))
 			tx := p.pending.PopWorst()
 			announcements.Append(tx.TxnSlot.Type, tx.TxnSlot.Size, tx.TxnSlot.IDHash[:])
 			p.baseFee.Add(tx, "demote-pending", logger)
 		} else {
+			demoted = append(demoted, p.newDemotion(worst, QueuedSubPool, reason))
 			p.queued.Add(p.pending.PopWorst(), "demote-pending", logger)
 		}
 	}
 
 	// Promote best transactions from base fee pool to pending pool while they qualify
-	for best := p.baseFee.Best(); p.baseFee.Len() > 0 && best.subPool >= BaseFeePoolBits && best.minFeeCap.CmpUint64(pendingBaseFee) >= 0 && (best.TxnSlot.Type != BlobTxnType || best.TxnSlot.BlobFeeCap.CmpUint64(pendingBlobFee) >= 0); best = p.baseFee.Best() {
+	for best := p.baseFee.Best(); p.baseFee.Len() > 0 && best.subPool >= BaseFeePoolBits && best.enoughFeeCap(baseFee, blobFee); best = p.baseFee.Best() {
 		tx := p.baseFee.PopBest()
 		announcements.Append(tx.TxnSlot.Type, tx.TxnSlot.Size, tx.TxnSlot.IDHash[:])
 		p.pending.Add(tx, logger)
@@ -1847,12 +1906,13 @@ func (p *TxPool) promote(pendingBaseFee uint64, pendingBlobFee uint64, announcem
 
 	// Demote worst transactions that do not qualify for base fee pool anymore, to queued sub pool, or discard
 	for worst := p.baseFee.Worst(); p.baseFee.Len() > 0 && worst.subPool < BaseFeePoolBits; worst = p.baseFee.Worst() {
+		demoted = append(demoted, p.newDemotion(worst, QueuedSubPool, DemotedByState))
 		p.queued.Add(p.baseFee.PopWorst(), "demote-base", logger)
 	}
 
 	// Promote best transactions from the queued pool to either pending or base fee pool, while they qualify
 	for best := p.queued.Best(); p.queued.Len() > 0 && best.subPool >= BaseFeePoolBits; best = p.queued.Best() {
-		if best.minFeeCap.Cmp(uint256.NewInt(pendingBaseFee)) >= 0 {
+		if best.enoughFeeCap(baseFee, blobFee) {
 			tx := p.queued.PopBest()
 			announcements.Append(tx.TxnSlot.Type, tx.TxnSlot.Size, tx.TxnSlot.IDHash[:])
 			p.pending.Add(tx, logger)
@@ -1860,6 +1920,7 @@ func (p *TxPool) promote(pendingBaseFee uint64, pendingBlobFee uint64, announcem
 			p.baseFee.Add(p.queued.PopBest(), "promote-queued", logger)
 		}
 	}
+	p.demotions.broadcast(demoted, logger)
 
 	// Discard worst transactions from the queued sub pool if they do not qualify
 	// <FUNCTIONALITY REMOVED>
@@ -1902,6 +1963,13 @@ func (p *TxPool) Run(ctx context.Context) error {
 	defer commitEvery.Stop()
 	logEvery := time.NewTicker(p.cfg.LogEvery)
 	defer logEvery.Stop()
+	var reloadIngressRules <-chan time.Time // nil if ingress rules are not set
+	if p.ingress != nil {
+		reloadEvery := time.NewTicker(ingressRulesReloadEvery)
+		defer reloadEvery.Stop()
+		reloadIngressRules = reloadEvery.C
+		defer p.ingress.close()
+	}
 
 	if err := p.start(ctx); err != nil {
 		p.logger.Error("[txpool] Failed to start", "err", err)
@@ -1919,6 +1987,8 @@ func (p *TxPool) Run(ctx context.Context) error {
 			return err
 		case <-logEvery.C:
 			p.logStats()
+		case <-reloadIngressRules:
+			p.ingress.reloadIfChanged()
 		case <-processRemoteTxnsEvery.C:
 			if !p.Started() {
 				continue
@@ -1979,6 +2049,7 @@ func (p *TxPool) Run(ctx context.Context) error {
 				var remoteTxnSizes []uint32
 				var remoteTxnHashes Hashes
 				var remoteTxnRlps [][]byte
+				var remoteBroadcastHashes Hashes
 				var broadcastHashes Hashes
 				slotsRlp := make([][]byte, 0, announcements.Len())
 
@@ -2018,6 +2089,7 @@ func (p *TxPool) Run(ctx context.Context) error {
 							// "Nodes MUST NOT automatically broadcast blob transactions to their peers" - EIP-4844
 							if t != BlobTxnType && len(slotRlp) < txMaxBroadcastSize {
 								remoteTxnRlps = append(remoteTxnRlps, slotRlp)
+								remoteBroadcastHashes = append(remoteBroadcastHashes, hash...)
 							}
 						}
 					}
@@ -2035,17 +2107,25 @@ func (p *TxPool) Run(ctx context.Context) error {
 				txnSentTo := p.p2pSender.BroadcastPooledTxns(localTxnRlps, localTxnsBroadcastMaxPeers)
 				for i, peer := range txnSentTo {
 					p.logger.Trace("Local txn broadcast", "txHash", hex.EncodeToString(broadcastHashes.At(i)), "to peer", peer)
+					p.propagation.sent(broadcastHashes.At(i), peer)
 				}
 				hashSentTo := p.p2pSender.AnnouncePooledTxns(localTxnTypes, localTxnSizes, localTxnHashes, localTxnsBroadcastMaxPeers*2)
 				for i := 0; i < localTxnHashes.Len(); i++ {
 					hash := localTxnHashes.At(i)
 					p.logger.Trace("Local txn announced", "txHash", hex.EncodeToString(hash), "to peer", hashSentTo[i], "baseFee", p.pendingBaseFee.Load())
+					p.propagation.sent(hash, hashSentTo[i])
 				}
 
 				// broadcast remote transactions
 				const remoteTxnsBroadcastMaxPeers uint64 = 3
-				p.p2pSender.BroadcastPooledTxns(remoteTxnRlps, remoteTxnsBroadcastMaxPeers)
-				p.p2pSender.AnnouncePooledTxns(remoteTxnTypes, remoteTxnSizes, remoteTxnHashes, remoteTxnsBroadcastMaxPeers*2)
+				txnSentTo = p.p2pSender.BroadcastPooledTxns(remoteTxnRlps, remoteTxnsBroadcastMaxPeers)
+				for i, peers := range txnSentTo {
+					p.propagation.sent(remoteBroadcastHashes.At(i), peers)
+				}
+				hashSentTo = p.p2pSender.AnnouncePooledTxns(remoteTxnTypes, remoteTxnSizes, remoteTxnHashes, remoteTxnsBroadcastMaxPeers*2)
+				for i, peers := range hashSentTo {
+					p.propagation.sent(remoteTxnHashes.At(i), peers)
+				}
 			}()
 		case <-syncToNewPeersEvery.C: // new peer
 			newPeers := p.recentlyConnectedPeers.GetAndClean()
@@ -2325,6 +2405,7 @@ func (p *TxPool) fromDB(ctx context.Context, tx kv.Tx, coreTx kv.Tx) error {
 	if err != nil {
 		return err
 	}
+	p.reprice(pendingBaseFee, pendingBlobFee)
 	if _, _, err := p.addTxns(p.lastSeenBlock.Load(), cacheView, p.senders, txns,
 		pendingBaseFee, pendingBlobFee, blockGasLimit, false, p.logger); err != nil {
 		return err
@@ -1617,6 +1617,96 @@ func TestGasLimitChanged(t *testing.T) {
 	}
 }
 
+func TestRepriceOnFeeChange(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+	ch := make(chan Announcements, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	coreDB, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	db := memdb.NewTestPoolDB(t)
+	cfg := txpoolcfg.DefaultConfig
+	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	pool, err := New(ctx, ch, db, coreDB, cfg, sendersCache, *u256.N1, common.Big0, nil, common.Big0, nil, nil, nil, nil, func() {}, nil, log.New(), WithFeeCalculator(nil))
+	assert.NoError(err)
+	require.True(pool != nil)
+	demotions, unsubscribe := pool.SubscribeDemotions()
+	defer unsubscribe()
+
+	blobSender, regularSender := [20]byte{1}, [20]byte{2}
+	h1 := gointerfaces.ConvertHashToH256([32]byte{})
+	change := &remote.StateChangeBatch{
+		PendingBlockBaseFee:  200_000,
+		BlockGasLimit:        math.MaxUint64,
+		PendingBlobFeePerGas: 100_000,
+		ChangeBatch: []*remote.StateChange{
+			{BlockHeight: 0, BlockHash: h1},
+		},
+	}
+	for _, addr := range [][20]byte{blobSender, regularSender} {
+		acc := accounts3.Account{Balance: *uint256.NewInt(1 * common.Ether), Incarnation: 1}
+		change.ChangeBatch[0].Changes = append(change.ChangeBatch[0].Changes, &remote.AccountChange{
+			Action:  remote.Action_UPSERT,
+			Address: gointerfaces.ConvertAddressToH160(addr),
+			Data:    accounts3.SerialiseV3(&acc),
+		})
+	}
+	require.NoError(pool.OnNewBlock(ctx, change, TxnSlots{}, TxnSlots{}, TxnSlots{}))
+	change.ChangeBatch[0].Changes = nil
+
+	// blob txn pays more for gas than the regular one: it's not the worst in pending sub-pool
+	blobTxn := makeBlobTxn()
+	blobTxn.Nonce = 0
+	blobTxn.FeeCap = *uint256.NewInt(400_000)
+	regularTxn := &TxnSlot{Tip: *uint256.NewInt(100_000), FeeCap: *uint256.NewInt(250_000), Gas: 100_000}
+	regularTxn.IDHash[0] = 1
+	var txnSlots TxnSlots
+	txnSlots.Append(&blobTxn, blobSender[:], true)
+	txnSlots.Append(regularTxn, regularSender[:], true)
+	reasons, err := pool.AddLocalTxns(ctx, txnSlots)
+	require.NoError(err)
+	for _, reason := range reasons {
+		require.Equal(txpoolcfg.Success, reason, reason.String())
+	}
+	subPool := func(txn *TxnSlot) SubPoolType {
+		return pool.byHash[string(txn.IDHash[:])].currentSubPool
+	}
+	require.Equal(PendingSubPool, subPool(&blobTxn))
+	require.Equal(PendingSubPool, subPool(regularTxn))
+
+	// blob fee spike demotes the blob txn
+	change.PendingBlobFeePerGas = 300_000
+	require.NoError(pool.OnNewBlock(ctx, change, TxnSlots{}, TxnSlots{}, TxnSlots{}))
+	require.Equal(BaseFeeSubPool, subPool(&blobTxn))
+	require.Equal(PendingSubPool, subPool(regularTxn))
+	require.Equal([]Demotion{{
+		IDHash: blobTxn.IDHash,
+		Sender: blobSender,
+		From:   PendingSubPool,
+		To:     BaseFeeSubPool,
+		Reason: DemotedByBlobFee,
+	}}, <-demotions)
+
+	// and it's promoted back when the blob fee drops
+	change.PendingBlobFeePerGas = 100_000
+	require.NoError(pool.OnNewBlock(ctx, change, TxnSlots{}, TxnSlots{}, TxnSlots{}))
+	require.Equal(PendingSubPool, subPool(&blobTxn))
+
+	// base fee spike demotes the regular txn
+	change.PendingBlockBaseFee = 300_000
+	require.NoError(pool.OnNewBlock(ctx, change, TxnSlots{}, TxnSlots{}, TxnSlots{}))
+	require.Equal(PendingSubPool, subPool(&blobTxn))
+	require.Equal(BaseFeeSubPool, subPool(regularTxn))
+	demoted := <-demotions
+	require.Len(demoted, 1)
+	require.Equal(regularTxn.IDHash, [32]byte(demoted[0].IDHash))
+	require.Equal(DemotedByBaseFee, demoted[0].Reason)
+
+	unsubscribe()
+	unsubscribe() // idempotent
+	_, ok := <-demotions
+	require.False(ok)
+}
+
 // sender - immutable structure which stores only nonce and balance of account
 type sender struct {
 	balance uint256.Int
@@ -1626,3 +1716,25 @@ type sender struct {
 func newSender(nonce uint64, balance uint256.Int) *sender {
 	return &sender{nonce: nonce, balance: balance}
 }
+
+func TestVerifyBlobProofsBatch(t *testing.T) {
+	coreDB, _ := temporaltest.NewTestDB(t, datadir.New(t.TempDir()))
+	db := memdb.NewTestPoolDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	sendersCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	pool, err := New(ctx, make(chan Announcements, 5), db, coreDB, txpoolcfg.DefaultConfig, sendersCache, *u256.N1, common.Big0, nil, common.Big0, nil, nil, nil, nil, func() {}, nil, log.New(), WithFeeCalculator(nil))
+	require.NoError(t, err)
+
+	txn1, txn2 := makeBlobTxn(), makeBlobTxn()
+	pool.verifyBlobProofs([]*TxnSlot{&txn1, &txn2})
+	require.True(t, txn1.blobProofsVerified)
+	require.True(t, txn2.blobProofsVerified)
+
+	// a wrong proof fails the batch, every transaction is verified on its own then
+	txn3, txn4 := makeBlobTxn(), makeBlobTxn()
+	txn4.Proofs[0], txn4.Proofs[1] = txn4.Proofs[1], txn4.Proofs[0]
+	pool.verifyBlobProofs([]*TxnSlot{&txn3, &txn4})
+	require.False(t, txn3.blobProofsVerified)
+	require.False(t, txn4.blobProofsVerified)
+}
@@ -19,9 +19,13 @@ package txpool
 import (
 	"errors"
 	"fmt"
+	"io"
+
+	"github.com/erigontech/secp256k1"
 
 	"github.com/erigontech/erigon-lib/common"
 	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/crypto/sigrecover"
 	"github.com/erigontech/erigon-lib/rlp"
 )
 
@@ -170,11 +174,53 @@ func EncodeTransactions(txnsRlp [][]byte, encodeBuf []byte) []byte {
 	return encodeBuf
 }
 
+// deferredSenders are signatures of the parsed transactions, in order, whose senders are recovered in one batch
+// offloaded to the signature recovery accelerator (see sigrecover)
+type deferredSenders struct {
+	hashes [][32]byte
+	sigs   [][65]byte
+}
+
+// deferSenders makes ParseTransaction collect the signatures instead of recovering the senders,
+// if a signature recovery accelerator is selected
+func (ctx *TxnParseContext) deferSenders() bool {
+	if !ctx.withSender || !sigrecover.Enabled() {
+		return false
+	}
+	ctx.deferredSenders = &deferredSenders{}
+	return true
+}
+
+// recoverDeferredSenders recovers the senders of all parsed transactions, the i-th collected signature is of the i-th transaction
+func (ctx *TxnParseContext) recoverDeferredSenders(txnSlots *TxnSlots) error {
+	d := ctx.deferredSenders
+	ctx.deferredSenders = nil
+	if len(d.hashes) != len(txnSlots.Txns) {
+		return fmt.Errorf("%w: %d signatures of %d transactions", ErrParseTxn, len(d.hashes), len(txnSlots.Txns))
+	}
+	pubs, errs := sigrecover.Recover(secp256k1.DefaultContext, d.hashes, d.sigs)
+	for i := range pubs {
+		if errs[i] != nil {
+			return fmt.Errorf("%w: recovering sender from signature: %s", ErrParseTxn, errs[i]) //nolint
+		}
+		ctx.Keccak2.Reset()
+		if _, err := ctx.Keccak2.Write(pubs[i][1:]); err != nil {
+			return fmt.Errorf("%w: computing sender from public key: %s", ErrParseTxn, err) //nolint
+		}
+		_, _ = ctx.Keccak2.(io.Reader).Read(ctx.buf[:32])
+		copy(txnSlots.Senders.At(i), ctx.buf[12:32])
+	}
+	return nil
+}
+
 func ParseTransactions(payload []byte, pos int, ctx *TxnParseContext, txnSlots *TxnSlots, validateHash func([]byte) error) (newPos int, err error) {
 	pos, _, err = rlp.ParseList(payload, pos)
 	if err != nil {
 		return 0, err
 	}
+	if ctx.deferSenders() {
+		defer func() { ctx.deferredSenders = nil }()
+	}
 
 	for i := 0; pos < len(payload); i++ {
 		txnSlots.Resize(uint(i + 1))
@@ -189,6 +235,11 @@ func ParseTransactions(payload []byte, pos int, ctx *TxnParseContext, txnSlots *
 			return 0, err
 		}
 	}
+	if ctx.deferredSenders != nil {
+		if err := ctx.recoverDeferredSenders(txnSlots); err != nil {
+			return 0, err
+		}
+	}
 	return pos, nil
 }
 
@@ -205,6 +256,9 @@ func ParsePooledTransactions66(payload []byte, pos int, ctx *TxnParseContext, tx
 	if err != nil {
 		return requestID, 0, err
 	}
+	if ctx.deferSenders() {
+		defer func() { ctx.deferredSenders = nil }()
+	}
 
 	for i := 0; p < len(payload); i++ {
 		txnSlots.Resize(uint(i + 1))
@@ -219,5 +273,10 @@ func ParsePooledTransactions66(payload []byte, pos int, ctx *TxnParseContext, tx
 			return requestID, 0, err
 		}
 	}
+	if ctx.deferredSenders != nil {
+		if err := ctx.recoverDeferredSenders(txnSlots); err != nil {
+			return requestID, 0, err
+		}
+	}
 	return requestID, p, nil
 }
@@ -74,7 +74,8 @@ type TxnParseContext struct {
 	Sig             [65]byte
 	Sighash         [length.Hash]byte
 	withSender      bool
-	allowPreEip2s   bool // Allow s > secp256k1n/2; see EIP-2
+	deferredSenders *deferredSenders // set while ParseTransactions recovers senders in one batch
+	allowPreEip2s   bool             // Allow s > secp256k1n/2; see EIP-2
 	chainIDRequired bool
 }
 
@@ -392,8 +393,10 @@ func (ctx *TxnParseContext) parseTransactionBody(payload []byte, pos, p0 int, sl
 		return 0, fmt.Errorf("%w: unexpected length of 'to' field: %d", ErrParseTxn, dataLen)
 	}
 
-	// Only note if To field is empty or not
 	slot.Creation = dataLen == 0
+	if !slot.Creation {
+		copy(slot.To[:], payload[dataPos:dataPos+dataLen])
+	}
 	p = dataPos + dataLen
 	// Next follows value
 	p, err = rlp.ParseU256(payload, p, &slot.Value)
@@ -406,6 +409,7 @@ func (ctx *TxnParseContext) parseTransactionBody(payload []byte, pos, p0 int, sl
 		return 0, fmt.Errorf("%w: data len: %s", ErrParseTxn, err) //nolint
 	}
 	slot.DataLen = dataLen
+	copy(slot.Selector[:], payload[dataPos:dataPos+min(dataLen, len(slot.Selector))])
 
 	// Zero and non-zero bytes are priced differently
 	slot.DataNonZeroLen = 0
@@ -642,6 +646,11 @@ func (ctx *TxnParseContext) parseTransactionBody(payload []byte, pos, p0 int, sl
 	binary.BigEndian.PutUint64(ctx.Sig[48:56], ctx.S[1])
 	binary.BigEndian.PutUint64(ctx.Sig[56:64], ctx.S[0])
 	ctx.Sig[64] = vByte
+	if ctx.deferredSenders != nil {
+		ctx.deferredSenders.hashes = append(ctx.deferredSenders.hashes, ctx.Sighash)
+		ctx.deferredSenders.sigs = append(ctx.deferredSenders.sigs, ctx.Sig)
+		return p, nil
+	}
 	// recover sender
 	if _, err = secp256k1.RecoverPubkeyWithContext(secp256k1.DefaultContext, ctx.Sighash[:], ctx.Sig[:], ctx.buf[:0]); err != nil {
 		return 0, fmt.Errorf("%w: recovering sender from signature: %s", ErrParseTxn, err) //nolint
@@ -671,14 +680,16 @@ type TxnSlot struct {
 	Nonce               uint64      // Nonce of the transaction
 	DataLen             int         // Length of transaction's data (for calculation of intrinsic gas)
 	DataNonZeroLen      int
-	AccessListAddrCount int      // Number of addresses in the access list
-	AccessListStorCount int      // Number of storage keys in the access list
-	Gas                 uint64   // Gas limit of the transaction
-	IDHash              [32]byte // Transaction hash for the purposes of using it as a transaction Id
-	Traced              bool     // Whether transaction needs to be traced throughout transaction pool code and generate debug printing
-	Creation            bool     // Set to true if "To" field of the transaction is not set
-	Type                byte     // Transaction type
-	Size                uint32   // Size of the payload (without the RLP string envelope for typed transactions)
+	AccessListAddrCount int            // Number of addresses in the access list
+	AccessListStorCount int            // Number of storage keys in the access list
+	Gas                 uint64         // Gas limit of the transaction
+	IDHash              [32]byte       // Transaction hash for the purposes of using it as a transaction Id
+	Traced              bool           // Whether transaction needs to be traced throughout transaction pool code and generate debug printing
+	Creation            bool           // Set to true if "To" field of the transaction is not set
+	To                  common.Address // Zero if Creation
+	Selector            [4]byte        // First 4 bytes of the data, zero-padded if the data is shorter
+	Type                byte           // Transaction type
+	Size                uint32         // Size of the payload (without the RLP string envelope for typed transactions)
 	ChainID             uint256.Int
 
 	// EIP-4844: Shard Blob Transactions
@@ -687,6 +698,10 @@ type TxnSlot struct {
 	Blobs       [][]byte
 	Commitments []gokzg4844.KZGCommitment
 	Proofs      []gokzg4844.KZGProof
+	// blobProofsVerified is set when the proofs were verified together with other transactions, see verifyBlobProofs
+	blobProofsVerified bool
+	// deprioritized is set when the transaction matched a "deprioritize" ingress rule, see ingressFilter
+	deprioritized bool
 
 	// EIP-7702: set code tx
 	Authorizations []Signature
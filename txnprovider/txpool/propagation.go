@@ -0,0 +1,142 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package txpool
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+)
+
+// propagationLogSize - amount of transactions which propagation is remembered
+const propagationLogSize = 50_000
+
+const (
+	PropagationSourceAnnouncement = "announcement" // hash announced by the peer, then requested from it
+	PropagationSourceBroadcast    = "broadcast"    // full transaction sent by the peer unrequested
+	PropagationSourceLocal        = "local"        // submitted to this node
+)
+
+// TxnPropagation is how a transaction went through the pool, the result of erigon_traceTxPropagation
+type TxnPropagation struct {
+	Hash      common.Hash `json:"hash"`
+	Source    string      `json:"source"`              // PropagationSourceAnnouncement, ...
+	FirstPeer string      `json:"firstPeer,omitempty"` // peer which the transaction was first seen from, empty if local
+	FirstSeen time.Time   `json:"firstSeen"`
+
+	BroadcastAt    *time.Time `json:"broadcastAt,omitempty"` // first time the transaction or its hash was sent onward
+	BroadcastPeers int        `json:"broadcastPeers"`        // amount of peers it was sent to, summed over all broadcasts
+
+	MinedAt    *time.Time `json:"minedAt,omitempty"`
+	MinedBlock *uint64    `json:"minedBlock,omitempty"`
+}
+
+// PropagationLog remembers the propagation of the last propagationLogSize pool transactions.
+// The methods are no-op on nil log.
+type PropagationLog struct {
+	mu   sync.Mutex
+	txns *simplelru.LRU[string, *TxnPropagation]
+}
+
+func NewPropagationLog(size int) (*PropagationLog, error) {
+	txns, err := simplelru.NewLRU[string, *TxnPropagation](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PropagationLog{txns: txns}, nil
+}
+
+// seen records the first appearance of the transactions, later ones are ignored. peer is nil for local transactions.
+func (l *PropagationLog) seen(hashes Hashes, source string, peer PeerID) {
+	if l == nil || len(hashes) == 0 {
+		return
+	}
+	var peerHex string
+	if peer != nil {
+		id := gointerfaces.ConvertH512ToHash(peer)
+		peerHex = hex.EncodeToString(id[:])
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i < hashes.Len(); i++ {
+		hash := hashes.At(i)
+		if l.txns.Contains(string(hash)) {
+			continue
+		}
+		l.txns.Add(string(hash), &TxnPropagation{Hash: common.BytesToHash(hash), Source: source, FirstPeer: peerHex, FirstSeen: now})
+	}
+}
+
+// sent records that the transaction was sent onward to the amount of peers
+func (l *PropagationLog) sent(hash []byte, peers int) {
+	if l == nil || peers == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.txns.Peek(string(hash))
+	if !ok {
+		return
+	}
+	if p.BroadcastAt == nil {
+		now := time.Now()
+		p.BroadcastAt = &now
+	}
+	p.BroadcastPeers += peers
+}
+
+func (l *PropagationLog) mined(hash []byte, blockNum uint64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.txns.Peek(string(hash))
+	if !ok || p.MinedBlock != nil {
+		return
+	}
+	now := time.Now()
+	p.MinedAt, p.MinedBlock = &now, &blockNum
+}
+
+// FirstSeen returns when the transaction was first seen by the pool, false if it's not known
+func (l *PropagationLog) FirstSeen(hash common.Hash) (time.Time, bool) {
+	if p := l.Get(hash); p != nil {
+		return p.FirstSeen, true
+	}
+	return time.Time{}, false
+}
+
+// Get returns a copy of the propagation of the transaction, nil if it's not known
+func (l *PropagationLog) Get(hash common.Hash) *TxnPropagation {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.txns.Peek(string(hash[:]))
+	if !ok {
+		return nil
+	}
+	res := *p
+	return &res
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+package txpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/gointerfaces"
+)
+
+func TestPropagationLog(t *testing.T) {
+	l, err := NewPropagationLog(2)
+	require.NoError(t, err)
+	h1, h2, h3 := common.Hash{1}, common.Hash{2}, common.Hash{3}
+	peer := gointerfaces.ConvertHashToH512([64]byte{0xaa})
+
+	l.seen(append(h1.Bytes(), h2.Bytes()...), PropagationSourceAnnouncement, peer)
+	l.seen(h1.Bytes(), PropagationSourceBroadcast, nil) // first appearance is kept
+	l.sent(h1.Bytes(), 3)
+	l.sent(h1.Bytes(), 2)
+	l.sent(h3.Bytes(), 1) // not seen
+	l.mined(h1.Bytes(), 10)
+	l.mined(h1.Bytes(), 11)
+
+	p := l.Get(h1)
+	require.NotNil(t, p)
+	require.Equal(t, PropagationSourceAnnouncement, p.Source)
+	require.Equal(t, "aa", p.FirstPeer[:2])
+	require.Equal(t, 5, p.BroadcastPeers)
+	require.NotNil(t, p.BroadcastAt)
+	require.Equal(t, uint64(10), *p.MinedBlock)
+
+	p = l.Get(h2)
+	require.NotNil(t, p)
+	require.Nil(t, p.BroadcastAt)
+	require.Nil(t, p.MinedBlock)
+	require.Nil(t, l.Get(h3))
+
+	// the oldest is evicted
+	l.seen(h3.Bytes(), PropagationSourceLocal, nil)
+	require.Nil(t, l.Get(h1))
+	require.Equal(t, "", l.Get(h3).FirstPeer)
+
+	var disabled *PropagationLog
+	disabled.seen(h1.Bytes(), PropagationSourceLocal, nil)
+	require.Nil(t, disabled.Get(h1))
+}
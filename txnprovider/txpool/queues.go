@@ -23,6 +23,7 @@ import "github.com/holiman/uint256"
 type bestSlice struct {
 	ms             []*metaTxn
 	pendingBaseFee uint64
+	pendingBlobFee uint64
 }
 
 func (s *bestSlice) Len() int {
@@ -35,7 +36,7 @@ func (s *bestSlice) Swap(i, j int) {
 }
 
 func (s *bestSlice) Less(i, j int) bool {
-	return s.ms[i].better(s.ms[j], *uint256.NewInt(s.pendingBaseFee))
+	return s.ms[i].better(s.ms[j], *uint256.NewInt(s.pendingBaseFee), *uint256.NewInt(s.pendingBlobFee))
 }
 
 func (s *bestSlice) UnsafeRemove(i *metaTxn) {
@@ -53,6 +54,7 @@ func (s *bestSlice) UnsafeAdd(i *metaTxn) {
 type BestQueue struct {
 	ms             []*metaTxn
 	pendingBastFee uint64
+	pendingBlobFee uint64
 }
 
 func (p *BestQueue) Len() int {
@@ -60,7 +62,7 @@ func (p *BestQueue) Len() int {
 }
 
 func (p *BestQueue) Less(i, j int) bool {
-	return p.ms[i].better(p.ms[j], *uint256.NewInt(p.pendingBastFee))
+	return p.ms[i].better(p.ms[j], *uint256.NewInt(p.pendingBastFee), *uint256.NewInt(p.pendingBlobFee))
 }
 
 func (p *BestQueue) Swap(i, j int) {
@@ -90,6 +92,7 @@ func (p *BestQueue) Pop() interface{} {
 type WorstQueue struct {
 	ms             []*metaTxn
 	pendingBaseFee uint64
+	pendingBlobFee uint64
 }
 
 func (p *WorstQueue) Len() int {
@@ -97,7 +100,7 @@ func (p *WorstQueue) Len() int {
 }
 
 func (p *WorstQueue) Less(i, j int) bool {
-	return p.ms[i].worse(p.ms[j], *uint256.NewInt(p.pendingBaseFee))
+	return p.ms[i].worse(p.ms[j], *uint256.NewInt(p.pendingBaseFee), *uint256.NewInt(p.pendingBlobFee))
 }
 
 func (p *WorstQueue) Swap(i, j int) {
@@ -0,0 +1,181 @@
+// Copyright 2024 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+)
+
+// DemotionReason is why the txn doesn't qualify for its sub-pool anymore
+type DemotionReason uint8
+
+const (
+	DemotedByBaseFee DemotionReason = 1 // fee cap is below the base fee of the pending block
+	DemotedByBlobFee DemotionReason = 2 // blob fee cap is below the blob fee of the pending block
+	DemotedByState   DemotionReason = 3 // nonce gap, not enough balance or too much gas
+)
+
+func (r DemotionReason) String() string {
+	switch r {
+	case DemotedByBaseFee:
+		return "base fee"
+	case DemotedByBlobFee:
+		return "blob fee"
+	case DemotedByState:
+		return "sender state"
+	}
+	return fmt.Sprintf("Unknown:%d", r)
+}
+
+// Demotion is an event about the transaction moved from the pending or base fee sub-pool to a lower sub-pool
+type Demotion struct {
+	IDHash common.Hash
+	Sender common.Address
+	Nonce  uint64
+	From   SubPoolType
+	To     SubPoolType
+	Reason DemotionReason
+}
+
+// demotionsBuffer - amount of demotion batches buffered for a slow subscriber, next batches are dropped
+const demotionsBuffer = 16
+
+// demotionStreams - it's safe to use this class as non-pointer
+type demotionStreams struct {
+	chans map[uint]chan []Demotion
+	mu    sync.Mutex
+	id    uint
+}
+
+func (s *demotionStreams) add() (<-chan []Demotion, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chans == nil {
+		s.chans = make(map[uint]chan []Demotion)
+	}
+	s.id++
+	id, ch := s.id, make(chan []Demotion, demotionsBuffer)
+	s.chans[id] = ch
+	return ch, func() { s.remove(id) }
+}
+
+func (s *demotionStreams) broadcast(demotions []Demotion, logger log.Logger) {
+	if len(demotions) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range s.chans {
+		select {
+		case ch <- demotions:
+		default:
+			logger.Debug("[txpool] demotions subscriber is too slow, batch dropped", "size", len(demotions))
+		}
+	}
+}
+
+func (s *demotionStreams) remove(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch, ok := s.chans[id]
+	if !ok { // double-unsubscribe support
+		return
+	}
+	delete(s.chans, id)
+	close(ch)
+}
+
+// SubscribeDemotions returns a channel of transactions demoted from the pending or base fee sub-pools, one batch per
+// pool update: new block, base fee or blob fee change, new transactions. Batches are dropped if the subscriber
+// doesn't keep up. Returned func unsubscribes and closes the channel.
+func (p *TxPool) SubscribeDemotions() (<-chan []Demotion, func()) {
+	return p.demotions.add()
+}
+
+// demotionReason returns why the txn doesn't qualify for its current sub-pool anymore
+func demotionReason(mt *metaTxn, pendingBaseFee, pendingBlobFee *uint256.Int) DemotionReason {
+	switch {
+	case mt.subPool < BaseFeePoolBits:
+		return DemotedByState
+	case mt.minFeeCap.Lt(pendingBaseFee):
+		return DemotedByBaseFee
+	default:
+		return DemotedByBlobFee
+	}
+}
+
+func (p *TxPool) newDemotion(mt *metaTxn, to SubPoolType, reason DemotionReason) Demotion {
+	addr, _ := p.senders.getAddr(mt.TxnSlot.SenderID)
+	return Demotion{IDHash: mt.TxnSlot.IDHash, Sender: addr, Nonce: mt.TxnSlot.Nonce, From: mt.currentSubPool, To: to, Reason: reason}
+}
+
+// reprice switches sub-pools ordering to new pending block fees. Instead of rebuilding all queues, only txns whose
+// fee adequacy flipped are re-inserted: relative order of other txns in heaps doesn't depend on fees. Pending best
+// slice must be re-sorted by the caller anyway, because effective tips depend on the base fee.
+func (p *TxPool) reprice(pendingBaseFee, pendingBlobFee uint64) {
+	oldBaseFee, oldBlobFee := uint256.NewInt(p.pending.worst.pendingBaseFee), uint256.NewInt(p.pending.worst.pendingBlobFee)
+	newBaseFee, newBlobFee := uint256.NewInt(pendingBaseFee), uint256.NewInt(pendingBlobFee)
+	if oldBaseFee.Eq(newBaseFee) && oldBlobFee.Eq(newBlobFee) {
+		return
+	}
+	defer repriceTimer.ObserveDuration(time.Now())
+
+	flipped := func(ms []*metaTxn) (res []*metaTxn) {
+		for _, mt := range ms {
+			if mt.enoughFeeCap(oldBaseFee, oldBlobFee) != mt.enoughFeeCap(newBaseFee, newBlobFee) {
+				res = append(res, mt)
+			}
+		}
+		return res
+	}
+	pending, baseFee, queued := flipped(p.pending.worst.ms), flipped(p.baseFee.worst.ms), flipped(p.queued.worst.ms)
+	for _, mt := range pending {
+		p.pending.Remove(mt, "reprice", p.logger)
+	}
+	for _, mt := range baseFee {
+		p.baseFee.Remove(mt, "reprice", p.logger)
+	}
+	for _, mt := range queued {
+		p.queued.Remove(mt, "reprice", p.logger)
+	}
+
+	p.pending.best.pendingBaseFee, p.pending.best.pendingBlobFee = pendingBaseFee, pendingBlobFee
+	p.pending.worst.pendingBaseFee, p.pending.worst.pendingBlobFee = pendingBaseFee, pendingBlobFee
+	p.baseFee.best.pendingBastFee, p.baseFee.best.pendingBlobFee = pendingBaseFee, pendingBlobFee
+	p.baseFee.worst.pendingBaseFee, p.baseFee.worst.pendingBlobFee = pendingBaseFee, pendingBlobFee
+	p.queued.best.pendingBastFee, p.queued.best.pendingBlobFee = pendingBaseFee, pendingBlobFee
+	p.queued.worst.pendingBaseFee, p.queued.worst.pendingBlobFee = pendingBaseFee, pendingBlobFee
+
+	for _, mt := range pending {
+		p.pending.Add(mt, p.logger)
+	}
+	for _, mt := range baseFee {
+		p.baseFee.Add(mt, "reprice", p.logger)
+	}
+	for _, mt := range queued {
+		p.queued.Add(mt, "reprice", p.logger)
+	}
+	p.logger.Trace("[txpool] reprice", "pendingBaseFee", pendingBaseFee, "pendingBlobFee", pendingBlobFee,
+		"pending", len(pending), "baseFee", len(baseFee), "queued", len(queued))
+}
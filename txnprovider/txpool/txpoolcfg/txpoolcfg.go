@@ -30,19 +30,22 @@ import (
 const BorDefaultTxPoolPriceLimit = 25 * common.GWei
 
 type Config struct {
-	Disable             bool
-	DBDir               string
-	TracedSenders       []string // List of senders for which txn pool should print out debugging info
-	PendingSubPoolLimit int
-	BaseFeeSubPoolLimit int
-	QueuedSubPoolLimit  int
-	MinFeeCap           uint64
-	AccountSlots        uint64 // Number of executable transaction slots guaranteed per account
-	BlobSlots           uint64 // Total number of blobs (not txns) allowed per account
-	TotalBlobPoolLimit  uint64 // Total number of blobs (not txns) allowed within the txpool
-	PriceBump           uint64 // Price bump percentage to replace an already existing transaction
-	BlobPriceBump       uint64 //Price bump percentage to replace an existing 4844 blob txn (type-3)
-	OverridePragueTime  *big.Int
+	Disable              bool
+	DBDir                string
+	TracedSenders        []string // List of senders for which txn pool should print out debugging info
+	PendingSubPoolLimit  int
+	BaseFeeSubPoolLimit  int
+	QueuedSubPoolLimit   int
+	MinFeeCap            uint64
+	AccountSlots         uint64 // Number of executable transaction slots guaranteed per account
+	BlobSlots            uint64 // Total number of blobs (not txns) allowed per account
+	TotalBlobPoolLimit   uint64 // Total number of blobs (not txns) allowed within the txpool
+	PriceBump            uint64 // Price bump percentage to replace an already existing transaction
+	BlobPriceBump        uint64 //Price bump percentage to replace an existing 4844 blob txn (type-3)
+	OverrideShanghaiTime *big.Int
+	OverrideCancunTime   *big.Int
+	OverridePragueTime   *big.Int
+	OverrideOsakaTime    *big.Int
 
 	// regular batch tasks processing
 	SyncToNewPeersEvery    time.Duration
@@ -57,6 +60,8 @@ type Config struct {
 	MdbxWriteMap    bool
 
 	NoGossip bool // this mode doesn't broadcast any txns, and if receive remote-txn - skip it
+
+	IngressRules string // JSON file of address and selector rules (see txpool.IngressRules), reloaded on change
 }
 
 var DefaultConfig = Config{
@@ -118,6 +123,7 @@ const (
 	NoAuthorizations     DiscardReason = 32 // EIP-7702 transactions with an empty authorization list are invalid
 	GasLimitTooHigh      DiscardReason = 33 // Gas limit is too high
 	ErrAuthorityReserved DiscardReason = 34 // EIP-7702 transaction with authority already reserved
+	IngressDenied        DiscardReason = 35 // Rejected by the operator's ingress rules, see Config.IngressRules
 )
 
 func (r DiscardReason) String() string {
@@ -192,6 +198,8 @@ func (r DiscardReason) String() string {
 		return "blob_versioned_hashes, blobs, commitments and proofs must have equal number"
 	case ErrAuthorityReserved:
 		return "EIP-7702 transaction with authority already reserved"
+	case IngressDenied:
+		return "denied by txpool ingress rules"
 	default:
 		panic(fmt.Sprintf("discard reason: %d", r))
 	}
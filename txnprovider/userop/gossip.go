@@ -0,0 +1,395 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package userop
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/holiman/uint256"
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	libp2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/params"
+)
+
+const (
+	// maxOpsPerMessage is MAX_OPS_PER_REQUEST of the mempool p2p spec
+	maxOpsPerMessage = 4096
+	// maxValidatedOpsPerMessage bounds the simulations a single message may cause, larger messages are ignored
+	maxValidatedOpsPerMessage = 16
+	// peerOpsPerSecond is the rate of gossiped operations validated for a peer, operations over it are ignored
+	peerOpsPerSecond = 4
+	maxTrackedPeers  = 1024
+
+	validatorConcurrency = 4
+	validatorTimeout     = maxValidatedOpsPerMessage * simulationTimeout
+)
+
+// Topic is the gossipsub topic of the mempool, see
+// https://github.com/eth-infinitism/bundler-spec/blob/main/p2p-specs/p2p-interface.md
+func Topic(mempoolId string) string {
+	return "/account_abstraction/" + mempoolId + "/user_operations_with_entrypoint/ssz_snappy"
+}
+
+// gossip exchanges user operations with the other bundlers of the mempool
+type gossip struct {
+	pool   *Pool
+	logger log.Logger
+	host   host.Host
+	pubSub *pubsub.PubSub
+	topic  *pubsub.Topic
+
+	peerLimits *lru.Cache[peer.ID, *rate.Limiter]
+}
+
+func newGossip(ctx context.Context, pool *Pool, logger log.Logger) (*gossip, error) {
+	config := pool.config.P2pConfig
+	listenAddr, err := multiaddr.NewMultiaddr("/ip4/0.0.0.0/tcp/" + strconv.FormatUint(config.ListenPort, 10))
+	if err != nil {
+		return nil, err
+	}
+	var privKey libp2pcrypto.PrivKey
+	if config.PrivateKey != nil {
+		privKey, err = libp2pcrypto.UnmarshalSecp256k1PrivateKey(config.PrivateKey.D.Bytes())
+	} else {
+		privKey, _, err = libp2pcrypto.GenerateSecp256k1Key(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p2pHost, err := libp2p.New(
+		libp2p.Identity(privKey),
+		libp2p.ListenAddrs(listenAddr),
+		libp2p.UserAgent("erigon/userop/"+params.VersionWithCommit(params.GitCommit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("[userop] p2p host initialised", "addr", listenAddr, "id", p2pHost.ID())
+
+	peerLimits, err := lru.New[peer.ID, *rate.Limiter](maxTrackedPeers)
+	if err != nil {
+		p2pHost.Close()
+		return nil, err
+	}
+	g := &gossip{pool: pool, logger: logger, host: p2pHost, peerLimits: peerLimits}
+	if err := g.init(ctx); err != nil {
+		p2pHost.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *gossip) init(ctx context.Context) error {
+	var err error
+	if g.pubSub, err = pubsub.NewGossipSub(ctx, g.host); err != nil {
+		return err
+	}
+	nodes, err := g.pool.config.BootstrapNodesAddrInfo()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := g.host.Connect(ctx, node); err != nil {
+			g.logger.Warn("[userop] failed to connect to bootstrap node", "node", node, "err", err)
+		}
+	}
+	topic := Topic(g.pool.config.MempoolId)
+	err = g.pubSub.RegisterTopicValidator(topic, g.validate,
+		pubsub.WithValidatorConcurrency(validatorConcurrency), pubsub.WithValidatorTimeout(validatorTimeout))
+	if err != nil {
+		return err
+	}
+	g.topic, err = g.pubSub.Join(topic)
+	return err
+}
+
+func (g *gossip) run(ctx context.Context) error {
+	defer g.host.Close()
+	defer g.topic.Close()
+	sub, err := g.topic.Subscribe()
+	if err != nil {
+		return err
+	}
+	defer sub.Cancel()
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		// the operations are added to the pool by the validator
+		for {
+			if _, err := sub.Next(ctx); err != nil {
+				return err
+			}
+		}
+	})
+	eg.Go(func() error {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				g.logger.Info("[userop] mempool peer count", "peers", len(g.topic.ListPeers()), "ops", g.pool.Len())
+			}
+		}
+	})
+	return eg.Wait()
+}
+
+func (g *gossip) publish(ctx context.Context, pooled *pooledOp) error {
+	msg := &opsMessage{
+		EntryPoint: pooled.entryPoint,
+		BlockHash:  pooled.blockHash,
+		ChainID:    g.pool.chainID,
+		Ops:        []*UserOperation{pooled.op},
+	}
+	return g.topic.Publish(ctx, snappy.Encode(nil, msg.encode()))
+}
+
+// validate adds the received operations to the pool: messages with invalid operations are rejected, so that
+// gossipsub penalizes the peer, and messages with no new operations are not forwarded. Messages with too many
+// operations, or over the rate of the peer, are ignored without simulating them.
+func (g *gossip) validate(ctx context.Context, from peer.ID, m *pubsub.Message) pubsub.ValidationResult {
+	if from == g.host.ID() { // published by Pool.Add, validated already
+		return pubsub.ValidationAccept
+	}
+	data, err := snappy.Decode(nil, m.Data)
+	if err != nil {
+		return pubsub.ValidationReject
+	}
+	msg, err := decodeOpsMessage(data)
+	if err != nil {
+		g.logger.Debug("[userop] malformed gossip message", "peer", from, "err", err)
+		return pubsub.ValidationReject
+	}
+	if msg.ChainID.Cmp(g.pool.chainID) != 0 {
+		return pubsub.ValidationReject
+	}
+	if len(msg.Ops) > maxValidatedOpsPerMessage {
+		g.logger.Debug("[userop] ignoring gossip message with too many operations", "peer", from, "ops", len(msg.Ops))
+		return pubsub.ValidationIgnore
+	}
+	if !g.allow(from, len(msg.Ops)) {
+		g.logger.Debug("[userop] ignoring gossip message over the peer rate", "peer", from, "ops", len(msg.Ops))
+		return pubsub.ValidationIgnore
+	}
+	added := 0
+	for _, op := range msg.Ops {
+		_, err := g.pool.add(ctx, op, msg.EntryPoint)
+		switch {
+		case err == nil:
+			added++
+		case errors.Is(err, ErrAlreadyKnown), errors.Is(err, ErrReplacementUnderpriced), errors.Is(err, ErrSenderLimit), errors.Is(err, ErrPoolFull),
+			errors.Is(err, ErrEntityThrottled), errors.Is(err, ErrEntityBanned):
+		default:
+			g.logger.Debug("[userop] invalid gossiped user operation", "peer", from, "sender", op.Sender, "err", err)
+			return pubsub.ValidationReject
+		}
+	}
+	if added == 0 {
+		return pubsub.ValidationIgnore
+	}
+	return pubsub.ValidationAccept
+}
+
+// allow takes n operations from the rate limit of the peer
+func (g *gossip) allow(from peer.ID, n int) bool {
+	limiter, ok := g.peerLimits.Get(from)
+	if !ok {
+		limiter = rate.NewLimiter(peerOpsPerSecond, maxValidatedOpsPerMessage)
+		if prev, ok, _ := g.peerLimits.PeekOrAdd(from, limiter); ok {
+			limiter = prev
+		}
+	}
+	return limiter.AllowN(time.Now(), n)
+}
+
+// opsMessage is the SSZ encoded UserOperationsWithEntryPoint of the mempool p2p spec
+type opsMessage struct {
+	EntryPoint common.Address
+	BlockHash  common.Hash // verified_at_block_hash
+	ChainID    *big.Int
+	Ops        []*UserOperation
+}
+
+const (
+	opsMessageFixedSize = length.Addr + 32 + 32 + 4
+	userOpFixedSize     = length.Addr + 32 + 4 + 4 + 5*32 + 4 + 4
+)
+
+func (m *opsMessage) encode() []byte {
+	res := make([]byte, 0, opsMessageFixedSize)
+	res = append(res, m.EntryPoint[:]...)
+	res = append(res, m.BlockHash[:]...)
+	res = appendSszUint256(res, m.ChainID)
+	res = binary.LittleEndian.AppendUint32(res, opsMessageFixedSize)
+
+	ops := make([][]byte, len(m.Ops))
+	offset := 4 * len(m.Ops)
+	for i, op := range m.Ops {
+		ops[i] = encodeSszUserOp(op)
+		res = binary.LittleEndian.AppendUint32(res, uint32(offset))
+		offset += len(ops[i])
+	}
+	for _, op := range ops {
+		res = append(res, op...)
+	}
+	return res
+}
+
+func encodeSszUserOp(op *UserOperation) []byte {
+	res := make([]byte, 0, userOpFixedSize)
+	offset := userOpFixedSize
+	appendOffset := func(b []byte) {
+		res = binary.LittleEndian.AppendUint32(res, uint32(offset))
+		offset += len(b)
+	}
+	res = append(res, op.Sender[:]...)
+	res = appendSszUint256(res, op.Nonce.ToInt())
+	appendOffset(op.InitCode)
+	appendOffset(op.CallData)
+	res = appendSszUint256(res, op.CallGasLimit.ToInt())
+	res = appendSszUint256(res, op.VerificationGasLimit.ToInt())
+	res = appendSszUint256(res, op.PreVerificationGas.ToInt())
+	res = appendSszUint256(res, op.MaxFeePerGas.ToInt())
+	res = appendSszUint256(res, op.MaxPriorityFeePerGas.ToInt())
+	appendOffset(op.PaymasterAndData)
+	appendOffset(op.Signature)
+	res = append(res, op.InitCode...)
+	res = append(res, op.CallData...)
+	res = append(res, op.PaymasterAndData...)
+	return append(res, op.Signature...)
+}
+
+// appendSszUint256 appends the little endian uint256
+func appendSszUint256(b []byte, v *big.Int) []byte {
+	var u uint256.Int
+	u.SetFromBig(v)
+	be := u.Bytes32()
+	for i := len(be) - 1; i >= 0; i-- {
+		b = append(b, be[i])
+	}
+	return b
+}
+
+func readSszUint256(b []byte) *hexutil.Big {
+	be := make([]byte, 32)
+	for i := range be {
+		be[i] = b[31-i]
+	}
+	return (*hexutil.Big)(new(big.Int).SetBytes(be))
+}
+
+func decodeOpsMessage(data []byte) (*opsMessage, error) {
+	if len(data) < opsMessageFixedSize {
+		return nil, errors.New("message too short")
+	}
+	m := &opsMessage{
+		EntryPoint: common.BytesToAddress(data[:20]),
+		BlockHash:  common.BytesToHash(data[20:52]),
+		ChainID:    readSszUint256(data[52:84]).ToInt(),
+	}
+	if binary.LittleEndian.Uint32(data[84:88]) != opsMessageFixedSize {
+		return nil, errors.New("unexpected user operations offset")
+	}
+	list := data[opsMessageFixedSize:]
+	if len(list) == 0 {
+		return m, nil
+	}
+	if len(list) < 4 {
+		return nil, errors.New("user operations list too short")
+	}
+	first := binary.LittleEndian.Uint32(list[:4])
+	if first%4 != 0 || first == 0 || int(first) > len(list) || first/4 > maxOpsPerMessage {
+		return nil, errors.New("invalid user operations list offset")
+	}
+	offsets := make([]int, first/4+1)
+	for i := range offsets[:len(offsets)-1] {
+		offsets[i] = int(binary.LittleEndian.Uint32(list[4*i:]))
+	}
+	offsets[len(offsets)-1] = len(list)
+	for i := 0; i < len(offsets)-1; i++ {
+		if offsets[i] > offsets[i+1] {
+			return nil, errors.New("user operations list offsets out of order")
+		}
+		op, err := decodeSszUserOp(list[offsets[i]:offsets[i+1]])
+		if err != nil {
+			return nil, fmt.Errorf("user operation %d: %w", i, err)
+		}
+		m.Ops = append(m.Ops, op)
+	}
+	return m, nil
+}
+
+func decodeSszUserOp(data []byte) (*UserOperation, error) {
+	if len(data) < userOpFixedSize {
+		return nil, errors.New("user operation too short")
+	}
+	op := &UserOperation{Sender: common.BytesToAddress(data[:20]), Nonce: readSszUint256(data[20:52])}
+	pos := 52
+	readOffset := func() int {
+		o := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		return o
+	}
+	readUint := func() *hexutil.Big {
+		v := readSszUint256(data[pos : pos+32])
+		pos += 32
+		return v
+	}
+	initCodeOffset, callDataOffset := readOffset(), readOffset()
+	op.CallGasLimit = readUint()
+	op.VerificationGasLimit = readUint()
+	op.PreVerificationGas = readUint()
+	op.MaxFeePerGas = readUint()
+	op.MaxPriorityFeePerGas = readUint()
+	paymasterOffset, signatureOffset := readOffset(), readOffset()
+
+	offsets := []int{initCodeOffset, callDataOffset, paymasterOffset, signatureOffset, len(data)}
+	if offsets[0] != userOpFixedSize {
+		return nil, errors.New("unexpected init code offset")
+	}
+	fields := make([]hexutil.Bytes, 4)
+	for i := range fields {
+		if offsets[i] > offsets[i+1] || offsets[i+1] > len(data) {
+			return nil, errors.New("user operation offsets out of range")
+		}
+		fields[i] = common.CopyBytes(data[offsets[i]:offsets[i+1]])
+	}
+	op.InitCode, op.CallData, op.PaymasterAndData, op.Signature = fields[0], fields[1], fields[2], fields[3]
+	return op, nil
+}
@@ -0,0 +1,408 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package userop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/eth/filters"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/txnprovider/userop/useropcfg"
+)
+
+const (
+	// replacementBumpPercent is the min increase of both fees of an operation replacing one with the same sender and nonce
+	replacementBumpPercent = 10
+	// revalidatePerHead is how many of the operations validated the longest ago are validated again on every new head
+	revalidatePerHead = 64
+)
+
+var (
+	ErrUnsupportedEntryPoint  = errors.New("unsupported entry point")
+	ErrAlreadyKnown           = errors.New("user operation already known")
+	ErrReplacementUnderpriced = errors.New("replacement user operation underpriced")
+	ErrSenderLimit            = errors.New("too many pending user operations of the sender")
+	ErrPoolFull               = errors.New("user operation pool is full")
+	ErrEntityThrottled        = errors.New("too many pending user operations of the throttled factory or paymaster")
+	ErrEntityBanned           = errors.New("factory or paymaster is banned")
+)
+
+type pooledOp struct {
+	op         *UserOperation
+	entryPoint common.Address
+	hash       common.Hash
+	blockHash  common.Hash // head the operation was validated on
+	validUntil uint64      // unix time, 0 if not limited
+	validated  time.Time
+}
+
+func (p *pooledOp) setValidation(res *ValidationResult, now time.Time) {
+	p.blockHash, p.validUntil, p.validated = res.BlockHash, res.ValidUntil, now
+}
+
+// expired returns true if the operation is no longer worth keeping, see checkValidationResult
+func (p *pooledOp) expired(now time.Time) bool {
+	return p.validUntil != 0 && time.Unix(int64(p.validUntil), 0).Before(now.Add(minValidityRemaining))
+}
+
+// senderNonce identifies operations replacing each other
+type senderNonce struct {
+	entryPoint common.Address
+	sender     common.Address
+	nonce      string
+}
+
+func (p *pooledOp) key() senderNonce {
+	return senderNonce{entryPoint: p.entryPoint, sender: p.op.Sender, nonce: p.op.Nonce.String()}
+}
+
+// Pool keeps validated user operations until they are included on chain
+type Pool struct {
+	config    useropcfg.Config
+	chainID   *big.Int
+	validator Validator
+	logger    log.Logger
+
+	mu          sync.RWMutex
+	ops         map[common.Hash]*pooledOp
+	bySenderKey map[senderNonce]*pooledOp
+	senderCount map[common.Address]int
+	entityCount map[common.Address]int // pooled operations by factory and paymaster
+	reputation  reputation
+
+	gossip       *gossip // nil until Run, or if gossip is disabled
+	revalidating atomic.Bool
+}
+
+func NewPool(config useropcfg.Config, chainID *big.Int, validator Validator, logger log.Logger) *Pool {
+	return &Pool{
+		config:      config,
+		chainID:     chainID,
+		validator:   validator,
+		logger:      logger,
+		ops:         map[common.Hash]*pooledOp{},
+		bySenderKey: map[senderNonce]*pooledOp{},
+		senderCount: map[common.Address]int{},
+		entityCount: map[common.Address]int{},
+		reputation:  reputation{},
+	}
+}
+
+// EntryPoints returns the supported EntryPoint contracts
+func (p *Pool) EntryPoints() []common.Address {
+	return slices.Clone(p.config.EntryPoints)
+}
+
+// ChainID is the chain id the operation hashes commit to
+func (p *Pool) ChainID() *big.Int {
+	return p.chainID
+}
+
+// Add validates the operation and adds it to the pool, gossiping it to the mempool peers
+func (p *Pool) Add(ctx context.Context, op *UserOperation, entryPoint common.Address) (common.Hash, error) {
+	pooled, err := p.add(ctx, op, entryPoint)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	p.mu.RLock()
+	g := p.gossip
+	p.mu.RUnlock()
+	if g != nil {
+		if err := g.publish(ctx, pooled); err != nil {
+			p.logger.Debug("[userop] failed to gossip user operation", "hash", pooled.hash, "err", err)
+		}
+	}
+	return pooled.hash, nil
+}
+
+func (p *Pool) add(ctx context.Context, op *UserOperation, entryPoint common.Address) (*pooledOp, error) {
+	if !slices.Contains(p.config.EntryPoints, entryPoint) {
+		return nil, fmt.Errorf("%w: %x", ErrUnsupportedEntryPoint, entryPoint)
+	}
+	if err := op.checkFields(); err != nil {
+		return nil, err
+	}
+	pooled := &pooledOp{op: op, entryPoint: entryPoint, hash: op.Hash(entryPoint, p.chainID)}
+	// cheap checks first, they are repeated on insert as the pool may change during the validation
+	p.mu.RLock()
+	err := p.checkInsert(pooled)
+	p.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.validator.Validate(ctx, entryPoint, op)
+	if err != nil {
+		return nil, err
+	}
+	pooled.setValidation(res, time.Now())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkInsert(pooled); err != nil {
+		return nil, err
+	}
+	if replaced, ok := p.bySenderKey[pooled.key()]; ok {
+		p.remove(replaced.hash)
+	} else if len(p.ops) >= p.config.MaxOps {
+		p.remove(p.cheapest().hash)
+	}
+	p.ops[pooled.hash] = pooled
+	p.bySenderKey[pooled.key()] = pooled
+	p.senderCount[op.Sender]++
+	for _, e := range op.entities() {
+		p.entityCount[e]++
+		p.reputation.seen(e)
+	}
+	return pooled, nil
+}
+
+// checkInsert must be called with p.mu held
+func (p *Pool) checkInsert(pooled *pooledOp) error {
+	if _, ok := p.ops[pooled.hash]; ok {
+		return ErrAlreadyKnown
+	}
+	if replaced, ok := p.bySenderKey[pooled.key()]; ok {
+		if !bumped(replaced.op.MaxFeePerGas.ToInt(), pooled.op.MaxFeePerGas.ToInt()) ||
+			!bumped(replaced.op.MaxPriorityFeePerGas.ToInt(), pooled.op.MaxPriorityFeePerGas.ToInt()) {
+			return ErrReplacementUnderpriced
+		}
+		return nil
+	}
+	if p.senderCount[pooled.op.Sender] >= p.config.MaxOpsPerSender {
+		return ErrSenderLimit
+	}
+	for _, e := range pooled.op.entities() {
+		switch p.reputation.status(e) {
+		case reputationBanned:
+			return fmt.Errorf("%w: %x", ErrEntityBanned, e)
+		case reputationThrottled:
+			if p.entityCount[e] >= throttledEntityMempoolCount {
+				return fmt.Errorf("%w: %x", ErrEntityThrottled, e)
+			}
+		}
+	}
+	if len(p.ops) >= p.config.MaxOps {
+		if cheapest := p.cheapest(); cheapest == nil || cheapest.op.MaxPriorityFeePerGas.ToInt().Cmp(pooled.op.MaxPriorityFeePerGas.ToInt()) >= 0 {
+			return ErrPoolFull
+		}
+	}
+	return nil
+}
+
+// bumped returns true if `v` is at least replacementBumpPercent higher than `old`
+func bumped(old, v *big.Int) bool {
+	threshold := new(big.Int).Mul(old, big.NewInt(100+replacementBumpPercent))
+	return new(big.Int).Mul(v, big.NewInt(100)).Cmp(threshold) >= 0
+}
+
+// cheapest must be called with p.mu held
+func (p *Pool) cheapest() *pooledOp {
+	var res *pooledOp
+	for _, o := range p.ops {
+		if res == nil || o.op.MaxPriorityFeePerGas.ToInt().Cmp(res.op.MaxPriorityFeePerGas.ToInt()) < 0 {
+			res = o
+		}
+	}
+	return res
+}
+
+// remove must be called with p.mu held
+func (p *Pool) remove(hash common.Hash) bool {
+	o, ok := p.ops[hash]
+	if !ok {
+		return false
+	}
+	delete(p.ops, hash)
+	delete(p.bySenderKey, o.key())
+	if p.senderCount[o.op.Sender]--; p.senderCount[o.op.Sender] <= 0 {
+		delete(p.senderCount, o.op.Sender)
+	}
+	for _, e := range o.op.entities() {
+		if p.entityCount[e]--; p.entityCount[e] <= 0 {
+			delete(p.entityCount, e)
+		}
+	}
+	return true
+}
+
+// Get returns the pooled operation and its entry point
+func (p *Pool) Get(hash common.Hash) (*UserOperation, common.Address, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	o, ok := p.ops[hash]
+	if !ok {
+		return nil, common.Address{}, false
+	}
+	return o.op, o.entryPoint, true
+}
+
+// Pending returns the pooled operations of the entry point, by max priority fee descending
+func (p *Pool) Pending(entryPoint common.Address) []*UserOperation {
+	p.mu.RLock()
+	res := make([]*UserOperation, 0, len(p.ops))
+	for _, o := range p.ops {
+		if o.entryPoint == entryPoint {
+			res = append(res, o.op)
+		}
+	}
+	p.mu.RUnlock()
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].MaxPriorityFeePerGas.ToInt().Cmp(res[j].MaxPriorityFeePerGas.ToInt()) > 0
+	})
+	return res
+}
+
+// Len returns the amount of pooled operations
+func (p *Pool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.ops)
+}
+
+// Run drops operations once the EntryPoint emits their UserOperationEvent, evicts and revalidates operations on new
+// heads and gossips with the mempool peers if a mempool id is configured
+func (p *Pool) Run(ctx context.Context, logsFilters *rpchelper.Filters) error {
+	p.logger.Info("[userop] running user operation mempool", "entryPoints", p.config.EntryPoints, "mempoolId", p.config.MempoolId)
+	if p.config.MempoolId != "" {
+		g, err := newGossip(ctx, p, p.logger)
+		if err != nil {
+			return err
+		}
+		p.mu.Lock()
+		p.gossip = g
+		p.mu.Unlock()
+		go func() {
+			if err := g.run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				p.logger.Error("[userop] gossip stopped", "err", err)
+			}
+		}()
+	}
+
+	logs, id := logsFilters.SubscribeLogs(256, filters.FilterCriteria{
+		Addresses: p.config.EntryPoints,
+		Topics:    [][]common.Hash{{UserOperationEventTopic}},
+	})
+	defer logsFilters.UnsubscribeLogs(id)
+	heads, headsId := logsFilters.SubscribeNewHeads(16)
+	defer logsFilters.UnsubscribeHeads(headsId)
+	decay := time.NewTicker(reputationDecayInterval)
+	defer decay.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case l, ok := <-logs:
+			if !ok {
+				return nil
+			}
+			if l.Removed || len(l.Topics) < 2 || l.Topics[0] != UserOperationEventTopic {
+				continue
+			}
+			p.included(l)
+		case _, ok := <-heads:
+			if !ok {
+				return nil
+			}
+			p.evictExpired(time.Now())
+			if p.revalidating.CompareAndSwap(false, true) {
+				go func() {
+					defer p.revalidating.Store(false)
+					p.revalidate(ctx, revalidatePerHead)
+				}()
+			}
+		case <-decay.C:
+			p.mu.Lock()
+			p.reputation.decay()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// included drops the operation of the UserOperationEvent and credits its factory and paymaster
+func (p *Pool) included(l *types.Log) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var entities []common.Address
+	if o, ok := p.ops[l.Topics[1]]; ok {
+		entities = o.op.entities()
+		p.remove(o.hash)
+	} else if len(l.Topics) > 3 && l.Topics[3] != (common.Hash{}) {
+		entities = []common.Address{common.BytesToAddress(l.Topics[3][:])} // the paymaster
+	}
+	for _, e := range entities {
+		p.reputation.included(e)
+	}
+}
+
+func (p *Pool) evictExpired(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for hash, o := range p.ops {
+		if o.expired(now) {
+			p.remove(hash)
+		}
+	}
+}
+
+// revalidate validates again the `limit` operations validated the longest ago and drops the ones no longer valid
+func (p *Pool) revalidate(ctx context.Context, limit int) {
+	p.mu.RLock()
+	stale := make([]*pooledOp, 0, len(p.ops))
+	for _, o := range p.ops {
+		stale = append(stale, o)
+	}
+	p.mu.RUnlock()
+	sort.Slice(stale, func(i, j int) bool { return stale[i].validated.Before(stale[j].validated) })
+	if len(stale) > limit {
+		stale = stale[:limit]
+	}
+
+	for _, o := range stale {
+		if ctx.Err() != nil {
+			return
+		}
+		res, err := p.validator.Validate(ctx, o.entryPoint, o.op)
+		if ctx.Err() != nil {
+			return
+		}
+		p.mu.Lock()
+		if p.ops[o.hash] == o {
+			if err != nil {
+				p.logger.Debug("[userop] dropping user operation no longer valid", "hash", o.hash, "err", err)
+				p.remove(o.hash)
+			} else {
+				// pooled operations are not modified, they are read without the lock once returned by add
+				updated := *o
+				updated.setValidation(res, time.Now())
+				p.ops[o.hash], p.bySenderKey[o.key()] = &updated, &updated
+			}
+		}
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package userop
+
+import (
+	"errors"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon/core/types"
+)
+
+// Receipt is the result of eth_getUserOperationReceipt
+type Receipt struct {
+	UserOpHash    common.Hash            `json:"userOpHash"`
+	EntryPoint    common.Address         `json:"entryPoint"`
+	Sender        common.Address         `json:"sender"`
+	Nonce         *hexutil.Big           `json:"nonce"`
+	Paymaster     common.Address         `json:"paymaster"`
+	ActualGasCost *hexutil.Big           `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big           `json:"actualGasUsed"`
+	Success       bool                   `json:"success"`
+	Reason        hexutil.Bytes          `json:"reason,omitempty"` // revert reason of the execution
+	Logs          types.Logs             `json:"logs"`             // logs emitted by the execution of the operation
+	Receipt       map[string]interface{} `json:"receipt"`          // receipt of the bundle transaction
+}
+
+// NewReceipt builds the receipt of the operation from the logs of the bundle transaction, `eventIndex` is the
+// index of the UserOperationEvent of the operation in `logs`
+func NewReceipt(logs types.Logs, eventIndex int) (*Receipt, error) {
+	if eventIndex < 0 || eventIndex >= len(logs) {
+		return nil, errors.New("user operation event out of range")
+	}
+	event := logs[eventIndex]
+	if len(event.Topics) != 4 || event.Topics[0] != UserOperationEventTopic {
+		return nil, errors.New("not a UserOperationEvent")
+	}
+	data := abiReader(event.Data)
+	nonce, err := data.uint(0)
+	if err != nil {
+		return nil, err
+	}
+	success, err := data.uint(1)
+	if err != nil {
+		return nil, err
+	}
+	gasCost, err := data.uint(2)
+	if err != nil {
+		return nil, err
+	}
+	gasUsed, err := data.uint(3)
+	if err != nil {
+		return nil, err
+	}
+	r := &Receipt{
+		UserOpHash:    event.Topics[1],
+		EntryPoint:    event.Address,
+		Sender:        common.BytesToAddress(event.Topics[2][:]),
+		Paymaster:     common.BytesToAddress(event.Topics[3][:]),
+		Nonce:         (*hexutil.Big)(nonce),
+		Success:       success.Sign() != 0,
+		ActualGasCost: (*hexutil.Big)(gasCost),
+		ActualGasUsed: (*hexutil.Big)(gasUsed),
+		Logs:          types.Logs{},
+	}
+
+	// the operation emitted the logs between the event of the previous operation of the bundle
+	// (or BeforeExecution for the first one) and its own event
+	start := eventIndex
+	for start > 0 {
+		l := logs[start-1]
+		if l.Address == event.Address && len(l.Topics) > 0 && (l.Topics[0] == UserOperationEventTopic || l.Topics[0] == BeforeExecutionTopic) {
+			break
+		}
+		start--
+	}
+	for _, l := range logs[start:eventIndex] {
+		if l.Address == event.Address && len(l.Topics) > 1 && l.Topics[0] == UserOperationRevertReasonTopic && l.Topics[1] == r.UserOpHash {
+			if reason, err := abiReader(l.Data).offset(1); err == nil {
+				if length, err := reason.uint(0); err == nil && length.IsUint64() && uint64(len(reason)) >= 32+length.Uint64() {
+					r.Reason = common.CopyBytes(reason[32 : 32+length.Uint64()])
+				}
+			}
+			continue
+		}
+		r.Logs = append(r.Logs, l)
+	}
+	return r, nil
+}
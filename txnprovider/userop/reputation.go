@@ -0,0 +1,100 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package userop
+
+import (
+	"time"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// reputation parameters of ERC-7562, see https://eips.ethereum.org/EIPS/eip-7562#reputation-scoring-and-throttlingbanning-for-global-entities
+const (
+	minInclusionRateDenominator = 10
+	throttlingSlack             = 10
+	banSlack                    = 50
+	throttledEntityMempoolCount = 4
+	reputationDecayInterval     = time.Hour
+)
+
+type reputationStatus int
+
+const (
+	reputationOk reputationStatus = iota
+	reputationThrottled
+	reputationBanned
+)
+
+type entityReputation struct {
+	opsSeen     uint64
+	opsIncluded uint64
+}
+
+// reputation tracks how many of the operations using a factory or a paymaster got included on chain, entities
+// whose operations are pooled but not included are throttled, then banned
+type reputation map[common.Address]*entityReputation
+
+func (r reputation) status(entity common.Address) reputationStatus {
+	e, ok := r[entity]
+	if !ok {
+		return reputationOk
+	}
+	maxSeen := e.opsSeen / minInclusionRateDenominator
+	switch {
+	case maxSeen > e.opsIncluded+banSlack:
+		return reputationBanned
+	case maxSeen > e.opsIncluded+throttlingSlack:
+		return reputationThrottled
+	default:
+		return reputationOk
+	}
+}
+
+func (r reputation) entry(entity common.Address) *entityReputation {
+	e, ok := r[entity]
+	if !ok {
+		e = &entityReputation{}
+		r[entity] = e
+	}
+	return e
+}
+
+func (r reputation) seen(entity common.Address)     { r.entry(entity).opsSeen++ }
+func (r reputation) included(entity common.Address) { r.entry(entity).opsIncluded++ }
+
+// decay is called every reputationDecayInterval, so that the counters cover about the last day
+func (r reputation) decay() {
+	for entity, e := range r {
+		e.opsSeen -= e.opsSeen / 24
+		e.opsIncluded -= e.opsIncluded / 24
+		if e.opsSeen < 24 && e.opsIncluded < 24 {
+			// small counters never decay, forget the entity instead
+			delete(r, entity)
+		}
+	}
+}
+
+// entities returns the factory and the paymaster of the operation, the global entities reputation applies to
+func (op *UserOperation) entities() []common.Address {
+	var res []common.Address
+	for _, e := range []common.Address{op.Factory(), op.Paymaster()} {
+		if e != (common.Address{}) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+// Package userop is an ERC-4337 user operation mempool: user operations are validated by simulating
+// EntryPoint.simulateValidation on the latest state, pooled until a bundler includes them on chain and
+// gossiped to the other bundlers of the same mempool, see https://eips.ethereum.org/EIPS/eip-4337
+package userop
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/common/length"
+	"github.com/erigontech/erigon-lib/crypto"
+)
+
+// UserOperation is the user operation of the v0.6 EntryPoint
+type UserOperation struct {
+	Sender               common.Address `json:"sender"`
+	Nonce                *hexutil.Big   `json:"nonce"`
+	InitCode             hexutil.Bytes  `json:"initCode"`
+	CallData             hexutil.Bytes  `json:"callData"`
+	CallGasLimit         *hexutil.Big   `json:"callGasLimit"`
+	VerificationGasLimit *hexutil.Big   `json:"verificationGasLimit"`
+	PreVerificationGas   *hexutil.Big   `json:"preVerificationGas"`
+	MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     hexutil.Bytes  `json:"paymasterAndData"`
+	Signature            hexutil.Bytes  `json:"signature"`
+}
+
+var (
+	// simulateValidation((address,uint256,bytes,bytes,uint256,uint256,uint256,uint256,uint256,bytes,bytes))
+	simulateValidationSelector = selector("simulateValidation((address,uint256,bytes,bytes,uint256,uint256,uint256,uint256,uint256,bytes,bytes))")
+
+	// errors simulateValidation always reverts with
+	validationResultSelector                = selector("ValidationResult((uint256,uint256,bool,uint48,uint48,bytes),(uint256,uint256),(uint256,uint256),(uint256,uint256))")
+	validationResultWithAggregationSelector = selector("ValidationResultWithAggregation((uint256,uint256,bool,uint48,uint48,bytes),(uint256,uint256),(uint256,uint256),(uint256,uint256),(address,(uint256,uint256)))")
+	failedOpSelector                        = selector("FailedOp(uint256,string)")
+
+	// UserOperationEventTopic is emitted by the EntryPoint for every executed user operation:
+	// UserOperationEvent(bytes32 indexed userOpHash, address indexed sender, address indexed paymaster, uint256 nonce, bool success, uint256 actualGasCost, uint256 actualGasUsed)
+	UserOperationEventTopic = common.BytesToHash(crypto.Keccak256([]byte("UserOperationEvent(bytes32,address,address,uint256,bool,uint256,uint256)")))
+	// UserOperationRevertReasonTopic is emitted by the EntryPoint when the execution of a user operation reverts:
+	// UserOperationRevertReason(bytes32 indexed userOpHash, address indexed sender, uint256 nonce, bytes revertReason)
+	UserOperationRevertReasonTopic = common.BytesToHash(crypto.Keccak256([]byte("UserOperationRevertReason(bytes32,address,uint256,bytes)")))
+	// BeforeExecutionTopic is emitted by the EntryPoint after validation of all user operations of a bundle
+	BeforeExecutionTopic = common.BytesToHash(crypto.Keccak256([]byte("BeforeExecution()")))
+)
+
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// checkFields returns an error if a numeric field is missing
+func (op *UserOperation) checkFields() error {
+	for _, f := range []struct {
+		name string
+		v    *hexutil.Big
+	}{
+		{"nonce", op.Nonce},
+		{"callGasLimit", op.CallGasLimit},
+		{"verificationGasLimit", op.VerificationGasLimit},
+		{"preVerificationGas", op.PreVerificationGas},
+		{"maxFeePerGas", op.MaxFeePerGas},
+		{"maxPriorityFeePerGas", op.MaxPriorityFeePerGas},
+	} {
+		if f.v == nil {
+			return fmt.Errorf("missing %s", f.name)
+		}
+	}
+	if op.MaxPriorityFeePerGas.ToInt().Cmp(op.MaxFeePerGas.ToInt()) > 0 {
+		return errors.New("maxPriorityFeePerGas is higher than maxFeePerGas")
+	}
+	return nil
+}
+
+// Hash is the hash of the user operation returned by EntryPoint.getUserOpHash
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	var packed abiWriter
+	packed.address(op.Sender)
+	packed.uint(op.Nonce)
+	packed.word(crypto.Keccak256(op.InitCode))
+	packed.word(crypto.Keccak256(op.CallData))
+	packed.uint(op.CallGasLimit)
+	packed.uint(op.VerificationGasLimit)
+	packed.uint(op.PreVerificationGas)
+	packed.uint(op.MaxFeePerGas)
+	packed.uint(op.MaxPriorityFeePerGas)
+	packed.word(crypto.Keccak256(op.PaymasterAndData))
+
+	var enc abiWriter
+	enc.word(crypto.Keccak256(packed.head))
+	enc.address(entryPoint)
+	enc.uint((*hexutil.Big)(chainID))
+	return common.BytesToHash(crypto.Keccak256(enc.head))
+}
+
+// Paymaster is the address the paymasterAndData starts with, zero if the operation has no paymaster
+func (op *UserOperation) Paymaster() common.Address {
+	if len(op.PaymasterAndData) < length.Addr {
+		return common.Address{}
+	}
+	return common.BytesToAddress(op.PaymasterAndData[:length.Addr])
+}
+
+// Factory is the address the initCode starts with, zero if the operation doesn't deploy the sender
+func (op *UserOperation) Factory() common.Address {
+	if len(op.InitCode) < length.Addr {
+		return common.Address{}
+	}
+	return common.BytesToAddress(op.InitCode[:length.Addr])
+}
+
+// simulateValidationData is the calldata of EntryPoint.simulateValidation(op)
+func (op *UserOperation) simulateValidationData() []byte {
+	var tuple abiWriter
+	tuple.address(op.Sender)
+	tuple.uint(op.Nonce)
+	tuple.bytes(op.InitCode)
+	tuple.bytes(op.CallData)
+	tuple.uint(op.CallGasLimit)
+	tuple.uint(op.VerificationGasLimit)
+	tuple.uint(op.PreVerificationGas)
+	tuple.uint(op.MaxFeePerGas)
+	tuple.uint(op.MaxPriorityFeePerGas)
+	tuple.bytes(op.PaymasterAndData)
+	tuple.bytes(op.Signature)
+
+	data := append([]byte{}, simulateValidationSelector...)
+	data = append(data, common.LeftPadBytes([]byte{32}, 32)...) // offset of the tuple
+	return append(data, tuple.encode()...)
+}
+
+// abiWriter encodes a tuple: static values go to the head, dynamic ones to the tail referenced by offsets
+type abiWriter struct {
+	head    []byte
+	tails   [][]byte
+	offsets []int // positions of the offset words in the head, by tail
+}
+
+func (w *abiWriter) word(b []byte) {
+	w.head = append(w.head, common.LeftPadBytes(b, 32)...)
+}
+
+func (w *abiWriter) address(a common.Address) { w.word(a[:]) }
+
+func (w *abiWriter) uint(v *hexutil.Big) {
+	var u uint256.Int
+	u.SetFromBig(v.ToInt())
+	b := u.Bytes32()
+	w.word(b[:])
+}
+
+func (w *abiWriter) bytes(b []byte) {
+	w.offsets = append(w.offsets, len(w.head))
+	w.head = append(w.head, make([]byte, 32)...)
+	tail := common.LeftPadBytes(new(big.Int).SetInt64(int64(len(b))).Bytes(), 32)
+	tail = append(tail, common.RightPadBytes(b, (len(b)+31)/32*32)...)
+	w.tails = append(w.tails, tail)
+}
+
+func (w *abiWriter) encode() []byte {
+	res := append([]byte{}, w.head...)
+	for i, tail := range w.tails {
+		offset := big.NewInt(int64(len(res))).Bytes()
+		copy(res[w.offsets[i]+32-len(offset):w.offsets[i]+32], offset)
+		res = append(res, tail...)
+	}
+	return res
+}
+
+// ValidationResult is the result of EntryPoint.simulateValidation of a valid user operation
+type ValidationResult struct {
+	PreOpGas   *big.Int
+	Prefund    *big.Int
+	SigFailed  bool
+	ValidAfter uint64 // unix time, 0 if not limited
+	ValidUntil uint64 // unix time, 0 if not limited
+
+	SenderStake    StakeInfo
+	FactoryStake   StakeInfo
+	PaymasterStake StakeInfo
+
+	BlockHash common.Hash // head the operation was validated on
+}
+
+type StakeInfo struct {
+	Stake           *big.Int
+	UnstakeDelaySec uint64
+}
+
+// ErrFailedOp is returned when the EntryPoint rejects the user operation, with the reason it reverted with
+type ErrFailedOp struct {
+	Reason string
+}
+
+func (e ErrFailedOp) Error() string { return "user operation rejected by entry point: " + e.Reason }
+
+var errAggregatorsNotSupported = errors.New("user operations with signature aggregators are not supported")
+
+// decodeValidationRevert decodes the data simulateValidation reverted with
+func decodeValidationRevert(data []byte) (*ValidationResult, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("unexpected simulateValidation revert: %x", data)
+	}
+	sel, args := data[:4], abiReader(data[4:])
+	switch {
+	case string(sel) == string(validationResultSelector):
+		returnInfo, err := args.offset(0)
+		if err != nil {
+			return nil, err
+		}
+		res := &ValidationResult{}
+		if res.PreOpGas, err = returnInfo.uint(0); err != nil {
+			return nil, err
+		}
+		if res.Prefund, err = returnInfo.uint(1); err != nil {
+			return nil, err
+		}
+		sigFailed, err := returnInfo.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		res.SigFailed = sigFailed.Sign() != 0
+		validAfter, err := returnInfo.uint(3)
+		if err != nil {
+			return nil, err
+		}
+		validUntil, err := returnInfo.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		res.ValidAfter, res.ValidUntil = validAfter.Uint64(), validUntil.Uint64()
+		for i, stake := range []*StakeInfo{&res.SenderStake, &res.FactoryStake, &res.PaymasterStake} {
+			if stake.Stake, err = args.uint(1 + 2*i); err != nil {
+				return nil, err
+			}
+			delay, err := args.uint(2 + 2*i)
+			if err != nil {
+				return nil, err
+			}
+			stake.UnstakeDelaySec = delay.Uint64()
+		}
+		return res, nil
+	case string(sel) == string(validationResultWithAggregationSelector):
+		return nil, errAggregatorsNotSupported
+	case string(sel) == string(failedOpSelector):
+		reason, err := args.offset(1)
+		if err != nil {
+			return nil, err
+		}
+		size, err := reason.uint(0)
+		if err != nil {
+			return nil, err
+		}
+		if !size.IsUint64() || uint64(len(reason)) < 32+size.Uint64() {
+			return nil, errors.New("malformed FailedOp reason")
+		}
+		return nil, ErrFailedOp{Reason: string(reason[32 : 32+size.Uint64()])}
+	default:
+		return nil, fmt.Errorf("unexpected simulateValidation revert: %x", data)
+	}
+}
+
+// abiReader reads words of an encoded tuple
+type abiReader []byte
+
+func (r abiReader) uint(i int) (*big.Int, error) {
+	if len(r) < (i+1)*32 {
+		return nil, errors.New("abi data too short")
+	}
+	return new(big.Int).SetBytes(r[i*32 : (i+1)*32]), nil
+}
+
+// offset returns the dynamic value referenced by the i-th word
+func (r abiReader) offset(i int) (abiReader, error) {
+	offset, err := r.uint(i)
+	if err != nil {
+		return nil, err
+	}
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(r)) {
+		return nil, errors.New("abi offset out of range")
+	}
+	return r[offset.Uint64():], nil
+}
@@ -0,0 +1,394 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package userop
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/holiman/uint256"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/log/v3"
+	"github.com/erigontech/erigon/accounts/abi"
+	"github.com/erigontech/erigon/core/types"
+	"github.com/erigontech/erigon/txnprovider/userop/useropcfg"
+)
+
+const userOpTupleJSON = `{"type":"tuple","components":[
+	{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"initCode","type":"bytes"},
+	{"name":"callData","type":"bytes"},{"name":"callGasLimit","type":"uint256"},{"name":"verificationGasLimit","type":"uint256"},
+	{"name":"preVerificationGas","type":"uint256"},{"name":"maxFeePerGas","type":"uint256"},{"name":"maxPriorityFeePerGas","type":"uint256"},
+	{"name":"paymasterAndData","type":"bytes"},{"name":"signature","type":"bytes"}]}`
+
+type abiUserOp struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	InitCode             []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	PaymasterAndData     []byte
+	Signature            []byte
+}
+
+func testOp(sender common.Address, nonce, priorityFee int64) *UserOperation {
+	return &UserOperation{
+		Sender:               sender,
+		Nonce:                (*hexutil.Big)(big.NewInt(nonce)),
+		InitCode:             common.FromHex("0x9406cc6185a346906296840746125a0e449764545fbfb9cf"),
+		CallData:             common.FromHex("0xb61d27f60000000000000000000000000000000000000000000000000000000000000001"),
+		CallGasLimit:         (*hexutil.Big)(big.NewInt(100_000)),
+		VerificationGasLimit: (*hexutil.Big)(big.NewInt(200_000)),
+		PreVerificationGas:   (*hexutil.Big)(big.NewInt(50_000)),
+		MaxFeePerGas:         (*hexutil.Big)(big.NewInt(2 * priorityFee)),
+		MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(priorityFee)),
+		Signature:            common.FromHex("0x01020304"),
+	}
+}
+
+func (op *UserOperation) abiTuple() abiUserOp {
+	return abiUserOp{
+		Sender: op.Sender, Nonce: op.Nonce.ToInt(), InitCode: op.InitCode, CallData: op.CallData,
+		CallGasLimit: op.CallGasLimit.ToInt(), VerificationGasLimit: op.VerificationGasLimit.ToInt(),
+		PreVerificationGas: op.PreVerificationGas.ToInt(), MaxFeePerGas: op.MaxFeePerGas.ToInt(),
+		MaxPriorityFeePerGas: op.MaxPriorityFeePerGas.ToInt(), PaymasterAndData: op.PaymasterAndData, Signature: op.Signature,
+	}
+}
+
+func TestSimulateValidationData(t *testing.T) {
+	t.Parallel()
+	entryPointAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"simulateValidation","inputs":[` +
+		strings.Replace(userOpTupleJSON, `{"type":"tuple"`, `{"name":"userOp","type":"tuple"`, 1) + `]}]`))
+	require.NoError(t, err)
+
+	op := testOp(common.HexToAddress("0x1111111111111111111111111111111111111111"), 7, 1_000_000_000)
+	op.PaymasterAndData = common.FromHex("0x2222222222222222222222222222222222222222aabbcc")
+	expected, err := entryPointAbi.Pack("simulateValidation", op.abiTuple())
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Bytes(expected), hexutil.Bytes(op.simulateValidationData()))
+	require.Equal(t, common.HexToAddress("0x2222222222222222222222222222222222222222"), op.Paymaster())
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	op := testOp(common.HexToAddress("0x1111111111111111111111111111111111111111"), 7, 1_000_000_000)
+	entryPoint, chainID := useropcfg.EntryPointV06, big.NewInt(1)
+
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	addressType, _ := abi.NewType("address", "", nil)
+	bytes32Type, _ := abi.NewType("bytes32", "", nil)
+	packed, err := abi.Arguments{
+		{Type: addressType}, {Type: uint256Type}, {Type: bytes32Type}, {Type: bytes32Type}, {Type: uint256Type},
+		{Type: uint256Type}, {Type: uint256Type}, {Type: uint256Type}, {Type: uint256Type}, {Type: bytes32Type},
+	}.Pack(op.Sender, op.Nonce.ToInt(), common.BytesToHash(crypto.Keccak256(op.InitCode)), common.BytesToHash(crypto.Keccak256(op.CallData)),
+		op.CallGasLimit.ToInt(), op.VerificationGasLimit.ToInt(), op.PreVerificationGas.ToInt(), op.MaxFeePerGas.ToInt(),
+		op.MaxPriorityFeePerGas.ToInt(), common.BytesToHash(crypto.Keccak256(op.PaymasterAndData)))
+	require.NoError(t, err)
+	enc, err := abi.Arguments{{Type: bytes32Type}, {Type: addressType}, {Type: uint256Type}}.
+		Pack(common.BytesToHash(crypto.Keccak256(packed)), entryPoint, chainID)
+	require.NoError(t, err)
+
+	require.Equal(t, common.BytesToHash(crypto.Keccak256(enc)), op.Hash(entryPoint, chainID))
+	require.NotEqual(t, op.Hash(entryPoint, chainID), op.Hash(entryPoint, big.NewInt(10)))
+}
+
+func TestDecodeValidationRevert(t *testing.T) {
+	t.Parallel()
+	returnInfoType, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "preOpGas", Type: "uint256"}, {Name: "prefund", Type: "uint256"}, {Name: "sigFailed", Type: "bool"},
+		{Name: "validAfter", Type: "uint48"}, {Name: "validUntil", Type: "uint48"}, {Name: "paymasterContext", Type: "bytes"},
+	})
+	require.NoError(t, err)
+	stakeInfoType, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{{Name: "stake", Type: "uint256"}, {Name: "unstakeDelaySec", Type: "uint256"}})
+	require.NoError(t, err)
+	type returnInfo struct {
+		PreOpGas, Prefund      *big.Int
+		SigFailed              bool
+		ValidAfter, ValidUntil *big.Int
+		PaymasterContext       []byte
+	}
+	type stakeInfo struct{ Stake, UnstakeDelaySec *big.Int }
+	args, err := abi.Arguments{{Type: returnInfoType}, {Type: stakeInfoType}, {Type: stakeInfoType}, {Type: stakeInfoType}}.Pack(
+		returnInfo{PreOpGas: big.NewInt(60_000), Prefund: big.NewInt(1e15), ValidAfter: big.NewInt(10), ValidUntil: big.NewInt(2_000_000_000), PaymasterContext: []byte{1, 2}},
+		stakeInfo{big.NewInt(1), big.NewInt(2)}, stakeInfo{big.NewInt(3), big.NewInt(4)}, stakeInfo{big.NewInt(5), big.NewInt(6)})
+	require.NoError(t, err)
+
+	res, err := decodeValidationRevert(append(append([]byte{}, validationResultSelector...), args...))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(60_000), res.PreOpGas)
+	require.Equal(t, big.NewInt(1e15), res.Prefund)
+	require.False(t, res.SigFailed)
+	require.Equal(t, uint64(10), res.ValidAfter)
+	require.Equal(t, uint64(2_000_000_000), res.ValidUntil)
+	require.Equal(t, big.NewInt(3), res.FactoryStake.Stake)
+	require.Equal(t, uint64(6), res.PaymasterStake.UnstakeDelaySec)
+
+	stringType, _ := abi.NewType("string", "", nil)
+	uint256Type, _ := abi.NewType("uint256", "", nil)
+	args, err = abi.Arguments{{Type: uint256Type}, {Type: stringType}}.Pack(big.NewInt(0), "AA23 reverted (or OOG)")
+	require.NoError(t, err)
+	_, err = decodeValidationRevert(append(append([]byte{}, failedOpSelector...), args...))
+	require.Equal(t, ErrFailedOp{Reason: "AA23 reverted (or OOG)"}, err)
+
+	_, err = decodeValidationRevert(common.FromHex("0x08c379a0"))
+	require.Error(t, err)
+}
+
+func TestOpsMessageEncoding(t *testing.T) {
+	t.Parallel()
+	op1 := testOp(common.HexToAddress("0x1111111111111111111111111111111111111111"), 1, 1_000_000_000)
+	op2 := testOp(common.HexToAddress("0x3333333333333333333333333333333333333333"), 2, 3)
+	op2.InitCode, op2.PaymasterAndData = hexutil.Bytes{}, common.FromHex("0x4444444444444444444444444444444444444444")
+	msg := &opsMessage{EntryPoint: useropcfg.EntryPointV06, BlockHash: common.HexToHash("0xab"), ChainID: big.NewInt(100), Ops: []*UserOperation{op1, op2}}
+
+	decoded, err := decodeOpsMessage(msg.encode())
+	require.NoError(t, err)
+	require.Equal(t, msg.EntryPoint, decoded.EntryPoint)
+	require.Equal(t, msg.BlockHash, decoded.BlockHash)
+	require.Equal(t, msg.ChainID, decoded.ChainID)
+	require.Len(t, decoded.Ops, 2)
+	for i, op := range msg.Ops {
+		require.Equal(t, op.Hash(msg.EntryPoint, msg.ChainID), decoded.Ops[i].Hash(msg.EntryPoint, msg.ChainID))
+	}
+
+	data := msg.encode()
+	for _, n := range []int{0, opsMessageFixedSize - 1, opsMessageFixedSize + 3, opsMessageFixedSize + 18} {
+		_, err := decodeOpsMessage(data[:n])
+		require.Error(t, err, "truncated to %d bytes", n)
+	}
+}
+
+type testValidator struct {
+	err        error
+	validUntil uint64
+}
+
+func (v testValidator) Validate(context.Context, common.Address, *UserOperation) (*ValidationResult, error) {
+	return &ValidationResult{ValidUntil: v.validUntil}, v.err
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := useropcfg.DefaultConfig
+	config.MaxOps, config.MaxOpsPerSender = 3, 2
+	pool := NewPool(config, big.NewInt(1), testValidator{}, log.New())
+	entryPoint := useropcfg.EntryPointV06
+	alice, bob := common.HexToAddress("0xa1"), common.HexToAddress("0xb0")
+
+	_, err := pool.Add(ctx, testOp(alice, 0, 100), common.HexToAddress("0x01"))
+	require.ErrorIs(t, err, ErrUnsupportedEntryPoint)
+
+	_, err = pool.Add(ctx, testOp(alice, 0, 100), entryPoint)
+	require.NoError(t, err)
+	_, err = pool.Add(ctx, testOp(alice, 0, 100), entryPoint)
+	require.ErrorIs(t, err, ErrAlreadyKnown)
+	_, err = pool.Add(ctx, testOp(alice, 0, 105), entryPoint)
+	require.ErrorIs(t, err, ErrReplacementUnderpriced)
+	h0, err := pool.Add(ctx, testOp(alice, 0, 110), entryPoint)
+	require.NoError(t, err)
+	require.Equal(t, 1, pool.Len())
+
+	_, err = pool.Add(ctx, testOp(alice, 1, 100), entryPoint)
+	require.NoError(t, err)
+	_, err = pool.Add(ctx, testOp(alice, 2, 100), entryPoint)
+	require.ErrorIs(t, err, ErrSenderLimit)
+
+	_, err = pool.Add(ctx, testOp(bob, 0, 50), entryPoint)
+	require.NoError(t, err)
+	_, err = pool.Add(ctx, testOp(bob, 1, 10), entryPoint)
+	require.ErrorIs(t, err, ErrPoolFull)
+	_, err = pool.Add(ctx, testOp(bob, 1, 200), entryPoint) // evicts the cheapest one
+	require.NoError(t, err)
+	require.Equal(t, 3, pool.Len())
+
+	pending := pool.Pending(entryPoint)
+	require.Len(t, pending, 3)
+	require.Equal(t, big.NewInt(200), pending[0].MaxPriorityFeePerGas.ToInt())
+	require.Equal(t, big.NewInt(100), pending[2].MaxPriorityFeePerGas.ToInt())
+
+	op, _, ok := pool.Get(h0)
+	require.True(t, ok)
+	require.Equal(t, alice, op.Sender)
+
+	invalid := NewPool(config, big.NewInt(1), testValidator{err: ErrFailedOp{Reason: "AA21 didn't pay prefund"}}, log.New())
+	_, err = invalid.Add(ctx, testOp(alice, 0, 100), entryPoint)
+	require.ErrorAs(t, err, &ErrFailedOp{})
+	require.Equal(t, 0, invalid.Len())
+}
+
+func TestPoolReputation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pool := NewPool(useropcfg.DefaultConfig, big.NewInt(1), testValidator{}, log.New())
+	entryPoint := useropcfg.EntryPointV06
+	factory := testOp(common.Address{}, 0, 1).Factory()
+
+	pool.reputation[factory] = &entityReputation{opsSeen: 200}
+	for i := int64(0); i < throttledEntityMempoolCount; i++ {
+		_, err := pool.Add(ctx, testOp(common.BigToAddress(big.NewInt(0xa0+i)), 0, 100), entryPoint)
+		require.NoError(t, err)
+	}
+	_, err := pool.Add(ctx, testOp(common.HexToAddress("0xb0"), 0, 100), entryPoint)
+	require.ErrorIs(t, err, ErrEntityThrottled)
+	noFactory := testOp(common.HexToAddress("0xb0"), 0, 100)
+	noFactory.InitCode = nil
+	_, err = pool.Add(ctx, noFactory, entryPoint)
+	require.NoError(t, err)
+
+	pool.reputation[factory].opsSeen = 1000
+	h, err := pool.Add(ctx, testOp(common.HexToAddress("0xa0"), 1, 100), entryPoint)
+	require.ErrorIs(t, err, ErrEntityBanned)
+	require.Equal(t, common.Hash{}, h)
+
+	r := reputation{factory: {opsSeen: 240, opsIncluded: 48}, common.HexToAddress("0x01"): {opsSeen: 20}}
+	r.decay()
+	require.Equal(t, reputation{factory: {opsSeen: 230, opsIncluded: 46}}, r)
+}
+
+func TestPoolRevalidation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	now := time.Now()
+	entryPoint := useropcfg.EntryPointV06
+	pool := NewPool(useropcfg.DefaultConfig, big.NewInt(1), testValidator{validUntil: uint64(now.Add(time.Hour).Unix())}, log.New())
+
+	h1, err := pool.Add(ctx, testOp(common.HexToAddress("0xa1"), 0, 100), entryPoint)
+	require.NoError(t, err)
+	pool.validator = testValidator{}
+	h2, err := pool.Add(ctx, testOp(common.HexToAddress("0xa2"), 0, 100), entryPoint)
+	require.NoError(t, err)
+
+	pool.evictExpired(now)
+	require.Equal(t, 2, pool.Len())
+	pool.evictExpired(now.Add(time.Hour))
+	require.Equal(t, 1, pool.Len())
+	_, _, ok := pool.Get(h1)
+	require.False(t, ok)
+
+	pool.validator = testValidator{err: ErrFailedOp{Reason: "AA25 invalid account nonce"}}
+	pool.revalidate(ctx, revalidatePerHead)
+	_, _, ok = pool.Get(h2)
+	require.False(t, ok)
+	require.Equal(t, 0, pool.Len())
+}
+
+func TestCheckStorage(t *testing.T) {
+	t.Parallel()
+	sender, paymaster := common.HexToAddress("0xa1"), common.HexToAddress("0x2222222222222222222222222222222222222222")
+	token, senderCreator := common.HexToAddress("0x70"), common.HexToAddress("0x5c")
+	op := testOp(sender, 0, 100)
+	op.PaymasterAndData = paymaster[:]
+	factory := op.Factory()
+
+	senderKey := append(common.BytesToHash(sender[:]).Bytes(), make([]byte, 32)...) // balances[sender] of a mapping at slot 0
+	var balance uint256.Int
+	balance.SetBytes(crypto.Keccak256(senderKey))
+	tracer := &opcodeTracer{keccaks: []keccakInput{{prefix: common.BytesToHash(sender[:]), hash: balance}}}
+	slot := func(v uint64) common.Hash {
+		var s uint256.Int
+		s.AddUint64(&balance, v)
+		return s.Bytes32()
+	}
+	staked := StakeInfo{Stake: big.NewInt(1), UnstakeDelaySec: minUnstakeDelay}
+
+	for _, tt := range []struct {
+		name   string
+		access storageAccess
+		res    ValidationResult
+		err    error
+	}{
+		{"sender storage", storageAccess{frame: sender, contract: sender, slot: common.HexToHash("0x05")}, ValidationResult{}, nil},
+		{"associated with sender", storageAccess{frame: paymaster, contract: token, slot: slot(1)}, ValidationResult{}, nil},
+		{"too far from the sender key", storageAccess{frame: sender, contract: token, slot: slot(maxAssociatedSlotOffset + 1)}, ValidationResult{},
+			ErrStorageViolation{Entity: sender, Contract: token, Slot: slot(maxAssociatedSlotOffset + 1)}},
+		{"unstaked paymaster storage", storageAccess{frame: paymaster, contract: paymaster, slot: common.HexToHash("0x01")}, ValidationResult{},
+			ErrStorageViolation{Entity: paymaster, Contract: paymaster, Slot: common.HexToHash("0x01")}},
+		{"staked paymaster storage", storageAccess{frame: paymaster, contract: paymaster, slot: common.HexToHash("0x01")}, ValidationResult{PaymasterStake: staked}, nil},
+		{"unstaked factory storage", storageAccess{frame: senderCreator, contract: factory, slot: common.HexToHash("0x01")}, ValidationResult{},
+			ErrStorageViolation{Entity: factory, Contract: factory, Slot: common.HexToHash("0x01")}},
+		{"staked factory storage", storageAccess{frame: senderCreator, contract: factory, slot: common.HexToHash("0x01")}, ValidationResult{FactoryStake: staked}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := *tracer
+			tracer.accesses = []storageAccess{tt.access}
+			require.Equal(t, tt.err, tracer.checkStorage(op, &tt.res))
+		})
+	}
+}
+
+func TestGossipPeerRate(t *testing.T) {
+	t.Parallel()
+	peerLimits, err := lru.New[peer.ID, *rate.Limiter](maxTrackedPeers)
+	require.NoError(t, err)
+	g := &gossip{peerLimits: peerLimits}
+	require.True(t, g.allow("a", maxValidatedOpsPerMessage))
+	require.False(t, g.allow("a", 1))
+	require.True(t, g.allow("b", 1))
+}
+
+func TestNewReceipt(t *testing.T) {
+	t.Parallel()
+	entryPoint := useropcfg.EntryPointV06
+	hash1, hash2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+	sender := common.HexToAddress("0xa1")
+	word := func(v int64) []byte { return common.LeftPadBytes(big.NewInt(v).Bytes(), 32) }
+	event := func(hash common.Hash, success int64) *types.Log {
+		data := append(append(append(word(5), word(success)...), word(1000)...), word(50_000)...)
+		return &types.Log{Address: entryPoint, Topics: []common.Hash{UserOperationEventTopic, hash, common.BytesToHash(sender[:]), {}}, Data: data}
+	}
+	revertData := append(append(append(word(5), word(64)...), word(2)...), common.RightPadBytes([]byte{0xde, 0xad}, 32)...)
+	token := &types.Log{Address: common.HexToAddress("0x70")}
+	logs := types.Logs{
+		{Address: entryPoint, Topics: []common.Hash{BeforeExecutionTopic}},
+		token,
+		event(hash1, 1),
+		{Address: entryPoint, Topics: []common.Hash{UserOperationRevertReasonTopic, hash2, common.BytesToHash(sender[:])}, Data: revertData},
+		event(hash2, 0),
+	}
+
+	r, err := NewReceipt(logs, 2)
+	require.NoError(t, err)
+	require.Equal(t, hash1, r.UserOpHash)
+	require.Equal(t, sender, r.Sender)
+	require.True(t, r.Success)
+	require.Equal(t, big.NewInt(5), r.Nonce.ToInt())
+	require.Equal(t, big.NewInt(50_000), r.ActualGasUsed.ToInt())
+	require.Equal(t, types.Logs{token}, r.Logs)
+
+	r, err = NewReceipt(logs, 4)
+	require.NoError(t, err)
+	require.False(t, r.Success)
+	require.Equal(t, hexutil.Bytes{0xde, 0xad}, r.Reason)
+	require.Empty(t, r.Logs)
+
+	_, err = NewReceipt(logs, 1)
+	require.Error(t, err)
+}
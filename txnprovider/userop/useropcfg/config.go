@@ -0,0 +1,70 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+// Package useropcfg is the configuration of the ERC-4337 user operation mempool, kept apart from the mempool
+// so that ethconfig can embed it without the mempool dependencies
+package useropcfg
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/erigontech/erigon-lib/common"
+)
+
+// EntryPointV06 is the canonical ERC-4337 v0.6 EntryPoint, deployed at the same address on all chains
+var EntryPointV06 = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+
+type Config struct {
+	P2pConfig
+	Enabled         bool
+	EntryPoints     []common.Address // supported EntryPoint contracts (v0.6 ABI)
+	MaxOps          int              // max amount of pooled user operations
+	MaxOpsPerSender int              // max amount of pooled user operations of one sender (unstaked account)
+}
+
+type P2pConfig struct {
+	MempoolId      string // id of the gossiped mempool (the canonical mempool IPFS CID), gossip is disabled if empty
+	PrivateKey     *ecdsa.PrivateKey
+	ListenPort     uint64
+	BootstrapNodes []string
+}
+
+var DefaultConfig = Config{
+	EntryPoints:     []common.Address{EntryPointV06},
+	MaxOps:          4096,
+	MaxOpsPerSender: 4,
+	P2pConfig: P2pConfig{
+		ListenPort: 23_337,
+	},
+}
+
+func (c P2pConfig) BootstrapNodesAddrInfo() ([]peer.AddrInfo, error) {
+	addrInfos := make([]peer.AddrInfo, len(c.BootstrapNodes))
+	for i, node := range c.BootstrapNodes {
+		ma, err := multiaddr.NewMultiaddr(node)
+		if err != nil {
+			return nil, err
+		}
+		ai, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return nil, err
+		}
+		addrInfos[i] = *ai
+	}
+	return addrInfos, nil
+}
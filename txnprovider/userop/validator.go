@@ -0,0 +1,316 @@
+// Copyright 2025 The Erigon Authors
+// This file is part of Erigon.
+//
+// Erigon is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Erigon is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Erigon. If not, see <http://www.gnu.org/licenses/>.
+
+package userop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/erigontech/erigon-lib/chain"
+	"github.com/erigontech/erigon-lib/common"
+	"github.com/erigontech/erigon-lib/common/hexutil"
+	"github.com/erigontech/erigon-lib/crypto"
+	"github.com/erigontech/erigon-lib/kv"
+	"github.com/erigontech/erigon/consensus"
+	"github.com/erigontech/erigon/core"
+	"github.com/erigontech/erigon/core/rawdb"
+	"github.com/erigontech/erigon/core/state"
+	"github.com/erigontech/erigon/core/vm"
+	ethapi2 "github.com/erigontech/erigon/turbo/adapter/ethapi"
+	"github.com/erigontech/erigon/turbo/rpchelper"
+	"github.com/erigontech/erigon/turbo/services"
+	"github.com/erigontech/erigon/turbo/transactions"
+)
+
+const (
+	simulationGasCap     = 30_000_000
+	simulationTimeout    = 5 * time.Second
+	minValidityRemaining = 30 * time.Second // operations expiring sooner are not worth pooling
+
+	// minUnstakeDelay is MIN_UNSTAKE_DELAY of ERC-7562, entities staked for less are treated as unstaked
+	minUnstakeDelay = 86400
+	// maxTracedKeccaks bounds the KECCAK256 inputs recorded to find the storage slots associated with an address
+	maxTracedKeccaks = 4096
+	// maxAssociatedSlotOffset is how far a slot may be from keccak(address||...) to be associated with the address
+	maxAssociatedSlotOffset = 128
+)
+
+// Validator validates a user operation against the EntryPoint
+type Validator interface {
+	Validate(ctx context.Context, entryPoint common.Address, op *UserOperation) (*ValidationResult, error)
+}
+
+// ErrOpcodeViolation is returned when the validation of a user operation uses an opcode banned by ERC-7562
+type ErrOpcodeViolation struct {
+	Entity common.Address
+	Op     vm.OpCode
+}
+
+func (e ErrOpcodeViolation) Error() string {
+	return fmt.Sprintf("validation of %x uses banned opcode %s", e.Entity, e.Op)
+}
+
+// ErrStorageViolation is returned when the validation of a user operation accesses storage ERC-7562 doesn't allow
+// the entity to access
+type ErrStorageViolation struct {
+	Entity   common.Address
+	Contract common.Address
+	Slot     common.Hash
+}
+
+func (e ErrStorageViolation) Error() string {
+	return fmt.Sprintf("validation of %x accesses slot %x of %x", e.Entity, e.Slot, e.Contract)
+}
+
+// EvmValidator simulates EntryPoint.simulateValidation on the latest state, tracing the opcodes used and the
+// storage accessed by the sender, factory and paymaster.
+type EvmValidator struct {
+	db          kv.TemporalRoDB
+	blockReader services.FullBlockReader
+	engine      consensus.EngineReader
+	chainConfig *chain.Config
+}
+
+func NewEvmValidator(db kv.TemporalRoDB, blockReader services.FullBlockReader, engine consensus.EngineReader, chainConfig *chain.Config) *EvmValidator {
+	return &EvmValidator{db: db, blockReader: blockReader, engine: engine, chainConfig: chainConfig}
+}
+
+func (v *EvmValidator) Validate(ctx context.Context, entryPoint common.Address, op *UserOperation) (*ValidationResult, error) {
+	tx, err := v.db.BeginTemporalRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	header := rawdb.ReadCurrentHeader(tx)
+	if header == nil {
+		return nil, errors.New("no current header")
+	}
+	ibs := state.New(rpchelper.NewLatestStateReader(tx))
+
+	gas := hexutil.Uint64(simulationGasCap)
+	data := hexutil.Bytes(op.simulateValidationData())
+	args := ethapi2.CallArgs{To: &entryPoint, Gas: &gas, Data: &data}
+	var baseFee *uint256.Int
+	if header.BaseFee != nil {
+		baseFee, _ = uint256.FromBig(header.BaseFee)
+	}
+	msg, err := args.ToMessage(simulationGasCap, baseFee)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := &opcodeTracer{}
+	blockCtx := transactions.NewEVMBlockContext(v.engine, header, true, tx, v.blockReader, v.chainConfig)
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), ibs, v.chainConfig, vm.Config{Tracer: tracer, Debug: true, NoBaseFee: true})
+
+	ctx, cancel := context.WithTimeout(ctx, simulationTimeout)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	gp := new(core.GasPool).AddGas(msg.Gas()).AddBlobGas(msg.BlobGas())
+	res, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */, v.engine)
+	if err != nil {
+		return nil, err
+	}
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("validation timed out after %v", simulationTimeout)
+	}
+	if tracer.violation != nil {
+		return nil, *tracer.violation
+	}
+	if !errors.Is(res.Err, vm.ErrExecutionReverted) {
+		return nil, fmt.Errorf("simulateValidation didn't revert: %v", res.Err)
+	}
+	result, err := decodeValidationRevert(res.Revert())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkValidationResult(result, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := tracer.checkStorage(op, result); err != nil {
+		return nil, err
+	}
+	result.BlockHash = header.Hash()
+	return result, nil
+}
+
+func checkValidationResult(res *ValidationResult, now time.Time) error {
+	if res.SigFailed {
+		return errors.New("invalid user operation signature")
+	}
+	if res.ValidUntil != 0 && time.Unix(int64(res.ValidUntil), 0).Before(now.Add(minValidityRemaining)) {
+		return errors.New("user operation expires too soon")
+	}
+	return nil
+}
+
+// bannedOpcodes may not be used by the entities validating a user operation, as their result differs
+// between the simulation and the inclusion, see https://eips.ethereum.org/EIPS/eip-7562
+var bannedOpcodes = map[vm.OpCode]struct{}{
+	vm.GASPRICE:     {},
+	vm.GASLIMIT:     {},
+	vm.DIFFICULTY:   {},
+	vm.TIMESTAMP:    {},
+	vm.BASEFEE:      {},
+	vm.BLOCKHASH:    {},
+	vm.NUMBER:       {},
+	vm.SELFBALANCE:  {},
+	vm.BALANCE:      {},
+	vm.ORIGIN:       {},
+	vm.CREATE:       {},
+	vm.COINBASE:     {},
+	vm.SELFDESTRUCT: {},
+	vm.BLOBHASH:     {},
+	vm.BLOBBASEFEE:  {},
+}
+
+// storageAccess is a storage slot accessed during the validation by a frame called by the EntryPoint
+type storageAccess struct {
+	frame    common.Address // contract the EntryPoint called
+	contract common.Address // owner of the storage
+	slot     common.Hash
+}
+
+// keccakInput is a KECCAK256 computed during the validation, by the first word of its input
+type keccakInput struct {
+	prefix common.Hash
+	hash   uint256.Int
+}
+
+// opcodeTracer records the first banned opcode used below the EntryPoint (depth 1), the storage accessed there and
+// the KECCAK256 computed to derive the slots. GAS is allowed only right before a call.
+type opcodeTracer struct {
+	violation *ErrOpcodeViolation
+
+	gasAt     common.Address
+	gasDepth  int
+	gasPlaced bool
+
+	depth    int
+	frame    common.Address
+	accesses []storageAccess
+	keccaks  []keccakInput
+}
+
+func (t *opcodeTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.violation != nil || depth <= 1 {
+		return
+	}
+	switch op {
+	case vm.SLOAD, vm.SSTORE, vm.TLOAD, vm.TSTORE:
+		t.accesses = append(t.accesses, storageAccess{frame: t.frame, contract: scope.Contract.Address(), slot: common.Hash(scope.Stack.Back(0).Bytes32())})
+	case vm.KECCAK256:
+		offset, size := scope.Stack.Back(0), scope.Stack.Back(1)
+		if len(t.keccaks) < maxTracedKeccaks && size.IsUint64() && size.Uint64() >= 32 && offset.IsUint64() &&
+			offset.Uint64()+size.Uint64() <= uint64(scope.Memory.Len()) {
+			input := scope.Memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
+			k := keccakInput{prefix: common.BytesToHash(input[:32])}
+			k.hash.SetBytes(crypto.Keccak256(input))
+			t.keccaks = append(t.keccaks, k)
+		}
+	}
+	if t.gasPlaced && t.gasDepth == depth {
+		t.gasPlaced = false
+		switch op {
+		case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		default:
+			t.violation = &ErrOpcodeViolation{Entity: t.gasAt, Op: vm.GAS}
+			return
+		}
+	}
+	if op == vm.GAS {
+		t.gasAt, t.gasDepth, t.gasPlaced = scope.Contract.Address(), depth, true
+		return
+	}
+	if _, ok := bannedOpcodes[op]; ok {
+		t.violation = &ErrOpcodeViolation{Entity: scope.Contract.Address(), Op: op}
+	}
+}
+
+func (t *opcodeTracer) CaptureTxStart(gasLimit uint64) {}
+func (t *opcodeTracer) CaptureTxEnd(restGas uint64)    {}
+func (t *opcodeTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.depth = 1
+}
+func (t *opcodeTracer) CaptureEnd(output []byte, usedGas uint64, err error) {}
+func (t *opcodeTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, precompile bool, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	if t.depth++; t.depth == 2 {
+		t.frame = to
+	}
+}
+func (t *opcodeTracer) CaptureExit(output []byte, usedGas uint64, err error) { t.depth-- }
+func (t *opcodeTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// checkStorage enforces the storage rules of ERC-7562: an entity may access the storage of the sender and the
+// slots associated with the sender in any contract, a staked entity also its own storage and the slots associated
+// with itself. The factory is called through the SenderCreator of the EntryPoint, so frames other than the sender
+// and the paymaster are the factory's.
+func (t *opcodeTracer) checkStorage(op *UserOperation, res *ValidationResult) error {
+	factory, paymaster := op.Factory(), op.Paymaster()
+	for _, a := range t.accesses {
+		if a.contract == op.Sender || t.associated(a.slot, op.Sender) {
+			continue
+		}
+		entity, stake := a.frame, StakeInfo{}
+		switch {
+		case a.frame == op.Sender:
+			stake = res.SenderStake
+		case a.frame == paymaster && paymaster != (common.Address{}):
+			stake = res.PaymasterStake
+		case factory != (common.Address{}):
+			entity, stake = factory, res.FactoryStake
+		}
+		if staked(stake) && (a.contract == entity || t.associated(a.slot, entity)) {
+			continue
+		}
+		return ErrStorageViolation{Entity: entity, Contract: a.contract, Slot: a.slot}
+	}
+	return nil
+}
+
+// associated returns true if the slot is the address or at most maxAssociatedSlotOffset after keccak(address||...)
+func (t *opcodeTracer) associated(slot common.Hash, addr common.Address) bool {
+	prefix := common.BytesToHash(addr[:])
+	if slot == prefix {
+		return true
+	}
+	var s, offset uint256.Int
+	s.SetBytes(slot[:])
+	for i := range t.keccaks {
+		if t.keccaks[i].prefix != prefix || s.Lt(&t.keccaks[i].hash) {
+			continue
+		}
+		if offset.Sub(&s, &t.keccaks[i].hash).LtUint64(maxAssociatedSlotOffset + 1) {
+			return true
+		}
+	}
+	return false
+}
+
+func staked(stake StakeInfo) bool {
+	return stake.Stake != nil && stake.Stake.Sign() > 0 && stake.UnstakeDelaySec >= minUnstakeDelay
+}